@@ -36,6 +36,15 @@ func (r *ServiceRequester) GetConfig() (*types.ApiResponse[api.ServiceGetConfigD
 	return client.SendGetRequest[api.ServiceGetConfigData](r, "get-config", "GetConfig", nil)
 }
 
+// Recreates a service's Docker container with its current extra environment variables and mounts, starting it again
+// if it was running
+func (r *ServiceRequester) RecreateContainer(container string) (*types.ApiResponse[types.SuccessData], error) {
+	args := map[string]string{
+		"container": container,
+	}
+	return client.SendGetRequest[types.SuccessData](r, "recreate-container", "RecreateContainer", args)
+}
+
 // Restarts a Docker container
 func (r *ServiceRequester) RestartContainer(container string) (*types.ApiResponse[types.SuccessData], error) {
 	args := map[string]string{
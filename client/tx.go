@@ -52,6 +52,18 @@ func (r *TxRequester) SubmitTx(txSubmission *eth.TransactionSubmission, nonce *b
 	return client.SendPostRequest[api.TxData](r, "submit-tx", "SubmitTx", body)
 }
 
+// Simulate a transaction against the pending block, then submit it if it won't revert
+func (r *TxRequester) SimulateAndSubmitTx(txSubmission *eth.TransactionSubmission, nonce *big.Int, maxFee *big.Int, maxPriorityFee *big.Int) (*types.ApiResponse[api.TxData], error) {
+	body := api.SubmitTxBody{
+		Submission:     txSubmission,
+		Nonce:          nonce,
+		MaxFee:         maxFee,
+		MaxPriorityFee: maxPriorityFee,
+		Simulate:       true,
+	}
+	return client.SendPostRequest[api.TxData](r, "submit-tx", "SubmitTx", body)
+}
+
 // Use the node private key to sign a batch of transactions without submitting them
 func (r *TxRequester) SignTxBatch(txSubmissions []*eth.TransactionSubmission, firstNonce *big.Int, maxFee *big.Int, maxPriorityFee *big.Int) (*types.ApiResponse[api.TxBatchSignTxData], error) {
 	body := api.BatchSubmitTxsBody{
@@ -138,6 +138,11 @@ func (r *WalletRequester) Status() (*types.ApiResponse[api.WalletStatusData], er
 	return client.SendGetRequest[api.WalletStatusData](r, "status", "Status", nil)
 }
 
+// Check that every validator keystore on disk decrypts with the stored node password
+func (r *WalletRequester) VerifyKeystores() (*types.ApiResponse[api.WalletVerifyKeystoresData], error) {
+	return client.SendGetRequest[api.WalletVerifyKeystoresData](r, "verify-keystores", "VerifyKeystores", nil)
+}
+
 // Recover wallet in test-mode so none of the artifacts are saved
 func (r *WalletRequester) TestRecover(derivationPath *string, mnemonic string, index *uint64) (*types.ApiResponse[api.WalletRecoverData], error) {
 	args := map[string]string{
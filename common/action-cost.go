@@ -0,0 +1,137 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// The simulated cost of a single pending action
+type ActionCost struct {
+	// The action that was simulated
+	Action PendingAction `json:"action"`
+
+	// The gas the action's transaction is estimated to use; 0 if it's expected to revert
+	Gas uint64 `json:"gas"`
+
+	// The cost of the gas at the current base fee and suggested priority fee, in wei
+	Cost *big.Int `json:"cost"`
+
+	// The decoded revert reason, if the action is expected to revert and the reason could be decoded
+	RevertReason string `json:"revertReason,omitempty"`
+}
+
+// The estimated cost of a batch of pending actions, with the ones expected to revert set apart so they can be left out
+type CostEstimate struct {
+	// The actions expected to succeed, in the order they were provided
+	Succeeding []ActionCost `json:"succeeding"`
+
+	// The actions expected to revert, in the order they were provided
+	Reverting []ActionCost `json:"reverting"`
+
+	// The base fee of the latest block, in wei; 0 on networks without EIP-1559
+	BaseFee *big.Int `json:"baseFee"`
+
+	// The priority fee the Execution Client suggests, or its suggested gas price on networks without EIP-1559, in wei
+	PriorityFee *big.Int `json:"priorityFee"`
+
+	// The total gas of the actions expected to succeed
+	TotalGas uint64 `json:"totalGas"`
+
+	// The total cost of the actions expected to succeed at the current fees, in wei
+	TotalCost *big.Int `json:"totalCost"`
+
+	// The most the actions expected to succeed could cost if the base fee doubles before they're included, in wei
+	MaxTotalCost *big.Int `json:"maxTotalCost"`
+}
+
+// Estimates the cost of a batch of pending actions before any of them are executed. Each action's transaction is
+// simulated with eth_call to catch reverts, and the gas of the ones that would succeed is estimated with eth_estimateGas
+// and priced at the current base fee plus the suggested priority fee. Actions are simulated against the current state
+// independently of each other, so an action that only works after another one in the batch may be reported as
+// reverting. Returns an error if an action doesn't have a transaction, or if the simulations can't be run.
+func (sp *ServiceProvider) EstimateActionsCost(ctx context.Context, actions []PendingAction) (CostEstimate, error) {
+	for _, action := range actions {
+		if action.Transaction == nil {
+			return CostEstimate{}, fmt.Errorf("pending action [%s] doesn't have a transaction to simulate", action.ID)
+		}
+	}
+	rpcClient, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+
+	// Get the current fees; networks without EIP-1559 only have a gas price
+	estimate := CostEstimate{
+		Succeeding:   []ActionCost{},
+		Reverting:    []ActionCost{},
+		BaseFee:      big.NewInt(0),
+		TotalCost:    big.NewInt(0),
+		MaxTotalCost: big.NewInt(0),
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return CostEstimate{}, fmt.Errorf("error getting the latest block: %w", err)
+	}
+	if head.BaseFee != nil {
+		estimate.BaseFee = head.BaseFee
+		estimate.PriorityFee, err = client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return CostEstimate{}, fmt.Errorf("error getting the suggested priority fee: %w", err)
+		}
+	} else {
+		estimate.PriorityFee, err = client.SuggestGasPrice(ctx)
+		if err != nil {
+			return CostEstimate{}, fmt.Errorf("error getting the suggested gas price: %w", err)
+		}
+	}
+	gasPrice := new(big.Int).Add(estimate.BaseFee, estimate.PriorityFee)
+	maxGasPrice := new(big.Int).Add(gasPrice, estimate.BaseFee)
+
+	for _, action := range actions {
+		if ctx.Err() != nil {
+			return CostEstimate{}, ctx.Err()
+		}
+		result, err := sp.SimulateTransaction(ctx, action.Transaction, action.From)
+		if err != nil {
+			return CostEstimate{}, fmt.Errorf("error simulating pending action [%s]: %w", action.ID, err)
+		}
+		if result.Reverted {
+			estimate.Reverting = append(estimate.Reverting, ActionCost{
+				Action:       action,
+				Cost:         big.NewInt(0),
+				RevertReason: result.RevertReason,
+			})
+			continue
+		}
+
+		// Gas estimation runs the transaction again, so it can still catch a revert the call missed if the state moved
+		gas, err := client.EstimateGas(ctx, newSimulationCallMsg(action.Transaction, action.From))
+		if err != nil {
+			revertData, isRevert := getRevertData(err)
+			if !isRevert {
+				return CostEstimate{}, fmt.Errorf("error estimating gas for pending action [%s]: %w", action.ID, err)
+			}
+			estimate.Reverting = append(estimate.Reverting, ActionCost{
+				Action:       action,
+				Cost:         big.NewInt(0),
+				RevertReason: decodeRevertReason(revertData, nil),
+			})
+			continue
+		}
+		gasBig := new(big.Int).SetUint64(gas)
+		estimate.Succeeding = append(estimate.Succeeding, ActionCost{
+			Action: action,
+			Gas:    gas,
+			Cost:   new(big.Int).Mul(gasBig, gasPrice),
+		})
+		estimate.TotalGas += gas
+		estimate.TotalCost.Add(estimate.TotalCost, new(big.Int).Mul(gasBig, gasPrice))
+		estimate.MaxTotalCost.Add(estimate.MaxTotalCost, new(big.Int).Mul(gasBig, maxGasPrice))
+	}
+	return estimate, nil
+}
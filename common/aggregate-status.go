@@ -0,0 +1,132 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// A module that can report its status. Modules register one of these with the service provider so they're included in
+// the node's aggregate status.
+type StatusReporter interface {
+	// The name of the module
+	GetModuleName() string
+
+	// True if the module is enabled; disabled modules are left out of the aggregate status
+	IsEnabled() bool
+
+	// Get the module's validator counts, the actions it's waiting on the user for, and anything that needs attention
+	GetStatus(ctx context.Context) (ModuleStatus, error)
+}
+
+// The status of a single module, as reported by the module
+type ModuleStatus struct {
+	// The number of the module's validators (or minipools) in each status
+	ValidatorCounts map[ValidatorStatus]int `json:"validatorCounts"`
+
+	// Human-readable descriptions of the actions the module is waiting on the user for
+	PendingActions []string `json:"pendingActions"`
+
+	// Human-readable warnings about anything in the module that needs attention
+	Warnings []string `json:"warnings"`
+}
+
+// A module's part of the aggregate status
+type ModuleStatusResult struct {
+	// The status the module reported; empty if it failed
+	Status ModuleStatus `json:"status"`
+
+	// True if the module couldn't report its status, so it's missing from the totals
+	Failed bool `json:"failed"`
+
+	// The error the module returned, if it failed
+	Error string `json:"error,omitempty"`
+}
+
+// The status of every enabled module, merged together
+type AggregateStatus struct {
+	// The result from each enabled module, keyed by module name
+	Modules map[string]ModuleStatusResult `json:"modules"`
+
+	// The number of validators in each status across the modules that reported one
+	ValidatorCounts map[ValidatorStatus]int `json:"validatorCounts"`
+
+	// The total number of validators across the modules that reported one
+	TotalValidators int `json:"totalValidators"`
+
+	// The pending actions of every module, each prefixed with the module's name
+	PendingActions []string `json:"pendingActions"`
+
+	// The warnings of every module, each prefixed with the module's name, along with one for every module that failed
+	Warnings []string `json:"warnings"`
+
+	// The names of the modules that couldn't report their status, sorted
+	FailedModules []string `json:"failedModules"`
+}
+
+// Registers a module's status reporter so it's included in the node's aggregate status
+func (sp *ServiceProvider) RegisterStatusReporter(reporter StatusReporter) {
+	sp.statusReporterLock.Lock()
+	defer sp.statusReporterLock.Unlock()
+	sp.statusReporters = append(sp.statusReporters, reporter)
+}
+
+// Gets the status of every enabled module and merges them into one, for a node-wide summary. A module that fails to
+// report its status is flagged in the result and left out of the totals instead of failing the whole call, so this only
+// returns an error if the context is cancelled.
+func (sp *ServiceProvider) GetAggregateStatus(ctx context.Context) (AggregateStatus, error) {
+	sp.statusReporterLock.Lock()
+	reporters := make([]StatusReporter, len(sp.statusReporters))
+	copy(reporters, sp.statusReporters)
+	sp.statusReporterLock.Unlock()
+
+	status := AggregateStatus{
+		Modules: map[string]ModuleStatusResult{},
+		ValidatorCounts: map[ValidatorStatus]int{
+			ValidatorStatus_Pending:      0,
+			ValidatorStatus_Active:       0,
+			ValidatorStatus_Exiting:      0,
+			ValidatorStatus_Slashed:      0,
+			ValidatorStatus_Withdrawable: 0,
+			ValidatorStatus_Withdrawn:    0,
+		},
+		PendingActions: []string{},
+		Warnings:       []string{},
+		FailedModules:  []string{},
+	}
+	for _, reporter := range reporters {
+		if !reporter.IsEnabled() {
+			continue
+		}
+		name := reporter.GetModuleName()
+		moduleStatus, err := reporter.GetStatus(ctx)
+		if ctx.Err() != nil {
+			return AggregateStatus{}, ctx.Err()
+		}
+		if err != nil {
+			status.Modules[name] = ModuleStatusResult{
+				Failed: true,
+				Error:  err.Error(),
+			}
+			status.FailedModules = append(status.FailedModules, name)
+			status.Warnings = append(status.Warnings, fmt.Sprintf("%s: couldn't get the module's status: %s", name, err.Error()))
+			continue
+		}
+
+		status.Modules[name] = ModuleStatusResult{
+			Status: moduleStatus,
+		}
+		for validatorStatus, count := range moduleStatus.ValidatorCounts {
+			status.ValidatorCounts[validatorStatus] += count
+			status.TotalValidators += count
+		}
+		for _, action := range moduleStatus.PendingActions {
+			status.PendingActions = append(status.PendingActions, fmt.Sprintf("%s: %s", name, action))
+		}
+		for _, warning := range moduleStatus.Warnings {
+			status.Warnings = append(status.Warnings, fmt.Sprintf("%s: %s", name, warning))
+		}
+	}
+	sort.Strings(status.FailedModules)
+	return status, nil
+}
@@ -0,0 +1,124 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+// The severities in order from least to most severe
+var alertSeverities []hdconfig.AlertSeverity = []hdconfig.AlertSeverity{
+	hdconfig.AlertSeverity_Info,
+	hdconfig.AlertSeverity_Warning,
+	hdconfig.AlertSeverity_Critical,
+}
+
+// Something the operator should be told about
+type Alert struct {
+	// How urgent the alert is
+	Severity hdconfig.AlertSeverity `json:"severity"`
+
+	// A short summary of the alert
+	Title string `json:"title"`
+
+	// The details of the alert
+	Message string `json:"message"`
+
+	// When the alert was raised
+	Time time.Time `json:"time"`
+}
+
+// Somewhere alerts can be delivered, like an email address
+type AlertSink interface {
+	// The name of the sink, for errors
+	GetName() string
+
+	// Delivers an alert
+	SendAlert(ctx context.Context, alert Alert) error
+}
+
+// Delivers alerts to the sinks selected for their severity
+type AlertManager struct {
+	sinks map[hdconfig.AlertSeverity][]AlertSink
+	lock  *sync.Mutex
+}
+
+// Creates a new alert manager with the sinks enabled in the config. The email sink receives alerts from the configured
+// email severity upwards.
+func NewAlertManager(cfg *hdconfig.AlertsConfig) *AlertManager {
+	manager := &AlertManager{
+		sinks: map[hdconfig.AlertSeverity][]AlertSink{},
+		lock:  &sync.Mutex{},
+	}
+	manager.setEmailSink(cfg)
+	return manager
+}
+
+// Gets the provided severity and every one more severe than it
+func GetAlertSeveritiesFrom(minimum hdconfig.AlertSeverity) []hdconfig.AlertSeverity {
+	for i, severity := range alertSeverities {
+		if severity == minimum {
+			return append([]hdconfig.AlertSeverity{}, alertSeverities[i:]...)
+		}
+	}
+	return []hdconfig.AlertSeverity{}
+}
+
+// Adds a sink that receives alerts of the provided severities
+func (m *AlertManager) AddSink(sink AlertSink, severities ...hdconfig.AlertSeverity) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, severity := range severities {
+		m.sinks[severity] = append(m.sinks[severity], sink)
+	}
+}
+
+// Replaces the email sink with one for the provided config, so changes to whether email is enabled and which severities
+// it gets take effect; sinks added by modules are kept as they are
+func (m *AlertManager) setEmailSink(cfg *hdconfig.AlertsConfig) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for severity, sinks := range m.sinks {
+		m.sinks[severity] = slices.DeleteFunc(sinks, func(sink AlertSink) bool {
+			_, isEmail := sink.(*EmailAlertSink)
+			return isEmail
+		})
+	}
+	if !cfg.IsEmailEnabled() {
+		return
+	}
+	sink := NewEmailAlertSink(cfg)
+	for _, severity := range GetAlertSeveritiesFrom(cfg.EmailSeverity.Value) {
+		m.sinks[severity] = append(m.sinks[severity], sink)
+	}
+}
+
+// Gets the sinks that receive alerts of the provided severity
+func (m *AlertManager) GetSinks(severity hdconfig.AlertSeverity) []AlertSink {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	sinks := make([]AlertSink, len(m.sinks[severity]))
+	copy(sinks, m.sinks[severity])
+	return sinks
+}
+
+// Delivers an alert to every sink selected for its severity. A sink that fails doesn't stop the others from getting
+// it; the errors of all the sinks that failed are returned together.
+func (m *AlertManager) SendAlert(ctx context.Context, alert Alert) error {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	errs := []error{}
+	for _, sink := range m.GetSinks(alert.Severity) {
+		err := sink.SendAlert(ctx, alert)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error sending alert to [%s]: %w", sink.GetName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
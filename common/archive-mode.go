@@ -0,0 +1,87 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// How many blocks behind the head to probe for state. Full nodes only keep the state of recent blocks (128 for Geth,
+	// around 10,000 for Reth and Erigon), so a block this old only has state on an archive node.
+	archiveProbeDepth uint64 = 100000
+)
+
+var (
+	// The feature needs historical state that only an archive Execution Client has
+	ErrArchiveNodeRequired error = errors.New("this requires an archive Execution Client, but the configured one is a full node that doesn't keep historical state")
+
+	// The errors the Execution Clients return for state they've pruned, in lower case
+	missingStateErrors []string = []string{
+		"missing trie node",
+		"state not available",
+		"state is not available",
+		"historical state",
+		"is pruned",
+	}
+)
+
+// Checks whether the Execution Client is an archive node by asking for an account balance at an old block, which only
+// archive nodes have the state for. A full node's "missing trie node" or "state not available" error means it isn't one;
+// any other error is returned as is. The answer is cached once it's known, since a client doesn't switch modes without
+// being resynced.
+func (sp *ServiceProvider) DetectExecutionArchiveMode(ctx context.Context) (bool, error) {
+	sp.archiveModeLock.Lock()
+	defer sp.archiveModeLock.Unlock()
+	if sp.executionArchiveMode != nil {
+		return *sp.executionArchiveMode, nil
+	}
+
+	ec := sp.GetEthClient()
+	latestBlock, err := ec.BlockNumber(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error getting latest block number: %w", err)
+	}
+	probeBlock := uint64(1)
+	if latestBlock > archiveProbeDepth+1 {
+		probeBlock = latestBlock - archiveProbeDepth
+	}
+	_, err = ec.BalanceAt(ctx, ethcommon.Address{}, new(big.Int).SetUint64(probeBlock))
+	isArchive := true
+	if err != nil {
+		if !isMissingStateError(err) {
+			return false, fmt.Errorf("error getting state at block %d: %w", probeBlock, err)
+		}
+		isArchive = false
+	}
+	sp.executionArchiveMode = &isArchive
+	return isArchive, nil
+}
+
+// Returns an error wrapping ErrArchiveNodeRequired if the Execution Client isn't an archive node, for features that can't
+// work without historical state. The feature's name is included in the error.
+func (sp *ServiceProvider) RequireExecutionArchiveMode(ctx context.Context, feature string) error {
+	isArchive, err := sp.DetectExecutionArchiveMode(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking if the Execution Client is an archive node: %w", err)
+	}
+	if !isArchive {
+		return fmt.Errorf("%s: %w", feature, ErrArchiveNodeRequired)
+	}
+	return nil
+}
+
+// Checks if an error is from an Execution Client that doesn't have the state that was asked for
+func isMissingStateError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, missingStateError := range missingStateErrors {
+		if strings.Contains(message, missingStateError) {
+			return true
+		}
+	}
+	return false
+}
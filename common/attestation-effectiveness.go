@@ -0,0 +1,261 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// Describes how a validator's attestations fared over a window of epochs
+type EffectivenessStatus string
+
+const (
+	// The validator had at least one attestation included in the window
+	EffectivenessStatus_Attesting EffectivenessStatus = "attesting"
+
+	// The validator had attestation duties in the window, but none of its attestations were included
+	EffectivenessStatus_NoAttestations EffectivenessStatus = "noAttestations"
+
+	// The validator didn't have any attestation duties in the window (e.g. it isn't active or doesn't exist)
+	EffectivenessStatus_NoDuties EffectivenessStatus = "noDuties"
+)
+
+// Attestation performance for a single validator over a window of epochs
+type EffectivenessStats struct {
+	// The overall status of the validator's attestations in the window
+	Status EffectivenessStatus `json:"status"`
+
+	// The validator's index, if it exists on the Beacon Chain
+	Index string `json:"index"`
+
+	// The first epoch in the window
+	StartEpoch uint64 `json:"startEpoch"`
+
+	// The last epoch in the window
+	EndEpoch uint64 `json:"endEpoch"`
+
+	// The number of attestation duties the validator had
+	ExpectedAttestations uint64 `json:"expectedAttestations"`
+
+	// The number of the validator's attestations that were included in a block
+	IncludedAttestations uint64 `json:"includedAttestations"`
+
+	// The number of the validator's attestations that were never included
+	MissedAttestations uint64 `json:"missedAttestations"`
+
+	// The fraction of duties that resulted in an included attestation
+	Participation float64 `json:"participation"`
+
+	// The average number of slots between an attestation's slot and the slot of the block that included it
+	AverageInclusionDistance float64 `json:"averageInclusionDistance"`
+
+	// The smallest inclusion distance in the window
+	MinInclusionDistance uint64 `json:"minInclusionDistance"`
+
+	// The largest inclusion distance in the window
+	MaxInclusionDistance uint64 `json:"maxInclusionDistance"`
+}
+
+// A validator's position within a committee
+type attestationDuty struct {
+	slot           uint64
+	committeeIndex uint64
+	position       int
+}
+
+// A block's attestations, cached while searching for inclusions
+type cachedAttestations struct {
+	attestations []BeaconAttestation
+	version      string
+}
+
+// Gets the attestation performance of the provided validators over the last N completed epochs, based on the attestations included in the chain
+func (sp *ServiceProvider) GetAttestationEffectiveness(ctx context.Context, pubkeys []beacon.ValidatorPubkey, epochs uint64) (map[beacon.ValidatorPubkey]EffectivenessStats, error) {
+	bn := sp.GetBeaconApiClient()
+	if epochs == 0 {
+		return nil, fmt.Errorf("at least one epoch is required")
+	}
+
+	// Get the window
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slotsPerEpoch := spec.SlotsPerEpoch
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return nil, err
+	}
+	currentEpoch := headSlot / slotsPerEpoch
+	if currentEpoch == 0 {
+		return nil, fmt.Errorf("the chain has not completed an epoch yet")
+	}
+	endEpoch := currentEpoch - 1
+	startEpoch := uint64(0)
+	if endEpoch+1 > epochs {
+		startEpoch = endEpoch + 1 - epochs
+	}
+
+	// Look up the validator indices
+	stats := map[beacon.ValidatorPubkey]EffectivenessStats{}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+		stats[pubkey] = EffectivenessStats{
+			Status:     EffectivenessStatus_NoDuties,
+			StartEpoch: startEpoch,
+			EndEpoch:   endEpoch,
+		}
+	}
+	validators, err := bn.GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return nil, err
+	}
+	pubkeysByIndex := map[string]beacon.ValidatorPubkey{}
+	for _, validator := range validators {
+		pubkey := beacon.ValidatorPubkey(validator.Validator.Pubkey)
+		if _, exists := stats[pubkey]; !exists {
+			continue
+		}
+		pubkeysByIndex[validator.Index] = pubkey
+		validatorStats := stats[pubkey]
+		validatorStats.Index = validator.Index
+		stats[pubkey] = validatorStats
+	}
+
+	// Get the duties for each epoch
+	// Committees come from the epoch's own state, since the head state may not go back far enough
+	duties := map[beacon.ValidatorPubkey][]attestationDuty{}
+	committeeSizes := map[uint64][]int{}
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		committees, err := bn.GetCommittees(ctx, strconv.FormatUint(epoch*slotsPerEpoch, 10), epoch)
+		if err != nil {
+			return nil, err
+		}
+		for _, committee := range committees {
+			slot := uint64(committee.Slot)
+			index := int(committee.Index)
+			for len(committeeSizes[slot]) <= index {
+				committeeSizes[slot] = append(committeeSizes[slot], 0)
+			}
+			committeeSizes[slot][index] = len(committee.Validators)
+			for position, index := range committee.Validators {
+				pubkey, exists := pubkeysByIndex[index]
+				if !exists {
+					continue
+				}
+				duties[pubkey] = append(duties[pubkey], attestationDuty{
+					slot:           uint64(committee.Slot),
+					committeeIndex: uint64(committee.Index),
+					position:       position,
+				})
+			}
+		}
+	}
+
+	// Find the inclusion slot of each duty
+	blockCache := map[uint64]cachedAttestations{}
+	for pubkey, validatorDuties := range duties {
+		validatorStats := stats[pubkey]
+		totalDistance := uint64(0)
+		for _, duty := range validatorDuties {
+			validatorStats.ExpectedAttestations++
+			distance, included, err := findInclusionDistance(ctx, bn, blockCache, committeeSizes[duty.slot], duty, slotsPerEpoch, headSlot)
+			if err != nil {
+				return nil, err
+			}
+			if !included {
+				validatorStats.MissedAttestations++
+				continue
+			}
+			validatorStats.IncludedAttestations++
+			totalDistance += distance
+			if validatorStats.MinInclusionDistance == 0 || distance < validatorStats.MinInclusionDistance {
+				validatorStats.MinInclusionDistance = distance
+			}
+			validatorStats.MaxInclusionDistance = max(validatorStats.MaxInclusionDistance, distance)
+		}
+
+		validatorStats.Participation = float64(validatorStats.IncludedAttestations) / float64(validatorStats.ExpectedAttestations)
+		if validatorStats.IncludedAttestations > 0 {
+			validatorStats.Status = EffectivenessStatus_Attesting
+			validatorStats.AverageInclusionDistance = float64(totalDistance) / float64(validatorStats.IncludedAttestations)
+		} else {
+			validatorStats.Status = EffectivenessStatus_NoAttestations
+		}
+		stats[pubkey] = validatorStats
+	}
+	return stats, nil
+}
+
+// Finds the first block that included an attestation for the duty and returns the number of slots between the two
+func findInclusionDistance(ctx context.Context, bn *BeaconApiClient, blockCache map[uint64]cachedAttestations, committeeSizes []int, duty attestationDuty, slotsPerEpoch uint64, headSlot uint64) (uint64, bool, error) {
+	lastSlot := min(duty.slot+slotsPerEpoch, headSlot)
+	for slot := duty.slot + 1; slot <= lastSlot; slot++ {
+		block, cached := blockCache[slot]
+		if !cached {
+			attestations, version, _, err := bn.GetBlockAttestations(ctx, strconv.FormatUint(slot, 10))
+			if err != nil {
+				return 0, false, err
+			}
+			block = cachedAttestations{
+				attestations: attestations,
+				version:      version,
+			}
+			blockCache[slot] = block
+		}
+
+		for _, attestation := range block.attestations {
+			if uint64(attestation.Data.Slot) != duty.slot {
+				continue
+			}
+			position, covered := getAggregationBitPosition(attestation, block.version, committeeSizes, duty)
+			if covered && isBitSet(attestation.AggregationBits, position) {
+				return slot - duty.slot, true, nil
+			}
+		}
+	}
+	return 0, false, nil
+}
+
+// Gets the position of the duty's bit within an attestation's aggregation bits, or false if the attestation isn't for the duty's committee.
+// Before Electra, each attestation covers the single committee in Data.Index. From Electra onwards (EIP-7549), the
+// committee bits select the committees, and each one's bits follow the previous one's in the aggregation bits.
+func getAggregationBitPosition(attestation BeaconAttestation, version string, committeeSizes []int, duty attestationDuty) (int, bool) {
+	if !isElectraOrLater(version) {
+		return duty.position, uint64(attestation.Data.Index) == duty.committeeIndex
+	}
+	offset := 0
+	for index, size := range committeeSizes {
+		if !isBitSet(attestation.CommitteeBits, index) {
+			continue
+		}
+		if uint64(index) == duty.committeeIndex {
+			return offset + duty.position, true
+		}
+		offset += size
+	}
+	return 0, false
+}
+
+// True if the provided block version is Electra or a later fork
+func isElectraOrLater(version string) bool {
+	switch strings.ToLower(version) {
+	case "", "phase0", "altair", "bellatrix", "capella", "deneb":
+		return false
+	default:
+		return true
+	}
+}
+
+// Checks if a bit in an SSZ bitlist is set
+func isBitSet(bits []byte, position int) bool {
+	byteIndex := position / 8
+	if byteIndex >= len(bits) {
+		return false
+	}
+	return bits[byteIndex]&(1<<(position%8)) != 0
+}
@@ -0,0 +1,212 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// The Beacon Node's attestation pool doesn't have any attestations for the slot, from any validator. This isn't a
+	// failure of the query: the pool may have been pruned already, or the node may not be seeing attestation gossip.
+	ErrAttestationPoolEmpty error = errors.New("the attestation pool is empty")
+)
+
+// One of the node's validators' attestations waiting in the Beacon Node's attestation pool
+type PendingAttestation struct {
+	// The validator that made the attestation
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// The validator's index
+	ValidatorIndex string `json:"validatorIndex"`
+
+	// The slot the attestation is for
+	Slot uint64 `json:"slot"`
+
+	// The index of the validator's committee within the slot
+	CommitteeIndex uint64 `json:"committeeIndex"`
+
+	// The block the attestation voted for as the head of the chain
+	BeaconBlockRoot string `json:"beaconBlockRoot"`
+
+	// The number of attestations aggregated into the largest one in the pool that covers the validator; 1 if it hasn't
+	// been aggregated with anyone else's yet
+	AggregationCount int `json:"aggregationCount"`
+}
+
+// An attestation duty of one of the node's validators
+type validatorAttestationDuty struct {
+	pubkey beacon.ValidatorPubkey
+	index  string
+	duty   attestationDuty
+}
+
+// Gets the attestations of the node's validators for the provided slot that are in the Beacon Node's attestation pool,
+// in committee order. A validator with a duty in the slot that isn't in the results either didn't produce its
+// attestation or, depending on the client, had it pruned from the pool once it was included in a block. Returns
+// ErrAttestationPoolEmpty if the pool has no attestations for the slot at all.
+func (sp *ServiceProvider) GetPendingAttestations(ctx context.Context, slot uint64) ([]PendingAttestation, error) {
+	attestations, _, err := sp.getPendingAttestations(ctx, slot)
+	return attestations, err
+}
+
+// Gets the attestations of the node's validators for the provided slot that are in the Beacon Node's attestation pool,
+// along with the duties the validators had in the slot
+func (sp *ServiceProvider) getPendingAttestations(ctx context.Context, slot uint64) ([]PendingAttestation, []validatorAttestationDuty, error) {
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pubkeys) == 0 {
+		return []PendingAttestation{}, []validatorAttestationDuty{}, nil
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+	validators, err := bn.GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubkeysByIndex := map[string]beacon.ValidatorPubkey{}
+	for _, validator := range validators {
+		pubkeysByIndex[validator.Index] = beacon.ValidatorPubkey(validator.Validator.Pubkey)
+	}
+
+	// Find the committees the node's validators are in during the slot
+	epoch := slot / spec.SlotsPerEpoch
+	committees, err := bn.GetCommittees(ctx, strconv.FormatUint(epoch*spec.SlotsPerEpoch, 10), epoch)
+	if err != nil {
+		return nil, nil, err
+	}
+	committeeSizes := []int{}
+	duties := []validatorAttestationDuty{}
+	for _, committee := range committees {
+		if uint64(committee.Slot) != slot {
+			continue
+		}
+		index := int(committee.Index)
+		for len(committeeSizes) <= index {
+			committeeSizes = append(committeeSizes, 0)
+		}
+		committeeSizes[index] = len(committee.Validators)
+		for position, validatorIndex := range committee.Validators {
+			pubkey, isOurs := pubkeysByIndex[validatorIndex]
+			if !isOurs {
+				continue
+			}
+			duties = append(duties, validatorAttestationDuty{
+				pubkey: pubkey,
+				index:  validatorIndex,
+				duty: attestationDuty{
+					slot:           slot,
+					committeeIndex: uint64(committee.Index),
+					position:       position,
+				},
+			})
+		}
+	}
+
+	pool, err := bn.GetPoolAttestations(ctx, slot)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pool) == 0 {
+		return nil, duties, fmt.Errorf("%w for slot %d", ErrAttestationPoolEmpty, slot)
+	}
+
+	attestations := []PendingAttestation{}
+	for _, duty := range duties {
+		var found *PendingAttestation
+		for _, attestation := range pool {
+			if uint64(attestation.Data.Slot) != slot {
+				continue
+			}
+			// The v1 pool only serves single-committee attestations, but some clients include the Electra layout
+			version := ""
+			if len(attestation.CommitteeBits) > 0 {
+				version = "electra"
+			}
+			position, covered := getAggregationBitPosition(attestation, version, committeeSizes, duty.duty)
+			if !covered || !isBitSet(attestation.AggregationBits, position) {
+				continue
+			}
+			count := countAggregationBits(attestation.AggregationBits)
+			if found == nil || count > found.AggregationCount {
+				found = &PendingAttestation{
+					Pubkey:           duty.pubkey,
+					ValidatorIndex:   duty.index,
+					Slot:             slot,
+					CommitteeIndex:   duty.duty.committeeIndex,
+					BeaconBlockRoot:  attestation.Data.BeaconBlockRoot,
+					AggregationCount: count,
+				}
+			}
+		}
+		if found != nil {
+			attestations = append(attestations, *found)
+		}
+	}
+	return attestations, duties, nil
+}
+
+// Counts the validators covered by an SSZ bitlist of aggregation bits, leaving out the bit that marks its length
+func countAggregationBits(aggregationBits []byte) int {
+	count := 0
+	for _, b := range aggregationBits {
+		count += bits.OnesCount8(b)
+	}
+	return max(count-1, 0)
+}
+
+// The node's validators' attestations in the Beacon Node's pool for the last slot, and anything unexpected about them
+type diagnosticsAttestationPool struct {
+	Slot         uint64               `json:"slot"`
+	Attestations []PendingAttestation `json:"attestations"`
+	Anomalies    []string             `json:"anomalies"`
+}
+
+// Checks the attestation pool for the slot before the head, which the node's validators have already attested to but
+// which can't have been included in more than one block yet
+func (sp *ServiceProvider) getAttestationPoolDiagnostics(ctx context.Context) (diagnosticsAttestationPool, error) {
+	headSlot, _, err := sp.GetBeaconApiClient().GetBlockSlot(ctx, "head")
+	if err != nil {
+		return diagnosticsAttestationPool{}, err
+	}
+	diagnostics := diagnosticsAttestationPool{
+		Slot:         max(headSlot, 1) - 1,
+		Attestations: []PendingAttestation{},
+		Anomalies:    []string{},
+	}
+	attestations, duties, err := sp.getPendingAttestations(ctx, diagnostics.Slot)
+	if errors.Is(err, ErrAttestationPoolEmpty) {
+		if len(duties) > 0 {
+			diagnostics.Anomalies = append(diagnostics.Anomalies, fmt.Sprintf("the attestation pool is empty for slot %d even though %d of the node's validators had duties in it; the Beacon Node may not be receiving attestation gossip", diagnostics.Slot, len(duties)))
+		}
+		return diagnostics, nil
+	}
+	if err != nil {
+		return diagnosticsAttestationPool{}, err
+	}
+
+	diagnostics.Attestations = attestations
+	found := map[string]bool{}
+	for _, attestation := range attestations {
+		found[attestation.ValidatorIndex] = true
+	}
+	for _, duty := range duties {
+		if !found[duty.index] {
+			diagnostics.Anomalies = append(diagnostics.Anomalies, fmt.Sprintf("validator %s (%s) had a duty in slot %d but its attestation isn't in the pool; it may not have been produced, or the Beacon Node may have pruned it after it was included", duty.index, duty.pubkey.HexWithPrefix(), diagnostics.Slot))
+		}
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// The most balance samples a history can have, so a long range at a fine resolution doesn't flood the Beacon Node
+	// with state queries
+	maxBalanceHistorySamples uint64 = 512
+)
+
+// The node's total validator balance at the start of an epoch
+type BalancePoint struct {
+	// The epoch of the sample
+	Epoch uint64 `json:"epoch"`
+
+	// The start of the epoch
+	Time time.Time `json:"time"`
+
+	// The number of the node's validators on the Beacon Chain at the time
+	ValidatorCount int `json:"validatorCount"`
+
+	// The total balance of the node's validators, in gwei
+	TotalBalance uint64 `json:"totalBalance"`
+}
+
+// Samples the total balance of the validators of every enabled module every resolution epochs from fromEpoch to toEpoch,
+// for charting. The last sample is always at toEpoch, even if the range isn't a multiple of the resolution.
+// Ranges that would need more than 512 samples are refused; use a coarser resolution for them. Returns an
+// *InsufficientHistoryError if the Beacon Node doesn't have the state for one of the samples (e.g. because it's been
+// pruned).
+func (sp *ServiceProvider) GetBalanceHistory(ctx context.Context, fromEpoch uint64, toEpoch uint64, resolution uint64) ([]BalancePoint, error) {
+	if resolution == 0 {
+		return nil, fmt.Errorf("resolution must be at least 1 epoch")
+	}
+	if fromEpoch > toEpoch {
+		return nil, fmt.Errorf("start epoch %d is after end epoch %d", fromEpoch, toEpoch)
+	}
+	sampleCount := (toEpoch-fromEpoch)/resolution + 1
+	if (toEpoch-fromEpoch)%resolution != 0 {
+		sampleCount++
+	}
+	if sampleCount > maxBalanceHistorySamples {
+		return nil, fmt.Errorf("epochs %d to %d at a resolution of %d would take %d samples, more than the limit of %d", fromEpoch, toEpoch, resolution, sampleCount, maxBalanceHistorySamples)
+	}
+
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return nil, err
+	}
+	if headEpoch := headSlot / spec.SlotsPerEpoch; toEpoch > headEpoch {
+		return nil, fmt.Errorf("end epoch %d is after the head epoch %d", toEpoch, headEpoch)
+	}
+	genesisTime, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+
+	points := make([]BalancePoint, 0, sampleCount)
+	for epoch := fromEpoch; ; epoch = min(epoch+resolution, toEpoch) {
+		slot := epoch * spec.SlotsPerEpoch
+		point := BalancePoint{
+			Epoch: epoch,
+			Time:  genesisTime.Add(time.Duration(slot*spec.SecondsPerSlot) * time.Second),
+		}
+		if len(ids) > 0 {
+			balances, exists, err := bn.GetValidatorBalances(ctx, strconv.FormatUint(slot, 10), ids)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, &InsufficientHistoryError{
+					StartEpoch: fromEpoch,
+					Reason:     fmt.Sprintf("the Beacon Node doesn't have the state for epoch %d", epoch),
+				}
+			}
+			point.ValidatorCount = len(balances)
+			for _, balance := range balances {
+				point.TotalBalance += balance
+			}
+		}
+		points = append(points, point)
+		if epoch == toEpoch {
+			break
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,647 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+)
+
+const (
+	// Beacon API routes that aren't covered by the core Beacon client
+	beaconSyncingPath          string = "/eth/v1/node/syncing"
+	beaconIdentityPath         string = "/eth/v1/node/identity"
+	beaconVersionPath          string = "/eth/v1/node/version"
+	beaconSpecPath             string = "/eth/v1/config/spec"
+	beaconGenesisPath          string = "/eth/v1/beacon/genesis"
+	beaconForkPath             string = "/eth/v1/beacon/states/%s/fork"
+	beaconHeaderPath           string = "/eth/v1/beacon/headers/%s"
+	beaconValidatorsPath       string = "/eth/v1/beacon/states/%s/validators"
+	beaconBalancesPath         string = "/eth/v1/beacon/states/%s/validator_balances"
+	beaconCommitteesPath       string = "/eth/v1/beacon/states/%s/committees"
+	beaconAttestationsPath     string = "/eth/v2/beacon/blocks/%s/attestations"
+	beaconPoolAttestationsPath string = "/eth/v1/beacon/pool/attestations"
+	beaconBlobSidecarsPath     string = "/eth/v1/beacon/blob_sidecars/%s"
+	beaconFinalityPath         string = "/eth/v1/beacon/states/%s/finality_checkpoints"
+	beaconBlockV2Path          string = "/eth/v2/beacon/blocks/%s"
+	beaconPendingDepositsPath  string = "/eth/v1/beacon/states/%s/pending_deposits"
+	beaconSyncCommitteePath    string = "/eth/v1/beacon/states/%s/sync_committees"
+	beaconAttesterDutiesPath   string = "/eth/v1/validator/duties/attester/%d"
+	beaconProposerDutiesPath   string = "/eth/v1/validator/duties/proposer/%d"
+	beaconEventsPath           string = "/eth/v1/events"
+
+	// Client-specific routes
+	lighthouseDatabaseInfoPath string = "/lighthouse/database/info"
+)
+
+// A committee assigned to attest during a slot
+type BeaconCommittee struct {
+	Index      client.Uinteger `json:"index"`
+	Slot       client.Uinteger `json:"slot"`
+	Validators []string        `json:"validators"`
+}
+
+// An attestation included in a block. From Electra onwards (EIP-7549), Data.Index is always 0; the committees are
+// marked in CommitteeBits instead, and AggregationBits covers all of them back to back in committee order.
+type BeaconAttestation struct {
+	AggregationBits client.ByteArray `json:"aggregation_bits"`
+	CommitteeBits   client.ByteArray `json:"committee_bits,omitempty"`
+	Data            struct {
+		Slot            client.Uinteger `json:"slot"`
+		Index           client.Uinteger `json:"index"`
+		BeaconBlockRoot string          `json:"beacon_block_root"`
+	} `json:"data"`
+}
+
+// A blob sidecar attached to a block, without the blob data itself
+type BlobSidecar struct {
+	Index         client.Uinteger  `json:"index"`
+	KzgCommitment client.ByteArray `json:"kzg_commitment"`
+	KzgProof      client.ByteArray `json:"kzg_proof"`
+}
+
+// A withdrawal included in a block's execution payload
+type BeaconWithdrawal struct {
+	Index          client.Uinteger  `json:"index"`
+	ValidatorIndex client.Uinteger  `json:"validator_index"`
+	Address        client.ByteArray `json:"address"`
+	Amount         client.Uinteger  `json:"amount"`
+}
+
+// The parts of a block's execution payload that identify the Execution layer block and who it paid
+type BeaconExecutionPayload struct {
+	BlockNumber  client.Uinteger  `json:"block_number"`
+	BlockHash    client.ByteArray `json:"block_hash"`
+	FeeRecipient client.ByteArray `json:"fee_recipient"`
+}
+
+// A deposit waiting in the state's pending deposit queue (Electra onwards)
+type BeaconPendingDeposit struct {
+	Pubkey                client.ByteArray `json:"pubkey"`
+	WithdrawalCredentials client.ByteArray `json:"withdrawal_credentials"`
+	Amount                client.Uinteger  `json:"amount"`
+	Slot                  client.Uinteger  `json:"slot"`
+}
+
+// A validator's assignment to attest during a slot
+type BeaconAttesterDuty struct {
+	Pubkey                  client.ByteArray `json:"pubkey"`
+	ValidatorIndex          string           `json:"validator_index"`
+	CommitteeIndex          client.Uinteger  `json:"committee_index"`
+	CommitteeLength         client.Uinteger  `json:"committee_length"`
+	CommitteesAtSlot        client.Uinteger  `json:"committees_at_slot"`
+	ValidatorCommitteeIndex client.Uinteger  `json:"validator_committee_index"`
+	Slot                    client.Uinteger  `json:"slot"`
+}
+
+// A validator's assignment to propose the block for a slot
+type BeaconProposerDuty struct {
+	Pubkey         client.ByteArray `json:"pubkey"`
+	ValidatorIndex string           `json:"validator_index"`
+	Slot           client.Uinteger  `json:"slot"`
+}
+
+// A checkpoint as reported by the Beacon Node
+type BeaconCheckpoint struct {
+	Epoch client.Uinteger  `json:"epoch"`
+	Root  client.ByteArray `json:"root"`
+}
+
+// The finality checkpoints of a state
+type BeaconFinalityCheckpoints struct {
+	PreviousJustified BeaconCheckpoint `json:"previous_justified"`
+	CurrentJustified  BeaconCheckpoint `json:"current_justified"`
+	Finalized         BeaconCheckpoint `json:"finalized"`
+}
+
+// The sync status of the Beacon Node, including whether it can reach its Execution Client
+type BeaconSyncStatus struct {
+	HeadSlot     client.Uinteger `json:"head_slot"`
+	SyncDistance client.Uinteger `json:"sync_distance"`
+	IsSyncing    bool            `json:"is_syncing"`
+	IsOptimistic bool            `json:"is_optimistic"`
+	ElOffline    bool            `json:"el_offline"`
+}
+
+// Where a Lighthouse node's stored history starts. A node that was checkpoint synced fills in the blocks before its anchor
+// back to genesis, then rebuilds the historical states if it's configured to, moving the state limits towards each
+// other.
+type LighthouseAnchorInfo struct {
+	// The slot the node was checkpoint synced from
+	AnchorSlot client.Uinteger `json:"anchor_slot"`
+
+	// The earliest slot the node has the block for
+	OldestBlockSlot client.Uinteger `json:"oldest_block_slot"`
+
+	// The slot from which the node has every state
+	StateUpperLimit client.Uinteger `json:"state_upper_limit"`
+
+	// The slot up to which the node has every state, counting from genesis
+	StateLowerLimit client.Uinteger `json:"state_lower_limit"`
+}
+
+// The Beacon Node's network identity
+type BeaconNodeIdentity struct {
+	PeerId             string   `json:"peer_id"`
+	Enr                string   `json:"enr"`
+	P2pAddresses       []string `json:"p2p_addresses"`
+	DiscoveryAddresses []string `json:"discovery_addresses"`
+}
+
+// An error returned by the Beacon Node for an unsuccessful request
+type BeaconApiError struct {
+	// The HTTP status code of the response
+	StatusCode int
+
+	// The body of the response
+	Body string
+}
+
+func (e *BeaconApiError) Error() string {
+	return fmt.Sprintf("HTTP status %d; response body: '%s'", e.StatusCode, e.Body)
+}
+
+// Provides access to Beacon API routes that the core Beacon client doesn't expose.
+// Requests go to the primary Beacon Node, falling back to the fallback node if the primary can't be reached.
+type BeaconApiClient struct {
+	primaryUrl     string
+	fallbackUrl    string
+	client         *http.Client
+	latencyTracker *RpcLatencyTracker
+
+	// Used for event streams, which stay open far longer than the request timeout
+	streamClient *http.Client
+}
+
+// Creates a new Beacon API client. The fallback URL can be left blank if there isn't one.
+func NewBeaconApiClient(primaryUrl string, fallbackUrl string, timeout time.Duration) *BeaconApiClient {
+	return &BeaconApiClient{
+		primaryUrl:  strings.TrimSuffix(primaryUrl, "/"),
+		fallbackUrl: strings.TrimSuffix(fallbackUrl, "/"),
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		streamClient: &http.Client{},
+	}
+}
+
+// Creates a new Beacon API client that records the latency of each call with the tracker
+func NewLatencyTrackingBeaconApiClient(primaryUrl string, fallbackUrl string, timeout time.Duration, tracker *RpcLatencyTracker) *BeaconApiClient {
+	c := NewBeaconApiClient(primaryUrl, fallbackUrl, timeout)
+	c.latencyTracker = tracker
+	return c
+}
+
+// Sends a GET request to the Beacon Node, deserializing the response into the result.
+// Returns false if the Beacon Node doesn't have the requested resource.
+func (c *BeaconApiClient) Get(ctx context.Context, path string, query url.Values, result any) (bool, error) {
+	return c.get(ctx, "Get", path, query, result)
+}
+
+// Sends a POST request to the Beacon Node, deserializing the response into the result if provided
+func (c *BeaconApiClient) Post(ctx context.Context, path string, body any, result any) error {
+	if c.latencyTracker != nil {
+		defer c.latencyTracker.recordSince(RpcClientType_BeaconNode, "Post", time.Now())
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error serializing request body: %w", err)
+	}
+	_, err = c.sendRequest(ctx, http.MethodPost, path, bodyBytes, result)
+	return err
+}
+
+// Gets the Beacon Node's full chain spec as a map of raw values
+func (c *BeaconApiClient) GetSpec(ctx context.Context) (map[string]any, error) {
+	var response struct {
+		Data map[string]any `json:"data"`
+	}
+	_, err := c.get(ctx, "GetSpec", beaconSpecPath, nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting chain spec: %w", err)
+	}
+	return response.Data, nil
+}
+
+// Gets the Beacon Node's sync status
+func (c *BeaconApiClient) GetSyncStatus(ctx context.Context) (BeaconSyncStatus, error) {
+	var response struct {
+		Data BeaconSyncStatus `json:"data"`
+	}
+	_, err := c.get(ctx, "GetSyncStatus", beaconSyncingPath, nil, &response)
+	if err != nil {
+		return BeaconSyncStatus{}, fmt.Errorf("error getting sync status: %w", err)
+	}
+	return response.Data, nil
+}
+
+// Gets the Beacon Node's network identity
+func (c *BeaconApiClient) GetNodeIdentity(ctx context.Context) (BeaconNodeIdentity, error) {
+	var response struct {
+		Data BeaconNodeIdentity `json:"data"`
+	}
+	_, err := c.get(ctx, "GetNodeIdentity", beaconIdentityPath, nil, &response)
+	if err != nil {
+		return BeaconNodeIdentity{}, fmt.Errorf("error getting node identity: %w", err)
+	}
+	return response.Data, nil
+}
+
+// Gets the Beacon Node's version string, like `Lighthouse/v4.5.0-441fc16/x86_64-linux`
+func (c *BeaconApiClient) GetNodeVersion(ctx context.Context) (string, error) {
+	var response struct {
+		Data struct {
+			Version string `json:"version"`
+		} `json:"data"`
+	}
+	_, err := c.get(ctx, "GetNodeVersion", beaconVersionPath, nil, &response)
+	if err != nil {
+		return "", fmt.Errorf("error getting node version: %w", err)
+	}
+	return response.Data.Version, nil
+}
+
+// Gets a Lighthouse node's history anchor. Returns false if the node doesn't serve Lighthouse's database route, and a
+// nil anchor if the node has had its whole history since genesis.
+func (c *BeaconApiClient) GetLighthouseAnchor(ctx context.Context) (*LighthouseAnchorInfo, bool, error) {
+	var response struct {
+		Anchor *LighthouseAnchorInfo `json:"anchor"`
+	}
+	exists, err := c.get(ctx, "GetLighthouseAnchor", lighthouseDatabaseInfoPath, nil, &response)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting Lighthouse database info: %w", err)
+	}
+	return response.Anchor, exists, nil
+}
+
+// Gets the chain's genesis info
+func (c *BeaconApiClient) GetGenesis(ctx context.Context) (client.GenesisResponse, error) {
+	var response client.GenesisResponse
+	_, err := c.get(ctx, "GetGenesis", beaconGenesisPath, nil, &response)
+	if err != nil {
+		return client.GenesisResponse{}, fmt.Errorf("error getting genesis info: %w", err)
+	}
+	return response, nil
+}
+
+// Gets the fork that's active on the provided state
+func (c *BeaconApiClient) GetFork(ctx context.Context, stateId string) (client.ForkResponse, error) {
+	var response client.ForkResponse
+	_, err := c.get(ctx, "GetFork", fmt.Sprintf(beaconForkPath, stateId), nil, &response)
+	if err != nil {
+		return client.ForkResponse{}, fmt.Errorf("error getting fork for state %s: %w", stateId, err)
+	}
+	return response, nil
+}
+
+// Gets the slot of the provided block. Returns false if the block doesn't exist.
+func (c *BeaconApiClient) GetBlockSlot(ctx context.Context, blockId string) (uint64, bool, error) {
+	var response client.BeaconBlockHeaderResponse
+	exists, err := c.get(ctx, "GetBlockSlot", fmt.Sprintf(beaconHeaderPath, blockId), nil, &response)
+	if err != nil {
+		return 0, false, fmt.Errorf("error getting header for block %s: %w", blockId, err)
+	}
+	return uint64(response.Data.Header.Message.Slot), exists, nil
+}
+
+// Gets the validators on the provided state, optionally filtered by ID (index or pubkey) and status.
+// Statuses can be specific validator states or the general ones (pending, active, exited, withdrawal).
+func (c *BeaconApiClient) GetValidators(ctx context.Context, stateId string, ids []string, statuses []string) ([]client.Validator, error) {
+	query := url.Values{}
+	if len(statuses) > 0 {
+		query.Set("status", strings.Join(statuses, ","))
+	}
+
+	// Query large ID sets in batches to keep the URL length reasonable
+	validators := []client.Validator{}
+	for i := 0; i == 0 || i < len(ids); i += client.MaxRequestValidatorsCount {
+		if len(ids) > 0 {
+			end := min(i+client.MaxRequestValidatorsCount, len(ids))
+			query.Set("id", strings.Join(ids[i:end], ","))
+		}
+		var response client.ValidatorsResponse
+		_, err := c.get(ctx, "GetValidators", fmt.Sprintf(beaconValidatorsPath, stateId), query, &response)
+		if err != nil {
+			return nil, fmt.Errorf("error getting validators for state %s: %w", stateId, err)
+		}
+		validators = append(validators, response.Data...)
+	}
+	return validators, nil
+}
+
+// Gets the balances (in gwei) of the provided validators (by index or pubkey) on the provided state, keyed by validator
+// index. Returns false if the node doesn't have the state (e.g. because it's been pruned).
+func (c *BeaconApiClient) GetValidatorBalances(ctx context.Context, stateId string, ids []string) (map[string]uint64, bool, error) {
+	balances := map[string]uint64{}
+	for i := 0; i < len(ids); i += client.MaxRequestValidatorsCount {
+		end := min(i+client.MaxRequestValidatorsCount, len(ids))
+		query := url.Values{}
+		query.Set("id", strings.Join(ids[i:end], ","))
+		var response struct {
+			Data []struct {
+				Index   string          `json:"index"`
+				Balance client.Uinteger `json:"balance"`
+			} `json:"data"`
+		}
+		exists, err := c.get(ctx, "GetValidatorBalances", fmt.Sprintf(beaconBalancesPath, stateId), query, &response)
+		if err != nil {
+			return nil, false, fmt.Errorf("error getting validator balances for state %s: %w", stateId, err)
+		}
+		if !exists {
+			return nil, false, nil
+		}
+		for _, balance := range response.Data {
+			balances[balance.Index] = uint64(balance.Balance)
+		}
+	}
+	return balances, true, nil
+}
+
+// Gets the committees assigned to attest during the provided epoch
+func (c *BeaconApiClient) GetCommittees(ctx context.Context, stateId string, epoch uint64) ([]BeaconCommittee, error) {
+	query := url.Values{}
+	query.Set("epoch", strconv.FormatUint(epoch, 10))
+	var response struct {
+		Data []BeaconCommittee `json:"data"`
+	}
+	_, err := c.get(ctx, "GetCommittees", fmt.Sprintf(beaconCommitteesPath, stateId), query, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting committees for epoch %d: %w", epoch, err)
+	}
+	return response.Data, nil
+}
+
+// Gets the attestations included in the provided block, along with the name of the fork the block is from.
+// Returns false if the block doesn't exist (e.g. the slot was missed).
+func (c *BeaconApiClient) GetBlockAttestations(ctx context.Context, blockId string) ([]BeaconAttestation, string, bool, error) {
+	var response struct {
+		Version string              `json:"version"`
+		Data    []BeaconAttestation `json:"data"`
+	}
+	exists, err := c.get(ctx, "GetBlockAttestations", fmt.Sprintf(beaconAttestationsPath, blockId), nil, &response)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error getting attestations for block %s: %w", blockId, err)
+	}
+	return response.Data, response.Version, exists, nil
+}
+
+// Gets the attestations for the provided slot that are waiting in the node's pool to be aggregated or included in a
+// block
+func (c *BeaconApiClient) GetPoolAttestations(ctx context.Context, slot uint64) ([]BeaconAttestation, error) {
+	query := url.Values{}
+	query.Set("slot", strconv.FormatUint(slot, 10))
+	var response struct {
+		Data []BeaconAttestation `json:"data"`
+	}
+	_, err := c.get(ctx, "GetPoolAttestations", beaconPoolAttestationsPath, query, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pool attestations for slot %d: %w", slot, err)
+	}
+	return response.Data, nil
+}
+
+// Gets the blob sidecars for the provided block. Returns false if the block doesn't exist.
+func (c *BeaconApiClient) GetBlobSidecars(ctx context.Context, blockId string) ([]BlobSidecar, bool, error) {
+	var response struct {
+		Data []BlobSidecar `json:"data"`
+	}
+	exists, err := c.get(ctx, "GetBlobSidecars", fmt.Sprintf(beaconBlobSidecarsPath, blockId), nil, &response)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting blob sidecars for block %s: %w", blockId, err)
+	}
+	if response.Data == nil {
+		response.Data = []BlobSidecar{}
+	}
+	return response.Data, exists, nil
+}
+
+// Gets the withdrawals in the execution payload of the provided block. Returns false if the block doesn't exist.
+// Blocks from before the merge or Capella have no withdrawals.
+func (c *BeaconApiClient) GetBlockWithdrawals(ctx context.Context, blockId string) ([]BeaconWithdrawal, bool, error) {
+	var response struct {
+		Data struct {
+			Message struct {
+				Body struct {
+					ExecutionPayload *struct {
+						Withdrawals []BeaconWithdrawal `json:"withdrawals"`
+					} `json:"execution_payload"`
+				} `json:"body"`
+			} `json:"message"`
+		} `json:"data"`
+	}
+	exists, err := c.get(ctx, "GetBlockWithdrawals", fmt.Sprintf(beaconBlockV2Path, blockId), nil, &response)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting block %s: %w", blockId, err)
+	}
+	payload := response.Data.Message.Body.ExecutionPayload
+	if payload == nil || payload.Withdrawals == nil {
+		return []BeaconWithdrawal{}, exists, nil
+	}
+	return payload.Withdrawals, exists, nil
+}
+
+// Gets the execution payload of the provided block. Returns false if the block doesn't exist, or if it's from before the
+// merge and has no payload.
+func (c *BeaconApiClient) GetBlockExecutionPayload(ctx context.Context, blockId string) (BeaconExecutionPayload, bool, error) {
+	var response struct {
+		Data struct {
+			Message struct {
+				Body struct {
+					ExecutionPayload *BeaconExecutionPayload `json:"execution_payload"`
+				} `json:"body"`
+			} `json:"message"`
+		} `json:"data"`
+	}
+	exists, err := c.get(ctx, "GetBlockExecutionPayload", fmt.Sprintf(beaconBlockV2Path, blockId), nil, &response)
+	if err != nil {
+		return BeaconExecutionPayload{}, false, fmt.Errorf("error getting block %s: %w", blockId, err)
+	}
+	payload := response.Data.Message.Body.ExecutionPayload
+	if !exists || payload == nil {
+		return BeaconExecutionPayload{}, false, nil
+	}
+	return *payload, true, nil
+}
+
+// Gets the justified and finalized checkpoints of the provided state. Returns false if the state doesn't exist.
+func (c *BeaconApiClient) GetFinalityCheckpoints(ctx context.Context, stateId string) (BeaconFinalityCheckpoints, bool, error) {
+	var response struct {
+		Data BeaconFinalityCheckpoints `json:"data"`
+	}
+	exists, err := c.get(ctx, "GetFinalityCheckpoints", fmt.Sprintf(beaconFinalityPath, stateId), nil, &response)
+	if err != nil {
+		return BeaconFinalityCheckpoints{}, false, fmt.Errorf("error getting finality checkpoints for state %s: %w", stateId, err)
+	}
+	return response.Data, exists, nil
+}
+
+// Gets the deposits waiting to be processed in the provided state. Only available from Electra onwards.
+func (c *BeaconApiClient) GetPendingDeposits(ctx context.Context, stateId string) ([]BeaconPendingDeposit, error) {
+	var response struct {
+		Data []BeaconPendingDeposit `json:"data"`
+	}
+	_, err := c.get(ctx, "GetPendingDeposits", fmt.Sprintf(beaconPendingDepositsPath, stateId), nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pending deposits for state %s: %w", stateId, err)
+	}
+	return response.Data, nil
+}
+
+// Gets the indices of the validators in the sync committee for the period containing the provided epoch, in committee
+// order; a validator can appear more than once. Beacon Nodes only know the committees for the state's period and the one
+// after it, and only from Altair onwards.
+func (c *BeaconApiClient) GetSyncCommittee(ctx context.Context, stateId string, epoch uint64) ([]string, error) {
+	query := url.Values{}
+	query.Set("epoch", strconv.FormatUint(epoch, 10))
+	var response struct {
+		Data struct {
+			Validators []string `json:"validators"`
+		} `json:"data"`
+	}
+	_, err := c.get(ctx, "GetSyncCommittee", fmt.Sprintf(beaconSyncCommitteePath, stateId), query, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sync committee for epoch %d: %w", epoch, err)
+	}
+	return response.Data.Validators, nil
+}
+
+// Gets the attestation duties of the provided validators (by index) during an epoch.
+// Beacon Nodes only know the duties up to the epoch after the current one.
+func (c *BeaconApiClient) GetAttesterDuties(ctx context.Context, epoch uint64, indices []string) ([]BeaconAttesterDuty, error) {
+	var response struct {
+		Data []BeaconAttesterDuty `json:"data"`
+	}
+	err := c.Post(ctx, fmt.Sprintf(beaconAttesterDutiesPath, epoch), indices, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting attester duties for epoch %d: %w", epoch, err)
+	}
+	return response.Data, nil
+}
+
+// Gets the block proposers for each slot of an epoch. Most Beacon Nodes only know them for the current epoch.
+func (c *BeaconApiClient) GetProposerDuties(ctx context.Context, epoch uint64) ([]BeaconProposerDuty, error) {
+	var response struct {
+		Data []BeaconProposerDuty `json:"data"`
+	}
+	_, err := c.get(ctx, "GetProposerDuties", fmt.Sprintf(beaconProposerDutiesPath, epoch), nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting proposer duties for epoch %d: %w", epoch, err)
+	}
+	return response.Data, nil
+}
+
+// Opens a server-sent event stream for the provided topics on the primary Beacon Node, or the fallback if the primary
+// can't be reached. The stream stays open until the context is cancelled, the node closes it, or the caller closes it.
+func (c *BeaconApiClient) OpenEventStream(ctx context.Context, topics []string) (io.ReadCloser, error) {
+	path := beaconEventsPath + "?" + url.Values{"topics": {strings.Join(topics, ",")}}.Encode()
+	stream, err := c.openEventStreamOnNode(ctx, c.primaryUrl, path)
+	if err == nil || c.fallbackUrl == "" || ctx.Err() != nil {
+		return stream, err
+	}
+	var apiErr *BeaconApiError
+	if errors.As(err, &apiErr) {
+		return nil, err
+	}
+	return c.openEventStreamOnNode(ctx, c.fallbackUrl, path)
+}
+
+// Opens a server-sent event stream on a single Beacon Node
+func (c *BeaconApiClient) openEventStreamOnNode(ctx context.Context, baseUrl string, path string) (io.ReadCloser, error) {
+	fullUrl := baseUrl + path
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fullUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating event stream request to [%s]: %w", fullUrl, err)
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	response, err := c.streamClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error opening event stream on [%s]: %w", fullUrl, err)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		defer response.Body.Close()
+		responseBytes, _ := io.ReadAll(response.Body)
+		return nil, &BeaconApiError{
+			StatusCode: response.StatusCode,
+			Body:       string(responseBytes),
+		}
+	}
+	return response.Body, nil
+}
+
+// Sends a GET request to the Beacon Node, recording its latency under the provided name
+func (c *BeaconApiClient) get(ctx context.Context, name string, path string, query url.Values, result any) (bool, error) {
+	if c.latencyTracker != nil {
+		defer c.latencyTracker.recordSince(RpcClientType_BeaconNode, name, time.Now())
+	}
+	if len(query) > 0 {
+		path = path + "?" + query.Encode()
+	}
+	return c.sendRequest(ctx, http.MethodGet, path, nil, result)
+}
+
+// Sends a request to the primary Beacon Node, or the fallback if the primary can't be reached
+func (c *BeaconApiClient) sendRequest(ctx context.Context, method string, path string, body []byte, result any) (bool, error) {
+	exists, err := c.sendRequestToNode(ctx, c.primaryUrl, method, path, body, result)
+	if err == nil || c.fallbackUrl == "" || ctx.Err() != nil {
+		return exists, err
+	}
+	var apiErr *BeaconApiError
+	if errors.As(err, &apiErr) {
+		// The primary responded, so the fallback won't do any better
+		return exists, err
+	}
+	return c.sendRequestToNode(ctx, c.fallbackUrl, method, path, body, result)
+}
+
+// Sends a request to a single Beacon Node
+func (c *BeaconApiClient) sendRequestToNode(ctx context.Context, baseUrl string, method string, path string, body []byte, result any) (bool, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	fullUrl := baseUrl + path
+	request, err := http.NewRequestWithContext(ctx, method, fullUrl, bodyReader)
+	if err != nil {
+		return false, fmt.Errorf("error creating %s request to [%s]: %w", method, fullUrl, err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", client.RequestContentType)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return false, fmt.Errorf("error running %s request to [%s]: %w", method, fullUrl, err)
+	}
+	defer response.Body.Close()
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return false, fmt.Errorf("error reading response from [%s]: %w", fullUrl, err)
+	}
+
+	if response.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return false, nil
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return false, &BeaconApiError{
+			StatusCode: response.StatusCode,
+			Body:       string(responseBytes),
+		}
+	}
+	if result == nil || len(responseBytes) == 0 {
+		return true, nil
+	}
+	err = json.Unmarshal(responseBytes, result)
+	if err != nil {
+		return false, fmt.Errorf("error decoding response from [%s]: %w", fullUrl, err)
+	}
+	return true, nil
+}
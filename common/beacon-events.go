@@ -0,0 +1,216 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+)
+
+const (
+	// Beacon event topics with typed payloads
+	BeaconEventTopic_Head                string = "head"
+	BeaconEventTopic_FinalizedCheckpoint string = "finalized_checkpoint"
+	BeaconEventTopic_ChainReorg          string = "chain_reorg"
+
+	// How long to wait before reconnecting after the event stream drops; the delay doubles after each failed attempt
+	beaconEventMinReconnectDelay time.Duration = 250 * time.Millisecond
+	beaconEventMaxReconnectDelay time.Duration = 30 * time.Second
+
+	// How many errors can be waiting in a subscription's error channel before new ones are dropped
+	beaconEventErrorBuffer int = 16
+)
+
+// A new head block
+type BeaconHeadEvent struct {
+	Slot                      client.Uinteger  `json:"slot"`
+	Block                     client.ByteArray `json:"block"`
+	State                     client.ByteArray `json:"state"`
+	EpochTransition           bool             `json:"epoch_transition"`
+	PreviousDutyDependentRoot client.ByteArray `json:"previous_duty_dependent_root"`
+	CurrentDutyDependentRoot  client.ByteArray `json:"current_duty_dependent_root"`
+	ExecutionOptimistic       bool             `json:"execution_optimistic"`
+}
+
+// A newly finalized checkpoint
+type BeaconFinalizedCheckpointEvent struct {
+	Block               client.ByteArray `json:"block"`
+	State               client.ByteArray `json:"state"`
+	Epoch               client.Uinteger  `json:"epoch"`
+	ExecutionOptimistic bool             `json:"execution_optimistic"`
+}
+
+// A reorg of the canonical chain
+type BeaconChainReorgEvent struct {
+	Slot                client.Uinteger  `json:"slot"`
+	Depth               client.Uinteger  `json:"depth"`
+	OldHeadBlock        client.ByteArray `json:"old_head_block"`
+	NewHeadBlock        client.ByteArray `json:"new_head_block"`
+	OldHeadState        client.ByteArray `json:"old_head_state"`
+	NewHeadState        client.ByteArray `json:"new_head_state"`
+	Epoch               client.Uinteger  `json:"epoch"`
+	ExecutionOptimistic bool             `json:"execution_optimistic"`
+}
+
+// An event from the Beacon Node's event stream. The payload of the event's topic is set if it has a typed one; the raw
+// payload is always available in Data.
+type BeaconEvent struct {
+	// The event's topic
+	Topic string
+
+	// The payload of head events
+	Head *BeaconHeadEvent
+
+	// The payload of finalized_checkpoint events
+	FinalizedCheckpoint *BeaconFinalizedCheckpointEvent
+
+	// The payload of chain_reorg events
+	ChainReorg *BeaconChainReorgEvent
+
+	// The raw JSON payload
+	Data json.RawMessage
+}
+
+// Subscribes to the Beacon Node's event stream for the provided topics (e.g. BeaconEventTopic_FinalizedCheckpoint).
+// The stream is reopened whenever it drops, with a growing delay between attempts; each drop is reported on the error
+// channel, along with events that couldn't be parsed. Errors don't block the events, so they're dropped if too many go
+// unread. If the Beacon Node rejects the subscription (e.g. because of an unknown topic), the error is reported and the
+// subscription ends.
+// Both channels are closed when the subscription ends, including when the context is cancelled.
+func (sp *ServiceProvider) SubscribeBeaconEvents(ctx context.Context, topics []string) (<-chan BeaconEvent, <-chan error) {
+	events := make(chan BeaconEvent)
+	errs := make(chan error, beaconEventErrorBuffer)
+	reportError := func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+		if len(topics) == 0 {
+			reportError(errors.New("at least one event topic is required"))
+			return
+		}
+
+		delay := beaconEventMinReconnectDelay
+		for {
+			connected, err := sp.readBeaconEvents(ctx, topics, events, reportError)
+			if ctx.Err() != nil {
+				return
+			}
+			var apiErr *BeaconApiError
+			if errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError {
+				reportError(fmt.Errorf("the Beacon Node rejected the event subscription: %w", err))
+				return
+			}
+			reportError(err)
+
+			// Back off, starting over once a connection succeeds
+			if connected {
+				delay = beaconEventMinReconnectDelay
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, beaconEventMaxReconnectDelay)
+		}
+	}()
+	return events, errs
+}
+
+// Reads events from a single connection to the Beacon Node's event stream until it drops or the context is cancelled.
+// Returns true if the stream was opened.
+func (sp *ServiceProvider) readBeaconEvents(ctx context.Context, topics []string, events chan<- BeaconEvent, reportError func(error)) (bool, error) {
+	stream, err := sp.GetBeaconApiClient().OpenEventStream(ctx, topics)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close()
+
+	// Server-sent events are blocks of "field: value" lines separated by blank lines; lines starting with a colon are
+	// comments, which nodes send as keepalives
+	reader := bufio.NewReader(stream)
+	topic := ""
+	data := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return true, errors.New("the Beacon Node closed the event stream")
+			}
+			return true, fmt.Errorf("error reading event stream: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				topic = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		// A blank line ends the event
+		if len(data) == 0 {
+			topic = ""
+			continue
+		}
+		event, err := parseBeaconEvent(topic, []byte(strings.Join(data, "\n")))
+		topic = ""
+		data = data[:0]
+		if err != nil {
+			reportError(err)
+			continue
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
+
+// Parses an event's payload into the typed struct for its topic, if it has one
+func parseBeaconEvent(topic string, data []byte) (BeaconEvent, error) {
+	event := BeaconEvent{
+		Topic: topic,
+		Data:  json.RawMessage(data),
+	}
+	var payload any
+	switch topic {
+	case BeaconEventTopic_Head:
+		event.Head = &BeaconHeadEvent{}
+		payload = event.Head
+	case BeaconEventTopic_FinalizedCheckpoint:
+		event.FinalizedCheckpoint = &BeaconFinalizedCheckpointEvent{}
+		payload = event.FinalizedCheckpoint
+	case BeaconEventTopic_ChainReorg:
+		event.ChainReorg = &BeaconChainReorgEvent{}
+		payload = event.ChainReorg
+	default:
+		if !json.Valid(data) {
+			return BeaconEvent{}, fmt.Errorf("%s event has invalid JSON: %s", topic, data)
+		}
+		return event, nil
+	}
+	err := json.Unmarshal(data, payload)
+	if err != nil {
+		return BeaconEvent{}, fmt.Errorf("error parsing %s event: %w", topic, err)
+	}
+	return event, nil
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// The Beacon Node's identity and version, for support requests
+type NodeInfo struct {
+	// The node's libp2p peer ID
+	PeerId string `json:"peerId"`
+
+	// The node's Ethereum Node Record
+	Enr string `json:"enr"`
+
+	// The multiaddresses the node listens on for libp2p connections
+	P2pAddresses []string `json:"p2pAddresses"`
+
+	// The multiaddresses the node uses for discv5 discovery
+	DiscoveryAddresses []string `json:"discoveryAddresses"`
+
+	// The full version string the node reported
+	Version string `json:"version"`
+
+	// The client name from the version string, like `Lighthouse`. Blank if the version string couldn't be parsed.
+	ClientName string `json:"clientName,omitempty"`
+
+	// The client's semantic version from the version string, without the leading `v`. Blank if it couldn't be parsed.
+	ClientVersion string `json:"clientVersion,omitempty"`
+}
+
+// Gets the primary Beacon Node's identity and version
+func (sp *ServiceProvider) GetBeaconNodeInfo(ctx context.Context) (NodeInfo, error) {
+	bn := sp.GetBeaconApiClient()
+	identity, err := bn.GetNodeIdentity(ctx)
+	if err != nil {
+		return NodeInfo{}, err
+	}
+	versionString, err := bn.GetNodeVersion(ctx)
+	if err != nil {
+		return NodeInfo{}, err
+	}
+
+	info := NodeInfo{
+		PeerId:             identity.PeerId,
+		Enr:                identity.Enr,
+		P2pAddresses:       identity.P2pAddresses,
+		DiscoveryAddresses: identity.DiscoveryAddresses,
+		Version:            versionString,
+	}
+	if info.P2pAddresses == nil {
+		info.P2pAddresses = []string{}
+	}
+	if info.DiscoveryAddresses == nil {
+		info.DiscoveryAddresses = []string{}
+	}
+	info.ClientName, info.ClientVersion = parseBeaconNodeVersion(versionString)
+	return info, nil
+}
+
+// Splits a Beacon Node version string into the client name and its semantic version. Clients report them as
+// `Name/vX.Y.Z...`, followed by build details separated with slashes (Lighthouse, Teku, Lodestar, Grandine) or spaces
+// (Prysm). Either part is blank if it can't be found.
+func parseBeaconNodeVersion(versionString string) (string, string) {
+	name, rest, found := strings.Cut(strings.TrimSpace(versionString), "/")
+	if !found || name == "" {
+		return "", ""
+	}
+	token, _, _ := strings.Cut(rest, "/")
+	token, _, _ = strings.Cut(token, " ")
+	parsed, err := version.NewSemver(token)
+	if err != nil {
+		return name, ""
+	}
+	return name, parsed.String()
+}
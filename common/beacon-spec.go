@@ -0,0 +1,221 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/utils"
+)
+
+const (
+	// The epoch used by the spec for forks that aren't scheduled
+	FarFutureEpoch uint64 = math.MaxUint64
+)
+
+// The Beacon Node's chain spec, with the commonly used values parsed into typed fields
+type BeaconSpec struct {
+	ConfigName string `json:"configName"`
+	PresetBase string `json:"presetBase"`
+
+	// Time
+	SecondsPerSlot               uint64 `json:"secondsPerSlot"`
+	SlotsPerEpoch                uint64 `json:"slotsPerEpoch"`
+	EpochsPerSyncCommitteePeriod uint64 `json:"epochsPerSyncCommitteePeriod"`
+	MinGenesisTime               uint64 `json:"minGenesisTime"`
+
+	// Balances, in gwei
+	MaxEffectiveBalance        uint64 `json:"maxEffectiveBalance"`
+	MaxEffectiveBalanceElectra uint64 `json:"maxEffectiveBalanceElectra"`
+	EffectiveBalanceIncrement  uint64 `json:"effectiveBalanceIncrement"`
+	MinActivationBalance       uint64 `json:"minActivationBalance"`
+	HysteresisQuotient         uint64 `json:"hysteresisQuotient"`
+	HysteresisUpwardMultiplier uint64 `json:"hysteresisUpwardMultiplier"`
+
+	// Validator lifecycle
+	MinPerEpochChurnLimit               uint64 `json:"minPerEpochChurnLimit"`
+	ChurnLimitQuotient                  uint64 `json:"churnLimitQuotient"`
+	MaxPerEpochActivationChurnLimit     uint64 `json:"maxPerEpochActivationChurnLimit"`
+	MinPerEpochChurnLimitElectra        uint64 `json:"minPerEpochChurnLimitElectra"`
+	MaxPerEpochActivationExitChurnLimit uint64 `json:"maxPerEpochActivationExitChurnLimit"`
+	ShardCommitteePeriod                uint64 `json:"shardCommitteePeriod"`
+	MaxValidatorsPerWithdrawalsSweep    uint64 `json:"maxValidatorsPerWithdrawalsSweep"`
+	MinValidatorWithdrawabilityDelay    uint64 `json:"minValidatorWithdrawabilityDelay"`
+
+	// Forks; epochs are FarFutureEpoch if the fork isn't scheduled
+	GenesisForkVersion   []byte `json:"genesisForkVersion"`
+	AltairForkVersion    []byte `json:"altairForkVersion"`
+	AltairForkEpoch      uint64 `json:"altairForkEpoch"`
+	BellatrixForkVersion []byte `json:"bellatrixForkVersion"`
+	BellatrixForkEpoch   uint64 `json:"bellatrixForkEpoch"`
+	CapellaForkVersion   []byte `json:"capellaForkVersion"`
+	CapellaForkEpoch     uint64 `json:"capellaForkEpoch"`
+	DenebForkVersion     []byte `json:"denebForkVersion"`
+	DenebForkEpoch       uint64 `json:"denebForkEpoch"`
+	ElectraForkVersion   []byte `json:"electraForkVersion"`
+	ElectraForkEpoch     uint64 `json:"electraForkEpoch"`
+	FuluForkVersion      []byte `json:"fuluForkVersion"`
+	FuluForkEpoch        uint64 `json:"fuluForkEpoch"`
+
+	// Deposits
+	DepositChainID         uint64            `json:"depositChainId"`
+	DepositContractAddress ethcommon.Address `json:"depositContractAddress"`
+
+	// Every spec value that doesn't have a typed field, as reported by the Beacon Node
+	Extra map[string]any `json:"extra"`
+}
+
+// Gets the Beacon Node's chain spec. The spec can't change while the node is running, so it's only queried once;
+// failed queries aren't cached. Each call returns its own copy, so callers can't modify the cached spec.
+func (sp *ServiceProvider) GetBeaconSpec(ctx context.Context) (BeaconSpec, error) {
+	sp.beaconSpecLock.Lock()
+	defer sp.beaconSpecLock.Unlock()
+	if sp.beaconSpec != nil {
+		return sp.beaconSpec.Clone(), nil
+	}
+
+	rawSpec, err := sp.GetBeaconApiClient().GetSpec(ctx)
+	if err != nil {
+		return BeaconSpec{}, err
+	}
+	spec, err := ParseBeaconSpec(rawSpec)
+	if err != nil {
+		return BeaconSpec{}, err
+	}
+	sp.beaconSpec = &spec
+	return spec.Clone(), nil
+}
+
+// Creates a deep copy of the spec, including the fork versions and the extra values
+func (s BeaconSpec) Clone() BeaconSpec {
+	clone := s
+	clone.GenesisForkVersion = bytes.Clone(s.GenesisForkVersion)
+	clone.AltairForkVersion = bytes.Clone(s.AltairForkVersion)
+	clone.BellatrixForkVersion = bytes.Clone(s.BellatrixForkVersion)
+	clone.CapellaForkVersion = bytes.Clone(s.CapellaForkVersion)
+	clone.DenebForkVersion = bytes.Clone(s.DenebForkVersion)
+	clone.ElectraForkVersion = bytes.Clone(s.ElectraForkVersion)
+	clone.FuluForkVersion = bytes.Clone(s.FuluForkVersion)
+	if s.Extra != nil {
+		clone.Extra = cloneSpecValue(s.Extra).(map[string]any)
+	}
+	return clone
+}
+
+// Deep copies a raw spec value. Values come from decoded JSON, so the only containers are maps and slices.
+func cloneSpecValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		clone := make(map[string]any, len(value))
+		for key, element := range value {
+			clone[key] = cloneSpecValue(element)
+		}
+		return clone
+	case []any:
+		clone := make([]any, len(value))
+		for i, element := range value {
+			clone[i] = cloneSpecValue(element)
+		}
+		return clone
+	default:
+		return value
+	}
+}
+
+// Parses a raw chain spec from the Beacon API
+func ParseBeaconSpec(rawSpec map[string]any) (BeaconSpec, error) {
+	spec := BeaconSpec{
+		AltairForkEpoch:    FarFutureEpoch,
+		BellatrixForkEpoch: FarFutureEpoch,
+		CapellaForkEpoch:   FarFutureEpoch,
+		DenebForkEpoch:     FarFutureEpoch,
+		ElectraForkEpoch:   FarFutureEpoch,
+		FuluForkEpoch:      FarFutureEpoch,
+		Extra:              map[string]any{},
+	}
+	stringFields := map[string]*string{
+		"CONFIG_NAME": &spec.ConfigName,
+		"PRESET_BASE": &spec.PresetBase,
+	}
+	uintFields := map[string]*uint64{
+		"SECONDS_PER_SLOT":                          &spec.SecondsPerSlot,
+		"SLOTS_PER_EPOCH":                           &spec.SlotsPerEpoch,
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD":          &spec.EpochsPerSyncCommitteePeriod,
+		"MIN_GENESIS_TIME":                          &spec.MinGenesisTime,
+		"MAX_EFFECTIVE_BALANCE":                     &spec.MaxEffectiveBalance,
+		"MAX_EFFECTIVE_BALANCE_ELECTRA":             &spec.MaxEffectiveBalanceElectra,
+		"EFFECTIVE_BALANCE_INCREMENT":               &spec.EffectiveBalanceIncrement,
+		"MIN_ACTIVATION_BALANCE":                    &spec.MinActivationBalance,
+		"HYSTERESIS_QUOTIENT":                       &spec.HysteresisQuotient,
+		"HYSTERESIS_UPWARD_MULTIPLIER":              &spec.HysteresisUpwardMultiplier,
+		"MIN_PER_EPOCH_CHURN_LIMIT":                 &spec.MinPerEpochChurnLimit,
+		"CHURN_LIMIT_QUOTIENT":                      &spec.ChurnLimitQuotient,
+		"MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT":      &spec.MaxPerEpochActivationChurnLimit,
+		"MIN_PER_EPOCH_CHURN_LIMIT_ELECTRA":         &spec.MinPerEpochChurnLimitElectra,
+		"MAX_PER_EPOCH_ACTIVATION_EXIT_CHURN_LIMIT": &spec.MaxPerEpochActivationExitChurnLimit,
+		"SHARD_COMMITTEE_PERIOD":                    &spec.ShardCommitteePeriod,
+		"MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP":      &spec.MaxValidatorsPerWithdrawalsSweep,
+		"MIN_VALIDATOR_WITHDRAWABILITY_DELAY":       &spec.MinValidatorWithdrawabilityDelay,
+		"ALTAIR_FORK_EPOCH":                         &spec.AltairForkEpoch,
+		"BELLATRIX_FORK_EPOCH":                      &spec.BellatrixForkEpoch,
+		"CAPELLA_FORK_EPOCH":                        &spec.CapellaForkEpoch,
+		"DENEB_FORK_EPOCH":                          &spec.DenebForkEpoch,
+		"ELECTRA_FORK_EPOCH":                        &spec.ElectraForkEpoch,
+		"FULU_FORK_EPOCH":                           &spec.FuluForkEpoch,
+		"DEPOSIT_CHAIN_ID":                          &spec.DepositChainID,
+	}
+	bytesFields := map[string]*[]byte{
+		"GENESIS_FORK_VERSION":   &spec.GenesisForkVersion,
+		"ALTAIR_FORK_VERSION":    &spec.AltairForkVersion,
+		"BELLATRIX_FORK_VERSION": &spec.BellatrixForkVersion,
+		"CAPELLA_FORK_VERSION":   &spec.CapellaForkVersion,
+		"DENEB_FORK_VERSION":     &spec.DenebForkVersion,
+		"ELECTRA_FORK_VERSION":   &spec.ElectraForkVersion,
+		"FULU_FORK_VERSION":      &spec.FuluForkVersion,
+	}
+
+	for key, value := range rawSpec {
+		stringField, hasStringField := stringFields[key]
+		uintField, hasUintField := uintFields[key]
+		bytesField, hasBytesField := bytesFields[key]
+		if !hasStringField && !hasUintField && !hasBytesField && key != "DEPOSIT_CONTRACT_ADDRESS" {
+			spec.Extra[key] = value
+			continue
+		}
+
+		valueString, isString := value.(string)
+		if !isString {
+			return BeaconSpec{}, fmt.Errorf("spec value [%s] is not a string", key)
+		}
+		switch {
+		case hasStringField:
+			*stringField = valueString
+		case hasUintField:
+			parsed, err := strconv.ParseUint(valueString, 10, 64)
+			if err != nil {
+				return BeaconSpec{}, fmt.Errorf("error parsing spec value [%s]: %w", key, err)
+			}
+			*uintField = parsed
+		case hasBytesField:
+			parsed, err := utils.DecodeHex(valueString)
+			if err != nil {
+				return BeaconSpec{}, fmt.Errorf("error parsing spec value [%s]: %w", key, err)
+			}
+			*bytesField = parsed
+		default:
+			if !ethcommon.IsHexAddress(valueString) {
+				return BeaconSpec{}, fmt.Errorf("spec value [%s] is not a valid address", key)
+			}
+			spec.DepositContractAddress = ethcommon.HexToAddress(valueString)
+		}
+	}
+
+	// The rest of the daemon can't work without these
+	if spec.SlotsPerEpoch == 0 || spec.SecondsPerSlot == 0 {
+		return BeaconSpec{}, fmt.Errorf("spec is missing SLOTS_PER_EPOCH or SECONDS_PER_SLOT")
+	}
+	return spec, nil
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+var (
+	// Blobs aren't available for slots before the Deneb fork
+	ErrBlobsNotActive error = errors.New("Blobs are not active for this slot because it is before the Deneb fork.")
+)
+
+// Gets the blob sidecars for the block in the provided slot. Returns an empty list if the block has no blobs or the slot was missed.
+// Returns ErrBlobsNotActive if the slot is before the Deneb fork.
+func (sp *ServiceProvider) GetBlobSidecars(ctx context.Context, slot uint64) ([]BlobSidecar, error) {
+	// Make sure blobs exist at this slot
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if slot/spec.SlotsPerEpoch < spec.DenebForkEpoch {
+		return nil, ErrBlobsNotActive
+	}
+
+	sidecars, _, err := sp.GetBeaconApiClient().GetBlobSidecars(ctx, strconv.FormatUint(slot, 10))
+	if err != nil {
+		return nil, err
+	}
+	return sidecars, nil
+}
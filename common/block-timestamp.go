@@ -0,0 +1,96 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Returned when a timestamp is before the chain's genesis block
+type TimestampBeforeGenesisError struct {
+	// The timestamp that was requested
+	Timestamp time.Time
+
+	// The time of the genesis block
+	GenesisTime time.Time
+}
+
+func (e *TimestampBeforeGenesisError) Error() string {
+	return fmt.Sprintf("timestamp %s is before the genesis block at %s", e.Timestamp.UTC().Format(time.RFC3339), e.GenesisTime.UTC().Format(time.RFC3339))
+}
+
+// Returned when a timestamp is after the Execution Client's latest block, so the block for it doesn't exist yet
+type TimestampAfterHeadError struct {
+	// The timestamp that was requested
+	Timestamp time.Time
+
+	// The number of the latest block
+	HeadBlock uint64
+
+	// The time of the latest block
+	HeadTime time.Time
+}
+
+func (e *TimestampAfterHeadError) Error() string {
+	return fmt.Sprintf("timestamp %s is after the latest block (%d at %s)", e.Timestamp.UTC().Format(time.RFC3339), e.HeadBlock, e.HeadTime.UTC().Format(time.RFC3339))
+}
+
+// Gets the number of the first block with a timestamp at or after the provided one, by binary searching the block headers
+// between genesis and the Execution Client's latest block. Returns a *TimestampBeforeGenesisError or a
+// *TimestampAfterHeadError if the timestamp is outside of the chain.
+func (sp *ServiceProvider) GetBlockByTimestamp(ctx context.Context, ts time.Time) (uint64, error) {
+	genesisTime, err := sp.getBlockTime(ctx, big.NewInt(0))
+	if err != nil {
+		return 0, fmt.Errorf("error getting genesis block: %w", err)
+	}
+	if ts.Before(genesisTime) {
+		return 0, &TimestampBeforeGenesisError{
+			Timestamp:   ts,
+			GenesisTime: genesisTime,
+		}
+	}
+	if ts.Equal(genesisTime) {
+		return 0, nil
+	}
+
+	head, err := sp.GetEthClient().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error getting latest block: %w", err)
+	}
+	headBlock := head.Number.Uint64()
+	headTime := time.Unix(int64(head.Time), 0)
+	if ts.After(headTime) {
+		return 0, &TimestampAfterHeadError{
+			Timestamp: ts,
+			HeadBlock: headBlock,
+			HeadTime:  headTime,
+		}
+	}
+
+	// Genesis is known to be before the target and head at or after it, so find the first block between them that isn't before it
+	low := uint64(0)
+	high := headBlock
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		blockTime, err := sp.getBlockTime(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, fmt.Errorf("error getting block %d: %w", mid, err)
+		}
+		if blockTime.Before(ts) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return high, nil
+}
+
+// Gets the time of a block
+func (sp *ServiceProvider) getBlockTime(ctx context.Context, number *big.Int) (time.Time, error) {
+	header, err := sp.GetEthClient().HeaderByNumber(ctx, number)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
@@ -0,0 +1,241 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	// How often to check if a client has come up during startup
+	bootPollInterval time.Duration = 250 * time.Millisecond
+
+	// The states of containers that have never been started and of running containers, as Docker lists them
+	containerStateCreated string = "created"
+	containerStateRunning string = "running"
+
+	// Client names used in startup logs and errors
+	executionClientName string = "Execution Client"
+	beaconNodeName      string = "Beacon Node"
+)
+
+// Returned when a client in the startup order couldn't be started or didn't come up
+type ClientBootError struct {
+	// The human-readable name of the client
+	Client string
+
+	// True if the client was started but didn't come up within the startup timeout
+	NotReady bool
+
+	// The reason it couldn't be started
+	Err error
+}
+
+func (e *ClientBootError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClientBootError) Unwrap() error {
+	return e.Err
+}
+
+// A single client in the startup order
+type bootStage struct {
+	// The human-readable name of the client, used in logs and errors
+	name string
+
+	// The containers to start for this stage; empty if the client isn't managed by Hyperdrive
+	containers []string
+
+	// Runs before the containers are started, even if there aren't any, stopping the startup if it fails; can be nil
+	beforeStart func(ctx context.Context) error
+
+	// Checks if the client is ready to accept connections
+	checkReady func(ctx context.Context) error
+
+	// Runs once the client is ready, before the next stage starts; can be nil
+	afterReady func(ctx context.Context, timeout time.Duration) error
+}
+
+// Starts the node's clients in dependency order: the Execution Client first, then the Beacon Node, then the provided
+// Validator Client containers. Each client must be ready before the next one is started; if a client doesn't come up
+// within the configured startup timeout, this stops and returns an error naming it. The Beacon Node also isn't started
+// until the Execution Client's Engine API accepts the shared secret and supports the methods it needs. Startup stops
+// before the Validator Clients if a validator key is claimed by more than one module, unless the config allows it; this
+// is checked even if no Validator Client containers are provided, since externally managed ones would load the same
+// keys. Externally managed clients aren't started, but the sequencer still waits for them to be reachable.
+// Errors for a client that couldn't be started are returned as a *ClientBootError naming it.
+func (sp *ServiceProvider) BootClients(ctx context.Context, vcContainers []string) error {
+	var ecContainers []string
+	var bnContainers []string
+	if sp.cfg.IsLocalMode() {
+		ecContainers = []string{sp.cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))}
+		bnContainers = []string{sp.cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))}
+	}
+
+	stages := []bootStage{
+		{
+			name:       executionClientName,
+			containers: ecContainers,
+			checkReady: sp.checkExecutionClientReachable,
+			afterReady: sp.waitForEngineApi,
+		},
+		{
+			name:       beaconNodeName,
+			containers: bnContainers,
+			checkReady: sp.checkBeaconNodeReachable,
+		},
+		{
+			name:        "Validator Client",
+			containers:  vcContainers,
+			beforeStart: sp.checkDuplicateValidators,
+			checkReady: func(ctx context.Context) error {
+				return sp.checkContainersRunning(ctx, vcContainers)
+			},
+		},
+	}
+
+	timeout := sp.cfg.GetStartupTimeout()
+	for _, stage := range stages {
+		err := sp.bootStage(ctx, stage, timeout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gets the names of the Validator Client containers to start with the other clients, sorted by name. Only containers
+// labeled with this instance's project and with a module that's enabled in the config are included, and only if they
+// haven't been started yet or are already running; a VC that was stopped is left alone, since it may have been stopped
+// on purpose.
+func (sp *ServiceProvider) GetValidatorClientContainers(ctx context.Context) ([]string, error) {
+	containers, err := sp.GetDocker().ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", hdconfig.InstanceLabel+"="+sp.cfg.ProjectName.Value)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing containers: %w", err)
+	}
+	names := []string{}
+	for _, listed := range containers {
+		if !sp.cfg.IsModuleEnabled(listed.Labels[hdconfig.ModuleLabel]) {
+			continue
+		}
+		if listed.State != containerStateCreated && listed.State != containerStateRunning {
+			continue
+		}
+		for _, name := range listed.Names {
+			if isValidatorClientContainer(name) {
+				names = append(names, strings.TrimPrefix(name, "/"))
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Starts the containers for a single stage and waits for the client to be ready. Errors are returned as a
+// *ClientBootError naming the client, unless the context was cancelled.
+func (sp *ServiceProvider) bootStage(ctx context.Context, stage bootStage, timeout time.Duration) error {
+	fail := func(err error, notReady bool) error {
+		if ctx.Err() != nil {
+			return err
+		}
+		return &ClientBootError{Client: stage.name, NotReady: notReady, Err: err}
+	}
+	if stage.beforeStart != nil {
+		err := stage.beforeStart(ctx)
+		if err != nil {
+			return fail(fmt.Errorf("can't start the %s: %w", stage.name, err), false)
+		}
+	}
+	for _, id := range stage.containers {
+		err := sp.StartContainer(ctx, id)
+		if err != nil {
+			return fail(fmt.Errorf("error starting %s container [%s]: %w", stage.name, id, err), false)
+		}
+	}
+	err := waitForClient(ctx, stage.name, stage.checkReady, timeout)
+	if err != nil {
+		return fail(err, true)
+	}
+	if stage.afterReady == nil {
+		return nil
+	}
+	err = stage.afterReady(ctx, timeout)
+	if err != nil {
+		return fail(err, errors.Is(err, ErrEngineApiUnreachable))
+	}
+	return nil
+}
+
+// Polls a client until it's ready, returning an error naming it if it doesn't come up within the timeout
+func waitForClient(ctx context.Context, name string, checkReady func(ctx context.Context) error, timeout time.Duration) error {
+	logger, hasLogger := log.FromContext(ctx)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(bootPollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		err := checkReady(waitCtx)
+		if err == nil {
+			if hasLogger {
+				logger.Info("Client is ready", slog.String("client", name))
+			}
+			return nil
+		}
+		if waitCtx.Err() == nil || lastErr == nil {
+			// Keep the client's own error instead of the timeout that cut off the last check
+			lastErr = err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s didn't come up within %s: %w", name, timeout, lastErr)
+		}
+	}
+}
+
+// Checks if the primary Execution Client is accepting RPC requests
+func (sp *ServiceProvider) checkExecutionClientReachable(ctx context.Context) error {
+	_, err := sp.GetEthClient().GetPrimaryClient().ChainID(ctx)
+	return err
+}
+
+// Checks if the primary Beacon Node is accepting API requests
+func (sp *ServiceProvider) checkBeaconNodeReachable(ctx context.Context) error {
+	_, err := sp.GetBeaconClient().GetPrimaryClient().GetSyncStatus(ctx)
+	return err
+}
+
+// Checks that all of the provided containers are running
+func (sp *ServiceProvider) checkContainersRunning(ctx context.Context, ids []string) error {
+	d := sp.GetDocker()
+	for _, id := range ids {
+		info, err := d.ContainerInspect(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error inspecting container [%s]: %w", id, err)
+		}
+		if info.State == nil || !info.State.Running {
+			return fmt.Errorf("container [%s] isn't running", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+var (
+	// The selected client doesn't have the requested feature
+	ErrUnsupportedByClient error = errors.New("the client doesn't support this feature")
+
+	// A builder boost factor is out of range
+	ErrInvalidBuilderBoostFactor error = errors.New("invalid builder boost factor")
+)
+
+// Gets the Validator Client's builder boost factor, which is the percentage it multiplies a builder block's value by
+// before comparing it to a locally built one
+func (sp *ServiceProvider) GetBuilderBoostFactor() uint64 {
+	return sp.cfg.MevBoost.BuilderBoostFactor.Value
+}
+
+// Sets the Validator Client's builder boost factor. It has to be a percentage up to hdconfig.BuilderBoostFactor_MaxPercentage,
+// or hdconfig.BuilderBoostFactor_AlwaysBuilder; returns an error wrapping ErrInvalidBuilderBoostFactor if not, or
+// ErrUnsupportedByClient if the Validator Client doesn't have the setting.
+// If the factor changes, the Validator Client is marked as pending a restart; the config isn't saved to disk.
+func (sp *ServiceProvider) SetBuilderBoostFactor(factor uint64) error {
+	if !hdconfig.IsValidBuilderBoostFactor(factor) {
+		return fmt.Errorf("%w: %d must be between 0 and %d, or %d to always use the builder's block", ErrInvalidBuilderBoostFactor, factor, hdconfig.BuilderBoostFactor_MaxPercentage, hdconfig.BuilderBoostFactor_AlwaysBuilder)
+	}
+	client := sp.cfg.GetSelectedBeaconNode()
+	if _, supported := hdconfig.GetBuilderBoostFactorFlag(client, factor); !supported {
+		return fmt.Errorf("%w: %s doesn't have a builder boost factor setting", ErrUnsupportedByClient, client)
+	}
+
+	param := &sp.cfg.MevBoost.BuilderBoostFactor
+	if param.Value == factor {
+		return nil
+	}
+	param.Value = factor
+	sp.markContainersForRestart(param.AffectsContainers)
+	return nil
+}
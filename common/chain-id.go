@@ -0,0 +1,26 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// The Execution Client is on a different chain than the configured network
+	ErrChainIdMismatch error = errors.New("the Execution Client's chain ID doesn't match the configured network")
+)
+
+// Checks that the Execution Client's chain ID matches the configured network's, returning an error wrapping
+// ErrChainIdMismatch if it doesn't. This only makes a single request, so it's cheap enough to run before sending a transaction.
+func (sp *ServiceProvider) VerifyExecutionChainId(ctx context.Context) error {
+	chainId, err := sp.GetEthClient().ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting Execution Client chain ID: %w", err)
+	}
+	expected := uint64(sp.cfg.GetNetworkResources().ChainID)
+	if !chainId.IsUint64() || chainId.Uint64() != expected {
+		return fmt.Errorf("%w: expected %d for network [%s], but the Execution Client is on %s", ErrChainIdMismatch, expected, sp.cfg.Network.Value, chainId.String())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"context"
+)
+
+// How far the Beacon Node has gotten with a checkpoint sync. After syncing forward from its checkpoint, a node fills in
+// the blocks back to genesis (backfill) and, if it's configured to keep historical states, rebuilds them from genesis.
+type CheckpointSyncProgress struct {
+	// The client name from the node's version string, like `Lighthouse`
+	ClientName string `json:"clientName"`
+
+	// The node's head slot
+	HeadSlot uint64 `json:"headSlot"`
+
+	// How many slots behind the head of the chain the node is
+	SyncDistance uint64 `json:"syncDistance"`
+
+	// True if the node is still syncing forward to the head
+	IsSyncing bool `json:"isSyncing"`
+
+	// True if the node's head hasn't been verified by its Execution Client yet
+	IsOptimistic bool `json:"isOptimistic"`
+
+	// True if the client reports its backfill and state reconstruction; the fields below are only set if it does
+	HasBackfillDetail bool `json:"hasBackfillDetail"`
+
+	// The slot the node was checkpoint synced from, or 0 if it synced from genesis
+	AnchorSlot uint64 `json:"anchorSlot"`
+
+	// The earliest slot the node has the block for
+	OldestBlockSlot uint64 `json:"oldestBlockSlot"`
+
+	// The number of slots of blocks the node still has to backfill to reach genesis
+	BackfillDistance uint64 `json:"backfillDistance"`
+
+	// True if the node has every block back to genesis
+	BackfillComplete bool `json:"backfillComplete"`
+
+	// True if the node has rebuilt every state since genesis. Nodes that aren't configured to keep historical states
+	// never do.
+	StateReconstructionComplete bool `json:"stateReconstructionComplete"`
+}
+
+// Gets the progress of the primary Beacon Node's checkpoint sync. The sync distance and optimistic status come from the
+// standard syncing route; the backfill and state reconstruction come from client-specific routes, which are only
+// available on Lighthouse. For other clients, HasBackfillDetail is false and only the standard fields are set.
+func (sp *ServiceProvider) GetCheckpointSyncProgress(ctx context.Context) (CheckpointSyncProgress, error) {
+	bn := sp.GetBeaconApiClient()
+	syncStatus, err := bn.GetSyncStatus(ctx)
+	if err != nil {
+		return CheckpointSyncProgress{}, err
+	}
+	versionString, err := bn.GetNodeVersion(ctx)
+	if err != nil {
+		return CheckpointSyncProgress{}, err
+	}
+	progress := CheckpointSyncProgress{
+		HeadSlot:     uint64(syncStatus.HeadSlot),
+		SyncDistance: uint64(syncStatus.SyncDistance),
+		IsSyncing:    syncStatus.IsSyncing,
+		IsOptimistic: syncStatus.IsOptimistic,
+	}
+	progress.ClientName, _ = parseBeaconNodeVersion(versionString)
+	if progress.ClientName != "Lighthouse" {
+		return progress, nil
+	}
+
+	anchor, exists, err := bn.GetLighthouseAnchor(ctx)
+	if err != nil {
+		return CheckpointSyncProgress{}, err
+	}
+	if !exists {
+		return progress, nil
+	}
+	progress.HasBackfillDetail = true
+	if anchor == nil {
+		progress.BackfillComplete = true
+		progress.StateReconstructionComplete = true
+		return progress, nil
+	}
+	progress.AnchorSlot = uint64(anchor.AnchorSlot)
+	progress.OldestBlockSlot = uint64(anchor.OldestBlockSlot)
+	progress.BackfillDistance = progress.OldestBlockSlot
+	progress.BackfillComplete = progress.OldestBlockSlot == 0
+	progress.StateReconstructionComplete = anchor.StateUpperLimit <= anchor.StateLowerLimit
+	return progress, nil
+}
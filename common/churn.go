@@ -0,0 +1,258 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/bits"
+	"strconv"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+const (
+	// Consensus spec defaults used when the Beacon Node doesn't report them
+	defaultMinPerEpochChurnLimit               uint64 = 4
+	defaultChurnLimitQuotient                  uint64 = 65536
+	defaultMaxPerEpochActivationChurnLimit     uint64 = 8
+	defaultMinPerEpochChurnLimitElectra        uint64 = 128e9
+	defaultMaxPerEpochActivationExitChurnLimit uint64 = 256e9
+	defaultEffectiveBalanceIncrement           uint64 = 1e9
+	defaultMinActivationBalance                uint64 = 32e9
+
+	// The fork name reported when the head state's fork version isn't one of the forks in the chain spec, like a fork
+	// that's newer than the ones Hyperdrive knows about
+	UnknownForkName string = "UNKNOWN"
+)
+
+// The network's current churn limits and validator queues
+type ChurnInfo struct {
+	// The name of the fork that's currently active, or UnknownForkName if it isn't one Hyperdrive knows about
+	Fork string `json:"fork"`
+
+	// True if Electra or a later fork is active, so the balance-based churn and pending deposit queue apply
+	ElectraActive bool `json:"electraActive"`
+
+	// The epoch of the head state
+	Epoch uint64 `json:"epoch"`
+
+	// The number of validators that are currently active
+	ActiveValidatorCount uint64 `json:"activeValidatorCount"`
+
+	// The total effective balance of the active validators, in gwei. From Electra onwards this is summed from the active
+	// validators, since compounding validators can have up to 2048 ETH; before that the Beacon API doesn't expose it, so
+	// it's estimated at MIN_ACTIVATION_BALANCE per active validator.
+	TotalActiveBalance uint64 `json:"totalActiveBalance"`
+
+	// The number of validators that can be activated per epoch
+	ActivationChurnLimit uint64 `json:"activationChurnLimit"`
+
+	// The number of validators that can exit per epoch
+	ExitChurnLimit uint64 `json:"exitChurnLimit"`
+
+	// The amount of stake (in gwei) that can enter or leave per epoch; only used from Electra onwards
+	BalanceChurnLimit uint64 `json:"balanceChurnLimit"`
+
+	// The number of validators waiting to be activated; from Electra onwards, this is the number of pending deposits
+	ActivationQueueLength uint64 `json:"activationQueueLength"`
+
+	// The total amount of the pending deposits, in gwei; only used from Electra onwards
+	PendingDepositBalance uint64 `json:"pendingDepositBalance"`
+
+	// The number of validators waiting to exit
+	ExitQueueLength uint64 `json:"exitQueueLength"`
+
+	// The number of epochs it will take to clear the activation queue
+	ActivationQueueEpochs uint64 `json:"activationQueueEpochs"`
+
+	// The number of epochs it will take to clear the exit queue
+	ExitQueueEpochs uint64 `json:"exitQueueEpochs"`
+}
+
+// Get the current activation and exit churn limits, along with the length of the activation and exit queues.
+// The active validator count comes from the epoch's committees and the queues come from status-filtered validator
+// queries (or the pending deposit queue on Electra), so the full validator set is never downloaded. On Electra the
+// active validators are downloaded to sum their effective balances, since the balance churn depends on them.
+func (sp *ServiceProvider) GetChurnInfo(ctx context.Context) (ChurnInfo, error) {
+	bn := sp.GetBeaconApiClient()
+
+	// Get the chain state
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return ChurnInfo{}, err
+	}
+	fork, err := bn.GetFork(ctx, "head")
+	if err != nil {
+		return ChurnInfo{}, err
+	}
+	slot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return ChurnInfo{}, err
+	}
+	stateId := strconv.FormatUint(slot, 10)
+	epoch := slot / spec.SlotsPerEpoch
+	info := ChurnInfo{
+		Fork:          getForkName(spec, epoch, fork.Data.CurrentVersion),
+		Epoch:         epoch,
+		ElectraActive: epoch >= spec.ElectraForkEpoch,
+	}
+
+	// Every active validator is assigned to exactly one committee per epoch
+	committees, err := bn.GetCommittees(ctx, stateId, info.Epoch)
+	if err != nil {
+		return ChurnInfo{}, err
+	}
+	for _, committee := range committees {
+		info.ActiveValidatorCount += uint64(len(committee.Validators))
+	}
+	if info.ElectraActive {
+		info.TotalActiveBalance, err = sp.sumActiveEffectiveBalance(ctx, stateId)
+		if err != nil {
+			return ChurnInfo{}, err
+		}
+	} else {
+		info.TotalActiveBalance = info.ActiveValidatorCount * getSpecValue(spec.MinActivationBalance, defaultMinActivationBalance)
+	}
+	info.computeChurnLimits(spec)
+
+	// Get the exit queue
+	exiting, err := bn.GetValidators(ctx, stateId, nil, []string{string(beacon.ValidatorState_ActiveExiting)})
+	if err != nil {
+		return ChurnInfo{}, err
+	}
+	info.ExitQueueLength = uint64(len(exiting))
+	info.ExitQueueEpochs = divideRoundingUp(info.ExitQueueLength, info.ExitChurnLimit)
+
+	// Get the activation queue; Electra moved it into the state's pending deposits, which are processed by balance
+	if info.ElectraActive {
+		deposits, err := bn.GetPendingDeposits(ctx, stateId)
+		if err != nil {
+			return ChurnInfo{}, err
+		}
+		info.ActivationQueueLength = uint64(len(deposits))
+		for _, deposit := range deposits {
+			info.PendingDepositBalance += uint64(deposit.Amount)
+		}
+		info.ActivationQueueEpochs = divideRoundingUp(info.PendingDepositBalance, info.BalanceChurnLimit)
+		return info, nil
+	}
+	pending, err := bn.GetValidators(ctx, stateId, nil, []string{string(beacon.ValidatorState_PendingQueued)})
+	if err != nil {
+		return ChurnInfo{}, err
+	}
+	info.ActivationQueueLength = uint64(len(pending))
+	info.ActivationQueueEpochs = divideRoundingUp(info.ActivationQueueLength, info.ActivationChurnLimit)
+	return info, nil
+}
+
+// Get the total effective balance of the active validators in the head state, in gwei. Compounding validators count
+// with their full effective balance of up to 2048 ETH, so this is the value the Electra balance churn is based on.
+// Before Electra the churn is based on the number of active validators instead, so an error wrapping
+// ErrElectraNotActive is returned; use the active validator count from GetChurnInfo there.
+func (sp *ServiceProvider) GetTotalEffectiveBalance(ctx context.Context) (uint64, error) {
+	electraActive, err := sp.isElectraActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !electraActive {
+		return 0, fmt.Errorf("%w: the churn limits are based on the number of active validators until Electra", ErrElectraNotActive)
+	}
+	slot, _, err := sp.GetBeaconApiClient().GetBlockSlot(ctx, "head")
+	if err != nil {
+		return 0, err
+	}
+	return sp.sumActiveEffectiveBalance(ctx, strconv.FormatUint(slot, 10))
+}
+
+// Sums the effective balances of the validators that are active in the provided state
+func (sp *ServiceProvider) sumActiveEffectiveBalance(ctx context.Context, stateId string) (uint64, error) {
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, stateId, nil, []string{"active"})
+	if err != nil {
+		return 0, fmt.Errorf("error getting active validators: %w", err)
+	}
+	var total uint64
+	for _, validator := range validators {
+		var carry uint64
+		total, carry = bits.Add64(total, uint64(validator.Validator.EffectiveBalance), 0)
+		if carry != 0 {
+			return 0, fmt.Errorf("the total effective balance of the active validators overflowed at validator %s", validator.Index)
+		}
+	}
+	return total, nil
+}
+
+// Computes the churn limits for the active fork, per the consensus spec
+func (info *ChurnInfo) computeChurnLimits(spec BeaconSpec) {
+	minChurnLimit := getSpecValue(spec.MinPerEpochChurnLimit, defaultMinPerEpochChurnLimit)
+	churnLimitQuotient := getSpecValue(spec.ChurnLimitQuotient, defaultChurnLimitQuotient)
+
+	// Electra replaced the validator count limits with a balance limit shared by activations and exits
+	if info.ElectraActive {
+		increment := getSpecValue(spec.EffectiveBalanceIncrement, defaultEffectiveBalanceIncrement)
+		balanceChurn := max(getSpecValue(spec.MinPerEpochChurnLimitElectra, defaultMinPerEpochChurnLimitElectra), info.TotalActiveBalance/churnLimitQuotient)
+		balanceChurn -= balanceChurn % increment
+		info.BalanceChurnLimit = min(getSpecValue(spec.MaxPerEpochActivationExitChurnLimit, defaultMaxPerEpochActivationExitChurnLimit), balanceChurn)
+		info.ActivationChurnLimit = info.BalanceChurnLimit / getSpecValue(spec.MinActivationBalance, defaultMinActivationBalance)
+		info.ExitChurnLimit = info.ActivationChurnLimit
+		return
+	}
+
+	churnLimit := max(minChurnLimit, info.ActiveValidatorCount/churnLimitQuotient)
+	info.ExitChurnLimit = churnLimit
+	info.ActivationChurnLimit = churnLimit
+	if info.Epoch >= spec.DenebForkEpoch {
+		// EIP-7514 capped the activation churn
+		info.ActivationChurnLimit = min(getSpecValue(spec.MaxPerEpochActivationChurnLimit, defaultMaxPerEpochActivationChurnLimit), churnLimit)
+	}
+}
+
+// Gets the name of the fork that's active at the provided epoch, based on the fork epochs in the chain spec. If the head
+// state's fork version isn't one of the spec's, the chain is on a fork Hyperdrive doesn't know about, so
+// UnknownForkName is returned; the epoch comparisons still tell whether the forks it does know about are active.
+func getForkName(spec BeaconSpec, epoch uint64, version []byte) string {
+	forks := []struct {
+		name    string
+		version []byte
+		epoch   uint64
+	}{
+		{"FULU", spec.FuluForkVersion, spec.FuluForkEpoch},
+		{"ELECTRA", spec.ElectraForkVersion, spec.ElectraForkEpoch},
+		{"DENEB", spec.DenebForkVersion, spec.DenebForkEpoch},
+		{"CAPELLA", spec.CapellaForkVersion, spec.CapellaForkEpoch},
+		{"BELLATRIX", spec.BellatrixForkVersion, spec.BellatrixForkEpoch},
+		{"ALTAIR", spec.AltairForkVersion, spec.AltairForkEpoch},
+		{"PHASE0", spec.GenesisForkVersion, 0},
+	}
+	isKnown := false
+	for _, fork := range forks {
+		if len(fork.version) > 0 && bytes.Equal(fork.version, version) {
+			isKnown = true
+			break
+		}
+	}
+	if !isKnown {
+		return UnknownForkName
+	}
+	for _, fork := range forks {
+		if epoch >= fork.epoch {
+			return fork.name
+		}
+	}
+	return UnknownForkName
+}
+
+// Returns the spec value, or the default if the Beacon Node didn't report it
+func getSpecValue(value uint64, defaultValue uint64) uint64 {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// Divides two numbers, rounding up; returns 0 if the divisor is 0
+func divideRoundingUp(numerator uint64, divisor uint64) uint64 {
+	if divisor == 0 {
+		return 0
+	}
+	return (numerator + divisor - 1) / divisor
+}
@@ -0,0 +1,139 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+var (
+	// The confirmation for a data reset didn't match the service being reset
+	ErrResetNotConfirmed error = errors.New("resetting a client's data must be confirmed by passing the service name")
+
+	// The service's container doesn't mount a Docker volume of its own, so there's nothing to reset
+	ErrNoManagedDataVolume error = errors.New("the service doesn't have a managed data volume")
+
+	// The service's data volume is bound to a host directory, which removing the volume wouldn't clear
+	ErrBindMountedDataVolume error = errors.New("the service's data volume is bound to a host directory")
+
+	// The Validator Client's slashing protection couldn't be backed up, so its data can't be reset safely
+	ErrSlashingProtectionNotBackedUp error = errors.New("the Validator Client's slashing protection must be backed up before its data is reset")
+)
+
+// Wipes a client's data by removing its container and Docker-managed data volume, creating a new empty volume with the
+// same settings, and starting the client again, for when its database is corrupted. The confirm string must be the
+// service name, to prevent accidental resets.
+// For the Validator Client, a slashing protection backup is taken first and restored into the new container; the reset
+// is refused if backups aren't enabled or the backup fails.
+func (sp *ServiceProvider) ResetClientData(ctx context.Context, service string, confirm string) error {
+	if confirm != service {
+		return fmt.Errorf("%w (expected [%s], got [%s])", ErrResetNotConfirmed, service, confirm)
+	}
+	id := config.ContainerID(service)
+	name := sp.cfg.GetDockerArtifactName(service)
+	d := sp.GetDocker()
+	info, err := d.ContainerInspect(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error inspecting %s container: %w", id, err)
+	}
+
+	// Find the data volume and make sure removing it will actually clear the data
+	volumeName, err := sp.getManagedDataVolume(info)
+	if err != nil {
+		return fmt.Errorf("error finding %s data volume: %w", id, err)
+	}
+	vol, err := d.VolumeInspect(ctx, volumeName)
+	if err != nil {
+		return fmt.Errorf("error inspecting %s data volume [%s]: %w", id, volumeName, err)
+	}
+	device := getBindMountDevice(&vol)
+	if device != "" {
+		return fmt.Errorf("%w [%s]; remove its contents manually instead", ErrBindMountedDataVolume, device)
+	}
+
+	// Don't take down a VC that can't be started again
+	err = sp.checkDuplicateValidatorsForContainer(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	// Back up the VC's slashing protection
+	isVc := id == config.ContainerID_ValidatorClient
+	var backup SlashingProtectionBackup
+	if isVc {
+		if !sp.isSlashingProtectionBackupEnabled() {
+			return fmt.Errorf("%w, but slashing protection backups aren't enabled", ErrSlashingProtectionNotBackedUp)
+		}
+		backup, err = sp.BackupSlashingProtection(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSlashingProtectionNotBackedUp, err)
+		}
+	}
+
+	// Replace the container, swapping in an empty volume while it's gone
+	containerCfg, hostCfg, networkCfg := getRecreateSettings(info)
+	err = sp.replaceContainer(ctx, id, name, containerCfg, hostCfg, networkCfg, true, func() error {
+		err := d.VolumeRemove(ctx, volumeName, false)
+		if err != nil {
+			return fmt.Errorf("error removing %s data volume [%s]: %w", id, volumeName, err)
+		}
+		_, err = d.VolumeCreate(ctx, volume.CreateOptions{
+			Name:       volumeName,
+			Driver:     vol.Driver,
+			DriverOpts: vol.Options,
+			Labels:     vol.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating %s data volume [%s]: %w", id, volumeName, err)
+		}
+
+		// The new volume is owned by root, so give it back to the service's user
+		user := sp.cfg.ContainerUser.GetUser(id)
+		if user != "" {
+			err = sp.setVolumeOwner(ctx, id, containerCfg.Image, volumeName, user)
+			if err != nil {
+				return fmt.Errorf("error setting owner of %s data volume [%s]: %w", id, volumeName, err)
+			}
+		}
+		return nil
+	})
+	if !isVc {
+		return err
+	}
+	return sp.restoreAfterReplace(ctx, id, backup, err)
+}
+
+// Gets the name of the single Hyperdrive-managed Docker volume a container mounts, from either its active mounts or the
+// mounts it was created with
+func (sp *ServiceProvider) getManagedDataVolume(info types.ContainerJSON) (string, error) {
+	prefix := sp.cfg.GetDockerArtifactName("")
+	names := []string{}
+	addVolume := func(mountType mount.Type, name string) {
+		if mountType == mount.TypeVolume && strings.HasPrefix(name, prefix) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, mountPoint := range info.Mounts {
+		addVolume(mountPoint.Type, mountPoint.Name)
+	}
+	if info.ContainerJSONBase != nil && info.HostConfig != nil {
+		for _, hostMount := range info.HostConfig.Mounts {
+			addVolume(hostMount.Type, hostMount.Source)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return "", ErrNoManagedDataVolume
+	case 1:
+		return names[0], nil
+	default:
+		return "", fmt.Errorf("the container mounts more than one managed volume (%s)", strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Issues a few cheap reads against the Execution Client and Beacon Node so their connection pools are open, and the
+// Beacon spec is cached, before the first real operation needs them. Reads are made in parallel; if any of them fail,
+// the failures are returned together and the next call tries again. Once a warm-up succeeds, later calls don't do
+// anything.
+func (sp *ServiceProvider) WarmUpClients(ctx context.Context) error {
+	sp.warmUpLock.Lock()
+	defer sp.warmUpLock.Unlock()
+	if sp.clientsWarmedUp {
+		return nil
+	}
+
+	reads := []struct {
+		name string
+		read func(ctx context.Context) error
+	}{
+		{
+			name: "Execution Client chain ID",
+			read: func(ctx context.Context) error {
+				_, err := sp.GetEthClient().ChainID(ctx)
+				return err
+			},
+		}, {
+			name: "Execution Client block number",
+			read: func(ctx context.Context) error {
+				_, err := sp.GetEthClient().BlockNumber(ctx)
+				return err
+			},
+		}, {
+			name: "Beacon Node sync status",
+			read: func(ctx context.Context) error {
+				_, err := sp.GetBeaconClient().GetSyncStatus(ctx)
+				return err
+			},
+		}, {
+			// These go through the Beacon API client, which has its own connections
+			name: "Beacon spec",
+			read: func(ctx context.Context) error {
+				_, err := sp.GetBeaconSpec(ctx)
+				return err
+			},
+		}, {
+			name: "Beacon genesis",
+			read: func(ctx context.Context) error {
+				_, err := sp.GetBeaconApiClient().GetGenesis(ctx)
+				return err
+			},
+		},
+	}
+
+	errs := make([]error, len(reads))
+	wg := &sync.WaitGroup{}
+	for i := range reads {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := reads[i].read(ctx)
+			if err != nil {
+				errs[i] = fmt.Errorf("error warming up %s: %w", reads[i].name, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err == nil {
+		sp.clientsWarmedUp = true
+	}
+	return err
+}
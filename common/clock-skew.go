@@ -0,0 +1,84 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	// How many times to sample the head when checking for clock skew, and how long to wait between samples
+	clockSkewSamples        int           = 3
+	clockSkewSampleInterval time.Duration = 100 * time.Millisecond
+)
+
+// Estimates how far the system clock is from the Beacon Chain's by checking where the current time falls relative to
+// the Beacon Node's head slot. A positive skew means the local clock is ahead; a negative one means it's behind.
+// A head block normally arrives partway through its slot, so a clock that's still inside the head slot counts as having
+// no skew, and the skew is how far outside of the slot it is otherwise. This can't detect skew smaller than the time left
+// in the slot, and a missed block looks like the clock running ahead, so the head is sampled a few times and the median
+// is used.
+// If the skew is more than half a slot, this logs a warning, since validators will miss duties.
+func (sp *ServiceProvider) CheckClockSkew(ctx context.Context) (time.Duration, error) {
+	bn := sp.GetBeaconApiClient()
+	syncStatus, err := bn.GetSyncStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if syncStatus.IsSyncing {
+		return 0, errors.New("the Beacon Node is syncing, so its head can't be compared with the clock")
+	}
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	genesisTime, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	slotDuration := time.Duration(spec.SecondsPerSlot) * time.Second
+
+	samples := make([]time.Duration, 0, clockSkewSamples)
+	for i := 0; i < clockSkewSamples; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(clockSkewSampleInterval):
+			}
+		}
+
+		// Use the middle of the request as the local time the head was read at
+		start := time.Now()
+		headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+		if err != nil {
+			return 0, fmt.Errorf("error getting head slot: %w", err)
+		}
+		now := start.Add(time.Since(start) / 2)
+
+		slotStart := genesisTime.Add(time.Duration(headSlot) * slotDuration)
+		offset := now.Sub(slotStart)
+		switch {
+		case offset < 0:
+			samples = append(samples, offset)
+		case offset >= slotDuration:
+			samples = append(samples, offset-slotDuration)
+		default:
+			samples = append(samples, 0)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	skew := samples[len(samples)/2]
+
+	if logger, hasLogger := log.FromContext(ctx); hasLogger && skew.Abs() > slotDuration/2 {
+		logger.Warn("The system clock is more than half a slot off from the Beacon Chain; validators will miss duties until it's fixed", slog.Duration("skew", skew))
+	}
+	return skew, nil
+}
@@ -0,0 +1,227 @@
+package common
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+var (
+	// There isn't a config snapshot with the requested ID in the history
+	ErrConfigSnapshotNotFound error = errors.New("there isn't a config snapshot with that ID")
+)
+
+// How a setting changed between two config snapshots
+type ConfigChangeType string
+
+const (
+	// The setting is only in the newer snapshot
+	ConfigChangeType_Added ConfigChangeType = "added"
+
+	// The setting is only in the older snapshot
+	ConfigChangeType_Removed ConfigChangeType = "removed"
+
+	// The setting is in both snapshots with different values
+	ConfigChangeType_Changed ConfigChangeType = "changed"
+)
+
+// A copy of the config at a point in time, with its secrets redacted
+type ConfigSnapshot struct {
+	// The snapshot's ID, which is its hash
+	ID string `json:"id"`
+
+	// When the snapshot was recorded
+	Timestamp time.Time `json:"timestamp"`
+
+	// The version of the daemon that recorded the snapshot
+	DaemonVersion string `json:"daemonVersion"`
+
+	// The SHA-256 hash of the redacted config, in hex, so identical configs have the same hash
+	Hash string `json:"hash"`
+
+	// The serialized config with its secrets redacted
+	Config map[string]any `json:"config"`
+}
+
+// A setting that differs between two config snapshots
+type ConfigChange struct {
+	// The setting's path in the serialized config, with each level separated by a dot
+	Path string `json:"path"`
+
+	// How the setting changed
+	Type ConfigChangeType `json:"type"`
+
+	// The setting's value in the older snapshot; empty if it was added
+	OldValue string `json:"oldValue,omitempty"`
+
+	// The setting's value in the newer snapshot; empty if it was removed
+	NewValue string `json:"newValue,omitempty"`
+}
+
+// Appends a snapshot of the current config to the config history journal in the user directory, for an audit trail of
+// changes. The snapshot is taken from the saved settings file, or the config the daemon was started with if it hasn't
+// been saved. Secrets are redacted the same way they are in diagnostics bundles before the snapshot is hashed or written,
+// so the journal is safe to share.
+func (sp *ServiceProvider) RecordConfigSnapshot() error {
+	cfg := sp.cfg
+	savedCfg, err := loadConfigFromFile(filepath.Join(sp.userDir, hdconfig.ConfigFilename))
+	if err != nil {
+		return fmt.Errorf("error loading saved config: %w", err)
+	}
+	if savedCfg != nil {
+		cfg = savedCfg
+	}
+
+	// Round-trip the redacted config through JSON so it's hashed exactly as it's stored
+	configBytes, err := json.Marshal(redactDiagnosticsValue("", cfg.Serialize(nil, false)))
+	if err != nil {
+		return fmt.Errorf("error serializing config: %w", err)
+	}
+	var redacted map[string]any
+	err = json.Unmarshal(configBytes, &redacted)
+	if err != nil {
+		return fmt.Errorf("error deserializing redacted config: %w", err)
+	}
+	hash := sha256.Sum256(configBytes)
+	snapshot := ConfigSnapshot{
+		ID:            hex.EncodeToString(hash[:]),
+		Timestamp:     time.Now().UTC(),
+		DaemonVersion: shared.HyperdriveVersion,
+		Hash:          hex.EncodeToString(hash[:]),
+		Config:        redacted,
+	}
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error serializing config snapshot: %w", err)
+	}
+
+	sp.configHistoryLock.Lock()
+	defer sp.configHistoryLock.Unlock()
+	path := filepath.Join(sp.userDir, hdconfig.ConfigHistoryFilename)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening config history [%s]: %w", path, err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("error writing config history [%s]: %w", path, err)
+	}
+	return nil
+}
+
+// Gets every config snapshot in the history journal, oldest first
+func (sp *ServiceProvider) GetConfigHistory() ([]ConfigSnapshot, error) {
+	sp.configHistoryLock.Lock()
+	defer sp.configHistoryLock.Unlock()
+
+	snapshots := []ConfigSnapshot{}
+	path := filepath.Join(sp.userDir, hdconfig.ConfigHistoryFilename)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening config history [%s]: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var snapshot ConfigSnapshot
+		err = json.Unmarshal(scanner.Bytes(), &snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing line %d of config history [%s]: %w", lineNumber, path, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading config history [%s]: %w", path, err)
+	}
+	return snapshots, nil
+}
+
+// Compares two recorded config snapshots by ID, returning the settings that changed from a to b sorted by path.
+// Returns an error wrapping ErrConfigSnapshotNotFound if either one isn't in the history.
+func (sp *ServiceProvider) DiffConfigSnapshots(a string, b string) ([]ConfigChange, error) {
+	snapshots, err := sp.GetConfigHistory()
+	if err != nil {
+		return nil, err
+	}
+	find := func(id string) (ConfigSnapshot, error) {
+		for _, snapshot := range snapshots {
+			if snapshot.ID == id {
+				return snapshot, nil
+			}
+		}
+		return ConfigSnapshot{}, fmt.Errorf("%w: [%s]", ErrConfigSnapshotNotFound, id)
+	}
+	older, err := find(a)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := find(b)
+	if err != nil {
+		return nil, err
+	}
+
+	oldValues := map[string]string{}
+	flattenConfigSnapshot("", older.Config, oldValues)
+	newValues := map[string]string{}
+	flattenConfigSnapshot("", newer.Config, newValues)
+	changes := []ConfigChange{}
+	for path, oldValue := range oldValues {
+		newValue, exists := newValues[path]
+		switch {
+		case !exists:
+			changes = append(changes, ConfigChange{Path: path, Type: ConfigChangeType_Removed, OldValue: oldValue})
+		case newValue != oldValue:
+			changes = append(changes, ConfigChange{Path: path, Type: ConfigChangeType_Changed, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for path, newValue := range newValues {
+		if _, exists := oldValues[path]; !exists {
+			changes = append(changes, ConfigChange{Path: path, Type: ConfigChangeType_Added, NewValue: newValue})
+		}
+	}
+	sort.Slice(changes, func(i int, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// Flattens a serialized config into its settings, keyed by their dotted paths. Lists are kept whole, since their
+// elements don't have stable names.
+func flattenConfigSnapshot(prefix string, value any, settings map[string]string) {
+	if section, isMap := value.(map[string]any); isMap {
+		for key, child := range section {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenConfigSnapshot(path, child, settings)
+		}
+		return
+	}
+	if stringValue, isString := value.(string); isString {
+		settings[prefix] = stringValue
+		return
+	}
+	encoded, _ := json.Marshal(value)
+	settings[prefix] = string(encoded)
+}
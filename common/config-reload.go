@@ -0,0 +1,205 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// A setting that changed when the config was reloaded
+type ReloadedSetting struct {
+	// The setting's path in the config, with each section separated by a dot
+	Path string `json:"path"`
+
+	// The setting's value before the reload, redacted if it's a secret; empty for sections that are compared as a whole,
+	// like the extra environment variables
+	OldValue string `json:"oldValue,omitempty"`
+
+	// The setting's value in the reloaded config, redacted if it's a secret
+	NewValue string `json:"newValue,omitempty"`
+
+	// The containers that have to be restarted for the change to take effect; empty if it was applied in place
+	Containers []config.ContainerID `json:"containers,omitempty"`
+}
+
+// The outcome of reloading the config
+type ReloadResult struct {
+	// The settings that were applied in place
+	Applied []ReloadedSetting `json:"applied"`
+
+	// The settings that changed on disk but only take effect once their containers are restarted. They're left as they
+	// were in the running daemon, and their containers are marked as pending a restart.
+	RestartRequired []ReloadedSetting `json:"restartRequired"`
+}
+
+// A scheduled task whose interval comes from a config setting, so reloading the config can change it
+type configTask struct {
+	name  string
+	param *config.Parameter[uint64]
+}
+
+// Filters log records by a level that can be changed while the logger is in use. The standard handlers only check
+// their own level in Enabled, so wrapping one with this replaces its level entirely.
+type levelHandler struct {
+	slog.Handler
+	level *slog.LevelVar
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		level:   h.level,
+	}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{
+		Handler: h.Handler.WithGroup(name),
+		level:   h.level,
+	}
+}
+
+// Schedules a task that runs every interval seconds, where interval is one of the provider's config settings. Reloading
+// the config applies changes to the setting to the task right away.
+func (sp *ServiceProvider) ScheduleConfigTask(name string, interval *config.Parameter[uint64], jitter time.Duration, fn func(ctx context.Context)) error {
+	sp.configTaskLock.Lock()
+	defer sp.configTaskLock.Unlock()
+	err := sp.scheduler.Schedule(name, time.Duration(interval.Value)*time.Second, jitter, fn)
+	if err != nil {
+		return err
+	}
+	sp.configTasks = append(sp.configTasks, configTask{
+		name:  name,
+		param: interval,
+	})
+	return nil
+}
+
+// Re-reads the config from the user directory and applies the settings that can change while the daemon is running:
+// the log level, the thresholds the health checks use, the alert settings, and the intervals of tasks scheduled with
+// ScheduleConfigTask. Every other changed setting is listed in the result as needing a restart of the containers it
+// affects. The reloaded config is validated before anything is applied, so an invalid or unreadable config leaves the
+// running daemon exactly as it was.
+func (sp *ServiceProvider) ReloadConfig(ctx context.Context) (ReloadResult, error) {
+	sp.configReloadLock.Lock()
+	defer sp.configReloadLock.Unlock()
+	sp.configTaskLock.Lock()
+	defer sp.configTaskLock.Unlock()
+
+	path := filepath.Join(sp.userDir, hdconfig.ConfigFilename)
+	newCfg, err := loadConfigFromFile(path)
+	if err != nil {
+		return ReloadResult{}, fmt.Errorf("error loading config: %w", err)
+	}
+	if newCfg == nil {
+		return ReloadResult{}, fmt.Errorf("hyperdrive config settings file [%s] not found", path)
+	}
+	if errs := newCfg.Validate(); len(errs) > 0 {
+		return ReloadResult{}, fmt.Errorf("the reloaded config is invalid: %s", strings.Join(errs, " "))
+	}
+
+	// Work out what changed
+	live := map[config.IParameter]bool{}
+	for _, param := range sp.cfg.GetLiveParameters() {
+		live[param] = true
+	}
+	for _, task := range sp.configTasks {
+		live[task.param] = true
+	}
+	oldParams := map[string]config.IParameter{}
+	newParams := map[string]config.IParameter{}
+	getConfigParameters(sp.cfg, "", oldParams)
+	getConfigParameters(newCfg, "", newParams)
+	paths := make([]string, 0, len(oldParams))
+	for path := range oldParams {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	result := ReloadResult{
+		Applied:         []ReloadedSetting{},
+		RestartRequired: []ReloadedSetting{},
+	}
+	applied := map[config.IParameter]config.IParameter{}
+	for _, path := range paths {
+		oldParam := oldParams[path]
+		newParam, exists := newParams[path]
+		if !exists || oldParam.String() == newParam.String() {
+			continue
+		}
+		id := oldParam.GetCommon().ID
+		setting := ReloadedSetting{
+			Path:     path,
+			OldValue: redactDiagnosticsValue(id, oldParam.String()).(string),
+			NewValue: redactDiagnosticsValue(id, newParam.String()).(string),
+		}
+		if live[oldParam] {
+			applied[oldParam] = newParam
+			result.Applied = append(result.Applied, setting)
+			continue
+		}
+		setting.Containers = oldParam.GetCommon().AffectsContainers
+		result.RestartRequired = append(result.RestartRequired, setting)
+	}
+	if !reflect.DeepEqual(sp.cfg.ExtraEnv.Serialize(), newCfg.ExtraEnv.Serialize()) {
+		result.RestartRequired = append(result.RestartRequired, ReloadedSetting{Path: "extraEnv"})
+	}
+	if !reflect.DeepEqual(sp.cfg.ExtraMounts.Serialize(), newCfg.ExtraMounts.Serialize()) {
+		result.RestartRequired = append(result.RestartRequired, ReloadedSetting{Path: "extraMounts"})
+	}
+	if !reflect.DeepEqual(sp.cfg.Modules, newCfg.Modules) {
+		result.RestartRequired = append(result.RestartRequired, ReloadedSetting{Path: "modules"})
+	}
+
+	// Change the task intervals first, since they're the only part that can fail; if one does, the ones already changed
+	// are put back
+	changedTasks := []configTask{}
+	for _, task := range sp.configTasks {
+		newParam, exists := applied[task.param]
+		if !exists {
+			continue
+		}
+		interval := time.Duration(newParam.GetValueAsAny().(uint64)) * time.Second
+		err = sp.scheduler.SetInterval(task.name, interval)
+		if err != nil {
+			for _, changed := range changedTasks {
+				_ = sp.scheduler.SetInterval(changed.name, time.Duration(changed.param.Value)*time.Second)
+			}
+			return ReloadResult{}, fmt.Errorf("error changing the interval of task [%s]: %w", task.name, err)
+		}
+		changedTasks = append(changedTasks, task)
+	}
+
+	// Apply the rest in place
+	for oldParam, newParam := range applied {
+		oldParam.SetValue(newParam.GetValueAsAny())
+	}
+	sp.logLevel.Set(sp.cfg.Logging.Level.Value)
+	sp.alertManager.setEmailSink(sp.cfg.Alerts)
+	for _, setting := range result.RestartRequired {
+		sp.markContainersForRestart(setting.Containers)
+	}
+	return result, nil
+}
+
+// Adds every parameter in a config section and its subsections to the map, keyed by its path in the config
+func getConfigParameters(section config.IConfigSection, prefix string, params map[string]config.IParameter) {
+	for _, param := range section.GetParameters() {
+		params[prefix+param.GetCommon().ID] = param
+	}
+	for id, subconfig := range section.GetSubconfigs() {
+		getConfigParameters(subconfig, prefix+id+".", params)
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// The source and target of a consolidation are the same validator
+	ErrSelfConsolidation error = errors.New("a validator can't be consolidated into itself")
+
+	// The target of a consolidation doesn't have compounding credentials, so it can't take on the source's balance
+	ErrTargetNotCompounding error = errors.New("the target validator doesn't have compounding withdrawal credentials")
+)
+
+// Submits a consolidation request to the EIP-7251 system contract with the node's transaction signer, which moves the
+// source validator's balance into the target validator once the source has exited. Returns the hash of the submitted
+// transaction.
+// Both validators must be active with 0x01 or 0x02 withdrawal credentials pointing to the node's address, and the target
+// must have compounding (0x02) credentials.
+func (sp *ServiceProvider) SubmitConsolidation(ctx context.Context, sourcePubkey beacon.ValidatorPubkey, targetPubkey beacon.ValidatorPubkey) (ethcommon.Hash, error) {
+	err := sp.RequireWalletReady()
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	if sourcePubkey == targetPubkey {
+		return ethcommon.Hash{}, fmt.Errorf("%w: %s", ErrSelfConsolidation, sourcePubkey.HexWithPrefix())
+	}
+	electraActive, err := sp.isElectraActive(ctx)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	if !electraActive {
+		return ethcommon.Hash{}, fmt.Errorf("%w: consolidation requests are only available from Electra onwards", ErrElectraNotActive)
+	}
+
+	_, err = sp.getNodeValidator(ctx, sourcePubkey)
+	if err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("error checking source validator: %w", err)
+	}
+	target, err := sp.getNodeValidator(ctx, targetPubkey)
+	if err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("error checking target validator: %w", err)
+	}
+	if target.CredentialType != WithdrawalCredentialType_Compounding {
+		return ethcommon.Hash{}, fmt.Errorf("%w: %s has type 0x%02x credentials", ErrTargetNotCompounding, targetPubkey.HexWithPrefix(), byte(target.CredentialType))
+	}
+
+	// The request is the source pubkey followed by the target pubkey
+	data := make([]byte, 0, 2*beacon.ValidatorPubkeyLength)
+	data = append(data, sourcePubkey[:]...)
+	data = append(data, targetPubkey[:]...)
+	return sp.submitExecutionRequest(ctx, ConsolidationRequestPredeployAddress, data)
+}
@@ -0,0 +1,389 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The suffix added to a container's name while it's being replaced
+	replacedContainerSuffix string = "_replaced"
+)
+
+// Runs a Docker container lifecycle operation (such as a create, start, or stop) once one of the configured
+// concurrency slots is free, so mass restarts don't overwhelm the host
+func (sp *ServiceProvider) RunContainerOp(ctx context.Context, op func() error) error {
+	select {
+	case sp.containerOpSemaphore <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() {
+		<-sp.containerOpSemaphore
+	}()
+	return op()
+}
+
+// Starts the container with the provided ID. Validator Clients aren't started if a validator key is claimed by more
+// than one module, unless the config allows it.
+func (sp *ServiceProvider) StartContainer(ctx context.Context, id string) error {
+	err := sp.checkDuplicateValidatorsForContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	return sp.RunContainerOp(ctx, func() error {
+		return sp.GetDocker().ContainerStart(ctx, id, container.StartOptions{})
+	})
+}
+
+// Stops the container with the provided ID
+func (sp *ServiceProvider) StopContainer(ctx context.Context, id string) error {
+	return sp.RunContainerOp(ctx, func() error {
+		return sp.GetDocker().ContainerStop(ctx, id, container.StopOptions{})
+	})
+}
+
+// Restarts the container with the provided ID. Like StartContainer, Validator Clients aren't restarted if a validator
+// key is claimed by more than one module, unless the config allows it.
+func (sp *ServiceProvider) RestartContainer(ctx context.Context, id string) error {
+	err := sp.checkDuplicateValidatorsForContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	return sp.RunContainerOp(ctx, func() error {
+		return sp.GetDocker().ContainerRestart(ctx, id, container.StopOptions{})
+	})
+}
+
+// Creates the container for a Hyperdrive service, merging the user's extra environment variables for that service
+// into the provided config. Variables already set in the config take precedence over the extra ones.
+// The names of the extra variables are recorded in the container's ExtraEnvLabel so RecreateContainer can swap them out later.
+// The container is labeled with the project name in InstanceLabel so ListManagedContainers can find it.
+// Services with a configured restart policy have it set in the host config, replacing the provided one.
+// Services with extra mounts have them added to the host config's mounts; their container paths are recorded in the
+// container's ExtraMountsLabel. Extra mounts can't overlap with the mounts in the provided host config.
+// On a custom network, the genesis artifacts the service needs are mounted read-only and referenced by environment
+// variables that take precedence over the extra ones.
+// Services with a configured user run as that user, and any of their managed data volumes that don't exist yet are
+// created up front and given to the user.
+// Once it's created, the service is no longer pending a restart.
+func (sp *ServiceProvider) CreateContainer(ctx context.Context, id config.ContainerID, containerCfg *container.Config, hostCfg *container.HostConfig, networkCfg *network.NetworkingConfig) (container.CreateResponse, error) {
+	extraEnv, err := sp.cfg.ExtraEnv.GetEnv(id)
+	if err != nil {
+		return container.CreateResponse{}, err
+	}
+	finalCfg := *containerCfg
+	genesisHostCfg := sp.applyCustomGenesis(id, &finalCfg, hostCfg)
+	var extraNames []string
+	finalCfg.Env, extraNames = hdconfig.MergeContainerEnv(finalCfg.Env, extraEnv)
+	finalCfg.Labels = maps.Clone(containerCfg.Labels)
+	if finalCfg.Labels == nil {
+		finalCfg.Labels = map[string]string{}
+	}
+	finalCfg.Labels[hdconfig.InstanceLabel] = sp.cfg.ProjectName.Value
+	if len(extraNames) > 0 {
+		finalCfg.Labels[hdconfig.ExtraEnvLabel] = strings.Join(extraNames, ",")
+	} else {
+		delete(finalCfg.Labels, hdconfig.ExtraEnvLabel)
+	}
+	finalHostCfg := sp.applyRestartPolicy(id, genesisHostCfg)
+	finalHostCfg, err = sp.applyExtraMounts(id, finalHostCfg, finalCfg.Labels)
+	if err != nil {
+		return container.CreateResponse{}, err
+	}
+	user := sp.cfg.ContainerUser.GetUser(id)
+	if user != "" {
+		finalCfg.User = user
+		finalCfg.Labels[hdconfig.ContainerUserLabel] = user
+		err = sp.prepareDataVolumes(ctx, id, finalCfg.Image, finalHostCfg, user)
+		if err != nil {
+			return container.CreateResponse{}, err
+		}
+	} else {
+		delete(finalCfg.Labels, hdconfig.ContainerUserLabel)
+	}
+
+	var response container.CreateResponse
+	err = sp.RunContainerOp(ctx, func() error {
+		var err error
+		response, err = sp.GetDocker().ContainerCreate(ctx, &finalCfg, finalHostCfg, networkCfg, nil, sp.cfg.GetDockerArtifactName(string(id)))
+		return err
+	})
+	if err != nil {
+		return container.CreateResponse{}, fmt.Errorf("error creating %s container: %w", id, err)
+	}
+
+	// The new container has the current settings
+	sp.pendingRestartLock.Lock()
+	delete(sp.pendingRestarts, id)
+	sp.pendingRestartLock.Unlock()
+	return response, nil
+}
+
+// Gets the service containers that need to be recreated because their settings were changed at runtime, sorted by ID
+func (sp *ServiceProvider) GetContainersPendingRestart() []config.ContainerID {
+	sp.pendingRestartLock.Lock()
+	defer sp.pendingRestartLock.Unlock()
+	ids := make([]config.ContainerID, 0, len(sp.pendingRestarts))
+	for id := range sp.pendingRestarts {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Marks the service containers a changed setting affects as needing to be recreated
+func (sp *ServiceProvider) markContainersForRestart(ids []config.ContainerID) {
+	sp.pendingRestartLock.Lock()
+	defer sp.pendingRestartLock.Unlock()
+	for _, id := range ids {
+		sp.pendingRestarts[id] = true
+	}
+}
+
+// Gets a copy of the host config with the service's configured restart policy, or the original if the service doesn't have one
+func (sp *ServiceProvider) applyRestartPolicy(id config.ContainerID, hostCfg *container.HostConfig) *container.HostConfig {
+	policy := sp.cfg.RestartPolicy.GetServicePolicy(id)
+	if policy == nil {
+		return hostCfg
+	}
+	finalHostCfg := container.HostConfig{}
+	if hostCfg != nil {
+		finalHostCfg = *hostCfg
+	}
+	finalHostCfg.RestartPolicy = container.RestartPolicy{
+		Name:              container.RestartPolicyMode(policy.Policy.Value),
+		MaximumRetryCount: int(policy.MaxRetries.Value),
+	}
+	return &finalHostCfg
+}
+
+// Adds the service's extra mounts to a copy of the host config and records their container paths in the labels, or
+// returns the original if the service doesn't have any
+func (sp *ServiceProvider) applyExtraMounts(id config.ContainerID, hostCfg *container.HostConfig, labels map[string]string) (*container.HostConfig, error) {
+	extraMounts := sp.cfg.ExtraMounts.GetMounts(id)
+	if len(extraMounts) == 0 {
+		delete(labels, hdconfig.ExtraMountsLabel)
+		return hostCfg, nil
+	}
+	finalHostCfg := container.HostConfig{}
+	if hostCfg != nil {
+		finalHostCfg = *hostCfg
+	}
+
+	// Make sure they don't shadow anything Hyperdrive mounts itself
+	managedTargets := make([]string, 0, len(finalHostCfg.Mounts)+len(finalHostCfg.Binds))
+	for _, managed := range finalHostCfg.Mounts {
+		managedTargets = append(managedTargets, managed.Target)
+	}
+	for _, bind := range finalHostCfg.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) > 1 {
+			managedTargets = append(managedTargets, parts[1])
+		}
+	}
+	targets := make([]string, 0, len(extraMounts))
+	mounts := slices.Clone(finalHostCfg.Mounts)
+	for _, extra := range extraMounts {
+		for _, target := range managedTargets {
+			if hdconfig.PathsOverlap(extra.ContainerPath, target) {
+				return nil, fmt.Errorf("the %s extra mount at [%s] overlaps with its managed mount at [%s]", id, extra.ContainerPath, target)
+			}
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   extra.HostPath,
+			Target:   extra.ContainerPath,
+			ReadOnly: extra.ReadOnly,
+		})
+		targets = append(targets, extra.ContainerPath)
+	}
+	finalHostCfg.Mounts = mounts
+	label, err := json.Marshal(targets)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing %s extra mount paths: %w", id, err)
+	}
+	labels[hdconfig.ExtraMountsLabel] = string(label)
+	return &finalHostCfg, nil
+}
+
+// Gets a copy of a container's host config without the extra mounts listed in its ExtraMountsLabel
+func removeExtraMounts(hostCfg *container.HostConfig, labels map[string]string) *container.HostConfig {
+	label, exists := labels[hdconfig.ExtraMountsLabel]
+	if hostCfg == nil || !exists {
+		return hostCfg
+	}
+	var targets []string
+	if json.Unmarshal([]byte(label), &targets) != nil {
+		return hostCfg
+	}
+	finalHostCfg := *hostCfg
+	finalHostCfg.Mounts = make([]mount.Mount, 0, len(hostCfg.Mounts))
+	for _, hostMount := range hostCfg.Mounts {
+		if hostMount.Type != mount.TypeBind || !slices.Contains(targets, hostMount.Target) {
+			finalHostCfg.Mounts = append(finalHostCfg.Mounts, hostMount)
+		}
+	}
+	return &finalHostCfg
+}
+
+// Recreates the container for a Hyperdrive service with the same settings, so changes to the user's extra environment
+// variables and mounts take effect. The variables and mounts from its last creation are replaced with the current
+// ones; if it was running, it's started again afterwards.
+// If a running Validator Client is recreated, its slashing protection is backed up first and restored into the new
+// container, or restored anyway if the new container fails to start.
+func (sp *ServiceProvider) RecreateContainer(ctx context.Context, id config.ContainerID) error {
+	// Get the existing container's settings
+	name := sp.cfg.GetDockerArtifactName(string(id))
+	info, err := sp.GetDocker().ContainerInspect(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error inspecting %s container: %w", id, err)
+	}
+	containerCfg, hostCfg, networkCfg := getRecreateSettings(info)
+	wasRunning := info.State != nil && info.State.Running
+
+	// Don't take down a running VC that can't be started again
+	if wasRunning {
+		err = sp.checkDuplicateValidatorsForContainer(ctx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Back up the VC's slashing protection
+	if id != config.ContainerID_ValidatorClient || !wasRunning || !sp.isSlashingProtectionBackupEnabled() {
+		return sp.replaceContainer(ctx, id, name, containerCfg, hostCfg, networkCfg, wasRunning, nil)
+	}
+	backup, err := sp.BackupSlashingProtection(ctx)
+	if err != nil {
+		return fmt.Errorf("error backing up slashing protection before recreating the %s container: %w", id, err)
+	}
+
+	// Replace it
+	err = sp.replaceContainer(ctx, id, name, containerCfg, hostCfg, networkCfg, wasRunning, nil)
+	return sp.restoreAfterReplace(ctx, id, backup, err)
+}
+
+// Gets the settings to create a new copy of an existing container with, minus the extra environment variables, extra
+// mounts, and configured user from its last creation
+func getRecreateSettings(info types.ContainerJSON) (*container.Config, *container.HostConfig, *network.NetworkingConfig) {
+	containerCfg := container.Config{}
+	if info.Config != nil {
+		containerCfg = *info.Config
+	}
+	containerCfg.Env = hdconfig.RemoveExtraEnv(containerCfg.Env, containerCfg.Labels[hdconfig.ExtraEnvLabel])
+	if _, hasUser := containerCfg.Labels[hdconfig.ContainerUserLabel]; hasUser {
+		containerCfg.User = ""
+	}
+	var hostCfg *container.HostConfig
+	if info.ContainerJSONBase != nil {
+		hostCfg = removeExtraMounts(info.HostConfig, containerCfg.Labels)
+	}
+	networkCfg := &network.NetworkingConfig{}
+	if info.NetworkSettings != nil {
+		networkCfg.EndpointsConfig = info.NetworkSettings.Networks
+	}
+	return &containerCfg, hostCfg, networkCfg
+}
+
+// Replaces a container with a new one created from the provided settings, starting it if requested. The old container
+// is renamed out of the way instead of being removed, and only removed once the new one has been created; if creating
+// the new one fails, the old one is renamed back and started again if requested.
+// If provided, beforeCreate is run once the old container is gone. Since it may need the old container removed (e.g. to
+// swap out its volumes), the old container is removed before it runs and can't be restored in that case.
+func (sp *ServiceProvider) replaceContainer(ctx context.Context, id config.ContainerID, name string, containerCfg *container.Config, hostCfg *container.HostConfig, networkCfg *network.NetworkingConfig, start bool, beforeCreate func() error) error {
+	d := sp.GetDocker()
+	err := sp.StopContainer(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error stopping %s container: %w", id, err)
+	}
+	oldName := name + replacedContainerSuffix
+	if beforeCreate == nil {
+		err = sp.RunContainerOp(ctx, func() error {
+			return d.ContainerRename(ctx, name, oldName)
+		})
+		if err != nil {
+			return fmt.Errorf("error moving the old %s container out of the way: %w", id, err)
+		}
+	} else {
+		err = sp.RunContainerOp(ctx, func() error {
+			return d.ContainerRemove(ctx, name, container.RemoveOptions{})
+		})
+		if err != nil {
+			return fmt.Errorf("error removing %s container: %w", id, err)
+		}
+		err = beforeCreate()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = sp.CreateContainer(ctx, id, containerCfg, hostCfg, networkCfg)
+	if err != nil {
+		if beforeCreate != nil {
+			return err
+		}
+		restoreErr := sp.restoreReplacedContainer(ctx, oldName, name, start)
+		if restoreErr != nil {
+			return fmt.Errorf("%w (restoring the old %s container also failed: %s)", err, id, restoreErr.Error())
+		}
+		return err
+	}
+	if beforeCreate == nil {
+		err = sp.RunContainerOp(ctx, func() error {
+			return d.ContainerRemove(ctx, oldName, container.RemoveOptions{})
+		})
+		if err != nil {
+			return fmt.Errorf("error removing the old %s container: %w", id, err)
+		}
+	}
+	if !start {
+		return nil
+	}
+	err = sp.StartContainer(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error starting %s container: %w", id, err)
+	}
+	return nil
+}
+
+// Puts a container that was moved out of the way for a replacement back under its original name, starting it if requested
+func (sp *ServiceProvider) restoreReplacedContainer(ctx context.Context, oldName string, name string, start bool) error {
+	err := sp.RunContainerOp(ctx, func() error {
+		return sp.GetDocker().ContainerRename(ctx, oldName, name)
+	})
+	if err != nil {
+		return err
+	}
+	if !start {
+		return nil
+	}
+	return sp.StartContainer(ctx, name)
+}
+
+// Restores a slashing protection backup into a Validator Client container that was just replaced, even if replacing it
+// failed. Returns the replacement error if there was one, noting a failed restore in it too.
+func (sp *ServiceProvider) restoreAfterReplace(ctx context.Context, id config.ContainerID, backup SlashingProtectionBackup, replaceErr error) error {
+	restoreErr := sp.RestoreSlashingProtectionBackup(ctx, backup.Path)
+	if replaceErr != nil {
+		if restoreErr != nil {
+			return fmt.Errorf("%w (restoring the slashing protection backup also failed: %s)", replaceErr, restoreErr.Error())
+		}
+		return replaceErr
+	}
+	if restoreErr != nil {
+		return fmt.Errorf("error restoring slashing protection into the new %s container: %w", id, restoreErr)
+	}
+	return nil
+}
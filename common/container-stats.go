@@ -0,0 +1,135 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// A sample of a container's resource usage
+type ContainerStats struct {
+	// The container's name, without Docker's leading slash
+	Name string `json:"name"`
+
+	// When Docker took the sample
+	Time time.Time `json:"time"`
+
+	// The container's CPU usage since the previous sample, as a percentage of one CPU; it can go above 100 on hosts
+	// with more than one CPU, like `docker stats`
+	CpuPercent float64 `json:"cpuPercent"`
+
+	// The bytes of memory the container is using, not counting the inactive page cache
+	MemoryUsageBytes uint64 `json:"memoryUsageBytes"`
+
+	// The bytes of memory the container can use
+	MemoryLimitBytes uint64 `json:"memoryLimitBytes"`
+
+	// The container's memory usage as a percentage of its limit
+	MemoryPercent float64 `json:"memoryPercent"`
+
+	// The total bytes the container has received over all of its networks
+	NetworkRxBytes uint64 `json:"networkRxBytes"`
+
+	// The total bytes the container has sent over all of its networks
+	NetworkTxBytes uint64 `json:"networkTxBytes"`
+}
+
+// Streams the resource usage of a Hyperdrive service's container, sending a sample each time Docker reports one
+// (normally every second). The channel is closed when ctx is cancelled, which also closes the stream, or when Docker
+// stops reporting, like when the container is removed.
+func (sp *ServiceProvider) StreamContainerStats(ctx context.Context, service string) (<-chan ContainerStats, error) {
+	name := sp.cfg.GetDockerArtifactName(service)
+	response, err := sp.GetDocker().ContainerStats(ctx, name, true)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stats for %s container: %w", service, err)
+	}
+
+	samples := make(chan ContainerStats)
+	go func() {
+		defer close(samples)
+		done := make(chan struct{})
+		defer close(done)
+
+		// Closing the body is what unblocks the decoder, so do it as soon as the stream is cancelled
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-done:
+			}
+			response.Body.Close()
+		}()
+
+		decoder := json.NewDecoder(response.Body)
+		for {
+			var frame types.StatsJSON
+			err := decoder.Decode(&frame)
+			if err != nil {
+				return
+			}
+			select {
+			case samples <- parseContainerStats(frame):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return samples, nil
+}
+
+// Gets a single sample of a container's resource usage. Docker waits for a second sample before it responds, so the
+// CPU usage can be worked out.
+func (sp *ServiceProvider) GetContainerStats(ctx context.Context, name string) (ContainerStats, error) {
+	response, err := sp.GetDocker().ContainerStats(ctx, name, false)
+	if err != nil {
+		return ContainerStats{}, fmt.Errorf("error getting stats for container [%s]: %w", name, err)
+	}
+	defer response.Body.Close()
+	var frame types.StatsJSON
+	err = json.NewDecoder(response.Body).Decode(&frame)
+	if err != nil {
+		return ContainerStats{}, fmt.Errorf("error decoding stats for container [%s]: %w", name, err)
+	}
+	return parseContainerStats(frame), nil
+}
+
+// Converts a stats frame from Docker into a sample, with the CPU and memory usage worked out the same way as
+// `docker stats`
+func parseContainerStats(frame types.StatsJSON) ContainerStats {
+	stats := ContainerStats{
+		Name:             strings.TrimPrefix(frame.Name, "/"),
+		Time:             frame.Read,
+		MemoryLimitBytes: frame.MemoryStats.Limit,
+	}
+
+	// CPU usage is the container's share of the host's CPU time since the previous sample
+	cpuDelta := float64(frame.CPUStats.CPUUsage.TotalUsage) - float64(frame.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(frame.CPUStats.SystemUsage) - float64(frame.PreCPUStats.SystemUsage)
+	onlineCpus := float64(frame.CPUStats.OnlineCPUs)
+	if onlineCpus == 0 {
+		onlineCpus = float64(len(frame.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CpuPercent = cpuDelta / systemDelta * onlineCpus * 100
+	}
+
+	// The page cache can be reclaimed, so it doesn't count; cgroups v1 and v2 report it under different keys
+	stats.MemoryUsageBytes = frame.MemoryStats.Usage
+	if inactive, isCgroupV1 := frame.MemoryStats.Stats["total_inactive_file"]; isCgroupV1 && inactive < stats.MemoryUsageBytes {
+		stats.MemoryUsageBytes -= inactive
+	} else if inactive := frame.MemoryStats.Stats["inactive_file"]; !isCgroupV1 && inactive < stats.MemoryUsageBytes {
+		stats.MemoryUsageBytes -= inactive
+	}
+	if stats.MemoryLimitBytes > 0 {
+		stats.MemoryPercent = float64(stats.MemoryUsageBytes) / float64(stats.MemoryLimitBytes) * 100
+	}
+
+	for _, network := range frame.Networks {
+		stats.NetworkRxBytes += network.RxBytes
+		stats.NetworkTxBytes += network.TxBytes
+	}
+	return stats
+}
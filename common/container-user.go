@@ -0,0 +1,97 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// Where the permissions container mounts the volume it's setting the owner of
+	volumePermissionsMountPath string = "/data"
+)
+
+// Creates the Hyperdrive-managed volumes a container mounts that don't exist yet, and gives them to the user the
+// container runs as. Docker would otherwise create them when the container is created, owned by root, which the
+// container's user couldn't write to. Volumes that already exist are left alone, since their data already has an owner.
+// Ownership is set by running chown as root in a short-lived container made from the service's own image.
+func (sp *ServiceProvider) prepareDataVolumes(ctx context.Context, id config.ContainerID, image string, hostCfg *container.HostConfig, user string) error {
+	if hostCfg == nil {
+		return nil
+	}
+	prefix := sp.cfg.GetDockerArtifactName("")
+	d := sp.GetDocker()
+	for _, hostMount := range hostCfg.Mounts {
+		if hostMount.Type != mount.TypeVolume || !strings.HasPrefix(hostMount.Source, prefix) {
+			continue
+		}
+		_, err := d.VolumeInspect(ctx, hostMount.Source)
+		if err == nil {
+			continue
+		}
+		if !errdefs.IsNotFound(err) {
+			return fmt.Errorf("error inspecting %s data volume [%s]: %w", id, hostMount.Source, err)
+		}
+
+		_, err = d.VolumeCreate(ctx, volume.CreateOptions{
+			Name: hostMount.Source,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating %s data volume [%s]: %w", id, hostMount.Source, err)
+		}
+		err = sp.setVolumeOwner(ctx, id, image, hostMount.Source, user)
+		if err != nil {
+			return fmt.Errorf("error setting owner of %s data volume [%s]: %w", id, hostMount.Source, err)
+		}
+	}
+	return nil
+}
+
+// Sets the owner of a volume's root directory to the provided user spec, waiting for the change to finish
+func (sp *ServiceProvider) setVolumeOwner(ctx context.Context, id config.ContainerID, image string, volumeName string, user string) error {
+	return sp.RunContainerOp(ctx, func() error {
+		d := sp.GetDocker()
+		name := sp.cfg.GetDockerArtifactName(string(id) + "_permissions")
+		_, err := d.ContainerCreate(ctx, &container.Config{
+			Image:      image,
+			User:       "0:0",
+			Entrypoint: []string{"chown", user, volumePermissionsMountPath},
+		}, &container.HostConfig{
+			Mounts: []mount.Mount{{
+				Type:   mount.TypeVolume,
+				Source: volumeName,
+				Target: volumePermissionsMountPath,
+			}},
+		}, nil, nil, name)
+		if err != nil {
+			return fmt.Errorf("error creating permissions container: %w", err)
+		}
+		defer func() {
+			_ = d.ContainerRemove(context.Background(), name, container.RemoveOptions{Force: true})
+		}()
+
+		err = d.ContainerStart(ctx, name, container.StartOptions{})
+		if err != nil {
+			return fmt.Errorf("error starting permissions container: %w", err)
+		}
+		statusCh, errCh := d.ContainerWait(ctx, name, container.WaitConditionNotRunning)
+		select {
+		case status := <-statusCh:
+			if status.Error != nil {
+				return fmt.Errorf("error running permissions container: %s", status.Error.Message)
+			}
+			if status.StatusCode != 0 {
+				return fmt.Errorf("chown exited with code %d; the %s image needs a chown command to set the owner", status.StatusCode, id)
+			}
+			return nil
+		case err := <-errCh:
+			return fmt.Errorf("error waiting for permissions container: %w", err)
+		}
+	})
+}
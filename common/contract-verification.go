@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+var (
+	// Returned when the contract at an address is missing or doesn't have the expected functions, usually because the
+	// address is for a different network
+	ErrContractMismatch error = errors.New("the contract at the address doesn't match the expected ABI")
+)
+
+// A contract a module can't work without, which is verified during preflight
+type CriticalContract struct {
+	// The name of the contract, used in logs and errors
+	Name string
+
+	// The address the module is configured to use
+	Address ethcommon.Address
+
+	// Selectors of view functions without arguments that the contract must implement
+	ExpectedSelectors [][4]byte
+}
+
+// The critical contracts registered by a single module
+type moduleContracts struct {
+	moduleName string
+	contracts  []CriticalContract
+}
+
+// Registers a module's critical contracts so they're verified during preflight
+func (sp *ServiceProvider) RegisterCriticalContracts(moduleName string, contracts []CriticalContract) {
+	sp.criticalContractLock.Lock()
+	defer sp.criticalContractLock.Unlock()
+	sp.criticalContracts = append(sp.criticalContracts, moduleContracts{
+		moduleName: moduleName,
+		contracts:  contracts,
+	})
+}
+
+// Checks that there's a contract deployed at the address and that each of the expected view functions can be called on it.
+// Returns an error wrapping ErrContractMismatch if not; other errors mean the Execution Client couldn't be queried.
+func (sp *ServiceProvider) VerifyContract(ctx context.Context, address ethcommon.Address, expectedSelectors [][4]byte) error {
+	code, err := sp.GetEthClient().CodeAt(ctx, address, nil)
+	if err != nil {
+		return fmt.Errorf("error getting code for %s: %w", address.Hex(), err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("%w: there is no contract deployed at %s", ErrContractMismatch, address.Hex())
+	}
+
+	for _, selector := range expectedSelectors {
+		_, err := sp.GetEthClient().CallContract(ctx, ethereum.CallMsg{
+			To:   &address,
+			Data: selector[:],
+		}, nil)
+		if err != nil {
+			// Reverts come back as JSON-RPC errors; anything else is a problem with the client
+			var rpcErr rpc.Error
+			if errors.As(err, &rpcErr) {
+				return fmt.Errorf("%w: calling %s on %s failed (%s)", ErrContractMismatch, hexutil.Encode(selector[:]), address.Hex(), err.Error())
+			}
+			return fmt.Errorf("error calling %s on %s: %w", hexutil.Encode(selector[:]), address.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// Verifies every module's critical contracts, logging each one that doesn't match. Returns an error naming the
+// mismatched contracts if there are any.
+func (sp *ServiceProvider) VerifyCriticalContracts(ctx context.Context) error {
+	sp.criticalContractLock.Lock()
+	modules := make([]moduleContracts, len(sp.criticalContracts))
+	copy(modules, sp.criticalContracts)
+	sp.criticalContractLock.Unlock()
+
+	logger, hasLogger := log.FromContext(ctx)
+	errs := []error{}
+	for _, module := range modules {
+		for _, contract := range module.contracts {
+			err := sp.VerifyContract(ctx, contract.Address, contract.ExpectedSelectors)
+			if err == nil {
+				continue
+			}
+			if hasLogger {
+				logger.Error("Contract verification failed",
+					slog.String("module", module.moduleName),
+					slog.String("contract", contract.Name),
+					slog.String("address", contract.Address.Hex()),
+					slog.String(log.ErrorKey, err.Error()),
+				)
+			}
+			errs = append(errs, fmt.Errorf("module [%s] contract [%s]: %w", module.moduleName, contract.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
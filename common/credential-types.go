@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// The type of a validator's withdrawal credentials, which is the first byte of them
+type WithdrawalCredentialType byte
+
+const (
+	// The credentials are a hash of a BLS key, so the validator can't withdraw until they're changed to an address
+	WithdrawalCredentialType_Bls WithdrawalCredentialType = 0x00
+
+	// The credentials are an execution address; balance over 32 ETH is swept automatically
+	WithdrawalCredentialType_Execution WithdrawalCredentialType = 0x01
+
+	// The credentials are an execution address and the validator's balance compounds up to 2048 ETH (EIP-7251, Electra onwards)
+	WithdrawalCredentialType_Compounding WithdrawalCredentialType = 0x02
+)
+
+var (
+	// The Electra fork isn't active on the Beacon Chain yet, so its features can't be used
+	ErrElectraNotActive error = errors.New("the Electra fork isn't active on this network yet")
+)
+
+// Counts the provided validators by the type of their withdrawal credentials, based on the head state. The result always
+// has the BLS and execution types; the compounding type is only included once Electra is active. Validators that aren't
+// on the Beacon Chain yet aren't counted, duplicate pubkeys are only counted once, and any unknown types are counted
+// under their own prefix.
+// If a validator has compounding credentials before Electra is active, an error wrapping ErrElectraNotActive is returned.
+func (sp *ServiceProvider) GetCredentialTypes(ctx context.Context, pubkeys []beacon.ValidatorPubkey) (map[WithdrawalCredentialType]int, error) {
+	electraActive, err := sp.isElectraActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[WithdrawalCredentialType]int{
+		WithdrawalCredentialType_Bls:       0,
+		WithdrawalCredentialType_Execution: 0,
+	}
+	if electraActive {
+		counts[WithdrawalCredentialType_Compounding] = 0
+	}
+
+	ids := []string{}
+	seen := map[beacon.ValidatorPubkey]bool{}
+	for _, pubkey := range pubkeys {
+		if seen[pubkey] {
+			continue
+		}
+		seen[pubkey] = true
+		ids = append(ids, pubkey.HexWithPrefix())
+	}
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator withdrawal credentials: %w", err)
+	}
+	for _, validator := range validators {
+		credentials := validator.Validator.WithdrawalCredentials
+		if len(credentials) == 0 {
+			return nil, fmt.Errorf("validator %s doesn't have any withdrawal credentials", validator.Index)
+		}
+		credentialType := WithdrawalCredentialType(credentials[0])
+		if credentialType == WithdrawalCredentialType_Compounding && !electraActive {
+			return nil, fmt.Errorf("%w: validator %s has compounding withdrawal credentials", ErrElectraNotActive, validator.Index)
+		}
+		counts[credentialType]++
+	}
+	return counts, nil
+}
+
+// Checks if the Electra fork, or a later one, is active at the Beacon Node's head
+func (sp *ServiceProvider) isElectraActive(ctx context.Context) (bool, error) {
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return false, err
+	}
+	slot, _, err := sp.GetBeaconApiClient().GetBlockSlot(ctx, "head")
+	if err != nil {
+		return false, err
+	}
+	return slot/spec.SlotsPerEpoch >= spec.ElectraForkEpoch, nil
+}
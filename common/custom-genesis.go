@@ -0,0 +1,98 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// A custom genesis artifact a client container needs, along with where it's mounted and the variable pointing to it
+type genesisArtifact struct {
+	hostPath      string
+	containerPath string
+	envVar        string
+}
+
+// Gets the network's genesis time. On a custom network with a genesis state, it's read from the state so it's known
+// even before the Beacon Node is up; otherwise the Beacon Node is asked for it.
+func (sp *ServiceProvider) GetGenesisTime(ctx context.Context) (time.Time, error) {
+	if sp.cfg.IsCustomNetwork() && sp.cfg.CustomGenesis.BeaconGenesisStatePath.Value != "" {
+		genesisTime, err := sp.cfg.CustomGenesis.GetGenesisTime()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("error reading genesis time from custom genesis state: %w", err)
+		}
+		return genesisTime, nil
+	}
+	genesis, err := sp.GetBeaconApiClient().GetGenesis(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(genesis.Data.GenesisTime), 0), nil
+}
+
+// Adds the custom genesis artifacts a container needs to its config as read-only mounts, along with the environment
+// variables its start script uses to find them. Any artifacts from a previous creation are replaced, so recreated
+// containers pick up changes and containers that no longer need them (like after switching to a built-in network)
+// have them removed.
+func (sp *ServiceProvider) applyCustomGenesis(id config.ContainerID, containerCfg *container.Config, hostCfg *container.HostConfig) *container.HostConfig {
+	artifacts := sp.getGenesisArtifacts(id)
+	finalHostCfg := container.HostConfig{}
+	if hostCfg != nil {
+		finalHostCfg = *hostCfg
+	}
+	finalHostCfg.Mounts = slices.DeleteFunc(slices.Clone(finalHostCfg.Mounts), func(managed mount.Mount) bool {
+		return managed.Target == hdconfig.ExecutionGenesisContainerPath ||
+			managed.Target == hdconfig.BeaconConfigContainerPath ||
+			managed.Target == hdconfig.BeaconGenesisStateContainerPath
+	})
+	containerCfg.Env = slices.DeleteFunc(slices.Clone(containerCfg.Env), func(variable string) bool {
+		name, _, _ := strings.Cut(variable, "=")
+		return name == hdconfig.ExecutionGenesisEnvVar ||
+			name == hdconfig.BeaconConfigEnvVar ||
+			name == hdconfig.BeaconGenesisStateEnvVar
+	})
+	for _, artifact := range artifacts {
+		finalHostCfg.Mounts = append(finalHostCfg.Mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   artifact.hostPath,
+			Target:   artifact.containerPath,
+			ReadOnly: true,
+		})
+		containerCfg.Env = append(containerCfg.Env, fmt.Sprintf("%s=%s", artifact.envVar, artifact.containerPath))
+	}
+	if hostCfg == nil && len(finalHostCfg.Mounts) == 0 {
+		return nil
+	}
+	return &finalHostCfg
+}
+
+// Gets the configured custom genesis artifacts for a container, or none if Hyperdrive isn't on a custom network
+func (sp *ServiceProvider) getGenesisArtifacts(id config.ContainerID) []genesisArtifact {
+	if !sp.cfg.IsCustomNetwork() {
+		return nil
+	}
+	genesis := sp.cfg.CustomGenesis
+	executionGenesis := genesisArtifact{genesis.ExecutionGenesisPath.Value, hdconfig.ExecutionGenesisContainerPath, hdconfig.ExecutionGenesisEnvVar}
+	beaconConfig := genesisArtifact{genesis.BeaconConfigPath.Value, hdconfig.BeaconConfigContainerPath, hdconfig.BeaconConfigEnvVar}
+	beaconState := genesisArtifact{genesis.BeaconGenesisStatePath.Value, hdconfig.BeaconGenesisStateContainerPath, hdconfig.BeaconGenesisStateEnvVar}
+
+	var artifacts []genesisArtifact
+	switch id {
+	case config.ContainerID_ExecutionClient:
+		artifacts = []genesisArtifact{executionGenesis}
+	case config.ContainerID_BeaconNode:
+		artifacts = []genesisArtifact{beaconConfig, beaconState}
+	case config.ContainerID_ValidatorClient:
+		artifacts = []genesisArtifact{beaconConfig}
+	}
+	return slices.DeleteFunc(artifacts, func(artifact genesisArtifact) bool {
+		return artifact.hostPath == ""
+	})
+}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+const (
+	// Consensus spec defaults for the effective balance rules, used when the Beacon Node doesn't report them
+	defaultMaxEffectiveBalanceElectra uint64 = 2048e9
+	defaultHysteresisQuotient         uint64 = 4
+	defaultHysteresisUpwardMultiplier uint64 = 5
+)
+
+var (
+	// The validator's effective balance is already higher than the one that was asked for
+	ErrAboveDesiredBalance error = errors.New("the validator's effective balance is already above the desired balance")
+)
+
+// Computes how much (in gwei) needs to be deposited to a validator for its effective balance to reach the desired one.
+// The desired balance is rounded down to the effective balance increment and capped at the most the validator can have
+// with its credentials: 2048 ETH for compounding (0x02) credentials, or 32 ETH otherwise. The balance it's topped up from
+// includes any deposits to it that are still pending. Since the effective balance only goes up once the balance is past
+// it by the spec's upward hysteresis threshold, a validator one increment away may need more than the difference.
+// Returns 0 if the validator already has enough, or an error wrapping ErrAboveDesiredBalance if its effective balance is
+// over the desired one.
+// Amounts under the deposit contract's 1 ETH minimum are returned as they are, so the caller can decide whether to round
+// them up.
+func (sp *ServiceProvider) ComputeDepositAmount(ctx context.Context, pubkey beacon.ValidatorPubkey, desiredEffectiveBalanceGwei uint64) (uint64, error) {
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	electraActive, err := sp.isElectraActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, "head", []string{pubkey.HexWithPrefix()}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error getting validator %s: %w", pubkey.HexWithPrefix(), err)
+	}
+	if len(validators) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrValidatorNotFound, pubkey.HexWithPrefix())
+	}
+	validator := validators[0]
+	switch beacon.ValidatorState(validator.Status) {
+	case beacon.ValidatorState_PendingInitialized, beacon.ValidatorState_PendingQueued, beacon.ValidatorState_ActiveOngoing:
+	default:
+		return 0, fmt.Errorf("%w: %s is %s, so deposits to it won't count", ErrValidatorNotActive, pubkey.HexWithPrefix(), validator.Status)
+	}
+
+	// Get the most it can have
+	credentials := validator.Validator.WithdrawalCredentials
+	if len(credentials) == 0 {
+		return 0, fmt.Errorf("validator %s doesn't have any withdrawal credentials", pubkey.HexWithPrefix())
+	}
+	maxEffectiveBalance := getSpecValue(spec.MinActivationBalance, defaultMinActivationBalance)
+	if WithdrawalCredentialType(credentials[0]) == WithdrawalCredentialType_Compounding {
+		if !electraActive {
+			return 0, fmt.Errorf("%w: validator %s has compounding withdrawal credentials", ErrElectraNotActive, pubkey.HexWithPrefix())
+		}
+		maxEffectiveBalance = getSpecValue(spec.MaxEffectiveBalanceElectra, defaultMaxEffectiveBalanceElectra)
+	}
+	increment := getSpecValue(spec.EffectiveBalanceIncrement, defaultEffectiveBalanceIncrement)
+	target := min(desiredEffectiveBalanceGwei-desiredEffectiveBalanceGwei%increment, maxEffectiveBalance)
+	effectiveBalance := uint64(validator.Validator.EffectiveBalance)
+	if effectiveBalance > target {
+		return 0, fmt.Errorf("%w: %s has %d gwei, but %d gwei was requested", ErrAboveDesiredBalance, pubkey.HexWithPrefix(), effectiveBalance, target)
+	}
+	if effectiveBalance == target {
+		return 0, nil
+	}
+
+	// Include the deposits that haven't been credited yet; they only queue up in the state from Electra onwards
+	balance := uint64(validator.Balance)
+	if electraActive {
+		deposits, err := sp.GetBeaconApiClient().GetPendingDeposits(ctx, "head")
+		if err != nil {
+			return 0, fmt.Errorf("error getting pending deposits: %w", err)
+		}
+		for _, deposit := range deposits {
+			if bytes.Equal(deposit.Pubkey, pubkey[:]) {
+				balance += uint64(deposit.Amount)
+			}
+		}
+	}
+
+	// The effective balance is raised to the balance (rounded down to the increment) once the balance is over it by the
+	// upward threshold
+	upwardThreshold := increment / getSpecValue(spec.HysteresisQuotient, defaultHysteresisQuotient) * getSpecValue(spec.HysteresisUpwardMultiplier, defaultHysteresisUpwardMultiplier)
+	requiredBalance := max(target, effectiveBalance+upwardThreshold+1)
+	if balance >= requiredBalance {
+		return 0, nil
+	}
+	return requiredBalance - balance, nil
+}
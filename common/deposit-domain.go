@@ -0,0 +1,34 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/types"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// Gets the domain for signing deposits on the configured network, which is cached once it's computed.
+// Unlike the domains for exits and other messages, which use the fork that's current at the message's epoch (and the
+// chain's genesis validators root), the deposit domain always uses the network's genesis fork version and a zero
+// genesis validators root; deposits have to be verifiable before the chain they're for exists, so they can't depend on
+// either. That means it never changes across forks and can be computed without a Beacon Node.
+func (sp *ServiceProvider) GetDepositDomain() (types.Domain, error) {
+	resources := sp.cfg.GetNetworkResources()
+	sp.depositDomainLock.Lock()
+	defer sp.depositDomainLock.Unlock()
+	if domain, exists := sp.depositDomains[resources.Network]; exists {
+		return domain, nil
+	}
+
+	rawDomain, err := eth2types.ComputeDomain(eth2types.DomainDeposit, resources.GenesisForkVersion, eth2types.ZeroGenesisValidatorsRoot)
+	if err != nil {
+		return types.Domain{}, fmt.Errorf("error computing deposit domain for network [%s]: %w", resources.Network, err)
+	}
+	var domain types.Domain
+	if len(rawDomain) != len(domain) {
+		return types.Domain{}, fmt.Errorf("deposit domain for network [%s] has %d bytes, expected %d", resources.Network, len(rawDomain), len(domain))
+	}
+	copy(domain[:], rawDomain)
+	sp.depositDomains[resources.Network] = domain
+	return domain, nil
+}
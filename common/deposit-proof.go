@@ -0,0 +1,250 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	prdeposit "github.com/prysmaticlabs/prysm/v5/contracts/deposit"
+	"github.com/rocket-pool/node-manager-core/beacon/ssz_types"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The depth of the deposit contract's Merkle tree, not counting the deposit count that's mixed into its root
+	depositContractTreeDepth int = 32
+
+	// The number of blocks to get deposit logs for in each request
+	depositLogChunkSize uint64 = 10000
+)
+
+var (
+	// The topic of the deposit contract's DepositEvent log
+	depositEventTopic ethcommon.Hash = crypto.Keccak256Hash([]byte("DepositEvent(bytes,bytes,bytes,bytes,bytes)"))
+
+	// The block the deposit contract was deployed in on each network that didn't have it at genesis, where the search for
+	// deposit logs starts
+	depositContractDeploymentBlocks map[config.Network]uint64 = map[config.Network]uint64{
+		config.Network_Mainnet: 11052984,
+	}
+)
+
+var (
+	// The deposit contract doesn't have a deposit with the requested index yet
+	ErrDepositNotFound error = errors.New("the deposit contract doesn't have a deposit with that index")
+)
+
+// The state of the deposit contract at a block
+type DepositContractInfo struct {
+	// The address of the deposit contract, from the Beacon Node's spec
+	Address ethcommon.Address `json:"address"`
+
+	// The block the rest of the info is from
+	BlockNumber uint64 `json:"blockNumber"`
+
+	// The number of deposits that have been made
+	DepositCount uint64 `json:"depositCount"`
+
+	// The root of the contract's deposit tree, with the deposit count mixed in
+	DepositRoot ethcommon.Hash `json:"depositRoot"`
+}
+
+// A Merkle proof that a deposit is in the deposit contract's tree
+type DepositProof struct {
+	// The index of the deposit in the tree
+	DepositIndex uint64 `json:"depositIndex"`
+
+	// The number of deposits in the tree the proof is for
+	DepositCount uint64 `json:"depositCount"`
+
+	// The block the tree is from
+	BlockNumber uint64 `json:"blockNumber"`
+
+	// The hash tree root of the deposit's data, which is the leaf being proven
+	Leaf ethcommon.Hash `json:"leaf"`
+
+	// The sibling of the leaf and each of its ancestors from the bottom of the tree up, followed by the deposit count as a
+	// little-endian integer; 33 entries in all, as in the Beacon Chain's Deposit container
+	Branch []ethcommon.Hash `json:"branch"`
+}
+
+// Gets the deposit count and root of the deposit contract as of the latest block
+func (sp *ServiceProvider) GetDepositContractInfo(ctx context.Context) (DepositContractInfo, error) {
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return DepositContractInfo{}, err
+	}
+	blockNumber, err := sp.GetEthClient().BlockNumber(ctx)
+	if err != nil {
+		return DepositContractInfo{}, fmt.Errorf("error getting latest block number: %w", err)
+	}
+	return sp.getDepositContractInfoAtBlock(ctx, spec.DepositContractAddress, blockNumber)
+}
+
+// Gets a Merkle proof that the deposit with the provided index is in the deposit contract's tree as of the latest block.
+// The tree is rebuilt from the contract's deposit logs, so this needs an Execution Client that has them all; the rebuilt
+// tree is checked against the contract's own root before the proof is returned. Returns an error wrapping
+// ErrDepositNotFound if the contract doesn't have that many deposits yet.
+func (sp *ServiceProvider) GetDepositProof(ctx context.Context, depositIndex uint64) (DepositProof, error) {
+	info, err := sp.GetDepositContractInfo(ctx)
+	if err != nil {
+		return DepositProof{}, err
+	}
+	if depositIndex >= info.DepositCount {
+		return DepositProof{}, fmt.Errorf("%w: deposit %d was requested, but there are only %d deposits", ErrDepositNotFound, depositIndex, info.DepositCount)
+	}
+	leaves, err := sp.getDepositLeaves(ctx, info.Address, info.BlockNumber, info.DepositCount)
+	if err != nil {
+		return DepositProof{}, err
+	}
+
+	// Build the tree a layer at a time, padding each layer's odd node with the root of an empty subtree
+	branch := make([]ethcommon.Hash, depositContractTreeDepth+1)
+	layer := leaves
+	zeroHash := ethcommon.Hash{}
+	index := depositIndex
+	for depth := 0; depth < depositContractTreeDepth; depth++ {
+		sibling := index ^ 1
+		if sibling < uint64(len(layer)) {
+			branch[depth] = layer[sibling]
+		} else {
+			branch[depth] = zeroHash
+		}
+		parents := make([]ethcommon.Hash, (len(layer)+1)/2)
+		for i := range parents {
+			right := zeroHash
+			if 2*i+1 < len(layer) {
+				right = layer[2*i+1]
+			}
+			parents[i] = hashDepositTreeNodes(layer[2*i], right)
+		}
+		layer = parents
+		zeroHash = hashDepositTreeNodes(zeroHash, zeroHash)
+		index /= 2
+	}
+	binary.LittleEndian.PutUint64(branch[depositContractTreeDepth][:], info.DepositCount)
+
+	proof := DepositProof{
+		DepositIndex: depositIndex,
+		DepositCount: info.DepositCount,
+		BlockNumber:  info.BlockNumber,
+		Leaf:         leaves[depositIndex],
+		Branch:       branch,
+	}
+	if !VerifyDepositProof(proof, info.DepositRoot) {
+		return DepositProof{}, fmt.Errorf("the deposit logs as of block %d don't match the deposit contract's root %s", info.BlockNumber, info.DepositRoot.Hex())
+	}
+	return proof, nil
+}
+
+// Checks that a deposit proof proves its leaf is in a deposit tree with the provided root, as the Beacon Chain does when
+// it processes deposits. The deposit count in the proof has to match the one mixed into the root as well.
+func VerifyDepositProof(proof DepositProof, root ethcommon.Hash) bool {
+	if len(proof.Branch) != depositContractTreeDepth+1 || proof.DepositIndex >= proof.DepositCount {
+		return false
+	}
+	var count ethcommon.Hash
+	binary.LittleEndian.PutUint64(count[:], proof.DepositCount)
+	if proof.Branch[depositContractTreeDepth] != count {
+		return false
+	}
+	value := proof.Leaf
+	for depth, sibling := range proof.Branch {
+		if (proof.DepositIndex>>depth)&1 == 1 {
+			value = hashDepositTreeNodes(sibling, value)
+		} else {
+			value = hashDepositTreeNodes(value, sibling)
+		}
+	}
+	return value == root
+}
+
+// Gets the deposit count and root of the deposit contract at a block
+func (sp *ServiceProvider) getDepositContractInfoAtBlock(ctx context.Context, address ethcommon.Address, blockNumber uint64) (DepositContractInfo, error) {
+	contract, err := prdeposit.NewDepositContractCaller(address, sp.GetEthClient())
+	if err != nil {
+		return DepositContractInfo{}, fmt.Errorf("error binding deposit contract %s: %w", address.Hex(), err)
+	}
+	opts := &bind.CallOpts{
+		Context:     ctx,
+		BlockNumber: new(big.Int).SetUint64(blockNumber),
+	}
+	countBytes, err := contract.GetDepositCount(opts)
+	if err != nil {
+		return DepositContractInfo{}, fmt.Errorf("error getting deposit count: %w", err)
+	}
+	if len(countBytes) != 8 {
+		return DepositContractInfo{}, fmt.Errorf("deposit contract returned a %d-byte deposit count, expected 8", len(countBytes))
+	}
+	root, err := contract.GetDepositRoot(opts)
+	if err != nil {
+		return DepositContractInfo{}, fmt.Errorf("error getting deposit root: %w", err)
+	}
+	return DepositContractInfo{
+		Address:      address,
+		BlockNumber:  blockNumber,
+		DepositCount: binary.LittleEndian.Uint64(countBytes),
+		DepositRoot:  root,
+	}, nil
+}
+
+// Gets the hash tree roots of the first count deposits to the deposit contract, in order, from its logs up to the
+// provided block
+func (sp *ServiceProvider) getDepositLeaves(ctx context.Context, address ethcommon.Address, toBlock uint64, count uint64) ([]ethcommon.Hash, error) {
+	leaves := make([]ethcommon.Hash, 0, count)
+	fromBlock := depositContractDeploymentBlocks[sp.cfg.GetNetworkResources().Network]
+	for start := fromBlock; start <= toBlock && uint64(len(leaves)) < count; start += depositLogChunkSize {
+		end := min(start+depositLogChunkSize-1, toBlock)
+		logs, err := sp.GetEthClient().FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []ethcommon.Address{address},
+			Topics:    [][]ethcommon.Hash{{depositEventTopic}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting deposit logs for blocks %d to %d: %w", start, end, err)
+		}
+		for _, log := range logs {
+			pubkey, withdrawalCredentials, amount, signature, index, err := prdeposit.UnpackDepositLogData(log.Data)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding deposit log in transaction %s: %w", log.TxHash.Hex(), err)
+			}
+			if len(amount) != 8 || len(index) != 8 {
+				return nil, fmt.Errorf("deposit log in transaction %s has a malformed amount or index", log.TxHash.Hex())
+			}
+
+			// A gap means the client is missing logs, which would make every later leaf land in the wrong place
+			logIndex := binary.LittleEndian.Uint64(index)
+			if logIndex != uint64(len(leaves)) {
+				return nil, fmt.Errorf("deposit log in transaction %s has index %d, expected %d", log.TxHash.Hex(), logIndex, len(leaves))
+			}
+			depositData := ssz_types.DepositData{
+				PublicKey:             pubkey,
+				WithdrawalCredentials: withdrawalCredentials,
+				Amount:                binary.LittleEndian.Uint64(amount),
+				Signature:             signature,
+			}
+			leaf, err := depositData.HashTreeRoot()
+			if err != nil {
+				return nil, fmt.Errorf("error computing root of deposit %d: %w", logIndex, err)
+			}
+			leaves = append(leaves, leaf)
+		}
+	}
+	if uint64(len(leaves)) < count {
+		return nil, fmt.Errorf("found %d deposit logs up to block %d, but the deposit contract has %d deposits", len(leaves), toBlock, count)
+	}
+	return leaves[:count], nil
+}
+
+// Hashes two nodes of the deposit tree into their parent
+func hashDepositTreeNodes(left ethcommon.Hash, right ethcommon.Hash) ethcommon.Hash {
+	return sha256.Sum256(append(left[:], right[:]...))
+}
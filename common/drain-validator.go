@@ -0,0 +1,170 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils"
+)
+
+const (
+	// How often to check the Beacon Node's head while waiting for a drained validator's duties to pass
+	drainPollInterval time.Duration = 250 * time.Millisecond
+)
+
+var (
+	// The validator's key isn't loaded into the Validator Client, or can't be removed from it
+	ErrValidatorNotLoaded error = errors.New("the validator's key isn't loaded into the Validator Client")
+
+	// The validator kept signing after its key was removed, so it isn't safe to run it anywhere else yet
+	ErrValidatorNotDrained error = errors.New("the validator wasn't drained")
+)
+
+// Safely stops a validator so it can be moved to another Validator Client. The key is removed from the VC, then this waits
+// for the Beacon Node's head to move the provided number of epochs past the one it was removed in, so any duties that were
+// in flight have passed. Afterwards, the key's slashing protection data is collected from the VC and checked for anything it
+// signed after the removal; if the key is back in the VC or signed something later, an error wrapping ErrValidatorNotDrained
+// is returned. Returns the key's EIP-3076 slashing protection data, ready to be imported along with the key elsewhere.
+// The keystore file on disk is untouched.
+// If provided, progress is reported after each step and each epoch of the wait; it can be nil. Canceling the context
+// stops the wait, but the key stays removed.
+func (sp *ServiceProvider) DrainValidator(ctx context.Context, pubkey beacon.ValidatorPubkey, waitEpochs uint64, progress ProgressFunc) ([]byte, error) {
+	totalSteps := int(waitEpochs) + 3
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	genesisValidatorsRoot, err := sp.getGenesisValidatorsRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the key
+	keyManager := sp.GetKeyManagerClient()
+	keystores, err := keyManager.ListKeystores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loaded := false
+	for _, keystore := range keystores {
+		if keystore.Pubkey != pubkey {
+			continue
+		}
+		if keystore.ReadOnly {
+			return nil, fmt.Errorf("%w: %s is a read-only remote key, so it has to be removed from its remote signer instead", ErrValidatorNotLoaded, pubkey.HexWithPrefix())
+		}
+		loaded = true
+	}
+	if !loaded {
+		return nil, fmt.Errorf("%w: %s", ErrValidatorNotLoaded, pubkey.HexWithPrefix())
+	}
+	disabledSlot, _, err := sp.GetBeaconApiClient().GetBlockSlot(ctx, "head")
+	if err != nil {
+		return nil, err
+	}
+	disabledEpoch := disabledSlot / spec.SlotsPerEpoch
+	removed, err := sp.deleteKeyForDrain(ctx, pubkey, genesisValidatorsRoot)
+	if err != nil {
+		return nil, err
+	}
+	progress.report(1, totalSteps, fmt.Sprintf("Removed %s from the Validator Client in epoch %d", pubkey.HexWithPrefix(), disabledEpoch))
+
+	// Wait for its duties to pass
+	waited := uint64(0)
+	for waited < waitEpochs {
+		headSlot, _, err := sp.GetBeaconApiClient().GetBlockSlot(ctx, "head")
+		if err != nil {
+			return nil, err
+		}
+		headEpoch := headSlot / spec.SlotsPerEpoch
+		for waited < waitEpochs && disabledEpoch+waited < headEpoch {
+			waited++
+			progress.report(1+int(waited), totalSteps, fmt.Sprintf("Waited %d of %d epochs", waited, waitEpochs))
+		}
+		if waited == waitEpochs {
+			break
+		}
+		if utils.SleepWithCancel(ctx, drainPollInterval) {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Collect anything it signed while its duties were in flight; deleting a removed key again just releases its data
+	released, err := sp.deleteKeyForDrain(ctx, pubkey, genesisValidatorsRoot)
+	if err != nil {
+		return nil, err
+	}
+	interchange := MergeSlashingProtection(removed, released)
+	progress.report(totalSteps-1, totalSteps, "Exported the validator's slashing protection data")
+
+	// Make sure it's really stopped; the head can lag behind the VC's clock, so anything in the removal epoch counts as in flight
+	keystores, err = keyManager.ListKeystores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, keystore := range keystores {
+		if keystore.Pubkey == pubkey {
+			return nil, fmt.Errorf("%w: %s was loaded into the Validator Client again", ErrValidatorNotDrained, pubkey.HexWithPrefix())
+		}
+	}
+	for _, record := range interchange.Data {
+		for _, attestation := range record.SignedAttestations {
+			if uint64(attestation.TargetEpoch) > disabledEpoch {
+				return nil, fmt.Errorf("%w: %s signed an attestation for epoch %d after it was removed in epoch %d", ErrValidatorNotDrained, pubkey.HexWithPrefix(), attestation.TargetEpoch, disabledEpoch)
+			}
+		}
+		for _, block := range record.SignedBlocks {
+			if uint64(block.Slot)/spec.SlotsPerEpoch > disabledEpoch {
+				return nil, fmt.Errorf("%w: %s signed a block for slot %d after it was removed in epoch %d", ErrValidatorNotDrained, pubkey.HexWithPrefix(), block.Slot, disabledEpoch)
+			}
+		}
+	}
+
+	bytes, err := json.MarshalIndent(interchange, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error serializing slashing protection data: %w", err)
+	}
+	progress.report(totalSteps, totalSteps, fmt.Sprintf("%s is safe to import into another Validator Client", pubkey.HexWithPrefix()))
+	return bytes, nil
+}
+
+// Deletes a key from the Validator Client, returning its slashing protection data. Keys that were already deleted just have
+// their data returned.
+func (sp *ServiceProvider) deleteKeyForDrain(ctx context.Context, pubkey beacon.ValidatorPubkey, genesisValidatorsRoot string) (SlashingProtectionInterchange, error) {
+	sp.slashingProtectionLock.Lock()
+	defer sp.slashingProtectionLock.Unlock()
+
+	interchange := SlashingProtectionInterchange{
+		Metadata: SlashingProtectionMetadata{
+			InterchangeFormatVersion: SlashingProtectionInterchangeVersion,
+			GenesisValidatorsRoot:    genesisValidatorsRoot,
+		},
+		Data: []SlashingProtectionRecord{},
+	}
+	statuses, data, err := sp.GetKeyManagerClient().DeleteKeystores(ctx, []beacon.ValidatorPubkey{pubkey})
+	if err != nil {
+		return interchange, err
+	}
+	if statuses[0].Status == "error" {
+		return interchange, fmt.Errorf("error deleting key %s from the Validator Client: %s", pubkey.HexWithPrefix(), statuses[0].Message)
+	}
+	if data == "" {
+		return interchange, nil
+	}
+	released, err := parseSlashingProtection([]byte(data), genesisValidatorsRoot)
+	if err != nil {
+		return interchange, fmt.Errorf("error reading the Validator Client's slashing protection data: %w", err)
+	}
+
+	// Only keep the requested key's history
+	for _, record := range released.Data {
+		if record.Pubkey == pubkey {
+			interchange.Data = append(interchange.Data, record)
+		}
+	}
+	return interchange, nil
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+var (
+	// More than one module claims the same validator key, so starting the Validator Clients would run it twice
+	ErrDuplicateValidators error = errors.New("validators are claimed by more than one module")
+)
+
+// A validator key claimed by more than one enabled module
+type DuplicateValidator struct {
+	// The validator's pubkey
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// The modules that claim it, in the order they were registered
+	Modules []string `json:"modules"`
+}
+
+// Finds the validator keys claimed by more than one enabled module, using the pubkeys each module's stake contributor
+// reports. The duplicates are returned in the order they were first claimed.
+func (sp *ServiceProvider) DetectDuplicateValidators(ctx context.Context) ([]DuplicateValidator, error) {
+	claims := map[beacon.ValidatorPubkey][]string{}
+	order := []beacon.ValidatorPubkey{}
+	for _, contributor := range sp.getStakeContributors() {
+		if !contributor.IsEnabled() {
+			continue
+		}
+		moduleName := contributor.GetModuleName()
+		pubkeys, err := contributor.GetValidatorPubkeys(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting validators for module [%s]: %w", moduleName, err)
+		}
+		for _, pubkey := range pubkeys {
+			modules, exists := claims[pubkey]
+			if !exists {
+				order = append(order, pubkey)
+			}
+			if !slices.Contains(modules, moduleName) {
+				claims[pubkey] = append(modules, moduleName)
+			}
+		}
+	}
+
+	duplicates := []DuplicateValidator{}
+	for _, pubkey := range order {
+		if len(claims[pubkey]) > 1 {
+			duplicates = append(duplicates, DuplicateValidator{
+				Pubkey:  pubkey,
+				Modules: claims[pubkey],
+			})
+		}
+	}
+	return duplicates, nil
+}
+
+// Checks that no validator key is claimed by more than one module before the Validator Clients are started, returning
+// an error wrapping ErrDuplicateValidators that lists the conflicts if there are any. The check is skipped if duplicate
+// validators are allowed in the config.
+func (sp *ServiceProvider) checkDuplicateValidators(ctx context.Context) error {
+	if sp.cfg.AllowDuplicateValidators.Value {
+		return nil
+	}
+	duplicates, err := sp.DetectDuplicateValidators(ctx)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	conflicts := make([]string, len(duplicates))
+	for i, duplicate := range duplicates {
+		conflicts[i] = fmt.Sprintf("%s (%s)", duplicate.Pubkey.HexWithPrefix(), strings.Join(duplicate.Modules, ", "))
+	}
+	return fmt.Errorf("%w: %s", ErrDuplicateValidators, strings.Join(conflicts, "; "))
+}
+
+// Checks for duplicate validators before a Validator Client container is started, so a conflict with a module that
+// registered after the clients booted still keeps the VC from running. Other containers aren't checked.
+func (sp *ServiceProvider) checkDuplicateValidatorsForContainer(ctx context.Context, name string) error {
+	if !isValidatorClientContainer(name) {
+		return nil
+	}
+	err := sp.checkDuplicateValidators(ctx)
+	if err != nil {
+		return fmt.Errorf("can't start Validator Client container [%s]: %w", name, err)
+	}
+	return nil
+}
+
+// True if the container is a Validator Client, based on its name
+func isValidatorClientContainer(name string) bool {
+	return strings.HasSuffix(strings.TrimPrefix(name, "/"), "_"+string(config.ContainerID_ValidatorClient))
+}
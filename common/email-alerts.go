@@ -0,0 +1,169 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+var (
+	// There's no SMTP server configured, so alerts can't be sent by email
+	ErrEmailAlertsNotConfigured error = errors.New("email alerts aren't configured")
+
+	// The SMTP server couldn't be reached, or the connection to it failed partway through
+	ErrSmtpConnectionFailed error = errors.New("couldn't connect to the SMTP server")
+
+	// The SMTP server rejected the username and password, or doesn't let clients log in
+	ErrSmtpAuthFailed error = errors.New("the SMTP server rejected the login")
+)
+
+// Sends alerts by email through the configured SMTP server
+type EmailAlertSink struct {
+	cfg *hdconfig.AlertsConfig
+}
+
+// Creates a new email alert sink that uses the SMTP settings in the provided config
+func NewEmailAlertSink(cfg *hdconfig.AlertsConfig) *EmailAlertSink {
+	return &EmailAlertSink{
+		cfg: cfg,
+	}
+}
+
+func (s *EmailAlertSink) GetName() string {
+	return "email"
+}
+
+// Sends an alert to every recipient in a single email. The connection is upgraded with STARTTLS if the server offers
+// it; the password is never sent over an unencrypted connection unless the server is on localhost.
+func (s *EmailAlertSink) SendAlert(ctx context.Context, alert Alert) error {
+	if !s.cfg.IsEmailEnabled() {
+		return ErrEmailAlertsNotConfigured
+	}
+	host := s.cfg.SmtpHost.Value
+	address := net.JoinHostPort(host, strconv.FormatUint(uint64(s.cfg.SmtpPort.Value), 10))
+	recipients := s.cfg.GetEmailRecipients()
+	if len(recipients) == 0 {
+		return fmt.Errorf("%w: there aren't any recipients", ErrEmailAlertsNotConfigured)
+	}
+
+	// Connect, bounding the whole conversation by the context's deadline
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("%w at %s: %w", ErrSmtpConnectionFailed, address, err)
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	if !hasDeadline {
+		deadline = time.Now().Add(hdconfig.ClientTimeout)
+	}
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("%w at %s: %w", ErrSmtpConnectionFailed, address, err)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("%w at %s: %w", ErrSmtpConnectionFailed, address, err)
+	}
+	defer client.Close()
+	if hasStartTls, _ := client.Extension("STARTTLS"); hasStartTls {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return fmt.Errorf("%w: error starting TLS with %s: %w", ErrSmtpConnectionFailed, address, err)
+		}
+	}
+
+	// Log in
+	if s.cfg.SmtpUsername.Value != "" {
+		password, err := s.readPassword()
+		if err != nil {
+			return err
+		}
+		if hasAuth, _ := client.Extension("AUTH"); !hasAuth {
+			return fmt.Errorf("%w: %s doesn't support logging in", ErrSmtpAuthFailed, address)
+		}
+		err = client.Auth(smtp.PlainAuth("", s.cfg.SmtpUsername.Value, password, host))
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSmtpAuthFailed, err)
+		}
+	}
+
+	// Send the email
+	err = client.Mail(s.cfg.SmtpFrom.Value)
+	if err != nil {
+		return fmt.Errorf("error setting the sender to %s: %w", s.cfg.SmtpFrom.Value, err)
+	}
+	for _, recipient := range recipients {
+		err = client.Rcpt(recipient)
+		if err != nil {
+			return fmt.Errorf("error adding recipient %s: %w", recipient, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error starting the email: %w", err)
+	}
+	_, err = writer.Write(s.formatMessage(alert, recipients))
+	if err != nil {
+		writer.Close()
+		return fmt.Errorf("error writing the email: %w", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("error sending the email: %w", err)
+	}
+	return client.Quit()
+}
+
+// Reads the SMTP password from its file, without the trailing newline editors tend to leave
+func (s *EmailAlertSink) readPassword() (string, error) {
+	path := s.cfg.SmtpPasswordPath.Value
+	if path == "" {
+		return "", fmt.Errorf("%w: a username is set but there's no password file", ErrSmtpAuthFailed)
+	}
+	password, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading the SMTP password file [%s]: %w", path, err)
+	}
+	return strings.TrimRight(string(password), "\r\n"), nil
+}
+
+// Formats an alert as a plain text email
+func (s *EmailAlertSink) formatMessage(alert Alert, recipients []string) []byte {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", s.cfg.SmtpFrom.Value)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(recipients, ", "))
+	title := strings.Join(strings.Fields(alert.Title), " ")
+	fmt.Fprintf(&message, "Subject: [Hyperdrive] [%s] %s\r\n", strings.ToUpper(string(alert.Severity)), title)
+	fmt.Fprintf(&message, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(strings.ReplaceAll(alert.Message, "\r\n", "\n"), "\n", "\r\n"))
+	message.WriteString("\r\n")
+	return message.Bytes()
+}
+
+// Sends a test email with the current SMTP settings, so operators can check them before relying on email alerts.
+// Returns ErrEmailAlertsNotConfigured if there's no SMTP server set, ErrSmtpConnectionFailed if it can't be reached,
+// and ErrSmtpAuthFailed if it rejects the login.
+func (sp *ServiceProvider) SendTestAlert(ctx context.Context) error {
+	sink := NewEmailAlertSink(sp.cfg.Alerts)
+	return sink.SendAlert(ctx, Alert{
+		Severity: hdconfig.AlertSeverity_Info,
+		Title:    "Test alert",
+		Message:  fmt.Sprintf("This is a test alert from the Hyperdrive daemon on %s. If you're reading this, email alerts are working.", sp.cfg.Network.Value),
+		Time:     time.Now(),
+	})
+}
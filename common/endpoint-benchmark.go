@@ -0,0 +1,242 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+const (
+	// The block used to check if an Execution Client keeps historical state
+	benchmarkArchiveBlock string = "0x1"
+
+	// The slot used to check if a Beacon Node keeps historical state
+	benchmarkArchiveSlot string = "1"
+)
+
+var (
+	// The endpoint didn't respond like an Execution Client or a Beacon Node
+	ErrUnknownEndpoint error = errors.New("the endpoint didn't respond to Execution Client or Beacon Node requests")
+)
+
+// The features an endpoint supports beyond the standard API
+type EndpointCapabilities struct {
+	// True if the endpoint can serve state from the start of the chain
+	IsArchive bool `json:"isArchive"`
+
+	// The JSON-RPC namespaces the endpoint supports; only reported for Execution Clients
+	Namespaces []string `json:"namespaces"`
+}
+
+// The results of benchmarking an endpoint
+type BenchmarkResult struct {
+	// The client type the endpoint was detected as
+	ClientType RpcClientType `json:"clientType"`
+
+	// The number of requests that were sent and how many of them failed
+	Samples int `json:"samples"`
+	Errors  int `json:"errors"`
+
+	// The fraction of requests that failed
+	ErrorRate float64 `json:"errorRate"`
+
+	// Latency percentiles for the successful requests, overall and for each request type
+	Latency       LatencySummary            `json:"latency"`
+	MethodLatency map[string]LatencySummary `json:"methodLatency"`
+
+	// The features the endpoint supports
+	Capabilities EndpointCapabilities `json:"capabilities"`
+}
+
+// A single read-only request used during a benchmark
+type benchmarkCall struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Benchmarks an Execution Client or Beacon Node endpoint by sending it a mix of typical read-only requests and reporting
+// how quickly and reliably it responded, along with the capabilities it supports. Nothing is written to the endpoint.
+// If the context is cancelled partway through, the results of the requests sent so far are returned along with the error.
+func (sp *ServiceProvider) BenchmarkEndpoint(ctx context.Context, url string, sampleCount int) (BenchmarkResult, error) {
+	if sampleCount < 1 {
+		return BenchmarkResult{}, fmt.Errorf("at least one sample is required")
+	}
+
+	// Figure out what kind of client this is
+	var calls []benchmarkCall
+	var capabilities EndpointCapabilities
+	var clientType RpcClientType
+	ecClient, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return BenchmarkResult{}, fmt.Errorf("error connecting to [%s]: %w", url, err)
+	}
+	defer ecClient.Close()
+	var chainID json.RawMessage
+	if ecClient.CallContext(ctx, &chainID, "eth_chainId") == nil {
+		clientType = RpcClientType_ExecutionClient
+		calls = getExecutionBenchmarkCalls(ecClient)
+		capabilities = getExecutionCapabilities(ctx, ecClient)
+	} else {
+		bn := NewBeaconApiClient(url, "", hdconfig.ClientTimeout)
+		_, err = bn.Get(ctx, "/eth/v1/node/syncing", nil, &json.RawMessage{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return BenchmarkResult{}, ctx.Err()
+			}
+			return BenchmarkResult{}, ErrUnknownEndpoint
+		}
+		clientType = RpcClientType_BeaconNode
+		calls = getBeaconBenchmarkCalls(bn)
+		capabilities = getBeaconCapabilities(ctx, bn)
+	}
+
+	// Run the samples, cycling through the calls
+	allLatencies := []time.Duration{}
+	methodLatencies := map[string][]time.Duration{}
+	result := BenchmarkResult{
+		ClientType:   clientType,
+		Capabilities: capabilities,
+	}
+	for i := 0; i < sampleCount; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		call := calls[i%len(calls)]
+		start := time.Now()
+		err := call.run(ctx)
+		latency := time.Since(start)
+		if err != nil && ctx.Err() != nil {
+			// Don't count the request that was cut off by the cancellation
+			break
+		}
+		result.Samples++
+		if err != nil {
+			result.Errors++
+			continue
+		}
+		allLatencies = append(allLatencies, latency)
+		methodLatencies[call.name] = append(methodLatencies[call.name], latency)
+	}
+
+	if result.Samples > 0 {
+		result.ErrorRate = float64(result.Errors) / float64(result.Samples)
+	}
+	result.Latency = getExactLatencySummary(allLatencies)
+	result.MethodLatency = map[string]LatencySummary{}
+	for name, latencies := range methodLatencies {
+		result.MethodLatency[name] = getExactLatencySummary(latencies)
+	}
+	return result, ctx.Err()
+}
+
+// Gets the read-only calls used to benchmark an Execution Client
+func getExecutionBenchmarkCalls(client *rpc.Client) []benchmarkCall {
+	call := func(method string, args ...any) benchmarkCall {
+		return benchmarkCall{
+			name: method,
+			run: func(ctx context.Context) error {
+				var result json.RawMessage
+				return client.CallContext(ctx, &result, method, args...)
+			},
+		}
+	}
+	return []benchmarkCall{
+		call("eth_blockNumber"),
+		call("eth_getBlockByNumber", "latest", false),
+		call("eth_getBalance", "0x0000000000000000000000000000000000000000", "latest"),
+		call("eth_gasPrice"),
+		call("eth_chainId"),
+	}
+}
+
+// Gets the read-only calls used to benchmark a Beacon Node
+func getBeaconBenchmarkCalls(bn *BeaconApiClient) []benchmarkCall {
+	call := func(name string, path string) benchmarkCall {
+		return benchmarkCall{
+			name: name,
+			run: func(ctx context.Context) error {
+				var result json.RawMessage
+				_, err := bn.Get(ctx, path, nil, &result)
+				return err
+			},
+		}
+	}
+	return []benchmarkCall{
+		call("syncing", "/eth/v1/node/syncing"),
+		call("header", "/eth/v1/beacon/headers/head"),
+		call("finality_checkpoints", fmt.Sprintf(beaconFinalityPath, "head")),
+		call("spec", beaconSpecPath),
+	}
+}
+
+// Checks which optional features an Execution Client supports
+func getExecutionCapabilities(ctx context.Context, client *rpc.Client) EndpointCapabilities {
+	capabilities := EndpointCapabilities{
+		Namespaces: []string{},
+	}
+	var balance json.RawMessage
+	capabilities.IsArchive = client.CallContext(ctx, &balance, "eth_getBalance", "0x0000000000000000000000000000000000000000", benchmarkArchiveBlock) == nil
+
+	// Most clients list their namespaces directly; for the rest, probe a cheap method in each common namespace
+	var modules map[string]string
+	if client.CallContext(ctx, &modules, "rpc_modules") == nil {
+		for namespace := range modules {
+			capabilities.Namespaces = append(capabilities.Namespaces, namespace)
+		}
+	} else {
+		probes := map[string]string{
+			"eth":    "eth_chainId",
+			"net":    "net_version",
+			"web3":   "web3_clientVersion",
+			"txpool": "txpool_status",
+		}
+		for namespace, method := range probes {
+			var result json.RawMessage
+			err := client.CallContext(ctx, &result, method)
+			var rpcErr rpc.Error
+			if err == nil || (errors.As(err, &rpcErr) && rpcErr.ErrorCode() != rpcMethodNotFoundCode) {
+				capabilities.Namespaces = append(capabilities.Namespaces, namespace)
+			}
+		}
+	}
+	sort.Strings(capabilities.Namespaces)
+	return capabilities
+}
+
+// Checks which optional features a Beacon Node supports
+func getBeaconCapabilities(ctx context.Context, bn *BeaconApiClient) EndpointCapabilities {
+	var result json.RawMessage
+	exists, err := bn.Get(ctx, fmt.Sprintf(beaconFinalityPath, benchmarkArchiveSlot), nil, &result)
+	return EndpointCapabilities{
+		IsArchive:  exists && err == nil,
+		Namespaces: []string{},
+	}
+}
+
+// Gets the exact latency percentiles of a set of samples
+func getExactLatencySummary(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	percentile := func(p float64) time.Duration {
+		index := int(float64(len(sorted))*p+0.5) - 1
+		return sorted[min(max(index, 0), len(sorted)-1)]
+	}
+	return LatencySummary{
+		Count: uint64(len(sorted)),
+		P50:   percentile(0.50),
+		P95:   percentile(0.95),
+		P99:   percentile(0.99),
+	}
+}
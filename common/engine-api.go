@@ -0,0 +1,166 @@
+package common
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/rocket-pool/node-manager-core/utils"
+)
+
+var (
+	// The Engine API methods the Execution Client has to support for the Beacon Node to follow the chain, as of Cancun
+	requiredEngineApiMethods []string = []string{
+		"engine_newPayloadV3",
+		"engine_forkchoiceUpdatedV3",
+		"engine_getPayloadV3",
+	}
+)
+
+var (
+	// The Execution Client's Engine API couldn't be reached
+	ErrEngineApiUnreachable error = errors.New("the Execution Client's Engine API couldn't be reached")
+
+	// The Execution Client rejected the Engine API secret
+	ErrEngineApiAuthFailed error = errors.New("the Execution Client rejected the Engine API secret; make sure it and the Beacon Node use the same one")
+
+	// The Execution Client doesn't support all of the Engine API methods the Beacon Node needs
+	ErrEngineApiUnsupported error = errors.New("the Execution Client doesn't support the Engine API methods the Beacon Node needs; it may need to be updated")
+
+	// The Execution Client is external and its Engine API URL or secret isn't configured, so it can't be checked
+	ErrEngineApiNotConfigured error = errors.New("the Engine API URL and secret path need to be set to check an external Execution Client")
+)
+
+// Checks that the Execution Client's Engine API is ready for the Beacon Node: it has to accept the Engine API secret
+// and report support for the Engine API methods the Beacon Node needs through engine_exchangeCapabilities. Returns an
+// error wrapping ErrEngineApiUnreachable, ErrEngineApiAuthFailed, or ErrEngineApiUnsupported if it isn't ready, or
+// ErrEngineApiNotConfigured if the Execution Client is external and its Engine API hasn't been set in the config.
+func (sp *ServiceProvider) VerifyEngineApiReady(ctx context.Context) error {
+	url, secret, err := sp.getEngineApiEndpoint(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := rpc.DialOptions(ctx, url, rpc.WithHTTPAuth(newEngineApiAuth(secret)))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrEngineApiUnreachable, err.Error())
+	}
+	defer client.Close()
+
+	var supported []string
+	err = client.CallContext(ctx, &supported, "engine_exchangeCapabilities", requiredEngineApiMethods)
+	if err != nil {
+		var httpErr rpc.HTTPError
+		if errors.As(err, &httpErr) {
+			if httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden {
+				return fmt.Errorf("%w (HTTP status %d)", ErrEngineApiAuthFailed, httpErr.StatusCode)
+			}
+			return fmt.Errorf("%w: %s", ErrEngineApiUnreachable, err.Error())
+		}
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			// The client is up but doesn't know the method, which is as good as not supporting the Engine API
+			return fmt.Errorf("%w: engine_exchangeCapabilities failed: %s", ErrEngineApiUnsupported, err.Error())
+		}
+		return fmt.Errorf("%w: %s", ErrEngineApiUnreachable, err.Error())
+	}
+
+	missing := []string{}
+	for _, method := range requiredEngineApiMethods {
+		if !slices.Contains(supported, method) {
+			missing = append(missing, method)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrEngineApiUnsupported, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Gets the URL of the Execution Client's Engine API and the secret it authenticates with. Either can be set in the
+// config; otherwise, they come from the Execution Client and Beacon Node containers Hyperdrive manages.
+func (sp *ServiceProvider) getEngineApiEndpoint(ctx context.Context) (string, [32]byte, error) {
+	url := sp.cfg.EngineApiUrl.Value
+	secretPath := sp.cfg.EngineJwtSecretPath.Value
+	if !sp.cfg.IsLocalMode() && (url == "" || secretPath == "") {
+		return "", [32]byte{}, ErrEngineApiNotConfigured
+	}
+
+	ecContainer := sp.cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))
+	if url == "" {
+		url = fmt.Sprintf("http://%s:%d", ecContainer, sp.cfg.LocalExecutionClient.EnginePort.Value)
+	}
+	if secretPath == "" {
+		bnContainer := sp.cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+		jwtFile, err := sp.getEngineJwtFile(ctx, ecContainer, bnContainer)
+		if err != nil {
+			return "", [32]byte{}, err
+		}
+		secretPath = jwtFile.path
+	}
+
+	contents, err := os.ReadFile(secretPath)
+	if err != nil {
+		return "", [32]byte{}, fmt.Errorf("error reading Engine API secret [%s]: %w", secretPath, err)
+	}
+	decoded, err := utils.DecodeHex(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return "", [32]byte{}, fmt.Errorf("error decoding Engine API secret [%s]: %w", secretPath, err)
+	}
+	if len(decoded) != engineJwtSecretLength {
+		return "", [32]byte{}, fmt.Errorf("Engine API secret [%s] is %d bytes, but it has to be %d", secretPath, len(decoded), engineJwtSecretLength)
+	}
+	return url, [32]byte(decoded), nil
+}
+
+// Creates an authenticator that signs each Engine API request with a fresh HS256 JWT, as the Engine API's authentication
+// spec requires; the issued-at claim is the only one, and clients reject tokens issued more than a minute from their own
+// clock
+func newEngineApiAuth(secret [32]byte) rpc.HTTPAuth {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	return func(h http.Header) error {
+		claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"iat":%d}`, time.Now().Unix())))
+		mac := hmac.New(sha256.New, secret[:])
+		mac.Write([]byte(header + "." + claims))
+		signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+		h.Set("Authorization", "Bearer "+header+"."+claims+"."+signature)
+		return nil
+	}
+}
+
+// Waits for the Execution Client's Engine API to come up during startup. Only an unreachable Engine API is retried,
+// since a rejected secret or a missing method won't fix itself. Externally managed clients without a configured Engine
+// API are skipped.
+func (sp *ServiceProvider) waitForEngineApi(ctx context.Context, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(bootPollInterval)
+	defer ticker.Stop()
+	for {
+		err := sp.VerifyEngineApiReady(waitCtx)
+		if err == nil || errors.Is(err, ErrEngineApiNotConfigured) {
+			return nil
+		}
+		if !errors.Is(err, ErrEngineApiUnreachable) {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("the Execution Client's Engine API didn't come up within %s: %w", timeout, err)
+		}
+	}
+}
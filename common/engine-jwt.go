@@ -0,0 +1,272 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The length of the Engine API secret, in bytes
+	engineJwtSecretLength int = 32
+)
+
+var (
+	// JWT rotation is disabled in the config
+	ErrEngineJwtRotationDisabled error = errors.New("Engine API secret rotation is disabled. Enable it in the Hyperdrive settings first.")
+
+	// The clients aren't managed by Hyperdrive, so their secret can't be rotated
+	ErrEngineJwtExternalClients error = errors.New("Engine API secret rotation is only available when Hyperdrive manages your Execution Client and Beacon Node.")
+)
+
+// The location of the Engine API secret shared by the Execution Client and Beacon Node
+type engineJwtFile struct {
+	// The path of the secret on the host
+	path string
+
+	// True if the file itself is bind mounted rather than the directory it's in
+	isFileMount bool
+}
+
+// Replaces the secret the Execution Client and Beacon Node use to authenticate the Engine API, then restarts both clients
+// together so neither is left using the old secret while the other has the new one. Returns once both clients are
+// accepting requests again and the Beacon Node has reconnected to the Execution Client's Engine API.
+func (sp *ServiceProvider) RotateEngineJwt(ctx context.Context) error {
+	if !sp.cfg.EnableEngineJwtRotation.Value {
+		return ErrEngineJwtRotationDisabled
+	}
+	if !sp.cfg.IsLocalMode() {
+		return ErrEngineJwtExternalClients
+	}
+
+	// Find the secret both clients read
+	ecContainer := sp.cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))
+	bnContainer := sp.cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	jwtFile, err := sp.getEngineJwtFile(ctx, ecContainer, bnContainer)
+	if err != nil {
+		return err
+	}
+
+	// Write the new secret
+	secret := make([]byte, engineJwtSecretLength)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return fmt.Errorf("error generating Engine API secret: %w", err)
+	}
+	err = writeEngineJwt(jwtFile, secret)
+	if err != nil {
+		return err
+	}
+
+	// Restart both clients at the same time. Each restart takes its own container operation slot, so they run one after
+	// the other if only one slot is free; either way both are restarted before waiting on them.
+	d := sp.GetDocker()
+	errs := make([]error, 2)
+	wg := &sync.WaitGroup{}
+	for i, id := range []string{ecContainer, bnContainer} {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs[i] = sp.RunContainerOp(ctx, func() error {
+				err := d.ContainerRestart(ctx, id, container.StopOptions{})
+				if err != nil {
+					return fmt.Errorf("error restarting container [%s]: %w", id, err)
+				}
+				return nil
+			})
+		}(i, id)
+	}
+	wg.Wait()
+	err = errors.Join(errs...)
+	if err != nil {
+		return err
+	}
+
+	// Make sure they both came back, and that the Beacon Node could authenticate with the new secret
+	timeout := sp.cfg.GetStartupTimeout()
+	err = waitForClient(ctx, executionClientName, sp.checkExecutionClientReachable, timeout)
+	if err != nil {
+		return err
+	}
+	err = waitForClient(ctx, beaconNodeName, sp.checkBeaconNodeReachable, timeout)
+	if err != nil {
+		return err
+	}
+	return waitForClient(ctx, "Beacon Node's connection to the Execution Client", sp.checkBeaconNodeEngineOnline, timeout)
+}
+
+// Checks that the Beacon Node can reach its Execution Client over the Engine API
+func (sp *ServiceProvider) checkBeaconNodeEngineOnline(ctx context.Context) error {
+	status, err := sp.GetBeaconApiClient().GetSyncStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if status.ElOffline {
+		return errors.New("the Beacon Node reports its Execution Client as offline")
+	}
+	return nil
+}
+
+// Finds the Engine API secret from the bind mounts of the Execution Client and Beacon Node containers. The secret must be
+// mounted into both of them, and readable by the users they run as. The daemon sees the host's paths at the same location,
+// as it does with the user directory.
+func (sp *ServiceProvider) getEngineJwtFile(ctx context.Context, ecContainer string, bnContainer string) (engineJwtFile, error) {
+	d := sp.GetDocker()
+	ecInfo, err := d.ContainerInspect(ctx, ecContainer)
+	if err != nil {
+		return engineJwtFile{}, fmt.Errorf("error inspecting container [%s]: %w", ecContainer, err)
+	}
+	bnInfo, err := d.ContainerInspect(ctx, bnContainer)
+	if err != nil {
+		return engineJwtFile{}, fmt.Errorf("error inspecting container [%s]: %w", bnContainer, err)
+	}
+
+	// Look for a secret that's visible through the mounts of both containers
+	bnCandidates := getEngineJwtCandidates(bnInfo.Mounts)
+	for _, candidate := range getEngineJwtCandidates(ecInfo.Mounts) {
+		if !slices.Contains(bnCandidates, candidate) {
+			continue
+		}
+		path := candidate.path
+		fileInfo, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return engineJwtFile{}, fmt.Errorf("error checking Engine API secret [%s]: %w", path, err)
+		}
+		if !fileInfo.Mode().IsRegular() {
+			continue
+		}
+		for _, info := range []struct {
+			name string
+			user string
+		}{
+			{ecContainer, getContainerUser(ecInfo.Config)},
+			{bnContainer, getContainerUser(bnInfo.Config)},
+		} {
+			err = checkEngineJwtReadable(fileInfo, info.user)
+			if err != nil {
+				return engineJwtFile{}, fmt.Errorf("container [%s] can't read the Engine API secret [%s]: %w", info.name, path, err)
+			}
+		}
+		return candidate, nil
+	}
+	return engineJwtFile{}, fmt.Errorf("couldn't find a file named [%s] mounted into both [%s] and [%s]", hdconfig.EngineJwtFilename, ecContainer, bnContainer)
+}
+
+// Gets the host paths where a container's bind mounts could provide the Engine API secret, in mount order
+func getEngineJwtCandidates(mounts []types.MountPoint) []engineJwtFile {
+	candidates := []engineJwtFile{}
+	for _, mountPoint := range mounts {
+		if mountPoint.Type != mount.TypeBind {
+			continue
+		}
+		if filepath.Base(mountPoint.Source) == hdconfig.EngineJwtFilename {
+			candidates = append(candidates, engineJwtFile{path: mountPoint.Source, isFileMount: true})
+		} else {
+			candidates = append(candidates, engineJwtFile{path: filepath.Join(mountPoint.Source, hdconfig.EngineJwtFilename)})
+		}
+	}
+	return candidates
+}
+
+// Gets the user a container runs as, or blank if it runs as the image's default user
+func getContainerUser(cfg *container.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.User
+}
+
+// Checks that a container running as the provided user (in Docker's `uid[:gid]` format) can read the file.
+// Root and named users are assumed to have access, since their IDs can only be resolved inside the container.
+func checkEngineJwtReadable(fileInfo fs.FileInfo, user string) error {
+	uidString, gidString, _ := strings.Cut(user, ":")
+	if uidString == "" || uidString == "0" || uidString == "root" {
+		return nil
+	}
+	uid, err := strconv.ParseUint(uidString, 10, 32)
+	if err != nil {
+		return nil
+	}
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	mode := fileInfo.Mode().Perm()
+	if uint32(uid) == stat.Uid {
+		if mode&0400 == 0 {
+			return fmt.Errorf("it's owned by user %d but its mode is %04o", uid, mode)
+		}
+		return nil
+	}
+	if gidString != "" {
+		gid, err := strconv.ParseUint(gidString, 10, 32)
+		if err == nil && uint32(gid) == stat.Gid && mode&0040 != 0 {
+			return nil
+		}
+	}
+	if mode&0004 == 0 {
+		return fmt.Errorf("the container runs as user %s, which isn't its owner (%d:%d), and its mode is %04o", user, stat.Uid, stat.Gid, mode)
+	}
+	return nil
+}
+
+// Writes the Engine API secret as hex, keeping the old file's mode and owner so the clients can still read it.
+// Directory mounts get the file replaced atomically so neither client can read a partial secret; a file that's bind
+// mounted directly has to be rewritten in place, since the containers would keep seeing the old file after a rename.
+func writeEngineJwt(jwtFile engineJwtFile, secret []byte) error {
+	fileInfo, err := os.Stat(jwtFile.path)
+	if err != nil {
+		return fmt.Errorf("error checking Engine API secret: %w", err)
+	}
+	contents := []byte(hex.EncodeToString(secret))
+	if jwtFile.isFileMount {
+		err = os.WriteFile(jwtFile.path, contents, fileInfo.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("error writing Engine API secret: %w", err)
+		}
+		return nil
+	}
+
+	tempPath := jwtFile.path + ".tmp"
+	err = os.WriteFile(tempPath, contents, fileInfo.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("error writing Engine API secret: %w", err)
+	}
+	err = os.Chmod(tempPath, fileInfo.Mode().Perm())
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("error setting Engine API secret permissions: %w", err)
+	}
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		err = os.Lchown(tempPath, int(stat.Uid), int(stat.Gid))
+		if err != nil {
+			_ = os.Remove(tempPath)
+			return fmt.Errorf("error setting Engine API secret owner: %w", err)
+		}
+	}
+	err = os.Rename(tempPath, jwtFile.path)
+	if err != nil {
+		return fmt.Errorf("error replacing Engine API secret: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	// How often to check if an executed action's transaction has been included
+	actionReceiptPollInterval time.Duration = time.Second
+
+	// How many times in a row an executed action's transaction can be missing from the Execution Client before it's
+	// considered dropped
+	actionReceiptMaxMisses int = 5
+
+	// The part of the Multicall3 ABI used to bundle actions
+	multicall3AbiString string = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"uint256","name":"value","type":"uint256"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3Value[]","name":"calls","type":"tuple[]"}],"name":"aggregate3Value","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+)
+
+var (
+	// The address Multicall3 is deployed to on every network that has it
+	Multicall3Address ethcommon.Address = ethcommon.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+	// Multicall3 isn't deployed on the network, so actions can't be bundled
+	ErrMulticallNotDeployed error = errors.New("multicall3 isn't deployed")
+)
+
+// Options for executing a batch of pending actions
+type ExecuteOptions struct {
+	// Send the bundleable actions in a single Multicall3 transaction, so they either all succeed or all revert. This only
+	// happens if there are at least two of them and Multicall3 is deployed; otherwise they're sent one at a time.
+	Bundle bool
+}
+
+// The outcome of executing one pending action
+type ActionResult struct {
+	// The action that was executed
+	Action PendingAction `json:"action"`
+
+	// The hash of the transaction the action was sent in, which is shared by every action in a bundle; empty if it
+	// wasn't sent
+	TxHash ethcommon.Hash `json:"txHash"`
+
+	// The receipt of the action's transaction, once it was included
+	Receipt *types.Receipt `json:"receipt,omitempty"`
+
+	// True if the action was sent as part of a Multicall3 bundle
+	Bundled bool `json:"bundled"`
+
+	// True if the action's transaction was included and didn't revert
+	Success bool `json:"success"`
+
+	// Why the action failed or was skipped
+	Error string `json:"error,omitempty"`
+
+	// The action's transaction, if it's known, used to tell when it's been replaced
+	tx *types.Transaction
+}
+
+// A Multicall3 Call3Value struct
+type multicall3Call struct {
+	Target       ethcommon.Address
+	AllowFailure bool
+	Value        *big.Int
+	CallData     []byte
+}
+
+// Executes a batch of pending actions and waits for their transactions to be included. Actions with a transaction sent
+// from the node's address are submitted directly with consecutive nonces; the rest are submitted with their execute
+// function. If opts.Bundle is set, the bundleable actions without dependencies are sent together in one Multicall3
+// transaction first, falling back to sending them individually if the bundle can't be submitted.
+//
+// An action is only submitted once the actions it depends on have succeeded, so dependencies have to come before the
+// actions that need them; dependencies that aren't in the batch are assumed to have been executed already. A failing
+// action doesn't stop the batch: it's reported in its result, actions that depend on it are skipped, and the rest are
+// still executed. An action whose transaction disappears from the Execution Client, or whose nonce gets used by another
+// transaction, fails instead of being waited on forever. The results are in the same order as the actions. Returns an
+// error if the node wallet isn't ready, the batch is invalid, the node's nonce can't be read, or the context is
+// cancelled, in which case the results so far are returned with it.
+func (sp *ServiceProvider) ExecuteActions(ctx context.Context, actions []PendingAction, opts ExecuteOptions) ([]ActionResult, error) {
+	if err := sp.RequireWalletReady(); err != nil {
+		return nil, err
+	}
+	positions, err := getActionPositions(actions)
+	if err != nil {
+		return nil, err
+	}
+	from := sp.GetSignerTransactor(ctx).From
+	nonce, err := sp.GetEthClient().PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the pending nonce of %s: %w", from.Hex(), err)
+	}
+	results := make([]ActionResult, len(actions))
+	for i, action := range actions {
+		results[i].Action = action
+	}
+
+	if opts.Bundle {
+		bundle := []int{}
+		for i, action := range actions {
+			if action.Bundleable && len(action.DependsOn) == 0 && canSubmitAction(action, from) {
+				bundle = append(bundle, i)
+			}
+		}
+		if len(bundle) > 1 {
+			tx, err := sp.submitActionBundle(ctx, actions, bundle, nonce)
+			if err != nil {
+				sp.GetApiLogger().Warn("Couldn't bundle the pending actions, sending them individually", slog.Int("actions", len(bundle)), slog.String(log.ErrorKey, err.Error()))
+			} else {
+				nonce++
+				for _, i := range bundle {
+					results[i].TxHash = tx.Hash()
+					results[i].Bundled = true
+					results[i].tx = tx
+				}
+			}
+		}
+	}
+
+	for i, action := range actions {
+		if results[i].Bundled {
+			continue
+		}
+		failed, err := sp.waitForActionDependencies(ctx, results, positions, i)
+		if err != nil {
+			return results, err
+		}
+		if failed != "" {
+			results[i].Error = fmt.Sprintf("skipped because [%s] didn't succeed", failed)
+			continue
+		}
+		results[i].TxHash, results[i].tx, nonce, err = sp.submitAction(ctx, action, from, nonce)
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+
+	for i := range results {
+		if err := sp.waitForActionReceipt(ctx, &results[i]); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Maps the IDs of a batch of actions to their positions, checking that each one is only included once, can be executed,
+// and comes after its dependencies
+func getActionPositions(actions []PendingAction) (map[string]int, error) {
+	positions := map[string]int{}
+	for i, action := range actions {
+		if _, exists := positions[action.ID]; exists {
+			return nil, fmt.Errorf("pending action [%s] is included more than once", action.ID)
+		}
+		if action.Execute == nil && action.Transaction == nil {
+			return nil, fmt.Errorf("pending action [%s] can't be executed", action.ID)
+		}
+		positions[action.ID] = i
+	}
+	for i, action := range actions {
+		for _, dependency := range action.DependsOn {
+			if position, exists := positions[dependency]; exists && position >= i {
+				return nil, fmt.Errorf("pending action [%s] depends on [%s], which comes after it", action.ID, dependency)
+			}
+		}
+	}
+	return positions, nil
+}
+
+// Checks if an action's transaction can be submitted directly from the node's address
+func canSubmitAction(action PendingAction, from ethcommon.Address) bool {
+	return action.Transaction != nil && action.Transaction.To() != nil && action.From == from
+}
+
+// Submits a single action with the provided nonce if its transaction is sent from the node's address, or with its
+// execute function otherwise. Returns the hash of its transaction, the transaction itself if it was sent directly, and
+// the nonce to use for the next one.
+func (sp *ServiceProvider) submitAction(ctx context.Context, action PendingAction, from ethcommon.Address, nonce uint64) (ethcommon.Hash, *types.Transaction, uint64, error) {
+	if canSubmitAction(action, from) {
+		opts := sp.GetSignerTransactor(ctx)
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		opts.Value = action.Transaction.Value()
+		tx, err := sp.submitTransaction(opts, *action.Transaction.To(), action.Transaction.Data())
+		if err != nil {
+			return ethcommon.Hash{}, nil, nonce, err
+		}
+		return tx.Hash(), tx, nonce + 1, nil
+	}
+	if action.Execute == nil {
+		return ethcommon.Hash{}, nil, nonce, fmt.Errorf("pending action [%s] isn't sent from %s and can't be executed on its own", action.ID, from.Hex())
+	}
+
+	hash, err := action.Execute(ctx)
+	if err != nil {
+		return ethcommon.Hash{}, nil, nonce, fmt.Errorf("error executing pending action [%s]: %w", action.ID, err)
+	}
+
+	// The action may have sent its transaction from the node's address, which would have used up the next nonce
+	next, err := sp.GetEthClient().PendingNonceAt(ctx, from)
+	if err != nil {
+		sp.GetApiLogger().Warn("Couldn't refresh the pending nonce after executing an action", slog.String("action", action.ID), slog.String(log.ErrorKey, err.Error()))
+		return hash, nil, nonce, nil
+	}
+	return hash, nil, max(nonce, next), nil
+}
+
+// Submits a set of actions together in one Multicall3 transaction with the provided nonce. None of the calls are
+// allowed to fail, so the whole transaction reverts if any of them would.
+func (sp *ServiceProvider) submitActionBundle(ctx context.Context, actions []PendingAction, bundle []int, nonce uint64) (*types.Transaction, error) {
+	code, err := sp.GetEthClient().CodeAt(ctx, Multicall3Address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error checking for multicall3: %w", err)
+	}
+	if len(code) == 0 {
+		return nil, ErrMulticallNotDeployed
+	}
+
+	calls := make([]multicall3Call, len(bundle))
+	value := big.NewInt(0)
+	for i, position := range bundle {
+		tx := actions[position].Transaction
+		calls[i] = multicall3Call{
+			Target:   *tx.To(),
+			Value:    tx.Value(),
+			CallData: tx.Data(),
+		}
+		value.Add(value, tx.Value())
+	}
+	multicallAbi, err := abi.JSON(strings.NewReader(multicall3AbiString))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the multicall3 ABI: %w", err)
+	}
+	data, err := multicallAbi.Pack("aggregate3Value", calls)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the bundle: %w", err)
+	}
+
+	opts := sp.GetSignerTransactor(ctx)
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.Value = value
+	return sp.submitTransaction(opts, Multicall3Address, data)
+}
+
+// Waits for the dependencies of an action that are in the batch to finish, returning the ID of the first one that
+// didn't succeed; blank if they all did
+func (sp *ServiceProvider) waitForActionDependencies(ctx context.Context, results []ActionResult, positions map[string]int, action int) (string, error) {
+	for _, dependency := range results[action].Action.DependsOn {
+		position, exists := positions[dependency]
+		if !exists {
+			continue
+		}
+		if err := sp.waitForActionReceipt(ctx, &results[position]); err != nil {
+			return "", err
+		}
+		if !results[position].Success {
+			return dependency, nil
+		}
+	}
+	return "", nil
+}
+
+// Waits for an action's transaction to be included and records its receipt. Does nothing if the action wasn't sent or
+// its receipt is already known. The action fails if its transaction can't be found several times in a row, or if its
+// sender's nonce moves past it without it being included. Only returns an error if the context is cancelled.
+func (sp *ServiceProvider) waitForActionReceipt(ctx context.Context, result *ActionResult) error {
+	if result.TxHash == (ethcommon.Hash{}) || result.Receipt != nil {
+		return nil
+	}
+	ticker := time.NewTicker(actionReceiptPollInterval)
+	defer ticker.Stop()
+	misses := 0
+	for {
+		receipt, err := sp.GetEthClient().TransactionReceipt(ctx, result.TxHash)
+		if err == nil {
+			result.Receipt = receipt
+			result.Success = receipt.Status == types.ReceiptStatusSuccessful
+			if !result.Success {
+				result.Error = "the transaction reverted"
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		failure := sp.checkActionTransaction(ctx, result, &misses)
+		if failure != "" {
+			result.Error = failure
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Checks on an action's transaction that doesn't have a receipt yet, returning why it won't ever be included; blank if
+// it still might be. misses tracks how many times in a row it couldn't be found.
+func (sp *ServiceProvider) checkActionTransaction(ctx context.Context, result *ActionResult, misses *int) string {
+	ec := sp.GetEthClient()
+	tx, _, err := ec.TransactionByHash(ctx, result.TxHash)
+	if err != nil {
+		*misses++
+		if *misses >= actionReceiptMaxMisses {
+			return fmt.Sprintf("the transaction couldn't be found after %d attempts, so it was probably dropped", *misses)
+		}
+		tx = result.tx
+	} else {
+		*misses = 0
+		result.tx = tx
+	}
+	if tx == nil {
+		return ""
+	}
+
+	// Once a later transaction from the same sender is included, this one can't be
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return ""
+	}
+	nonce, err := ec.NonceAt(ctx, sender, nil)
+	if err != nil || nonce <= tx.Nonce() {
+		return ""
+	}
+
+	// It may have been included since the last check
+	if _, err := ec.TransactionReceipt(ctx, result.TxHash); err == nil {
+		return ""
+	}
+	return fmt.Sprintf("the transaction was replaced, since nonce %d of %s has been used by another transaction", tx.Nonce(), sender.Hex())
+}
@@ -0,0 +1,125 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// How many blocks the Execution Client's finalized block can trail its latest one before it's flagged. This is
+	// FinalityStallThreshold epochs of 32 slots; missed slots only make the gap smaller, so a healthy chain stays under it.
+	ExecutionFinalityGapThreshold uint64 = FinalityStallThreshold * 32
+)
+
+// The number and hash of an Execution layer block
+type BlockPointer struct {
+	Number uint64         `json:"number"`
+	Hash   ethcommon.Hash `json:"hash"`
+}
+
+// The Execution Client's view of the chain, as forwarded to it by the Beacon Node
+type BlockPointers struct {
+	Latest    BlockPointer `json:"latest"`
+	Safe      BlockPointer `json:"safe"`
+	Finalized BlockPointer `json:"finalized"`
+
+	// True if the finalized block trails the latest one by more than ExecutionFinalityGapThreshold blocks
+	PossibleFinalityIssue bool `json:"possibleFinalityIssue"`
+}
+
+// Gets the number of blocks between the latest and finalized blocks
+func (p BlockPointers) GetBlocksSinceFinality() uint64 {
+	if p.Latest.Number < p.Finalized.Number {
+		return 0
+	}
+	return p.Latest.Number - p.Finalized.Number
+}
+
+// A comparison of the Execution Client's finalized block with the Beacon Node's
+type FinalityCrossCheck struct {
+	// The Execution Client's block pointers
+	Execution BlockPointers `json:"execution"`
+
+	// The Execution layer block in the Beacon Node's finalized block
+	BeaconFinalized BlockPointer `json:"beaconFinalized"`
+
+	// True if both clients have the same finalized block
+	Consistent bool `json:"consistent"`
+}
+
+// Gets the Execution Client's latest, safe, and finalized blocks. The finalized block is fetched first and the latest
+// one last, so the pointers stay in order even if new blocks arrive in between.
+func (sp *ServiceProvider) GetExecutionBlockPointers(ctx context.Context) (BlockPointers, error) {
+	client, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return BlockPointers{}, err
+	}
+	defer client.Close()
+
+	pointers := BlockPointers{}
+	for _, tag := range []struct {
+		name    string
+		pointer *BlockPointer
+	}{
+		{"finalized", &pointers.Finalized},
+		{"safe", &pointers.Safe},
+		{"latest", &pointers.Latest},
+	} {
+		*tag.pointer, err = getExecutionBlockPointer(ctx, client, tag.name)
+		if err != nil {
+			return BlockPointers{}, err
+		}
+	}
+	pointers.PossibleFinalityIssue = pointers.GetBlocksSinceFinality() > ExecutionFinalityGapThreshold
+	return pointers, nil
+}
+
+// Compares the Execution Client's finalized block with the one in the Beacon Node's finalized block. They can briefly
+// differ right after finality advances, since the Beacon Node has to pass it on; a persistent mismatch means the
+// Execution Client isn't following the Beacon Node.
+func (sp *ServiceProvider) CrossCheckFinality(ctx context.Context) (FinalityCrossCheck, error) {
+	payload, exists, err := sp.GetBeaconApiClient().GetBlockExecutionPayload(ctx, "finalized")
+	if err != nil {
+		return FinalityCrossCheck{}, err
+	}
+	if !exists {
+		return FinalityCrossCheck{}, errors.New("the Beacon Node's finalized block doesn't have an execution payload")
+	}
+	pointers, err := sp.GetExecutionBlockPointers(ctx)
+	if err != nil {
+		return FinalityCrossCheck{}, err
+	}
+	beaconFinalized := BlockPointer{
+		Number: uint64(payload.BlockNumber),
+		Hash:   ethcommon.BytesToHash(payload.BlockHash),
+	}
+	return FinalityCrossCheck{
+		Execution:       pointers,
+		BeaconFinalized: beaconFinalized,
+		Consistent:      pointers.Finalized == beaconFinalized,
+	}, nil
+}
+
+// Gets the number and hash of the block with the provided tag
+func getExecutionBlockPointer(ctx context.Context, client *rpc.Client, tag string) (BlockPointer, error) {
+	var block *struct {
+		Number hexutil.Uint64 `json:"number"`
+		Hash   ethcommon.Hash `json:"hash"`
+	}
+	err := client.CallContext(ctx, &block, "eth_getBlockByNumber", tag, false)
+	if err != nil {
+		return BlockPointer{}, fmt.Errorf("error getting %s block: %w", tag, err)
+	}
+	if block == nil {
+		return BlockPointer{}, fmt.Errorf("the Execution Client doesn't have a %s block", tag)
+	}
+	return BlockPointer{
+		Number: uint64(block.Number),
+		Hash:   block.Hash,
+	}, nil
+}
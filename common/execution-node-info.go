@@ -0,0 +1,103 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// The JSON-RPC error code Besu uses for methods in namespaces that aren't enabled
+	rpcMethodNotEnabledCode int = -32604
+)
+
+var (
+	// The Execution Client doesn't have the admin namespace enabled
+	ErrAdminNamespaceDisabled error = errors.New("the Execution Client doesn't have the admin API enabled")
+)
+
+// The Execution Client's P2P identity, for peering with it manually
+type ExecutionNodeInfo struct {
+	// The node's enode URL
+	Enode string `json:"enode"`
+
+	// The node's ENR, if the client reports one
+	Enr string `json:"enr,omitempty"`
+
+	// The node's ID
+	ID string `json:"id"`
+
+	// The client's version string
+	ClientVersion string `json:"clientVersion"`
+
+	// The IP address the node advertises
+	IP string `json:"ip"`
+
+	// The address the node listens for P2P connections on
+	ListenAddr string `json:"listenAddr"`
+
+	// The UDP port the node uses for discovery
+	DiscoveryPort uint16 `json:"discoveryPort"`
+
+	// The TCP port the node accepts peer connections on
+	ListenerPort uint16 `json:"listenerPort"`
+
+	// The names of the P2P protocols the node runs (e.g. eth and snap), sorted
+	Protocols []string `json:"protocols"`
+}
+
+// The response of admin_nodeInfo; clients report more than this, but these are the common fields
+type adminNodeInfo struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Enode      string `json:"enode"`
+	Enr        string `json:"enr"`
+	IP         string `json:"ip"`
+	ListenAddr string `json:"listenAddr"`
+	Ports      struct {
+		Discovery uint16 `json:"discovery"`
+		Listener  uint16 `json:"listener"`
+	} `json:"ports"`
+	Protocols map[string]json.RawMessage `json:"protocols"`
+}
+
+// Gets the primary Execution Client's enode, ports, protocols, and version from admin_nodeInfo. Returns
+// ErrAdminNamespaceDisabled if the client doesn't have the admin API enabled.
+func (sp *ServiceProvider) GetExecutionNodeInfo(ctx context.Context) (ExecutionNodeInfo, error) {
+	client, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return ExecutionNodeInfo{}, err
+	}
+	defer client.Close()
+
+	var response adminNodeInfo
+	err = client.CallContext(ctx, &response, "admin_nodeInfo")
+	if err != nil {
+		var rpcErr rpc.Error
+		if isRpcMethodNotFound(err) || (errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotEnabledCode) {
+			return ExecutionNodeInfo{}, ErrAdminNamespaceDisabled
+		}
+		return ExecutionNodeInfo{}, fmt.Errorf("error getting Execution Client node info: %w", err)
+	}
+
+	info := ExecutionNodeInfo{
+		Enode:         response.Enode,
+		Enr:           response.Enr,
+		ID:            response.ID,
+		ClientVersion: response.Name,
+		IP:            response.IP,
+		ListenAddr:    response.ListenAddr,
+		DiscoveryPort: response.Ports.Discovery,
+		ListenerPort:  response.Ports.Listener,
+		Protocols:     make([]string, 0, len(response.Protocols)),
+	}
+	for protocol := range response.Protocols {
+		info.Protocols = append(info.Protocols, protocol)
+	}
+	sort.Strings(info.Protocols)
+	return info, nil
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+)
+
+var (
+	// The system contract that queues withdrawal requests on the execution layer (EIP-7002). It's at the same address on
+	// every network.
+	WithdrawalRequestPredeployAddress ethcommon.Address = ethcommon.HexToAddress("0x00000961Ef480Eb55e80D19ad83579A64c007002")
+
+	// The system contract that queues consolidation requests on the execution layer (EIP-7251). It's at the same address
+	// on every network.
+	ConsolidationRequestPredeployAddress ethcommon.Address = ethcommon.HexToAddress("0x0000BBdDc7CE488642fb579F8B00f3a590007251")
+)
+
+var (
+	// The validator isn't on the Beacon Chain
+	ErrValidatorNotFound error = errors.New("the validator isn't on the Beacon Chain")
+
+	// The validator isn't active, so the Beacon Chain would ignore requests for it
+	ErrValidatorNotActive error = errors.New("the validator isn't active")
+
+	// The validator's withdrawal credentials are still a BLS key, so it can't be managed from the execution layer
+	ErrNoExecutionCredentials error = errors.New("the validator doesn't have execution withdrawal credentials")
+
+	// The validator's withdrawal credentials point to an address other than the node's
+	ErrValidatorNotOwned error = errors.New("the validator's withdrawal address isn't the node's address")
+)
+
+// A validator that's managed by the node through execution layer requests
+type nodeValidator struct {
+	client.Validator
+
+	// The type of the validator's withdrawal credentials
+	CredentialType WithdrawalCredentialType
+}
+
+// Gets a validator on the head state, making sure it's active and its withdrawal credentials are an address owned by the
+// node. Requests are credited to the address that sends them, so this is the transaction signer's address.
+func (sp *ServiceProvider) getNodeValidator(ctx context.Context, pubkey beacon.ValidatorPubkey) (nodeValidator, error) {
+	nodeAddress := sp.GetTxSigner().Address()
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, "head", []string{pubkey.HexWithPrefix()}, nil)
+	if err != nil {
+		return nodeValidator{}, err
+	}
+	if len(validators) == 0 {
+		return nodeValidator{}, fmt.Errorf("%w: %s", ErrValidatorNotFound, pubkey.HexWithPrefix())
+	}
+	validator := validators[0]
+	if validator.Status != string(beacon.ValidatorState_ActiveOngoing) {
+		return nodeValidator{}, fmt.Errorf("%w: %s is %s", ErrValidatorNotActive, pubkey.HexWithPrefix(), validator.Status)
+	}
+
+	credentials := validator.Validator.WithdrawalCredentials
+	if len(credentials) != 32 {
+		return nodeValidator{}, fmt.Errorf("validator %s has invalid withdrawal credentials [%x]", pubkey.HexWithPrefix(), credentials)
+	}
+	credentialType := WithdrawalCredentialType(credentials[0])
+	if credentialType != WithdrawalCredentialType_Execution && credentialType != WithdrawalCredentialType_Compounding {
+		return nodeValidator{}, fmt.Errorf("%w: %s has type 0x%02x credentials", ErrNoExecutionCredentials, pubkey.HexWithPrefix(), byte(credentialType))
+	}
+	withdrawalAddress := ethcommon.BytesToAddress(credentials[12:])
+	if withdrawalAddress != nodeAddress {
+		return nodeValidator{}, fmt.Errorf("%w: %s withdraws to %s", ErrValidatorNotOwned, pubkey.HexWithPrefix(), withdrawalAddress.Hex())
+	}
+	return nodeValidator{
+		Validator:      validator,
+		CredentialType: credentialType,
+	}, nil
+}
+
+// Submits an execution layer request to one of the request system contracts with the transaction signer, paying the
+// contract's current fee. The fee goes up with the number of requests waiting in the contract's queue; anything paid
+// over it isn't refunded, so it's read right before the transaction is built.
+func (sp *ServiceProvider) submitExecutionRequest(ctx context.Context, contract ethcommon.Address, data []byte) (ethcommon.Hash, error) {
+	fee, err := sp.getExecutionRequestFee(ctx, contract)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+
+	opts := sp.GetSignerTransactor(ctx)
+	opts.Value = fee
+	tx, err := sp.submitTransaction(opts, contract, data)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// Gets the fee (in wei) a request system contract currently charges for each request
+func (sp *ServiceProvider) getExecutionRequestFee(ctx context.Context, contract ethcommon.Address) (*big.Int, error) {
+	result, err := sp.GetEthClient().CallContract(ctx, ethereum.CallMsg{
+		To: &contract,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the request fee from %s: %w", contract.Hex(), err)
+	}
+	if len(result) != 32 {
+		return nil, fmt.Errorf("error getting the request fee from %s: expected 32 bytes but got %d; is the contract deployed?", contract.Hex(), len(result))
+	}
+	return new(big.Int).SetBytes(result), nil
+}
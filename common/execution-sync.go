@@ -0,0 +1,112 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// How far back to look when measuring the Execution Client's sync rate
+	executionSyncRateWindow time.Duration = 10 * time.Minute
+)
+
+// Details about the Execution Client's sync progress
+type SyncProgress struct {
+	// True if the client is fully synced; the other fields are only set while it's syncing
+	Synced bool `json:"synced"`
+
+	// The block the client started syncing from
+	StartingBlock uint64 `json:"startingBlock"`
+
+	// The block the client has synced to
+	CurrentBlock uint64 `json:"currentBlock"`
+
+	// The highest block the client knows about
+	HighestBlock uint64 `json:"highestBlock"`
+
+	// How much of the chain the client has synced, from 0 to 100
+	Percent float64 `json:"percent"`
+
+	// The number of blocks synced per second, measured over recent calls. This is 0 until there are two measurements.
+	BlocksPerSecond float64 `json:"blocksPerSecond"`
+
+	// True if there's enough recent progress to estimate how long the sync has left
+	HasEta bool `json:"hasEta"`
+
+	// The estimated time until the client reaches the highest block
+	Eta time.Duration `json:"eta"`
+
+	// True if the client is in the healing phase of snap sync, fixing up state it downloaded while the chain moved on
+	Healing bool `json:"healing"`
+
+	// Snap sync healing progress, for clients that report it
+	HealedTrienodes  uint64 `json:"healedTrienodes"`
+	HealedBytecodes  uint64 `json:"healedBytecodes"`
+	HealingTrienodes uint64 `json:"healingTrienodes"`
+	HealingBytecode  uint64 `json:"healingBytecode"`
+}
+
+// A measurement of the Execution Client's sync progress
+type executionSyncSample struct {
+	time  time.Time
+	block uint64
+}
+
+// Gets the Execution Client's sync progress, with an estimate of the time left based on how quickly it has progressed
+// since the previous calls. A fully synced client returns a progress with Synced set rather than an error.
+func (sp *ServiceProvider) GetExecutionSyncProgress(ctx context.Context) (SyncProgress, error) {
+	progress, err := sp.GetEthClient().SyncProgress(ctx)
+	if err != nil {
+		return SyncProgress{}, fmt.Errorf("error getting Execution Client sync progress: %w", err)
+	}
+	sp.executionSyncLock.Lock()
+	defer sp.executionSyncLock.Unlock()
+	if progress == nil {
+		sp.executionSyncSamples = nil
+		return SyncProgress{
+			Synced: true,
+		}, nil
+	}
+
+	result := SyncProgress{
+		StartingBlock:    progress.StartingBlock,
+		CurrentBlock:     progress.CurrentBlock,
+		HighestBlock:     progress.HighestBlock,
+		HealedTrienodes:  progress.HealedTrienodes,
+		HealedBytecodes:  progress.HealedBytecodes,
+		HealingTrienodes: progress.HealingTrienodes,
+		HealingBytecode:  progress.HealingBytecode,
+	}
+	result.Healing = progress.HealingTrienodes > 0 || progress.HealingBytecode > 0
+	if progress.HighestBlock > 0 {
+		result.Percent = min(float64(progress.CurrentBlock)/float64(progress.HighestBlock), 1) * 100
+	}
+
+	// Measure the rate against the oldest sample in the window, dropping everything before it.
+	// A client that restarted its sync from an earlier block invalidates the old samples.
+	now := time.Now()
+	samples := []executionSyncSample{}
+	for _, sample := range sp.executionSyncSamples {
+		if now.Sub(sample.time) <= executionSyncRateWindow && sample.block <= progress.CurrentBlock {
+			samples = append(samples, sample)
+		}
+	}
+	if len(samples) > 0 {
+		elapsed := now.Sub(samples[0].time).Seconds()
+		if elapsed > 0 {
+			result.BlocksPerSecond = float64(progress.CurrentBlock-samples[0].block) / elapsed
+		}
+	}
+	sp.executionSyncSamples = append(samples, executionSyncSample{
+		time:  now,
+		block: progress.CurrentBlock,
+	})
+
+	if result.BlocksPerSecond > 0 && progress.HighestBlock > progress.CurrentBlock {
+		remaining := float64(progress.HighestBlock - progress.CurrentBlock)
+		result.HasEta = true
+		result.Eta = time.Duration(remaining / result.BlocksPerSecond * float64(time.Second))
+	}
+	return result, nil
+}
@@ -0,0 +1,200 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// A module's rule for which fee recipient its validators have to use, like a protocol's smoothing pool. Validators that
+// propose with a different one can be penalized by the protocol.
+type FeeRecipientRule interface {
+	// The name of the module
+	GetModuleName() string
+
+	// True if the module is enabled; disabled modules aren't checked
+	IsEnabled() bool
+
+	// Get the fee recipient each of the module's validators has to use
+	GetExpectedFeeRecipients(ctx context.Context) (map[beacon.ValidatorPubkey]ethcommon.Address, error)
+}
+
+// The outcome of checking a single validator's fee recipient
+type FeeRecipientCheck struct {
+	// The validator's pubkey
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// The module whose rule the validator was checked against
+	Module string `json:"module"`
+
+	// The fee recipient the module expects the validator to use
+	Expected ethcommon.Address `json:"expected"`
+
+	// The fee recipient the Validator Client is using for the validator; only set if it could be read
+	Configured ethcommon.Address `json:"configured"`
+
+	// True if the Validator Client is using the expected fee recipient
+	Compliant bool `json:"compliant"`
+
+	// The error from reading the fee recipient from the Validator Client, if there was one
+	Error string `json:"error,omitempty"`
+}
+
+// The result of checking the validators' fee recipients against their modules' rules
+type ComplianceReport struct {
+	// The outcome for each validator, sorted by module and then pubkey
+	Validators []FeeRecipientCheck `json:"validators"`
+
+	// The number of validators using their expected fee recipient
+	CompliantCount int `json:"compliantCount"`
+
+	// The number of validators using a different fee recipient than the one their module expects
+	NonCompliantCount int `json:"nonCompliantCount"`
+
+	// The number of validators whose fee recipient couldn't be read from the Validator Client
+	ErrorCount int `json:"errorCount"`
+}
+
+// Registers a module's fee recipient rule so its validators are included in the compliance checks
+func (sp *ServiceProvider) RegisterFeeRecipientRule(rule FeeRecipientRule) {
+	sp.feeRecipientRuleLock.Lock()
+	defer sp.feeRecipientRuleLock.Unlock()
+	sp.feeRecipientRules = append(sp.feeRecipientRules, rule)
+}
+
+// Compares the fee recipient the Validator Client uses for each validator with the one its module expects, reading them
+// from the VC's key manager API a batch at a time. Validators whose fee recipient couldn't be read are reported with an
+// error rather than as non-compliant.
+func (sp *ServiceProvider) VerifyFeeRecipientCompliance(ctx context.Context) (ComplianceReport, error) {
+	report := ComplianceReport{
+		Validators: []FeeRecipientCheck{},
+	}
+	rules := sp.getFeeRecipientRules()
+	for _, rule := range rules {
+		if !rule.IsEnabled() {
+			continue
+		}
+		moduleName := rule.GetModuleName()
+		expected, err := rule.GetExpectedFeeRecipients(ctx)
+		if err != nil {
+			return ComplianceReport{}, fmt.Errorf("error getting expected fee recipients for module [%s]: %w", moduleName, err)
+		}
+		for pubkey, feeRecipient := range expected {
+			report.Validators = append(report.Validators, FeeRecipientCheck{
+				Pubkey:   pubkey,
+				Module:   moduleName,
+				Expected: feeRecipient,
+			})
+		}
+	}
+	if len(report.Validators) == 0 {
+		return report, nil
+	}
+	if sp.cfg.KeyManager.Url.Value == "" {
+		return ComplianceReport{}, ErrKeyManagerNotConfigured
+	}
+	sort.Slice(report.Validators, func(i, j int) bool {
+		a, b := report.Validators[i], report.Validators[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		return a.Pubkey.Hex() < b.Pubkey.Hex()
+	})
+
+	for start := 0; start < len(report.Validators); start += feeRecipientBatchSize {
+		if ctx.Err() != nil {
+			return ComplianceReport{}, ctx.Err()
+		}
+		end := min(start+feeRecipientBatchSize, len(report.Validators))
+		sp.checkFeeRecipientBatch(ctx, report.Validators[start:end])
+	}
+	for _, check := range report.Validators {
+		switch {
+		case check.Error != "":
+			report.ErrorCount++
+		case check.Compliant:
+			report.CompliantCount++
+		default:
+			report.NonCompliantCount++
+		}
+	}
+	return report, nil
+}
+
+// Checks the fee recipients of the node's validators and sends a critical alert for the violations that haven't been
+// alerted on yet, so a validator that stays non-compliant only raises one alert. A validator that becomes compliant
+// again, or switches to yet another wrong fee recipient, is alerted on again if it's found to be non-compliant later.
+func (sp *ServiceProvider) CheckFeeRecipientCompliance(ctx context.Context) (ComplianceReport, error) {
+	report, err := sp.VerifyFeeRecipientCompliance(ctx)
+	if err != nil {
+		return ComplianceReport{}, err
+	}
+
+	sp.feeRecipientAlertLock.Lock()
+	defer sp.feeRecipientAlertLock.Unlock()
+	violations := []string{}
+	for _, check := range report.Validators {
+		if check.Error != "" {
+			// Keep the previous state, since it's unknown whether the validator changed
+			continue
+		}
+		if check.Compliant {
+			delete(sp.alertedFeeRecipients, check.Pubkey)
+			continue
+		}
+		if alerted, exists := sp.alertedFeeRecipients[check.Pubkey]; exists && alerted == check.Configured {
+			continue
+		}
+		sp.alertedFeeRecipients[check.Pubkey] = check.Configured
+		violations = append(violations, fmt.Sprintf("%s (%s) is using %s instead of %s", check.Pubkey.HexWithPrefix(), check.Module, check.Configured.Hex(), check.Expected.Hex()))
+	}
+	if len(violations) == 0 {
+		return report, nil
+	}
+	err = sp.alertManager.SendAlert(ctx, Alert{
+		Severity: hdconfig.AlertSeverity_Critical,
+		Title:    fmt.Sprintf("%d validators have the wrong fee recipient", len(violations)),
+		Message:  "These validators' fee recipients don't match the ones their modules require, so their proposals could be penalized:\n" + strings.Join(violations, "\n"),
+	})
+	if err != nil {
+		return report, fmt.Errorf("error sending fee recipient alert: %w", err)
+	}
+	return report, nil
+}
+
+// Reads the fee recipients for a batch of validators in parallel, recording the outcome in each check
+func (sp *ServiceProvider) checkFeeRecipientBatch(ctx context.Context, checks []FeeRecipientCheck) {
+	keyManager := sp.GetKeyManagerClient()
+	finished := make(chan struct{}, len(checks))
+	for i := range checks {
+		go func(check *FeeRecipientCheck) {
+			defer func() {
+				finished <- struct{}{}
+			}()
+			configured, err := keyManager.GetFeeRecipient(ctx, check.Pubkey)
+			if err != nil {
+				check.Error = err.Error()
+				return
+			}
+			check.Configured = configured
+			check.Compliant = configured == check.Expected
+		}(&checks[i])
+	}
+	for range checks {
+		<-finished
+	}
+}
+
+// Gets the registered fee recipient rules
+func (sp *ServiceProvider) getFeeRecipientRules() []FeeRecipientRule {
+	sp.feeRecipientRuleLock.Lock()
+	defer sp.feeRecipientRuleLock.Unlock()
+	rules := make([]FeeRecipientRule, len(sp.feeRecipientRules))
+	copy(rules, sp.feeRecipientRules)
+	return rules
+}
@@ -0,0 +1,135 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+const (
+	// The number of fee recipients to update at once
+	feeRecipientBatchSize int = 32
+)
+
+var (
+	// A fee recipient address can't be used
+	ErrInvalidFeeRecipient error = errors.New("invalid fee recipient")
+)
+
+// The outcome of updating a single validator's fee recipient
+type FeeRecipientUpdate struct {
+	Pubkey       beacon.ValidatorPubkey `json:"pubkey"`
+	FeeRecipient ethcommon.Address      `json:"feeRecipient"`
+	Success      bool                   `json:"success"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// The result of updating a set of fee recipients
+type SetResult struct {
+	// The outcome for each validator, sorted by pubkey
+	Updates        []FeeRecipientUpdate `json:"updates"`
+	SucceededCount int                  `json:"succeededCount"`
+	FailedCount    int                  `json:"failedCount"`
+}
+
+// Parses a fee recipient address, which must be in its EIP-55 checksummed form so typos are caught
+func ParseFeeRecipient(address string) (ethcommon.Address, error) {
+	if !ethcommon.IsHexAddress(address) {
+		return ethcommon.Address{}, fmt.Errorf("%w: [%s] is not an address", ErrInvalidFeeRecipient, address)
+	}
+	parsed := ethcommon.HexToAddress(address)
+	if parsed.Hex() != address {
+		return ethcommon.Address{}, fmt.Errorf("%w: [%s] is not checksummed (expected [%s])", ErrInvalidFeeRecipient, address, parsed.Hex())
+	}
+	return parsed, nil
+}
+
+// Sets the fee recipients of the provided validators in the Validator Client via its key manager API, a batch at a time,
+// then reads each one back to verify it was applied. Every address is validated before any updates are sent; if one is
+// invalid, nothing is changed and an error wrapping ErrInvalidFeeRecipient is returned. Otherwise, failures for individual
+// validators are reported in the result.
+// If provided, progress is reported as each validator's update finishes; it can be nil.
+func (sp *ServiceProvider) SetFeeRecipients(ctx context.Context, recipients map[beacon.ValidatorPubkey]ethcommon.Address, progress ProgressFunc) (SetResult, error) {
+	result := SetResult{
+		Updates: []FeeRecipientUpdate{},
+	}
+	for pubkey, feeRecipient := range recipients {
+		if feeRecipient == (ethcommon.Address{}) {
+			return result, fmt.Errorf("%w: the fee recipient for validator %s is the zero address", ErrInvalidFeeRecipient, pubkey.HexWithPrefix())
+		}
+	}
+	if sp.cfg.KeyManager.Url.Value == "" {
+		return result, ErrKeyManagerNotConfigured
+	}
+
+	// Sort the updates so the result is deterministic
+	for pubkey, feeRecipient := range recipients {
+		result.Updates = append(result.Updates, FeeRecipientUpdate{
+			Pubkey:       pubkey,
+			FeeRecipient: feeRecipient,
+		})
+	}
+	sort.Slice(result.Updates, func(i, j int) bool {
+		return result.Updates[i].Pubkey.Hex() < result.Updates[j].Pubkey.Hex()
+	})
+
+	for start := 0; start < len(result.Updates); start += feeRecipientBatchSize {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		end := min(start+feeRecipientBatchSize, len(result.Updates))
+		sp.setFeeRecipientBatch(ctx, result.Updates[start:end], func(update *FeeRecipientUpdate, done int) {
+			message := fmt.Sprintf("Set the fee recipient for %s", update.Pubkey.HexWithPrefix())
+			if !update.Success {
+				message = fmt.Sprintf("Failed to set the fee recipient for %s", update.Pubkey.HexWithPrefix())
+			}
+			progress.report(start+done, len(result.Updates), message)
+		})
+	}
+	for _, update := range result.Updates {
+		if update.Success {
+			result.SucceededCount++
+		} else {
+			result.FailedCount++
+		}
+	}
+	return result, nil
+}
+
+// Sets and verifies the fee recipients for a batch of validators in parallel, recording the outcome in each update.
+// onDone is called from this goroutine as each update finishes, with the number finished so far.
+func (sp *ServiceProvider) setFeeRecipientBatch(ctx context.Context, updates []FeeRecipientUpdate, onDone func(update *FeeRecipientUpdate, done int)) {
+	keyManager := sp.GetKeyManagerClient()
+	finished := make(chan *FeeRecipientUpdate, len(updates))
+	for i := range updates {
+		go func(update *FeeRecipientUpdate) {
+			defer func() {
+				finished <- update
+			}()
+			err := keyManager.SetFeeRecipient(ctx, update.Pubkey, update.FeeRecipient)
+			if err != nil {
+				update.Error = err.Error()
+				return
+			}
+
+			// The VC accepting the update doesn't mean it's using it, so check
+			applied, err := keyManager.GetFeeRecipient(ctx, update.Pubkey)
+			if err != nil {
+				update.Error = fmt.Sprintf("error verifying fee recipient: %s", err.Error())
+				return
+			}
+			if applied != update.FeeRecipient {
+				update.Error = fmt.Sprintf("the Validator Client is using fee recipient %s instead", applied.Hex())
+				return
+			}
+			update.Success = true
+		}(&updates[i])
+	}
+	for done := 1; done <= len(updates); done++ {
+		onDone(<-finished, done)
+	}
+}
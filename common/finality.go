@@ -0,0 +1,82 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// How many epochs finality can trail the head before it's considered stalled. A healthy chain finalizes the epoch
+	// two behind the current one, so this leaves room for a couple of missed justifications before raising an alarm.
+	FinalityStallThreshold uint64 = 4
+)
+
+// A Casper FFG checkpoint
+type Checkpoint struct {
+	Epoch uint64         `json:"epoch"`
+	Root  ethcommon.Hash `json:"root"`
+}
+
+// The justification and finality progress of the chain as of the head state
+type FinalityInfo struct {
+	// The epoch of the head slot
+	HeadEpoch uint64 `json:"headEpoch"`
+
+	PreviousJustified Checkpoint `json:"previousJustified"`
+	CurrentJustified  Checkpoint `json:"currentJustified"`
+	Finalized         Checkpoint `json:"finalized"`
+}
+
+// Gets the number of epochs between the head and the finalized checkpoint
+func (f FinalityInfo) GetEpochsSinceFinality() uint64 {
+	if f.HeadEpoch < f.Finalized.Epoch {
+		return 0
+	}
+	return f.HeadEpoch - f.Finalized.Epoch
+}
+
+// Checks if finality has stalled, meaning the finalized checkpoint is more than FinalityStallThreshold epochs behind the head
+func (f FinalityInfo) IsStalled() bool {
+	return f.GetEpochsSinceFinality() > FinalityStallThreshold
+}
+
+// Gets the current justified and finalized checkpoints from the head state, along with the head's epoch so monitors
+// can check how far finality is trailing with IsStalled
+func (sp *ServiceProvider) GetFinalityCheckpoints(ctx context.Context) (FinalityInfo, error) {
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return FinalityInfo{}, fmt.Errorf("error getting Beacon spec: %w", err)
+	}
+	bnApi := sp.GetBeaconApiClient()
+	headSlot, exists, err := bnApi.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return FinalityInfo{}, fmt.Errorf("error getting head slot: %w", err)
+	}
+	if !exists {
+		return FinalityInfo{}, errors.New("the Beacon Node doesn't have a head block")
+	}
+	checkpoints, exists, err := bnApi.GetFinalityCheckpoints(ctx, "head")
+	if err != nil {
+		return FinalityInfo{}, err
+	}
+	if !exists {
+		return FinalityInfo{}, errors.New("the Beacon Node doesn't have the finality checkpoints of the head state")
+	}
+	return FinalityInfo{
+		HeadEpoch:         headSlot / spec.SlotsPerEpoch,
+		PreviousJustified: getCheckpoint(checkpoints.PreviousJustified),
+		CurrentJustified:  getCheckpoint(checkpoints.CurrentJustified),
+		Finalized:         getCheckpoint(checkpoints.Finalized),
+	}, nil
+}
+
+// Converts a checkpoint from the Beacon API format
+func getCheckpoint(checkpoint BeaconCheckpoint) Checkpoint {
+	return Checkpoint{
+		Epoch: uint64(checkpoint.Epoch),
+		Root:  ethcommon.BytesToHash(checkpoint.Root),
+	}
+}
@@ -0,0 +1,165 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// The most blocks Execution Clients will report in a single eth_feeHistory request
+	MaxGasHistoryBlocks uint64 = 1024
+)
+
+var (
+	// The priority fee percentiles included in the gas price history
+	GasHistoryRewardPercentiles []float64 = []float64{10, 50, 90}
+)
+
+// The gas prices over a window of recent blocks, in wei
+type GasHistory struct {
+	// True if the network has EIP-1559 fees. If not, the history only has the current gas price, since Execution Clients
+	// can't report legacy gas prices for past blocks.
+	Eip1559 bool `json:"eip1559"`
+
+	// The priority fee percentiles in each block's PriorityFees, and in AveragePriorityFees
+	RewardPercentiles []float64 `json:"rewardPercentiles"`
+
+	// The blocks in the window, oldest first
+	Blocks []GasHistoryBlock `json:"blocks"`
+
+	// The base fee of the block after the newest one in the window; nil without EIP-1559
+	NextBaseFee *big.Int `json:"nextBaseFee"`
+
+	// The average base fee across the window; nil without EIP-1559
+	AverageBaseFee *big.Int `json:"averageBaseFee"`
+
+	// The average of each priority fee percentile across the window; empty without EIP-1559
+	AveragePriorityFees []*big.Int `json:"averagePriorityFees"`
+
+	// The average gas price across the window. With EIP-1559, this is the base fee plus the median priority fee.
+	AverageGasPrice *big.Int `json:"averageGasPrice"`
+}
+
+// The gas prices of a single block, in wei
+type GasHistoryBlock struct {
+	// The block number
+	Number uint64 `json:"number"`
+
+	// The block's base fee; nil without EIP-1559
+	BaseFee *big.Int `json:"baseFee"`
+
+	// The fraction of the block's gas limit that was used; 0 without EIP-1559
+	GasUsedRatio float64 `json:"gasUsedRatio"`
+
+	// The priority fees paid in the block at each of the reward percentiles; empty without EIP-1559
+	PriorityFees []*big.Int `json:"priorityFees"`
+
+	// The gas price without EIP-1559, as reported by eth_gasPrice; nil with EIP-1559
+	GasPrice *big.Int `json:"gasPrice"`
+}
+
+// Gets the gas prices over the provided number of most recent blocks from the primary Execution Client, using
+// eth_feeHistory. On networks without EIP-1559, this falls back to sampling eth_gasPrice at the head block.
+// The block count must be between 1 and MaxGasHistoryBlocks; the history may have fewer blocks near genesis.
+func (sp *ServiceProvider) GetGasPriceHistory(ctx context.Context, blockCount uint64) (GasHistory, error) {
+	if blockCount == 0 || blockCount > MaxGasHistoryBlocks {
+		return GasHistory{}, fmt.Errorf("block count must be between 1 and %d, but was %d", MaxGasHistoryBlocks, blockCount)
+	}
+	rpcClient, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return GasHistory{}, err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+
+	feeHistory, err := client.FeeHistory(ctx, blockCount, nil, GasHistoryRewardPercentiles)
+	if err != nil && !isRpcMethodNotFound(err) {
+		return GasHistory{}, fmt.Errorf("error getting fee history: %w", err)
+	}
+	if err != nil || !hasBaseFees(feeHistory.BaseFee) {
+		return getLegacyGasHistory(ctx, client)
+	}
+
+	history := GasHistory{
+		Eip1559:             true,
+		RewardPercentiles:   GasHistoryRewardPercentiles,
+		Blocks:              make([]GasHistoryBlock, len(feeHistory.GasUsedRatio)),
+		AverageBaseFee:      big.NewInt(0),
+		AveragePriorityFees: make([]*big.Int, len(GasHistoryRewardPercentiles)),
+	}
+	if len(history.Blocks) == 0 {
+		return GasHistory{}, fmt.Errorf("the Execution Client didn't report any blocks in the fee history")
+	}
+	if len(feeHistory.BaseFee) < len(history.Blocks) || feeHistory.OldestBlock == nil {
+		return GasHistory{}, fmt.Errorf("the Execution Client reported an incomplete fee history for %d blocks", len(history.Blocks))
+	}
+	for i := range history.AveragePriorityFees {
+		history.AveragePriorityFees[i] = big.NewInt(0)
+	}
+	oldestBlock := feeHistory.OldestBlock.Uint64()
+	for i := range history.Blocks {
+		block := GasHistoryBlock{
+			Number:       oldestBlock + uint64(i),
+			BaseFee:      feeHistory.BaseFee[i],
+			GasUsedRatio: feeHistory.GasUsedRatio[i],
+			PriorityFees: make([]*big.Int, len(GasHistoryRewardPercentiles)),
+		}
+		// Empty blocks are reported with no rewards, or all zero rewards
+		for j := range block.PriorityFees {
+			block.PriorityFees[j] = big.NewInt(0)
+			if i < len(feeHistory.Reward) && j < len(feeHistory.Reward[i]) && feeHistory.Reward[i][j] != nil {
+				block.PriorityFees[j] = feeHistory.Reward[i][j]
+			}
+			history.AveragePriorityFees[j].Add(history.AveragePriorityFees[j], block.PriorityFees[j])
+		}
+		history.AverageBaseFee.Add(history.AverageBaseFee, block.BaseFee)
+		history.Blocks[i] = block
+	}
+	if len(feeHistory.BaseFee) > len(history.Blocks) {
+		history.NextBaseFee = feeHistory.BaseFee[len(history.Blocks)]
+	}
+
+	count := big.NewInt(int64(len(history.Blocks)))
+	history.AverageBaseFee.Div(history.AverageBaseFee, count)
+	for _, average := range history.AveragePriorityFees {
+		average.Div(average, count)
+	}
+	history.AverageGasPrice = new(big.Int).Add(history.AverageBaseFee, history.AveragePriorityFees[len(GasHistoryRewardPercentiles)/2])
+	return history, nil
+}
+
+// Gets the gas price history on a network without EIP-1559, which is a single eth_gasPrice sample at the head block
+func getLegacyGasHistory(ctx context.Context, client *ethclient.Client) (GasHistory, error) {
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return GasHistory{}, fmt.Errorf("error getting latest block number: %w", err)
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return GasHistory{}, fmt.Errorf("error getting gas price: %w", err)
+	}
+	return GasHistory{
+		Eip1559:           false,
+		RewardPercentiles: GasHistoryRewardPercentiles,
+		Blocks: []GasHistoryBlock{{
+			Number:       head,
+			PriorityFees: []*big.Int{},
+			GasPrice:     gasPrice,
+		}},
+		AveragePriorityFees: []*big.Int{},
+		AverageGasPrice:     gasPrice,
+	}, nil
+}
+
+// Checks if any of the base fees in a fee history are set; networks without EIP-1559 report them all as 0
+func hasBaseFees(baseFees []*big.Int) bool {
+	for _, baseFee := range baseFees {
+		if baseFee != nil && baseFee.Sign() > 0 {
+			return true
+		}
+	}
+	return false
+}
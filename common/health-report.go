@@ -0,0 +1,165 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A summary of the node's health, including warnings about conditions that need attention before they cause problems
+type HealthReport struct {
+	// Disk usage of each client data volume
+	Volumes map[string]VolumeUsage `json:"volumes"`
+
+	// The number of the node's validators in each Beacon Chain status
+	ValidatorCounts map[ValidatorStatus]int `json:"validatorCounts"`
+
+	// The daemon's own resource usage
+	SelfUsage SelfUsage `json:"selfUsage"`
+
+	// The latest resource usage sample of each running managed container, keyed by container name
+	ContainerStats map[string]ContainerStats `json:"containerStats"`
+
+	// The primary Beacon Node's identity and version
+	BeaconNode NodeInfo `json:"beaconNode"`
+
+	// How far the system clock is from the Beacon Chain's; positive if it's ahead
+	ClockSkew time.Duration `json:"clockSkew"`
+
+	// Whether the clients are getting closer to the head while they sync
+	SyncHealth SyncHealth `json:"syncHealth"`
+
+	// The node's validators in the current and next sync committees; empty before Altair
+	SyncCommittees []SyncCommitteeMembership `json:"syncCommittees"`
+
+	// Human-readable warnings about anything that needs attention
+	Warnings []string `json:"warnings"`
+}
+
+// Builds a health report for the node. Checks that fail are reported as warnings instead of failing the whole report.
+func (sp *ServiceProvider) GetHealthReport(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Volumes:         map[string]VolumeUsage{},
+		ValidatorCounts: map[ValidatorStatus]int{},
+		SelfUsage:       sp.GetSelfResourceUsage(),
+		ContainerStats:  map[string]ContainerStats{},
+		BeaconNode: NodeInfo{
+			P2pAddresses:       []string{},
+			DiscoveryAddresses: []string{},
+		},
+		SyncCommittees: []SyncCommitteeMembership{},
+		Warnings:       []string{},
+	}
+
+	// Disk usage
+	volumes, err := sp.GetVolumeUsage(ctx)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't check client volume usage: %s", err.Error()))
+	} else {
+		report.Volumes = volumes
+		names := make([]string, 0, len(volumes))
+		for name := range volumes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			usage := volumes[name]
+			if usage.NearlyFull {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("The %s volume is nearly full (%d of %d bytes available).", name, usage.AvailableBytes, usage.TotalBytes))
+			}
+		}
+	}
+
+	// Container resource usage
+	containers, err := sp.ListManagedContainers(ctx)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't list the managed containers: %s", err.Error()))
+	} else {
+		for _, container := range containers {
+			if container.State != "running" {
+				continue
+			}
+			stats, err := sp.GetContainerStats(ctx, container.Name)
+			if err != nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't get the resource usage of the %s container: %s", container.Name, err.Error()))
+				continue
+			}
+			report.ContainerStats[container.Name] = stats
+		}
+	}
+
+	// Stuck transactions
+	stuck, err := sp.GetStuckTransactions(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrTxPoolUnsupported) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't check for stuck transactions: %s", err.Error()))
+		}
+	} else {
+		for _, tx := range stuck {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("Transaction %s (nonce %d) from the node wallet looks stuck; it's waiting on an earlier nonce or its max fee is below the current base fee.", tx.Hash.Hex(), tx.Nonce))
+		}
+	}
+
+	// Validator counts
+	counts, err := sp.CountValidatorsByStatus(ctx)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't count the node's validators: %s", err.Error()))
+	} else {
+		report.ValidatorCounts = counts
+		if counts[ValidatorStatus_Slashed] > 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%d of the node's validators have been slashed.", counts[ValidatorStatus_Slashed]))
+		}
+	}
+
+	// Sync committee duty
+	syncCommittees, err := sp.GetCurrentSyncCommitteeMembership(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrSyncCommitteeNotActive) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't check the node's sync committee membership: %s", err.Error()))
+		}
+	} else {
+		report.SyncCommittees = syncCommittees
+	}
+
+	// Beacon Node identity
+	nodeInfo, err := sp.GetBeaconNodeInfo(ctx)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't get the Beacon Node's identity and version: %s", err.Error()))
+	} else {
+		report.BeaconNode = nodeInfo
+	}
+
+	// Clock skew
+	skew, err := sp.CheckClockSkew(ctx)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't check the system clock: %s", err.Error()))
+	} else {
+		report.ClockSkew = skew
+		if skew.Abs() > sp.cfg.GetClockSkewThreshold() {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("The system clock is off by %s from the Beacon Chain's; check that NTP is running.", skew))
+		}
+	}
+
+	// Stuck syncs
+	syncHealth, err := sp.GetSyncHealth(ctx)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Couldn't check the clients' sync progress: %s", err.Error()))
+	} else {
+		report.SyncHealth = syncHealth
+		for _, client := range []struct {
+			name   string
+			health ClientSyncHealth
+		}{
+			{"Execution Client", syncHealth.ExecutionClient},
+			{"Beacon Node", syncHealth.BeaconNode},
+		} {
+			if client.health.State == SyncState_Stuck {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("The %s's sync looks stuck; it's %d behind the head and hasn't gotten any closer in %s. Check its logs and peer count.", client.name, client.health.Distance, syncHealth.Window))
+			}
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,182 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+// The outcome of importing a single keystore
+type KeystoreImportStatus string
+
+const (
+	// The keystore was imported into the Validator Client
+	KeystoreImportStatus_Imported KeystoreImportStatus = "imported"
+
+	// The Validator Client already had the keystore, so it was skipped
+	KeystoreImportStatus_Skipped KeystoreImportStatus = "skipped"
+
+	// The keystore couldn't be imported
+	KeystoreImportStatus_Failed KeystoreImportStatus = "failed"
+)
+
+// The result of importing a single keystore
+type KeystoreImport struct {
+	Path          string                 `json:"path"`
+	Pubkey        beacon.ValidatorPubkey `json:"pubkey"`
+	Status        KeystoreImportStatus   `json:"status"`
+	FailureReason KeystoreFailureReason  `json:"failureReason,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// The result of importing a directory of keystores
+type ImportResult struct {
+	Keystores     []KeystoreImport `json:"keystores"`
+	ImportedCount int              `json:"importedCount"`
+	SkippedCount  int              `json:"skippedCount"`
+	FailedCount   int              `json:"failedCount"`
+
+	// True if the directory had slashing protection data that was provided to the Validator Client
+	ImportedSlashingProtection bool `json:"importedSlashingProtection"`
+}
+
+// Imports every EIP-2335 keystore in the provided directory into the Validator Client via its key manager API.
+// Each keystore is decrypted with the provided password first to make sure it's valid. Keystores the Validator Client
+// already has are skipped. If the directory has a slashing protection file, it's merged with Hyperdrive's stored slashing
+// protection data and provided to the VC along with the keys.
+// If provided, progress is reported after each keystore is checked and once more after the import; it can be nil.
+func (sp *ServiceProvider) ImportKeystores(ctx context.Context, dir string, password string, progress ProgressFunc) (ImportResult, error) {
+	result := ImportResult{
+		Keystores: []KeystoreImport{},
+	}
+	keyManager := sp.GetKeyManagerClient()
+
+	// Get the keystores
+	paths, err := getKeystorePaths(dir)
+	if err != nil {
+		return result, err
+	}
+	if len(paths) == 0 {
+		return result, nil
+	}
+
+	// Get the keys already loaded into the VC
+	existingKeystores, err := keyManager.ListKeystores(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	// Pass the directory's slashing protection data along to the VC, which merges it into its own history
+	var slashingProtection string
+	slashingProtectionBytes, err := os.ReadFile(filepath.Join(dir, hdconfig.SlashingProtectionFilename))
+	if err == nil {
+		genesisValidatorsRoot, err := sp.getGenesisValidatorsRoot(ctx)
+		if err != nil {
+			return result, err
+		}
+		interchange, err := parseSlashingProtection(slashingProtectionBytes, genesisValidatorsRoot)
+		if err != nil {
+			return result, fmt.Errorf("error importing slashing protection data: %w", err)
+		}
+		minimalBytes, err := json.Marshal(MergeSlashingProtection(interchange, SlashingProtectionInterchange{}))
+		if err != nil {
+			return result, fmt.Errorf("error serializing slashing protection data: %w", err)
+		}
+		slashingProtection = string(minimalBytes)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return result, fmt.Errorf("error reading slashing protection data: %w", err)
+	}
+	existingPubkeys := map[beacon.ValidatorPubkey]bool{}
+	for _, keystore := range existingKeystores {
+		existingPubkeys[keystore.Pubkey] = true
+	}
+
+	// Validate each keystore
+	err = validator.InitializeBls()
+	if err != nil {
+		return result, fmt.Errorf("error initializing BLS: %w", err)
+	}
+	encryptor := eth2ks.New()
+	keystoresToImport := []string{}
+	importIndices := []int{}
+	totalSteps := len(paths) + 1
+	for i, path := range paths {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		outcome := KeystoreImport{
+			Path: path,
+		}
+		verification := verifyKeystore(encryptor, path, password)
+		outcome.Pubkey = verification.Pubkey
+		switch {
+		case !verification.Success:
+			outcome.Status = KeystoreImportStatus_Failed
+			outcome.FailureReason = verification.FailureReason
+			outcome.Error = verification.Error
+		case existingPubkeys[verification.Pubkey]:
+			outcome.Status = KeystoreImportStatus_Skipped
+		default:
+			keystoreBytes, err := os.ReadFile(path)
+			if err != nil {
+				outcome.Status = KeystoreImportStatus_Failed
+				outcome.Error = fmt.Sprintf("error reading keystore: %s", err.Error())
+				break
+			}
+			keystoresToImport = append(keystoresToImport, string(keystoreBytes))
+			importIndices = append(importIndices, len(result.Keystores))
+
+			// Catch duplicates within the directory itself
+			existingPubkeys[verification.Pubkey] = true
+		}
+		result.Keystores = append(result.Keystores, outcome)
+		progress.report(i+1, totalSteps, fmt.Sprintf("Checked %s", filepath.Base(path)))
+	}
+
+	// Import the valid ones
+	if len(keystoresToImport) > 0 {
+		passwords := make([]string, len(keystoresToImport))
+		for i := range passwords {
+			passwords[i] = password
+		}
+		statuses, err := keyManager.ImportKeystores(ctx, keystoresToImport, passwords, slashingProtection)
+		if err != nil {
+			return result, err
+		}
+		result.ImportedSlashingProtection = slashingProtection != ""
+		for i, status := range statuses {
+			outcome := &result.Keystores[importIndices[i]]
+			switch status.Status {
+			case "imported":
+				outcome.Status = KeystoreImportStatus_Imported
+			case "duplicate":
+				outcome.Status = KeystoreImportStatus_Skipped
+			default:
+				outcome.Status = KeystoreImportStatus_Failed
+				outcome.Error = status.Message
+			}
+		}
+	}
+	progress.report(totalSteps, totalSteps, fmt.Sprintf("Imported %d keystores into the Validator Client", len(keystoresToImport)))
+
+	// Tally the results
+	for _, outcome := range result.Keystores {
+		switch outcome.Status {
+		case KeystoreImportStatus_Imported:
+			result.ImportedCount++
+		case KeystoreImportStatus_Skipped:
+			result.SkippedCount++
+		case KeystoreImportStatus_Failed:
+			result.FailedCount++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+// Checks that the provided path exists and is a Unix socket
+func ValidateIpcSocket(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("IPC socket [%s] does not exist", path)
+	}
+	if err != nil {
+		return fmt.Errorf("error checking IPC socket [%s]: %w", path, err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("[%s] is not a socket", path)
+	}
+	return nil
+}
+
+// Connects to an Execution Client at the provided endpoint, which is either a URL or the path of an IPC socket
+func DialExecutionClient(ctx context.Context, endpoint string) (*ethclient.Client, error) {
+	rpcClient, err := dialExecutionRpc(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(rpcClient), nil
+}
+
+// Opens a raw RPC connection to an Execution Client at the provided endpoint, which is either a URL or the path of an IPC socket.
+// IPC sockets are checked before dialing so a bad path is reported clearly at startup.
+func dialExecutionRpc(ctx context.Context, endpoint string) (*rpc.Client, error) {
+	if !hdconfig.IsIpcEndpoint(endpoint) {
+		rpcClient, err := rpc.DialContext(ctx, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to EC at [%s]: %w", endpoint, err)
+		}
+		return rpcClient, nil
+	}
+
+	err := ValidateIpcSocket(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	rpcClient, err := rpc.DialIPC(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to IPC socket [%s]: %w", endpoint, err)
+	}
+	return rpcClient, nil
+}
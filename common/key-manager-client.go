@@ -0,0 +1,294 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+const (
+	// Key manager API routes
+	keyManagerKeystoresPath    string = "/eth/v1/keystores"
+	keyManagerRemoteKeysPath   string = "/eth/v1/remotekeys"
+	keyManagerFeeRecipientPath string = "/eth/v1/validator/%s/feerecipient"
+)
+
+var (
+	// The key manager API hasn't been configured
+	ErrKeyManagerNotConfigured error = errors.New("the Validator Client's key manager API has not been configured")
+)
+
+// A keystore loaded into the Validator Client
+type KeyManagerKeystore struct {
+	Pubkey         beacon.ValidatorPubkey `json:"validating_pubkey"`
+	DerivationPath string                 `json:"derivation_path"`
+	ReadOnly       bool                   `json:"readonly"`
+}
+
+// A key the Validator Client signs with through a remote signer
+type KeyManagerRemoteKey struct {
+	Pubkey   beacon.ValidatorPubkey `json:"pubkey"`
+	Url      string                 `json:"url"`
+	ReadOnly bool                   `json:"readonly"`
+}
+
+// The status of a single keystore operation reported by the key manager
+type KeyManagerStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// The request body for importing keystores
+type keyManagerImportRequest struct {
+	Keystores          []string `json:"keystores"`
+	Passwords          []string `json:"passwords"`
+	SlashingProtection string   `json:"slashing_protection,omitempty"`
+}
+
+// The request body for deleting keystores
+type keyManagerDeleteRequest struct {
+	Pubkeys []beacon.ValidatorPubkey `json:"pubkeys"`
+}
+
+// The request body for adding remote keys
+type keyManagerImportRemoteRequest struct {
+	RemoteKeys []keyManagerRemoteKeyImport `json:"remote_keys"`
+}
+
+// A key to add to the Validator Client's remote keys
+type keyManagerRemoteKeyImport struct {
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+	Url    string                 `json:"url"`
+}
+
+// The request body for setting a validator's fee recipient
+type keyManagerFeeRecipientRequest struct {
+	EthAddress ethcommon.Address `json:"ethaddress"`
+}
+
+// Client for a Validator Client's key manager API
+type KeyManagerClient struct {
+	url       string
+	tokenPath string
+	client    *http.Client
+}
+
+// Creates a new key manager client. The token is read from the provided path on each request, so it can be rotated by the VC.
+func NewKeyManagerClient(url string, tokenPath string, timeout time.Duration) *KeyManagerClient {
+	return &KeyManagerClient{
+		url:       strings.TrimSuffix(url, "/"),
+		tokenPath: tokenPath,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// Gets the keystores currently loaded into the Validator Client
+func (c *KeyManagerClient) ListKeystores(ctx context.Context) ([]KeyManagerKeystore, error) {
+	var response struct {
+		Data []KeyManagerKeystore `json:"data"`
+	}
+	err := c.sendRequest(ctx, http.MethodGet, keyManagerKeystoresPath, nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error listing keystores: %w", err)
+	}
+	return response.Data, nil
+}
+
+// Imports the provided keystores into the Validator Client, along with optional EIP-3076 slashing protection data.
+// Returns a status for each keystore, in the same order they were provided.
+func (c *KeyManagerClient) ImportKeystores(ctx context.Context, keystores []string, passwords []string, slashingProtection string) ([]KeyManagerStatus, error) {
+	request := keyManagerImportRequest{
+		Keystores:          keystores,
+		Passwords:          passwords,
+		SlashingProtection: slashingProtection,
+	}
+	var response struct {
+		Data []KeyManagerStatus `json:"data"`
+	}
+	err := c.sendRequest(ctx, http.MethodPost, keyManagerKeystoresPath, request, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error importing keystores: %w", err)
+	}
+	if len(response.Data) != len(keystores) {
+		return nil, fmt.Errorf("key manager returned %d statuses for %d keystores", len(response.Data), len(keystores))
+	}
+	return response.Data, nil
+}
+
+// Removes the provided keystores from the Validator Client, returning a status for each one (in the same order) along with
+// the VC's EIP-3076 slashing protection data for them. Keys that were already removed still have their data returned.
+func (c *KeyManagerClient) DeleteKeystores(ctx context.Context, pubkeys []beacon.ValidatorPubkey) ([]KeyManagerStatus, string, error) {
+	request := keyManagerDeleteRequest{
+		Pubkeys: pubkeys,
+	}
+	var response struct {
+		Data               []KeyManagerStatus `json:"data"`
+		SlashingProtection string             `json:"slashing_protection"`
+	}
+	err := c.sendRequest(ctx, http.MethodDelete, keyManagerKeystoresPath, request, &response)
+	if err != nil {
+		return nil, "", fmt.Errorf("error deleting keystores: %w", err)
+	}
+	if len(response.Data) != len(pubkeys) {
+		return nil, "", fmt.Errorf("key manager returned %d statuses for %d keystores", len(response.Data), len(pubkeys))
+	}
+	return response.Data, response.SlashingProtection, nil
+}
+
+// Gets the keys the Validator Client signs with through a remote signer
+func (c *KeyManagerClient) ListRemoteKeys(ctx context.Context) ([]KeyManagerRemoteKey, error) {
+	var response struct {
+		Data []KeyManagerRemoteKey `json:"data"`
+	}
+	err := c.sendRequest(ctx, http.MethodGet, keyManagerRemoteKeysPath, nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error listing remote keys: %w", err)
+	}
+	return response.Data, nil
+}
+
+// Has the Validator Client sign for the provided keys with the remote signer at the provided URL.
+// Returns a status for each key, in the same order they were provided.
+func (c *KeyManagerClient) ImportRemoteKeys(ctx context.Context, pubkeys []beacon.ValidatorPubkey, signerUrl string) ([]KeyManagerStatus, error) {
+	request := keyManagerImportRemoteRequest{
+		RemoteKeys: make([]keyManagerRemoteKeyImport, len(pubkeys)),
+	}
+	for i, pubkey := range pubkeys {
+		request.RemoteKeys[i] = keyManagerRemoteKeyImport{
+			Pubkey: pubkey,
+			Url:    signerUrl,
+		}
+	}
+	var response struct {
+		Data []KeyManagerStatus `json:"data"`
+	}
+	err := c.sendRequest(ctx, http.MethodPost, keyManagerRemoteKeysPath, request, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error importing remote keys: %w", err)
+	}
+	if len(response.Data) != len(pubkeys) {
+		return nil, fmt.Errorf("key manager returned %d statuses for %d remote keys", len(response.Data), len(pubkeys))
+	}
+	return response.Data, nil
+}
+
+// Stops the Validator Client from signing for the provided remote keys, returning a status for each one (in the same
+// order). The remote signer keeps the keys and their slashing protection history.
+func (c *KeyManagerClient) DeleteRemoteKeys(ctx context.Context, pubkeys []beacon.ValidatorPubkey) ([]KeyManagerStatus, error) {
+	request := keyManagerDeleteRequest{
+		Pubkeys: pubkeys,
+	}
+	var response struct {
+		Data []KeyManagerStatus `json:"data"`
+	}
+	err := c.sendRequest(ctx, http.MethodDelete, keyManagerRemoteKeysPath, request, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting remote keys: %w", err)
+	}
+	if len(response.Data) != len(pubkeys) {
+		return nil, fmt.Errorf("key manager returned %d statuses for %d remote keys", len(response.Data), len(pubkeys))
+	}
+	return response.Data, nil
+}
+
+// Gets the fee recipient the Validator Client uses for a validator
+func (c *KeyManagerClient) GetFeeRecipient(ctx context.Context, pubkey beacon.ValidatorPubkey) (ethcommon.Address, error) {
+	var response struct {
+		Data struct {
+			EthAddress ethcommon.Address `json:"ethaddress"`
+		} `json:"data"`
+	}
+	err := c.sendRequest(ctx, http.MethodGet, fmt.Sprintf(keyManagerFeeRecipientPath, pubkey.HexWithPrefix()), nil, &response)
+	if err != nil {
+		return ethcommon.Address{}, fmt.Errorf("error getting fee recipient: %w", err)
+	}
+	return response.Data.EthAddress, nil
+}
+
+// Sets the fee recipient the Validator Client uses for a validator
+func (c *KeyManagerClient) SetFeeRecipient(ctx context.Context, pubkey beacon.ValidatorPubkey, feeRecipient ethcommon.Address) error {
+	request := keyManagerFeeRecipientRequest{
+		EthAddress: feeRecipient,
+	}
+	err := c.sendRequest(ctx, http.MethodPost, fmt.Sprintf(keyManagerFeeRecipientPath, pubkey.HexWithPrefix()), request, nil)
+	if err != nil {
+		return fmt.Errorf("error setting fee recipient: %w", err)
+	}
+	return nil
+}
+
+// Sends a request to the key manager API
+func (c *KeyManagerClient) sendRequest(ctx context.Context, method string, path string, body any, result any) error {
+	if c.url == "" {
+		return ErrKeyManagerNotConfigured
+	}
+
+	// Serialize the body
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error serializing request body: %w", err)
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+
+	// Create the request
+	fullUrl := c.url + path
+	request, err := http.NewRequestWithContext(ctx, method, fullUrl, bodyReader)
+	if err != nil {
+		return fmt.Errorf("error creating %s request to [%s]: %w", method, fullUrl, err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokenPath != "" {
+		token, err := os.ReadFile(c.tokenPath)
+		if err != nil {
+			return fmt.Errorf("error reading key manager token from [%s]: %w", c.tokenPath, err)
+		}
+		request.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	// Send it
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error running %s request to [%s]: %w", method, fullUrl, err)
+	}
+	defer response.Body.Close()
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response from [%s]: %w", fullUrl, err)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		var errResponse struct {
+			Message string `json:"message"`
+		}
+		message := string(responseBytes)
+		if json.Unmarshal(responseBytes, &errResponse) == nil && errResponse.Message != "" {
+			message = errResponse.Message
+		}
+		return fmt.Errorf("key manager returned HTTP status %d: %s", response.StatusCode, message)
+	}
+
+	if result == nil || len(responseBytes) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(responseBytes, result)
+	if err != nil {
+		return fmt.Errorf("error decoding response from [%s]: %w", fullUrl, err)
+	}
+	return nil
+}
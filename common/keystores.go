@@ -0,0 +1,250 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+var (
+	// The keystore couldn't be decrypted because the password doesn't match its checksum
+	ErrKeystoreWrongPassword error = errors.New("password does not match the keystore")
+
+	// The keystore isn't a valid EIP-2335 keystore
+	ErrKeystoreMalformed error = errors.New("the keystore isn't a valid EIP-2335 keystore")
+
+	// The keystore uses a key derivation function other than scrypt or PBKDF2
+	ErrKeystoreUnsupportedKdf error = errors.New("unsupported KDF")
+
+	// The keystore decrypted, but what's inside isn't a valid BLS private key
+	ErrKeystoreInvalidKey error = errors.New("the keystore doesn't contain a valid private key")
+
+	// The keystore decrypted, but the key inside doesn't belong to the pubkey the keystore declares
+	ErrKeystorePubkeyMismatch error = errors.New("the keystore's key doesn't match its pubkey")
+)
+
+// The reason a keystore failed verification
+type KeystoreFailureReason string
+
+const (
+	// The keystore was verified successfully
+	KeystoreFailureReason_None KeystoreFailureReason = ""
+
+	// The keystore couldn't be decrypted with the password
+	KeystoreFailureReason_WrongPassword KeystoreFailureReason = "wrongPassword"
+
+	// The keystore file isn't a valid EIP-2335 keystore
+	KeystoreFailureReason_CorruptJson KeystoreFailureReason = "corruptJson"
+
+	// The keystore uses a key derivation function that isn't supported
+	KeystoreFailureReason_UnsupportedKdf KeystoreFailureReason = "unsupportedKdf"
+
+	// The keystore decrypted, but the key inside doesn't match its pubkey
+	KeystoreFailureReason_InvalidKey KeystoreFailureReason = "invalidKey"
+)
+
+// The result of verifying a single keystore
+type KeystoreVerification struct {
+	Path          string                 `json:"path"`
+	Pubkey        beacon.ValidatorPubkey `json:"pubkey"`
+	Success       bool                   `json:"success"`
+	FailureReason KeystoreFailureReason  `json:"failureReason,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// The result of verifying all of the keystores on disk
+type VerifyResult struct {
+	Keystores    []KeystoreVerification `json:"keystores"`
+	SuccessCount int                    `json:"successCount"`
+	FailureCount int                    `json:"failureCount"`
+}
+
+// Attempts to decrypt every keystore in the keystore directory with the stored node password and reports which ones succeeded
+func (sp *ServiceProvider) VerifyKeystores(ctx context.Context) (VerifyResult, error) {
+	password, isSet, err := sp.GetWallet().GetPassword()
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("error getting node password: %w", err)
+	}
+	if !isSet {
+		return VerifyResult{}, errors.New("the node password has not been set, so the keystores cannot be verified")
+	}
+	return VerifyKeystoresInDir(ctx, sp.cfg.GetKeystoreDirectory(), password)
+}
+
+// Attempts to decrypt every keystore in the provided directory with the provided password
+func VerifyKeystoresInDir(ctx context.Context, keystoreDir string, password string) (VerifyResult, error) {
+	result := VerifyResult{
+		Keystores: []KeystoreVerification{},
+	}
+	paths, err := getKeystorePaths(keystoreDir)
+	if err != nil {
+		return result, err
+	}
+	err = validator.InitializeBls()
+	if err != nil {
+		return result, fmt.Errorf("error initializing BLS: %w", err)
+	}
+
+	encryptor := eth2ks.New()
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		verification := verifyKeystore(encryptor, path, password)
+		if verification.Success {
+			result.SuccessCount++
+		} else {
+			result.FailureCount++
+		}
+		result.Keystores = append(result.Keystores, verification)
+	}
+	return result, nil
+}
+
+// Get the paths of every keystore file in the directory, sorted by name
+func getKeystorePaths(keystoreDir string) ([]string, error) {
+	entries, err := os.ReadDir(keystoreDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading keystore directory [%s]: %w", keystoreDir, err)
+	}
+
+	paths := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || entry.Name() == hdconfig.SlashingProtectionFilename {
+			continue
+		}
+		paths = append(paths, filepath.Join(keystoreDir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Checks that an EIP-2335 keystore is valid without importing it: it has to be a version 4 keystore with a supported KDF
+// that decrypts with the provided password into the key for the pubkey it declares. Returns the keystore's pubkey if
+// it's valid, or an error wrapping ErrKeystoreMalformed, ErrKeystoreUnsupportedKdf, ErrKeystoreWrongPassword,
+// ErrKeystoreInvalidKey, or ErrKeystorePubkeyMismatch if it isn't.
+func ValidateKeystoreFile(data []byte, password string) (beacon.ValidatorPubkey, error) {
+	err := validator.InitializeBls()
+	if err != nil {
+		return beacon.ValidatorPubkey{}, fmt.Errorf("error initializing BLS: %w", err)
+	}
+	return validateKeystore(eth2ks.New(), data, password)
+}
+
+// Checks that an EIP-2335 keystore is valid without importing it; see ValidateKeystoreFile
+func (sp *ServiceProvider) ValidateKeystoreFile(data []byte, password string) (beacon.ValidatorPubkey, error) {
+	return ValidateKeystoreFile(data, password)
+}
+
+// Verifies a single keystore
+func verifyKeystore(encryptor *eth2ks.Encryptor, path string, password string) KeystoreVerification {
+	verification := KeystoreVerification{
+		Path: path,
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		verification.FailureReason = KeystoreFailureReason_CorruptJson
+		verification.Error = fmt.Sprintf("error reading keystore: %s", err.Error())
+		return verification
+	}
+
+	pubkey, err := validateKeystore(encryptor, bytes, password)
+	verification.Pubkey = pubkey
+	switch {
+	case err == nil:
+		verification.Success = true
+		return verification
+	case errors.Is(err, ErrKeystoreWrongPassword):
+		verification.FailureReason = KeystoreFailureReason_WrongPassword
+	case errors.Is(err, ErrKeystoreUnsupportedKdf):
+		verification.FailureReason = KeystoreFailureReason_UnsupportedKdf
+	case errors.Is(err, ErrKeystoreInvalidKey), errors.Is(err, ErrKeystorePubkeyMismatch):
+		verification.FailureReason = KeystoreFailureReason_InvalidKey
+	default:
+		verification.FailureReason = KeystoreFailureReason_CorruptJson
+	}
+	verification.Error = err.Error()
+	return verification
+}
+
+// Parses and decrypts a keystore, returning the pubkey of the key inside. If the keystore can be parsed but isn't valid,
+// its declared pubkey is returned along with the error.
+func validateKeystore(encryptor *eth2ks.Encryptor, data []byte, password string) (beacon.ValidatorPubkey, error) {
+	// Parse the keystore
+	var keystore beacon.ValidatorKeystore
+	err := json.Unmarshal(data, &keystore)
+	if err != nil {
+		return beacon.ValidatorPubkey{}, fmt.Errorf("%w: error deserializing keystore: %s", ErrKeystoreMalformed, err.Error())
+	}
+	if keystore.Crypto == nil {
+		return keystore.Pubkey, fmt.Errorf("%w: keystore is missing its crypto section", ErrKeystoreMalformed)
+	}
+	if keystore.Version != encryptor.Version() {
+		return keystore.Pubkey, fmt.Errorf("%w: keystore is version %d, but only version %d is supported", ErrKeystoreMalformed, keystore.Version, encryptor.Version())
+	}
+
+	// Check the KDF before trying to decrypt, since an unsupported one looks like a generic parse failure otherwise
+	kdf, err := getKeystoreKdf(keystore)
+	if err != nil {
+		return keystore.Pubkey, fmt.Errorf("%w: %s", ErrKeystoreMalformed, err.Error())
+	}
+	if kdf != "scrypt" && kdf != "pbkdf2" {
+		return keystore.Pubkey, fmt.Errorf("%w [%s]", ErrKeystoreUnsupportedKdf, kdf)
+	}
+
+	// Decrypt it and discard the key as soon as it's been checked
+	decryptedKey, err := decryptKeystore(encryptor, keystore, password)
+	if errors.Is(err, ErrKeystoreWrongPassword) {
+		return keystore.Pubkey, err
+	}
+	if err != nil {
+		return keystore.Pubkey, fmt.Errorf("%w: error decrypting keystore: %s", ErrKeystoreMalformed, err.Error())
+	}
+	defer clear(decryptedKey)
+	privateKey, err := eth2types.BLSPrivateKeyFromBytes(decryptedKey)
+	if err != nil {
+		return keystore.Pubkey, fmt.Errorf("%w: %s", ErrKeystoreInvalidKey, err.Error())
+	}
+	pubkey := beacon.ValidatorPubkey(privateKey.PublicKey().Marshal())
+	if keystore.Pubkey != (beacon.ValidatorPubkey{}) && pubkey != keystore.Pubkey {
+		return keystore.Pubkey, fmt.Errorf("%w: decrypted key has pubkey %s but the keystore is for %s", ErrKeystorePubkeyMismatch, pubkey.HexWithPrefix(), keystore.Pubkey.HexWithPrefix())
+	}
+	return pubkey, nil
+}
+
+// Decrypts a keystore, returning ErrKeystoreWrongPassword if the password doesn't match.
+// The encryptor only reports a checksum mismatch as an untyped error, so this is the one place that translates it.
+func decryptKeystore(encryptor *eth2ks.Encryptor, keystore beacon.ValidatorKeystore, password string) ([]byte, error) {
+	decryptedKey, err := encryptor.Decrypt(keystore.Crypto, password)
+	if err != nil && err.Error() == "invalid checksum" {
+		return nil, ErrKeystoreWrongPassword
+	}
+	return decryptedKey, err
+}
+
+// Get the name of the KDF used by a keystore
+func getKeystoreKdf(keystore beacon.ValidatorKeystore) (string, error) {
+	kdfSection, isMap := keystore.Crypto["kdf"].(map[string]any)
+	if !isMap {
+		return "", errors.New("keystore is missing its KDF section")
+	}
+	function, isString := kdfSection["function"].(string)
+	if !isString {
+		return "", errors.New("keystore is missing its KDF function")
+	}
+	return function, nil
+}
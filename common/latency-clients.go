@@ -0,0 +1,234 @@
+package common
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/eth"
+)
+
+// ========================
+// === Execution Client ===
+// ========================
+
+// An Execution Client that records the latency of each call, keyed by its JSON-RPC method
+type latencyTrackingExecutionClient struct {
+	eth.IExecutionClient
+	tracker *RpcLatencyTracker
+}
+
+// Wraps an Execution Client so the latency of its calls is recorded by the tracker
+func NewLatencyTrackingExecutionClient(client eth.IExecutionClient, tracker *RpcLatencyTracker) eth.IExecutionClient {
+	return &latencyTrackingExecutionClient{
+		IExecutionClient: client,
+		tracker:          tracker,
+	}
+}
+
+func (c *latencyTrackingExecutionClient) CodeAt(ctx context.Context, contract ethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getCode", time.Now())
+	return c.IExecutionClient.CodeAt(ctx, contract, blockNumber)
+}
+
+func (c *latencyTrackingExecutionClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_call", time.Now())
+	return c.IExecutionClient.CallContract(ctx, call, blockNumber)
+}
+
+func (c *latencyTrackingExecutionClient) HeaderByHash(ctx context.Context, hash ethcommon.Hash) (*types.Header, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getBlockByHash", time.Now())
+	return c.IExecutionClient.HeaderByHash(ctx, hash)
+}
+
+func (c *latencyTrackingExecutionClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getBlockByNumber", time.Now())
+	return c.IExecutionClient.HeaderByNumber(ctx, number)
+}
+
+func (c *latencyTrackingExecutionClient) PendingCodeAt(ctx context.Context, account ethcommon.Address) ([]byte, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getCode", time.Now())
+	return c.IExecutionClient.PendingCodeAt(ctx, account)
+}
+
+func (c *latencyTrackingExecutionClient) PendingNonceAt(ctx context.Context, account ethcommon.Address) (uint64, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getTransactionCount", time.Now())
+	return c.IExecutionClient.PendingNonceAt(ctx, account)
+}
+
+func (c *latencyTrackingExecutionClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_gasPrice", time.Now())
+	return c.IExecutionClient.SuggestGasPrice(ctx)
+}
+
+func (c *latencyTrackingExecutionClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_maxPriorityFeePerGas", time.Now())
+	return c.IExecutionClient.SuggestGasTipCap(ctx)
+}
+
+func (c *latencyTrackingExecutionClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_estimateGas", time.Now())
+	return c.IExecutionClient.EstimateGas(ctx, call)
+}
+
+func (c *latencyTrackingExecutionClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_sendRawTransaction", time.Now())
+	return c.IExecutionClient.SendTransaction(ctx, tx)
+}
+
+func (c *latencyTrackingExecutionClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getLogs", time.Now())
+	return c.IExecutionClient.FilterLogs(ctx, query)
+}
+
+func (c *latencyTrackingExecutionClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_subscribe", time.Now())
+	return c.IExecutionClient.SubscribeFilterLogs(ctx, query, ch)
+}
+
+func (c *latencyTrackingExecutionClient) TransactionReceipt(ctx context.Context, txHash ethcommon.Hash) (*types.Receipt, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getTransactionReceipt", time.Now())
+	return c.IExecutionClient.TransactionReceipt(ctx, txHash)
+}
+
+func (c *latencyTrackingExecutionClient) BlockNumber(ctx context.Context) (uint64, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_blockNumber", time.Now())
+	return c.IExecutionClient.BlockNumber(ctx)
+}
+
+func (c *latencyTrackingExecutionClient) BalanceAt(ctx context.Context, account ethcommon.Address, blockNumber *big.Int) (*big.Int, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getBalance", time.Now())
+	return c.IExecutionClient.BalanceAt(ctx, account, blockNumber)
+}
+
+func (c *latencyTrackingExecutionClient) TransactionByHash(ctx context.Context, hash ethcommon.Hash) (*types.Transaction, bool, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getTransactionByHash", time.Now())
+	return c.IExecutionClient.TransactionByHash(ctx, hash)
+}
+
+func (c *latencyTrackingExecutionClient) NonceAt(ctx context.Context, account ethcommon.Address, blockNumber *big.Int) (uint64, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_getTransactionCount", time.Now())
+	return c.IExecutionClient.NonceAt(ctx, account, blockNumber)
+}
+
+func (c *latencyTrackingExecutionClient) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_syncing", time.Now())
+	return c.IExecutionClient.SyncProgress(ctx)
+}
+
+func (c *latencyTrackingExecutionClient) ChainID(ctx context.Context) (*big.Int, error) {
+	defer c.tracker.recordSince(RpcClientType_ExecutionClient, "eth_chainId", time.Now())
+	return c.IExecutionClient.ChainID(ctx)
+}
+
+// ===================
+// === Beacon Node ===
+// ===================
+
+// A Beacon Node client that records the latency of each call, keyed by the client method
+type latencyTrackingBeaconClient struct {
+	beacon.IBeaconClient
+	tracker *RpcLatencyTracker
+}
+
+// Wraps a Beacon Node client so the latency of its calls is recorded by the tracker
+func NewLatencyTrackingBeaconClient(client beacon.IBeaconClient, tracker *RpcLatencyTracker) beacon.IBeaconClient {
+	return &latencyTrackingBeaconClient{
+		IBeaconClient: client,
+		tracker:       tracker,
+	}
+}
+
+func (c *latencyTrackingBeaconClient) GetSyncStatus(ctx context.Context) (beacon.SyncStatus, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetSyncStatus", time.Now())
+	return c.IBeaconClient.GetSyncStatus(ctx)
+}
+
+func (c *latencyTrackingBeaconClient) GetEth2Config(ctx context.Context) (beacon.Eth2Config, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetEth2Config", time.Now())
+	return c.IBeaconClient.GetEth2Config(ctx)
+}
+
+func (c *latencyTrackingBeaconClient) GetEth2DepositContract(ctx context.Context) (beacon.Eth2DepositContract, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetEth2DepositContract", time.Now())
+	return c.IBeaconClient.GetEth2DepositContract(ctx)
+}
+
+func (c *latencyTrackingBeaconClient) GetAttestations(ctx context.Context, blockId string) ([]beacon.AttestationInfo, bool, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetAttestations", time.Now())
+	return c.IBeaconClient.GetAttestations(ctx, blockId)
+}
+
+func (c *latencyTrackingBeaconClient) GetBeaconBlock(ctx context.Context, blockId string) (beacon.BeaconBlock, bool, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetBeaconBlock", time.Now())
+	return c.IBeaconClient.GetBeaconBlock(ctx, blockId)
+}
+
+func (c *latencyTrackingBeaconClient) GetBeaconBlockHeader(ctx context.Context, blockId string) (beacon.BeaconBlockHeader, bool, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetBeaconBlockHeader", time.Now())
+	return c.IBeaconClient.GetBeaconBlockHeader(ctx, blockId)
+}
+
+func (c *latencyTrackingBeaconClient) GetBeaconHead(ctx context.Context) (beacon.BeaconHead, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetBeaconHead", time.Now())
+	return c.IBeaconClient.GetBeaconHead(ctx)
+}
+
+func (c *latencyTrackingBeaconClient) GetValidatorStatusByIndex(ctx context.Context, index string, opts *beacon.ValidatorStatusOptions) (beacon.ValidatorStatus, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetValidatorStatusByIndex", time.Now())
+	return c.IBeaconClient.GetValidatorStatusByIndex(ctx, index, opts)
+}
+
+func (c *latencyTrackingBeaconClient) GetValidatorStatus(ctx context.Context, pubkey beacon.ValidatorPubkey, opts *beacon.ValidatorStatusOptions) (beacon.ValidatorStatus, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetValidatorStatus", time.Now())
+	return c.IBeaconClient.GetValidatorStatus(ctx, pubkey, opts)
+}
+
+func (c *latencyTrackingBeaconClient) GetValidatorStatuses(ctx context.Context, pubkeys []beacon.ValidatorPubkey, opts *beacon.ValidatorStatusOptions) (map[beacon.ValidatorPubkey]beacon.ValidatorStatus, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetValidatorStatuses", time.Now())
+	return c.IBeaconClient.GetValidatorStatuses(ctx, pubkeys, opts)
+}
+
+func (c *latencyTrackingBeaconClient) GetValidatorIndex(ctx context.Context, pubkey beacon.ValidatorPubkey) (string, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetValidatorIndex", time.Now())
+	return c.IBeaconClient.GetValidatorIndex(ctx, pubkey)
+}
+
+func (c *latencyTrackingBeaconClient) GetValidatorSyncDuties(ctx context.Context, indices []string, epoch uint64) (map[string]bool, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetValidatorSyncDuties", time.Now())
+	return c.IBeaconClient.GetValidatorSyncDuties(ctx, indices, epoch)
+}
+
+func (c *latencyTrackingBeaconClient) GetValidatorProposerDuties(ctx context.Context, indices []string, epoch uint64) (map[string]uint64, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetValidatorProposerDuties", time.Now())
+	return c.IBeaconClient.GetValidatorProposerDuties(ctx, indices, epoch)
+}
+
+func (c *latencyTrackingBeaconClient) GetDomainData(ctx context.Context, domainType []byte, epoch uint64, useGenesisFork bool) ([]byte, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetDomainData", time.Now())
+	return c.IBeaconClient.GetDomainData(ctx, domainType, epoch, useGenesisFork)
+}
+
+func (c *latencyTrackingBeaconClient) ExitValidator(ctx context.Context, validatorIndex string, epoch uint64, signature beacon.ValidatorSignature) error {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "ExitValidator", time.Now())
+	return c.IBeaconClient.ExitValidator(ctx, validatorIndex, epoch, signature)
+}
+
+func (c *latencyTrackingBeaconClient) GetEth1DataForEth2Block(ctx context.Context, blockId string) (beacon.Eth1Data, bool, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetEth1DataForEth2Block", time.Now())
+	return c.IBeaconClient.GetEth1DataForEth2Block(ctx, blockId)
+}
+
+func (c *latencyTrackingBeaconClient) GetCommitteesForEpoch(ctx context.Context, epoch *uint64) (beacon.Committees, error) {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "GetCommitteesForEpoch", time.Now())
+	return c.IBeaconClient.GetCommitteesForEpoch(ctx, epoch)
+}
+
+func (c *latencyTrackingBeaconClient) ChangeWithdrawalCredentials(ctx context.Context, validatorIndex string, fromBlsPubkey beacon.ValidatorPubkey, toExecutionAddress ethcommon.Address, signature beacon.ValidatorSignature) error {
+	defer c.tracker.recordSince(RpcClientType_BeaconNode, "ChangeWithdrawalCredentials", time.Now())
+	return c.IBeaconClient.ChangeWithdrawalCredentials(ctx, validatorIndex, fromBlsPubkey, toExecutionAddress, signature)
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+// The status of a container managed by this Hyperdrive instance
+type ContainerStatus struct {
+	// The container's name, without Docker's leading slash
+	Name string `json:"name"`
+
+	// The image the container was created from
+	Image string `json:"image"`
+
+	// The container's state (e.g. running or exited)
+	State string `json:"state"`
+
+	// The container's health (e.g. healthy or unhealthy), or blank if it doesn't have a health check
+	Health string `json:"health"`
+
+	// How long the container has been running, or 0 if it isn't running
+	Uptime time.Duration `json:"uptime"`
+}
+
+// Lists the containers labeled as belonging to this Hyperdrive instance's project, including stopped ones, sorted by
+// name. Containers of other instances on the same Docker host are left out.
+func (sp *ServiceProvider) ListManagedContainers(ctx context.Context) ([]ContainerStatus, error) {
+	project := sp.cfg.ProjectName.Value
+	d := sp.GetDocker()
+	containers, err := d.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", hdconfig.InstanceLabel+"="+project)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing containers: %w", err)
+	}
+
+	statuses := make([]ContainerStatus, 0, len(containers))
+	for _, listed := range containers {
+		name := listed.ID
+		if len(listed.Names) > 0 {
+			name = strings.TrimPrefix(listed.Names[0], "/")
+		}
+		status := ContainerStatus{
+			Name:  name,
+			Image: listed.Image,
+			State: listed.State,
+		}
+
+		// The list doesn't include the health or start time
+		info, err := d.ContainerInspect(ctx, listed.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error inspecting container [%s]: %w", name, err)
+		}
+		if info.ContainerJSONBase != nil && info.State != nil {
+			if info.State.Health != nil {
+				status.Health = info.State.Health.Status
+			}
+			if info.State.Running {
+				startedAt, err := time.Parse(time.RFC3339Nano, info.State.StartedAt)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing start time of container [%s]: %w", name, err)
+				}
+				status.Uptime = time.Since(startedAt)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses, nil
+}
@@ -0,0 +1,132 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+var (
+	// The module hasn't registered a resource with the provided key
+	ErrUnknownModuleResource error = errors.New("the module doesn't have a resource with that key")
+)
+
+// Registers the addresses a module resolved for its resources (such as its contracts) on the current network, keyed by
+// resource name, so they can be overridden at runtime. Saved overrides for the module's resources are applied on top.
+func (sp *ServiceProvider) RegisterModuleResources(module string, resources map[string]ethcommon.Address) {
+	sp.moduleResourceLock.Lock()
+	defer sp.moduleResourceLock.Unlock()
+	sp.moduleResources[module] = maps.Clone(resources)
+	for key, address := range sp.moduleResourceOverrides[module] {
+		if _, exists := resources[key]; exists {
+			sp.logModuleResourceOverride(module, key, resources[key], address)
+		}
+	}
+}
+
+// Gets the address of a module's resource, with any override applied. Returns false if the module hasn't registered it.
+func (sp *ServiceProvider) GetModuleResource(module string, key string) (ethcommon.Address, bool) {
+	sp.moduleResourceLock.Lock()
+	defer sp.moduleResourceLock.Unlock()
+	address, exists := sp.moduleResources[module][key]
+	if !exists {
+		return ethcommon.Address{}, false
+	}
+	if override, isOverridden := sp.moduleResourceOverrides[module][key]; isOverridden {
+		return override, true
+	}
+	return address, true
+}
+
+// Overrides the address of one of a module's registered resources, for when a protocol upgrade moves a contract before a
+// release with the new address ships. The address must have code deployed on-chain. The override only lasts until the
+// daemon restarts unless it's saved with SaveModuleResourceOverrides.
+// Returns ErrUnknownModuleResource if the module hasn't registered the resource.
+func (sp *ServiceProvider) OverrideModuleResource(module string, key string, address ethcommon.Address) error {
+	sp.moduleResourceLock.Lock()
+	original, exists := sp.moduleResources[module][key]
+	sp.moduleResourceLock.Unlock()
+	if !exists {
+		return fmt.Errorf("%w: module [%s] resource [%s]", ErrUnknownModuleResource, module, key)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hdconfig.ClientTimeout)
+	defer cancel()
+	err := sp.VerifyContract(ctx, address, nil)
+	if err != nil {
+		return fmt.Errorf("error verifying override for module [%s] resource [%s]: %w", module, key, err)
+	}
+
+	sp.moduleResourceLock.Lock()
+	defer sp.moduleResourceLock.Unlock()
+	if sp.moduleResourceOverrides[module] == nil {
+		sp.moduleResourceOverrides[module] = map[string]ethcommon.Address{}
+	}
+	sp.moduleResourceOverrides[module][key] = address
+	sp.logModuleResourceOverride(module, key, original, address)
+	return nil
+}
+
+// Gets the module resource overrides in effect, keyed by module and then resource key
+func (sp *ServiceProvider) GetModuleResourceOverrides() map[string]map[string]ethcommon.Address {
+	sp.moduleResourceLock.Lock()
+	defer sp.moduleResourceLock.Unlock()
+	overrides := make(map[string]map[string]ethcommon.Address, len(sp.moduleResourceOverrides))
+	for module, moduleOverrides := range sp.moduleResourceOverrides {
+		overrides[module] = maps.Clone(moduleOverrides)
+	}
+	return overrides
+}
+
+// Saves the module resource overrides in effect to the user directory, so they're applied again after a restart
+func (sp *ServiceProvider) SaveModuleResourceOverrides() error {
+	overrides := sp.GetModuleResourceOverrides()
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing module resource overrides: %w", err)
+	}
+	path := filepath.Join(sp.userDir, hdconfig.ModuleResourceOverridesFilename)
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing module resource overrides [%s]: %w", path, err)
+	}
+	return nil
+}
+
+// Loads the saved module resource overrides from the user directory, if there are any
+func (sp *ServiceProvider) loadModuleResourceOverrides() (map[string]map[string]ethcommon.Address, error) {
+	overrides := map[string]map[string]ethcommon.Address{}
+	path := filepath.Join(sp.userDir, hdconfig.ModuleResourceOverridesFilename)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return overrides, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading module resource overrides [%s]: %w", path, err)
+	}
+	err = json.Unmarshal(data, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing module resource overrides [%s]: %w", path, err)
+	}
+	return overrides, nil
+}
+
+// Logs that a module resource is using an overridden address instead of its default, in both the API and tasks logs
+func (sp *ServiceProvider) logModuleResourceOverride(module string, key string, original ethcommon.Address, override ethcommon.Address) {
+	for _, logger := range []*slog.Logger{sp.GetApiLogger().Logger, sp.GetTasksLogger().Logger} {
+		logger.Warn("MODULE RESOURCE OVERRIDDEN: using a non-default address",
+			slog.String("module", module),
+			slog.String("resource", key),
+			slog.String("default", original.Hex()),
+			slog.String("override", override.Hex()),
+		)
+	}
+}
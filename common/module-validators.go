@@ -0,0 +1,120 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// The requested module isn't registered with the service provider, or is disabled
+	ErrModuleNotEnabled error = errors.New("the module isn't enabled")
+)
+
+// A module that runs validators on the node. Every stake contributor is one, so registering a module's stake also
+// makes its validators available per module.
+type ValidatorEnumerator interface {
+	// The name of the module
+	GetModuleName() string
+
+	// True if the module is enabled
+	IsEnabled() bool
+
+	// Get the pubkeys of the validators the module runs on this node
+	GetValidatorPubkeys(ctx context.Context) ([]beacon.ValidatorPubkey, error)
+}
+
+// A validator owned by a module, with its status on the Beacon Chain
+type ValidatorInfo struct {
+	// The validator's pubkey
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// True if the Beacon Chain has seen the validator's deposit; the fields below are only set if it has
+	OnBeaconChain bool `json:"onBeaconChain"`
+
+	// The validator's index on the Beacon Chain
+	Index string `json:"index"`
+
+	// The validator's exact state on the Beacon Chain
+	State beacon.ValidatorState `json:"state"`
+
+	// The general status the state falls under; blank if the state isn't recognized
+	Status ValidatorStatus `json:"status"`
+
+	// The validator's balance, in gwei
+	Balance uint64 `json:"balance"`
+
+	// The validator's effective balance, in gwei
+	EffectiveBalance uint64 `json:"effectiveBalance"`
+
+	// True if the validator has been slashed
+	Slashed bool `json:"slashed"`
+
+	// The epoch the validator was or will be activated in; the far future epoch if it hasn't been scheduled yet
+	ActivationEpoch uint64 `json:"activationEpoch"`
+
+	// The epoch the validator exited or will exit in; the far future epoch if it hasn't started exiting
+	ExitEpoch uint64 `json:"exitEpoch"`
+}
+
+// Gets the validators the provided module runs on the node with their Beacon Chain statuses, in the order the module
+// lists them. Validators the Beacon Chain hasn't seen yet are included with OnBeaconChain unset. Returns an error
+// wrapping ErrModuleNotEnabled if there isn't an enabled module with that name.
+func (sp *ServiceProvider) GetValidatorsByModule(ctx context.Context, module string) ([]ValidatorInfo, error) {
+	var enumerator ValidatorEnumerator
+	for _, contributor := range sp.getStakeContributors() {
+		if contributor.GetModuleName() == module && contributor.IsEnabled() {
+			enumerator = contributor
+			break
+		}
+	}
+	if enumerator == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModuleNotEnabled, module)
+	}
+	pubkeys, err := enumerator.GetValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validators for module [%s]: %w", module, err)
+	}
+	infos := make([]ValidatorInfo, len(pubkeys))
+	if len(pubkeys) == 0 {
+		return infos, nil
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+		infos[i].Pubkey = pubkey
+	}
+
+	// Look up all of them at once so large sets are batched
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator statuses: %w", err)
+	}
+	positions := make(map[beacon.ValidatorPubkey]int, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		positions[pubkey] = i
+	}
+	for _, validator := range validators {
+		i, exists := positions[beacon.ValidatorPubkey(validator.Validator.Pubkey)]
+		if !exists {
+			continue
+		}
+		state := beacon.ValidatorState(validator.Status)
+		status, _ := getValidatorStatus(state)
+		infos[i] = ValidatorInfo{
+			Pubkey:           pubkeys[i],
+			OnBeaconChain:    true,
+			Index:            validator.Index,
+			State:            state,
+			Status:           status,
+			Balance:          uint64(validator.Balance),
+			EffectiveBalance: uint64(validator.Validator.EffectiveBalance),
+			Slashed:          validator.Validator.Slashed,
+			ActivationEpoch:  uint64(validator.Validator.ActivationEpoch),
+			ExitEpoch:        uint64(validator.Validator.ExitEpoch),
+		}
+	}
+	return infos, nil
+}
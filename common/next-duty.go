@@ -0,0 +1,114 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// A kind of validator duty
+type DutyType string
+
+const (
+	// The validator has to attest
+	DutyType_Attestation DutyType = "attestation"
+
+	// The validator has to propose a block
+	DutyType_Proposal DutyType = "proposal"
+)
+
+// A validator's next scheduled duty
+type NextDuty struct {
+	// True if the validator has a duty scheduled in the lookahead window; the duty fields are only set if it does.
+	// If not, the validator has nothing to do through LookaheadEndSlot.
+	HasDuty bool `json:"hasDuty"`
+
+	// The kind of duty
+	Type DutyType `json:"type"`
+
+	// The slot of the duty
+	Slot uint64 `json:"slot"`
+
+	// The estimated start of the duty's slot
+	Time time.Time `json:"time"`
+
+	// The head slot the lookahead started from
+	HeadSlot uint64 `json:"headSlot"`
+
+	// The last slot the lookahead covered, which is the end of the epoch after the head's
+	LookaheadEndSlot uint64 `json:"lookaheadEndSlot"`
+}
+
+// Gets the validator's next attestation or block proposal, from the Beacon Node's head slot through the end of the next
+// epoch. Beacon Nodes that can't provide the next epoch's proposers yet only have their attestations checked for it.
+func (sp *ServiceProvider) GetNextDuty(ctx context.Context, pubkey beacon.ValidatorPubkey) (NextDuty, error) {
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return NextDuty{}, err
+	}
+	genesisTime, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return NextDuty{}, err
+	}
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return NextDuty{}, err
+	}
+	currentEpoch := headSlot / spec.SlotsPerEpoch
+	result := NextDuty{
+		HeadSlot:         headSlot,
+		LookaheadEndSlot: (currentEpoch+2)*spec.SlotsPerEpoch - 1,
+	}
+
+	// Look up the validator index
+	validators, err := bn.GetValidators(ctx, "head", []string{pubkey.HexWithPrefix()}, nil)
+	if err != nil {
+		return NextDuty{}, err
+	}
+	if len(validators) == 0 {
+		return NextDuty{}, fmt.Errorf("validator %s was not found on the Beacon Chain", pubkey.HexWithPrefix())
+	}
+	index := validators[0].Index
+
+	// Find the earliest duty that hasn't passed yet
+	setDuty := func(dutyType DutyType, slot uint64) {
+		if slot < headSlot || (result.HasDuty && slot >= result.Slot) {
+			return
+		}
+		result.HasDuty = true
+		result.Type = dutyType
+		result.Slot = slot
+	}
+	for epoch := currentEpoch; epoch <= currentEpoch+1; epoch++ {
+		attesterDuties, err := bn.GetAttesterDuties(ctx, epoch, []string{index})
+		if err != nil {
+			return NextDuty{}, err
+		}
+		for _, duty := range attesterDuties {
+			if duty.ValidatorIndex == index {
+				setDuty(DutyType_Attestation, uint64(duty.Slot))
+			}
+		}
+
+		proposerDuties, err := bn.GetProposerDuties(ctx, epoch)
+		if err != nil {
+			if epoch > currentEpoch {
+				break
+			}
+			return NextDuty{}, err
+		}
+		for _, duty := range proposerDuties {
+			if duty.ValidatorIndex == index {
+				setDuty(DutyType_Proposal, uint64(duty.Slot))
+			}
+		}
+	}
+
+	if result.HasDuty {
+		result.Time = genesisTime.Add(time.Duration(result.Slot*spec.SecondsPerSlot) * time.Second)
+	}
+	return result, nil
+}
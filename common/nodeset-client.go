@@ -0,0 +1,310 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The login flow follows NodeSet's node authentication API, relative to the configured NodeSet API URL
+// (HyperdriveConfig.NodeSetApiUrl):
+//   - GET  <api>/nonce returns {"nonce": "..."}
+//   - POST <api>/login takes {"address", "nonce", "signature"} and returns {"token": "..."}
+//
+// The signature is an EIP-191 personal_sign over nodesetLoginMessageFormat.
+const (
+	// Route for requesting a login nonce from the NodeSet server
+	nodesetNoncePath string = "nonce"
+
+	// Route for logging into the NodeSet server with a signed nonce
+	nodesetLoginPath string = "login"
+
+	// The message format signed by the node wallet to prove ownership during login, as expected by the login route
+	nodesetLoginMessageFormat string = `{"nonce":"%s","address":"%s"}`
+)
+
+var (
+	// The NodeSet server rejected the credentials provided with the request
+	ErrNodeSetUnauthorized error = errors.New("NodeSet rejected the request's credentials")
+
+	// The NodeSet server is rate limiting this node
+	ErrNodeSetRateLimited error = errors.New("NodeSet is rate limiting requests from this node")
+
+	// The NodeSet server failed to process the request
+	ErrNodeSetServerFailure error = errors.New("NodeSet encountered an error processing the request")
+
+	// The NodeSet server rejected the request itself, such as for a malformed body or a missing resource
+	ErrNodeSetBadRequest error = errors.New("NodeSet rejected the request")
+
+	// The NodeSet server responded with a status code that isn't a success or an error
+	ErrNodeSetUnexpectedResponse error = errors.New("NodeSet sent an unexpected response")
+
+	// The node wallet isn't available for signing the login message
+	ErrNodeSetNoWallet error = errors.New("the node does not have an address to log into NodeSet with")
+)
+
+// An error returned by the NodeSet server, wrapping one of the typed NodeSet errors when applicable
+type NodeSetRequestError struct {
+	// The HTTP status code of the response
+	StatusCode int
+
+	// The message provided by the server, if any
+	Message string
+
+	// The typed error that corresponds to the status code
+	kind error
+}
+
+func (e *NodeSetRequestError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s (status code %d)", e.kind.Error(), e.StatusCode)
+	}
+	return fmt.Sprintf("%s (status code %d): %s", e.kind.Error(), e.StatusCode, e.Message)
+}
+
+func (e *NodeSetRequestError) Unwrap() error {
+	return e.kind
+}
+
+// Signs messages on behalf of the node for NodeSet authentication
+type NodeSetSigner interface {
+	// Get the node's address
+	GetAddress() (common.Address, bool)
+
+	// Sign a message with the node's private key
+	SignMessage(message []byte) ([]byte, error)
+}
+
+// The server's response to a nonce request
+type nodesetNonceResponse struct {
+	Nonce string `json:"nonce"`
+}
+
+// The body of a login request
+type nodesetLoginRequest struct {
+	Address   string `json:"address"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// The server's response to a login request
+type nodesetLoginResponse struct {
+	Token string `json:"token"`
+}
+
+// The error body returned by the server on failure
+type nodesetErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Client for NodeSet's web API. Logs in with the node wallet and transparently refreshes the auth token when it expires.
+type NodeSetClient struct {
+	baseUrl string
+	signer  NodeSetSigner
+	client  *http.Client
+
+	token     string
+	tokenLock *sync.Mutex
+}
+
+// Creates a new NodeSet client
+func NewNodeSetClient(baseUrl string, signer NodeSetSigner, timeout time.Duration) *NodeSetClient {
+	return &NodeSetClient{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		signer:  signer,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		tokenLock: &sync.Mutex{},
+	}
+}
+
+// Logs into the NodeSet server, replacing the cached auth token
+func (c *NodeSetClient) Login(ctx context.Context) error {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	return c.loginImpl(ctx)
+}
+
+// Sends an authenticated GET request to the NodeSet server, deserializing the response into the result if provided
+func (c *NodeSetClient) Get(ctx context.Context, path string, result any) error {
+	return c.sendAuthenticatedRequest(ctx, http.MethodGet, path, nil, result)
+}
+
+// Sends an authenticated POST request to the NodeSet server, deserializing the response into the result if provided
+func (c *NodeSetClient) Post(ctx context.Context, path string, body any, result any) error {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error serializing request body: %w", err)
+	}
+	return c.sendAuthenticatedRequest(ctx, http.MethodPost, path, bodyBytes, result)
+}
+
+// Sends a request with the cached auth token, logging in again and retrying once if the token was rejected.
+// Both 401 and 403 map to ErrNodeSetUnauthorized and both trigger the retry, since a session the server has
+// invalidated can come back as either; if the node genuinely lacks access, the single retry bounds the cost.
+func (c *NodeSetClient) sendAuthenticatedRequest(ctx context.Context, method string, path string, body []byte, result any) error {
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.sendRequest(ctx, method, path, token, body, result)
+	if !errors.Is(err, ErrNodeSetUnauthorized) {
+		return err
+	}
+
+	// The token expired, so refresh it and try again
+	token, err = c.refreshToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	return c.sendRequest(ctx, method, path, token, body, result)
+}
+
+// Gets the cached auth token, logging in if there isn't one yet
+func (c *NodeSetClient) getToken(ctx context.Context) (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+
+	if c.token == "" {
+		err := c.loginImpl(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// Logs in again if the rejected token is still the cached one; if another request already refreshed it, the new token is used instead
+func (c *NodeSetClient) refreshToken(ctx context.Context, rejectedToken string) (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+
+	if c.token == rejectedToken {
+		err := c.loginImpl(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// Runs the login flow. Must be called while holding the token lock.
+func (c *NodeSetClient) loginImpl(ctx context.Context) error {
+	c.token = ""
+	address, hasAddress := c.signer.GetAddress()
+	if !hasAddress {
+		return ErrNodeSetNoWallet
+	}
+
+	// Get a nonce
+	var nonceResponse nodesetNonceResponse
+	err := c.sendRequest(ctx, http.MethodGet, nodesetNoncePath, "", nil, &nonceResponse)
+	if err != nil {
+		return fmt.Errorf("error getting login nonce: %w", err)
+	}
+
+	// Sign it
+	message := fmt.Sprintf(nodesetLoginMessageFormat, nonceResponse.Nonce, address.Hex())
+	signature, err := c.signer.SignMessage([]byte(message))
+	if err != nil {
+		return fmt.Errorf("error signing login message: %w", err)
+	}
+
+	// Log in
+	request := nodesetLoginRequest{
+		Address:   address.Hex(),
+		Nonce:     nonceResponse.Nonce,
+		Signature: hexutil.Encode(signature),
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("error serializing login request: %w", err)
+	}
+	var loginResponse nodesetLoginResponse
+	err = c.sendRequest(ctx, http.MethodPost, nodesetLoginPath, "", body, &loginResponse)
+	if err != nil {
+		return fmt.Errorf("error logging in: %w", err)
+	}
+	if loginResponse.Token == "" {
+		return fmt.Errorf("login response did not include an auth token")
+	}
+	c.token = loginResponse.Token
+	return nil
+}
+
+// Sends a single request to the NodeSet server and maps failure status codes to typed errors
+func (c *NodeSetClient) sendRequest(ctx context.Context, method string, path string, token string, body []byte, result any) error {
+	url := fmt.Sprintf("%s/%s", c.baseUrl, strings.TrimPrefix(path, "/"))
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return fmt.Errorf("error creating request to [%s]: %w", url, err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending request to [%s]: %w", url, err)
+	}
+	defer response.Body.Close()
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response from [%s]: %w", url, err)
+	}
+
+	// Handle failures
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		var kind error
+		switch {
+		case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+			kind = ErrNodeSetUnauthorized
+		case response.StatusCode == http.StatusTooManyRequests:
+			kind = ErrNodeSetRateLimited
+		case response.StatusCode >= 500:
+			kind = ErrNodeSetServerFailure
+		case response.StatusCode >= 400:
+			kind = ErrNodeSetBadRequest
+		default:
+			kind = ErrNodeSetUnexpectedResponse
+		}
+		var errResponse nodesetErrorResponse
+		message := strings.TrimSpace(string(responseBytes))
+		if json.Unmarshal(responseBytes, &errResponse) == nil && errResponse.Error != "" {
+			message = errResponse.Error
+		}
+		return &NodeSetRequestError{
+			StatusCode: response.StatusCode,
+			Message:    message,
+			kind:       kind,
+		}
+	}
+
+	if result == nil || len(responseBytes) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(responseBytes, result)
+	if err != nil {
+		return fmt.Errorf("error deserializing response from [%s]: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+var (
+	// The characters an operation ID can have, since it's used as the name of the operation's journal file
+	operationIdPattern *regexp.Regexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+var (
+	// A step names a handler that hasn't been registered
+	ErrUnknownStepHandler error = errors.New("no step handler is registered with that name")
+
+	// An operation with the provided ID has already been started and hasn't finished yet
+	ErrOperationExists error = errors.New("an operation with that ID is already in progress")
+)
+
+// Runs one step of a resumable operation with the data the step was started with. Steps are run again if the daemon
+// stops before they're recorded as complete, so handlers have to be idempotent.
+type StepHandler func(ctx context.Context, data json.RawMessage) error
+
+// One step of a resumable operation. Steps refer to their handler by name so they can be saved and run by a restarted
+// daemon.
+type Step struct {
+	// The name of the handler that runs the step
+	Handler string `json:"handler"`
+
+	// The data passed to the handler
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// A long-running operation whose progress is saved to the user directory after every step, so it can pick up where it
+// left off if the daemon restarts
+type Operation struct {
+	// The operation's unique ID
+	ID string `json:"id"`
+
+	// The steps of the operation, in order
+	Steps []Step `json:"steps"`
+
+	// The number of steps that have finished
+	CompletedSteps int `json:"completedSteps"`
+
+	// The error from the last attempt at the next step, if it failed
+	Error string `json:"error,omitempty"`
+
+	// When the operation was started
+	StartTime time.Time `json:"startTime"`
+
+	sp *ServiceProvider
+}
+
+// An operation that was continued by ResumeOperations
+type ResumedOperation struct {
+	// The operation's ID
+	ID string `json:"id"`
+
+	// The index of the step the operation was resumed from
+	ResumedFromStep int `json:"resumedFromStep"`
+
+	// The number of steps that have finished
+	CompletedSteps int `json:"completedSteps"`
+
+	// The total number of steps in the operation
+	TotalSteps int `json:"totalSteps"`
+
+	// True if every step has finished
+	Completed bool `json:"completed"`
+
+	// The error the operation stopped on, if it didn't complete
+	Error string `json:"error,omitempty"`
+}
+
+// Registers the handler for steps with the provided name. Handlers have to be registered before an operation that uses
+// them is started or resumed.
+func (sp *ServiceProvider) RegisterStepHandler(name string, handler StepHandler) {
+	sp.operationLock.Lock()
+	defer sp.operationLock.Unlock()
+	sp.stepHandlers[name] = handler
+}
+
+// Starts a resumable operation with the provided steps and saves it to the user directory. The operation doesn't do
+// anything until it's run; if the daemon restarts before it finishes, ResumeOperations will continue it from the first
+// step that hadn't completed. Returns an error wrapping ErrOperationExists if an unfinished operation already has the ID.
+func (sp *ServiceProvider) StartResumableOperation(id string, steps []Step) (*Operation, error) {
+	if !operationIdPattern.MatchString(id) {
+		return nil, fmt.Errorf("operation ID [%s] can only have letters, numbers, dots, dashes, and underscores", id)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("operation [%s] doesn't have any steps", id)
+	}
+
+	sp.operationLock.Lock()
+	defer sp.operationLock.Unlock()
+	for i, step := range steps {
+		if _, exists := sp.stepHandlers[step.Handler]; !exists {
+			return nil, fmt.Errorf("step %d of operation [%s] uses handler [%s]: %w", i, id, step.Handler, ErrUnknownStepHandler)
+		}
+	}
+	path := sp.getOperationJournalPath(id)
+	_, err := os.Stat(path)
+	if err == nil {
+		return nil, fmt.Errorf("%w: [%s]", ErrOperationExists, id)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error checking for operation journal [%s]: %w", path, err)
+	}
+
+	op := &Operation{
+		ID:        id,
+		Steps:     steps,
+		StartTime: time.Now(),
+		sp:        sp,
+	}
+	err = op.save()
+	if err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// Runs the operation's remaining steps in order, saving its progress after each one. The operation's journal is removed
+// once every step has finished. If a step fails, the operation stops there and keeps its journal so it can be resumed.
+func (op *Operation) Run(ctx context.Context) error {
+	for op.CompletedSteps < len(op.Steps) {
+		step := op.Steps[op.CompletedSteps]
+		op.sp.operationLock.Lock()
+		handler, exists := op.sp.stepHandlers[step.Handler]
+		op.sp.operationLock.Unlock()
+		if !exists {
+			return op.fail(fmt.Errorf("step %d uses handler [%s]: %w", op.CompletedSteps, step.Handler, ErrUnknownStepHandler))
+		}
+
+		err := handler(ctx, step.Data)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return op.fail(fmt.Errorf("error running step %d (%s) of operation [%s]: %w", op.CompletedSteps, step.Handler, op.ID, err))
+		}
+		op.CompletedSteps++
+		op.Error = ""
+		if op.CompletedSteps < len(op.Steps) {
+			err = op.save()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	path := op.sp.getOperationJournalPath(op.ID)
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing journal of finished operation [%s]: %w", path, err)
+	}
+	return nil
+}
+
+// Continues every operation that was saved to the user directory but didn't finish, which is usually because the daemon
+// stopped while it was running. Each one is run from the first step that hadn't completed. An operation that fails again
+// is reported with its error rather than failing the whole call; the returned error is only for journals that couldn't
+// be read.
+func (sp *ServiceProvider) ResumeOperations(ctx context.Context) ([]ResumedOperation, error) {
+	ops, err := sp.loadOperations()
+	if err != nil {
+		return nil, err
+	}
+
+	resumed := []ResumedOperation{}
+	for _, op := range ops {
+		if ctx.Err() != nil {
+			return resumed, ctx.Err()
+		}
+		result := ResumedOperation{
+			ID:              op.ID,
+			ResumedFromStep: op.CompletedSteps,
+			TotalSteps:      len(op.Steps),
+		}
+		err := op.Run(ctx)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.CompletedSteps = op.CompletedSteps
+		result.Completed = op.CompletedSteps == len(op.Steps)
+		resumed = append(resumed, result)
+	}
+	return resumed, nil
+}
+
+// Records the error a step failed with in the operation's journal and returns it
+func (op *Operation) fail(err error) error {
+	op.Error = err.Error()
+	saveErr := op.save()
+	if saveErr != nil {
+		return fmt.Errorf("%w (and %s)", err, saveErr.Error())
+	}
+	return err
+}
+
+// Saves the operation's journal to the user directory, replacing the old one atomically so a crash mid-write can't
+// leave a partial journal behind
+func (op *Operation) save() error {
+	dir := filepath.Join(op.sp.userDir, hdconfig.OperationJournalDir)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("error creating operation journal directory [%s]: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing operation [%s]: %w", op.ID, err)
+	}
+	path := op.sp.getOperationJournalPath(op.ID)
+	tempPath := path + ".tmp"
+	err = os.WriteFile(tempPath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing operation journal [%s]: %w", tempPath, err)
+	}
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		return fmt.Errorf("error replacing operation journal [%s]: %w", path, err)
+	}
+	return nil
+}
+
+// Loads the journals of every unfinished operation in the user directory, oldest first
+func (sp *ServiceProvider) loadOperations() ([]*Operation, error) {
+	dir := filepath.Join(sp.userDir, hdconfig.OperationJournalDir)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Operation{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading operation journal directory [%s]: %w", dir, err)
+	}
+
+	ops := []*Operation{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading operation journal [%s]: %w", path, err)
+		}
+		op := &Operation{}
+		err = json.Unmarshal(data, op)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing operation journal [%s]: %w", path, err)
+		}
+		op.sp = sp
+		ops = append(ops, op)
+	}
+	sort.SliceStable(ops, func(i int, j int) bool {
+		return ops[i].StartTime.Before(ops[j].StartTime)
+	})
+	return ops, nil
+}
+
+// Gets the path of an operation's journal in the user directory
+func (sp *ServiceProvider) getOperationJournalPath(id string) string {
+	return filepath.Join(sp.userDir, hdconfig.OperationJournalDir, id+".json")
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// A module that can have on-chain actions waiting on the operator, such as claiming rewards or staking. Modules register
+// one of these with the service provider so their actions are included in the node's pending actions.
+type ActionProvider interface {
+	// The name of the module
+	GetModuleName() string
+
+	// True if the module is enabled; disabled modules are left out of the pending actions
+	IsEnabled() bool
+
+	// Get the actions the module is waiting on the operator to take
+	GetPendingActions(ctx context.Context) ([]PendingAction, error)
+}
+
+// An on-chain action waiting on the operator
+type PendingAction struct {
+	// An identifier for the action that stays the same while it's pending, so the same action reported more than once
+	// (e.g. by two modules sharing a contract) is only listed once
+	ID string `json:"id"`
+
+	// The module that reported the action; set by the service provider
+	Module string `json:"module"`
+
+	// A human-readable description of the action
+	Description string `json:"description"`
+
+	// The estimated gas the action's transaction will use
+	EstimatedGas uint64 `json:"estimatedGas"`
+
+	// The transaction Execute submits and the account that sends it, so the action can be simulated; these can be
+	// empty, but the action's cost can't be estimated without them
+	Transaction *types.Transaction `json:"-"`
+	From        ethcommon.Address  `json:"from"`
+
+	// True if the action's transaction can be sent through a multicall contract, which means its target doesn't care
+	// who the caller is
+	Bundleable bool `json:"bundleable"`
+
+	// The IDs of actions that have to succeed before this one can be executed
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Submits the action's transaction, returning its hash
+	Execute func(ctx context.Context) (ethcommon.Hash, error) `json:"-"`
+}
+
+// The pending actions of every enabled module
+type PendingActionsResult struct {
+	// The actions, in the order the modules were registered and then the order each module reported them
+	Actions []PendingAction `json:"actions"`
+
+	// The errors of the modules that couldn't list their actions, keyed by module name
+	FailedModules map[string]string `json:"failedModules"`
+}
+
+// Registers a module's action provider so its actions are included in the node's pending actions
+func (sp *ServiceProvider) RegisterActionProvider(provider ActionProvider) {
+	sp.actionProviderLock.Lock()
+	defer sp.actionProviderLock.Unlock()
+	sp.actionProviders = append(sp.actionProviders, provider)
+}
+
+// Gets the on-chain actions every enabled module is waiting on the operator to take, with duplicates removed. Modules
+// that fail to list their actions are logged and left out, so this only returns an error if the context is cancelled;
+// use GetPendingActionsResult to get their errors.
+func (sp *ServiceProvider) GetPendingActions(ctx context.Context) ([]PendingAction, error) {
+	result, err := sp.GetPendingActionsResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+	modules := make([]string, 0, len(result.FailedModules))
+	for module := range result.FailedModules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	logger := sp.GetApiLogger()
+	for _, module := range modules {
+		logger.Warn("Couldn't get a module's pending actions", slog.String("module", module), slog.String(log.ErrorKey, result.FailedModules[module]))
+	}
+	return result.Actions, nil
+}
+
+// Gets the on-chain actions every enabled module is waiting on the operator to take, along with the modules that
+// couldn't list theirs. Actions with the same ID are only included once, from the first module that reported them.
+// Actions without an execute function can't be resolved, so they're treated as a failure of the module that reported
+// them.
+func (sp *ServiceProvider) GetPendingActionsResult(ctx context.Context) (PendingActionsResult, error) {
+	sp.actionProviderLock.Lock()
+	providers := make([]ActionProvider, len(sp.actionProviders))
+	copy(providers, sp.actionProviders)
+	sp.actionProviderLock.Unlock()
+
+	result := PendingActionsResult{
+		Actions:       []PendingAction{},
+		FailedModules: map[string]string{},
+	}
+	seen := map[string]bool{}
+	for _, provider := range providers {
+		if !provider.IsEnabled() {
+			continue
+		}
+		name := provider.GetModuleName()
+		actions, err := provider.GetPendingActions(ctx)
+		if ctx.Err() != nil {
+			return PendingActionsResult{}, ctx.Err()
+		}
+		if err == nil {
+			err = validatePendingActions(actions)
+		}
+		if err != nil {
+			result.FailedModules[name] = err.Error()
+			continue
+		}
+
+		for _, action := range actions {
+			if seen[action.ID] {
+				continue
+			}
+			seen[action.ID] = true
+			action.Module = name
+			result.Actions = append(result.Actions, action)
+		}
+	}
+	return result, nil
+}
+
+// Checks that every action a module reported can be told apart from the others and resolved
+func validatePendingActions(actions []PendingAction) error {
+	for i, action := range actions {
+		if action.ID == "" {
+			return fmt.Errorf("pending action %d [%s] doesn't have an ID", i, action.Description)
+		}
+		if action.Execute == nil {
+			return fmt.Errorf("pending action [%s] can't be executed", action.ID)
+		}
+	}
+	return nil
+}
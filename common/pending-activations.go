@@ -0,0 +1,235 @@
+package common
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+)
+
+const (
+	// The number of epochs between a validator leaving the activation queue and being activated (1 + MAX_SEED_LOOKAHEAD)
+	activationDelayEpochs uint64 = 5
+
+	// The number of epochs the finalized checkpoint normally trails the head by; a validator can only leave the queue
+	// once the epoch it became eligible in is finalized
+	finalityDelayEpochs uint64 = 2
+
+	// The number of epochs between a deposit being processed and the validator becoming eligible for the queue, since
+	// its effective balance is only updated at the end of the epoch it was processed in
+	eligibilityDelayEpochs uint64 = 2
+)
+
+// One of the node's validators that's waiting to be activated
+type PendingActivation struct {
+	// The validator's pubkey
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// The validator's index on the Beacon Chain; blank if its deposit is still in the pending deposit queue
+	Index string `json:"index"`
+
+	// The validator's 1-based position in the network's activation queue, counting every validator ahead of it
+	Position uint64 `json:"position"`
+
+	// True if the Beacon Chain has already assigned the validator's activation epoch, so the estimate is exact
+	Scheduled bool `json:"scheduled"`
+
+	// The epoch the validator is expected to be activated in
+	EstimatedEpoch uint64 `json:"estimatedEpoch"`
+
+	// The time the validator is expected to be activated at, which is the start of the estimated epoch
+	EstimatedTime time.Time `json:"estimatedTime"`
+}
+
+// A validator in the network's activation queue, on the Beacon Chain or as a pending deposit
+type queuedValidator struct {
+	pubkey           beacon.ValidatorPubkey
+	index            uint64
+	indexString      string
+	eligibilityEpoch uint64
+	activationEpoch  uint64
+}
+
+// Gets every validator of the enabled modules that's waiting to be activated, with its position in the activation queue
+// and an estimate of when it will be activated, sorted by position. The queue is ordered the way the Beacon Chain
+// processes it: validators that have been assigned an activation epoch come first, then the rest by the epoch they
+// became eligible in and their index. Before Electra, the queue drains at the activation churn limit from GetChurnInfo.
+// From Electra onwards, eligible validators aren't rate limited; the deposits that create them are, so validators whose
+// deposits are still pending are placed after the ones on the Beacon Chain and estimated from the pending deposit
+// balance ahead of them and the balance churn limit.
+// Every estimate includes the wait for the validator's eligibility to be finalized and the delay between leaving the
+// queue and being activated, so a validator at the front of the queue is still several epochs away.
+func (sp *ServiceProvider) GetPendingActivations(ctx context.Context) ([]PendingActivation, error) {
+	activations := []PendingActivation{}
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pubkeys) == 0 {
+		return activations, nil
+	}
+	churn, err := sp.GetChurnInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting churn info: %w", err)
+	}
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bn := sp.GetBeaconApiClient()
+	genesisTime, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	epochLength := time.Duration(spec.SlotsPerEpoch*spec.SecondsPerSlot) * time.Second
+
+	// Find which of the node's validators are pending
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+	validators, err := bn.GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator statuses: %w", err)
+	}
+	onChain := map[beacon.ValidatorPubkey]bool{}
+	nodePending := map[beacon.ValidatorPubkey]bool{}
+	hasQueued := false
+	initialized := []queuedValidator{}
+	for _, validator := range validators {
+		pubkey := beacon.ValidatorPubkey(validator.Validator.Pubkey)
+		onChain[pubkey] = true
+		switch beacon.ValidatorState(validator.Status) {
+		case beacon.ValidatorState_PendingQueued:
+			nodePending[pubkey] = true
+			hasQueued = true
+		case beacon.ValidatorState_PendingInitialized:
+			// These aren't eligible yet, so they'll join the back of the queue at the end of the epoch
+			queued, err := getQueuedValidator(validator)
+			if err != nil {
+				return nil, err
+			}
+			queued.eligibilityEpoch = churn.Epoch + 1
+			queued.activationEpoch = FarFutureEpoch
+			nodePending[pubkey] = true
+			initialized = append(initialized, queued)
+		}
+	}
+
+	// Build the network's queue; it's also needed for the positions of pending deposits, which come after it
+	hasDeposits := churn.ElectraActive && len(onChain) < len(pubkeys)
+	queue := []queuedValidator{}
+	if hasQueued || hasDeposits {
+		pending, err := bn.GetValidators(ctx, "head", nil, []string{string(beacon.ValidatorState_PendingQueued)})
+		if err != nil {
+			return nil, fmt.Errorf("error getting the activation queue: %w", err)
+		}
+		for _, validator := range pending {
+			queued, err := getQueuedValidator(validator)
+			if err != nil {
+				return nil, err
+			}
+			queue = append(queue, queued)
+		}
+	}
+	slices.SortFunc(queue, compareQueuedValidators)
+	slices.SortFunc(initialized, compareQueuedValidators)
+	queue = append(queue, initialized...)
+
+	// Estimate the activations of the node's validators on the Beacon Chain
+	var waiting uint64
+	for i, queued := range queue {
+		scheduled := queued.activationEpoch != FarFutureEpoch
+		estimatedEpoch := queued.activationEpoch
+		if !scheduled {
+			waiting++
+			dequeueEpoch := max(churn.Epoch, queued.eligibilityEpoch+finalityDelayEpochs)
+			if !churn.ElectraActive && churn.ActivationChurnLimit > 0 {
+				// The validators ahead of this one take up the churn of the epochs before it
+				dequeueEpoch = max(dequeueEpoch, churn.Epoch+divideRoundingUp(waiting, churn.ActivationChurnLimit)-1)
+			}
+			estimatedEpoch = dequeueEpoch + activationDelayEpochs
+		}
+		if !nodePending[queued.pubkey] {
+			continue
+		}
+		activations = append(activations, PendingActivation{
+			Pubkey:         queued.pubkey,
+			Index:          queued.indexString,
+			Position:       uint64(i + 1),
+			Scheduled:      scheduled,
+			EstimatedEpoch: estimatedEpoch,
+			EstimatedTime:  genesisTime.Add(time.Duration(estimatedEpoch) * epochLength),
+		})
+	}
+
+	// Estimate the ones still in the pending deposit queue
+	if hasDeposits {
+		nodePubkeys := map[beacon.ValidatorPubkey]bool{}
+		for _, pubkey := range pubkeys {
+			nodePubkeys[pubkey] = !onChain[pubkey]
+		}
+		deposits, err := bn.GetPendingDeposits(ctx, "head")
+		if err != nil {
+			return nil, err
+		}
+		var balanceAhead uint64
+		for i, deposit := range deposits {
+			balanceAhead += uint64(deposit.Amount)
+			if len(deposit.Pubkey) != beacon.ValidatorPubkeyLength {
+				continue
+			}
+			pubkey := beacon.ValidatorPubkey(deposit.Pubkey)
+			if !nodePubkeys[pubkey] {
+				continue
+			}
+
+			// Only the first deposit for a new validator creates it
+			nodePubkeys[pubkey] = false
+			processedEpoch := churn.Epoch
+			if churn.BalanceChurnLimit > 0 {
+				processedEpoch += divideRoundingUp(balanceAhead, churn.BalanceChurnLimit) - 1
+			}
+			estimatedEpoch := processedEpoch + eligibilityDelayEpochs + finalityDelayEpochs + activationDelayEpochs
+			activations = append(activations, PendingActivation{
+				Pubkey:         pubkey,
+				Position:       uint64(len(queue) + i + 1),
+				EstimatedEpoch: estimatedEpoch,
+				EstimatedTime:  genesisTime.Add(time.Duration(estimatedEpoch) * epochLength),
+			})
+		}
+	}
+	return activations, nil
+}
+
+// Gets the parts of a validator that determine its place in the activation queue
+func getQueuedValidator(validator client.Validator) (queuedValidator, error) {
+	index, err := strconv.ParseUint(validator.Index, 10, 64)
+	if err != nil {
+		return queuedValidator{}, fmt.Errorf("error parsing validator index [%s]: %w", validator.Index, err)
+	}
+	return queuedValidator{
+		pubkey:           beacon.ValidatorPubkey(validator.Validator.Pubkey),
+		index:            index,
+		indexString:      validator.Index,
+		eligibilityEpoch: uint64(validator.Validator.ActivationEligibilityEpoch),
+		activationEpoch:  uint64(validator.Validator.ActivationEpoch),
+	}, nil
+}
+
+// Orders validators in the activation queue: scheduled ones by activation epoch, then the rest by eligibility epoch,
+// with ties broken by index
+func compareQueuedValidators(a queuedValidator, b queuedValidator) int {
+	if c := cmp.Compare(a.activationEpoch, b.activationEpoch); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.eligibilityEpoch, b.eligibilityEpoch); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.index, b.index)
+}
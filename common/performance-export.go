@@ -0,0 +1,222 @@
+package common
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// The columns of the performance CSV, in order. Spreadsheets and scripts rely on these, so new columns go at the
+	// end and existing ones are never renamed or reordered.
+	PerformanceCsvHeader []string = []string{
+		"epoch",
+		"validator_index",
+		"pubkey",
+		"balance_gwei",
+		"attestation_duties",
+		"attestations_included",
+		"inclusion_distance",
+		"participation",
+		"withdrawals",
+		"withdrawn_gwei",
+	}
+)
+
+// A validator being exported, and its attestation duty in the epoch being written
+type performanceExportValidator struct {
+	index  uint64
+	id     string
+	pubkey beacon.ValidatorPubkey
+	duty   *attestationDuty
+}
+
+// Writes the performance of the validators of every enabled module to w as CSV, with a row per validator per epoch from
+// fromEpoch to toEpoch (inclusive). Each row has the validator's balance at the start of the epoch, whether its
+// attestation for the epoch was included and how many slots late, and the withdrawals from it during the epoch; the
+// columns are in PerformanceCsvHeader. Validators that weren't on the Beacon Chain yet during an epoch don't have a row
+// for it.
+// Rows are written and flushed an epoch at a time, so large ranges don't have to fit in memory. The last epoch must be
+// complete so its attestations have had a chance to be included. Returns an *InsufficientHistoryError if the Beacon
+// Node doesn't have the state for one of the epochs; the rows for the epochs before it have already been written.
+func (sp *ServiceProvider) ExportPerformanceCSV(ctx context.Context, w io.Writer, fromEpoch, toEpoch uint64) error {
+	if fromEpoch > toEpoch {
+		return fmt.Errorf("start epoch %d is after end epoch %d", fromEpoch, toEpoch)
+	}
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return err
+	}
+	slotsPerEpoch := spec.SlotsPerEpoch
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return err
+	}
+	if headEpoch := headSlot / slotsPerEpoch; toEpoch >= headEpoch {
+		return fmt.Errorf("end epoch %d isn't complete yet (the head epoch is %d)", toEpoch, headEpoch)
+	}
+
+	// Get the validator indices
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return err
+	}
+	validators := []*performanceExportValidator{}
+	if len(pubkeys) > 0 {
+		ids := make([]string, len(pubkeys))
+		for i, pubkey := range pubkeys {
+			ids[i] = pubkey.HexWithPrefix()
+		}
+		headValidators, err := bn.GetValidators(ctx, "head", ids, nil)
+		if err != nil {
+			return err
+		}
+		for _, validator := range headValidators {
+			index, err := strconv.ParseUint(validator.Index, 10, 64)
+			if err != nil {
+				return fmt.Errorf("validator %s has an invalid index [%s]: %w", beacon.ValidatorPubkey(validator.Validator.Pubkey).HexWithPrefix(), validator.Index, err)
+			}
+			validators = append(validators, &performanceExportValidator{
+				index:  index,
+				id:     validator.Index,
+				pubkey: beacon.ValidatorPubkey(validator.Validator.Pubkey),
+			})
+		}
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].index < validators[j].index
+	})
+	ids := make([]string, len(validators))
+	indices := map[uint64]bool{}
+	byId := map[string]*performanceExportValidator{}
+	for i, validator := range validators {
+		ids[i] = validator.id
+		indices[validator.index] = true
+		byId[validator.id] = validator
+	}
+
+	writer := csv.NewWriter(w)
+	err = writer.Write(PerformanceCsvHeader)
+	if err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+	writer.Flush()
+	if len(validators) == 0 {
+		return writer.Error()
+	}
+
+	// Inclusions are only searched for in the epoch after a duty, so only the blocks from there on need to stay cached
+	blockCache := map[uint64]cachedAttestations{}
+	for epoch := fromEpoch; epoch <= toEpoch; epoch++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slot := epoch * slotsPerEpoch
+		for cachedSlot := range blockCache {
+			if cachedSlot <= slot {
+				delete(blockCache, cachedSlot)
+			}
+		}
+		stateId := strconv.FormatUint(slot, 10)
+
+		// Get the balances and duties
+		balances, exists, err := bn.GetValidatorBalances(ctx, stateId, ids)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return &InsufficientHistoryError{
+				StartEpoch: fromEpoch,
+				Reason:     fmt.Sprintf("the Beacon Node doesn't have the state for epoch %d", epoch),
+			}
+		}
+		committees, err := bn.GetCommittees(ctx, stateId, epoch)
+		if err != nil {
+			return err
+		}
+		committeeSizes := map[uint64][]int{}
+		for _, validator := range validators {
+			validator.duty = nil
+		}
+		for _, committee := range committees {
+			dutySlot := uint64(committee.Slot)
+			index := int(committee.Index)
+			for len(committeeSizes[dutySlot]) <= index {
+				committeeSizes[dutySlot] = append(committeeSizes[dutySlot], 0)
+			}
+			committeeSizes[dutySlot][index] = len(committee.Validators)
+			for position, id := range committee.Validators {
+				validator, exists := byId[id]
+				if !exists {
+					continue
+				}
+				validator.duty = &attestationDuty{
+					slot:           dutySlot,
+					committeeIndex: uint64(committee.Index),
+					position:       position,
+				}
+			}
+		}
+
+		// Get the withdrawals
+		withdrawals, scanErr := sp.getChunkWithdrawals(ctx, slot, slot+slotsPerEpoch-1, indices)
+		if scanErr != nil {
+			return fmt.Errorf("error getting withdrawals for epoch %d: %w", epoch, scanErr)
+		}
+		withdrawalCounts := map[uint64]uint64{}
+		withdrawalAmounts := map[uint64]uint64{}
+		for _, withdrawal := range withdrawals {
+			withdrawalCounts[withdrawal.ValidatorIndex]++
+			withdrawalAmounts[withdrawal.ValidatorIndex] += withdrawal.Amount
+		}
+
+		// Write the epoch's rows
+		for _, validator := range validators {
+			balance, exists := balances[validator.id]
+			if !exists {
+				continue
+			}
+			duties, included, inclusionDistance, participation := "0", "0", "", ""
+			if validator.duty != nil {
+				duties = "1"
+				participation = "0"
+				distance, wasIncluded, err := findInclusionDistance(ctx, bn, blockCache, committeeSizes[validator.duty.slot], *validator.duty, slotsPerEpoch, headSlot)
+				if err != nil {
+					return err
+				}
+				if wasIncluded {
+					included = "1"
+					inclusionDistance = strconv.FormatUint(distance, 10)
+					participation = "1"
+				}
+			}
+			err = writer.Write([]string{
+				strconv.FormatUint(epoch, 10),
+				validator.id,
+				validator.pubkey.HexWithPrefix(),
+				strconv.FormatUint(balance, 10),
+				duties,
+				included,
+				inclusionDistance,
+				participation,
+				strconv.FormatUint(withdrawalCounts[validator.index], 10),
+				strconv.FormatUint(withdrawalAmounts[validator.index], 10),
+			})
+			if err != nil {
+				return fmt.Errorf("error writing CSV row for validator %s in epoch %d: %w", validator.id, epoch, err)
+			}
+		}
+		writer.Flush()
+		err = writer.Error()
+		if err != nil {
+			return fmt.Errorf("error writing CSV rows for epoch %d: %w", epoch, err)
+		}
+	}
+	return nil
+}
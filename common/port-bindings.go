@@ -0,0 +1,285 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The directory with the kernel's socket tables, on platforms that have /proc
+	procNetDir string = "/proc/net"
+
+	// The socket states that mean a port is held in /proc/net; TCP_LISTEN and TCP_CLOSE (which unconnected UDP
+	// sockets report)
+	procNetTcpListenState string = "0A"
+	procNetUdpBoundState  string = "07"
+
+	// The error Windows returns instead of EADDRINUSE when a port is taken
+	wsaeAddrInUse syscall.Errno = 10048
+)
+
+// The result of checking one of the host ports a managed container publishes
+type PortCheck struct {
+	// The container that publishes the port
+	Service config.ContainerID `json:"service"`
+
+	// What the port is used for (e.g. "P2P" or "HTTP API")
+	Name string `json:"name"`
+
+	// The host port
+	Port uint16 `json:"port"`
+
+	// The port's protocol, tcp or udp
+	Protocol string `json:"protocol"`
+
+	// The host address the port is published on, or blank for all interfaces
+	HostIp string `json:"hostIp"`
+
+	// True if the service's running container has already published the port
+	Published bool `json:"published"`
+
+	// True if something other than the service's container is holding the port, so the container won't start
+	Conflict bool `json:"conflict"`
+
+	// The ID of the process holding the port, if it could be found; this is only discoverable on platforms with /proc,
+	// and only for processes the daemon can see
+	OwnerPid int `json:"ownerPid,omitempty"`
+
+	// The name of the process holding the port, if it could be found
+	OwnerProcess string `json:"ownerProcess,omitempty"`
+
+	// An error that stopped the port from being checked (e.g. not having permission to bind it), other than a conflict
+	Error string `json:"error,omitempty"`
+}
+
+// Checks that each host port the managed containers publish is available, so port conflicts are caught before they stop
+// a container from starting. Ports the service's own running container has published are reported as such without being
+// bound; the rest are bound and released again. For ports held by something else, the owning process is looked up
+// through /proc where it's available; on other platforms (e.g. Windows), the conflict is reported without it.
+func (sp *ServiceProvider) CheckPortBindings(ctx context.Context) ([]PortCheck, error) {
+	checks := getManagedHostPorts(sp.cfg)
+	if len(checks) == 0 {
+		return checks, nil
+	}
+	published, err := sp.getPublishedPorts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range checks {
+		check := &checks[i]
+		if published[getPublishedPortKey(check.Service, check.Port, check.Protocol)] {
+			check.Published = true
+			continue
+		}
+		err := tryBindPort(check.Protocol, net.JoinHostPort(check.HostIp, strconv.FormatUint(uint64(check.Port), 10)))
+		if err == nil {
+			continue
+		}
+		if !isAddressInUse(err) {
+			check.Error = err.Error()
+			continue
+		}
+		check.Conflict = true
+		check.OwnerPid, check.OwnerProcess = findPortOwner(check.Protocol, check.Port)
+	}
+	return checks, nil
+}
+
+// Gets the host ports the managed containers publish with the current config
+func getManagedHostPorts(cfg *hdconfig.HyperdriveConfig) []PortCheck {
+	checks := []PortCheck{}
+	addOptionalPort := func(service config.ContainerID, name string, port uint16, mode config.RpcPortMode) {
+		if !mode.IsOpen() {
+			return
+		}
+		hostIp := ""
+		if mode == config.RpcPortMode_OpenLocalhost {
+			hostIp = "127.0.0.1"
+		}
+		checks = append(checks, PortCheck{Service: service, Name: name, Port: port, Protocol: "tcp", HostIp: hostIp})
+	}
+	addPort := func(service config.ContainerID, name string, port uint16, protocols ...string) {
+		for _, protocol := range protocols {
+			checks = append(checks, PortCheck{Service: service, Name: name, Port: port, Protocol: protocol})
+		}
+	}
+
+	if cfg.IsLocalMode() {
+		ec := cfg.LocalExecutionClient
+		addPort(config.ContainerID_ExecutionClient, "P2P", ec.P2pPort.Value, "tcp", "udp")
+		addOptionalPort(config.ContainerID_ExecutionClient, "HTTP API", ec.HttpPort.Value, ec.OpenApiPorts.Value)
+		addOptionalPort(config.ContainerID_ExecutionClient, "Websocket API", ec.WebsocketPort.Value, ec.OpenApiPorts.Value)
+
+		bn := cfg.LocalBeaconClient
+		addPort(config.ContainerID_BeaconNode, "P2P", bn.P2pPort.Value, "tcp", "udp")
+		if bn.BeaconNode.Value == config.BeaconNode_Lighthouse {
+			addPort(config.ContainerID_BeaconNode, "P2P (QUIC)", bn.Lighthouse.P2pQuicPort.Value, "udp")
+		}
+		addOptionalPort(config.ContainerID_BeaconNode, "HTTP API", bn.HttpPort.Value, bn.OpenHttpPort.Value)
+		if bn.BeaconNode.Value == config.BeaconNode_Prysm {
+			addOptionalPort(config.ContainerID_BeaconNode, "RPC", bn.Prysm.RpcPort.Value, bn.Prysm.OpenRpcPort.Value)
+		}
+	}
+	if cfg.MevBoost.Enable.Value && cfg.MevBoost.Mode.Value == config.ClientMode_Local {
+		addOptionalPort(config.ContainerID_MevBoost, "API", cfg.MevBoost.Port.Value, cfg.MevBoost.OpenRpcPort.Value)
+	}
+	if cfg.Metrics.EnableMetrics.Value {
+		addOptionalPort(config.ContainerID_Prometheus, "API", cfg.Metrics.Prometheus.Port.Value, cfg.Metrics.Prometheus.OpenPort.Value)
+		addPort(config.ContainerID_Grafana, "Dashboard", cfg.Metrics.Grafana.Port.Value, "tcp")
+	}
+	return checks
+}
+
+// Gets the host ports published by the running managed containers, keyed by getPublishedPortKey
+func (sp *ServiceProvider) getPublishedPorts(ctx context.Context) (map[string]bool, error) {
+	containers, err := sp.GetDocker().ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", hdconfig.InstanceLabel+"="+sp.cfg.ProjectName.Value)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing running containers: %w", err)
+	}
+
+	// Container names are the service IDs with the project prefix
+	services := map[string]config.ContainerID{}
+	for _, id := range []config.ContainerID{
+		config.ContainerID_ExecutionClient,
+		config.ContainerID_BeaconNode,
+		config.ContainerID_MevBoost,
+		config.ContainerID_Prometheus,
+		config.ContainerID_Grafana,
+	} {
+		services[sp.cfg.GetDockerArtifactName(string(id))] = id
+	}
+
+	published := map[string]bool{}
+	for _, listed := range containers {
+		for _, name := range listed.Names {
+			service, exists := services[strings.TrimPrefix(name, "/")]
+			if !exists {
+				continue
+			}
+			for _, port := range listed.Ports {
+				if port.PublicPort != 0 {
+					published[getPublishedPortKey(service, port.PublicPort, port.Type)] = true
+				}
+			}
+		}
+	}
+	return published, nil
+}
+
+// Gets the key of a published port in the map from getPublishedPorts
+func getPublishedPortKey(service config.ContainerID, port uint16, protocol string) string {
+	return fmt.Sprintf("%s/%d/%s", service, port, protocol)
+}
+
+// Binds the address with the provided protocol and releases it right away
+func tryBindPort(protocol string, address string) error {
+	if protocol == "udp" {
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return listener.Close()
+}
+
+// Checks if a bind failed because the port is taken
+func isAddressInUse(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.EADDRINUSE || errno == wsaeAddrInUse
+}
+
+// Finds the process holding a port by matching its socket in /proc/net to a process's file descriptors. Returns 0 and
+// a blank name if /proc isn't available or the owner isn't visible to the daemon.
+func findPortOwner(protocol string, port uint16) (int, string) {
+	state := procNetTcpListenState
+	if protocol == "udp" {
+		state = procNetUdpBoundState
+	}
+	inodes := map[string]bool{}
+	for _, table := range []string{protocol, protocol + "6"} {
+		for _, inode := range getProcNetSocketInodes(filepath.Join(procNetDir, table), port, state) {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return 0, ""
+	}
+
+	processes, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, ""
+	}
+	for _, process := range processes {
+		pid, err := strconv.Atoi(process.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", process.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			// Other users' processes can't be inspected
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			if inodes[strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")] {
+				name, _ := os.ReadFile(filepath.Join("/proc", process.Name(), "comm"))
+				return pid, strings.TrimSpace(string(name))
+			}
+		}
+	}
+	return 0, ""
+}
+
+// Gets the inodes of the sockets in a /proc/net table that are bound to the port in the provided state
+func getProcNetSocketInodes(path string, port uint16, state string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	// Each line after the header is "sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...",
+	// with the addresses as hex IP:port
+	inodes := []string{}
+	portSuffix := fmt.Sprintf(":%04X", port)
+	scanner := bufio.NewScanner(file)
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], portSuffix) && fields[3] == state {
+			inodes = append(inodes, fields[9])
+		}
+	}
+	return inodes
+}
@@ -0,0 +1,308 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+const (
+	// The number of blocks to request from the Execution Client in a single batch when scanning for proposals
+	priorityFeeChunkSize uint64 = 100
+)
+
+// The Execution layer rewards of the node's proposals over a range of blocks, in wei
+type PriorityFeeBreakdown struct {
+	// The first and last blocks of the range
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+
+	// The number of blocks in the range the node's validators proposed
+	ProposalCount int `json:"proposalCount"`
+
+	// The priority fees paid to the validators' configured fee recipients by blocks they built locally
+	PriorityFees *big.Int `json:"priorityFees"`
+
+	// The payments from block builders to the validators' configured fee recipients
+	MevRewards *big.Int `json:"mevRewards"`
+
+	// The priority fees and builder payments from the validators' proposals that went somewhere else
+	Misdirected *big.Int `json:"misdirected"`
+
+	// The proposals whose rewards went somewhere other than the configured fee recipient, oldest first
+	MisdirectedProposals []MisdirectedProposal `json:"misdirectedProposals"`
+}
+
+// A proposal whose Execution layer rewards didn't go to the validator's configured fee recipient
+type MisdirectedProposal struct {
+	// The Execution layer block
+	BlockNumber uint64 `json:"blockNumber"`
+
+	// The slot the block was proposed in
+	Slot uint64 `json:"slot"`
+
+	// The validator that proposed it
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// Where the rewards went
+	FeeRecipient ethcommon.Address `json:"feeRecipient"`
+
+	// The fee recipient the Validator Client has configured for the validator
+	ExpectedFeeRecipient ethcommon.Address `json:"expectedFeeRecipient"`
+
+	// The rewards that were misdirected, in wei
+	Amount *big.Int `json:"amount"`
+}
+
+// The parts of an Execution layer block needed to find its proposer and what it paid them
+type feeBlock struct {
+	Number        hexutil.Uint64    `json:"number"`
+	Timestamp     hexutil.Uint64    `json:"timestamp"`
+	Miner         ethcommon.Address `json:"miner"`
+	BaseFeePerGas *hexutil.Big      `json:"baseFeePerGas"`
+}
+
+// The parts of a transaction needed to spot a builder's payment to the proposer
+type feeTransaction struct {
+	Hash  ethcommon.Hash     `json:"hash"`
+	From  ethcommon.Address  `json:"from"`
+	To    *ethcommon.Address `json:"to"`
+	Value *hexutil.Big       `json:"value"`
+}
+
+// Gets the priority fees and MEV payments captured by the node's validators in the provided range of Execution layer
+// blocks, in wei. Only rewards that were paid to the fee recipient the Validator Client has configured for the proposer
+// count; use GetPriorityFeeBreakdown to see the ones that went elsewhere.
+func (sp *ServiceProvider) GetPriorityFeesCaptured(ctx context.Context, fromBlock uint64, toBlock uint64) (*big.Int, error) {
+	breakdown, err := sp.GetPriorityFeeBreakdown(ctx, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Add(breakdown.PriorityFees, breakdown.MevRewards), nil
+}
+
+// Gets the Execution layer rewards of the blocks the node's validators proposed in the provided range, inclusive.
+// Each block's slot is worked out from its timestamp and checked against the proposer duties for its epoch. A block
+// whose last transaction is a payment from its fee recipient was built by a builder, so the payment is counted as MEV;
+// otherwise the block's priority fees are counted. Rewards are compared against the fee recipient the Validator Client
+// has configured for the proposer through its key manager API, so this returns ErrKeyManagerNotConfigured without it.
+// The range is scanned in chunks, and the context is checked between them so long ranges can be cancelled.
+func (sp *ServiceProvider) GetPriorityFeeBreakdown(ctx context.Context, fromBlock uint64, toBlock uint64) (PriorityFeeBreakdown, error) {
+	if fromBlock > toBlock {
+		return PriorityFeeBreakdown{}, fmt.Errorf("block range [%d, %d] is empty", fromBlock, toBlock)
+	}
+	breakdown := PriorityFeeBreakdown{
+		FromBlock:            fromBlock,
+		ToBlock:              toBlock,
+		PriorityFees:         big.NewInt(0),
+		MevRewards:           big.NewInt(0),
+		Misdirected:          big.NewInt(0),
+		MisdirectedProposals: []MisdirectedProposal{},
+	}
+
+	// Map the node's validators to their indices, which is what the proposer duties are made of
+	bn := sp.GetBeaconApiClient()
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return PriorityFeeBreakdown{}, err
+	}
+	if len(pubkeys) == 0 {
+		return breakdown, nil
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+	validators, err := bn.GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return PriorityFeeBreakdown{}, err
+	}
+	pubkeysByIndex := map[string]beacon.ValidatorPubkey{}
+	for _, validator := range validators {
+		pubkeysByIndex[validator.Index] = beacon.ValidatorPubkey(validator.Validator.Pubkey)
+	}
+	if len(pubkeysByIndex) == 0 {
+		return breakdown, nil
+	}
+	if sp.cfg.KeyManager.Url.Value == "" {
+		return PriorityFeeBreakdown{}, ErrKeyManagerNotConfigured
+	}
+
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return PriorityFeeBreakdown{}, err
+	}
+	genesis, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return PriorityFeeBreakdown{}, err
+	}
+	genesisTime := uint64(genesis.Unix())
+	client, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return PriorityFeeBreakdown{}, err
+	}
+	defer client.Close()
+
+	proposers := map[uint64]map[uint64]string{}
+	feeRecipients := map[beacon.ValidatorPubkey]ethcommon.Address{}
+	for start := fromBlock; ; start += priorityFeeChunkSize {
+		if ctx.Err() != nil {
+			return PriorityFeeBreakdown{}, ctx.Err()
+		}
+		end := toBlock
+		if toBlock-start >= priorityFeeChunkSize {
+			end = start + priorityFeeChunkSize - 1
+		}
+		blocks, err := getFeeBlocks(ctx, client, start, end)
+		if err != nil {
+			return PriorityFeeBreakdown{}, err
+		}
+
+		for _, block := range blocks {
+			// Blocks from before genesis, like the merge's terminal blocks, weren't proposed by anyone
+			if uint64(block.Timestamp) < genesisTime {
+				continue
+			}
+			slot := (uint64(block.Timestamp) - genesisTime) / spec.SecondsPerSlot
+			epoch := slot / spec.SlotsPerEpoch
+			if _, exists := proposers[epoch]; !exists {
+				duties, err := bn.GetProposerDuties(ctx, epoch)
+				if err != nil {
+					return PriorityFeeBreakdown{}, err
+				}
+				proposers[epoch] = map[uint64]string{}
+				for _, duty := range duties {
+					proposers[epoch][uint64(duty.Slot)] = duty.ValidatorIndex
+				}
+			}
+			pubkey, isOurs := pubkeysByIndex[proposers[epoch][slot]]
+			if !isOurs {
+				continue
+			}
+			breakdown.ProposalCount++
+
+			expected, exists := feeRecipients[pubkey]
+			if !exists {
+				expected, err = sp.GetKeyManagerClient().GetFeeRecipient(ctx, pubkey)
+				if err != nil {
+					return PriorityFeeBreakdown{}, fmt.Errorf("error getting the fee recipient for validator %s: %w", pubkey.HexWithPrefix(), err)
+				}
+				feeRecipients[pubkey] = expected
+			}
+			recipient, amount, isMev, err := getProposalFees(ctx, client, block)
+			if err != nil {
+				return PriorityFeeBreakdown{}, err
+			}
+			switch {
+			case recipient != expected:
+				breakdown.Misdirected.Add(breakdown.Misdirected, amount)
+				breakdown.MisdirectedProposals = append(breakdown.MisdirectedProposals, MisdirectedProposal{
+					BlockNumber:          uint64(block.Number),
+					Slot:                 slot,
+					Pubkey:               pubkey,
+					FeeRecipient:         recipient,
+					ExpectedFeeRecipient: expected,
+					Amount:               amount,
+				})
+			case isMev:
+				breakdown.MevRewards.Add(breakdown.MevRewards, amount)
+			default:
+				breakdown.PriorityFees.Add(breakdown.PriorityFees, amount)
+			}
+		}
+		if end == toBlock {
+			return breakdown, nil
+		}
+	}
+}
+
+// Gets the headers of a range of Execution layer blocks in a single batch, in order
+func getFeeBlocks(ctx context.Context, client *rpc.Client, start uint64, end uint64) ([]*feeBlock, error) {
+	blocks := make([]*feeBlock, end-start+1)
+	batch := make([]rpc.BatchElem, len(blocks))
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{hexutil.EncodeUint64(start + uint64(i)), false},
+			Result: &blocks[i],
+		}
+	}
+	err := client.BatchCallContext(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("error getting blocks %d to %d: %w", start, end, err)
+	}
+	for i, elem := range batch {
+		number := start + uint64(i)
+		if elem.Error != nil {
+			return nil, fmt.Errorf("error getting block %d: %w", number, elem.Error)
+		}
+		if blocks[i] == nil {
+			return nil, fmt.Errorf("the Execution Client doesn't have block %d", number)
+		}
+	}
+	return blocks, nil
+}
+
+// Gets where the Execution layer rewards of a block went and how much they were. If the block was built by a builder,
+// this is the builder's payment to the proposer; otherwise, it's the priority fees paid to the block's fee recipient.
+func getProposalFees(ctx context.Context, client *rpc.Client, block *feeBlock) (ethcommon.Address, *big.Int, bool, error) {
+	number := hexutil.EncodeUint64(uint64(block.Number))
+	var transactions struct {
+		Transactions []feeTransaction `json:"transactions"`
+	}
+	err := client.CallContext(ctx, &transactions, "eth_getBlockByNumber", number, true)
+	if err != nil {
+		return ethcommon.Address{}, nil, false, fmt.Errorf("error getting transactions for block %d: %w", block.Number, err)
+	}
+	txs := transactions.Transactions
+	if len(txs) == 0 {
+		return block.Miner, big.NewInt(0), false, nil
+	}
+
+	// Builders pay the proposer with the last transaction in the block
+	last := txs[len(txs)-1]
+	if last.From == block.Miner && last.To != nil && *last.To != block.Miner && last.Value != nil {
+		return *last.To, last.Value.ToInt(), true, nil
+	}
+
+	receipts := make([]*aprReceipt, len(txs))
+	batch := make([]rpc.BatchElem, len(txs))
+	for i, tx := range txs {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []any{tx.Hash},
+			Result: &receipts[i],
+		}
+	}
+	err = client.BatchCallContext(ctx, batch)
+	if err != nil {
+		return ethcommon.Address{}, nil, false, fmt.Errorf("error getting receipts for block %d: %w", block.Number, err)
+	}
+	baseFee := big.NewInt(0)
+	if block.BaseFeePerGas != nil {
+		baseFee = block.BaseFeePerGas.ToInt()
+	}
+	fees := big.NewInt(0)
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return ethcommon.Address{}, nil, false, fmt.Errorf("error getting receipt for transaction %s: %w", txs[i].Hash.Hex(), elem.Error)
+		}
+		receipt := receipts[i]
+		if receipt == nil || receipt.EffectiveGasPrice == nil {
+			return ethcommon.Address{}, nil, false, errors.New("receipt is missing its effective gas price")
+		}
+		tip := new(big.Int).Sub(receipt.EffectiveGasPrice.ToInt(), baseFee)
+		if tip.Sign() <= 0 {
+			continue
+		}
+		tip.Mul(tip, new(big.Int).SetUint64(uint64(receipt.GasUsed)))
+		fees.Add(fees, tip)
+	}
+	return block.Miner, fees, false, nil
+}
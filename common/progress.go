@@ -0,0 +1,12 @@
+package common
+
+// Reports the progress of a long-running operation: current out of total steps are done, and the message describes the
+// latest one. Operations always call it from a single goroutine, in order, so it can render its updates directly.
+type ProgressFunc func(current int, total int, message string)
+
+// Reports progress if a callback was provided
+func (f ProgressFunc) report(current int, total int, message string) {
+	if f != nil {
+		f(current, total, message)
+	}
+}
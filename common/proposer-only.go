@@ -0,0 +1,35 @@
+package common
+
+import (
+	"fmt"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+// Gets whether the Validator Client is set to only propose blocks
+func (sp *ServiceProvider) GetProposerOnlyMode() bool {
+	return sp.cfg.KeyManager.ProposerOnly.Value
+}
+
+// Sets whether the Validator Client only proposes blocks, for setups that leave attestations to a separate Validator
+// Client. Returns ErrUnsupportedByClient if it's being enabled and the Validator Client doesn't have the mode; turning
+// it off always works. Enabling it without an attestation Validator Client configured is allowed, since it may be
+// managed outside of Hyperdrive, but it's logged as a warning because the validators would miss their attestations.
+// If the mode changes, the Validator Client is marked as pending a restart; the config isn't saved to disk.
+func (sp *ServiceProvider) SetProposerOnlyMode(enabled bool) error {
+	client := sp.cfg.GetSelectedBeaconNode()
+	if _, supported := hdconfig.GetProposerOnlyFlag(client); enabled && !supported {
+		return fmt.Errorf("%w: %s doesn't have a proposer-only mode", ErrUnsupportedByClient, client)
+	}
+	if enabled && sp.cfg.KeyManager.AttestationVcUrl.Value == "" {
+		sp.GetApiLogger().Warn("Proposer-only mode is enabled without an attestation Validator Client configured; make sure another one is attesting for these validators")
+	}
+
+	param := &sp.cfg.KeyManager.ProposerOnly
+	if param.Value == enabled {
+		return nil
+	}
+	param.Value = enabled
+	sp.markContainersForRestart(param.AffectsContainers)
+	return nil
+}
@@ -0,0 +1,610 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/prysmaticlabs/prysm/v5/consensus-types/primitives"
+	ethpb "github.com/prysmaticlabs/prysm/v5/proto/prysm/v1alpha1"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	bclient "github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/rocket-pool/node-manager-core/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// The page size used when listing validators over gRPC
+	prysmGrpcValidatorPageSize int32 = 250
+)
+
+var (
+	// Returned by operations that Prysm's gRPC API has no equivalent for
+	ErrPrysmGrpcUnsupported = errors.New("This operation isn't available over Prysm's gRPC API. Set the Prysm API mode to REST to use it.")
+)
+
+// A Beacon API provider that translates the standard Beacon API calls into their equivalents on Prysm's v1alpha1 gRPC API.
+// Wrap it with bclient.NewStandardClient to use it as a Beacon Node client.
+type PrysmGrpcProvider struct {
+	conn      *grpc.ClientConn
+	node      ethpb.NodeClient
+	chain     ethpb.BeaconChainClient
+	validator ethpb.BeaconNodeValidatorClient
+	timeout   time.Duration
+
+	// Prysm's chain config never changes while it's running, so it's only fetched once
+	config     map[string]string
+	configLock *sync.Mutex
+}
+
+// Creates a new Prysm gRPC provider for the target (host:port). The connection is made lazily on the first call.
+func NewPrysmGrpcProvider(target string, timeout time.Duration) (*PrysmGrpcProvider, error) {
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating gRPC connection to Prysm at [%s]: %w", target, err)
+	}
+	return &PrysmGrpcProvider{
+		conn:       conn,
+		node:       ethpb.NewNodeClient(conn),
+		chain:      ethpb.NewBeaconChainClient(conn),
+		validator:  ethpb.NewBeaconNodeValidatorClient(conn),
+		timeout:    timeout,
+		configLock: &sync.Mutex{},
+	}, nil
+}
+
+// Closes the gRPC connection
+func (p *PrysmGrpcProvider) Close() error {
+	return p.conn.Close()
+}
+
+// A Beacon Node client that talks to Prysm over gRPC
+type PrysmGrpcClient struct {
+	*bclient.StandardClient
+	provider *PrysmGrpcProvider
+}
+
+// Creates a new Beacon Node client for Prysm's gRPC API at the target (host:port)
+func NewPrysmGrpcClient(target string, timeout time.Duration) (*PrysmGrpcClient, error) {
+	provider, err := NewPrysmGrpcProvider(target, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &PrysmGrpcClient{
+		StandardClient: bclient.NewStandardClient(provider),
+		provider:       provider,
+	}, nil
+}
+
+// Closes the client's gRPC connection
+func (c *PrysmGrpcClient) Close(ctx context.Context) error {
+	return c.provider.Close()
+}
+
+func (p *PrysmGrpcProvider) Beacon_Attestations(ctx context.Context, blockId string) (bclient.AttestationsResponse, bool, error) {
+	return bclient.AttestationsResponse{}, false, fmt.Errorf("error getting attestations for block %s: %w", blockId, ErrPrysmGrpcUnsupported)
+}
+
+func (p *PrysmGrpcProvider) Beacon_Block(ctx context.Context, blockId string) (bclient.BeaconBlockResponse, bool, error) {
+	return bclient.BeaconBlockResponse{}, false, fmt.Errorf("error getting block %s: %w", blockId, ErrPrysmGrpcUnsupported)
+}
+
+func (p *PrysmGrpcProvider) Beacon_BlsToExecutionChanges_Post(ctx context.Context, request bclient.BLSToExecutionChangeRequest) error {
+	return fmt.Errorf("error submitting BLS to execution change: %w", ErrPrysmGrpcUnsupported)
+}
+
+func (p *PrysmGrpcProvider) Beacon_Committees(ctx context.Context, stateId string, epoch *uint64) (bclient.CommitteesResponse, error) {
+	if stateId != "head" {
+		return bclient.CommitteesResponse{}, fmt.Errorf("error getting committees for state %s: %w", stateId, ErrPrysmGrpcUnsupported)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	request := &ethpb.ListCommitteesRequest{}
+	if epoch != nil {
+		request.QueryFilter = &ethpb.ListCommitteesRequest_Epoch{Epoch: primitives.Epoch(*epoch)}
+	}
+	response, err := p.chain.ListBeaconCommittees(ctx, request)
+	if err != nil {
+		return bclient.CommitteesResponse{}, fmt.Errorf("error getting committees: %w", err)
+	}
+
+	// The committees are keyed by slot, so sort them to match the REST API's order
+	slots := make([]uint64, 0, len(response.Committees))
+	for slot := range response.Committees {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+	committees := bclient.CommitteesResponse{}
+	for _, slot := range slots {
+		for index, item := range response.Committees[slot].Committees {
+			validators := make([]string, len(item.ValidatorIndices))
+			for i, validatorIndex := range item.ValidatorIndices {
+				validators[i] = strconv.FormatUint(uint64(validatorIndex), 10)
+			}
+			committees.Data = append(committees.Data, bclient.Committee{
+				Index:      bclient.Uinteger(index),
+				Slot:       bclient.Uinteger(slot),
+				Validators: validators,
+			})
+		}
+	}
+	return committees, nil
+}
+
+func (p *PrysmGrpcProvider) Beacon_FinalityCheckpoints(ctx context.Context, stateId string) (bclient.FinalityCheckpointsResponse, error) {
+	if stateId != "head" {
+		return bclient.FinalityCheckpointsResponse{}, fmt.Errorf("error getting finality checkpoints for state %s: %w", stateId, ErrPrysmGrpcUnsupported)
+	}
+	head, err := p.getChainHead(ctx)
+	if err != nil {
+		return bclient.FinalityCheckpointsResponse{}, err
+	}
+	var response bclient.FinalityCheckpointsResponse
+	response.Data.PreviousJustified.Epoch = bclient.Uinteger(head.PreviousJustifiedEpoch)
+	response.Data.CurrentJustified.Epoch = bclient.Uinteger(head.JustifiedEpoch)
+	response.Data.Finalized.Epoch = bclient.Uinteger(head.FinalizedEpoch)
+	return response, nil
+}
+
+func (p *PrysmGrpcProvider) Beacon_Genesis(ctx context.Context) (bclient.GenesisResponse, error) {
+	genesis, err := p.getGenesis(ctx)
+	if err != nil {
+		return bclient.GenesisResponse{}, err
+	}
+	config, err := p.getConfig(ctx)
+	if err != nil {
+		return bclient.GenesisResponse{}, err
+	}
+	forkVersion, err := parsePrysmConfigBytes(config, "GenesisForkVersion")
+	if err != nil {
+		return bclient.GenesisResponse{}, err
+	}
+
+	var response bclient.GenesisResponse
+	response.Data.GenesisTime = bclient.Uinteger(genesis.GenesisTime.GetSeconds())
+	response.Data.GenesisForkVersion = forkVersion
+	response.Data.GenesisValidatorsRoot = genesis.GenesisValidatorsRoot
+	return response, nil
+}
+
+func (p *PrysmGrpcProvider) Beacon_Header(ctx context.Context, blockId string) (bclient.BeaconBlockHeaderResponse, bool, error) {
+	return bclient.BeaconBlockHeaderResponse{}, false, fmt.Errorf("error getting header for block %s: %w", blockId, ErrPrysmGrpcUnsupported)
+}
+
+func (p *PrysmGrpcProvider) Beacon_Validators(ctx context.Context, stateId string, ids []string) (bclient.ValidatorsResponse, error) {
+	// Get the validators
+	request := &ethpb.ListValidatorsRequest{
+		PageSize: prysmGrpcValidatorPageSize,
+	}
+	err := p.setValidatorQueryFilter(ctx, request, stateId)
+	if err != nil {
+		return bclient.ValidatorsResponse{}, err
+	}
+	for _, id := range ids {
+		if strings.HasPrefix(id, "0x") {
+			pubkey, err := utils.DecodeHex(id)
+			if err != nil {
+				return bclient.ValidatorsResponse{}, fmt.Errorf("error decoding validator pubkey [%s]: %w", id, err)
+			}
+			request.PublicKeys = append(request.PublicKeys, pubkey)
+			continue
+		}
+		index, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return bclient.ValidatorsResponse{}, fmt.Errorf("invalid validator ID [%s]: %w", id, err)
+		}
+		request.Indices = append(request.Indices, primitives.ValidatorIndex(index))
+	}
+	containers, epoch, err := p.listValidators(ctx, request)
+	if err != nil {
+		return bclient.ValidatorsResponse{}, err
+	}
+	if len(containers) == 0 {
+		return bclient.ValidatorsResponse{Data: []bclient.Validator{}}, nil
+	}
+
+	// Get their balances
+	balanceRequest := &ethpb.ListValidatorBalancesRequest{
+		PageSize: prysmGrpcValidatorPageSize,
+		Indices:  make([]primitives.ValidatorIndex, len(containers)),
+	}
+	for i, container := range containers {
+		balanceRequest.Indices[i] = container.Index
+	}
+	switch filter := request.QueryFilter.(type) {
+	case *ethpb.ListValidatorsRequest_Epoch:
+		balanceRequest.QueryFilter = &ethpb.ListValidatorBalancesRequest_Epoch{Epoch: filter.Epoch}
+	case *ethpb.ListValidatorsRequest_Genesis:
+		balanceRequest.QueryFilter = &ethpb.ListValidatorBalancesRequest_Genesis{Genesis: filter.Genesis}
+	}
+	balances, err := p.listValidatorBalances(ctx, balanceRequest)
+	if err != nil {
+		return bclient.ValidatorsResponse{}, err
+	}
+
+	response := bclient.ValidatorsResponse{
+		Data: make([]bclient.Validator, len(containers)),
+	}
+	for i, container := range containers {
+		v := container.Validator
+		balance := balances[container.Index]
+		validator := bclient.Validator{
+			Index:   strconv.FormatUint(uint64(container.Index), 10),
+			Balance: bclient.Uinteger(balance),
+			Status:  string(getValidatorState(v, balance, uint64(epoch))),
+		}
+		validator.Validator.Pubkey = v.PublicKey
+		validator.Validator.WithdrawalCredentials = v.WithdrawalCredentials
+		validator.Validator.EffectiveBalance = bclient.Uinteger(v.EffectiveBalance)
+		validator.Validator.Slashed = v.Slashed
+		validator.Validator.ActivationEligibilityEpoch = bclient.Uinteger(v.ActivationEligibilityEpoch)
+		validator.Validator.ActivationEpoch = bclient.Uinteger(v.ActivationEpoch)
+		validator.Validator.ExitEpoch = bclient.Uinteger(v.ExitEpoch)
+		validator.Validator.WithdrawableEpoch = bclient.Uinteger(v.WithdrawableEpoch)
+		response.Data[i] = validator
+	}
+	return response, nil
+}
+
+func (p *PrysmGrpcProvider) Beacon_VoluntaryExits_Post(ctx context.Context, request bclient.VoluntaryExitRequest) error {
+	index, err := strconv.ParseUint(request.Message.ValidatorIndex, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid validator index [%s]: %w", request.Message.ValidatorIndex, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	_, err = p.validator.ProposeExit(ctx, &ethpb.SignedVoluntaryExit{
+		Exit: &ethpb.VoluntaryExit{
+			Epoch:          primitives.Epoch(request.Message.Epoch),
+			ValidatorIndex: primitives.ValidatorIndex(index),
+		},
+		Signature: request.Signature,
+	})
+	if err != nil {
+		return fmt.Errorf("error submitting voluntary exit: %w", err)
+	}
+	return nil
+}
+
+func (p *PrysmGrpcProvider) Config_DepositContract(ctx context.Context) (bclient.Eth2DepositContractResponse, error) {
+	config, err := p.getConfig(ctx)
+	if err != nil {
+		return bclient.Eth2DepositContractResponse{}, err
+	}
+	chainID, err := parsePrysmConfigUint(config, "DepositChainID")
+	if err != nil {
+		return bclient.Eth2DepositContractResponse{}, err
+	}
+	address := config["DepositContractAddress"]
+	if !ethcommon.IsHexAddress(address) {
+		return bclient.Eth2DepositContractResponse{}, fmt.Errorf("Prysm's deposit contract address [%s] is invalid", address)
+	}
+
+	var response bclient.Eth2DepositContractResponse
+	response.Data.ChainID = bclient.Uinteger(chainID)
+	response.Data.Address = ethcommon.HexToAddress(address)
+	return response, nil
+}
+
+func (p *PrysmGrpcProvider) Config_Spec(ctx context.Context) (bclient.Eth2ConfigResponse, error) {
+	config, err := p.getConfig(ctx)
+	if err != nil {
+		return bclient.Eth2ConfigResponse{}, err
+	}
+	var response bclient.Eth2ConfigResponse
+	for key, value := range map[string]*bclient.Uinteger{
+		"SecondsPerSlot":               &response.Data.SecondsPerSlot,
+		"SlotsPerEpoch":                &response.Data.SlotsPerEpoch,
+		"EpochsPerSyncCommitteePeriod": &response.Data.EpochsPerSyncCommitteePeriod,
+	} {
+		parsed, err := parsePrysmConfigUint(config, key)
+		if err != nil {
+			return bclient.Eth2ConfigResponse{}, err
+		}
+		*value = bclient.Uinteger(parsed)
+	}
+	response.Data.CapellaForkVersion, err = parsePrysmConfigBytes(config, "CapellaForkVersion")
+	if err != nil {
+		return bclient.Eth2ConfigResponse{}, err
+	}
+	return response, nil
+}
+
+func (p *PrysmGrpcProvider) Node_Syncing(ctx context.Context) (bclient.SyncStatusResponse, error) {
+	var response bclient.SyncStatusResponse
+	callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	status, err := p.node.GetSyncStatus(callCtx, &emptypb.Empty{})
+	if err != nil {
+		return response, fmt.Errorf("error getting sync status: %w", err)
+	}
+	head, err := p.getChainHead(ctx)
+	if err != nil {
+		return response, err
+	}
+	response.Data.IsSyncing = status.Syncing
+	response.Data.HeadSlot = bclient.Uinteger(head.HeadSlot)
+	if !status.Syncing {
+		return response, nil
+	}
+
+	// gRPC doesn't report the sync distance, so work it out from the wall clock slot
+	genesis, err := p.getGenesis(ctx)
+	if err != nil {
+		return response, err
+	}
+	config, err := p.getConfig(ctx)
+	if err != nil {
+		return response, err
+	}
+	secondsPerSlot, err := parsePrysmConfigUint(config, "SecondsPerSlot")
+	if err != nil {
+		return response, err
+	}
+	elapsed := time.Now().Unix() - genesis.GenesisTime.GetSeconds()
+	if elapsed > 0 && secondsPerSlot > 0 {
+		currentSlot := uint64(elapsed) / secondsPerSlot
+		if currentSlot > uint64(head.HeadSlot) {
+			response.Data.SyncDistance = bclient.Uinteger(currentSlot - uint64(head.HeadSlot))
+		}
+	}
+	return response, nil
+}
+
+func (p *PrysmGrpcProvider) Validator_DutiesProposer(ctx context.Context, indices []string, epoch uint64) (bclient.ProposerDutiesResponse, error) {
+	duties, err := p.getDuties(ctx, indices, epoch)
+	if err != nil {
+		return bclient.ProposerDutiesResponse{}, err
+	}
+	response := bclient.ProposerDutiesResponse{
+		Data: []bclient.ProposerDuty{},
+	}
+	for _, duty := range duties {
+		// The REST API returns one duty per proposal slot
+		for range duty.ProposerSlots {
+			response.Data = append(response.Data, bclient.ProposerDuty{
+				ValidatorIndex: strconv.FormatUint(uint64(duty.ValidatorIndex), 10),
+			})
+		}
+	}
+	return response, nil
+}
+
+func (p *PrysmGrpcProvider) Validator_DutiesSync_Post(ctx context.Context, indices []string, epoch uint64) (bclient.SyncDutiesResponse, error) {
+	duties, err := p.getDuties(ctx, indices, epoch)
+	if err != nil {
+		return bclient.SyncDutiesResponse{}, err
+	}
+	response := bclient.SyncDutiesResponse{
+		Data: []bclient.SyncDuty{},
+	}
+	for _, duty := range duties {
+		if duty.IsSyncCommittee {
+			response.Data = append(response.Data, bclient.SyncDuty{
+				Pubkey:         duty.PublicKey,
+				ValidatorIndex: strconv.FormatUint(uint64(duty.ValidatorIndex), 10),
+			})
+		}
+	}
+	return response, nil
+}
+
+// ===============
+// === Helpers ===
+// ===============
+
+// Gets the head of the chain
+func (p *PrysmGrpcProvider) getChainHead(ctx context.Context) (*ethpb.ChainHead, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	head, err := p.chain.GetChainHead(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting chain head: %w", err)
+	}
+	return head, nil
+}
+
+// Gets the genesis info of the chain
+func (p *PrysmGrpcProvider) getGenesis(ctx context.Context) (*ethpb.Genesis, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	genesis, err := p.node.GetGenesis(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting genesis info: %w", err)
+	}
+	return genesis, nil
+}
+
+// Gets Prysm's chain config, which is keyed by the field names of its config struct
+func (p *PrysmGrpcProvider) getConfig(ctx context.Context) (map[string]string, error) {
+	p.configLock.Lock()
+	defer p.configLock.Unlock()
+	if p.config != nil {
+		return p.config, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	config, err := p.chain.GetBeaconConfig(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting beacon config: %w", err)
+	}
+	p.config = config.Config
+	return p.config, nil
+}
+
+// Converts a REST state ID into the query filter for listing validators
+func (p *PrysmGrpcProvider) setValidatorQueryFilter(ctx context.Context, request *ethpb.ListValidatorsRequest, stateId string) error {
+	switch stateId {
+	case "head":
+		// No filter means the current epoch
+		return nil
+	case "genesis":
+		request.QueryFilter = &ethpb.ListValidatorsRequest_Genesis{Genesis: true}
+		return nil
+	case "finalized":
+		head, err := p.getChainHead(ctx)
+		if err != nil {
+			return err
+		}
+		request.QueryFilter = &ethpb.ListValidatorsRequest_Epoch{Epoch: head.FinalizedEpoch}
+		return nil
+	}
+
+	// Everything else has to be a slot; gRPC only works with epochs, so use the epoch the slot is in
+	slot, err := strconv.ParseUint(stateId, 10, 64)
+	if err != nil {
+		return fmt.Errorf("error getting validators for state %s: %w", stateId, ErrPrysmGrpcUnsupported)
+	}
+	config, err := p.getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	slotsPerEpoch, err := parsePrysmConfigUint(config, "SlotsPerEpoch")
+	if err != nil {
+		return err
+	}
+	if slotsPerEpoch == 0 {
+		return fmt.Errorf("Prysm reported 0 slots per epoch")
+	}
+	request.QueryFilter = &ethpb.ListValidatorsRequest_Epoch{Epoch: primitives.Epoch(slot / slotsPerEpoch)}
+	return nil
+}
+
+// Lists every page of validators that match a request, returning the epoch they're from
+func (p *PrysmGrpcProvider) listValidators(ctx context.Context, request *ethpb.ListValidatorsRequest) ([]*ethpb.Validators_ValidatorContainer, primitives.Epoch, error) {
+	containers := []*ethpb.Validators_ValidatorContainer{}
+	var epoch primitives.Epoch
+	for {
+		callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		response, err := p.chain.ListValidators(callCtx, request)
+		cancel()
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting validators: %w", err)
+		}
+		containers = append(containers, response.ValidatorList...)
+		epoch = response.Epoch
+		if response.NextPageToken == "" || len(response.ValidatorList) == 0 {
+			return containers, epoch, nil
+		}
+		request.PageToken = response.NextPageToken
+	}
+}
+
+// Lists every page of validator balances that match a request, keyed by validator index
+func (p *PrysmGrpcProvider) listValidatorBalances(ctx context.Context, request *ethpb.ListValidatorBalancesRequest) (map[primitives.ValidatorIndex]uint64, error) {
+	balances := map[primitives.ValidatorIndex]uint64{}
+	for {
+		callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		response, err := p.chain.ListValidatorBalances(callCtx, request)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("error getting validator balances: %w", err)
+		}
+		for _, balance := range response.Balances {
+			balances[balance.Index] = balance.Balance
+		}
+		if response.NextPageToken == "" || len(response.Balances) == 0 {
+			return balances, nil
+		}
+		request.PageToken = response.NextPageToken
+	}
+}
+
+// Gets the duties of the validators with the provided indices for an epoch. gRPC duties are looked up by pubkey, so the indices are resolved first.
+func (p *PrysmGrpcProvider) getDuties(ctx context.Context, indices []string, epoch uint64) ([]*ethpb.DutiesResponse_Duty, error) {
+	validators, err := p.Beacon_Validators(ctx, "head", indices)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator pubkeys for duties: %w", err)
+	}
+	if len(validators.Data) == 0 {
+		return nil, nil
+	}
+	request := &ethpb.DutiesRequest{
+		Epoch:      primitives.Epoch(epoch),
+		PublicKeys: make([][]byte, len(validators.Data)),
+	}
+	for i, validator := range validators.Data {
+		request.PublicKeys[i] = validator.Validator.Pubkey
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	response, err := p.validator.GetDuties(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator duties: %w", err)
+	}
+	return response.CurrentEpochDuties, nil
+}
+
+// Gets the status of a validator at an epoch, following the same rules as the Beacon REST API
+func getValidatorState(v *ethpb.Validator, balance uint64, epoch uint64) beacon.ValidatorState {
+	activationEpoch := uint64(v.ActivationEpoch)
+	exitEpoch := uint64(v.ExitEpoch)
+	withdrawableEpoch := uint64(v.WithdrawableEpoch)
+	switch {
+	case activationEpoch > epoch:
+		if uint64(v.ActivationEligibilityEpoch) == FarFutureEpoch {
+			return beacon.ValidatorState_PendingInitialized
+		}
+		return beacon.ValidatorState_PendingQueued
+	case epoch < exitEpoch:
+		if exitEpoch == FarFutureEpoch {
+			return beacon.ValidatorState_ActiveOngoing
+		}
+		if v.Slashed {
+			return beacon.ValidatorState_ActiveSlashed
+		}
+		return beacon.ValidatorState_ActiveExiting
+	case epoch < withdrawableEpoch:
+		if v.Slashed {
+			return beacon.ValidatorState_ExitedSlashed
+		}
+		return beacon.ValidatorState_ExitedUnslashed
+	case balance != 0:
+		return beacon.ValidatorState_WithdrawalPossible
+	default:
+		return beacon.ValidatorState_WithdrawalDone
+	}
+}
+
+// Parses an unsigned integer from Prysm's chain config
+func parsePrysmConfigUint(config map[string]string, key string) (uint64, error) {
+	value, exists := config[key]
+	if !exists {
+		return 0, fmt.Errorf("Prysm's beacon config is missing %s", key)
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s [%s] from Prysm's beacon config: %w", key, value, err)
+	}
+	return parsed, nil
+}
+
+// Parses a byte array from Prysm's chain config. Prysm formats these as a list of decimal bytes, such as "[3 0 0 0]".
+func parsePrysmConfigBytes(config map[string]string, key string) ([]byte, error) {
+	value, exists := config[key]
+	if !exists {
+		return nil, fmt.Errorf("Prysm's beacon config is missing %s", key)
+	}
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	fields := strings.Fields(trimmed)
+	bytes := make([]byte, len(fields))
+	for i, field := range fields {
+		parsed, err := strconv.ParseUint(field, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s [%s] from Prysm's beacon config: %w", key, value, err)
+		}
+		bytes[i] = byte(parsed)
+	}
+	return bytes, nil
+}
@@ -0,0 +1,171 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// A way the keystores on disk and the keys loaded into the Validator Client disagree
+type KeystoreDiscrepancyType string
+
+const (
+	// The keystore is on disk but isn't loaded into the Validator Client
+	KeystoreDiscrepancyType_NotLoaded KeystoreDiscrepancyType = "notLoaded"
+
+	// The key is loaded into the Validator Client but doesn't have a keystore on disk
+	KeystoreDiscrepancyType_NotOnDisk KeystoreDiscrepancyType = "notOnDisk"
+
+	// The keystore on disk can't be decrypted with the node password, so it can't be loaded
+	KeystoreDiscrepancyType_Invalid KeystoreDiscrepancyType = "invalid"
+)
+
+// What was done about a keystore discrepancy
+type KeystoreReconcileAction string
+
+const (
+	// Nothing was done; the discrepancy is only reported
+	KeystoreReconcileAction_None KeystoreReconcileAction = "none"
+
+	// The keystore would have been imported, but this was a dry run
+	KeystoreReconcileAction_WouldImport KeystoreReconcileAction = "wouldImport"
+
+	// The keystore was imported into the Validator Client
+	KeystoreReconcileAction_Imported KeystoreReconcileAction = "imported"
+
+	// Importing the keystore into the Validator Client failed
+	KeystoreReconcileAction_ImportFailed KeystoreReconcileAction = "importFailed"
+)
+
+// A single keystore discrepancy and what was done about it
+type KeystoreDiscrepancy struct {
+	Pubkey beacon.ValidatorPubkey  `json:"pubkey"`
+	Path   string                  `json:"path,omitempty"`
+	Type   KeystoreDiscrepancyType `json:"type"`
+	Action KeystoreReconcileAction `json:"action"`
+	Error  string                  `json:"error,omitempty"`
+}
+
+// The result of reconciling the keystores on disk with the keys loaded into the Validator Client
+type ReconcileKeystoreResult struct {
+	// True if nothing was changed
+	DryRun bool `json:"dryRun"`
+
+	// The number of keys that are both on disk and loaded
+	ConsistentCount int `json:"consistentCount"`
+
+	// Every discrepancy, with the keystores on disk first (sorted by path) and then the keys that are only loaded
+	// (sorted by pubkey)
+	Discrepancies []KeystoreDiscrepancy `json:"discrepancies"`
+}
+
+// Compares the keystores in the keystore directory with the keys loaded into the Validator Client via its key manager
+// API. Unless this is a dry run, keystores that aren't loaded are imported, along with the directory's slashing
+// protection data if it has any. Keys that are loaded but don't have a keystore on disk are only reported; they're never
+// removed automatically, since that could take a validator offline. Read-only keys (such as ones from a remote signer)
+// aren't expected on disk, so they're left out.
+func (sp *ServiceProvider) ReconcileKeystores(ctx context.Context, dryRun bool) (ReconcileKeystoreResult, error) {
+	result := ReconcileKeystoreResult{
+		DryRun:        dryRun,
+		Discrepancies: []KeystoreDiscrepancy{},
+	}
+	password, isSet, err := sp.GetWallet().GetPassword()
+	if err != nil {
+		return result, fmt.Errorf("error getting node password: %w", err)
+	}
+	if !isSet {
+		return result, errors.New("the node password has not been set, so the keystores cannot be reconciled")
+	}
+	keystoreDir := sp.cfg.GetKeystoreDirectory()
+	verifyResult, err := VerifyKeystoresInDir(ctx, keystoreDir, password)
+	if err != nil {
+		return result, err
+	}
+	loadedKeystores, err := sp.GetKeyManagerClient().ListKeystores(ctx)
+	if err != nil {
+		return result, err
+	}
+	loaded := map[beacon.ValidatorPubkey]bool{}
+	for _, keystore := range loadedKeystores {
+		if !keystore.ReadOnly {
+			loaded[keystore.Pubkey] = true
+		}
+	}
+
+	// Find the keystores that aren't loaded
+	onDisk := map[beacon.ValidatorPubkey]bool{}
+	missingIndices := map[string]int{}
+	for _, keystore := range verifyResult.Keystores {
+		if !keystore.Success {
+			result.Discrepancies = append(result.Discrepancies, KeystoreDiscrepancy{
+				Pubkey: keystore.Pubkey,
+				Path:   keystore.Path,
+				Type:   KeystoreDiscrepancyType_Invalid,
+				Action: KeystoreReconcileAction_None,
+				Error:  keystore.Error,
+			})
+			continue
+		}
+		if onDisk[keystore.Pubkey] {
+			continue
+		}
+		onDisk[keystore.Pubkey] = true
+		if loaded[keystore.Pubkey] {
+			result.ConsistentCount++
+			continue
+		}
+		action := KeystoreReconcileAction_WouldImport
+		if !dryRun {
+			action = KeystoreReconcileAction_ImportFailed
+		}
+		missingIndices[keystore.Path] = len(result.Discrepancies)
+		result.Discrepancies = append(result.Discrepancies, KeystoreDiscrepancy{
+			Pubkey: keystore.Pubkey,
+			Path:   keystore.Path,
+			Type:   KeystoreDiscrepancyType_NotLoaded,
+			Action: action,
+		})
+	}
+
+	// Import them; keys that are already loaded are skipped, so only the missing ones are sent
+	if !dryRun && len(missingIndices) > 0 {
+		importResult, err := sp.ImportKeystores(ctx, keystoreDir, password, nil)
+		if err != nil {
+			return result, fmt.Errorf("error importing missing keystores: %w", err)
+		}
+		for _, outcome := range importResult.Keystores {
+			index, isMissing := missingIndices[outcome.Path]
+			if !isMissing {
+				continue
+			}
+			discrepancy := &result.Discrepancies[index]
+			if outcome.Status == KeystoreImportStatus_Imported {
+				discrepancy.Action = KeystoreReconcileAction_Imported
+			} else {
+				discrepancy.Error = outcome.Error
+			}
+		}
+	}
+
+	// Report the loaded keys without keystores
+	extraneous := []beacon.ValidatorPubkey{}
+	for pubkey := range loaded {
+		if !onDisk[pubkey] {
+			extraneous = append(extraneous, pubkey)
+		}
+	}
+	sort.Slice(extraneous, func(i, j int) bool {
+		return extraneous[i].Hex() < extraneous[j].Hex()
+	})
+	for _, pubkey := range extraneous {
+		result.Discrepancies = append(result.Discrepancies, KeystoreDiscrepancy{
+			Pubkey: pubkey,
+			Type:   KeystoreDiscrepancyType_NotOnDisk,
+			Action: KeystoreReconcileAction_None,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// Where the Validator Client gets a key's signatures from
+type ValidatorKeySource string
+
+const (
+	// The key's keystore is loaded into the Validator Client
+	ValidatorKeySource_Local ValidatorKeySource = "local"
+
+	// The Validator Client signs for the key through a remote signer
+	ValidatorKeySource_Remote ValidatorKeySource = "remote"
+)
+
+var (
+	// The remote signer URL hasn't been configured
+	ErrRemoteSignerNotConfigured error = errors.New("the remote signer URL has not been configured")
+)
+
+// A key the Validator Client is signing for
+type ValidatorKey struct {
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+	Source ValidatorKeySource     `json:"source"`
+
+	// The remote signer the key is signed through; only set for remote keys the VC reports a signer for
+	SignerUrl string `json:"signerUrl,omitempty"`
+
+	// True if the key can't be removed through the key manager API, such as a remote key from the VC's own config
+	ReadOnly bool `json:"readOnly"`
+}
+
+// Gets the pubkeys the Validator Client signs for through a remote signer
+func (sp *ServiceProvider) ListRemoteKeys(ctx context.Context) ([]beacon.ValidatorPubkey, error) {
+	remoteKeys, err := sp.GetKeyManagerClient().ListRemoteKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pubkeys := make([]beacon.ValidatorPubkey, len(remoteKeys))
+	for i, key := range remoteKeys {
+		pubkeys[i] = key.Pubkey
+	}
+	return pubkeys, nil
+}
+
+// Has the Validator Client sign for the provided keys through the configured remote signer, returning the key manager's
+// status for each one (in the same order). Returns ErrRemoteSignerNotConfigured if there's no remote signer URL.
+func (sp *ServiceProvider) AddRemoteKeys(ctx context.Context, pubkeys []beacon.ValidatorPubkey) ([]KeyManagerStatus, error) {
+	signerUrl := strings.TrimSuffix(sp.cfg.KeyManager.RemoteSignerUrl.Value, "/")
+	if signerUrl == "" {
+		return nil, ErrRemoteSignerNotConfigured
+	}
+	return sp.GetKeyManagerClient().ImportRemoteKeys(ctx, pubkeys, signerUrl)
+}
+
+// Stops the Validator Client from signing for the provided remote keys, returning the key manager's status for each one
+// (in the same order). The keys stay in the remote signer.
+func (sp *ServiceProvider) DeleteRemoteKeys(ctx context.Context, pubkeys []beacon.ValidatorPubkey) ([]KeyManagerStatus, error) {
+	return sp.GetKeyManagerClient().DeleteRemoteKeys(ctx, pubkeys)
+}
+
+// Gets every key the Validator Client is signing for, sorted by pubkey, with whether each one is local or remote.
+// Some clients also list their remote keys as read-only keystores, so those are reported as remote rather than twice.
+func (sp *ServiceProvider) ListValidatorKeys(ctx context.Context) ([]ValidatorKey, error) {
+	keyManager := sp.GetKeyManagerClient()
+	keystores, err := keyManager.ListKeystores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remoteKeys, err := keyManager.ListRemoteKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[beacon.ValidatorPubkey]ValidatorKey{}
+	for _, keystore := range keystores {
+		source := ValidatorKeySource_Local
+		if keystore.ReadOnly {
+			source = ValidatorKeySource_Remote
+		}
+		keys[keystore.Pubkey] = ValidatorKey{
+			Pubkey:   keystore.Pubkey,
+			Source:   source,
+			ReadOnly: keystore.ReadOnly,
+		}
+	}
+	for _, remoteKey := range remoteKeys {
+		keys[remoteKey.Pubkey] = ValidatorKey{
+			Pubkey:    remoteKey.Pubkey,
+			Source:    ValidatorKeySource_Remote,
+			SignerUrl: remoteKey.Url,
+			ReadOnly:  remoteKey.ReadOnly,
+		}
+	}
+
+	result := make([]ValidatorKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Pubkey.Hex() < result[j].Pubkey.Hex()
+	})
+	return result, nil
+}
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// The change in a validator's balance over a reward period, split by what caused it. Amounts are in gwei.
+type ValidatorRewardDelta struct {
+	// The validator's pubkey
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// The validator's index on the Beacon Chain
+	Index string `json:"index"`
+
+	// The validator's balance at the start of the period
+	StartBalance uint64 `json:"startBalance"`
+
+	// The validator's balance at the end of the period
+	EndBalance uint64 `json:"endBalance"`
+
+	// The end balance minus the start balance
+	Delta int64 `json:"delta"`
+
+	// The total withdrawn from the validator during the period
+	Withdrawals uint64 `json:"withdrawals"`
+
+	// What the validator earned during the period once its withdrawals are accounted for, if that's positive
+	Rewards uint64 `json:"rewards"`
+
+	// What the validator lost during the period once its withdrawals are accounted for, if that's positive
+	Penalties uint64 `json:"penalties"`
+}
+
+// The change in the balances of the node's validators between two epoch boundaries. Amounts are in gwei.
+type RewardDelta struct {
+	// The epoch the period starts at; it runs from the state at the epoch's first slot
+	StartEpoch uint64 `json:"startEpoch"`
+
+	// The epoch the period ends at; it runs up to the state at the epoch's first slot
+	EndEpoch uint64 `json:"endEpoch"`
+
+	// The change for each validator, in the order of their indices
+	Validators []ValidatorRewardDelta `json:"validators"`
+
+	// The sum of the validators' balance changes
+	TotalDelta int64 `json:"totalDelta"`
+
+	// The sum of the validators' withdrawals
+	TotalWithdrawals uint64 `json:"totalWithdrawals"`
+
+	// The sum of the validators' rewards
+	TotalRewards uint64 `json:"totalRewards"`
+
+	// The sum of the validators' penalties
+	TotalPenalties uint64 `json:"totalPenalties"`
+}
+
+// Gets the change in the balance of every validator of the enabled modules between the starts of startEpoch and
+// endEpoch. A withdrawal lowers a validator's balance without it having lost anything, so the withdrawals during the
+// period are added back before the change is counted as a reward or a penalty. Each validator's change is net over the
+// period, so a validator has either rewards or penalties but not both; top-up deposits count as rewards. Validators
+// that weren't in the state at the start of the period are left out.
+// Returns an *InsufficientHistoryError if the Beacon Node doesn't have the state at the start of the period.
+func (sp *ServiceProvider) GetRewardPeriodDelta(ctx context.Context, startEpoch uint64, endEpoch uint64) (RewardDelta, error) {
+	if startEpoch >= endEpoch {
+		return RewardDelta{}, fmt.Errorf("start epoch %d must be before end epoch %d", startEpoch, endEpoch)
+	}
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return RewardDelta{}, err
+	}
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return RewardDelta{}, err
+	}
+	if headEpoch := headSlot / spec.SlotsPerEpoch; endEpoch > headEpoch {
+		return RewardDelta{}, fmt.Errorf("end epoch %d is after the head epoch %d", endEpoch, headEpoch)
+	}
+	startSlot := startEpoch * spec.SlotsPerEpoch
+	endSlot := endEpoch * spec.SlotsPerEpoch
+	delta := RewardDelta{
+		StartEpoch: startEpoch,
+		EndEpoch:   endEpoch,
+		Validators: []ValidatorRewardDelta{},
+	}
+
+	// Map the validators' indices to their pubkeys
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return RewardDelta{}, err
+	}
+	if len(pubkeys) == 0 {
+		return delta, nil
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+	validators, err := bn.GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return RewardDelta{}, err
+	}
+	indices := make([]string, 0, len(validators))
+	indexPubkeys := map[string]beacon.ValidatorPubkey{}
+	for _, validator := range validators {
+		indices = append(indices, validator.Index)
+		indexPubkeys[validator.Index] = beacon.ValidatorPubkey(validator.Validator.Pubkey)
+	}
+	if len(indices) == 0 {
+		return delta, nil
+	}
+
+	// Get the balances at each end of the period
+	startBalances, exists, err := bn.GetValidatorBalances(ctx, strconv.FormatUint(startSlot, 10), indices)
+	if err != nil {
+		return RewardDelta{}, err
+	}
+	if !exists {
+		return RewardDelta{}, &InsufficientHistoryError{
+			StartEpoch: startEpoch,
+			Reason:     fmt.Sprintf("the Beacon Node doesn't have the state at slot %d", startSlot),
+		}
+	}
+	endBalances, exists, err := bn.GetValidatorBalances(ctx, strconv.FormatUint(endSlot, 10), indices)
+	if err != nil {
+		return RewardDelta{}, err
+	}
+	if !exists {
+		return RewardDelta{}, fmt.Errorf("the Beacon Node doesn't have the state at slot %d", endSlot)
+	}
+	includedIndices := []uint64{}
+	for _, index := range indices {
+		if _, exists := startBalances[index]; !exists {
+			continue
+		}
+		parsedIndex, err := strconv.ParseUint(index, 10, 64)
+		if err != nil {
+			return RewardDelta{}, fmt.Errorf("error parsing validator index [%s]: %w", index, err)
+		}
+		includedIndices = append(includedIndices, parsedIndex)
+	}
+	if len(includedIndices) == 0 {
+		return delta, nil
+	}
+
+	// Get the withdrawals during the period; the state at the start slot already includes its block, so its
+	// withdrawals are already out of the start balance
+	withdrawals, err := sp.GetWithdrawals(ctx, startSlot+1, endSlot, includedIndices)
+	if err != nil {
+		return RewardDelta{}, fmt.Errorf("error getting withdrawals during the period: %w", err)
+	}
+	withdrawn := map[uint64]uint64{}
+	for _, withdrawal := range withdrawals {
+		withdrawn[withdrawal.ValidatorIndex] += withdrawal.Amount
+	}
+
+	// Split each validator's change
+	slices.Sort(includedIndices)
+	for _, index := range includedIndices {
+		indexString := strconv.FormatUint(index, 10)
+		validatorDelta := ValidatorRewardDelta{
+			Pubkey:       indexPubkeys[indexString],
+			Index:        indexString,
+			StartBalance: startBalances[indexString],
+			EndBalance:   endBalances[indexString],
+			Withdrawals:  withdrawn[index],
+		}
+		validatorDelta.Delta = int64(validatorDelta.EndBalance) - int64(validatorDelta.StartBalance)
+		earned := validatorDelta.Delta + int64(validatorDelta.Withdrawals)
+		if earned > 0 {
+			validatorDelta.Rewards = uint64(earned)
+		} else {
+			validatorDelta.Penalties = uint64(-earned)
+		}
+		delta.Validators = append(delta.Validators, validatorDelta)
+		delta.TotalDelta += validatorDelta.Delta
+		delta.TotalWithdrawals += validatorDelta.Withdrawals
+		delta.TotalRewards += validatorDelta.Rewards
+		delta.TotalPenalties += validatorDelta.Penalties
+	}
+	return delta, nil
+}
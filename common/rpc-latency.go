@@ -0,0 +1,241 @@
+package common
+
+import (
+	"math/bits"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// The length of each slot in the rolling latency window
+	rpcLatencySlotDuration time.Duration = time.Minute
+
+	// The number of slots in the rolling latency window
+	rpcLatencySlotCount int = 10
+
+	// The number of histogram bins; each power of two is split into 4 bins, covering latencies up to ~2 minutes
+	rpcLatencyBinCount int = 108
+
+	// The window reported by the latency stats
+	RpcLatencyWindow time.Duration = rpcLatencySlotDuration * time.Duration(rpcLatencySlotCount)
+)
+
+// The client type an RPC call was made to
+type RpcClientType string
+
+const (
+	RpcClientType_ExecutionClient RpcClientType = "ec"
+	RpcClientType_BeaconNode      RpcClientType = "bn"
+)
+
+// Latency percentiles for a single RPC method over the rolling window
+type LatencySummary struct {
+	Count uint64        `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+}
+
+// Latency percentiles for each RPC method of the Execution Client and Beacon Node, over the last RpcLatencyWindow
+type RpcLatencyStats struct {
+	Window          time.Duration             `json:"window"`
+	ExecutionClient map[string]LatencySummary `json:"executionClient"`
+	BeaconNode      map[string]LatencySummary `json:"beaconNode"`
+}
+
+// Tracks the latency of RPC calls in a rolling window of histograms. Recording is lock-free so it can sit on the hot path of every call;
+// when a slot rolls over, calls that race with its reset may be counted in either window, which is fine for monitoring.
+type RpcLatencyTracker struct {
+	histograms sync.Map // rpcLatencyKey -> *latencyHistogram
+	now        func() time.Time
+}
+
+// The key for a method's histogram
+type rpcLatencyKey struct {
+	client RpcClientType
+	method string
+}
+
+// A rolling window of histograms for a single method
+type latencyHistogram struct {
+	slots [rpcLatencySlotCount]latencySlot
+}
+
+// The histogram for one slot of the window
+type latencySlot struct {
+	// The index of the slot period this histogram covers, in units of rpcLatencySlotDuration since the Unix epoch
+	period atomic.Int64
+	sumUs  atomic.Uint64
+	bins   [rpcLatencyBinCount]atomic.Uint64
+}
+
+// Creates a new RPC latency tracker
+func NewRpcLatencyTracker() *RpcLatencyTracker {
+	return &RpcLatencyTracker{
+		now: time.Now,
+	}
+}
+
+// Records the latency of a single call
+func (t *RpcLatencyTracker) Record(client RpcClientType, method string, latency time.Duration) {
+	key := rpcLatencyKey{client: client, method: method}
+	value, exists := t.histograms.Load(key)
+	if !exists {
+		value, _ = t.histograms.LoadOrStore(key, &latencyHistogram{})
+	}
+	histogram := value.(*latencyHistogram)
+
+	period := t.now().UnixNano() / int64(rpcLatencySlotDuration)
+	slot := &histogram.slots[period%int64(rpcLatencySlotCount)]
+	oldPeriod := slot.period.Load()
+	if oldPeriod != period && slot.period.CompareAndSwap(oldPeriod, period) {
+		// This slot is from an old period, so clear it out
+		slot.sumUs.Store(0)
+		for i := range slot.bins {
+			slot.bins[i].Store(0)
+		}
+	}
+	slot.sumUs.Add(uint64(latency / time.Microsecond))
+	slot.bins[getLatencyBin(latency)].Add(1)
+}
+
+// Records the time since the provided start time; meant to be deferred at the start of a call
+func (t *RpcLatencyTracker) recordSince(client RpcClientType, method string, start time.Time) {
+	t.Record(client, method, time.Since(start))
+}
+
+// Gets the latency percentiles for every method called within the window
+func (t *RpcLatencyTracker) GetStats() RpcLatencyStats {
+	stats := RpcLatencyStats{
+		Window:          RpcLatencyWindow,
+		ExecutionClient: map[string]LatencySummary{},
+		BeaconNode:      map[string]LatencySummary{},
+	}
+	t.forEachWindow(func(key rpcLatencyKey, bins []uint64, count uint64, _ uint64) {
+		summary := LatencySummary{
+			Count: count,
+			P50:   getLatencyPercentile(bins, count, 0.50),
+			P95:   getLatencyPercentile(bins, count, 0.95),
+			P99:   getLatencyPercentile(bins, count, 0.99),
+		}
+		switch key.client {
+		case RpcClientType_ExecutionClient:
+			stats.ExecutionClient[key.method] = summary
+		case RpcClientType_BeaconNode:
+			stats.BeaconNode[key.method] = summary
+		}
+	})
+	return stats
+}
+
+// Runs the callback with the combined histogram of each method that was called within the window
+func (t *RpcLatencyTracker) forEachWindow(callback func(key rpcLatencyKey, bins []uint64, count uint64, sumUs uint64)) {
+	currentPeriod := t.now().UnixNano() / int64(rpcLatencySlotDuration)
+	t.histograms.Range(func(k any, v any) bool {
+		histogram := v.(*latencyHistogram)
+		bins := make([]uint64, rpcLatencyBinCount)
+		count := uint64(0)
+		sumUs := uint64(0)
+		for i := range histogram.slots {
+			slot := &histogram.slots[i]
+			period := slot.period.Load()
+			if period <= currentPeriod-int64(rpcLatencySlotCount) || period > currentPeriod {
+				continue
+			}
+			sumUs += slot.sumUs.Load()
+			for j := range slot.bins {
+				binCount := slot.bins[j].Load()
+				bins[j] += binCount
+				count += binCount
+			}
+		}
+		if count > 0 {
+			callback(k.(rpcLatencyKey), bins, count, sumUs)
+		}
+		return true
+	})
+}
+
+// Gets the histogram bin for a latency. Latencies under 4 microseconds get their own bins; above that, each power of two is split into 4 bins.
+func getLatencyBin(latency time.Duration) int {
+	us := uint64(max(latency, 0) / time.Microsecond)
+	if us < 4 {
+		return int(us)
+	}
+	exponent := bits.Len64(us) - 1
+	subBin := int((us >> (exponent - 2)) & 3)
+	return min(4*(exponent-1)+subBin, rpcLatencyBinCount-1)
+}
+
+// Gets the upper bound of a histogram bin
+func getLatencyBinUpperBound(bin int) time.Duration {
+	if bin < 4 {
+		return time.Duration(bin+1) * time.Microsecond
+	}
+	exponent := bin/4 + 1
+	subBin := bin % 4
+	return time.Duration(uint64(5+subBin)<<(exponent-2)) * time.Microsecond
+}
+
+// Gets a percentile from a histogram, reported as the upper bound of the bin it falls in
+func getLatencyPercentile(bins []uint64, count uint64, percentile float64) time.Duration {
+	target := uint64(float64(count)*percentile + 0.5)
+	target = max(target, 1)
+	seen := uint64(0)
+	for bin, binCount := range bins {
+		seen += binCount
+		if seen >= target {
+			return getLatencyBinUpperBound(bin)
+		}
+	}
+	return getLatencyBinUpperBound(len(bins) - 1)
+}
+
+// ==================
+// === Prometheus ===
+// ==================
+
+var rpcLatencyDesc = prometheus.NewDesc(
+	"hyperdrive_rpc_latency_seconds",
+	"Latency of RPC calls to the Execution Client and Beacon Node over a rolling window",
+	[]string{"client", "method"},
+	nil,
+)
+
+// Describes the latency metrics for the Prometheus registry
+func (t *RpcLatencyTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rpcLatencyDesc
+}
+
+// Collects the latency metrics for the Prometheus registry as a summary per method
+func (t *RpcLatencyTracker) Collect(ch chan<- prometheus.Metric) {
+	type sample struct {
+		key   rpcLatencyKey
+		bins  []uint64
+		count uint64
+		sumUs uint64
+	}
+	samples := []sample{}
+	t.forEachWindow(func(key rpcLatencyKey, bins []uint64, count uint64, sumUs uint64) {
+		samples = append(samples, sample{key: key, bins: bins, count: count, sumUs: sumUs})
+	})
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].key.client != samples[j].key.client {
+			return samples[i].key.client < samples[j].key.client
+		}
+		return samples[i].key.method < samples[j].key.method
+	})
+
+	for _, s := range samples {
+		quantiles := map[float64]float64{}
+		for _, q := range []float64{0.5, 0.95, 0.99} {
+			quantiles[q] = getLatencyPercentile(s.bins, s.count, q).Seconds()
+		}
+		sum := (time.Duration(s.sumUs) * time.Microsecond).Seconds()
+		ch <- prometheus.MustNewConstSummary(rpcLatencyDesc, s.count, sum, quantiles, string(s.key.client), s.key.method)
+	}
+}
@@ -0,0 +1,214 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// A task with the same name is already scheduled
+	ErrTaskAlreadyScheduled error = errors.New("a task with that name is already scheduled")
+
+	// The scheduler has been stopped, so it can't run new tasks
+	ErrSchedulerStopped error = errors.New("the scheduler has been stopped")
+
+	// There isn't a task with the requested name
+	ErrTaskNotFound error = errors.New("there isn't a task with that name")
+)
+
+// The status of a scheduled task
+type ScheduledTaskStatus struct {
+	Name     string        `json:"name"`
+	Interval time.Duration `json:"interval"`
+	Jitter   time.Duration `json:"jitter"`
+
+	// True if the task is running right now
+	Running bool `json:"running"`
+
+	// When the last run started, or the zero time if it hasn't run yet
+	LastRun time.Time `json:"lastRun"`
+
+	// How long the last completed run took
+	LastDuration time.Duration `json:"lastDuration"`
+
+	// When the next run is due to start; while the task is running, this is when the next one would start if the
+	// current one finishes in time
+	NextRun time.Time `json:"nextRun"`
+
+	// The number of runs that have started
+	RunCount uint64 `json:"runCount"`
+
+	// The number of runs that were dropped because an earlier run was still going when they were due
+	SkippedRuns uint64 `json:"skippedRuns"`
+}
+
+// Runs periodic tasks for the daemon and its modules. Each run is delayed by a random amount up to the task's jitter, so
+// tasks with the same interval don't all hit the clients at once. A task never runs concurrently with itself: if a run
+// is still going when the next one is due, that run is dropped and the task picks up at the next interval after it
+// finishes.
+type Scheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	tasks  map[string]*scheduledTask
+	lock   *sync.Mutex
+	wg     *sync.WaitGroup
+}
+
+// A single task and its bookkeeping, which is guarded by the scheduler's lock
+type scheduledTask struct {
+	fn     func(ctx context.Context)
+	status ScheduledTaskStatus
+
+	// Wakes the task up when its next run has been moved
+	reset chan struct{}
+}
+
+// Creates a new scheduler. Cancelling the context stops every task, the same as calling Stop.
+func NewScheduler(ctx context.Context) *Scheduler {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		tasks:  map[string]*scheduledTask{},
+		lock:   &sync.Mutex{},
+		wg:     &sync.WaitGroup{},
+	}
+}
+
+// Starts running a task every interval. The first run starts within the jitter of the call, and each later one within
+// the jitter of its interval. The function's context is cancelled when the scheduler stops.
+func (s *Scheduler) Schedule(name string, interval time.Duration, jitter time.Duration, fn func(ctx context.Context)) error {
+	if interval <= 0 {
+		return fmt.Errorf("task [%s] has an interval of %s, but it must be positive", name, interval)
+	}
+	if jitter < 0 {
+		return fmt.Errorf("task [%s] has a jitter of %s, but it can't be negative", name, jitter)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ctx.Err() != nil {
+		return ErrSchedulerStopped
+	}
+	if _, exists := s.tasks[name]; exists {
+		return fmt.Errorf("%w: %s", ErrTaskAlreadyScheduled, name)
+	}
+	task := &scheduledTask{
+		fn:    fn,
+		reset: make(chan struct{}, 1),
+		status: ScheduledTaskStatus{
+			Name:     name,
+			Interval: interval,
+			Jitter:   jitter,
+			NextRun:  time.Now().Add(getJitter(jitter)),
+		},
+	}
+	s.tasks[name] = task
+	s.wg.Add(1)
+	go s.runTask(task)
+	return nil
+}
+
+// Changes how often a task runs. The next run is moved to one interval after the last one started, or right away if
+// that's already passed; a run in progress isn't interrupted, and the one after it follows the new interval. A task
+// that hasn't run yet still starts its first run when it was going to.
+func (s *Scheduler) SetInterval(name string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("task [%s] can't have an interval of %s, it must be positive", name, interval)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	task, exists := s.tasks[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, name)
+	}
+	task.status.Interval = interval
+	if task.status.Running || task.status.LastRun.IsZero() {
+		return nil
+	}
+	task.status.NextRun = task.status.LastRun.Add(interval)
+	if now := time.Now(); task.status.NextRun.Before(now) {
+		task.status.NextRun = now
+	}
+	select {
+	case task.reset <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Gets the status of every scheduled task, sorted by name
+func (s *Scheduler) GetTaskStatuses() []ScheduledTaskStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	statuses := make([]ScheduledTaskStatus, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		statuses = append(statuses, task.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Stops every task and waits for the runs in progress to return
+func (s *Scheduler) Stop() {
+	// Cancel under the lock so no new task can be added once Wait starts
+	s.lock.Lock()
+	s.cancel()
+	s.lock.Unlock()
+	s.wg.Wait()
+}
+
+// Runs a task until the scheduler stops
+func (s *Scheduler) runTask(task *scheduledTask) {
+	defer s.wg.Done()
+	for {
+		s.lock.Lock()
+		nextRun := task.status.NextRun
+		s.lock.Unlock()
+		timer := time.NewTimer(time.Until(nextRun))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-task.reset:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		// Run it
+		start := time.Now()
+		s.lock.Lock()
+		task.status.Running = true
+		task.status.LastRun = start
+		task.status.RunCount++
+		task.status.NextRun = start.Add(task.status.Interval)
+		s.lock.Unlock()
+		task.fn(s.ctx)
+		finish := time.Now()
+
+		// Drop the runs that were due while this one was going
+		s.lock.Lock()
+		task.status.Running = false
+		task.status.LastDuration = finish.Sub(start)
+		missed := finish.Sub(start) / task.status.Interval
+		task.status.SkippedRuns += uint64(missed)
+		task.status.NextRun = start.Add((missed + 1) * task.status.Interval).Add(getJitter(task.status.Jitter))
+		s.lock.Unlock()
+	}
+}
+
+// Gets a random delay up to the provided jitter
+func getJitter(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(jitter)))
+}
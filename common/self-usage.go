@@ -0,0 +1,106 @@
+package common
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// The directory listing the daemon's open file descriptors, on platforms that have /proc
+	selfFdDir string = "/proc/self/fd"
+)
+
+// The daemon process's own resource usage
+type SelfUsage struct {
+	// The number of running goroutines
+	Goroutines int `json:"goroutines"`
+
+	// The bytes of allocated heap objects
+	HeapAllocBytes uint64 `json:"heapAllocBytes"`
+
+	// True if the platform reports open file descriptors; OpenFileDescriptors is only set if this is
+	HasOpenFileDescriptors bool `json:"hasOpenFileDescriptors"`
+
+	// The number of open file descriptors
+	OpenFileDescriptors int `json:"openFileDescriptors"`
+
+	// How long the daemon has been running
+	Uptime time.Duration `json:"uptime"`
+}
+
+// Gets the daemon's own resource usage from the Go runtime and, where available, /proc
+func (sp *ServiceProvider) GetSelfResourceUsage() SelfUsage {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	usage := SelfUsage{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: memStats.HeapAlloc,
+		Uptime:         time.Since(sp.startTime),
+	}
+
+	// Platforms without /proc just don't report descriptors
+	entries, err := os.ReadDir(selfFdDir)
+	if err == nil {
+		usage.HasOpenFileDescriptors = true
+		usage.OpenFileDescriptors = len(entries)
+	}
+	return usage
+}
+
+// ==================
+// === Prometheus ===
+// ==================
+
+var (
+	selfGoroutinesDesc = prometheus.NewDesc(
+		"hyperdrive_goroutines",
+		"Number of goroutines running in the daemon",
+		nil,
+		nil,
+	)
+	selfHeapAllocDesc = prometheus.NewDesc(
+		"hyperdrive_heap_alloc_bytes",
+		"Bytes of allocated heap objects in the daemon",
+		nil,
+		nil,
+	)
+	selfOpenFdsDesc = prometheus.NewDesc(
+		"hyperdrive_open_fds",
+		"Number of file descriptors the daemon has open",
+		nil,
+		nil,
+	)
+	selfUptimeDesc = prometheus.NewDesc(
+		"hyperdrive_uptime_seconds",
+		"How long the daemon has been running",
+		nil,
+		nil,
+	)
+)
+
+// Exports the daemon's resource usage to the Prometheus registry
+type selfUsageCollector struct {
+	sp *ServiceProvider
+}
+
+// Describes the resource usage metrics for the Prometheus registry
+func (c *selfUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- selfGoroutinesDesc
+	ch <- selfHeapAllocDesc
+	ch <- selfOpenFdsDesc
+	ch <- selfUptimeDesc
+}
+
+// Collects the resource usage metrics for the Prometheus registry, leaving out the descriptor count if it isn't available
+func (c *selfUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	usage := c.sp.GetSelfResourceUsage()
+	ch <- prometheus.MustNewConstMetric(selfGoroutinesDesc, prometheus.GaugeValue, float64(usage.Goroutines))
+	ch <- prometheus.MustNewConstMetric(selfHeapAllocDesc, prometheus.GaugeValue, float64(usage.HeapAllocBytes))
+	if usage.HasOpenFileDescriptors {
+		ch <- prometheus.MustNewConstMetric(selfOpenFdsDesc, prometheus.GaugeValue, float64(usage.OpenFileDescriptors))
+	}
+	ch <- prometheus.MustNewConstMetric(selfUptimeDesc, prometheus.CounterValue, usage.Uptime.Seconds())
+}
@@ -1,13 +1,25 @@
 package common
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/docker/docker/client"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
 	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	bclient "github.com/rocket-pool/node-manager-core/beacon/client"
 	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/rocket-pool/node-manager-core/eth"
+	"github.com/rocket-pool/node-manager-core/log"
 	"github.com/rocket-pool/node-manager-core/node/services"
 )
 
@@ -16,10 +28,102 @@ type ServiceProvider struct {
 	*services.ServiceProvider
 
 	// Services
-	cfg *hdconfig.HyperdriveConfig
+	cfg           *hdconfig.HyperdriveConfig
+	nodesetClient *NodeSetClient
+	bnApiClient   *BeaconApiClient
+	keyManager    *KeyManagerClient
+	txSigner      Signer
+
+	// Execution and Beacon Node clients this provider created, which need to be closed with it
+	ownedExecutionClients []*ethclient.Client
+	ownedBeaconClients    []beacon.IBeaconClient
+
+	// Metrics
+	rpcLatencyTracker *RpcLatencyTracker
+	metricsRegistry   *prometheus.Registry
+
+	// Module integrations
+	stakeContributors []StakeContributor
+	statusReporters   []StatusReporter
+	actionProviders   []ActionProvider
+	criticalContracts []moduleContracts
+	feeRecipientRules []FeeRecipientRule
+
+	// The resource addresses modules resolved for the current network, and the ones overridden at runtime, keyed by
+	// module and then resource key
+	moduleResources         map[string]map[string]ethcommon.Address
+	moduleResourceOverrides map[string]map[string]ethcommon.Address
+
+	// Cached chain info
+	beaconSpec     *BeaconSpec
+	depositDomains map[config.Network]types.Domain
+
+	// The indices of validators that have been looked up, which never change once assigned
+	validatorIndices map[beacon.ValidatorPubkey]uint64
+
+	// True once the client connections have been warmed up
+	clientsWarmedUp bool
+
+	// Recent measurements of the Execution Client's sync progress
+	executionSyncSamples []executionSyncSample
+
+	// Whether the Execution Client is an archive node, once it's been detected
+	executionArchiveMode *bool
+
+	// Recent measurements of how far each client is from the head, and the last sync health computed from them
+	executionSyncDistanceSamples []syncDistanceSample
+	beaconSyncDistanceSamples    []syncDistanceSample
+	lastSyncHealth               *SyncHealth
+
+	// Containers whose settings were changed at runtime, which need to be recreated for the changes to take effect
+	pendingRestarts map[config.ContainerID]bool
+
+	// Runs the periodic tasks of the daemon and its modules
+	scheduler *Scheduler
+
+	// Delivers alerts to the operator
+	alertManager *AlertManager
+
+	// The level the API and tasks loggers log at, which can be changed by reloading the config
+	logLevel *slog.LevelVar
+
+	// The scheduled tasks whose intervals come from the config
+	configTasks []configTask
+
+	// The fee recipient violations that have already been alerted on, with the fee recipient each validator was using
+	alertedFeeRecipients map[beacon.ValidatorPubkey]ethcommon.Address
+
+	// The handlers for the steps of resumable operations, keyed by name
+	stepHandlers map[string]StepHandler
+
+	// Synchronization
+	slashingProtectionLock *sync.Mutex
+	stakeContributorLock   *sync.Mutex
+	statusReporterLock     *sync.Mutex
+	actionProviderLock     *sync.Mutex
+	criticalContractLock   *sync.Mutex
+	feeRecipientRuleLock   *sync.Mutex
+	feeRecipientAlertLock  *sync.Mutex
+	moduleResourceLock     *sync.Mutex
+	beaconSpecLock         *sync.Mutex
+	depositDomainLock      *sync.Mutex
+	warmUpLock             *sync.Mutex
+	validatorIndexLock     *sync.Mutex
+	executionSyncLock      *sync.Mutex
+	syncHealthLock         *sync.Mutex
+	archiveModeLock        *sync.Mutex
+	pendingRestartLock     *sync.Mutex
+	operationLock          *sync.Mutex
+	configHistoryLock      *sync.Mutex
+	configTaskLock         *sync.Mutex
+	configReloadLock       *sync.Mutex
+	containerOpSemaphore   chan struct{}
 
 	// Path info
 	userDir string
+
+	// When the provider was created, for the daemon's uptime
+	startTime time.Time
 }
 
 // Creates a new ServiceProvider instance by loading the Hyperdrive config in the provided directory
@@ -39,34 +143,241 @@ func NewServiceProvider(userDir string) (*ServiceProvider, error) {
 
 // Creates a new ServiceProvider instance directly from a Hyperdrive config instead of loading it from the filesystem
 func NewServiceProviderFromConfig(cfg *hdconfig.HyperdriveConfig) (*ServiceProvider, error) {
-	// Core provider
-	sp, err := services.NewServiceProvider(cfg, hdconfig.ClientTimeout)
+	return NewServiceProviderFromConfigWithSigners(cfg, nil)
+}
+
+// Creates a new ServiceProvider instance from a Hyperdrive config, with custom transaction signers (such as hardware
+// wallets) keyed by the name they're selected with in the config. The node wallet's keystore is always available as
+// the local signer.
+func NewServiceProviderFromConfigWithSigners(cfg *hdconfig.HyperdriveConfig, signers map[string]Signer) (*ServiceProvider, error) {
+	resources := cfg.GetNetworkResources()
+	latencyTracker := NewRpcLatencyTracker()
+
+	// EC Manager
+	var ecManager *services.ExecutionClientManager
+	primaryEcUrl, fallbackEcUrl := cfg.GetExecutionClientUrls()
+	// Either endpoint can be the path of an IPC socket instead of a URL
+	primaryEcClient, err := DialExecutionClient(context.Background(), primaryEcUrl)
 	if err != nil {
-		return nil, fmt.Errorf("error creating core service provider: %w", err)
+		return nil, fmt.Errorf("error connecting to primary EC: %w", err)
+	}
+	ownedExecutionClients := []*ethclient.Client{primaryEcClient}
+	var primaryEc eth.IExecutionClient = primaryEcClient
+	primaryEc = NewLatencyTrackingExecutionClient(primaryEc, latencyTracker)
+	if fallbackEcUrl != "" {
+		fallbackEc, err := DialExecutionClient(context.Background(), fallbackEcUrl)
+		if err != nil {
+			closeExecutionClients(ownedExecutionClients)
+			return nil, fmt.Errorf("error connecting to fallback EC: %w", err)
+		}
+		ownedExecutionClients = append(ownedExecutionClients, fallbackEc)
+		ecManager = services.NewExecutionClientManagerWithFallback(primaryEc, NewLatencyTrackingExecutionClient(fallbackEc, latencyTracker), resources.ChainID, hdconfig.ClientTimeout)
+	} else {
+		ecManager = services.NewExecutionClientManager(primaryEc, resources.ChainID, hdconfig.ClientTimeout)
 	}
 
-	// Create the provider
-	provider := &ServiceProvider{
-		ServiceProvider: sp,
-		userDir:         cfg.GetUserDirectory(),
-		cfg:             cfg,
+	// Beacon manager
+	var bnManager *services.BeaconClientManager
+	primaryBn, fallbackBn, err := createBeaconClients(cfg)
+	if err != nil {
+		closeExecutionClients(ownedExecutionClients)
+		return nil, err
+	}
+	ownedBeaconClients := []beacon.IBeaconClient{primaryBn}
+	if fallbackBn != nil {
+		ownedBeaconClients = append(ownedBeaconClients, fallbackBn)
+		bnManager = services.NewBeaconClientManagerWithFallback(NewLatencyTrackingBeaconClient(primaryBn, latencyTracker), NewLatencyTrackingBeaconClient(fallbackBn, latencyTracker), resources.ChainID, hdconfig.ClientTimeout)
+	} else {
+		bnManager = services.NewBeaconClientManager(NewLatencyTrackingBeaconClient(primaryBn, latencyTracker), resources.ChainID, hdconfig.ClientTimeout)
+	}
+
+	// Docker client
+	docker, err := client.NewClientWithOpts(client.WithVersion(services.DockerApiVersion))
+	if err != nil {
+		closeExecutionClients(ownedExecutionClients)
+		closeBeaconClients(ownedBeaconClients)
+		return nil, fmt.Errorf("error creating Docker client: %w", err)
+	}
+
+	sp, err := newServiceProviderFromCustomServicesImpl(cfg, resources, ecManager, bnManager, nil, docker, latencyTracker, signers)
+	if err != nil {
+		closeExecutionClients(ownedExecutionClients)
+		closeBeaconClients(ownedBeaconClients)
+		return nil, err
+	}
+	sp.ownedExecutionClients = ownedExecutionClients
+	sp.ownedBeaconClients = ownedBeaconClients
+	return sp, nil
+}
+
+// Stops the provider's scheduled tasks, then closes its loggers and the Execution and Beacon Node clients it created
+func (sp *ServiceProvider) Close() {
+	sp.scheduler.Stop()
+	closeExecutionClients(sp.ownedExecutionClients)
+	closeBeaconClients(sp.ownedBeaconClients)
+	sp.ServiceProvider.Close()
+}
+
+// Closes a set of Execution Client connections
+func closeExecutionClients(clients []*ethclient.Client) {
+	for _, ec := range clients {
+		ec.Close()
+	}
+}
+
+// Closes a set of Beacon Node clients, ignoring errors since there's nothing left to do with them
+func closeBeaconClients(clients []beacon.IBeaconClient) {
+	for _, bc := range clients {
+		_ = bc.Close(context.Background())
 	}
-	return provider, nil
 }
 
-// Creates a new ServiceProvider instance from custom services and artifacts
+// Creates the primary and fallback Beacon Node clients. The fallback is nil if fallback clients are disabled.
+// Prysm nodes are accessed over gRPC instead of REST if the Prysm API mode calls for it.
+func createBeaconClients(cfg *hdconfig.HyperdriveConfig) (beacon.IBeaconClient, beacon.IBeaconClient, error) {
+	primaryBnUrl, fallbackBnUrl := cfg.GetBeaconNodeUrls()
+	if !cfg.UsePrysmGrpc() {
+		primaryBn := bclient.NewStandardHttpClient(primaryBnUrl, hdconfig.ClientTimeout)
+		if fallbackBnUrl == "" {
+			return primaryBn, nil, nil
+		}
+		return primaryBn, bclient.NewStandardHttpClient(fallbackBnUrl, hdconfig.ClientTimeout), nil
+	}
+
+	primaryTarget, fallbackTarget, err := cfg.GetPrysmGrpcTargets()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting Prysm gRPC endpoints: %w", err)
+	}
+	primaryBn, err := NewPrysmGrpcClient(primaryTarget, hdconfig.ClientTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error connecting to primary BN: %w", err)
+	}
+	switch {
+	case fallbackTarget != "":
+		fallbackBn, err := NewPrysmGrpcClient(fallbackTarget, hdconfig.ClientTimeout)
+		if err != nil {
+			_ = primaryBn.Close(context.Background())
+			return nil, nil, fmt.Errorf("error connecting to fallback BN: %w", err)
+		}
+		return primaryBn, fallbackBn, nil
+	case fallbackBnUrl != "":
+		// The fallback doesn't have a gRPC endpoint, so stick with REST for it
+		return primaryBn, bclient.NewStandardHttpClient(fallbackBnUrl, hdconfig.ClientTimeout), nil
+	default:
+		return primaryBn, nil, nil
+	}
+}
+
+// Creates a new ServiceProvider instance from custom services and artifacts.
+// RPC latency is only tracked for the clients if they were wrapped with the latency tracking clients.
 func NewServiceProviderFromCustomServices(cfg *hdconfig.HyperdriveConfig, resources *config.NetworkResources, ecManager *services.ExecutionClientManager, bnManager *services.BeaconClientManager, docker client.APIClient) (*ServiceProvider, error) {
+	return newServiceProviderFromCustomServicesImpl(cfg, resources, ecManager, bnManager, nil, docker, NewRpcLatencyTracker(), nil)
+}
+
+// Creates a new ServiceProvider instance from custom services and artifacts, including the client for the Beacon API routes
+// that the core Beacon client doesn't cover. Useful when the Beacon Node isn't at the URL in the config.
+func NewServiceProviderFromCustomServicesWithBeaconApi(cfg *hdconfig.HyperdriveConfig, resources *config.NetworkResources, ecManager *services.ExecutionClientManager, bnManager *services.BeaconClientManager, bnApiClient *BeaconApiClient, docker client.APIClient) (*ServiceProvider, error) {
+	return newServiceProviderFromCustomServicesImpl(cfg, resources, ecManager, bnManager, bnApiClient, docker, NewRpcLatencyTracker(), nil)
+}
+
+// Creates a new ServiceProvider instance from custom services, an RPC latency tracker, and custom transaction signers.
+// If the Beacon API client is nil, one is created for the Beacon Node URLs in the config.
+func newServiceProviderFromCustomServicesImpl(cfg *hdconfig.HyperdriveConfig, resources *config.NetworkResources, ecManager *services.ExecutionClientManager, bnManager *services.BeaconClientManager, bnApiClient *BeaconApiClient, docker client.APIClient, latencyTracker *RpcLatencyTracker, signers map[string]Signer) (*ServiceProvider, error) {
 	// Core provider
 	sp, err := services.NewServiceProviderWithCustomServices(cfg, resources, ecManager, bnManager, docker)
 	if err != nil {
 		return nil, fmt.Errorf("error creating core service provider: %w", err)
 	}
+	txSigner, err := selectTxSigner(cfg, sp.GetWallet(), signers)
+	if err != nil {
+		sp.Close()
+		return nil, err
+	}
 
-	// Create the provider
+	// Metrics
+	metricsRegistry := prometheus.NewRegistry()
+	err = metricsRegistry.Register(latencyTracker)
+	if err != nil {
+		sp.Close()
+		return nil, fmt.Errorf("error registering RPC latency metrics: %w", err)
+	}
+
+	if bnApiClient == nil {
+		primaryBnUrl, fallbackBnUrl := cfg.GetBeaconNodeUrls()
+		bnApiClient = NewLatencyTrackingBeaconApiClient(primaryBnUrl, fallbackBnUrl, hdconfig.ClientTimeout, latencyTracker)
+	}
 	provider := &ServiceProvider{
-		ServiceProvider: sp,
-		userDir:         cfg.GetUserDirectory(),
-		cfg:             cfg,
+		ServiceProvider:   sp,
+		userDir:           cfg.GetUserDirectory(),
+		cfg:               cfg,
+		nodesetClient:     NewNodeSetClient(cfg.NodeSetApiUrl.Value, sp.GetWallet(), hdconfig.ClientTimeout),
+		bnApiClient:       bnApiClient,
+		keyManager:        NewKeyManagerClient(cfg.KeyManager.Url.Value, cfg.KeyManager.TokenPath.Value, hdconfig.ClientTimeout),
+		txSigner:          txSigner,
+		rpcLatencyTracker: latencyTracker,
+		metricsRegistry:   metricsRegistry,
+
+		stakeContributors: []StakeContributor{},
+		statusReporters:   []StatusReporter{},
+		actionProviders:   []ActionProvider{},
+		criticalContracts: []moduleContracts{},
+		feeRecipientRules: []FeeRecipientRule{},
+		moduleResources:   map[string]map[string]ethcommon.Address{},
+		pendingRestarts:   map[config.ContainerID]bool{},
+		depositDomains:    map[config.Network]types.Domain{},
+		validatorIndices:  map[beacon.ValidatorPubkey]uint64{},
+		scheduler:         NewScheduler(context.Background()),
+		alertManager:      NewAlertManager(cfg.Alerts),
+		logLevel:          &slog.LevelVar{},
+		configTasks:       []configTask{},
+		stepHandlers:      map[string]StepHandler{},
+
+		alertedFeeRecipients: map[beacon.ValidatorPubkey]ethcommon.Address{},
+
+		slashingProtectionLock: &sync.Mutex{},
+		stakeContributorLock:   &sync.Mutex{},
+		statusReporterLock:     &sync.Mutex{},
+		actionProviderLock:     &sync.Mutex{},
+		criticalContractLock:   &sync.Mutex{},
+		feeRecipientRuleLock:   &sync.Mutex{},
+		feeRecipientAlertLock:  &sync.Mutex{},
+		moduleResourceLock:     &sync.Mutex{},
+		beaconSpecLock:         &sync.Mutex{},
+		depositDomainLock:      &sync.Mutex{},
+		warmUpLock:             &sync.Mutex{},
+		validatorIndexLock:     &sync.Mutex{},
+		executionSyncLock:      &sync.Mutex{},
+		syncHealthLock:         &sync.Mutex{},
+		archiveModeLock:        &sync.Mutex{},
+		pendingRestartLock:     &sync.Mutex{},
+		operationLock:          &sync.Mutex{},
+		configHistoryLock:      &sync.Mutex{},
+		configTaskLock:         &sync.Mutex{},
+		configReloadLock:       &sync.Mutex{},
+		containerOpSemaphore:   make(chan struct{}, cfg.GetMaxConcurrentContainerOps()),
+		startTime:              time.Now(),
+	}
+	provider.logLevel.Set(cfg.Logging.Level.Value)
+	for _, logger := range []*log.Logger{sp.GetApiLogger(), sp.GetTasksLogger()} {
+		logger.Logger = slog.New(&levelHandler{
+			Handler: logger.Handler(),
+			level:   provider.logLevel,
+		})
+	}
+	err = metricsRegistry.Register(&selfUsageCollector{sp: provider})
+	if err != nil {
+		sp.Close()
+		return nil, fmt.Errorf("error registering resource usage metrics: %w", err)
+	}
+	err = metricsRegistry.Register(&syncHealthCollector{sp: provider})
+	if err != nil {
+		sp.Close()
+		return nil, fmt.Errorf("error registering sync health metrics: %w", err)
+	}
+	provider.moduleResourceOverrides, err = provider.loadModuleResourceOverrides()
+	if err != nil {
+		sp.Close()
+		return nil, err
 	}
 	return provider, nil
 }
@@ -83,6 +394,38 @@ func (p *ServiceProvider) GetConfig() *hdconfig.HyperdriveConfig {
 	return p.cfg
 }
 
+func (p *ServiceProvider) GetNodeSetClient() *NodeSetClient {
+	return p.nodesetClient
+}
+
+func (p *ServiceProvider) GetBeaconApiClient() *BeaconApiClient {
+	return p.bnApiClient
+}
+
+func (p *ServiceProvider) GetKeyManagerClient() *KeyManagerClient {
+	return p.keyManager
+}
+
+// Gets the registry of the daemon's own metrics, which the API server serves at HyperdriveMetricsPath
+func (p *ServiceProvider) GetMetricsRegistry() *prometheus.Registry {
+	return p.metricsRegistry
+}
+
+// Gets the scheduler for periodic tasks, which is stopped when the provider is closed
+func (p *ServiceProvider) GetScheduler() *Scheduler {
+	return p.scheduler
+}
+
+// Gets the manager that delivers alerts to the sinks selected for each severity
+func (p *ServiceProvider) GetAlertManager() *AlertManager {
+	return p.alertManager
+}
+
+// Gets the latency percentiles of each Execution Client and Beacon Node RPC method over the rolling window
+func (p *ServiceProvider) GetRpcLatencyStats() RpcLatencyStats {
+	return p.rpcLatencyTracker.GetStats()
+}
+
 // =============
 // === Utils ===
 // =============
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+var (
+	// The network's fork schedule isn't known ahead of time, so exits for it can't be signed offline
+	ErrNoOfflineForkSchedule error = errors.New("the network's fork schedule isn't known, so exits can't be signed offline")
+
+	// None of the keystores on disk have the validator's key
+	ErrValidatorKeyNotFound error = errors.New("the validator's keystore wasn't found")
+)
+
+// A signed voluntary exit, in the format the Beacon API accepts for submission
+type SignedVoluntaryExit struct {
+	Message   client.VoluntaryExitMessage `json:"message"`
+	Signature client.ByteArray            `json:"signature"`
+}
+
+// Gets the domain for signing voluntary exits that take effect at the provided epoch on the configured network, without
+// a Beacon Node. Exits normally use the fork that's current at their epoch, but from Deneb onwards they're locked to the
+// Capella fork (EIP-7044) so pre-signed exits stay valid across later forks.
+func (sp *ServiceProvider) GetVoluntaryExitDomain(epoch uint64) (types.Domain, error) {
+	network := sp.cfg.Network.Value
+	schedule, exists := hdconfig.GetForkSchedule(network)
+	if !exists {
+		return types.Domain{}, fmt.Errorf("%w (network [%s])", ErrNoOfflineForkSchedule, network)
+	}
+	fork := schedule.GetForkAt(epoch)
+	deneb, hasDeneb := schedule.GetFork(hdconfig.ForkName_Deneb)
+	if hasDeneb && epoch >= deneb.Epoch {
+		fork, _ = schedule.GetFork(hdconfig.ForkName_Capella)
+	}
+
+	rawDomain, err := eth2types.ComputeDomain(eth2types.DomainVoluntaryExit, fork.Version, schedule.GenesisValidatorsRoot)
+	if err != nil {
+		return types.Domain{}, fmt.Errorf("error computing voluntary exit domain for epoch %d: %w", epoch, err)
+	}
+	var domain types.Domain
+	copy(domain[:], rawDomain)
+	return domain, nil
+}
+
+// Signs a voluntary exit for a validator without submitting it, so it can be stored and submitted later (e.g. from cold
+// storage). The key comes from the validator's keystore on disk and the domain from the network's known fork schedule,
+// so this works without a Beacon Node; the caller provides the validator's index for the same reason.
+// The exit can't be processed before the provided epoch.
+func (sp *ServiceProvider) GenerateSignedExit(pubkey beacon.ValidatorPubkey, epoch uint64, validatorIndex uint64) (SignedVoluntaryExit, error) {
+	domain, err := sp.GetVoluntaryExitDomain(epoch)
+	if err != nil {
+		return SignedVoluntaryExit{}, err
+	}
+	err = validator.InitializeBls()
+	if err != nil {
+		return SignedVoluntaryExit{}, fmt.Errorf("error initializing BLS: %w", err)
+	}
+	privateKey, err := sp.loadValidatorKey(pubkey)
+	if err != nil {
+		return SignedVoluntaryExit{}, err
+	}
+
+	index := strconv.FormatUint(validatorIndex, 10)
+	signature, err := validator.GetSignedExitMessage(privateKey, index, epoch, domain[:])
+	if err != nil {
+		return SignedVoluntaryExit{}, fmt.Errorf("error signing exit for validator %s: %w", pubkey.HexWithPrefix(), err)
+	}
+	return SignedVoluntaryExit{
+		Message: client.VoluntaryExitMessage{
+			Epoch:          client.Uinteger(epoch),
+			ValidatorIndex: index,
+		},
+		Signature: signature[:],
+	}, nil
+}
+
+// Finds the validator's keystore in the keystore directory and decrypts it with the node password
+func (sp *ServiceProvider) loadValidatorKey(pubkey beacon.ValidatorPubkey) (*eth2types.BLSPrivateKey, error) {
+	password, isSet, err := sp.GetWallet().GetPassword()
+	if err != nil {
+		return nil, fmt.Errorf("error getting node password: %w", err)
+	}
+	if !isSet {
+		return nil, errors.New("the node password has not been set, so the validator's keystore cannot be decrypted")
+	}
+	paths, err := getKeystorePaths(sp.cfg.GetKeystoreDirectory())
+	if err != nil {
+		return nil, err
+	}
+
+	encryptor := eth2ks.New()
+	for _, path := range paths {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading keystore [%s]: %w", path, err)
+		}
+		var keystore beacon.ValidatorKeystore
+		if json.Unmarshal(bytes, &keystore) != nil || keystore.Pubkey != pubkey {
+			continue
+		}
+		decryptedKey, err := decryptKeystore(encryptor, keystore, password)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting keystore [%s]: %w", path, err)
+		}
+		privateKey, err := eth2types.BLSPrivateKeyFromBytes(decryptedKey)
+		clear(decryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("error recreating private key from keystore [%s]: %w", path, err)
+		}
+		if beacon.ValidatorPubkey(privateKey.PublicKey().Marshal()) != pubkey {
+			return nil, fmt.Errorf("keystore [%s] is for %s but has a different key", path, pubkey.HexWithPrefix())
+		}
+		return privateKey, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrValidatorKeyNotFound, pubkey.HexWithPrefix())
+}
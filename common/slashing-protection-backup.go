@@ -0,0 +1,284 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+const (
+	// The prefix and extension of slashing protection backup files
+	slashingProtectionBackupPrefix    string = "slashing-protection-"
+	slashingProtectionBackupExtension string = ".json"
+
+	// The timestamp format of slashing protection backup names, which sorts chronologically
+	slashingProtectionBackupTimeFormat string = "20060102T150405.000000000Z"
+
+	// How often to check whether the Validator Client's key manager API is up while waiting to restore a backup
+	keyManagerReadyCheckInterval time.Duration = 1 * time.Second
+)
+
+// True if slashing protection should be backed up before recreating the Validator Client
+func (sp *ServiceProvider) isSlashingProtectionBackupEnabled() bool {
+	return sp.cfg.KeyManager.Url.Value != "" && sp.cfg.KeyManager.SlashingProtectionBackups.Value > 0
+}
+
+// A slashing protection backup made before changing the Validator Client
+type SlashingProtectionBackup struct {
+	// The path of the backup file
+	Path string `json:"path"`
+
+	// The keys that were removed from the VC for the backup. They're saved in the backup file too, which is where
+	// restoring it reads them from.
+	Pubkeys []beacon.ValidatorPubkey `json:"pubkeys"`
+}
+
+// The contents of a slashing protection backup file. The removed keys are saved with the slashing protection data so
+// the backup can still be restored if the daemon stops before restoring it, including keys that have no signing history.
+type slashingProtectionBackupFile struct {
+	// The keys that were removed from the VC for the backup
+	Pubkeys []beacon.ValidatorPubkey `json:"pubkeys"`
+
+	// The VC's slashing protection data, in the EIP-3076 interchange format
+	SlashingProtection json.RawMessage `json:"slashing_protection"`
+}
+
+// Exports the Validator Client's slashing protection data into a timestamped backup in the user directory, then deletes
+// the oldest backups beyond the configured limit.
+// Like RemoveKeysAndExportSlashingProtection, this removes the keys from the VC; RestoreSlashingProtectionBackup loads
+// them again from the node's keystore directory. Nothing is removed if one of the keys doesn't have a keystore there or
+// the backup directory can't be written to, and if writing the backup fails anyway, the keys and their slashing
+// protection are loaded back into the VC.
+func (sp *ServiceProvider) BackupSlashingProtection(ctx context.Context) (SlashingProtectionBackup, error) {
+	// Make sure the keys can be loaded again before removing them
+	password, isSet, err := sp.GetWallet().GetPassword()
+	if err != nil {
+		return SlashingProtectionBackup{}, fmt.Errorf("error getting node password: %w", err)
+	}
+	if !isSet {
+		return SlashingProtectionBackup{}, errors.New("the node password has not been set, so the keys couldn't be loaded into the Validator Client again after the backup")
+	}
+	dir := sp.cfg.GetSlashingProtectionBackupDirectory()
+	err = checkDirWritable(dir)
+	if err != nil {
+		return SlashingProtectionBackup{}, fmt.Errorf("can't write to the slashing protection backup directory [%s]: %w", dir, err)
+	}
+	keystoreDir := sp.cfg.GetKeystoreDirectory()
+	keystorePaths, err := getKeystorePathsByPubkey(keystoreDir, password)
+	if err != nil {
+		return SlashingProtectionBackup{}, err
+	}
+
+	data, pubkeys, err := sp.removeKeysAndExportSlashingProtection(ctx, func(pubkeys []beacon.ValidatorPubkey) error {
+		missing := 0
+		for _, pubkey := range pubkeys {
+			if _, exists := keystorePaths[pubkey]; !exists {
+				missing++
+			}
+		}
+		if missing > 0 {
+			return fmt.Errorf("%d keys loaded into the Validator Client don't have a keystore in [%s], so they couldn't be loaded again after the backup", missing, keystoreDir)
+		}
+		return nil
+	})
+	if err != nil {
+		return SlashingProtectionBackup{}, fmt.Errorf("error exporting slashing protection data: %w", err)
+	}
+	backup := SlashingProtectionBackup{
+		Path:    filepath.Join(dir, slashingProtectionBackupPrefix+time.Now().UTC().Format(slashingProtectionBackupTimeFormat)+slashingProtectionBackupExtension),
+		Pubkeys: pubkeys,
+	}
+	contents, err := json.MarshalIndent(slashingProtectionBackupFile{
+		Pubkeys:            pubkeys,
+		SlashingProtection: data,
+	}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(backup.Path, contents, 0600)
+	}
+	if err != nil {
+		err = fmt.Errorf("error writing slashing protection backup [%s]: %w", backup.Path, err)
+		reloadErr := sp.restoreSlashingProtection(ctx, data, pubkeys, password)
+		if reloadErr != nil {
+			return SlashingProtectionBackup{}, fmt.Errorf("%w (loading the keys back into the Validator Client also failed: %s)", err, reloadErr.Error())
+		}
+		return SlashingProtectionBackup{}, err
+	}
+
+	// Remove the oldest backups
+	backups, err := sp.GetSlashingProtectionBackups()
+	if err != nil {
+		return backup, err
+	}
+	keep := int(sp.cfg.KeyManager.SlashingProtectionBackups.Value)
+	for len(backups) > keep {
+		err = os.Remove(backups[0])
+		if err != nil {
+			return backup, fmt.Errorf("error removing old slashing protection backup [%s]: %w", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+	return backup, nil
+}
+
+// Gets the paths of the slashing protection backups in the user directory, oldest first
+func (sp *ServiceProvider) GetSlashingProtectionBackups() ([]string, error) {
+	dir := sp.cfg.GetSlashingProtectionBackupDirectory()
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading slashing protection backup directory [%s]: %w", dir, err)
+	}
+	backups := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, slashingProtectionBackupPrefix) && strings.HasSuffix(name, slashingProtectionBackupExtension) {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// Restores the slashing protection backup at the provided path into the Validator Client once its key manager API is up,
+// then loads the keys that were removed for it back into the VC from the node's keystore directory. Everything needed is
+// read from the backup file, so this works for backups made before the daemon restarted.
+func (sp *ServiceProvider) RestoreSlashingProtectionBackup(ctx context.Context, path string) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading slashing protection backup [%s]: %w", path, err)
+	}
+	var backupFile slashingProtectionBackupFile
+	err = json.Unmarshal(bytes, &backupFile)
+	if err != nil {
+		return fmt.Errorf("error deserializing slashing protection backup [%s]: %w", path, err)
+	}
+	if len(backupFile.SlashingProtection) == 0 {
+		return fmt.Errorf("slashing protection backup [%s] doesn't have any slashing protection data", path)
+	}
+	password, isSet, err := sp.GetWallet().GetPassword()
+	if err != nil {
+		return fmt.Errorf("error getting node password: %w", err)
+	}
+	if !isSet {
+		return errors.New("the node password has not been set, so the keystores cannot be loaded")
+	}
+	err = sp.waitForKeyManager(ctx)
+	if err != nil {
+		return err
+	}
+	return sp.restoreSlashingProtection(ctx, backupFile.SlashingProtection, backupFile.Pubkeys, password)
+}
+
+// Imports slashing protection data into the Validator Client, then loads the keystores for the provided keys from the
+// node's keystore directory
+func (sp *ServiceProvider) restoreSlashingProtection(ctx context.Context, data []byte, pubkeys []beacon.ValidatorPubkey, password string) error {
+	// The protection has to go in first so the keys can't sign anything that conflicts with it
+	err := sp.ImportSlashingProtection(ctx, data)
+	if err != nil {
+		return err
+	}
+	if len(pubkeys) == 0 {
+		return nil
+	}
+
+	keystoreDir := sp.cfg.GetKeystoreDirectory()
+	keystorePaths, err := getKeystorePathsByPubkey(keystoreDir, password)
+	if err != nil {
+		return err
+	}
+	keystores := make([]string, len(pubkeys))
+	passwords := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		path, exists := keystorePaths[pubkey]
+		if !exists {
+			return fmt.Errorf("the keystore for key %s is no longer in [%s]", pubkey.HexWithPrefix(), keystoreDir)
+		}
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading keystore [%s]: %w", path, err)
+		}
+		keystores[i] = string(bytes)
+		passwords[i] = password
+	}
+	statuses, err := sp.GetKeyManagerClient().ImportKeystores(ctx, keystores, passwords, "")
+	if err != nil {
+		return fmt.Errorf("error loading keystores into the Validator Client: %w", err)
+	}
+	failed := 0
+	for _, status := range statuses {
+		if status.Status != "imported" && status.Status != "duplicate" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d keystores couldn't be loaded into the Validator Client", failed)
+	}
+	return nil
+}
+
+// Gets the paths of the valid keystores in a directory, keyed by their pubkeys
+func getKeystorePathsByPubkey(dir string, password string) (map[beacon.ValidatorPubkey]string, error) {
+	paths, err := getKeystorePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = validator.InitializeBls()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing BLS: %w", err)
+	}
+	encryptor := eth2ks.New()
+	keystorePaths := map[beacon.ValidatorPubkey]string{}
+	for _, path := range paths {
+		verification := verifyKeystore(encryptor, path, password)
+		if verification.Success {
+			keystorePaths[verification.Pubkey] = path
+		}
+	}
+	return keystorePaths, nil
+}
+
+// Makes sure a directory exists and files can be written to it
+func checkDirWritable(dir string) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// Waits for the Validator Client's key manager API to respond, up to the configured startup timeout
+func (sp *ServiceProvider) waitForKeyManager(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, sp.cfg.GetStartupTimeout())
+	defer cancel()
+	for {
+		_, err := sp.GetKeyManagerClient().ListKeystores(ctx)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrKeyManagerNotConfigured) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the Validator Client's key manager API didn't come up: %w", err)
+		case <-time.After(keyManagerReadyCheckInterval):
+		}
+	}
+}
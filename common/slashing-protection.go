@@ -0,0 +1,335 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/rocket-pool/node-manager-core/utils"
+)
+
+const (
+	// The EIP-3076 interchange format version supported by Hyperdrive
+	SlashingProtectionInterchangeVersion string = "5"
+)
+
+var (
+	// The Validator Client has keys loaded, so its slashing protection data can't be exported without removing them
+	ErrSlashingProtectionKeysLoaded error = errors.New("the Validator Client's key manager API only releases slashing protection data for keys it removes, and some keys are still loaded")
+)
+
+// EIP-3076 slashing protection interchange data
+type SlashingProtectionInterchange struct {
+	Metadata SlashingProtectionMetadata `json:"metadata"`
+	Data     []SlashingProtectionRecord `json:"data"`
+}
+
+// The metadata for a slashing protection interchange
+type SlashingProtectionMetadata struct {
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+	GenesisValidatorsRoot    string `json:"genesis_validators_root"`
+}
+
+// The slashing protection history for a single validator
+type SlashingProtectionRecord struct {
+	Pubkey             beacon.ValidatorPubkey `json:"pubkey"`
+	SignedBlocks       []SignedBlock          `json:"signed_blocks"`
+	SignedAttestations []SignedAttestation    `json:"signed_attestations"`
+}
+
+// A block signed by a validator
+type SignedBlock struct {
+	Slot        client.Uinteger `json:"slot"`
+	SigningRoot string          `json:"signing_root,omitempty"`
+}
+
+// An attestation signed by a validator
+type SignedAttestation struct {
+	SourceEpoch client.Uinteger `json:"source_epoch"`
+	TargetEpoch client.Uinteger `json:"target_epoch"`
+	SigningRoot string          `json:"signing_root,omitempty"`
+}
+
+// Exports the Validator Client's slashing protection data for the validators with keystores in the node's keystore
+// directory, in the EIP-3076 interchange format. This doesn't change anything in the VC: the key manager API only
+// releases slashing protection data when keys are deleted, and it returns the history of keys that aren't loaded
+// (with a not_active status) without removing anything. If the VC has any keys it could delete (read-only remote keys
+// don't count), this returns ErrSlashingProtectionKeysLoaded; use RemoveKeysAndExportSlashingProtection to take them
+// out of the VC first.
+func (sp *ServiceProvider) ExportSlashingProtection(ctx context.Context) ([]byte, error) {
+	sp.slashingProtectionLock.Lock()
+	defer sp.slashingProtectionLock.Unlock()
+
+	genesisValidatorsRoot, err := sp.getGenesisValidatorsRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loadedKeys, err := sp.getWritableKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(loadedKeys) > 0 {
+		return nil, fmt.Errorf("%w (%d keys loaded)", ErrSlashingProtectionKeysLoaded, len(loadedKeys))
+	}
+	pubkeys, err := getKeystoreDirectoryPubkeys(sp.cfg.GetKeystoreDirectory())
+	if err != nil {
+		return nil, err
+	}
+
+	// None of these keys are loaded, so the VC only reports their history
+	interchange, statuses, err := sp.releaseSlashingProtection(ctx, pubkeys, genesisValidatorsRoot)
+	if err != nil {
+		return nil, err
+	}
+	deleted := 0
+	for _, status := range statuses {
+		if status.Status == "deleted" {
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		return nil, fmt.Errorf("%d keys were loaded into the Validator Client during the export and have been removed from it; import their keystores again once their slashing protection data is saved", deleted)
+	}
+	return serializeSlashingProtection(interchange)
+}
+
+// Removes every key the Validator Client can delete (read-only remote keys are left alone) and exports their slashing
+// protection data in the EIP-3076 interchange format. This takes the node's validators offline on this VC, which is
+// what a migration needs: the keys must stop signing here before they start on the new VC. The keystore files on disk
+// are untouched, so they can be imported again later.
+func (sp *ServiceProvider) RemoveKeysAndExportSlashingProtection(ctx context.Context) ([]byte, error) {
+	data, _, err := sp.removeKeysAndExportSlashingProtection(ctx, nil)
+	return data, err
+}
+
+// Removes the keys the Validator Client can delete and exports their slashing protection data, returning the keys that
+// were removed. If provided, checkKeys is run on the keys before they're removed, and nothing is removed if it fails.
+func (sp *ServiceProvider) removeKeysAndExportSlashingProtection(ctx context.Context, checkKeys func([]beacon.ValidatorPubkey) error) ([]byte, []beacon.ValidatorPubkey, error) {
+	sp.slashingProtectionLock.Lock()
+	defer sp.slashingProtectionLock.Unlock()
+
+	genesisValidatorsRoot, err := sp.getGenesisValidatorsRoot(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubkeys, err := sp.getWritableKeys(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if checkKeys != nil {
+		err = checkKeys(pubkeys)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Remove the keys and collect their history
+	interchange, statuses, err := sp.releaseSlashingProtection(ctx, pubkeys, genesisValidatorsRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, status := range statuses {
+		if status.Status == "error" {
+			return nil, nil, fmt.Errorf("error deleting key %s from the Validator Client: %s", pubkeys[i].HexWithPrefix(), status.Message)
+		}
+	}
+	bytes, err := serializeSlashingProtection(interchange)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytes, pubkeys, nil
+}
+
+// Sends a key manager delete request for the provided keys, returning the slashing protection data the Validator
+// Client released for them along with the status of each key. Loaded keys are removed from the VC.
+func (sp *ServiceProvider) releaseSlashingProtection(ctx context.Context, pubkeys []beacon.ValidatorPubkey, genesisValidatorsRoot string) (SlashingProtectionInterchange, []KeyManagerStatus, error) {
+	interchange := newSlashingProtectionInterchange(genesisValidatorsRoot)
+	if len(pubkeys) == 0 {
+		return interchange, []KeyManagerStatus{}, nil
+	}
+	statuses, data, err := sp.GetKeyManagerClient().DeleteKeystores(ctx, pubkeys)
+	if err != nil {
+		return SlashingProtectionInterchange{}, nil, err
+	}
+	if data != "" {
+		interchange, err = parseSlashingProtection([]byte(data), genesisValidatorsRoot)
+		if err != nil {
+			return SlashingProtectionInterchange{}, nil, fmt.Errorf("error reading the Validator Client's slashing protection data: %w", err)
+		}
+	}
+	return interchange, statuses, nil
+}
+
+// Imports EIP-3076 slashing protection data into the Validator Client through the key manager API.
+// The data is reduced to the highest signed block slot and attestation epochs for each validator, and VCs merge imported
+// data into their own history rather than replacing it, so protection can never regress.
+func (sp *ServiceProvider) ImportSlashingProtection(ctx context.Context, data []byte) error {
+	sp.slashingProtectionLock.Lock()
+	defer sp.slashingProtectionLock.Unlock()
+
+	genesisValidatorsRoot, err := sp.getGenesisValidatorsRoot(ctx)
+	if err != nil {
+		return err
+	}
+	interchange, err := parseSlashingProtection(data, genesisValidatorsRoot)
+	if err != nil {
+		return err
+	}
+	minimal, err := json.Marshal(MergeSlashingProtection(interchange, SlashingProtectionInterchange{}))
+	if err != nil {
+		return fmt.Errorf("error serializing slashing protection data: %w", err)
+	}
+	_, err = sp.GetKeyManagerClient().ImportKeystores(ctx, []string{}, []string{}, string(minimal))
+	if err != nil {
+		return fmt.Errorf("error importing slashing protection data into the Validator Client: %w", err)
+	}
+	return nil
+}
+
+// Gets the keys loaded into the Validator Client that the key manager API can delete
+func (sp *ServiceProvider) getWritableKeys(ctx context.Context) ([]beacon.ValidatorPubkey, error) {
+	keystores, err := sp.GetKeyManagerClient().ListKeystores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, keystore := range keystores {
+		if !keystore.ReadOnly {
+			pubkeys = append(pubkeys, keystore.Pubkey)
+		}
+	}
+	return pubkeys, nil
+}
+
+// Gets the pubkeys declared by the keystores in a directory, without decrypting them
+func getKeystoreDirectoryPubkeys(dir string) ([]beacon.ValidatorPubkey, error) {
+	paths, err := getKeystorePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, path := range paths {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading keystore [%s]: %w", path, err)
+		}
+		var keystore beacon.ValidatorKeystore
+		if json.Unmarshal(bytes, &keystore) != nil || keystore.Pubkey == (beacon.ValidatorPubkey{}) {
+			continue
+		}
+		if !slices.Contains(pubkeys, keystore.Pubkey) {
+			pubkeys = append(pubkeys, keystore.Pubkey)
+		}
+	}
+	return pubkeys, nil
+}
+
+// Creates an interchange without any records for the current network
+func newSlashingProtectionInterchange(genesisValidatorsRoot string) SlashingProtectionInterchange {
+	return SlashingProtectionInterchange{
+		Metadata: SlashingProtectionMetadata{
+			InterchangeFormatVersion: SlashingProtectionInterchangeVersion,
+			GenesisValidatorsRoot:    genesisValidatorsRoot,
+		},
+		Data: []SlashingProtectionRecord{},
+	}
+}
+
+// Serializes slashing protection data for export
+func serializeSlashingProtection(interchange SlashingProtectionInterchange) ([]byte, error) {
+	bytes, err := json.MarshalIndent(interchange, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error serializing slashing protection data: %w", err)
+	}
+	return bytes, nil
+}
+
+// Deserializes slashing protection data and makes sure it's for the current network
+func parseSlashingProtection(data []byte, genesisValidatorsRoot string) (SlashingProtectionInterchange, error) {
+	var interchange SlashingProtectionInterchange
+	err := json.Unmarshal(data, &interchange)
+	if err != nil {
+		return SlashingProtectionInterchange{}, fmt.Errorf("error deserializing slashing protection data: %w", err)
+	}
+	if interchange.Metadata.InterchangeFormatVersion != SlashingProtectionInterchangeVersion {
+		return SlashingProtectionInterchange{}, fmt.Errorf("unsupported slashing protection interchange version [%s], expected [%s]", interchange.Metadata.InterchangeFormatVersion, SlashingProtectionInterchangeVersion)
+	}
+	if !strings.EqualFold(interchange.Metadata.GenesisValidatorsRoot, genesisValidatorsRoot) {
+		return SlashingProtectionInterchange{}, fmt.Errorf("slashing protection data is for genesis validators root %s, but the current network's is %s", interchange.Metadata.GenesisValidatorsRoot, genesisValidatorsRoot)
+	}
+	return interchange, nil
+}
+
+// Merges two sets of slashing protection data into a minimal interchange, keeping the highest signed slot and epochs for each validator.
+// The metadata is taken from the first interchange.
+func MergeSlashingProtection(first SlashingProtectionInterchange, second SlashingProtectionInterchange) SlashingProtectionInterchange {
+	type watermark struct {
+		hasBlock       bool
+		maxSlot        client.Uinteger
+		hasAttestation bool
+		maxSource      client.Uinteger
+		maxTarget      client.Uinteger
+	}
+	watermarks := map[beacon.ValidatorPubkey]*watermark{}
+	for _, record := range append(first.Data, second.Data...) {
+		mark, exists := watermarks[record.Pubkey]
+		if !exists {
+			mark = &watermark{}
+			watermarks[record.Pubkey] = mark
+		}
+		for _, block := range record.SignedBlocks {
+			if !mark.hasBlock || block.Slot > mark.maxSlot {
+				mark.maxSlot = block.Slot
+			}
+			mark.hasBlock = true
+		}
+		for _, attestation := range record.SignedAttestations {
+			if !mark.hasAttestation || attestation.SourceEpoch > mark.maxSource {
+				mark.maxSource = attestation.SourceEpoch
+			}
+			if !mark.hasAttestation || attestation.TargetEpoch > mark.maxTarget {
+				mark.maxTarget = attestation.TargetEpoch
+			}
+			mark.hasAttestation = true
+		}
+	}
+
+	merged := SlashingProtectionInterchange{
+		Metadata: first.Metadata,
+		Data:     make([]SlashingProtectionRecord, 0, len(watermarks)),
+	}
+	for pubkey, mark := range watermarks {
+		record := SlashingProtectionRecord{
+			Pubkey:             pubkey,
+			SignedBlocks:       []SignedBlock{},
+			SignedAttestations: []SignedAttestation{},
+		}
+		if mark.hasBlock {
+			record.SignedBlocks = append(record.SignedBlocks, SignedBlock{Slot: mark.maxSlot})
+		}
+		if mark.hasAttestation {
+			record.SignedAttestations = append(record.SignedAttestations, SignedAttestation{SourceEpoch: mark.maxSource, TargetEpoch: mark.maxTarget})
+		}
+		merged.Data = append(merged.Data, record)
+	}
+	sort.Slice(merged.Data, func(i, j int) bool {
+		return merged.Data[i].Pubkey.Hex() < merged.Data[j].Pubkey.Hex()
+	})
+	return merged
+}
+
+// Gets the current network's genesis validators root from the Beacon Node
+func (sp *ServiceProvider) getGenesisValidatorsRoot(ctx context.Context) (string, error) {
+	genesis, err := sp.GetBeaconApiClient().GetGenesis(ctx)
+	if err != nil {
+		return "", err
+	}
+	return utils.EncodeHexWithPrefix(genesis.Data.GenesisValidatorsRoot), nil
+}
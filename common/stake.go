@@ -0,0 +1,172 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// The general status of a staked validator balance
+type StakeStatus string
+
+const (
+	// The validator has been deposited but isn't active yet
+	StakeStatus_Pending StakeStatus = "pending"
+
+	// The validator is active and attesting
+	StakeStatus_Active StakeStatus = "active"
+
+	// The validator is exiting or has exited, but its balance can't be withdrawn yet
+	StakeStatus_Exiting StakeStatus = "exiting"
+
+	// The validator's balance can be withdrawn
+	StakeStatus_Withdrawable StakeStatus = "withdrawable"
+)
+
+// A module that has stake on the node. Modules register one of these with the service provider so their validators
+// and on-chain collateral are included in the node's total stake; disabled modules are left out of the total.
+type StakeContributor interface {
+	ValidatorEnumerator
+
+	// Get the amount of ETH (in wei) the module has locked on-chain as collateral for the node
+	GetCollateral(ctx context.Context) (*big.Int, error)
+}
+
+// The stake belonging to a single module
+type ModuleStake struct {
+	// The number of the module's validators on the Beacon Chain that still hold a balance
+	ValidatorCount int `json:"validatorCount"`
+
+	// The module's validator balances (in gwei) by status
+	Balances map[StakeStatus]uint64 `json:"balances"`
+
+	// The total of the module's validator balances (in gwei)
+	TotalBalance uint64 `json:"totalBalance"`
+
+	// The module's on-chain collateral (in wei)
+	Collateral *big.Int `json:"collateral"`
+}
+
+// The node's total stake across all enabled modules
+type StakeSummary struct {
+	// The stake of each enabled module, keyed by module name
+	Modules map[string]ModuleStake `json:"modules"`
+
+	// The validator balances (in gwei) of every module by status
+	Balances map[StakeStatus]uint64 `json:"balances"`
+
+	// The total of every module's validator balances (in gwei)
+	TotalBalance uint64 `json:"totalBalance"`
+
+	// The total on-chain collateral of every module (in wei)
+	TotalCollateral *big.Int `json:"totalCollateral"`
+}
+
+// Registers a module's stake so it's included in the node's total stake
+func (sp *ServiceProvider) RegisterStakeContributor(contributor StakeContributor) {
+	sp.stakeContributorLock.Lock()
+	defer sp.stakeContributorLock.Unlock()
+	sp.stakeContributors = append(sp.stakeContributors, contributor)
+}
+
+// Gets the node's total staked balance across all enabled modules, including their validator balances and on-chain collateral
+func (sp *ServiceProvider) GetTotalStake(ctx context.Context) (StakeSummary, error) {
+	contributors := sp.getStakeContributors()
+	summary := StakeSummary{
+		Modules:         map[string]ModuleStake{},
+		Balances:        newStakeBalanceMap(),
+		TotalCollateral: big.NewInt(0),
+	}
+	bn := sp.GetBeaconApiClient()
+	for _, contributor := range contributors {
+		if !contributor.IsEnabled() {
+			continue
+		}
+		name := contributor.GetModuleName()
+
+		// Get the module's validators
+		pubkeys, err := contributor.GetValidatorPubkeys(ctx)
+		if err != nil {
+			return StakeSummary{}, fmt.Errorf("error getting validators for module [%s]: %w", name, err)
+		}
+		ids := make([]string, len(pubkeys))
+		for i, pubkey := range pubkeys {
+			ids[i] = pubkey.HexWithPrefix()
+		}
+		stake := ModuleStake{
+			Balances: newStakeBalanceMap(),
+		}
+		if len(ids) > 0 {
+			validators, err := bn.GetValidators(ctx, "head", ids, nil)
+			if err != nil {
+				return StakeSummary{}, fmt.Errorf("error getting validator balances for module [%s]: %w", name, err)
+			}
+			for _, validator := range validators {
+				status, hasStake := getStakeStatus(beacon.ValidatorState(validator.Status))
+				if !hasStake {
+					continue
+				}
+				balance := uint64(validator.Balance)
+				stake.ValidatorCount++
+				stake.Balances[status] += balance
+				stake.TotalBalance += balance
+			}
+		}
+
+		// Get the module's collateral
+		collateral, err := contributor.GetCollateral(ctx)
+		if err != nil {
+			return StakeSummary{}, fmt.Errorf("error getting collateral for module [%s]: %w", name, err)
+		}
+		if collateral == nil {
+			collateral = big.NewInt(0)
+		}
+		stake.Collateral = collateral
+
+		// Add it to the total
+		summary.Modules[name] = stake
+		for status, balance := range stake.Balances {
+			summary.Balances[status] += balance
+		}
+		summary.TotalBalance += stake.TotalBalance
+		summary.TotalCollateral.Add(summary.TotalCollateral, collateral)
+	}
+	return summary, nil
+}
+
+// Gets a copy of the registered stake contributors
+func (sp *ServiceProvider) getStakeContributors() []StakeContributor {
+	sp.stakeContributorLock.Lock()
+	defer sp.stakeContributorLock.Unlock()
+	contributors := make([]StakeContributor, len(sp.stakeContributors))
+	copy(contributors, sp.stakeContributors)
+	return contributors
+}
+
+// Creates a balance map with an entry for each status
+func newStakeBalanceMap() map[StakeStatus]uint64 {
+	return map[StakeStatus]uint64{
+		StakeStatus_Pending:      0,
+		StakeStatus_Active:       0,
+		StakeStatus_Exiting:      0,
+		StakeStatus_Withdrawable: 0,
+	}
+}
+
+// Gets the stake status for a validator state. Returns false if the validator has been fully withdrawn.
+func getStakeStatus(state beacon.ValidatorState) (StakeStatus, bool) {
+	switch state {
+	case beacon.ValidatorState_PendingInitialized, beacon.ValidatorState_PendingQueued:
+		return StakeStatus_Pending, true
+	case beacon.ValidatorState_ActiveOngoing:
+		return StakeStatus_Active, true
+	case beacon.ValidatorState_ActiveExiting, beacon.ValidatorState_ActiveSlashed, beacon.ValidatorState_ExitedUnslashed, beacon.ValidatorState_ExitedSlashed:
+		return StakeStatus_Exiting, true
+	case beacon.ValidatorState_WithdrawalPossible:
+		return StakeStatus_Withdrawable, true
+	default:
+		return "", false
+	}
+}
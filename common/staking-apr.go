@@ -0,0 +1,294 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// The length of a year used to annualize returns, averaging in leap years
+	aprYearLength time.Duration = 36525 * 24 * time.Hour / 100
+)
+
+// Returned when a range of epochs reaches back further than the history the node's clients have
+type InsufficientHistoryError struct {
+	// The first epoch of the requested range
+	StartEpoch uint64
+
+	// What's missing
+	Reason string
+}
+
+func (e *InsufficientHistoryError) Error() string {
+	return fmt.Sprintf("history from epoch %d isn't available: %s", e.StartEpoch, e.Reason)
+}
+
+// The node's realized returns over a window of epochs, split into the Beacon Chain and Execution layer components.
+// APRs are annualized fractions of the principal (e.g. 0.035 for 3.5%).
+type APRBreakdown struct {
+	// The first epoch of the window
+	StartEpoch uint64 `json:"startEpoch"`
+
+	// The epoch the window ends at; it runs up to the state at the epoch's first slot
+	EndEpoch uint64 `json:"endEpoch"`
+
+	// The number of validators the returns were measured for; validators that weren't active at the start of the window
+	// are left out
+	ValidatorCount int `json:"validatorCount"`
+
+	// The validators' total balance at the start of the window, in gwei
+	Principal uint64 `json:"principal"`
+
+	// The validators' Beacon Chain rewards over the window net of penalties, in gwei; this is negative if penalties
+	// outweighed rewards
+	ConsensusRewards int64 `json:"consensusRewards"`
+
+	// The number of blocks the validators proposed during the window
+	ProposalCount int `json:"proposalCount"`
+
+	// The priority fees paid to the validators' fee recipients by blocks they built locally, in wei
+	PriorityFees *big.Int `json:"priorityFees"`
+
+	// The payments from block builders to the validators' fee recipients, in wei
+	MevRewards *big.Int `json:"mevRewards"`
+
+	// The annualized Beacon Chain return
+	ConsensusAPR float64 `json:"consensusApr"`
+
+	// The annualized Execution layer return from priority fees and MEV
+	ExecutionAPR float64 `json:"executionApr"`
+
+	// The total annualized return
+	TotalAPR float64 `json:"totalApr"`
+}
+
+// The parts of an Execution layer block needed to work out what its proposer earned
+type aprBlock struct {
+	BaseFeePerGas *hexutil.Big `json:"baseFeePerGas"`
+	Transactions  []struct {
+		From  ethcommon.Address  `json:"from"`
+		To    *ethcommon.Address `json:"to"`
+		Value *hexutil.Big       `json:"value"`
+	} `json:"transactions"`
+}
+
+// The parts of a receipt needed to work out the priority fee a transaction paid
+type aprReceipt struct {
+	GasUsed           hexutil.Uint64 `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+}
+
+// Computes the realized APR of the validators of every enabled module over the last lookbackEpochs completed epochs.
+// The Beacon Chain component comes from the change in the validators' balances over the window, with their withdrawals
+// during it added back; top-up deposits aren't subtracted, so they count as rewards. The Execution layer component comes
+// from the blocks the validators proposed: a block whose last transaction is a payment from its fee recipient was built
+// by a builder, so the payment is counted as MEV, and otherwise the block's priority fees are counted.
+// Returns an *InsufficientHistoryError if the window starts before genesis, or if the Beacon Node or Execution Client
+// doesn't have the states or blocks it covers.
+func (sp *ServiceProvider) ComputeAPR(ctx context.Context, lookbackEpochs uint64) (APRBreakdown, error) {
+	if lookbackEpochs == 0 {
+		return APRBreakdown{}, fmt.Errorf("lookback must be at least 1 epoch")
+	}
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return APRBreakdown{}, err
+	}
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return APRBreakdown{}, err
+	}
+	endEpoch := headSlot / spec.SlotsPerEpoch
+	if lookbackEpochs > endEpoch {
+		return APRBreakdown{}, &InsufficientHistoryError{
+			Reason: fmt.Sprintf("the chain is only %d epochs old", endEpoch),
+		}
+	}
+	startEpoch := endEpoch - lookbackEpochs
+	startSlot := startEpoch * spec.SlotsPerEpoch
+	endSlot := endEpoch * spec.SlotsPerEpoch
+	breakdown := APRBreakdown{
+		StartEpoch:   startEpoch,
+		EndEpoch:     endEpoch,
+		PriorityFees: big.NewInt(0),
+		MevRewards:   big.NewInt(0),
+	}
+
+	// Find the validators that were active for the whole window
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return APRBreakdown{}, err
+	}
+	if len(pubkeys) == 0 {
+		return breakdown, nil
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+	validators, err := bn.GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return APRBreakdown{}, err
+	}
+	indices := []string{}
+	for _, validator := range validators {
+		if uint64(validator.Validator.ActivationEpoch) <= startEpoch {
+			indices = append(indices, validator.Index)
+		}
+	}
+	if len(indices) == 0 {
+		return breakdown, nil
+	}
+
+	// Get the Beacon Chain rewards from the balances at each end of the window
+	startBalances, exists, err := bn.GetValidatorBalances(ctx, strconv.FormatUint(startSlot, 10), indices)
+	if err != nil {
+		return APRBreakdown{}, err
+	}
+	if !exists {
+		return APRBreakdown{}, &InsufficientHistoryError{
+			StartEpoch: startEpoch,
+			Reason:     fmt.Sprintf("the Beacon Node doesn't have the state at slot %d", startSlot),
+		}
+	}
+	endBalances, exists, err := bn.GetValidatorBalances(ctx, strconv.FormatUint(endSlot, 10), indices)
+	if err != nil {
+		return APRBreakdown{}, err
+	}
+	if !exists {
+		return APRBreakdown{}, fmt.Errorf("the Beacon Node doesn't have the state at slot %d", endSlot)
+	}
+	included := map[uint64]bool{}
+	withdrawalIndices := []uint64{}
+	rewards := int64(0)
+	for _, index := range indices {
+		startBalance, exists := startBalances[index]
+		if !exists {
+			continue
+		}
+		parsedIndex, err := strconv.ParseUint(index, 10, 64)
+		if err != nil {
+			return APRBreakdown{}, fmt.Errorf("error parsing validator index [%s]: %w", index, err)
+		}
+		included[parsedIndex] = true
+		withdrawalIndices = append(withdrawalIndices, parsedIndex)
+		breakdown.Principal += startBalance
+		rewards += int64(endBalances[index]) - int64(startBalance)
+	}
+	breakdown.ValidatorCount = len(included)
+	if breakdown.ValidatorCount == 0 {
+		return breakdown, nil
+	}
+	withdrawals, err := sp.GetWithdrawals(ctx, startSlot+1, endSlot, withdrawalIndices)
+	if err != nil {
+		return APRBreakdown{}, fmt.Errorf("error getting withdrawals during the window: %w", err)
+	}
+	for _, withdrawal := range withdrawals {
+		rewards += int64(withdrawal.Amount)
+	}
+	breakdown.ConsensusRewards = rewards
+
+	// Get the Execution layer rewards from the validators' proposals; the state at the start slot already includes its
+	// block, so it's left out like it is for withdrawals
+	client, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return APRBreakdown{}, err
+	}
+	defer client.Close()
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		duties, err := bn.GetProposerDuties(ctx, epoch)
+		if err != nil {
+			return APRBreakdown{}, err
+		}
+		for _, duty := range duties {
+			slot := uint64(duty.Slot)
+			index, err := strconv.ParseUint(duty.ValidatorIndex, 10, 64)
+			if err != nil || !included[index] || slot <= startSlot || slot > endSlot {
+				continue
+			}
+			proposed, err := sp.addProposalRewards(ctx, client, startEpoch, slot, &breakdown)
+			if err != nil {
+				return APRBreakdown{}, err
+			}
+			if proposed {
+				breakdown.ProposalCount++
+			}
+		}
+	}
+
+	// Annualize the returns
+	principalWei := new(big.Float).SetInt(new(big.Int).Mul(new(big.Int).SetUint64(breakdown.Principal), big.NewInt(1e9)))
+	windowLength := time.Duration(lookbackEpochs*spec.SlotsPerEpoch*spec.SecondsPerSlot) * time.Second
+	annualization := float64(aprYearLength) / float64(windowLength)
+	breakdown.ConsensusAPR = float64(breakdown.ConsensusRewards) / float64(breakdown.Principal) * annualization
+	executionRewards := new(big.Int).Add(breakdown.PriorityFees, breakdown.MevRewards)
+	executionFraction, _ := new(big.Float).Quo(new(big.Float).SetInt(executionRewards), principalWei).Float64()
+	breakdown.ExecutionAPR = executionFraction * annualization
+	breakdown.TotalAPR = breakdown.ConsensusAPR + breakdown.ExecutionAPR
+	return breakdown, nil
+}
+
+// Adds the priority fees or MEV payment of the block proposed in the provided slot to the breakdown. Returns false if
+// the slot was missed.
+func (sp *ServiceProvider) addProposalRewards(ctx context.Context, client *rpc.Client, startEpoch uint64, slot uint64, breakdown *APRBreakdown) (bool, error) {
+	payload, exists, err := sp.GetBeaconApiClient().GetBlockExecutionPayload(ctx, strconv.FormatUint(slot, 10))
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+
+	blockNumber := hexutil.EncodeUint64(uint64(payload.BlockNumber))
+	var block *aprBlock
+	err = client.CallContext(ctx, &block, "eth_getBlockByNumber", blockNumber, true)
+	if err != nil {
+		return false, fmt.Errorf("error getting block %d: %w", payload.BlockNumber, err)
+	}
+	if block == nil {
+		return false, &InsufficientHistoryError{
+			StartEpoch: startEpoch,
+			Reason:     fmt.Sprintf("the Execution Client doesn't have block %d", payload.BlockNumber),
+		}
+	}
+
+	// Builders pay the proposer with the last transaction in the block
+	feeRecipient := ethcommon.BytesToAddress(payload.FeeRecipient)
+	if len(block.Transactions) > 0 {
+		last := block.Transactions[len(block.Transactions)-1]
+		if last.From == feeRecipient && last.To != nil && *last.To != feeRecipient && last.Value != nil {
+			breakdown.MevRewards.Add(breakdown.MevRewards, last.Value.ToInt())
+			return true, nil
+		}
+	}
+
+	var receipts []aprReceipt
+	err = client.CallContext(ctx, &receipts, "eth_getBlockReceipts", blockNumber)
+	if err != nil {
+		return false, fmt.Errorf("error getting receipts for block %d: %w", payload.BlockNumber, err)
+	}
+	baseFee := big.NewInt(0)
+	if block.BaseFeePerGas != nil {
+		baseFee = block.BaseFeePerGas.ToInt()
+	}
+	for _, receipt := range receipts {
+		if receipt.EffectiveGasPrice == nil {
+			return false, errors.New("receipt is missing its effective gas price")
+		}
+		tip := new(big.Int).Sub(receipt.EffectiveGasPrice.ToInt(), baseFee)
+		if tip.Sign() <= 0 {
+			continue
+		}
+		tip.Mul(tip, new(big.Int).SetUint64(uint64(receipt.GasUsed)))
+		breakdown.PriorityFees.Add(breakdown.PriorityFees, tip)
+	}
+	return true, nil
+}
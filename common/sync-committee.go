@@ -0,0 +1,116 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// The chain hasn't reached Altair yet, so there aren't any sync committees
+	ErrSyncCommitteeNotActive error = errors.New("sync committees aren't active until the Altair fork")
+)
+
+// The node's validators in the sync committee for one period
+type SyncCommitteeMembership struct {
+	// The sync committee period
+	Period uint64 `json:"period"`
+
+	// True if this is the current period, false if it's the next one
+	IsCurrent bool `json:"isCurrent"`
+
+	// The first and last slots of the period
+	StartSlot uint64 `json:"startSlot"`
+	EndSlot   uint64 `json:"endSlot"`
+
+	// When the period starts and ends
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+
+	// The node's validators that are in the period's sync committee; empty if none of them are
+	Validators []beacon.ValidatorPubkey `json:"validators"`
+}
+
+// Gets which of the node's validators are in the sync committees for the current and next periods, in that order. The
+// next period's committee is already known, so operators can see a duty coming before it starts.
+// Returns ErrSyncCommitteeNotActive if the chain hasn't reached Altair yet.
+func (sp *ServiceProvider) GetCurrentSyncCommitteeMembership(ctx context.Context) ([]SyncCommitteeMembership, error) {
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if spec.EpochsPerSyncCommitteePeriod == 0 {
+		return nil, fmt.Errorf("spec is missing EPOCHS_PER_SYNC_COMMITTEE_PERIOD")
+	}
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return nil, err
+	}
+	currentEpoch := headSlot / spec.SlotsPerEpoch
+	if currentEpoch < spec.AltairForkEpoch {
+		return nil, ErrSyncCommitteeNotActive
+	}
+	genesisTime, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the node's validators to their indices, which is what the committees are made of
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pubkeysByIndex := map[string]beacon.ValidatorPubkey{}
+	if len(pubkeys) > 0 {
+		ids := make([]string, len(pubkeys))
+		for i, pubkey := range pubkeys {
+			ids[i] = pubkey.HexWithPrefix()
+		}
+		validators, err := bn.GetValidators(ctx, "head", ids, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, validator := range validators {
+			pubkeysByIndex[validator.Index] = beacon.ValidatorPubkey(validator.Validator.Pubkey)
+		}
+	}
+
+	currentPeriod := currentEpoch / spec.EpochsPerSyncCommitteePeriod
+	memberships := make([]SyncCommitteeMembership, 0, 2)
+	for period := currentPeriod; period <= currentPeriod+1; period++ {
+		startEpoch := period * spec.EpochsPerSyncCommitteePeriod
+		startSlot := startEpoch * spec.SlotsPerEpoch
+		nextStartSlot := (startEpoch + spec.EpochsPerSyncCommitteePeriod) * spec.SlotsPerEpoch
+		membership := SyncCommitteeMembership{
+			Period:     period,
+			IsCurrent:  period == currentPeriod,
+			StartSlot:  startSlot,
+			EndSlot:    nextStartSlot - 1,
+			StartTime:  genesisTime.Add(time.Duration(startSlot*spec.SecondsPerSlot) * time.Second),
+			EndTime:    genesisTime.Add(time.Duration(nextStartSlot*spec.SecondsPerSlot) * time.Second),
+			Validators: []beacon.ValidatorPubkey{},
+		}
+		if len(pubkeysByIndex) > 0 {
+			// The period Altair forked in started before there were sync committees, so ask with an epoch that had one
+			committee, err := bn.GetSyncCommittee(ctx, "head", max(startEpoch, currentEpoch))
+			if err != nil {
+				return nil, err
+			}
+			seen := map[string]bool{}
+			for _, index := range committee {
+				pubkey, isOurs := pubkeysByIndex[index]
+				if !isOurs || seen[index] {
+					continue
+				}
+				seen[index] = true
+				membership.Validators = append(membership.Validators, pubkey)
+			}
+		}
+		memberships = append(memberships, membership)
+	}
+	return memberships, nil
+}
@@ -0,0 +1,195 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// How a client's sync is going
+type SyncState string
+
+const (
+	// The client is synced
+	SyncState_Synced SyncState = "synced"
+
+	// The client is syncing and has gotten closer to the head during the window, however slowly
+	SyncState_Progressing SyncState = "progressing"
+
+	// The client is syncing but hasn't gotten any closer to the head over a whole window
+	SyncState_Stuck SyncState = "stuck"
+
+	// The client is syncing, but it hasn't been watched for a whole window yet and hasn't gotten closer to the head so far
+	SyncState_Measuring SyncState = "measuring"
+)
+
+// The sync health of one of the clients
+type ClientSyncHealth struct {
+	// How the client's sync is going
+	State SyncState `json:"state"`
+
+	// How far the client is from the head, in blocks for the Execution Client or slots for the Beacon Node
+	Distance uint64 `json:"distance"`
+
+	// The distance at the start of the window the progress was measured over
+	WindowStartDistance uint64 `json:"windowStartDistance"`
+
+	// How much the distance shrank per minute over the window; only set while the client is progressing
+	ProgressPerMinute float64 `json:"progressPerMinute"`
+
+	// True if the client is progressing quickly enough to estimate how long it has left
+	HasEta bool `json:"hasEta"`
+
+	// The estimated time until the client catches up to the head
+	Eta time.Duration `json:"eta"`
+}
+
+// The sync health of the clients
+type SyncHealth struct {
+	// How long a client's distance can go without shrinking before it's considered stuck
+	Window time.Duration `json:"window"`
+
+	// The Execution Client's sync health
+	ExecutionClient ClientSyncHealth `json:"executionClient"`
+
+	// The Beacon Node's sync health
+	BeaconNode ClientSyncHealth `json:"beaconNode"`
+}
+
+// A measurement of a client's distance from the head
+type syncDistanceSample struct {
+	time     time.Time
+	distance uint64
+}
+
+// Checks whether the clients are getting closer to the head while they sync. Each call records how far behind each one
+// is; a client whose distance hasn't shrunk since the start of the configured window is stuck, while one that has
+// shrunk at all is progressing, no matter how slowly. Until a client has been watched for a whole window without
+// shrinking it's still being measured, so this needs to be called regularly across the window to be useful.
+func (sp *ServiceProvider) GetSyncHealth(ctx context.Context) (SyncHealth, error) {
+	// The primary Execution Client is queried directly, since a synced fallback would hide a primary that's stuck
+	ecRpc, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return SyncHealth{}, err
+	}
+	defer ecRpc.Close()
+	ecProgress, err := ethclient.NewClient(ecRpc).SyncProgress(ctx)
+	if err != nil {
+		return SyncHealth{}, fmt.Errorf("error getting Execution Client sync progress: %w", err)
+	}
+	bnStatus, err := sp.GetBeaconApiClient().GetSyncStatus(ctx)
+	if err != nil {
+		return SyncHealth{}, fmt.Errorf("error getting Beacon Node sync status: %w", err)
+	}
+
+	sp.syncHealthLock.Lock()
+	defer sp.syncHealthLock.Unlock()
+	now := time.Now()
+	health := SyncHealth{
+		Window: sp.cfg.GetStuckSyncWindow(),
+	}
+	if ecProgress == nil {
+		health.ExecutionClient.State = SyncState_Synced
+		sp.executionSyncDistanceSamples = nil
+	} else {
+		var distance uint64
+		if ecProgress.HighestBlock > ecProgress.CurrentBlock {
+			distance = ecProgress.HighestBlock - ecProgress.CurrentBlock
+		}
+		health.ExecutionClient, sp.executionSyncDistanceSamples = getClientSyncHealth(sp.executionSyncDistanceSamples, now, distance, health.Window)
+	}
+	if !bnStatus.IsSyncing {
+		health.BeaconNode.State = SyncState_Synced
+		sp.beaconSyncDistanceSamples = nil
+	} else {
+		health.BeaconNode, sp.beaconSyncDistanceSamples = getClientSyncHealth(sp.beaconSyncDistanceSamples, now, uint64(bnStatus.SyncDistance), health.Window)
+	}
+	sp.lastSyncHealth = &health
+	return health, nil
+}
+
+// Gets a syncing client's health from its previous samples and its current distance, returning the samples to keep for
+// the next call
+func getClientSyncHealth(samples []syncDistanceSample, now time.Time, distance uint64, window time.Duration) (ClientSyncHealth, []syncDistanceSample) {
+	samples = append(samples, syncDistanceSample{
+		time:     now,
+		distance: distance,
+	})
+
+	// Measure from the latest sample that's at least a window old, since nothing before it is needed anymore
+	fullWindow := false
+	for i := len(samples) - 1; i >= 0; i-- {
+		if now.Sub(samples[i].time) >= window {
+			samples = samples[i:]
+			fullWindow = true
+			break
+		}
+	}
+	start := samples[0]
+	health := ClientSyncHealth{
+		Distance:            distance,
+		WindowStartDistance: start.distance,
+	}
+	switch {
+	case distance < start.distance:
+		health.State = SyncState_Progressing
+		minutes := now.Sub(start.time).Minutes()
+		if minutes > 0 {
+			health.ProgressPerMinute = float64(start.distance-distance) / minutes
+			health.HasEta = true
+			health.Eta = time.Duration(float64(distance) / health.ProgressPerMinute * float64(time.Minute))
+		}
+	case fullWindow:
+		health.State = SyncState_Stuck
+	default:
+		health.State = SyncState_Measuring
+	}
+	return health, samples
+}
+
+// ==================
+// === Prometheus ===
+// ==================
+
+var (
+	syncStuckDesc = prometheus.NewDesc(
+		"hyperdrive_sync_stuck",
+		"1 if the client is syncing but hasn't gotten closer to the head over the stuck sync window",
+		[]string{"client"},
+		nil,
+	)
+)
+
+// Exports the result of the latest sync health check to the Prometheus registry, so alerts can fire on stuck clients
+type syncHealthCollector struct {
+	sp *ServiceProvider
+}
+
+// Describes the sync health metrics for the Prometheus registry
+func (c *syncHealthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- syncStuckDesc
+}
+
+// Collects the sync health metrics for the Prometheus registry. Nothing is reported until the sync health has been
+// checked; scrapes don't check it themselves, since that would take samples on Prometheus's schedule.
+func (c *syncHealthCollector) Collect(ch chan<- prometheus.Metric) {
+	c.sp.syncHealthLock.Lock()
+	health := c.sp.lastSyncHealth
+	c.sp.syncHealthLock.Unlock()
+	if health == nil {
+		return
+	}
+	for client, clientHealth := range map[string]ClientSyncHealth{
+		"ec": health.ExecutionClient,
+		"bn": health.BeaconNode,
+	} {
+		stuck := 0.0
+		if clientHealth.State == SyncState_Stuck {
+			stuck = 1
+		}
+		ch <- prometheus.MustNewConstMetric(syncStuckDesc, prometheus.GaugeValue, stuck, client)
+	}
+}
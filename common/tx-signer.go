@@ -0,0 +1,154 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/node/wallet"
+)
+
+var (
+	// The transaction signer selected in the config wasn't provided to the service provider
+	ErrUnknownTxSigner error = errors.New("unknown transaction signer")
+)
+
+// Signs the node's transactions. The node wallet's keystore is used by default; other implementations, like hardware
+// wallets or remote signers, can be provided when the service provider is created and selected by name in the config.
+type Signer interface {
+	// Signs a transaction for the provided chain, returning the signed copy
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+	// The address that transactions are signed for
+	Address() ethcommon.Address
+}
+
+// Signs transactions with the node wallet's keystore
+type keystoreSigner struct {
+	wallet *wallet.Wallet
+}
+
+func (s *keystoreSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	keyBytes, err := s.wallet.GetNodePrivateKeyBytes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting node wallet key: %w", err)
+	}
+	key, err := crypto.ToECDSA(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing node wallet key: %w", err)
+	}
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+}
+
+// The address of the wallet's own key, which is what SignTx signs with. That isn't the node address while the node is
+// masquerading, so the node address can't be used here. The wallet can be recovered while the daemon is running, so
+// it's read on every call; it's the zero address if no wallet is loaded.
+func (s *keystoreSigner) Address() ethcommon.Address {
+	status, err := s.wallet.GetStatus()
+	if err != nil || !status.Wallet.IsLoaded {
+		return ethcommon.Address{}
+	}
+	return status.Wallet.WalletAddress
+}
+
+// Gets the signer the node's transactions are signed with
+func (sp *ServiceProvider) GetTxSigner() Signer {
+	return sp.txSigner
+}
+
+// Gets transaction options that sign with the configured transaction signer, from its address. The context is used for
+// signing and for any requests the transaction binding makes.
+func (sp *ServiceProvider) GetSignerTransactor(ctx context.Context) *bind.TransactOpts {
+	signer := sp.txSigner
+	chainID := new(big.Int).SetUint64(uint64(sp.GetNetworkResources().ChainID))
+	from := signer.Address()
+	return &bind.TransactOpts{
+		From:    from,
+		Context: ctx,
+		Signer: func(address ethcommon.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, chainID)
+		},
+	}
+}
+
+// Signs a serialized transaction with the configured transaction signer for the network's chain, returning the signed
+// transaction in its serialized form
+func (sp *ServiceProvider) SignSerializedTransaction(ctx context.Context, serializedTx []byte) ([]byte, error) {
+	tx := new(types.Transaction)
+	err := tx.UnmarshalBinary(serializedTx)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing transaction: %w", err)
+	}
+	chainID := new(big.Int).SetUint64(uint64(sp.GetNetworkResources().ChainID))
+	signedTx, err := sp.txSigner.SignTx(ctx, tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("error signing transaction: %w", err)
+	}
+	signedBytes, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("error serializing signed transaction: %w", err)
+	}
+	return signedBytes, nil
+}
+
+// Simulates a transaction with the provided options and submits it if it wouldn't revert, paying opts.Value. The fees
+// leave room for the base fee to double before the transaction is included. The nonce in the options is used if it's
+// set; otherwise the binding picks the account's pending nonce.
+func (sp *ServiceProvider) submitTransaction(opts *bind.TransactOpts, to ethcommon.Address, data []byte) (*types.Transaction, error) {
+	ec := sp.GetEthClient()
+	txInfo := sp.GetTransactionManager().CreateTransactionInfoRaw(to, data, opts)
+	if txInfo.SimulationResult.SimulationError != "" {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionWouldRevert, txInfo.SimulationResult.SimulationError)
+	}
+
+	tip, err := ec.SuggestGasTipCap(opts.Context)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the suggested priority fee: %w", err)
+	}
+	head, err := ec.HeaderByNumber(opts.Context, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the latest block: %w", err)
+	}
+	maxFee := new(big.Int).Set(tip)
+	if head.BaseFee != nil {
+		maxFee.Add(maxFee, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	}
+	opts.GasLimit = txInfo.SimulationResult.SafeGasLimit
+	opts.GasFeeCap = maxFee
+	opts.GasTipCap = tip
+
+	tx, err := sp.GetTransactionManager().ExecuteTransaction(txInfo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error submitting transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// Picks the transaction signer selected in the config from the keystore signer and the provided custom signers
+func selectTxSigner(cfg *hdconfig.HyperdriveConfig, nodeWallet *wallet.Wallet, signers map[string]Signer) (Signer, error) {
+	name := cfg.TransactionSigner.Value
+	if name == "" || name == hdconfig.LocalTransactionSignerName {
+		return &keystoreSigner{wallet: nodeWallet}, nil
+	}
+	signer, exists := signers[name]
+	if !exists {
+		available := []string{hdconfig.LocalTransactionSignerName}
+		for name := range signers {
+			available = append(available, name)
+		}
+		sort.Strings(available[1:])
+		return nil, fmt.Errorf("%w [%s]; available signers are %s", ErrUnknownTxSigner, name, strings.Join(available, ", "))
+	}
+	return signer, nil
+}
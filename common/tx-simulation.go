@@ -0,0 +1,169 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// The JSON-RPC error code geth-based clients use for reverted calls
+	rpcExecutionRevertedCode int = 3
+)
+
+var (
+	// The transaction is expected to revert, so it wasn't submitted
+	ErrTransactionWouldRevert error = errors.New("the transaction would revert")
+)
+
+// The outcome of simulating a transaction
+type SimResult struct {
+	// True if the transaction reverted
+	Reverted bool `json:"reverted"`
+
+	// The data the transaction returned, if it succeeded
+	ReturnData hexutil.Bytes `json:"returnData,omitempty"`
+
+	// The raw revert data, if it reverted with any
+	RevertData hexutil.Bytes `json:"revertData,omitempty"`
+
+	// The decoded revert reason: the message of an Error(string), the description of a Panic(uint256), or a custom error
+	// with its arguments (like `InsufficientBalance(5, 10)`). Blank if the revert didn't have any data or it couldn't be decoded.
+	RevertReason string `json:"revertReason,omitempty"`
+}
+
+// Runs a transaction through eth_call against the primary Execution Client's pending block, without submitting it.
+// Reverts are reported in the result rather than as an error; errors mean the simulation itself couldn't be run.
+// Custom errors are decoded with the provided contract ABIs; Error(string) and Panic(uint256) are always decoded.
+func (sp *ServiceProvider) SimulateTransaction(ctx context.Context, tx *types.Transaction, from ethcommon.Address, errorAbis ...*abi.ABI) (SimResult, error) {
+	client, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return SimResult{}, err
+	}
+	defer client.Close()
+
+	returnData, err := ethclient.NewClient(client).PendingCallContract(ctx, newSimulationCallMsg(tx, from))
+	if err == nil {
+		return SimResult{
+			ReturnData: returnData,
+		}, nil
+	}
+
+	// Anything other than a revert is a problem with the client or the transaction itself
+	revertData, isRevert := getRevertData(err)
+	if !isRevert {
+		return SimResult{}, fmt.Errorf("error simulating transaction: %w", err)
+	}
+	return SimResult{
+		Reverted:     true,
+		RevertData:   revertData,
+		RevertReason: decodeRevertReason(revertData, errorAbis),
+	}, nil
+}
+
+// Creates the call for simulating a transaction from the provided sender
+func newSimulationCallMsg(tx *types.Transaction, from ethcommon.Address) ethereum.CallMsg {
+	msg := ethereum.CallMsg{
+		From:       from,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+	// Unset fees are left out so the call doesn't have to meet the base fee
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		if tx.GasPrice().Sign() > 0 {
+			msg.GasPrice = tx.GasPrice()
+		}
+	} else if tx.GasFeeCap().Sign() > 0 {
+		msg.GasFeeCap = tx.GasFeeCap()
+		msg.GasTipCap = tx.GasTipCap()
+	}
+	return msg
+}
+
+// Gets the revert data from an eth_call error, and whether the error was a revert at all.
+// Clients report the data either as a hex string or, like Hardhat, as an object with the string in its data field.
+func getRevertData(err error) ([]byte, bool) {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return nil, false
+	}
+	var revertData []byte
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		switch data := dataErr.ErrorData().(type) {
+		case string:
+			revertData, _ = hexutil.Decode(data)
+		case map[string]any:
+			if nested, ok := data["data"].(string); ok {
+				revertData, _ = hexutil.Decode(nested)
+			}
+		}
+	}
+	isRevert := rpcErr.ErrorCode() == rpcExecutionRevertedCode || len(revertData) > 0 || strings.Contains(strings.ToLower(rpcErr.Error()), "revert")
+	return revertData, isRevert
+}
+
+// Decodes revert data into a readable reason, or returns a blank string if it can't be decoded
+func decodeRevertReason(data []byte, errorAbis []*abi.ABI) string {
+	if len(data) < 4 {
+		return ""
+	}
+	reason, err := abi.UnpackRevert(data)
+	if err == nil {
+		return reason
+	}
+
+	for _, contractAbi := range errorAbis {
+		if contractAbi == nil {
+			continue
+		}
+		for _, customError := range contractAbi.Errors {
+			if !bytes.Equal(customError.ID[:4], data[:4]) {
+				continue
+			}
+			unpacked, err := customError.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			args := make([]string, len(unpacked))
+			for i, arg := range unpacked {
+				args[i] = formatRevertArg(arg)
+			}
+			return fmt.Sprintf("%s(%s)", customError.Name, strings.Join(args, ", "))
+		}
+	}
+	return ""
+}
+
+// Formats a custom error argument, using hex for byte arrays and quotes for strings
+func formatRevertArg(arg any) string {
+	switch value := arg.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case []byte:
+		return hexutil.Encode(value)
+	case fmt.Stringer:
+		return value.String()
+	}
+	value := reflect.ValueOf(arg)
+	if value.Kind() == reflect.Array && value.Type().Elem().Kind() == reflect.Uint8 {
+		raw := make([]byte, value.Len())
+		reflect.Copy(reflect.ValueOf(raw), value)
+		return hexutil.Encode(raw)
+	}
+	return fmt.Sprint(arg)
+}
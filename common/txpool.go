@@ -0,0 +1,204 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// The JSON-RPC error code for methods the client doesn't have
+	rpcMethodNotFoundCode int = -32601
+)
+
+var (
+	// The Execution Client doesn't support the txpool namespace, or has it disabled
+	ErrTxPoolUnsupported error = errors.New("the Execution Client doesn't support the txpool API, or it isn't enabled")
+)
+
+// A transaction waiting in the Execution Client's mempool
+type PooledTransaction struct {
+	Hash                 ethcommon.Hash     `json:"hash"`
+	From                 ethcommon.Address  `json:"from"`
+	To                   *ethcommon.Address `json:"to"`
+	Nonce                uint64             `json:"nonce"`
+	GasPrice             *big.Int           `json:"gasPrice"`
+	MaxFeePerGas         *big.Int           `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int           `json:"maxPriorityFeePerGas"`
+}
+
+// Gets the most the transaction will pay per gas, including the base fee
+func (tx PooledTransaction) GetFeeCap() *big.Int {
+	if tx.MaxFeePerGas != nil {
+		return tx.MaxFeePerGas
+	}
+	return tx.GasPrice
+}
+
+// The state of the Execution Client's mempool
+type TxPoolStatus struct {
+	// The number of transactions that are ready to be included
+	Pending uint64 `json:"pending"`
+
+	// The number of transactions that are waiting on an earlier nonce
+	Queued uint64 `json:"queued"`
+
+	// The node wallet's transactions that are ready to be included, in nonce order
+	OwnPending []PooledTransaction `json:"ownPending"`
+
+	// The node wallet's transactions that are waiting on an earlier nonce, in nonce order
+	OwnQueued []PooledTransaction `json:"ownQueued"`
+}
+
+// A transaction as reported by the txpool API
+type txPoolTransaction struct {
+	Hash                 ethcommon.Hash     `json:"hash"`
+	From                 ethcommon.Address  `json:"from"`
+	To                   *ethcommon.Address `json:"to"`
+	Nonce                hexutil.Uint64     `json:"nonce"`
+	GasPrice             *hexutil.Big       `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas"`
+}
+
+// Gets the number of pending and queued transactions in the primary Execution Client's mempool, along with the node wallet's
+// own transactions if the node has an address. Returns ErrTxPoolUnsupported if the client doesn't provide the txpool API.
+func (sp *ServiceProvider) GetTxPoolStatus(ctx context.Context) (TxPoolStatus, error) {
+	client, err := sp.dialPrimaryExecutionRpc(ctx)
+	if err != nil {
+		return TxPoolStatus{}, err
+	}
+	defer client.Close()
+
+	// Get the counts
+	var counts struct {
+		Pending hexutil.Uint64 `json:"pending"`
+		Queued  hexutil.Uint64 `json:"queued"`
+	}
+	err = client.CallContext(ctx, &counts, "txpool_status")
+	if err != nil {
+		return TxPoolStatus{}, getTxPoolError(err)
+	}
+	status := TxPoolStatus{
+		Pending:    uint64(counts.Pending),
+		Queued:     uint64(counts.Queued),
+		OwnPending: []PooledTransaction{},
+		OwnQueued:  []PooledTransaction{},
+	}
+	address, hasAddress := sp.GetWallet().GetAddress()
+	if !hasAddress {
+		return status, nil
+	}
+
+	// Find the node's transactions
+	content, err := getTxPoolContentFrom(ctx, client, address)
+	if err != nil {
+		return TxPoolStatus{}, err
+	}
+	status.OwnPending = getPooledTransactions(content.Pending)
+	status.OwnQueued = getPooledTransactions(content.Queued)
+	return status, nil
+}
+
+// The transactions from a single address in the txpool, keyed by nonce
+type txPoolContentFrom struct {
+	Pending map[string]txPoolTransaction `json:"pending"`
+	Queued  map[string]txPoolTransaction `json:"queued"`
+}
+
+// Gets the transactions in the txpool from an address. This uses txpool_contentFrom so the client only sends that
+// address's transactions; clients that don't have it fall back to filtering the full txpool_content.
+func getTxPoolContentFrom(ctx context.Context, client *rpc.Client, address ethcommon.Address) (txPoolContentFrom, error) {
+	var content txPoolContentFrom
+	err := client.CallContext(ctx, &content, "txpool_contentFrom", address)
+	if err == nil {
+		return content, nil
+	}
+	if !isRpcMethodNotFound(err) {
+		return txPoolContentFrom{}, getTxPoolError(err)
+	}
+
+	var fullContent struct {
+		Pending map[ethcommon.Address]map[string]txPoolTransaction `json:"pending"`
+		Queued  map[ethcommon.Address]map[string]txPoolTransaction `json:"queued"`
+	}
+	err = client.CallContext(ctx, &fullContent, "txpool_content")
+	if err != nil {
+		return txPoolContentFrom{}, getTxPoolError(err)
+	}
+	return txPoolContentFrom{
+		Pending: fullContent.Pending[address],
+		Queued:  fullContent.Queued[address],
+	}, nil
+}
+
+// Gets the node wallet's transactions that can't be included in a block as they are: queued transactions are waiting on a
+// missing nonce, and pending transactions whose fee cap is below the current base fee have to wait for it to drop.
+func (sp *ServiceProvider) GetStuckTransactions(ctx context.Context) ([]PooledTransaction, error) {
+	status, err := sp.GetTxPoolStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stuck := []PooledTransaction{}
+	if len(status.OwnPending) > 0 {
+		header, err := sp.GetEthClient().HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting latest block header: %w", err)
+		}
+		if header.BaseFee != nil {
+			for _, tx := range status.OwnPending {
+				feeCap := tx.GetFeeCap()
+				if feeCap != nil && feeCap.Cmp(header.BaseFee) < 0 {
+					stuck = append(stuck, tx)
+				}
+			}
+		}
+	}
+	return append(stuck, status.OwnQueued...), nil
+}
+
+// Opens a raw RPC connection to the primary Execution Client, for namespaces the client manager doesn't expose
+func (sp *ServiceProvider) dialPrimaryExecutionRpc(ctx context.Context) (*rpc.Client, error) {
+	primaryEcUrl, _ := sp.cfg.GetExecutionClientUrls()
+	return dialExecutionRpc(ctx, primaryEcUrl)
+}
+
+// Converts errors from txpool calls, detecting clients that don't have the API
+func getTxPoolError(err error) error {
+	if isRpcMethodNotFound(err) {
+		return ErrTxPoolUnsupported
+	}
+	return fmt.Errorf("error querying txpool: %w", err)
+}
+
+// Checks if an RPC call failed because the client doesn't have the method
+func isRpcMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFoundCode
+}
+
+// Converts a set of txpool transactions keyed by nonce into a list sorted by nonce
+func getPooledTransactions(txs map[string]txPoolTransaction) []PooledTransaction {
+	pooled := make([]PooledTransaction, 0, len(txs))
+	for _, tx := range txs {
+		pooled = append(pooled, PooledTransaction{
+			Hash:                 tx.Hash,
+			From:                 tx.From,
+			To:                   tx.To,
+			Nonce:                uint64(tx.Nonce),
+			GasPrice:             tx.GasPrice.ToInt(),
+			MaxFeePerGas:         tx.MaxFeePerGas.ToInt(),
+			MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas.ToInt(),
+		})
+	}
+	sort.Slice(pooled, func(i, j int) bool {
+		return pooled[i].Nonce < pooled[j].Nonce
+	})
+	return pooled
+}
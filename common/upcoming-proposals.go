@@ -0,0 +1,100 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// A block proposal assigned to one of the node's validators, or a marker for an epoch whose proposers aren't known yet
+type UpcomingProposal struct {
+	// The epoch of the proposal
+	Epoch uint64 `json:"epoch"`
+
+	// True if the epoch's proposers are known. If not, this is a marker for the whole epoch and only Epoch and Time are
+	// set; the Beacon Chain only determines proposers for the current and next epoch.
+	Determinable bool `json:"determinable"`
+
+	// The slot to propose in
+	Slot uint64 `json:"slot"`
+
+	// The index of the proposing validator
+	ValidatorIndex string `json:"validatorIndex"`
+
+	// The pubkey of the proposing validator
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// The estimated start of the proposal's slot, or of the epoch for markers
+	Time time.Time `json:"time"`
+}
+
+// Gets the block proposals assigned to the validators of every enabled module over the provided number of epochs,
+// starting with the current one, in slot order. Proposals in slots before the head are left out.
+// Epochs beyond the Beacon Node's proposer lookahead get a single marker that isn't determinable instead of proposals.
+func (sp *ServiceProvider) GetUpcomingProposals(ctx context.Context, epochs uint64) ([]UpcomingProposal, error) {
+	if epochs == 0 {
+		return nil, fmt.Errorf("epoch count must be at least 1")
+	}
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	genesisTime, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headSlot, _, err := bn.GetBlockSlot(ctx, "head")
+	if err != nil {
+		return nil, err
+	}
+	currentEpoch := headSlot / spec.SlotsPerEpoch
+	getSlotTime := func(slot uint64) time.Time {
+		return genesisTime.Add(time.Duration(slot*spec.SecondsPerSlot) * time.Second)
+	}
+
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ours := make(map[beacon.ValidatorPubkey]bool, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		ours[pubkey] = true
+	}
+
+	proposals := []UpcomingProposal{}
+	for epoch := currentEpoch; epoch < currentEpoch+epochs; epoch++ {
+		if epoch <= currentEpoch+1 {
+			duties, err := bn.GetProposerDuties(ctx, epoch)
+			if err == nil {
+				for _, duty := range duties {
+					pubkey := beacon.ValidatorPubkey(duty.Pubkey)
+					slot := uint64(duty.Slot)
+					if !ours[pubkey] || slot < headSlot {
+						continue
+					}
+					proposals = append(proposals, UpcomingProposal{
+						Epoch:          epoch,
+						Determinable:   true,
+						Slot:           slot,
+						ValidatorIndex: duty.ValidatorIndex,
+						Pubkey:         pubkey,
+						Time:           getSlotTime(slot),
+					})
+				}
+				continue
+			}
+			// Some Beacon Nodes can't provide the next epoch's proposers until late in the current one
+			if epoch == currentEpoch {
+				return nil, fmt.Errorf("error getting proposer duties for epoch %d: %w", epoch, err)
+			}
+		}
+		proposals = append(proposals, UpcomingProposal{
+			Epoch: epoch,
+			Time:  getSlotTime(epoch * spec.SlotsPerEpoch),
+		})
+	}
+	return proposals, nil
+}
@@ -0,0 +1,112 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// The general Beacon Chain status of a validator, used for counting the node's validators
+type ValidatorStatus string
+
+const (
+	// The validator has been deposited but isn't active yet
+	ValidatorStatus_Pending ValidatorStatus = "pending"
+
+	// The validator is active and attesting
+	ValidatorStatus_Active ValidatorStatus = "active"
+
+	// The validator is exiting or has exited without being slashed, but its balance can't be withdrawn yet
+	ValidatorStatus_Exiting ValidatorStatus = "exiting"
+
+	// The validator was slashed and is being (or has been) removed from the active set
+	ValidatorStatus_Slashed ValidatorStatus = "slashed"
+
+	// The validator's balance can be withdrawn
+	ValidatorStatus_Withdrawable ValidatorStatus = "withdrawable"
+
+	// The validator's balance has been fully withdrawn
+	ValidatorStatus_Withdrawn ValidatorStatus = "withdrawn"
+)
+
+// Counts the validators of every enabled module on the node by their Beacon Chain status.
+// Validators that aren't on the Beacon Chain yet aren't counted.
+func (sp *ServiceProvider) CountValidatorsByStatus(ctx context.Context) (map[ValidatorStatus]int, error) {
+	counts := map[ValidatorStatus]int{
+		ValidatorStatus_Pending:      0,
+		ValidatorStatus_Active:       0,
+		ValidatorStatus_Exiting:      0,
+		ValidatorStatus_Slashed:      0,
+		ValidatorStatus_Withdrawable: 0,
+		ValidatorStatus_Withdrawn:    0,
+	}
+
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pubkeys) == 0 {
+		return counts, nil
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+
+	// Look up all of them at once so large sets are batched
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator statuses: %w", err)
+	}
+	for _, validator := range validators {
+		status, exists := getValidatorStatus(beacon.ValidatorState(validator.Status))
+		if exists {
+			counts[status]++
+		}
+	}
+	return counts, nil
+}
+
+// Gets the general status for a validator state. Returns false if the state isn't recognized.
+func getValidatorStatus(state beacon.ValidatorState) (ValidatorStatus, bool) {
+	switch state {
+	case beacon.ValidatorState_PendingInitialized, beacon.ValidatorState_PendingQueued:
+		return ValidatorStatus_Pending, true
+	case beacon.ValidatorState_ActiveOngoing:
+		return ValidatorStatus_Active, true
+	case beacon.ValidatorState_ActiveExiting, beacon.ValidatorState_ExitedUnslashed:
+		return ValidatorStatus_Exiting, true
+	case beacon.ValidatorState_ActiveSlashed, beacon.ValidatorState_ExitedSlashed:
+		return ValidatorStatus_Slashed, true
+	case beacon.ValidatorState_WithdrawalPossible:
+		return ValidatorStatus_Withdrawable, true
+	case beacon.ValidatorState_WithdrawalDone:
+		return ValidatorStatus_Withdrawn, true
+	default:
+		return "", false
+	}
+}
+
+// Gets the validators of every enabled module on the node, without duplicates
+func (sp *ServiceProvider) getModuleValidatorPubkeys(ctx context.Context) ([]beacon.ValidatorPubkey, error) {
+	pubkeys := []beacon.ValidatorPubkey{}
+	seen := map[beacon.ValidatorPubkey]bool{}
+	for _, contributor := range sp.getStakeContributors() {
+		if !contributor.IsEnabled() {
+			continue
+		}
+		modulePubkeys, err := contributor.GetValidatorPubkeys(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting validators for module [%s]: %w", contributor.GetModuleName(), err)
+		}
+		for _, pubkey := range modulePubkeys {
+			if seen[pubkey] {
+				continue
+			}
+			seen[pubkey] = true
+			pubkeys = append(pubkeys, pubkey)
+		}
+	}
+	return pubkeys, nil
+}
@@ -0,0 +1,41 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// Gets the Beacon Chain index of the validator with the provided pubkey. Returns false if the validator isn't in the
+// head state yet, because its deposit hasn't been processed.
+// A validator's index never changes once it's assigned, so found indices are cached for the life of the daemon; ones
+// that weren't found are looked up again on the next call.
+func (sp *ServiceProvider) GetValidatorIndex(ctx context.Context, pubkey beacon.ValidatorPubkey) (uint64, bool, error) {
+	sp.validatorIndexLock.Lock()
+	index, exists := sp.validatorIndices[pubkey]
+	sp.validatorIndexLock.Unlock()
+	if exists {
+		return index, true, nil
+	}
+
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, "head", []string{pubkey.HexWithPrefix()}, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("error getting index of validator %s: %w", pubkey.HexWithPrefix(), err)
+	}
+	for _, validator := range validators {
+		if beacon.ValidatorPubkey(validator.Validator.Pubkey) != pubkey {
+			continue
+		}
+		index, err := strconv.ParseUint(validator.Index, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("validator %s has an invalid index [%s]: %w", pubkey.HexWithPrefix(), validator.Index, err)
+		}
+		sp.validatorIndexLock.Lock()
+		sp.validatorIndices[pubkey] = index
+		sp.validatorIndexLock.Unlock()
+		return index, true, nil
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,126 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// How much each kind of underperformance counts towards a validator's score when ranking them
+type PerformanceWeights struct {
+	// The penalty for missing every attestation in the window; it's scaled by the fraction of attestations that were missed
+	MissedAttestations float64 `json:"missedAttestations"`
+
+	// The penalty for each slot of average inclusion distance beyond the best possible distance of 1
+	InclusionDistance float64 `json:"inclusionDistance"`
+}
+
+var (
+	// The default ranking weights. A missed attestation loses all of its rewards while a late one loses some of them,
+	// so a single slot of delay counts for much less than a miss.
+	DefaultPerformanceWeights PerformanceWeights = PerformanceWeights{
+		MissedAttestations: 1,
+		InclusionDistance:  0.25,
+	}
+)
+
+// A validator's place in the node's performance ranking
+type ValidatorRank struct {
+	// The validator's pubkey
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+
+	// The validator's index, if it exists on the Beacon Chain
+	Index string `json:"index"`
+
+	// The validator's position in the ranking, starting at 1 for the worst performer. Validators that aren't ranked have
+	// a rank of 0.
+	Rank int `json:"rank"`
+
+	// The validator's weighted penalty over the window; higher is worse, and 0 is a perfect record
+	Score float64 `json:"score"`
+
+	// True if the validator was activated after the window started (or hasn't been activated yet), so it's left out of the
+	// ranking since it wasn't around for the whole window
+	RecentlyActivated bool `json:"recentlyActivated"`
+
+	// The attestation performance the score is based on
+	Stats EffectivenessStats `json:"stats"`
+}
+
+// Ranks the validators of every enabled module by their attestation performance over the last N completed epochs,
+// worst first. Validators activated partway through the window, and ones without any attestation duties in it, come
+// after the ranked validators with a rank of 0.
+func (sp *ServiceProvider) RankValidatorPerformance(ctx context.Context, epochs uint64, weights PerformanceWeights) ([]ValidatorRank, error) {
+	if weights.MissedAttestations < 0 || weights.InclusionDistance < 0 {
+		return nil, fmt.Errorf("performance weights can't be negative")
+	}
+	pubkeys, err := sp.getModuleValidatorPubkeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pubkeys) == 0 {
+		return []ValidatorRank{}, nil
+	}
+
+	stats, err := sp.GetAttestationEffectiveness(ctx, pubkeys, epochs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ids[i] = pubkey.HexWithPrefix()
+	}
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, "head", ids, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator activation epochs: %w", err)
+	}
+	activationEpochs := map[beacon.ValidatorPubkey]uint64{}
+	for _, validator := range validators {
+		activationEpochs[beacon.ValidatorPubkey(validator.Validator.Pubkey)] = uint64(validator.Validator.ActivationEpoch)
+	}
+
+	ranked := []ValidatorRank{}
+	unranked := []ValidatorRank{}
+	for _, pubkey := range pubkeys {
+		validatorStats := stats[pubkey]
+		rank := ValidatorRank{
+			Pubkey: pubkey,
+			Index:  validatorStats.Index,
+			Stats:  validatorStats,
+		}
+		activationEpoch, exists := activationEpochs[pubkey]
+		rank.RecentlyActivated = !exists || activationEpoch > validatorStats.StartEpoch
+		if rank.RecentlyActivated || validatorStats.ExpectedAttestations == 0 {
+			unranked = append(unranked, rank)
+			continue
+		}
+		rank.Score = getPerformanceScore(validatorStats, weights)
+		ranked = append(ranked, rank)
+	}
+
+	// Ties are broken by pubkey so the order is stable
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Pubkey.Hex() < ranked[j].Pubkey.Hex()
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	sort.Slice(unranked, func(i, j int) bool {
+		return unranked[i].Pubkey.Hex() < unranked[j].Pubkey.Hex()
+	})
+	return append(ranked, unranked...), nil
+}
+
+// Gets a validator's weighted penalty from its attestation performance
+func getPerformanceScore(stats EffectivenessStats, weights PerformanceWeights) float64 {
+	score := weights.MissedAttestations * float64(stats.MissedAttestations) / float64(stats.ExpectedAttestations)
+	if stats.IncludedAttestations > 0 {
+		score += weights.InclusionDistance * (stats.AverageInclusionDistance - 1)
+	}
+	return score
+}
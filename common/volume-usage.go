@@ -0,0 +1,121 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+)
+
+const (
+	// A volume is considered nearly full once its filesystem has less than this fraction of its space available
+	volumeNearlyFullFraction float64 = 0.1
+)
+
+// Disk usage for a client's data volume
+type VolumeUsage struct {
+	// The full name of the Docker volume
+	Name string `json:"name"`
+
+	// True if the volume is bound to a directory on the host instead of being managed by Docker
+	IsBindMount bool `json:"isBindMount"`
+
+	// The host path of the volume's data
+	Path string `json:"path"`
+
+	// The number of bytes used. For managed volumes this is the size of the volume itself; Docker doesn't track the size
+	// of bind mounts, so for those it's the used space of the filesystem that contains the directory.
+	UsedBytes uint64 `json:"usedBytes"`
+
+	// The number of bytes still available on the volume's filesystem
+	AvailableBytes uint64 `json:"availableBytes"`
+
+	// The total size of the volume's filesystem
+	TotalBytes uint64 `json:"totalBytes"`
+
+	// True if the filesystem containing the volume could be read; if not, only the used bytes are known
+	HasFilesystemInfo bool `json:"hasFilesystemInfo"`
+
+	// True if the volume's filesystem is nearly out of space
+	NearlyFull bool `json:"nearlyFull"`
+}
+
+// Gets the disk usage of each locally managed client's data volume, keyed by volume name (without the project prefix).
+// Returns an empty map if the node uses externally managed clients.
+func (sp *ServiceProvider) GetVolumeUsage(ctx context.Context) (map[string]VolumeUsage, error) {
+	usages := map[string]VolumeUsage{}
+	if !sp.cfg.IsLocalMode() {
+		return usages, nil
+	}
+
+	// Get the volume info from Docker
+	d := sp.GetDocker()
+	diskUsage, err := d.DiskUsage(ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.VolumeObject},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting Docker disk usage: %w", err)
+	}
+	volumes := map[string]*volume.Volume{}
+	for _, vol := range diskUsage.Volumes {
+		volumes[vol.Name] = vol
+	}
+
+	for _, name := range []string{hdconfig.ExecutionClientDataVolume, hdconfig.BeaconNodeDataVolume} {
+		fullName := sp.cfg.GetDockerArtifactName(name)
+		vol, exists := volumes[fullName]
+		if !exists {
+			// The client hasn't been started yet
+			continue
+		}
+		usages[name] = getVolumeUsage(vol)
+	}
+	return usages, nil
+}
+
+// Gets the usage of a single Docker volume
+func getVolumeUsage(vol *volume.Volume) VolumeUsage {
+	usage := VolumeUsage{
+		Name: vol.Name,
+		Path: vol.Mountpoint,
+	}
+
+	device := getBindMountDevice(vol)
+	if device != "" {
+		usage.IsBindMount = true
+		usage.Path = device
+	}
+	if !usage.IsBindMount && vol.UsageData != nil && vol.UsageData.Size >= 0 {
+		usage.UsedBytes = uint64(vol.UsageData.Size)
+	}
+
+	// Get the filesystem's capacity; this won't work if the path isn't visible to the daemon
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(usage.Path, &stat)
+	if err != nil {
+		return usage
+	}
+	blockSize := uint64(stat.Bsize)
+	usage.HasFilesystemInfo = true
+	usage.TotalBytes = uint64(stat.Blocks) * blockSize
+	usage.AvailableBytes = uint64(stat.Bavail) * blockSize
+	if usage.IsBindMount {
+		usage.UsedBytes = (uint64(stat.Blocks) - uint64(stat.Bfree)) * blockSize
+	}
+	usage.NearlyFull = usage.TotalBytes > 0 && float64(usage.AvailableBytes) < float64(usage.TotalBytes)*volumeNearlyFullFraction
+	return usage
+}
+
+// Gets the host directory a volume is bound to, or an empty string if it's managed by Docker.
+// Local volumes created with the bind option point to a host directory.
+func getBindMountDevice(vol *volume.Volume) string {
+	device := vol.Options["device"]
+	if vol.Driver == "local" && device != "" && strings.Contains(vol.Options["o"], "bind") {
+		return device
+	}
+	return ""
+}
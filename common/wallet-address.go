@@ -0,0 +1,29 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// The loaded wallet isn't the one the node is expected to have
+	ErrWalletAddressMismatch error = errors.New("the loaded wallet doesn't have the expected address")
+)
+
+// Checks that the loaded node wallet has the expected address, to catch a mnemonic or derivation path that was restored
+// incorrectly. Returns ErrWalletAddressMismatch with both addresses if it doesn't.
+func (sp *ServiceProvider) VerifyWalletAddress(expected common.Address) error {
+	status, err := sp.GetWallet().GetStatus()
+	if err != nil {
+		return fmt.Errorf("error getting wallet status: %w", err)
+	}
+	if !status.Wallet.IsLoaded {
+		return errors.New("the node doesn't have a wallet loaded")
+	}
+	if status.Wallet.WalletAddress != expected {
+		return fmt.Errorf("%w: wallet address is %s, expected %s", ErrWalletAddressMismatch, status.Wallet.WalletAddress.Hex(), expected.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// The validator hasn't started exiting, so it doesn't have a withdrawable epoch yet
+	ErrValidatorNotExiting error = errors.New("the validator hasn't started exiting")
+)
+
+// Gets the epoch an exiting validator's balance becomes withdrawable, and when that epoch starts. The epoch comes from
+// the validator's withdrawable_epoch on the head state; if the Beacon Node hasn't set it, it's estimated as
+// MIN_VALIDATOR_WITHDRAWABILITY_DELAY epochs after the validator's exit epoch, which is the earliest it can be.
+// Withdrawable balances are paid out by the withdrawal sweep, so the funds arrive some time after this.
+// Returns an error wrapping ErrValidatorNotExiting if the validator hasn't started exiting.
+func (sp *ServiceProvider) GetWithdrawableEpoch(ctx context.Context, pubkey beacon.ValidatorPubkey) (uint64, time.Time, error) {
+	bn := sp.GetBeaconApiClient()
+	spec, err := sp.GetBeaconSpec(ctx)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	validators, err := bn.GetValidators(ctx, "head", []string{pubkey.HexWithPrefix()}, nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if len(validators) == 0 {
+		return 0, time.Time{}, fmt.Errorf("%w: %s", ErrValidatorNotFound, pubkey.HexWithPrefix())
+	}
+	validator := validators[0]
+	exitEpoch := uint64(validator.Validator.ExitEpoch)
+	if exitEpoch == FarFutureEpoch {
+		return 0, time.Time{}, fmt.Errorf("%w: %s is %s", ErrValidatorNotExiting, pubkey.HexWithPrefix(), validator.Status)
+	}
+
+	// Slashed validators have a longer delay, so the state's epoch can be later than the minimum but never earlier
+	withdrawableEpoch := uint64(validator.Validator.WithdrawableEpoch)
+	minWithdrawableEpoch := exitEpoch + spec.MinValidatorWithdrawabilityDelay
+	if withdrawableEpoch == FarFutureEpoch || withdrawableEpoch < minWithdrawableEpoch {
+		withdrawableEpoch = minWithdrawableEpoch
+	}
+
+	genesisTime, err := sp.GetGenesisTime(ctx)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	withdrawableTime := genesisTime.Add(time.Duration(withdrawableEpoch*spec.SlotsPerEpoch*spec.SecondsPerSlot) * time.Second)
+	return withdrawableEpoch, withdrawableTime, nil
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// Partial withdrawals are only processed for validators with compounding credentials; the others can only fully exit
+	ErrPartialWithdrawalNotCompounding error = errors.New("partial withdrawals are only available to validators with compounding withdrawal credentials")
+)
+
+// Submits a withdrawal request for one of the node's validators to the EIP-7002 system contract, signed with the
+// node's transaction signer.
+// The amount is in gwei; an amount of 0 requests a full exit instead of a partial withdrawal. Partial withdrawals only
+// come out of the balance above the validator's minimum activation balance, so the Beacon Chain may withdraw less than
+// requested.
+// The validator must be active with 0x01 or 0x02 withdrawal credentials pointing to the node's address, and only 0x02
+// validators can make partial withdrawals. Returns the hash of the submitted transaction.
+func (sp *ServiceProvider) SubmitWithdrawalRequest(ctx context.Context, pubkey beacon.ValidatorPubkey, amountGwei uint64) (ethcommon.Hash, error) {
+	err := sp.RequireWalletReady()
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	electraActive, err := sp.isElectraActive(ctx)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	if !electraActive {
+		return ethcommon.Hash{}, fmt.Errorf("%w: withdrawal requests are only available from Electra onwards", ErrElectraNotActive)
+	}
+
+	validator, err := sp.getNodeValidator(ctx, pubkey)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	if amountGwei > 0 && validator.CredentialType != WithdrawalCredentialType_Compounding {
+		return ethcommon.Hash{}, fmt.Errorf("%w: %s has type 0x%02x credentials", ErrPartialWithdrawalNotCompounding, pubkey.HexWithPrefix(), byte(validator.CredentialType))
+	}
+
+	// The request is the validator's pubkey followed by the amount as a big-endian uint64
+	data := make([]byte, 0, beacon.ValidatorPubkeyLength+8)
+	data = append(data, pubkey[:]...)
+	data = binary.BigEndian.AppendUint64(data, amountGwei)
+	return sp.submitExecutionRequest(ctx, WithdrawalRequestPredeployAddress, data)
+}
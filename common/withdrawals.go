@@ -0,0 +1,231 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// The number of blocks to fetch at once when scanning for withdrawals
+	withdrawalScanChunkSize uint64 = 32
+)
+
+// The kind of balance a withdrawal took from its validator
+type WithdrawalType string
+
+const (
+	// The withdrawal couldn't be classified, usually because the validator's balance wasn't available
+	WithdrawalType_Unknown WithdrawalType = "unknown"
+
+	// A withdrawal of the balance above the validator's maximum effective balance, or a partial withdrawal the
+	// validator requested; the validator keeps staking
+	WithdrawalType_Partial WithdrawalType = "partial"
+
+	// A withdrawal of the validator's entire balance after it exited
+	WithdrawalType_FullExit WithdrawalType = "fullExit"
+)
+
+// An automatic withdrawal from the Beacon Chain to a validator's withdrawal address
+type Withdrawal struct {
+	// The slot of the block that included the withdrawal
+	Slot uint64 `json:"slot"`
+
+	// The global index of the withdrawal
+	Index uint64 `json:"index"`
+
+	// The index of the validator being withdrawn from
+	ValidatorIndex uint64 `json:"validatorIndex"`
+
+	// The address the withdrawal was sent to
+	Address ethcommon.Address `json:"address"`
+
+	// The amount withdrawn, in gwei
+	Amount uint64 `json:"amount"`
+
+	// Whether the withdrawal was a partial withdrawal or the validator's full exit
+	Type WithdrawalType `json:"type"`
+}
+
+// Gets the amount of the withdrawal in wei
+func (w Withdrawal) GetAmountWei() *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(w.Amount), big.NewInt(1e9))
+}
+
+// Classifies a withdrawal by how much of the validator's balance (in gwei, just before the withdrawal) it left behind.
+// A full exit sweeps the whole balance. Partial withdrawals never take a validator below MIN_ACTIVATION_BALANCE: the
+// automatic sweep leaves 32 ETH (or the 2048 ETH maximum effective balance of a compounding validator), and requested
+// partial withdrawals are capped so at least 32 ETH remains. Anything in between is inconsistent with the balance and is
+// left unknown.
+func ClassifyWithdrawal(w Withdrawal, validatorBalance uint64) WithdrawalType {
+	if w.Amount == 0 || w.Amount > validatorBalance {
+		return WithdrawalType_Unknown
+	}
+	remaining := validatorBalance - w.Amount
+	switch {
+	case remaining == 0:
+		return WithdrawalType_FullExit
+	case remaining >= defaultMinActivationBalance:
+		return WithdrawalType_Partial
+	default:
+		return WithdrawalType_Unknown
+	}
+}
+
+// Returned when some of the blocks in a withdrawal scan couldn't be retrieved
+type WithdrawalScanError struct {
+	// The slots that couldn't be retrieved
+	FailedSlots []uint64
+
+	// The error for the first failed slot
+	Err error
+}
+
+func (e *WithdrawalScanError) Error() string {
+	return fmt.Sprintf("error getting withdrawals for %d slots (first failure at slot %d): %s", len(e.FailedSlots), e.FailedSlots[0], e.Err.Error())
+}
+
+func (e *WithdrawalScanError) Unwrap() error {
+	return e.Err
+}
+
+// Gets the withdrawals for the provided validators in blocks between fromSlot and toSlot (inclusive), in slot order.
+// Each withdrawal is classified from the validator's balance in the state of its block, and left unknown if that state
+// isn't available (e.g. because the Beacon Node has pruned it).
+// Blocks are fetched a chunk at a time. If some blocks can't be retrieved, the withdrawals from the rest are still returned
+// along with a *WithdrawalScanError listing the failed slots; if the context is cancelled, the withdrawals found so far are
+// returned along with the context's error.
+func (sp *ServiceProvider) GetWithdrawals(ctx context.Context, fromSlot uint64, toSlot uint64, validatorIndices []uint64) ([]Withdrawal, error) {
+	if fromSlot > toSlot {
+		return nil, fmt.Errorf("start slot %d is after end slot %d", fromSlot, toSlot)
+	}
+	indices := map[uint64]bool{}
+	for _, index := range validatorIndices {
+		indices[index] = true
+	}
+
+	withdrawals := []Withdrawal{}
+	var scanErr *WithdrawalScanError
+	for chunkStart := fromSlot; chunkStart <= toSlot; chunkStart += withdrawalScanChunkSize {
+		if ctx.Err() != nil {
+			return withdrawals, ctx.Err()
+		}
+		chunkEnd := min(chunkStart+withdrawalScanChunkSize-1, toSlot)
+		chunkWithdrawals, chunkErr := sp.getChunkWithdrawals(ctx, chunkStart, chunkEnd, indices)
+		withdrawals = append(withdrawals, chunkWithdrawals...)
+		if chunkErr != nil {
+			if scanErr == nil {
+				scanErr = chunkErr
+			} else {
+				scanErr.FailedSlots = append(scanErr.FailedSlots, chunkErr.FailedSlots...)
+			}
+		}
+
+		// Don't overflow if the range ends at the last slot
+		if chunkEnd == toSlot {
+			break
+		}
+	}
+
+	if ctx.Err() != nil {
+		return withdrawals, ctx.Err()
+	}
+	if scanErr != nil {
+		return withdrawals, scanErr
+	}
+	return withdrawals, nil
+}
+
+// Gets the matching withdrawals from each block in a chunk of slots
+func (sp *ServiceProvider) getChunkWithdrawals(ctx context.Context, fromSlot uint64, toSlot uint64, indices map[uint64]bool) ([]Withdrawal, *WithdrawalScanError) {
+	bn := sp.GetBeaconApiClient()
+	count := toSlot - fromSlot + 1
+	results := make([][]Withdrawal, count)
+	errs := make([]error, count)
+
+	// Fetch the blocks in parallel
+	wg := &sync.WaitGroup{}
+	for i := uint64(0); i < count; i++ {
+		wg.Add(1)
+		go func(i uint64) {
+			defer wg.Done()
+			slot := fromSlot + i
+			blockWithdrawals, _, err := bn.GetBlockWithdrawals(ctx, strconv.FormatUint(slot, 10))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			for _, withdrawal := range blockWithdrawals {
+				if !indices[uint64(withdrawal.ValidatorIndex)] {
+					continue
+				}
+				results[i] = append(results[i], Withdrawal{
+					Slot:           slot,
+					Index:          uint64(withdrawal.Index),
+					ValidatorIndex: uint64(withdrawal.ValidatorIndex),
+					Address:        ethcommon.BytesToAddress(withdrawal.Address),
+					Amount:         uint64(withdrawal.Amount),
+					Type:           WithdrawalType_Unknown,
+				})
+			}
+			if len(results[i]) > 0 {
+				sp.classifyBlockWithdrawals(ctx, slot, results[i])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Combine them in slot order
+	withdrawals := []Withdrawal{}
+	var scanErr *WithdrawalScanError
+	for i := uint64(0); i < count; i++ {
+		if errs[i] != nil {
+			if scanErr == nil {
+				scanErr = &WithdrawalScanError{Err: errs[i]}
+			}
+			scanErr.FailedSlots = append(scanErr.FailedSlots, fromSlot+i)
+			continue
+		}
+		withdrawals = append(withdrawals, results[i]...)
+	}
+	return withdrawals, scanErr
+}
+
+// Classifies the withdrawals from a block using the validators' balances in the block's state. That's the balance after
+// the withdrawals, so each one's starting balance is rebuilt by adding back the amounts withdrawn from then on.
+func (sp *ServiceProvider) classifyBlockWithdrawals(ctx context.Context, slot uint64, withdrawals []Withdrawal) {
+	ids := []string{}
+	for _, withdrawal := range withdrawals {
+		id := strconv.FormatUint(withdrawal.ValidatorIndex, 10)
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	validators, err := sp.GetBeaconApiClient().GetValidators(ctx, strconv.FormatUint(slot, 10), ids, nil)
+	if err != nil {
+		return
+	}
+	balances := map[uint64]uint64{}
+	for _, validator := range validators {
+		index, err := strconv.ParseUint(validator.Index, 10, 64)
+		if err != nil {
+			continue
+		}
+		balances[index] = uint64(validator.Balance)
+	}
+
+	for i := len(withdrawals) - 1; i >= 0; i-- {
+		balance, exists := balances[withdrawals[i].ValidatorIndex]
+		if !exists {
+			continue
+		}
+		balance += withdrawals[i].Amount
+		balances[withdrawals[i].ValidatorIndex] = balance
+		withdrawals[i].Type = ClassifyWithdrawal(withdrawals[i], balance)
+	}
+}
@@ -1,13 +1,38 @@
 package api_test
 
 import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
 	dtypes "github.com/docker/docker/api/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
 	"github.com/nodeset-org/hyperdrive-daemon/shared"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	hdtesting "github.com/nodeset-org/hyperdrive-daemon/testing"
 	"github.com/nodeset-org/osha"
+	"github.com/nodeset-org/osha/keys"
+	prdeposit "github.com/prysmaticlabs/prysm/v5/contracts/deposit"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	bclient "github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/rocket-pool/node-manager-core/beacon/ssz_types"
+	"github.com/rocket-pool/node-manager-core/eth"
+	"github.com/rocket-pool/node-manager-core/wallet"
 	"github.com/stretchr/testify/require"
 )
 
@@ -110,6 +135,99 @@ func TestRestartContainer(t *testing.T) {
 	t.Logf("VC restart was successful - original start = %s, new start = %s", oneMinuteAgoStr, vc.State.StartedAt)
 }
 
+// Test that a snapshot taken around a destructive operation can undo it
+func TestWithAutoSnapshot(t *testing.T) {
+	defer service_cleanup("")
+
+	sp := testMgr.GetServiceProvider()
+	cfg := sp.GetConfig()
+	ctx := sp.GetBaseContext()
+	docker := testMgr.GetDockerMockManager()
+	fullName := cfg.GetDockerArtifactName("mock_ec")
+	nowStr := time.Now().Format(time.RFC3339Nano)
+
+	// Pretend to swap the EC by creating a new container
+	snapshotID, err := testMgr.WithAutoSnapshot(func() error {
+		return docker.Mock_AddContainer(dtypes.ContainerJSON{
+			ContainerJSONBase: &dtypes.ContainerJSONBase{
+				Name:    fullName,
+				Created: nowStr,
+				State: &dtypes.ContainerState{
+					Running:   true,
+					StartedAt: nowStr,
+				},
+			},
+		})
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, snapshotID)
+	_, err = docker.ContainerInspect(ctx, fullName)
+	require.NoError(t, err)
+	t.Logf("Created mock EC after taking snapshot %s", snapshotID)
+
+	// Roll it back
+	err = testMgr.RevertToSnapshot(snapshotID)
+	require.NoError(t, err)
+	_, err = docker.ContainerInspect(ctx, fullName)
+	require.Error(t, err)
+	t.Log("Reverting the snapshot removed the mock EC")
+}
+
+// Test labeling snapshots and listing them with the chain state they captured
+func TestNamedSnapshots(t *testing.T) {
+	defer service_cleanup("")
+
+	first, err := testMgr.CreateNamedSnapshot("before blocks")
+	require.NoError(t, err)
+	require.NoError(t, testMgr.CommitBlock())
+	require.NoError(t, testMgr.CommitBlock())
+	second, err := testMgr.CreateNamedSnapshot("after blocks")
+	require.NoError(t, err)
+
+	// Labels can't be reused
+	_, err = testMgr.CreateNamedSnapshot("before blocks")
+	require.ErrorContains(t, err, "already exists")
+	_, err = testMgr.CreateNamedSnapshot(hdtesting.BaselineSnapshotLabel)
+	require.Error(t, err)
+
+	// The baseline is only listed when asked for
+	snapshots := testMgr.ListSnapshots()
+	require.Len(t, snapshots, 2)
+	require.Equal(t, first, snapshots[0].ID)
+	require.Equal(t, "before blocks", snapshots[0].Label)
+	require.Equal(t, second, snapshots[1].ID)
+	require.Equal(t, snapshots[0].Block+2, snapshots[1].Block)
+	require.Equal(t, snapshots[0].Slot+2, snapshots[1].Slot)
+	require.False(t, snapshots[1].CreatedAt.Before(snapshots[0].CreatedAt))
+	all := testMgr.ListAllSnapshots()
+	require.Len(t, all, 3)
+	require.Equal(t, hdtesting.BaselineSnapshotLabel, all[0].Label)
+	t.Logf("Snapshots: %+v", all)
+
+	// Reverting to the first one discards both
+	err = testMgr.RevertToSnapshot(first)
+	require.NoError(t, err)
+	require.Empty(t, testMgr.ListSnapshots())
+}
+
+// Test that the daemon serves its RPC latency metrics for Prometheus to scrape
+func TestMetrics(t *testing.T) {
+	defer service_cleanup("")
+
+	// Make a Beacon API call so there's at least one latency to report; it's timed whether or not the node answers
+	sp := testMgr.GetServiceProvider()
+	_, _ = sp.GetBeaconApiClient().GetGenesis(sp.GetBaseContext())
+
+	url := fmt.Sprintf("http://localhost:%d%s", testMgr.GetServerManager().GetPort(), config.HyperdriveMetricsPath)
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "hyperdrive_rpc_latency_seconds")
+}
+
 func service_cleanup(snapshotName string) {
 	// Handle panics
 	r := recover()
@@ -126,3 +244,551 @@ func service_cleanup(snapshotName string) {
 		}
 	}
 }
+
+// Test resolving timestamps to blocks on the Hardhat chain, which mines a block every slot
+func TestGetBlockByTimestamp(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+	require.NoError(t, testMgr.AdvanceSlots(10, true))
+
+	ctx := context.Background()
+	ec := testMgr.GetExecutionClient()
+	sp := testMgr.GetServiceProvider()
+	head, err := ec.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	number := head.Number.Uint64() - 5
+	header, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	require.NoError(t, err)
+	blockTime := time.Unix(int64(header.Time), 0)
+
+	// Exact matches resolve to the block, and anything after it resolves to the next one
+	block, err := sp.GetBlockByTimestamp(ctx, blockTime)
+	require.NoError(t, err)
+	require.Equal(t, number, block)
+	block, err = sp.GetBlockByTimestamp(ctx, blockTime.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, number+1, block)
+	block, err = sp.GetBlockByTimestamp(ctx, time.Unix(int64(head.Time), 0))
+	require.NoError(t, err)
+	require.Equal(t, head.Number.Uint64(), block)
+
+	// Timestamps outside of the chain are rejected
+	genesis, err := ec.HeaderByNumber(ctx, big.NewInt(0))
+	require.NoError(t, err)
+	_, err = sp.GetBlockByTimestamp(ctx, time.Unix(int64(genesis.Time)-1, 0))
+	var beforeErr *common.TimestampBeforeGenesisError
+	require.ErrorAs(t, err, &beforeErr)
+	_, err = sp.GetBlockByTimestamp(ctx, time.Unix(int64(head.Time)+1, 0))
+	var afterErr *common.TimestampAfterHeadError
+	require.ErrorAs(t, err, &afterErr)
+	require.Equal(t, head.Number.Uint64(), afterErr.HeadBlock)
+	t.Logf("Block %d resolved from timestamp %s", number, blockTime)
+}
+
+// Test verifying contracts deployed to Hardhat, one implementing version() and one that reverts every call
+func TestVerifyContract(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+
+	// Returns 1 for version() and reverts anything else
+	correctAddress := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	correctCode := "0x60003560e01c6354fd4d5014601357600080fd5b600160005260206000f3"
+	wrongAddress := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de2")
+	wrongCode := "0x600080fd"
+	rpcClient := testMgr.GetHardhatRpcClient()
+	require.NoError(t, rpcClient.Call(nil, "hardhat_setCode", correctAddress, correctCode))
+	require.NoError(t, rpcClient.Call(nil, "hardhat_setCode", wrongAddress, wrongCode))
+
+	ctx := context.Background()
+	sp := testMgr.GetServiceProvider()
+	version := [4]byte{0x54, 0xfd, 0x4d, 0x50}
+	require.NoError(t, sp.VerifyContract(ctx, correctAddress, [][4]byte{version}))
+	err = sp.VerifyContract(ctx, wrongAddress, [][4]byte{version})
+	require.ErrorIs(t, err, common.ErrContractMismatch)
+	err = sp.VerifyContract(ctx, ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de3"), [][4]byte{version})
+	require.ErrorIs(t, err, common.ErrContractMismatch)
+	t.Logf("Wrong contract: %s", err.Error())
+}
+
+// Test that Hardhat's chain ID matches the local test network
+func TestVerifyExecutionChainId(t *testing.T) {
+	sp := testMgr.GetServiceProvider()
+	err := sp.VerifyExecutionChainId(context.Background())
+	require.NoError(t, err)
+}
+
+// Test simulating a transaction against a Hardhat contract that always reverts with a reason
+func TestSimulateTransaction(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+
+	// Copies an Error("not allowed") revert out of its own code and reverts with it
+	revertingAddress := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de4")
+	revertingCode := "0x6064600c60003960646000fd08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000b6e6f7420616c6c6f776564000000000000000000000000000000000000000000"
+	rpcClient := testMgr.GetHardhatRpcClient()
+	require.NoError(t, rpcClient.Call(nil, "hardhat_setCode", revertingAddress, revertingCode))
+
+	// Hardhat's first default account
+	from := ethcommon.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		To:    &revertingAddress,
+		Value: big.NewInt(0),
+		Gas:   100000,
+	})
+	sp := testMgr.GetServiceProvider()
+	result, err := sp.SimulateTransaction(context.Background(), tx, from)
+	require.NoError(t, err)
+	require.True(t, result.Reverted)
+	require.Equal(t, "not allowed", result.RevertReason)
+
+	// Plain transfers to an account go through
+	to := ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	tx = types.NewTx(&types.DynamicFeeTx{
+		To:    &to,
+		Value: big.NewInt(1),
+		Gas:   21000,
+	})
+	result, err = sp.SimulateTransaction(context.Background(), tx, from)
+	require.NoError(t, err)
+	require.False(t, result.Reverted)
+}
+
+// Test submitting withdrawal requests to a mock of the EIP-7002 contract on Hardhat
+func TestSubmitWithdrawalRequest(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients | osha.Service_Filesystem)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+	rpcClient := testMgr.GetHardhatRpcClient()
+	require.NoError(t, rpcClient.Call(nil, "hardhat_setCode", common.WithdrawalRequestPredeployAddress, getMockRequestContractCode(56)))
+	require.NoError(t, testMgr.CommitBlock())
+
+	derivationPath := string(wallet.DerivationPath_Default)
+	index := uint64(0)
+	_, err = testMgr.GetApiClient().Wallet.Recover(&derivationPath, keys.DefaultMnemonic, &index, goodPassword, true)
+	require.NoError(t, err)
+
+	// The first withdraws to the node, the second to someone else
+	compounding := newElectraTestValidator(0, 0x02, expectedWalletAddress)
+	other := newElectraTestValidator(1, 0x02, ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"))
+	sp := newElectraTestServiceProvider(t, compounding, other)
+	ctx := context.Background()
+
+	pubkey := beacon.ValidatorPubkey(compounding.Validator.Pubkey)
+	hash, err := sp.SubmitWithdrawalRequest(ctx, pubkey, 1e9)
+	require.NoError(t, err)
+	require.NoError(t, testMgr.CommitBlock())
+	receipt, err := testMgr.GetExecutionClient().TransactionReceipt(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	require.Len(t, receipt.Logs, 1)
+	require.Equal(t, common.WithdrawalRequestPredeployAddress, receipt.Logs[0].Address)
+	require.Equal(t, pubkey[:], receipt.Logs[0].Data[:48])
+	require.Equal(t, uint64(1e9), binary.BigEndian.Uint64(receipt.Logs[0].Data[48:]))
+
+	_, err = sp.SubmitWithdrawalRequest(ctx, beacon.ValidatorPubkey(other.Validator.Pubkey), 0)
+	require.ErrorIs(t, err, common.ErrValidatorNotOwned)
+}
+
+// Test submitting consolidation requests to a mock of the EIP-7251 contract on Hardhat
+func TestSubmitConsolidation(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients | osha.Service_Filesystem)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+	rpcClient := testMgr.GetHardhatRpcClient()
+	require.NoError(t, rpcClient.Call(nil, "hardhat_setCode", common.ConsolidationRequestPredeployAddress, getMockRequestContractCode(96)))
+	require.NoError(t, testMgr.CommitBlock())
+
+	derivationPath := string(wallet.DerivationPath_Default)
+	index := uint64(0)
+	_, err = testMgr.GetApiClient().Wallet.Recover(&derivationPath, keys.DefaultMnemonic, &index, goodPassword, true)
+	require.NoError(t, err)
+
+	validators := []bclient.Validator{
+		newElectraTestValidator(0, 0x01, expectedWalletAddress),
+		newElectraTestValidator(1, 0x02, expectedWalletAddress),
+		newElectraTestValidator(2, 0x02, ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")),
+	}
+	sp := newElectraTestServiceProvider(t, validators...)
+	pubkeys := make([]beacon.ValidatorPubkey, len(validators))
+	for i, validator := range validators {
+		pubkeys[i] = beacon.ValidatorPubkey(validator.Validator.Pubkey)
+	}
+	ctx := context.Background()
+
+	// Execution into compounding
+	hash, err := sp.SubmitConsolidation(ctx, pubkeys[0], pubkeys[1])
+	require.NoError(t, err)
+	require.NoError(t, testMgr.CommitBlock())
+	receipt, err := testMgr.GetExecutionClient().TransactionReceipt(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	require.Len(t, receipt.Logs, 1)
+	require.Equal(t, common.ConsolidationRequestPredeployAddress, receipt.Logs[0].Address)
+	require.Equal(t, append(pubkeys[0][:], pubkeys[1][:]...), receipt.Logs[0].Data)
+
+	// Invalid combinations are never submitted
+	_, err = sp.SubmitConsolidation(ctx, pubkeys[1], pubkeys[1])
+	require.ErrorIs(t, err, common.ErrSelfConsolidation)
+	_, err = sp.SubmitConsolidation(ctx, pubkeys[1], pubkeys[0])
+	require.ErrorIs(t, err, common.ErrTargetNotCompounding)
+	_, err = sp.SubmitConsolidation(ctx, pubkeys[0], pubkeys[2])
+	require.ErrorIs(t, err, common.ErrValidatorNotOwned)
+	_, err = sp.SubmitConsolidation(ctx, pubkeys[2], pubkeys[1])
+	require.ErrorIs(t, err, common.ErrValidatorNotOwned)
+}
+
+// Gets the code for a mock execution layer request contract. It returns a fee of 1 wei when called without data, and
+// logs requests of the provided length that pay the fee; anything else reverts.
+func getMockRequestContractCode(requestLength int) string {
+	return fmt.Sprintf("0x36600e57600160005260206000f35b60%02x3614346001111516602057600080fd5b3660006000373660006000a000", requestLength)
+}
+
+// Creates an active validator on a mock Beacon Node with withdrawal credentials of the provided type and address
+func newElectraTestValidator(index int, prefix byte, withdrawalAddress ethcommon.Address) bclient.Validator {
+	var validator bclient.Validator
+	validator.Index = fmt.Sprint(index)
+	validator.Status = string(beacon.ValidatorState_ActiveOngoing)
+	validator.Balance = 32e9
+	validator.Validator.EffectiveBalance = 32e9
+	validator.Validator.Pubkey = make([]byte, beacon.ValidatorPubkeyLength)
+	validator.Validator.Pubkey[0] = 0xaa
+	validator.Validator.Pubkey[1] = byte(index)
+	validator.Validator.WithdrawalCredentials = make([]byte, 32)
+	validator.Validator.WithdrawalCredentials[0] = prefix
+	copy(validator.Validator.WithdrawalCredentials[12:], withdrawalAddress.Bytes())
+	return validator
+}
+
+// Creates a service provider that uses Hardhat and the test manager's wallet, and a mock Beacon Node on Electra with
+// the provided validators. The test manager's Beacon mock doesn't serve the spec or the fork, so it can't be used here.
+func newElectraTestServiceProvider(t *testing.T, validators ...bclient.Validator) *common.ServiceProvider {
+	bn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data any
+		switch r.URL.Path {
+		case "/eth/v1/config/spec":
+			data = map[string]string{
+				"SLOTS_PER_EPOCH":        "32",
+				"SECONDS_PER_SLOT":       "12",
+				"DENEB_FORK_VERSION":     "0x04000000",
+				"ELECTRA_FORK_VERSION":   "0x05000000",
+				"MIN_ACTIVATION_BALANCE": "32000000000",
+			}
+		case "/eth/v1/beacon/states/head/fork":
+			data = map[string]string{"previous_version": "0x04000000", "current_version": "0x05000000", "epoch": "0"}
+		case "/eth/v1/beacon/states/head/validators":
+			ids := strings.Split(r.URL.Query().Get("id"), ",")
+			matches := []bclient.Validator{}
+			for _, validator := range validators {
+				for _, id := range ids {
+					if id == validator.Index || strings.EqualFold(id, beacon.ValidatorPubkey(validator.Validator.Pubkey).HexWithPrefix()) {
+						matches = append(matches, validator)
+					}
+				}
+			}
+			data = matches
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(bn.Close)
+
+	testSp := testMgr.GetServiceProvider()
+	bnApi := common.NewBeaconApiClient(bn.URL, "", time.Minute)
+	sp, err := common.NewServiceProviderFromCustomServicesWithBeaconApi(testSp.GetConfig(), testSp.GetConfig().GetNetworkResources(), testSp.GetEthClient(), testSp.GetBeaconClient(), bnApi, testMgr.GetDockerMockManager())
+	require.NoError(t, err)
+	t.Cleanup(sp.Close)
+	return sp
+}
+
+// Test proving deposits to a deposit contract deployed on Hardhat against the contract's own root
+func TestGetDepositProof(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+
+	// Deploy the deposit contract from Hardhat's first default account
+	bytecode, err := os.ReadFile(filepath.Join("testdata", "deposit-contract.bin"))
+	require.NoError(t, err)
+	from := ethcommon.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	rpcClient := testMgr.GetHardhatRpcClient()
+	var deployHash ethcommon.Hash
+	require.NoError(t, rpcClient.Call(&deployHash, "eth_sendTransaction", map[string]any{
+		"from": from,
+		"data": strings.TrimSpace(string(bytecode)),
+		"gas":  hexutil.Uint64(5000000),
+	}))
+	require.NoError(t, testMgr.CommitBlock())
+	ctx := context.Background()
+	receipt, err := testMgr.GetExecutionClient().TransactionReceipt(ctx, deployHash)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	depositContract := receipt.ContractAddress
+
+	// Make several deposits
+	contractAbi, err := abi.JSON(strings.NewReader(prdeposit.DepositContractABI))
+	require.NoError(t, err)
+	depositCount := 7
+	for i := 0; i < depositCount; i++ {
+		depositData := ssz_types.DepositData{
+			PublicKey:             make([]byte, beacon.ValidatorPubkeyLength),
+			WithdrawalCredentials: make([]byte, 32),
+			Amount:                32e9,
+			Signature:             make([]byte, 96),
+		}
+		depositData.PublicKey[0] = byte(i + 1)
+		depositData.WithdrawalCredentials[0] = 0x01
+		depositDataRoot, err := depositData.HashTreeRoot()
+		require.NoError(t, err)
+		data, err := contractAbi.Pack("deposit", depositData.PublicKey, depositData.WithdrawalCredentials, depositData.Signature, depositDataRoot)
+		require.NoError(t, err)
+		var hash ethcommon.Hash
+		require.NoError(t, rpcClient.Call(&hash, "eth_sendTransaction", map[string]any{
+			"from":  from,
+			"to":    depositContract,
+			"data":  hexutil.Bytes(data),
+			"value": (*hexutil.Big)(eth.EthToWei(32)),
+			"gas":   hexutil.Uint64(200000),
+		}))
+		require.NoError(t, testMgr.CommitBlock())
+	}
+
+	sp := newDepositTestServiceProvider(t, depositContract)
+	info, err := sp.GetDepositContractInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(depositCount), info.DepositCount)
+
+	// Check the first, middle, and last deposits
+	for _, index := range []uint64{0, uint64(depositCount / 2), uint64(depositCount - 1)} {
+		proof, err := sp.GetDepositProof(ctx, index)
+		require.NoError(t, err)
+		require.True(t, common.VerifyDepositProof(proof, info.DepositRoot), "proof for deposit %d", index)
+	}
+	_, err = sp.GetDepositProof(ctx, uint64(depositCount))
+	require.ErrorIs(t, err, common.ErrDepositNotFound)
+}
+
+// Creates a service provider that uses Hardhat and a mock Beacon Node whose spec has the provided deposit contract
+func newDepositTestServiceProvider(t *testing.T, depositContract ethcommon.Address) *common.ServiceProvider {
+	bn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v1/config/spec" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{
+			"SLOTS_PER_EPOCH":          "32",
+			"SECONDS_PER_SLOT":         "12",
+			"DEPOSIT_CONTRACT_ADDRESS": depositContract.Hex(),
+		}})
+	}))
+	t.Cleanup(bn.Close)
+
+	testSp := testMgr.GetServiceProvider()
+	bnApi := common.NewBeaconApiClient(bn.URL, "", time.Minute)
+	sp, err := common.NewServiceProviderFromCustomServicesWithBeaconApi(testSp.GetConfig(), testSp.GetConfig().GetNetworkResources(), testSp.GetEthClient(), testSp.GetBeaconClient(), bnApi, testMgr.GetDockerMockManager())
+	require.NoError(t, err)
+	t.Cleanup(sp.Close)
+	return sp
+}
+
+// A module with a fixed set of validators, for tests that only need the node to own some
+type testStakeContributor struct {
+	pubkeys []beacon.ValidatorPubkey
+}
+
+func (c *testStakeContributor) GetModuleName() string {
+	return "test"
+}
+
+func (c *testStakeContributor) IsEnabled() bool {
+	return true
+}
+
+func (c *testStakeContributor) GetValidatorPubkeys(ctx context.Context) ([]beacon.ValidatorPubkey, error) {
+	return c.pubkeys, nil
+}
+
+func (c *testStakeContributor) GetCollateral(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+// Test adding up the priority fees and builder payments of blocks proposed on Hardhat by one of the node's validators,
+// with one block paying the wrong fee recipient
+func TestGetPriorityFeesCaptured(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+
+	// Hardhat's default accounts
+	from := ethcommon.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	feeRecipient := ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	builder := ethcommon.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")
+	wrongRecipient := ethcommon.HexToAddress("0x90F79bf6EB2c4f870365E785982E1f161e628c4c")
+	mevPayment := big.NewInt(1e15)
+	ctx := context.Background()
+	rpcClient := testMgr.GetHardhatRpcClient()
+	ec := testMgr.GetExecutionClient()
+
+	// Mines a block paying the coinbase, with a single transaction, and returns the block and the tip it paid
+	mine := func(coinbase ethcommon.Address, sender ethcommon.Address, to ethcommon.Address, value *big.Int) (*types.Header, *big.Int) {
+		require.NoError(t, rpcClient.Call(nil, "hardhat_setCoinbase", coinbase))
+		var hash ethcommon.Hash
+		require.NoError(t, rpcClient.Call(&hash, "eth_sendTransaction", map[string]any{
+			"from":                 sender,
+			"to":                   to,
+			"value":                (*hexutil.Big)(value),
+			"gas":                  hexutil.Uint64(21000),
+			"maxPriorityFeePerGas": (*hexutil.Big)(big.NewInt(2e9)),
+			"maxFeePerGas":         (*hexutil.Big)(big.NewInt(100e9)),
+		}))
+		require.NoError(t, testMgr.CommitBlock())
+		receipt, err := ec.TransactionReceipt(ctx, hash)
+		require.NoError(t, err)
+		header, err := ec.HeaderByNumber(ctx, receipt.BlockNumber)
+		require.NoError(t, err)
+		tip := new(big.Int).Sub(receipt.EffectiveGasPrice, header.BaseFee)
+		return header, tip.Mul(tip, new(big.Int).SetUint64(receipt.GasUsed))
+	}
+	local, localTip := mine(feeRecipient, from, wrongRecipient, big.NewInt(1))
+	_, wrongTip := mine(wrongRecipient, from, wrongRecipient, big.NewInt(1))
+	relayed, _ := mine(builder, builder, feeRecipient, mevPayment)
+
+	// The node's validator proposed all three blocks, starting at the first slot of epoch 1
+	validator := newElectraTestValidator(0, 0x01, expectedWalletAddress)
+	pubkey := beacon.ValidatorPubkey(validator.Validator.Pubkey)
+	genesisTime := local.Time - 32*12
+	bn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data any
+		switch r.URL.Path {
+		case "/eth/v1/config/spec":
+			data = map[string]string{
+				"SLOTS_PER_EPOCH":  "32",
+				"SECONDS_PER_SLOT": "12",
+			}
+		case "/eth/v1/beacon/genesis":
+			data = map[string]string{
+				"genesis_time":            fmt.Sprint(genesisTime),
+				"genesis_validators_root": "0x0000000000000000000000000000000000000000000000000000000000000000",
+				"genesis_fork_version":    "0x00000000",
+			}
+		case "/eth/v1/beacon/states/head/validators":
+			data = []bclient.Validator{validator}
+		case "/eth/v1/validator/duties/proposer/1":
+			duties := []map[string]string{}
+			for slot := 32; slot < 35; slot++ {
+				duties = append(duties, map[string]string{"pubkey": pubkey.HexWithPrefix(), "validator_index": validator.Index, "slot": fmt.Sprint(slot)})
+			}
+			data = duties
+		default:
+			if !strings.HasPrefix(r.URL.Path, "/eth/v1/validator/duties/proposer/") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			data = []any{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(bn.Close)
+	keyManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf("/eth/v1/validator/%s/feerecipient", pubkey.HexWithPrefix()) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"pubkey": pubkey.HexWithPrefix(), "ethaddress": feeRecipient.Hex()}})
+	}))
+	t.Cleanup(keyManager.Close)
+
+	testSp := testMgr.GetServiceProvider()
+	cfg := testSp.GetConfig().Clone()
+	cfg.KeyManager.Url.Value = keyManager.URL
+	bnApi := common.NewBeaconApiClient(bn.URL, "", time.Minute)
+	sp, err := common.NewServiceProviderFromCustomServicesWithBeaconApi(cfg, cfg.GetNetworkResources(), testSp.GetEthClient(), testSp.GetBeaconClient(), bnApi, testMgr.GetDockerMockManager())
+	require.NoError(t, err)
+	t.Cleanup(sp.Close)
+	sp.RegisterStakeContributor(&testStakeContributor{pubkeys: []beacon.ValidatorPubkey{pubkey}})
+
+	// Include a block before the proposals to make sure it isn't counted
+	fromBlock := local.Number.Uint64() - 1
+	toBlock := relayed.Number.Uint64()
+	captured, err := sp.GetPriorityFeesCaptured(ctx, fromBlock, toBlock)
+	require.NoError(t, err)
+	require.Equal(t, new(big.Int).Add(localTip, mevPayment).String(), captured.String())
+
+	breakdown, err := sp.GetPriorityFeeBreakdown(ctx, fromBlock, toBlock)
+	require.NoError(t, err)
+	require.Equal(t, 3, breakdown.ProposalCount)
+	require.Equal(t, localTip.String(), breakdown.PriorityFees.String())
+	require.Equal(t, mevPayment.String(), breakdown.MevRewards.String())
+	require.Equal(t, wrongTip.String(), breakdown.Misdirected.String())
+	require.Len(t, breakdown.MisdirectedProposals, 1)
+	require.Equal(t, local.Number.Uint64()+1, breakdown.MisdirectedProposals[0].BlockNumber)
+	require.Equal(t, wrongRecipient, breakdown.MisdirectedProposals[0].FeeRecipient)
+	require.Equal(t, feeRecipient, breakdown.MisdirectedProposals[0].ExpectedFeeRecipient)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = sp.GetPriorityFeesCaptured(cancelled, fromBlock, toBlock)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+// Test estimating the cost of pending actions on Hardhat, where one of them calls a contract that always reverts
+func TestEstimateActionsCost(t *testing.T) {
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_EthClients)
+	require.NoError(t, err)
+	defer service_cleanup(snapshotName)
+
+	// Copies an Error("not allowed") revert out of its own code and reverts with it
+	revertingAddress := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de4")
+	revertingCode := "0x6064600c60003960646000fd08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000b6e6f7420616c6c6f776564000000000000000000000000000000000000000000"
+	rpcClient := testMgr.GetHardhatRpcClient()
+	require.NoError(t, rpcClient.Call(nil, "hardhat_setCode", revertingAddress, revertingCode))
+	require.NoError(t, testMgr.CommitBlock())
+
+	// Hardhat's first and second default accounts
+	from := ethcommon.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	to := ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	newAction := func(id string, to ethcommon.Address, value *big.Int) common.PendingAction {
+		return common.PendingAction{
+			ID:          id,
+			Description: id,
+			From:        from,
+			Transaction: types.NewTx(&types.DynamicFeeTx{
+				To:    &to,
+				Value: value,
+			}),
+			Execute: func(ctx context.Context) (ethcommon.Hash, error) {
+				return ethcommon.Hash{}, nil
+			},
+		}
+	}
+	actions := []common.PendingAction{
+		newAction("transfer", to, big.NewInt(1)),
+		newAction("reverting", revertingAddress, big.NewInt(0)),
+		newAction("second-transfer", to, big.NewInt(2)),
+	}
+
+	sp := testMgr.GetServiceProvider()
+	estimate, err := sp.EstimateActionsCost(context.Background(), actions)
+	require.NoError(t, err)
+	require.Len(t, estimate.Succeeding, 2)
+	require.Equal(t, "transfer", estimate.Succeeding[0].Action.ID)
+	require.Equal(t, uint64(21000), estimate.Succeeding[0].Gas)
+	require.Equal(t, "second-transfer", estimate.Succeeding[1].Action.ID)
+	require.Len(t, estimate.Reverting, 1)
+	require.Equal(t, "reverting", estimate.Reverting[0].Action.ID)
+	require.Equal(t, "not allowed", estimate.Reverting[0].RevertReason)
+
+	gasPrice := new(big.Int).Add(estimate.BaseFee, estimate.PriorityFee)
+	require.Equal(t, uint64(42000), estimate.TotalGas)
+	require.Equal(t, new(big.Int).Mul(gasPrice, big.NewInt(42000)).String(), estimate.TotalCost.String())
+	require.True(t, estimate.MaxTotalCost.Cmp(estimate.TotalCost) >= 0)
+	t.Logf("Estimated %s wei for %d actions at %s wei per gas", estimate.TotalCost, len(estimate.Succeeding), gasPrice)
+}
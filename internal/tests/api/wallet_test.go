@@ -321,6 +321,34 @@ func TestWalletSend_EthFailure(t *testing.T) {
 }
 
 // Clean up after each test
+func TestWalletVerifyKeystores(t *testing.T) {
+	// Take a snapshot, revert at the end
+	snapshotName, err := testMgr.CreateCustomSnapshot(osha.Service_Filesystem)
+	if err != nil {
+		fail("Error creating custom snapshot: %v", err)
+	}
+	defer wallet_cleanup(snapshotName)
+
+	// Verifying before a password is stored should fail
+	_, err = testMgr.GetApiClient().Wallet.VerifyKeystores()
+	require.Error(t, err)
+	t.Log("Verification failed without a stored password")
+
+	// Recover the wallet and save the password
+	derivationPath := string(wallet.DerivationPath_Default)
+	index := uint64(0)
+	_, err = testMgr.GetApiClient().Wallet.Recover(&derivationPath, keys.DefaultMnemonic, &index, goodPassword, true)
+	require.NoError(t, err)
+	t.Log("Recover called")
+
+	// No keystores have been saved yet, so there's nothing to fail
+	response, err := testMgr.GetApiClient().Wallet.VerifyKeystores()
+	require.NoError(t, err)
+	require.Empty(t, response.Data.Keystores)
+	require.Zero(t, response.Data.FailureCount)
+	t.Log("Verified an empty keystore directory")
+}
+
 func wallet_cleanup(snapshotName string) {
 	// Handle panics
 	r := recover()
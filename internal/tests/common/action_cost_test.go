@@ -0,0 +1,88 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test estimating a batch of actions where one reverts in eth_call and one only reverts when its gas is estimated
+func TestEstimateActionsCost(t *testing.T) {
+	from := ethcommon.HexToAddress("0x90F79bf6EB2c4f870365E785982E1f101E93b906")
+	claimContract := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	stakeContract := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de2")
+	exitContract := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de3")
+	newAction := func(id string, to ethcommon.Address) common.PendingAction {
+		action := newMockPendingAction(id, id, ethcommon.Hash{})
+		action.From = from
+		action.Transaction = types.NewTx(&types.DynamicFeeTx{
+			To:    &to,
+			Value: big.NewInt(0),
+			Data:  []byte{0x12, 0x34, 0x56, 0x78},
+		})
+		return action
+	}
+	getTo := func(params []json.RawMessage) ethcommon.Address {
+		var call struct {
+			To ethcommon.Address `json:"to"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &call))
+		return call.To
+	}
+
+	ec := newMockExecutionClient(t, 1)
+	ec.SetResult("eth_getBlockByNumber", &types.Header{
+		Number:     big.NewInt(1000),
+		Difficulty: big.NewInt(0),
+		BaseFee:    big.NewInt(3e9),
+	})
+	ec.SetResult("eth_maxPriorityFeePerGas", "0x3b9aca00")
+	ec.Handlers["eth_call"] = func(params []json.RawMessage) (any, error) {
+		if getTo(params) == stakeContract {
+			return nil, &mockRpcError{Code: 3, Message: "execution reverted: not ready", Data: hexutil.Encode(newRevertReasonData(t, "not ready"))}
+		}
+		return "0x", nil
+	}
+	ec.Handlers["eth_estimateGas"] = func(params []json.RawMessage) (any, error) {
+		if getTo(params) == exitContract {
+			return nil, &mockRpcError{Code: 3, Message: "execution reverted"}
+		}
+		return "0x186a0", nil
+	}
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+	actions := []common.PendingAction{
+		newAction("claim", claimContract),
+		newAction("stake", stakeContract),
+		newAction("exit", exitContract),
+		newAction("claim-again", claimContract),
+	}
+	estimate, err := sp.EstimateActionsCost(context.Background(), actions)
+	require.NoError(t, err)
+	require.Len(t, estimate.Succeeding, 2)
+	require.Equal(t, "claim", estimate.Succeeding[0].Action.ID)
+	require.Equal(t, uint64(100000), estimate.Succeeding[0].Gas)
+	require.Equal(t, big.NewInt(4e14).String(), estimate.Succeeding[0].Cost.String())
+	require.Equal(t, "claim-again", estimate.Succeeding[1].Action.ID)
+	require.Len(t, estimate.Reverting, 2)
+	require.Equal(t, "stake", estimate.Reverting[0].Action.ID)
+	require.Equal(t, "not ready", estimate.Reverting[0].RevertReason)
+	require.Equal(t, "exit", estimate.Reverting[1].Action.ID)
+
+	require.Equal(t, uint64(200000), estimate.TotalGas)
+	require.Equal(t, big.NewInt(3e9).String(), estimate.BaseFee.String())
+	require.Equal(t, big.NewInt(1e9).String(), estimate.PriorityFee.String())
+	require.Equal(t, big.NewInt(8e14).String(), estimate.TotalCost.String())
+	require.Equal(t, big.NewInt(14e14).String(), estimate.MaxTotalCost.String())
+
+	// Actions without a transaction can't be simulated
+	_, err = sp.EstimateActionsCost(context.Background(), []common.PendingAction{newMockPendingAction("bare", "bare", ethcommon.Hash{})})
+	require.ErrorContains(t, err, "bare")
+}
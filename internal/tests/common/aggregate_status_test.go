@@ -0,0 +1,87 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// A module that reports a fixed status, or an error
+type mockStatusReporter struct {
+	name    string
+	enabled bool
+	status  common.ModuleStatus
+	err     error
+}
+
+func (r *mockStatusReporter) GetModuleName() string {
+	return r.name
+}
+
+func (r *mockStatusReporter) IsEnabled() bool {
+	return r.enabled
+}
+
+func (r *mockStatusReporter) GetStatus(ctx context.Context) (common.ModuleStatus, error) {
+	return r.status, r.err
+}
+
+// Test merging the status of two modules, where a third one fails and a disabled one is ignored
+func TestGetAggregateStatus(t *testing.T) {
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+	sp.RegisterStatusReporter(&mockStatusReporter{
+		name:    "stakewise",
+		enabled: true,
+		status: common.ModuleStatus{
+			ValidatorCounts: map[common.ValidatorStatus]int{
+				common.ValidatorStatus_Active:  3,
+				common.ValidatorStatus_Pending: 1,
+			},
+			PendingActions: []string{"1 validator is waiting for its deposit"},
+		},
+	})
+	sp.RegisterStatusReporter(&mockStatusReporter{
+		name:    "constellation",
+		enabled: true,
+		status: common.ModuleStatus{
+			ValidatorCounts: map[common.ValidatorStatus]int{
+				common.ValidatorStatus_Active:  2,
+				common.ValidatorStatus_Exiting: 1,
+			},
+			Warnings: []string{"the node's RPL collateral is low"},
+		},
+	})
+	sp.RegisterStatusReporter(&mockStatusReporter{
+		name:    "broken",
+		enabled: true,
+		err:     errors.New("contract call reverted"),
+	})
+	sp.RegisterStatusReporter(&mockStatusReporter{
+		name:   "disabled",
+		status: common.ModuleStatus{ValidatorCounts: map[common.ValidatorStatus]int{common.ValidatorStatus_Active: 10}},
+	})
+
+	status, err := sp.GetAggregateStatus(context.Background())
+	require.NoError(t, err)
+	require.Len(t, status.Modules, 3)
+	require.NotContains(t, status.Modules, "disabled")
+	require.Equal(t, 5, status.ValidatorCounts[common.ValidatorStatus_Active])
+	require.Equal(t, 1, status.ValidatorCounts[common.ValidatorStatus_Pending])
+	require.Equal(t, 1, status.ValidatorCounts[common.ValidatorStatus_Exiting])
+	require.Zero(t, status.ValidatorCounts[common.ValidatorStatus_Slashed])
+	require.Equal(t, 7, status.TotalValidators)
+	require.Equal(t, []string{"stakewise: 1 validator is waiting for its deposit"}, status.PendingActions)
+
+	// The failing module is flagged, but the others still count
+	require.Equal(t, []string{"broken"}, status.FailedModules)
+	require.True(t, status.Modules["broken"].Failed)
+	require.Equal(t, "contract call reverted", status.Modules["broken"].Error)
+	require.False(t, status.Modules["stakewise"].Failed)
+	require.Equal(t, []string{
+		"constellation: the node's RPL collateral is low",
+		"broken: couldn't get the module's status: contract call reverted",
+	}, status.Warnings)
+}
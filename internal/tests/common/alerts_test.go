@@ -0,0 +1,102 @@
+package common_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/stretchr/testify/require"
+)
+
+// A fake alert sink that records the alerts it receives
+type mockAlertSink struct {
+	alerts []common.Alert
+}
+
+func (s *mockAlertSink) GetName() string {
+	return "mock"
+}
+
+func (s *mockAlertSink) SendAlert(ctx context.Context, alert common.Alert) error {
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+// Creates a config that sends email alerts through the provided SMTP server, logging in with the provided password
+func newSmtpTestConfig(t *testing.T, server *mockSmtpServer, password string) *hdconfig.HyperdriveConfig {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	passwordPath := filepath.Join(t.TempDir(), "smtp-password")
+	require.NoError(t, os.WriteFile(passwordPath, []byte(password+"\n"), 0600))
+	cfg.Alerts.SmtpHost.Value = server.Host
+	cfg.Alerts.SmtpPort.Value = server.Port
+	cfg.Alerts.SmtpFrom.Value = "hyperdrive@example.com"
+	cfg.Alerts.SmtpTo.Value = "operator@example.com, backup@example.com"
+	cfg.Alerts.SmtpUsername.Value = "hyperdrive"
+	cfg.Alerts.SmtpPasswordPath.Value = passwordPath
+	return cfg
+}
+
+// Test sending a test email, checking its envelope, headers, and body
+func TestSendTestAlert(t *testing.T) {
+	server := newMockSmtpServer(t, "hyperdrive", "smtp-password")
+	cfg := newSmtpTestConfig(t, server, "smtp-password")
+	require.Empty(t, cfg.Alerts.Validate())
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	require.NoError(t, sp.SendTestAlert(context.Background()))
+	messages := server.GetMessages()
+	require.Len(t, messages, 1)
+	require.Equal(t, "hyperdrive@example.com", messages[0].From)
+	require.Equal(t, []string{"operator@example.com", "backup@example.com"}, messages[0].To)
+	require.Contains(t, messages[0].Data, "From: hyperdrive@example.com\n")
+	require.Contains(t, messages[0].Data, "To: operator@example.com, backup@example.com\n")
+	require.Contains(t, messages[0].Data, "Subject: [Hyperdrive] [INFO] Test alert\n")
+	require.Contains(t, messages[0].Data, "Content-Type: text/plain; charset=UTF-8\n\nThis is a test alert from the Hyperdrive daemon")
+}
+
+// Test that login and connection failures are reported as such
+func TestSendTestAlert_Failures(t *testing.T) {
+	server := newMockSmtpServer(t, "hyperdrive", "smtp-password")
+	sp := newTestServiceProviderFromConfig(t, newSmtpTestConfig(t, server, "wrong-password"))
+	err := sp.SendTestAlert(context.Background())
+	require.ErrorIs(t, err, common.ErrSmtpAuthFailed)
+	require.Empty(t, server.GetMessages())
+
+	server.Close()
+	err = sp.SendTestAlert(context.Background())
+	require.ErrorIs(t, err, common.ErrSmtpConnectionFailed)
+
+	sp.GetConfig().Alerts.SmtpHost.Value = ""
+	err = sp.SendTestAlert(context.Background())
+	require.ErrorIs(t, err, common.ErrEmailAlertsNotConfigured)
+}
+
+// Test that alerts only go to the sinks selected for their severity
+func TestAlertManager_SeverityRouting(t *testing.T) {
+	server := newMockSmtpServer(t, "", "")
+	cfg := newSmtpTestConfig(t, server, "")
+	cfg.Alerts.SmtpUsername.Value = ""
+	cfg.Alerts.EmailSeverity.Value = hdconfig.AlertSeverity_Warning
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	manager := sp.GetAlertManager()
+	infoSink := &mockAlertSink{}
+	manager.AddSink(infoSink, hdconfig.AlertSeverity_Info)
+
+	ctx := context.Background()
+	require.NoError(t, manager.SendAlert(ctx, common.Alert{Severity: hdconfig.AlertSeverity_Info, Title: "Restarted", Message: "The Beacon Node was restarted."}))
+	require.NoError(t, manager.SendAlert(ctx, common.Alert{Severity: hdconfig.AlertSeverity_Critical, Title: "Missed\r\nproposal", Message: "Validator 5 missed a proposal.\nCheck the Validator Client."}))
+	require.Len(t, infoSink.alerts, 1)
+	require.Equal(t, "Restarted", infoSink.alerts[0].Title)
+	messages := server.GetMessages()
+	require.Len(t, messages, 1)
+	require.Contains(t, messages[0].Data, "Subject: [Hyperdrive] [CRITICAL] Missed proposal\n")
+	require.Contains(t, messages[0].Data, "Validator 5 missed a proposal.\nCheck the Validator Client.\n")
+
+	// Bad addresses are caught before saving
+	cfg.Alerts.SmtpFrom.Value = "not an address"
+	cfg.Alerts.SmtpTo.Value = ""
+	require.Len(t, cfg.Alerts.Validate(), 2)
+}
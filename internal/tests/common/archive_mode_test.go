@@ -0,0 +1,73 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test detecting an archive node, which has the state of old blocks, and caching the result
+func TestDetectExecutionArchiveMode_Archive(t *testing.T) {
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetResult("eth_blockNumber", hexutil.Uint64(2000000))
+	var probedBlock string
+	ec.Handlers["eth_getBalance"] = func(params []json.RawMessage) (any, error) {
+		require.NoError(t, json.Unmarshal(params[1], &probedBlock))
+		return "0x0", nil
+	}
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+	isArchive, err := sp.DetectExecutionArchiveMode(context.Background())
+	require.NoError(t, err)
+	require.True(t, isArchive)
+	require.Equal(t, hexutil.Uint64(1900000).String(), probedBlock)
+	require.NoError(t, sp.RequireExecutionArchiveMode(context.Background(), "reward history"))
+	require.Equal(t, 1, ec.GetRequestCount("eth_getBalance"))
+}
+
+// Test detecting a full node from the errors clients return for pruned state
+func TestDetectExecutionArchiveMode_Full(t *testing.T) {
+	for _, message := range []string{
+		"missing trie node 1d9f4c6e7d2b3f0e (path ) state 0x1d9f4c6e7d2b3f0e is not available, not found",
+		"historical state not available in path scheme yet",
+		"World state not available for block number (0x1cfde0)",
+	} {
+		ec := newMockExecutionClient(t, 17000)
+		ec.SetResult("eth_blockNumber", hexutil.Uint64(50))
+		ec.Handlers["eth_getBalance"] = func(params []json.RawMessage) (any, error) {
+			return nil, errors.New(message)
+		}
+		sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+		isArchive, err := sp.DetectExecutionArchiveMode(context.Background())
+		require.NoError(t, err, message)
+		require.False(t, isArchive, message)
+		err = sp.RequireExecutionArchiveMode(context.Background(), "reward history")
+		require.ErrorIs(t, err, common.ErrArchiveNodeRequired)
+		require.ErrorContains(t, err, "reward history")
+		require.Equal(t, 1, ec.GetRequestCount("eth_getBalance"))
+	}
+}
+
+// Test that other errors are returned instead of being taken as a full node, and aren't cached
+func TestDetectExecutionArchiveMode_Error(t *testing.T) {
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetResult("eth_blockNumber", hexutil.Uint64(50))
+	ec.Handlers["eth_getBalance"] = func(params []json.RawMessage) (any, error) {
+		return nil, errors.New("request timed out")
+	}
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+	_, err := sp.DetectExecutionArchiveMode(context.Background())
+	require.ErrorContains(t, err, "request timed out")
+	delete(ec.Handlers, "eth_getBalance")
+	ec.SetResult("eth_getBalance", "0x0")
+	isArchive, err := sp.DetectExecutionArchiveMode(context.Background())
+	require.NoError(t, err)
+	require.True(t, isArchive)
+}
@@ -0,0 +1,127 @@
+package common_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+const testPoolBlockRoot string = "0xcf8e0d4e9587369b2301d0790347320302cc0943d5a1884560367e8208d920f2"
+
+// Creates a Beacon Node with 4-slot epochs where the node's module runs validators 0 to 2 and validator 3 belongs to
+// someone else. In slot 13, validators 0 and 3 are in committee 0 and validators 1 and 2 are in committee 1; the pool
+// has validator 0's attestation on its own and aggregated with validator 3's, and validator 2's on its own.
+func newAttestationPoolTestProvider(t *testing.T) (*mockBeaconNode, *common.ServiceProvider, []beacon.ValidatorPubkey) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = 14
+	bn.AddValidators(4, beacon.ValidatorState_ActiveOngoing, 32e9)
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, validator := range bn.Validators {
+		pubkeys = append(pubkeys, beacon.ValidatorPubkey(validator.Validator.Pubkey))
+	}
+	bn.Committees[3] = []common.BeaconCommittee{
+		{Index: 0, Slot: 13, Validators: []string{"0", "3"}},
+		{Index: 1, Slot: 13, Validators: []string{"1", "2"}},
+		{Index: 0, Slot: 14, Validators: []string{"2", "1", "0"}},
+	}
+	for _, bits := range []struct {
+		slot      uint64
+		committee uint64
+		bits      string
+	}{
+		{slot: 13, committee: 0, bits: "0x05"},
+		{slot: 13, committee: 0, bits: "0x07"},
+		{slot: 13, committee: 1, bits: "0x06"},
+		{slot: 12, committee: 1, bits: "0x05"},
+	} {
+		attestation := newTestAttestation(bits.slot, bits.committee, bits.bits)
+		attestation.Data.BeaconBlockRoot = testPoolBlockRoot
+		bn.AttestationPool = append(bn.AttestationPool, attestation)
+	}
+
+	ec := newMockExecutionClient(t, 1)
+	ec.SetResult("eth_syncing", false)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys[:3],
+	})
+	return bn, sp, pubkeys
+}
+
+// Test finding the node's validators' attestations in the pool, preferring the largest aggregate
+func TestGetPendingAttestations(t *testing.T) {
+	_, sp, pubkeys := newAttestationPoolTestProvider(t)
+
+	attestations, err := sp.GetPendingAttestations(context.Background(), 13)
+	require.NoError(t, err)
+	require.Equal(t, []common.PendingAttestation{
+		{
+			Pubkey:           pubkeys[0],
+			ValidatorIndex:   "0",
+			Slot:             13,
+			CommitteeIndex:   0,
+			BeaconBlockRoot:  testPoolBlockRoot,
+			AggregationCount: 2,
+		},
+		{
+			Pubkey:           pubkeys[2],
+			ValidatorIndex:   "2",
+			Slot:             13,
+			CommitteeIndex:   1,
+			BeaconBlockRoot:  testPoolBlockRoot,
+			AggregationCount: 1,
+		},
+	}, attestations)
+}
+
+// Test that an empty pool is reported separately from a pool that can't be read
+func TestGetPendingAttestations_EmptyPool(t *testing.T) {
+	bn, sp, _ := newAttestationPoolTestProvider(t)
+
+	_, err := sp.GetPendingAttestations(context.Background(), 14)
+	require.ErrorIs(t, err, common.ErrAttestationPoolEmpty)
+
+	bn.Handle("/eth/v1/beacon/pool/attestations", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusInternalServerError, map[string]any{"code": 500, "message": "pool unavailable"})
+	})
+	_, err = sp.GetPendingAttestations(context.Background(), 13)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, common.ErrAttestationPoolEmpty)
+}
+
+// Test that validators missing from the pool are flagged in the diagnostics bundle
+func TestGenerateDiagnosticsBundle_AttestationPool(t *testing.T) {
+	_, sp, pubkeys := newAttestationPoolTestProvider(t)
+
+	var buffer bytes.Buffer
+	require.NoError(t, sp.GenerateDiagnosticsBundle(context.Background(), &buffer))
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	handle, err := reader.Open("attestation-pool.json")
+	require.NoError(t, err)
+	contents, err := io.ReadAll(handle)
+	require.NoError(t, err)
+	require.NoError(t, handle.Close())
+
+	var pool struct {
+		Slot         uint64                      `json:"slot"`
+		Attestations []common.PendingAttestation `json:"attestations"`
+		Anomalies    []string                    `json:"anomalies"`
+	}
+	require.NoError(t, json.Unmarshal(contents, &pool))
+	require.Equal(t, uint64(13), pool.Slot)
+	require.Len(t, pool.Attestations, 2)
+	require.Len(t, pool.Anomalies, 1)
+	require.Contains(t, pool.Anomalies[0], "validator 1 ("+pubkeys[1].HexWithPrefix()+")")
+}
@@ -0,0 +1,94 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test sampling the balance history of a seeded set of validators, including a range that isn't a multiple of the
+// resolution
+func TestGetBalanceHistory(t *testing.T) {
+	bn, sp := newBalanceHistoryTestProvider(t, 100)
+	points, err := sp.GetBalanceHistory(context.Background(), 10, 50, 15)
+	require.NoError(t, err)
+
+	epochs := []uint64{10, 25, 40, 50}
+	require.Len(t, points, len(epochs))
+	for i, point := range points {
+		slot := epochs[i] * 4
+		require.Equal(t, epochs[i], point.Epoch)
+		require.True(t, time.Unix(mockGenesisTime+int64(slot)*12, 0).Equal(point.Time))
+		require.Equal(t, 8, point.ValidatorCount)
+		expected := uint64(0)
+		for index := 0; index < 8; index++ {
+			expected += getMockHistoricalBalance(index, slot)
+		}
+		require.Equal(t, expected, point.TotalBalance)
+	}
+	require.Equal(t, 1, bn.GetRequestCount("/eth/v1/beacon/states/100/validator_balances"))
+	require.Zero(t, bn.GetRequestCount("/eth/v1/beacon/states/104/validator_balances"))
+
+	// A single epoch gives a single point
+	points, err = sp.GetBalanceHistory(context.Background(), 30, 30, 1)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+}
+
+// Test that ranges with too many samples, or outside the available history, are refused
+func TestGetBalanceHistory_Limits(t *testing.T) {
+	bn, sp := newBalanceHistoryTestProvider(t, 1000)
+	ctx := context.Background()
+
+	_, err := sp.GetBalanceHistory(ctx, 0, 1000, 1)
+	require.ErrorContains(t, err, "more than the limit")
+	require.Zero(t, bn.GetRequestCount("/eth/v1/beacon/states/0/validator_balances"))
+	_, err = sp.GetBalanceHistory(ctx, 0, 1000, 2)
+	require.NoError(t, err)
+
+	_, err = sp.GetBalanceHistory(ctx, 10, 20, 0)
+	require.Error(t, err)
+	_, err = sp.GetBalanceHistory(ctx, 20, 10, 1)
+	require.Error(t, err)
+	_, err = sp.GetBalanceHistory(ctx, 990, 1001, 1)
+	require.ErrorContains(t, err, "after the head epoch")
+
+	// States before slot 400 (epoch 100) have been pruned
+	bn.EarliestStateSlot = 400
+	_, err = sp.GetBalanceHistory(ctx, 50, 200, 10)
+	var historyErr *common.InsufficientHistoryError
+	require.ErrorAs(t, err, &historyErr)
+	require.Equal(t, uint64(50), historyErr.StartEpoch)
+	_, err = sp.GetBalanceHistory(ctx, 100, 200, 10)
+	require.NoError(t, err)
+}
+
+// Creates a Beacon Node with 4-slot epochs and seeded validators whose balances grow over time, and a service provider
+// whose module runs the first 8 of them
+func newBalanceHistoryTestProvider(t *testing.T, headEpoch uint64) (*mockBeaconNode, *common.ServiceProvider) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = headEpoch * 4
+	bn.AddSeededValidators(31, 10)
+	slots := []uint64{}
+	for slot := uint64(0); slot <= bn.HeadSlot; slot += 4 {
+		slots = append(slots, slot)
+	}
+	bn.SetBalanceHistory(slots, getMockHistoricalBalance)
+
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: getMockValidatorPubkeys(bn)[:8],
+	})
+	return bn, sp
+}
+
+// Gets the balance of a mock validator at a slot; each one starts at a different balance and earns 1000 gwei per slot
+func getMockHistoricalBalance(index int, slot uint64) uint64 {
+	return 32e9 + uint64(index)*1e6 + slot*1e3
+}
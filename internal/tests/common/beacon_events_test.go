@@ -0,0 +1,129 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+// Test receiving typed events, and that the subscription survives the stream dropping
+func TestSubscribeBeaconEvents(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errs := sp.SubscribeBeaconEvents(ctx, []string{
+		common.BeaconEventTopic_FinalizedCheckpoint,
+		common.BeaconEventTopic_Head,
+		common.BeaconEventTopic_ChainReorg,
+	})
+	bn.WaitForEventConnections(1)
+
+	bn.EmitEvent(common.BeaconEventTopic_FinalizedCheckpoint, map[string]any{
+		"block":                "0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf",
+		"state":                "0x600e852a08c1200654ddf11025f1ceacb3c2e74bdd5c630cde0838b2591b69f9",
+		"epoch":                "2",
+		"execution_optimistic": false,
+	})
+	event := receiveBeaconEvent(t, events)
+	require.Equal(t, common.BeaconEventTopic_FinalizedCheckpoint, event.Topic)
+	require.NotNil(t, event.FinalizedCheckpoint)
+	require.Equal(t, client.Uinteger(2), event.FinalizedCheckpoint.Epoch)
+	require.Equal(t, byte(0x9a), event.FinalizedCheckpoint.Block[0])
+	require.Nil(t, event.Head)
+
+	bn.EmitEvent(common.BeaconEventTopic_Head, map[string]any{
+		"slot":                         "10",
+		"block":                        "0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf",
+		"state":                        "0x600e852a08c1200654ddf11025f1ceacb3c2e74bdd5c630cde0838b2591b69f9",
+		"epoch_transition":             true,
+		"previous_duty_dependent_root": "0x5e0043f107cb57913498fbf2f99ff55e730bf1e151f02f221e977c91a90a0e91",
+		"current_duty_dependent_root":  "0x5e0043f107cb57913498fbf2f99ff55e730bf1e151f02f221e977c91a90a0e91",
+		"execution_optimistic":         false,
+	})
+	event = receiveBeaconEvent(t, events)
+	require.Equal(t, client.Uinteger(10), event.Head.Slot)
+	require.True(t, event.Head.EpochTransition)
+
+	// Events that can't be parsed are reported without ending the stream
+	bn.EmitEvent(common.BeaconEventTopic_ChainReorg, map[string]any{"slot": true})
+	require.ErrorContains(t, receiveBeaconEventError(t, errs), "chain_reorg")
+
+	// The stream is reopened after it drops
+	bn.DropEventStreams()
+	require.ErrorContains(t, receiveBeaconEventError(t, errs), "closed the event stream")
+	bn.WaitForEventConnections(2)
+	bn.EmitEvent(common.BeaconEventTopic_ChainReorg, map[string]any{
+		"slot":                 "200",
+		"depth":                "2",
+		"old_head_block":       "0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf",
+		"new_head_block":       "0x76262e91970d375a19bfe8a867288d7b9cde43c8635f598d93d39d041706fc76",
+		"old_head_state":       "0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf",
+		"new_head_state":       "0x600e852a08c1200654ddf11025f1ceacb3c2e74bdd5c630cde0838b2591b69f9",
+		"epoch":                "6",
+		"execution_optimistic": false,
+	})
+	event = receiveBeaconEvent(t, events)
+	require.Equal(t, client.Uinteger(2), event.ChainReorg.Depth)
+	require.Equal(t, client.Uinteger(6), event.ChainReorg.Epoch)
+
+	// Cancelling closes both channels
+	cancel()
+	requireBeaconEventChannelsClosed(t, events, errs)
+}
+
+// Test that a subscription the Beacon Node rejects ends instead of retrying
+func TestSubscribeBeaconEvents_Rejected(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	events, errs := sp.SubscribeBeaconEvents(context.Background(), []string{"not_a_topic"})
+	require.ErrorContains(t, receiveBeaconEventError(t, errs), "invalid topic")
+	requireBeaconEventChannelsClosed(t, events, errs)
+	require.Zero(t, bn.EventConnections)
+}
+
+// Waits for the next event from a subscription
+func receiveBeaconEvent(t *testing.T, events <-chan common.BeaconEvent) common.BeaconEvent {
+	select {
+	case event, open := <-events:
+		require.True(t, open, "event channel was closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for an event")
+		return common.BeaconEvent{}
+	}
+}
+
+// Waits for the next error from a subscription
+func receiveBeaconEventError(t *testing.T, errs <-chan error) error {
+	select {
+	case err, open := <-errs:
+		require.True(t, open, "error channel was closed")
+		return err
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for an error")
+		return nil
+	}
+}
+
+// Makes sure both of a subscription's channels get closed
+func requireBeaconEventChannelsClosed(t *testing.T, events <-chan common.BeaconEvent, errs <-chan error) {
+	require.Eventually(t, func() bool {
+		select {
+		case _, open := <-events:
+			return !open
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, open := <-errs
+		return !open
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,79 @@
+package common_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the Beacon Node's identity and parsing the version strings reported by each client
+func TestGetBeaconNodeInfo(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	ctx := context.Background()
+
+	info, err := sp.GetBeaconNodeInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, bn.Identity.PeerId, info.PeerId)
+	require.Equal(t, bn.Identity.Enr, info.Enr)
+	require.Equal(t, bn.Identity.P2pAddresses, info.P2pAddresses)
+	require.Equal(t, bn.Identity.DiscoveryAddresses, info.DiscoveryAddresses)
+	require.Equal(t, "Lighthouse/v4.5.0-441fc16/x86_64-linux", info.Version)
+	require.Equal(t, "Lighthouse", info.ClientName)
+	require.Equal(t, "4.5.0-441fc16", info.ClientVersion)
+
+	tests := []struct {
+		version       string
+		clientName    string
+		clientVersion string
+	}{
+		{"Prysm/v5.0.3 (linux amd64)", "Prysm", "5.0.3"},
+		{"teku/v24.4.0/linux-x86_64/-eclipseadoptium-openjdk64bit-java-21", "teku", "24.4.0"},
+		{"Nimbus/v24.5.0-5e3e0b-stateofus", "Nimbus", "24.5.0-5e3e0b-stateofus"},
+		{"Lodestar/v1.18.1/a8f5d3e", "Lodestar", "1.18.1"},
+		{"Grandine/0.4.0-569ba5a/x86_64-linux", "Grandine", "0.4.0-569ba5a"},
+		{"Lighthouse/unstable-dev/x86_64-linux", "Lighthouse", ""},
+		{"custom build", "", ""},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		bn.Version = test.version
+		info, err := sp.GetBeaconNodeInfo(ctx)
+		require.NoError(t, err)
+		require.Equal(t, test.version, info.Version)
+		require.Equal(t, test.clientName, info.ClientName, "wrong client name for %q", test.version)
+		require.Equal(t, test.clientVersion, info.ClientVersion, "wrong client version for %q", test.version)
+	}
+
+	// Nodes that don't report any addresses still get empty lists
+	bn.Identity.P2pAddresses = nil
+	bn.Identity.DiscoveryAddresses = nil
+	info, err = sp.GetBeaconNodeInfo(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, info.P2pAddresses)
+	require.NotNil(t, info.DiscoveryAddresses)
+}
+
+// Test that the health report includes the Beacon Node's identity, or a warning if it can't be retrieved
+func TestGetBeaconNodeInfo_HealthReport(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	report := sp.GetHealthReport(context.Background())
+	require.Equal(t, bn.Identity.PeerId, report.BeaconNode.PeerId)
+	require.Equal(t, "Lighthouse", report.BeaconNode.ClientName)
+
+	bn.SetUnavailable(true)
+	report = sp.GetHealthReport(context.Background())
+	require.Empty(t, report.BeaconNode.PeerId)
+	require.NotNil(t, report.BeaconNode.P2pAddresses)
+	found := false
+	for _, warning := range report.Warnings {
+		if strings.HasPrefix(warning, "Couldn't get the Beacon Node's identity") {
+			found = true
+		}
+	}
+	require.True(t, found, "missing identity warning in %v", report.Warnings)
+}
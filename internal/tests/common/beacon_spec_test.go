@@ -0,0 +1,104 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test parsing the full spec into typed fields, keeping the unknown values
+func TestGetBeaconSpec(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	requests := 0
+	bn.Handle("/eth/v1/config/spec", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeJson(w, http.StatusOK, map[string]any{"data": bn.Spec})
+	})
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	spec, err := sp.GetBeaconSpec(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "mainnet", spec.ConfigName)
+	require.Equal(t, "mainnet", spec.PresetBase)
+	require.Equal(t, uint64(12), spec.SecondsPerSlot)
+	require.Equal(t, uint64(32), spec.SlotsPerEpoch)
+	require.Equal(t, uint64(256), spec.EpochsPerSyncCommitteePeriod)
+	require.Equal(t, uint64(32e9), spec.MaxEffectiveBalance)
+	require.Equal(t, uint64(65536), spec.ChurnLimitQuotient)
+	require.Equal(t, uint64(16384), spec.MaxValidatorsPerWithdrawalsSweep)
+	require.Equal(t, []byte{0x04, 0x00, 0x00, 0x00}, spec.DenebForkVersion)
+	require.Equal(t, uint64(4), spec.DenebForkEpoch)
+	require.Equal(t, uint64(1), spec.DepositChainID)
+	require.Equal(t, ethcommon.HexToAddress("0x00000000219ab540356cbb839cbe05303d7705fa"), spec.DepositContractAddress)
+	require.Equal(t, map[string]any{
+		"TARGET_AGGREGATORS_PER_COMMITTEE": "16",
+		"DOMAIN_BEACON_PROPOSER":           "0x00000000",
+	}, spec.Extra)
+	t.Log("Spec values were parsed into typed fields")
+
+	// The spec should be cached after the first query
+	_, err = sp.GetBeaconSpec(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, requests)
+	t.Log("Spec was only queried once")
+}
+
+// Test that callers get their own copy of the cached spec, so changing it doesn't affect later reads
+func TestGetBeaconSpec_Copy(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["BLOB_SCHEDULE"] = []any{
+		map[string]any{"EPOCH": "5", "MAX_BLOBS_PER_BLOCK": "9"},
+	}
+	sp := newTestServiceProvider(t, bn.URL, "")
+	ctx := context.Background()
+
+	spec, err := sp.GetBeaconSpec(ctx)
+	require.NoError(t, err)
+	spec.DenebForkVersion[0] = 0xff
+	spec.Extra["TARGET_AGGREGATORS_PER_COMMITTEE"] = "1"
+	spec.Extra["NEW_VALUE"] = "1"
+	spec.Extra["BLOB_SCHEDULE"].([]any)[0].(map[string]any)["EPOCH"] = "100"
+
+	cached, err := sp.GetBeaconSpec(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x04, 0x00, 0x00, 0x00}, cached.DenebForkVersion)
+	require.Equal(t, "16", cached.Extra["TARGET_AGGREGATORS_PER_COMMITTEE"])
+	require.NotContains(t, cached.Extra, "NEW_VALUE")
+	require.Equal(t, "5", cached.Extra["BLOB_SCHEDULE"].([]any)[0].(map[string]any)["EPOCH"])
+	t.Log("Changes to a returned spec didn't reach the cache")
+}
+
+// Test that forks missing from the spec are treated as unscheduled, numbers are parsed as decimal, and malformed values are rejected
+func TestParseBeaconSpec(t *testing.T) {
+	spec, err := common.ParseBeaconSpec(map[string]any{
+		"SLOTS_PER_EPOCH":  "8",
+		"SECONDS_PER_SLOT": "6",
+	})
+	require.NoError(t, err)
+	require.Equal(t, common.FarFutureEpoch, spec.DenebForkEpoch)
+	require.Empty(t, spec.Extra)
+
+	// Numbers are always decimal, even with a leading zero
+	spec, err = common.ParseBeaconSpec(map[string]any{
+		"SLOTS_PER_EPOCH":  "08",
+		"SECONDS_PER_SLOT": "012",
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), spec.SlotsPerEpoch)
+	require.Equal(t, uint64(12), spec.SecondsPerSlot)
+
+	_, err = common.ParseBeaconSpec(map[string]any{
+		"SLOTS_PER_EPOCH":  "eight",
+		"SECONDS_PER_SLOT": "6",
+	})
+	require.ErrorContains(t, err, "SLOTS_PER_EPOCH")
+
+	_, err = common.ParseBeaconSpec(map[string]any{
+		"SECONDS_PER_SLOT": "6",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the blob sidecars for blocks with and without blobs
+func TestGetBlobSidecars(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddBlobSidecars(300, 0)
+	bn.AddBlobSidecars(301, 3)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	ctx := context.Background()
+
+	// A block without any blobs
+	sidecars, err := sp.GetBlobSidecars(ctx, 300)
+	require.NoError(t, err)
+	require.Empty(t, sidecars)
+	t.Log("Block without blobs returned no sidecars")
+
+	// A block with several blobs
+	sidecars, err = sp.GetBlobSidecars(ctx, 301)
+	require.NoError(t, err)
+	require.Len(t, sidecars, 3)
+	for i, sidecar := range sidecars {
+		require.Equal(t, client.Uinteger(i), sidecar.Index)
+		require.Len(t, sidecar.KzgCommitment, 48)
+		require.Len(t, sidecar.KzgProof, 48)
+		require.Equal(t, bn.BlobSidecars[301][i].KzgCommitment, sidecar.KzgCommitment)
+		require.Equal(t, bn.BlobSidecars[301][i].KzgProof, sidecar.KzgProof)
+	}
+	t.Logf("Block with blobs returned %d sidecars", len(sidecars))
+
+	// A missed slot
+	sidecars, err = sp.GetBlobSidecars(ctx, 302)
+	require.NoError(t, err)
+	require.Empty(t, sidecars)
+}
+
+// Test that slots before Deneb are rejected
+func TestGetBlobSidecars_PreDeneb(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	// Deneb starts at epoch 4 in the mock spec
+	_, err := sp.GetBlobSidecars(context.Background(), 127)
+	require.ErrorIs(t, err, common.ErrBlobsNotActive)
+	_, err = sp.GetBlobSidecars(context.Background(), 128)
+	require.NoError(t, err)
+}
@@ -0,0 +1,95 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The number of blocks in the mock chain and the time of its genesis block
+	blockTimestampHead    uint64 = 1000
+	blockTimestampGenesis uint64 = 1700000000
+)
+
+// Test finding the blocks at and after timestamps on a chain with missed slots
+func TestGetBlockByTimestamp(t *testing.T) {
+	ec, lookups := newBlockTimestampExecutionClient(t)
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	ctx := context.Background()
+
+	tests := map[time.Time]uint64{
+		getMockBlockTime(0):                             0,
+		getMockBlockTime(1):                             1,
+		getMockBlockTime(437):                           437,
+		getMockBlockTime(437).Add(time.Second):          438,
+		getMockBlockTime(500).Add(-time.Second):         500,
+		getMockBlockTime(499).Add(time.Second):          500,
+		getMockBlockTime(blockTimestampHead - 1):        blockTimestampHead - 1,
+		getMockBlockTime(blockTimestampHead):            blockTimestampHead,
+		getMockBlockTime(0).Add(500 * time.Millisecond): 1,
+	}
+	for ts, expected := range tests {
+		*lookups = 0
+		block, err := sp.GetBlockByTimestamp(ctx, ts)
+		require.NoError(t, err)
+		require.Equal(t, expected, block, "timestamp %d", ts.Unix())
+		require.LessOrEqual(t, *lookups, 12)
+	}
+}
+
+// Test the errors for timestamps outside of the chain
+func TestGetBlockByTimestamp_OutOfRange(t *testing.T) {
+	ec, _ := newBlockTimestampExecutionClient(t)
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	ctx := context.Background()
+
+	_, err := sp.GetBlockByTimestamp(ctx, getMockBlockTime(0).Add(-time.Second))
+	var beforeErr *common.TimestampBeforeGenesisError
+	require.ErrorAs(t, err, &beforeErr)
+	require.True(t, getMockBlockTime(0).Equal(beforeErr.GenesisTime))
+
+	_, err = sp.GetBlockByTimestamp(ctx, getMockBlockTime(blockTimestampHead).Add(time.Second))
+	var afterErr *common.TimestampAfterHeadError
+	require.ErrorAs(t, err, &afterErr)
+	require.Equal(t, blockTimestampHead, afterErr.HeadBlock)
+	require.True(t, getMockBlockTime(blockTimestampHead).Equal(afterErr.HeadTime))
+	t.Logf("Out of range errors: [%s] and [%s]", beforeErr.Error(), afterErr.Error())
+}
+
+// Creates an Execution Client that serves the headers of the mock chain, along with a count of the headers it has served
+func newBlockTimestampExecutionClient(t *testing.T) (*mockExecutionClient, *int) {
+	ec := newMockExecutionClient(t, 1)
+	lookups := 0
+	ec.Handlers["eth_getBlockByNumber"] = func(params []json.RawMessage) (any, error) {
+		var tag string
+		err := json.Unmarshal(params[0], &tag)
+		require.NoError(t, err)
+		number := blockTimestampHead
+		if tag != "latest" {
+			number, err = hexutil.DecodeUint64(tag)
+			require.NoError(t, err)
+		}
+		lookups++
+		return &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(0),
+			Time:       uint64(getMockBlockTime(number).Unix()),
+		}, nil
+	}
+	return ec, &lookups
+}
+
+// Gets the time of a block in the mock chain, which has 12 second slots and misses 5 of them every 100 blocks
+func getMockBlockTime(number uint64) time.Time {
+	return time.Unix(int64(blockTimestampGenesis+number*12+(number/100)*60), 0)
+}
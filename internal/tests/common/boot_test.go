@@ -0,0 +1,323 @@
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/hyperdrive-daemon/tasks"
+	"github.com/nodeset-org/osha/docker"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// A Docker mock where each client only becomes reachable a while after its container starts, recording the start order
+type stagedDockerClient struct {
+	*docker.DockerMockManager
+	startDelay time.Duration
+	onStart    map[string]func()
+
+	// The containers that were started, and every start and readiness event in order
+	started []string
+	events  []string
+	lock    *sync.Mutex
+}
+
+// Records a boot event
+func (d *stagedDockerClient) recordEvent(event string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.events = append(d.events, event)
+}
+
+func (d *stagedDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	err := d.DockerMockManager.ContainerStart(ctx, containerID, options)
+	if err != nil {
+		return err
+	}
+	d.lock.Lock()
+	d.started = append(d.started, containerID)
+	d.lock.Unlock()
+	d.recordEvent("start " + containerID)
+	if callback, exists := d.onStart[containerID]; exists {
+		time.AfterFunc(d.startDelay, callback)
+	}
+	return nil
+}
+
+func (d *stagedDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]dtypes.Container, error) {
+	containers, err := d.DockerMockManager.ContainerList(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return filterContainersByLabel(containers, options), nil
+}
+
+func (d *stagedDockerClient) ContainerRestart(ctx context.Context, containerID string, options container.StopOptions) error {
+	return d.ContainerStart(ctx, containerID, container.StartOptions{})
+}
+
+// The pieces of a boot sequencing test
+type bootTest struct {
+	sp     *common.ServiceProvider
+	docker *stagedDockerClient
+	ec     *mockExecutionClient
+	engine *mockExecutionClient
+	bn     *mockBeaconNode
+
+	ecContainer string
+	bnContainer string
+	vcContainer string
+
+	// The directory holding the Engine API secret, mounted into the EC and BN containers
+	secretsDir string
+
+	// The Engine API secret
+	jwtSecret []byte
+}
+
+// Sets up a local-mode node with stopped EC, BN, and VC containers whose clients start refusing requests until their containers are started
+func newBootTest(t *testing.T, timeout time.Duration) *bootTest {
+	cfg := newTestConfig(t, "", "")
+	cfg.ClientMode.Value = config.ClientMode_Local
+	cfg.StartupTimeout.Value = uint64(timeout / time.Second)
+
+	test := &bootTest{
+		docker: &stagedDockerClient{
+			DockerMockManager: docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil))),
+			startDelay:        300 * time.Millisecond,
+			onStart:           map[string]func(){},
+			lock:              &sync.Mutex{},
+		},
+		ec:          newMockExecutionClient(t, uint64(cfg.GetNetworkResources().ChainID)),
+		engine:      newMockExecutionClient(t, uint64(cfg.GetNetworkResources().ChainID)),
+		bn:          newMockBeaconNode(t),
+		ecContainer: cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient)),
+		bnContainer: cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode)),
+		vcContainer: cfg.GetDockerArtifactName("sw_vc"),
+		secretsDir:  t.TempDir(),
+		jwtSecret:   bytes.Repeat([]byte{0x5a}, 32),
+	}
+	err := os.WriteFile(filepath.Join(test.secretsDir, hdconfig.EngineJwtFilename), []byte(hex.EncodeToString(test.jwtSecret)), 0640)
+	require.NoError(t, err)
+
+	// The Engine API comes up with the EC
+	test.engine.JwtSecret = test.jwtSecret
+	test.engine.SetResult("engine_exchangeCapabilities", []string{"engine_newPayloadV3", "engine_forkchoiceUpdatedV3", "engine_getPayloadV3", "engine_getPayloadBodiesByHashV1"})
+	test.engine.SetUnavailable(true)
+	cfg.EngineApiUrl.Value = test.engine.URL
+	test.ec.SetUnavailable(true)
+	test.bn.SetUnavailable(true)
+	test.docker.onStart[test.ecContainer] = func() {
+		test.ec.SetUnavailable(false)
+		test.engine.SetUnavailable(false)
+		test.docker.recordEvent("ready " + test.ecContainer)
+	}
+	test.docker.onStart[test.bnContainer] = func() {
+		test.bn.SetUnavailable(false)
+		test.docker.recordEvent("ready " + test.bnContainer)
+	}
+
+	// Add the stopped clients and a Validator Client for an enabled module that hasn't been started yet
+	then := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+	secretsMounts := []dtypes.MountPoint{
+		{Type: mount.TypeBind, Source: test.secretsDir, Destination: "/secrets"},
+	}
+	test.addContainer(t, test.ecContainer, &dtypes.ContainerState{StartedAt: then, FinishedAt: then}, nil, secretsMounts)
+	test.addContainer(t, test.bnContainer, &dtypes.ContainerState{StartedAt: then, FinishedAt: then}, nil, secretsMounts)
+	cfg.Modules["stakewise"] = map[string]any{hdconfig.ModuleEnableID: "true"}
+	test.addContainer(t, test.vcContainer, newCreatedContainerState(), map[string]string{
+		hdconfig.InstanceLabel: cfg.ProjectName.Value,
+		hdconfig.ModuleLabel:   "stakewise",
+	}, nil)
+
+	test.sp = newDockerTestServiceProviderWithUrls(t, cfg, test.docker, test.ec.URL, test.bn.URL)
+	return test
+}
+
+// Adds a container to the mock
+func (test *bootTest) addContainer(t *testing.T, name string, state *dtypes.ContainerState, labels map[string]string, mounts []dtypes.MountPoint) {
+	var size int64
+	err := test.docker.Mock_AddContainer(dtypes.ContainerJSON{
+		ContainerJSONBase: &dtypes.ContainerJSONBase{
+			Name:       name,
+			Created:    time.Now().Add(-time.Hour).Format(time.RFC3339),
+			State:      state,
+			HostConfig: &container.HostConfig{},
+			SizeRw:     &size,
+			SizeRootFs: &size,
+		},
+		Mounts:          mounts,
+		Config:          &container.Config{Labels: labels},
+		NetworkSettings: &dtypes.NetworkSettings{},
+	})
+	require.NoError(t, err)
+}
+
+// Gets the state of a container that was created but never started
+func newCreatedContainerState() *dtypes.ContainerState {
+	never := time.Time{}.Format(time.RFC3339Nano)
+	return &dtypes.ContainerState{StartedAt: never, FinishedAt: never}
+}
+
+// Test that the clients are started in order, each after the previous one is ready
+func TestBootClients_Order(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+
+	err := test.sp.BootClients(context.Background(), []string{test.vcContainer})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"start " + test.ecContainer,
+		"ready " + test.ecContainer,
+		"start " + test.bnContainer,
+		"ready " + test.bnContainer,
+		"start " + test.vcContainer,
+	}, test.docker.events)
+	t.Logf("Boot events: %v", test.docker.events)
+}
+
+// Test that startup stops at the first client that doesn't come up, naming it in the error
+func TestBootClients_Timeout(t *testing.T) {
+	test := newBootTest(t, 1*time.Second)
+	delete(test.docker.onStart, test.bnContainer)
+
+	err := test.sp.BootClients(context.Background(), []string{test.vcContainer})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Beacon Node")
+	require.Contains(t, err.Error(), "503")
+	require.Equal(t, []string{test.ecContainer, test.bnContainer}, test.docker.started)
+	var bootErr *common.ClientBootError
+	require.ErrorAs(t, err, &bootErr)
+	require.Equal(t, "Beacon Node", bootErr.Client)
+	require.True(t, bootErr.NotReady)
+	require.True(t, strings.HasPrefix(err.Error(), "Beacon Node didn't come up within 1s: "))
+	t.Logf("Startup failed with: %s", err.Error())
+}
+
+// Test that only the Validator Client containers of this instance's enabled modules that haven't been stopped are found
+func TestGetValidatorClientContainers(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+	cfg := test.sp.GetConfig()
+	project := cfg.ProjectName.Value
+	cfg.Modules["constellation"] = map[string]any{hdconfig.ModuleEnableID: "true"}
+	cfg.Modules["disabled"] = map[string]any{hdconfig.ModuleEnableID: "false"}
+	then := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+
+	// A running VC of an enabled module is included
+	runningVc := cfg.GetDockerArtifactName("const_vc")
+	test.addContainer(t, runningVc, &dtypes.ContainerState{Running: true, StartedAt: then, FinishedAt: time.Time{}.Format(time.RFC3339Nano)}, map[string]string{
+		hdconfig.InstanceLabel: project,
+		hdconfig.ModuleLabel:   "constellation",
+	}, nil)
+
+	// A VC that was stopped, one of a disabled module, one without a module, and one of another instance whose project
+	// name starts with this one's are left out
+	test.addContainer(t, cfg.GetDockerArtifactName("stopped_vc"), &dtypes.ContainerState{StartedAt: then, FinishedAt: then}, map[string]string{
+		hdconfig.InstanceLabel: project,
+		hdconfig.ModuleLabel:   "stakewise",
+	}, nil)
+	test.addContainer(t, cfg.GetDockerArtifactName("disabled_vc"), newCreatedContainerState(), map[string]string{
+		hdconfig.InstanceLabel: project,
+		hdconfig.ModuleLabel:   "disabled",
+	}, nil)
+	test.addContainer(t, cfg.GetDockerArtifactName("unknown_vc"), newCreatedContainerState(), map[string]string{
+		hdconfig.InstanceLabel: project,
+	}, nil)
+	test.addContainer(t, project+"_test_sw_vc", newCreatedContainerState(), map[string]string{
+		hdconfig.InstanceLabel: project + "_test",
+		hdconfig.ModuleLabel:   "stakewise",
+	}, nil)
+
+	// Other containers of an enabled module aren't Validator Clients
+	test.addContainer(t, cfg.GetDockerArtifactName("sw_operator"), newCreatedContainerState(), map[string]string{
+		hdconfig.InstanceLabel: project,
+		hdconfig.ModuleLabel:   "stakewise",
+	}, nil)
+
+	containers, err := test.sp.GetValidatorClientContainers(context.Background())
+	require.NoError(t, err)
+	expected := []string{runningVc, test.vcContainer}
+	sort.Strings(expected)
+	require.Equal(t, expected, containers)
+}
+
+// Test that the daemon's task loop boots the clients when it starts, including the Validator Clients it finds
+func TestTaskLoop_BootsClients(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+
+	wg := &sync.WaitGroup{}
+	err := tasks.NewTaskLoop(test.sp, wg).Run()
+	require.NoError(t, err)
+	defer func() {
+		test.sp.CancelContextOnShutdown()
+		wg.Wait()
+	}()
+
+	expected := []string{
+		"start " + test.ecContainer,
+		"ready " + test.ecContainer,
+		"start " + test.bnContainer,
+		"ready " + test.bnContainer,
+		"start " + test.vcContainer,
+	}
+	require.Eventually(t, func() bool {
+		test.docker.lock.Lock()
+		defer test.docker.lock.Unlock()
+		return len(test.docker.events) >= len(expected)
+	}, 5*time.Second, 50*time.Millisecond)
+	test.docker.lock.Lock()
+	defer test.docker.lock.Unlock()
+	require.Equal(t, expected, test.docker.events)
+}
+
+// Test that the task loop tries starting the clients again after a failed attempt, and starts the Validator Clients once
+// the others come up
+func TestTaskLoop_RetriesBoot(t *testing.T) {
+	test := newBootTest(t, 1*time.Second)
+
+	// The Beacon Node only comes up the second time it's started
+	bnStarts := 0
+	test.docker.onStart[test.bnContainer] = func() {
+		test.docker.lock.Lock()
+		bnStarts++
+		ready := bnStarts > 1
+		test.docker.lock.Unlock()
+		if ready {
+			test.bn.SetUnavailable(false)
+			test.docker.recordEvent("ready " + test.bnContainer)
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	err := tasks.NewTaskLoop(test.sp, wg).Run()
+	require.NoError(t, err)
+	defer func() {
+		test.sp.CancelContextOnShutdown()
+		wg.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		test.docker.lock.Lock()
+		defer test.docker.lock.Unlock()
+		return slices.Contains(test.docker.started, test.vcContainer)
+	}, 20*time.Second, 100*time.Millisecond)
+	test.docker.lock.Lock()
+	defer test.docker.lock.Unlock()
+	require.Equal(t, 2, bnStarts)
+	require.Equal(t, "start "+test.vcContainer, test.docker.events[len(test.docker.events)-1])
+	t.Logf("Boot events: %v", test.docker.events)
+}
@@ -0,0 +1,86 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the builder boost factor flag for each Validator Client
+func TestGetVcBuilderBoostFlags(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.MevBoost.BuilderBoostFactor.Value = 250
+
+	for _, test := range []struct {
+		client config.BeaconNode
+		flags  []string
+	}{
+		{client: config.BeaconNode_Lighthouse, flags: []string{"--builder-boost-factor=250"}},
+		{client: config.BeaconNode_Lodestar, flags: []string{"--builder.boostFactor=250"}},
+		{client: config.BeaconNode_Nimbus, flags: []string{}},
+		{client: config.BeaconNode_Prysm, flags: []string{}},
+		{client: config.BeaconNode_Teku, flags: []string{}},
+	} {
+		cfg.ExternalBeaconClient.BeaconNode.Value = test.client
+		require.Equal(t, test.flags, cfg.GetVcBuilderBoostFlags(), test.client)
+	}
+
+	// The default factor doesn't need a flag
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Lighthouse
+	cfg.MevBoost.BuilderBoostFactor.Value = hdconfig.BuilderBoostFactor_Default
+	require.Empty(t, cfg.GetVcBuilderBoostFlags())
+	cfg.MevBoost.BuilderBoostFactor.Value = hdconfig.BuilderBoostFactor_AlwaysBuilder
+	require.Equal(t, []string{"--builder-boost-factor=18446744073709551615"}, cfg.GetVcBuilderBoostFlags())
+}
+
+// Test setting the builder boost factor, which marks the VC for a restart until it's recreated
+func TestSetBuilderBoostFactor(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Lodestar
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	require.Equal(t, hdconfig.BuilderBoostFactor_Default, sp.GetBuilderBoostFactor())
+
+	// Setting the same value doesn't need a restart
+	require.NoError(t, sp.SetBuilderBoostFactor(hdconfig.BuilderBoostFactor_Default))
+	require.Empty(t, sp.GetContainersPendingRestart())
+
+	require.NoError(t, sp.SetBuilderBoostFactor(0))
+	require.Equal(t, uint64(0), sp.GetBuilderBoostFactor())
+	require.Equal(t, []config.ContainerID{config.ContainerID_ValidatorClient}, sp.GetContainersPendingRestart())
+	_, err := sp.CreateContainer(context.Background(), config.ContainerID_ValidatorClient, &container.Config{}, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, sp.GetContainersPendingRestart())
+
+	// Out of range factors are rejected
+	err = sp.SetBuilderBoostFactor(hdconfig.BuilderBoostFactor_MaxPercentage + 1)
+	require.ErrorIs(t, err, common.ErrInvalidBuilderBoostFactor)
+	require.Equal(t, uint64(0), sp.GetBuilderBoostFactor())
+	require.NoError(t, sp.SetBuilderBoostFactor(hdconfig.BuilderBoostFactor_AlwaysBuilder))
+}
+
+// Test that Validator Clients without the setting reject it
+func TestSetBuilderBoostFactor_Unsupported(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Prysm
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	err := sp.SetBuilderBoostFactor(200)
+	require.ErrorIs(t, err, common.ErrUnsupportedByClient)
+	require.Equal(t, hdconfig.BuilderBoostFactor_Default, sp.GetBuilderBoostFactor())
+	require.Empty(t, sp.GetContainersPendingRestart())
+
+	// Editing the config directly still gets caught
+	cfg.MevBoost.BuilderBoostFactor.Value = 200
+	require.Empty(t, cfg.Validate())
+	warnings := cfg.GetWarnings()
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "prysm")
+	cfg.MevBoost.BuilderBoostFactor.Value = hdconfig.BuilderBoostFactor_MaxPercentage + 1
+	require.Len(t, cfg.Validate(), 1)
+}
@@ -0,0 +1,36 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/log"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the Execution Client's chain ID is checked against the configured network's
+func TestVerifyExecutionChainId(t *testing.T) {
+	ctx := log.NewDefaultLogger().CreateContextWithLogger(context.Background())
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	expected := uint64(cfg.GetNetworkResources().ChainID)
+
+	ec := newMockExecutionClient(t, expected)
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+	require.NoError(t, sp.VerifyExecutionChainId(ctx))
+
+	// A client on a different chain is reported with both IDs
+	wrongEc := newMockExecutionClient(t, 31337)
+	sp = newTestServiceProvider(t, "http://127.0.0.1:1", wrongEc.URL)
+	err := sp.VerifyExecutionChainId(ctx)
+	require.ErrorIs(t, err, common.ErrChainIdMismatch)
+	require.ErrorContains(t, err, "31337")
+	require.ErrorContains(t, err, fmt.Sprintf("expected %d", expected))
+
+	// Failing to reach the client isn't a mismatch
+	sp = newTestServiceProvider(t, "http://127.0.0.1:1", "")
+	err = sp.VerifyExecutionChainId(ctx)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, common.ErrChainIdMismatch)
+}
@@ -0,0 +1,67 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test reporting a Lighthouse node's backfill while it's checkpoint syncing, and once its history is complete
+func TestGetCheckpointSyncProgress(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.SetSyncDistance(12)
+	bn.SetBackfilling(256, 96)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	progress, err := sp.GetCheckpointSyncProgress(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, common.CheckpointSyncProgress{
+		ClientName:        "Lighthouse",
+		HeadSlot:          320,
+		SyncDistance:      12,
+		IsSyncing:         true,
+		IsOptimistic:      true,
+		HasBackfillDetail: true,
+		AnchorSlot:        256,
+		OldestBlockSlot:   96,
+		BackfillDistance:  96,
+	}, progress)
+
+	// Backfilled, but the states before the checkpoint haven't been rebuilt
+	bn.LighthouseAnchor.OldestBlockSlot = 0
+	progress, err = sp.GetCheckpointSyncProgress(context.Background())
+	require.NoError(t, err)
+	require.True(t, progress.BackfillComplete)
+	require.Zero(t, progress.BackfillDistance)
+	require.False(t, progress.StateReconstructionComplete)
+
+	bn.LighthouseAnchor.StateUpperLimit = 0
+	progress, err = sp.GetCheckpointSyncProgress(context.Background())
+	require.NoError(t, err)
+	require.True(t, progress.StateReconstructionComplete)
+
+	bn.LighthouseAnchor = nil
+	progress, err = sp.GetCheckpointSyncProgress(context.Background())
+	require.NoError(t, err)
+	require.True(t, progress.HasBackfillDetail)
+	require.True(t, progress.BackfillComplete)
+	require.True(t, progress.StateReconstructionComplete)
+}
+
+// Test that clients without a backfill route still report the standard sync status
+func TestGetCheckpointSyncProgress_NoBackfillDetail(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Version = "teku/v24.4.0/linux-x86_64/-eclipseadoptium-openjdk64bitservervm-java-21"
+	bn.SetBackfilling(256, 96)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	progress, err := sp.GetCheckpointSyncProgress(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, common.CheckpointSyncProgress{
+		ClientName:   "teku",
+		HeadSlot:     320,
+		IsOptimistic: true,
+	}, progress)
+}
@@ -0,0 +1,161 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the churn limits on Deneb, where the activation churn is capped
+func TestGetChurnInfo_Deneb(t *testing.T) {
+	bn := newChurnTestBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	info, err := sp.GetChurnInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "DENEB", info.Fork)
+	require.Equal(t, uint64(10), info.Epoch)
+	require.Equal(t, uint64(205), info.ActiveValidatorCount)
+	require.Equal(t, uint64(205*32e9), info.TotalActiveBalance)
+	require.Equal(t, uint64(8), info.ActivationChurnLimit)
+	require.Equal(t, uint64(12), info.ExitChurnLimit)
+	require.Equal(t, uint64(20), info.ActivationQueueLength)
+	require.Equal(t, uint64(5), info.ExitQueueLength)
+	require.Equal(t, uint64(3), info.ActivationQueueEpochs)
+	require.Equal(t, uint64(1), info.ExitQueueEpochs)
+	t.Logf("Churn limits were correct: %d activations and %d exits per epoch", info.ActivationChurnLimit, info.ExitChurnLimit)
+}
+
+// Test the churn limits before Deneb, where activations and exits share the same limit
+func TestGetChurnInfo_Capella(t *testing.T) {
+	bn := newChurnTestBeaconNode(t)
+	bn.Spec["DENEB_FORK_EPOCH"] = "20"
+	bn.ForkVersion = []byte{0x03, 0x00, 0x00, 0x00}
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	info, err := sp.GetChurnInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "CAPELLA", info.Fork)
+	require.Equal(t, uint64(12), info.ActivationChurnLimit)
+	require.Equal(t, uint64(12), info.ExitChurnLimit)
+	require.Equal(t, uint64(2), info.ActivationQueueEpochs)
+}
+
+// Test the balance-based churn limits on Electra
+func TestGetChurnInfo_Electra(t *testing.T) {
+	bn := newChurnTestBeaconNode(t)
+	bn.ActivateElectra()
+	for i := 0; i < 3; i++ {
+		bn.PendingDeposits = append(bn.PendingDeposits, common.BeaconPendingDeposit{Amount: 32e9})
+	}
+	bn.PendingDeposits = append(bn.PendingDeposits, common.BeaconPendingDeposit{Amount: 1e9})
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	info, err := sp.GetChurnInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "ELECTRA", info.Fork)
+	require.Equal(t, uint64(256e9), info.BalanceChurnLimit)
+	require.Equal(t, uint64(8), info.ActivationChurnLimit)
+	require.Equal(t, uint64(8), info.ExitChurnLimit)
+
+	// The activation queue comes from the pending deposits rather than the validator statuses
+	require.Equal(t, uint64(4), info.ActivationQueueLength)
+	require.Equal(t, uint64(3*32e9+1e9), info.PendingDepositBalance)
+	require.Equal(t, uint64(1), info.ActivationQueueEpochs)
+	require.Equal(t, uint64(5), info.ExitQueueLength)
+}
+
+// Test that a fork after Electra keeps the Electra churn, and that a fork version the spec doesn't have is reported as
+// unknown instead of being mistaken for an earlier fork
+func TestGetChurnInfo_PostElectra(t *testing.T) {
+	bn := newChurnTestBeaconNode(t)
+	bn.ActivateElectra()
+	bn.Spec["FULU_FORK_VERSION"] = "0x06000000"
+	bn.Spec["FULU_FORK_EPOCH"] = "8"
+	bn.ForkVersion = []byte{0x06, 0x00, 0x00, 0x00}
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	info, err := sp.GetChurnInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "FULU", info.Fork)
+	require.True(t, info.ElectraActive)
+	require.Equal(t, uint64(256e9), info.BalanceChurnLimit)
+	_, err = sp.GetTotalEffectiveBalance(context.Background())
+	require.NoError(t, err)
+
+	// A fork newer than the ones in the spec
+	bn.ForkVersion = []byte{0x07, 0x00, 0x00, 0x00}
+	info, err = sp.GetChurnInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, common.UnknownForkName, info.Fork)
+	require.True(t, info.ElectraActive)
+	require.Equal(t, uint64(256e9), info.BalanceChurnLimit)
+}
+
+// Test that the total effective balance counts compounding validators at their full balance, and uses it for the churn
+func TestGetTotalEffectiveBalance_Compounding(t *testing.T) {
+	bn := newChurnTestBeaconNode(t)
+	bn.ActivateElectra()
+	bn.Spec["MAX_PER_EPOCH_ACTIVATION_EXIT_CHURN_LIMIT"] = "1000000000000000"
+	for i := 0; i < 100; i++ {
+		bn.SetEffectiveBalance(i, 2048e9)
+	}
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	// 100 compounding validators, 105 at 32 ETH (including the exiting ones), and none of the pending or exited ones
+	total, err := sp.GetTotalEffectiveBalance(context.Background())
+	require.NoError(t, err)
+	expected := uint64(100*2048e9 + 105*32e9)
+	require.Equal(t, expected, total)
+
+	info, err := sp.GetChurnInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, expected, info.TotalActiveBalance)
+	require.Equal(t, expected/16, info.BalanceChurnLimit)
+	t.Logf("Total effective balance: %d gwei", total)
+}
+
+// Test that the total effective balance isn't available before Electra
+func TestGetTotalEffectiveBalance_PreElectra(t *testing.T) {
+	bn := newChurnTestBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	_, err := sp.GetTotalEffectiveBalance(context.Background())
+	require.ErrorIs(t, err, common.ErrElectraNotActive)
+}
+
+// Test that the churn info is built from the committees and filtered queues instead of the full validator set
+func TestGetChurnInfo_NoFullValidatorSet(t *testing.T) {
+	bn := newChurnTestBeaconNode(t)
+	statuses := []string{}
+	bn.Handle("/eth/v1/beacon/states/320/validators", func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		statuses = append(statuses, status)
+		writeJson(w, http.StatusOK, client.ValidatorsResponse{
+			Data: filterValidators(bn.Validators, status, r.URL.Query().Get("id")),
+		})
+	})
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	info, err := sp.GetChurnInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(205), info.ActiveValidatorCount)
+	require.ElementsMatch(t, []string{"active_exiting", "pending_queued"}, statuses)
+	t.Log("Only the queued and exiting validators were requested")
+}
+
+// Creates a mock Beacon Node with a small churn quotient so the limits are above the minimum
+func newChurnTestBeaconNode(t *testing.T) *mockBeaconNode {
+	bn := newMockBeaconNode(t)
+	bn.Spec["CHURN_LIMIT_QUOTIENT"] = "16"
+	bn.AddValidators(200, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.AddValidators(5, beacon.ValidatorState_ActiveExiting, 32e9)
+	bn.AddValidators(20, beacon.ValidatorState_PendingQueued, 32e9)
+	bn.AddValidators(3, beacon.ValidatorState_ExitedUnslashed, 32e9)
+	bn.AssignCommittees(10)
+	return bn
+}
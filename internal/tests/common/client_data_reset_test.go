@@ -0,0 +1,117 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test wiping the BN's data volume and starting it again with an empty one
+func TestResetClientData(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ClientMode.Value = config.ClientMode_Local
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	ctx := context.Background()
+	service := string(config.ContainerID_BeaconNode)
+	name := cfg.GetDockerArtifactName(service)
+	volumeName := cfg.GetDockerArtifactName(hdconfig.BeaconNodeDataVolume)
+
+	// Make the BN with a data volume and a bind-mounted script
+	mock.addVolume(volume.Volume{
+		Name:      volumeName,
+		Driver:    "local",
+		Labels:    map[string]string{"com.docker.compose.volume": hdconfig.BeaconNodeDataVolume},
+		UsageData: &volume.UsageData{Size: 900_000_000_000},
+	})
+	hostCfg := &container.HostConfig{
+		Mounts: []mount.Mount{
+			{Type: mount.TypeVolume, Source: volumeName, Target: "/ethclient"},
+			{Type: mount.TypeBind, Source: "/usr/share/hyperdrive/scripts", Target: "/setup"},
+		},
+	}
+	_, err := sp.CreateContainer(ctx, config.ContainerID_BeaconNode, &container.Config{Env: []string{"NETWORK=holesky"}}, hostCfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, sp.StartContainer(ctx, name))
+
+	// It has to be confirmed with the service name
+	err = sp.ResetClientData(ctx, service, "yes")
+	require.ErrorIs(t, err, common.ErrResetNotConfirmed)
+	require.Empty(t, mock.volumeEvents)
+
+	err = sp.ResetClientData(ctx, service, service)
+	require.NoError(t, err)
+	require.Equal(t, []string{"remove " + volumeName, "create " + volumeName}, mock.volumeEvents)
+
+	// The new volume is empty with the same settings, and the new container is running with the same mounts
+	vol, err := mock.VolumeInspect(ctx, volumeName)
+	require.NoError(t, err)
+	require.Equal(t, "local", vol.Driver)
+	require.Equal(t, hdconfig.BeaconNodeDataVolume, vol.Labels["com.docker.compose.volume"])
+	require.Nil(t, vol.UsageData)
+	info, err := mock.ContainerInspect(ctx, name)
+	require.NoError(t, err)
+	require.True(t, info.State.Running)
+	require.Equal(t, hostCfg.Mounts, info.HostConfig.Mounts)
+	require.Equal(t, []string{"NETWORK=holesky"}, info.Config.Env)
+}
+
+// Test that volumes the reset can't clear are left alone
+func TestResetClientData_Unmanaged(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	ctx := context.Background()
+
+	// The EC's volume is bound to a host directory
+	ecVolumeName := cfg.GetDockerArtifactName(hdconfig.ExecutionClientDataVolume)
+	mock.addVolume(volume.Volume{
+		Name:    ecVolumeName,
+		Driver:  "local",
+		Options: map[string]string{"type": "none", "o": "bind", "device": "/mnt/ssd/ecdata"},
+	})
+	_, err := sp.CreateContainer(ctx, config.ContainerID_ExecutionClient, &container.Config{}, &container.HostConfig{
+		Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: ecVolumeName, Target: "/ethclient"}},
+	}, nil)
+	require.NoError(t, err)
+	err = sp.ResetClientData(ctx, string(config.ContainerID_ExecutionClient), string(config.ContainerID_ExecutionClient))
+	require.ErrorIs(t, err, common.ErrBindMountedDataVolume)
+	require.ErrorContains(t, err, "/mnt/ssd/ecdata")
+
+	// MEV-Boost doesn't have one
+	_, err = sp.CreateContainer(ctx, config.ContainerID_MevBoost, &container.Config{}, nil, nil)
+	require.NoError(t, err)
+	err = sp.ResetClientData(ctx, string(config.ContainerID_MevBoost), string(config.ContainerID_MevBoost))
+	require.ErrorIs(t, err, common.ErrNoManagedDataVolume)
+	require.Empty(t, mock.volumeEvents)
+}
+
+// Test that the VC's data is only reset once its slashing protection is backed up, and that the backup is restored
+func TestResetClientData_ValidatorClient(t *testing.T) {
+	sp, keyManager, docker, pubkeys := newSlashingBackupTest(t)
+	ctx := context.Background()
+	service := string(config.ContainerID_ValidatorClient)
+	volumeName := sp.GetConfig().GetDockerArtifactName(testVcDataVolume)
+
+	// Refuse without backups
+	sp.GetConfig().KeyManager.SlashingProtectionBackups.Value = 0
+	err := sp.ResetClientData(ctx, service, service)
+	require.ErrorIs(t, err, common.ErrSlashingProtectionNotBackedUp)
+	require.Empty(t, docker.volumeEvents)
+
+	sp.GetConfig().KeyManager.SlashingProtectionBackups.Value = 2
+	err = sp.ResetClientData(ctx, service, service)
+	require.NoError(t, err)
+	require.Equal(t, []string{"remove " + volumeName, "create " + volumeName}, docker.volumeEvents)
+	requireSlashingProtectionRestored(t, keyManager, pubkeys)
+	backups, err := sp.GetSlashingProtectionBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+}
@@ -0,0 +1,53 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test that warming up reads from both clients and only does it once
+func TestWarmUpClients(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	ec := newMockExecutionClient(t, 1)
+	ec.SetResult("eth_blockNumber", "0x10")
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+
+	require.NoError(t, sp.WarmUpClients(context.Background()))
+	require.Equal(t, 1, ec.GetRequestCount("eth_chainId"))
+	require.Equal(t, 1, ec.GetRequestCount("eth_blockNumber"))
+	require.Equal(t, 1, bn.GetRequestCount("/eth/v1/node/syncing"))
+	require.Equal(t, 1, bn.GetRequestCount("/eth/v1/config/spec"))
+	require.Equal(t, 1, bn.GetRequestCount("/eth/v1/beacon/genesis"))
+
+	// The spec is cached now
+	_, err := sp.GetBeaconSpec(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, bn.GetRequestCount("/eth/v1/config/spec"))
+
+	// Warming up again doesn't make any requests
+	require.NoError(t, sp.WarmUpClients(context.Background()))
+	require.Equal(t, 1, ec.GetRequestCount("eth_chainId"))
+	require.Equal(t, 1, bn.GetRequestCount("/eth/v1/node/syncing"))
+}
+
+// Test that failed reads are all reported, and that the next call tries again
+func TestWarmUpClients_Failure(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	ec := newMockExecutionClient(t, 1)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	bn.SetUnavailable(true)
+
+	err := sp.WarmUpClients(context.Background())
+	require.ErrorContains(t, err, "Execution Client block number")
+	require.ErrorContains(t, err, "Beacon Node sync status")
+	require.ErrorContains(t, err, "Beacon spec")
+	require.ErrorContains(t, err, "Beacon genesis")
+	require.NotContains(t, err.Error(), "chain ID")
+
+	bn.SetUnavailable(false)
+	ec.SetResult("eth_blockNumber", "0x10")
+	require.NoError(t, sp.WarmUpClients(context.Background()))
+	require.Equal(t, 2, ec.GetRequestCount("eth_chainId"))
+}
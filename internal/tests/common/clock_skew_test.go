@@ -0,0 +1,81 @@
+package common_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a clock in sync with the Beacon Node has no skew
+func TestCheckClockSkew_InSync(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.FollowClock = true
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	skew, err := sp.CheckClockSkew(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, skew)
+
+	report := sp.GetHealthReport(context.Background())
+	require.Zero(t, report.ClockSkew)
+	for _, warning := range report.Warnings {
+		require.NotContains(t, warning, "clock")
+	}
+}
+
+// Test detecting a local clock that's behind or ahead of the Beacon Node's by more than a slot
+func TestCheckClockSkew_Skewed(t *testing.T) {
+	tests := []struct {
+		name        string
+		clockOffset time.Duration
+		minSkew     time.Duration
+		maxSkew     time.Duration
+	}{
+		// The head is from a slot that hasn't started locally
+		{"behind", 30 * time.Second, -30 * time.Second, -18 * time.Second},
+		// The head slot ended locally a while ago
+		{"ahead", -30 * time.Second, 18 * time.Second, 30 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bn := newMockBeaconNode(t)
+			bn.FollowClock = true
+			bn.ClockOffset = test.clockOffset
+			sp := newTestServiceProvider(t, bn.URL, "")
+
+			skew, err := sp.CheckClockSkew(context.Background())
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, skew, test.minSkew)
+			require.LessOrEqual(t, skew, test.maxSkew)
+
+			report := sp.GetHealthReport(context.Background())
+			require.NotZero(t, report.ClockSkew)
+			found := false
+			for _, warning := range report.Warnings {
+				if strings.HasPrefix(warning, "The system clock is off") {
+					found = true
+				}
+			}
+			require.True(t, found, "missing clock warning in %v", report.Warnings)
+		})
+	}
+}
+
+// Test that skew under the configured threshold isn't flagged
+func TestCheckClockSkew_Threshold(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.FollowClock = true
+	bn.ClockOffset = -30 * time.Second
+	cfg := newTestConfig(t, bn.URL, "")
+	cfg.ClockSkewThreshold.Value = 60000
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	report := sp.GetHealthReport(context.Background())
+	require.NotZero(t, report.ClockSkew)
+	for _, warning := range report.Warnings {
+		require.NotContains(t, warning, "clock")
+	}
+}
@@ -0,0 +1,63 @@
+package common_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that saving a config change and recording a snapshot adds it to the history, with its secrets redacted
+func TestConfigHistory(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	history, err := sp.GetConfigHistory()
+	require.NoError(t, err)
+	require.Empty(t, history)
+
+	// The first snapshot comes from the config the daemon started with, since it hasn't been saved yet
+	require.NoError(t, sp.RecordConfigSnapshot())
+	cfg.ExternalExecutionClient.HttpUrl.Value = "https://eth.provider.example/v1?apikey=super-secret-key"
+	_, err = cfg.SaveToFile(filepath.Join(cfg.GetUserDirectory(), hdconfig.ConfigFilename), nil)
+	require.NoError(t, err)
+	require.NoError(t, sp.RecordConfigSnapshot())
+
+	history, err = sp.GetConfigHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.NotEqual(t, history[0].Hash, history[1].Hash)
+	require.False(t, history[1].Timestamp.Before(history[0].Timestamp))
+
+	changes, err := sp.DiffConfigSnapshots(history[0].ID, history[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, []common.ConfigChange{
+		{
+			Path:     "hyperdrive.externalExecution.httpUrl",
+			Type:     common.ConfigChangeType_Changed,
+			OldValue: "http://127.0.0.1:1",
+			NewValue: "https://eth.provider.example/v1?apikey=[REDACTED]",
+		},
+	}, changes)
+
+	// The secret never makes it into the journal
+	journal, err := os.ReadFile(filepath.Join(cfg.GetUserDirectory(), hdconfig.ConfigHistoryFilename))
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(journal), "super-secret-key"))
+
+	// Recording the same config again gives the same hash, and an empty diff
+	require.NoError(t, sp.RecordConfigSnapshot())
+	history, err = sp.GetConfigHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, history[1].Hash, history[2].Hash)
+	changes, err = sp.DiffConfigSnapshots(history[1].ID, history[2].ID)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	_, err = sp.DiffConfigSnapshots(history[0].ID, "missing")
+	require.ErrorIs(t, err, common.ErrConfigSnapshotNotFound)
+}
@@ -0,0 +1,71 @@
+package common_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// Test that serializing the config repeatedly produces identical output
+func TestConfigSerialization_Deterministic(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	serialize := func() ([]byte, []byte, string) {
+		settings, err := yaml.Marshal(cfg.Serialize(nil, false))
+		require.NoError(t, err)
+		schema, err := json.Marshal(cfg.Schema())
+		require.NoError(t, err)
+		return settings, schema, strings.Join(cfg.ExportEnv(), "\n")
+	}
+
+	// Map iteration order is randomized on every pass, so a few rounds will catch anything that depends on it
+	firstSettings, firstSchema, firstEnv := serialize()
+	for i := 0; i < 20; i++ {
+		settings, schema, env := serialize()
+		require.Equal(t, firstSettings, settings)
+		require.Equal(t, firstSchema, schema)
+		require.Equal(t, firstEnv, env)
+	}
+	t.Logf("Serialized %d parameters identically every time", len(cfg.Schema()))
+}
+
+// Test that parameters come back in declaration order
+func TestConfigSchema_DeclarationOrder(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+
+	// Every subconfig must have an explicit place in the order
+	order := cfg.GetSubconfigOrder()
+	for id := range cfg.GetSubconfigs() {
+		require.Contains(t, order, id)
+	}
+
+	schema := cfg.Schema()
+	rootParams := cfg.GetParameters()
+	for i, param := range rootParams {
+		require.Equal(t, param.GetCommon().ID, schema[i].Key)
+	}
+
+	// Subconfigs follow the root parameters in their declared order
+	lastIndex := -1
+	for _, id := range order {
+		index := -1
+		for i, paramSchema := range schema {
+			if strings.HasPrefix(paramSchema.Key, id+".") {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			continue
+		}
+		require.Greater(t, index, lastIndex, "section %s is out of order", id)
+		lastIndex = index
+	}
+
+	require.Contains(t, cfg.ExportEnv(), hdconfig.GetExportEnvName(ids.KeyManagerID+"."+ids.KeyManagerTokenPathID)+"=")
+	require.Equal(t, "HD_KEY_MANAGER_TOKEN_PATH", hdconfig.GetExportEnvName("keyManager.tokenPath"))
+}
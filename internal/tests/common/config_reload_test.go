@@ -0,0 +1,91 @@
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Saves the config to the settings file in its user directory
+func saveTestConfig(t *testing.T, cfg *hdconfig.HyperdriveConfig) {
+	_, err := cfg.SaveToFile(filepath.Join(cfg.GetUserDirectory(), hdconfig.ConfigFilename), nil)
+	require.NoError(t, err)
+}
+
+// Test a reload that changes settings that apply live and settings that need a restart
+func TestReloadConfig(t *testing.T) {
+	server := newMockSmtpServer(t, "", "")
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	require.NoError(t, sp.ScheduleConfigTask("sync-check", &cfg.StuckSyncWindow, 0, func(ctx context.Context) {}))
+	logger := sp.GetApiLogger()
+	require.False(t, logger.Enabled(context.Background(), slog.LevelDebug))
+
+	saved := cfg.Clone()
+	saved.Logging.Level.Value = slog.LevelDebug
+	saved.StuckSyncWindow.Value = 60
+	saved.Alerts.SmtpHost.Value = server.Host
+	saved.Alerts.SmtpPort.Value = server.Port
+	saved.Alerts.SmtpFrom.Value = "hyperdrive@example.com"
+	saved.Alerts.SmtpTo.Value = "operator@example.com"
+	saved.Alerts.EmailSeverity.Value = hdconfig.AlertSeverity_Critical
+	saved.ExternalBeaconClient.HttpUrl.Value = "http://127.0.0.1:2"
+	saved.MevBoost.BuilderBoostFactor.Value = 50
+	saveTestConfig(t, saved)
+
+	result, err := sp.ReloadConfig(context.Background())
+	require.NoError(t, err)
+	applied := []string{}
+	for _, setting := range result.Applied {
+		applied = append(applied, setting.Path)
+	}
+	require.ElementsMatch(t, []string{
+		"logging.level",
+		"stuckSyncWindow",
+		"alerts.smtpHost",
+		"alerts.smtpPort",
+		"alerts.smtpFrom",
+		"alerts.smtpTo",
+		"alerts.emailSeverity",
+	}, applied)
+	restartRequired := map[string][]config.ContainerID{}
+	for _, setting := range result.RestartRequired {
+		restartRequired[setting.Path] = setting.Containers
+	}
+	require.Len(t, restartRequired, 2)
+	require.Contains(t, restartRequired, "externalBeacon.httpUrl")
+	require.Equal(t, cfg.MevBoost.BuilderBoostFactor.AffectsContainers, restartRequired["mevBoost.builderBoostFactor"])
+	require.Subset(t, sp.GetContainersPendingRestart(), cfg.MevBoost.BuilderBoostFactor.AffectsContainers)
+
+	// The live settings took effect, and the others were left alone
+	require.True(t, logger.Enabled(context.Background(), slog.LevelDebug))
+	logger.Debug("Debug logging after reload")
+	logContents, err := os.ReadFile(logger.GetFilePath())
+	require.NoError(t, err)
+	require.Contains(t, string(logContents), "Debug logging after reload")
+	require.Equal(t, time.Minute, sp.GetScheduler().GetTaskStatuses()[0].Interval)
+	require.Equal(t, "http://127.0.0.1:1", cfg.ExternalBeaconClient.HttpUrl.Value)
+	require.Len(t, sp.GetAlertManager().GetSinks(hdconfig.AlertSeverity_Critical), 1)
+	require.Empty(t, sp.GetAlertManager().GetSinks(hdconfig.AlertSeverity_Warning))
+
+	// An invalid config isn't applied at all; it's edited by hand, since saving validates it
+	saved.Logging.Level.Value = slog.LevelError
+	saveTestConfig(t, saved)
+	path := filepath.Join(cfg.GetUserDirectory(), hdconfig.ConfigFilename)
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, bytes.ReplaceAll(contents, []byte("operator@example.com"), []byte("not an address")), 0644))
+	_, err = sp.ReloadConfig(context.Background())
+	require.ErrorContains(t, err, "isn't a valid email address")
+	require.Equal(t, slog.LevelDebug, cfg.Logging.Level.Value)
+	require.True(t, logger.Enabled(context.Background(), slog.LevelDebug))
+	require.Equal(t, "operator@example.com", cfg.Alerts.SmtpTo.Value)
+}
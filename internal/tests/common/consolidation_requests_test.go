@@ -0,0 +1,105 @@
+package common_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test consolidating validators into one of the node's compounding validators
+func TestSubmitConsolidation(t *testing.T) {
+	bn, _, sp, sent := newExecutionRequestTestServiceProvider(t)
+	bn.SetWithdrawalCredentials(1, getNodeWithdrawalCredentials(0x02))
+	bn.SetWithdrawalCredentials(2, getNodeWithdrawalCredentials(0x02))
+	pubkeys := getMockValidatorPubkeys(bn)
+	ctx := context.Background()
+
+	// Both execution and compounding validators can be the source
+	for _, source := range []beacon.ValidatorPubkey{pubkeys[0], pubkeys[2]} {
+		hash, err := sp.SubmitConsolidation(ctx, source, pubkeys[1])
+		require.NoError(t, err)
+		tx := (*sent)[len(*sent)-1]
+		require.Equal(t, tx.Hash(), hash)
+		require.Equal(t, common.ConsolidationRequestPredeployAddress, *tx.To())
+		require.Equal(t, big.NewInt(testExecutionRequestFee), tx.Value())
+		require.Equal(t, append(source[:], pubkeys[1][:]...), tx.Data())
+		sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		require.NoError(t, err)
+		require.Equal(t, testNodeAddress, sender)
+	}
+	require.Len(t, *sent, 2)
+}
+
+// Test that consolidations the Beacon Chain would ignore are rejected before anything is submitted
+func TestSubmitConsolidation_Invalid(t *testing.T) {
+	bn, _, sp, sent := newExecutionRequestTestServiceProvider(t)
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.SetWithdrawalCredentials(1, getNodeWithdrawalCredentials(0x02))
+	bn.SetWithdrawalCredentials(3, getMockWithdrawalCredentials(0x02, 3))
+	bn.SetWithdrawalCredentials(4, getMockWithdrawalCredentials(0x00, 4))
+	bn.SetWithdrawalCredentials(5, getNodeWithdrawalCredentials(0x01))
+	pubkeys := getMockValidatorPubkeys(bn)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name   string
+		source beacon.ValidatorPubkey
+		target beacon.ValidatorPubkey
+		err    error
+	}{{
+		name:   "self",
+		source: pubkeys[1],
+		target: pubkeys[1],
+		err:    common.ErrSelfConsolidation,
+	}, {
+		name:   "target not compounding",
+		source: pubkeys[0],
+		target: pubkeys[2],
+		err:    common.ErrTargetNotCompounding,
+	}, {
+		name:   "target not owned",
+		source: pubkeys[0],
+		target: pubkeys[3],
+		err:    common.ErrValidatorNotOwned,
+	}, {
+		name:   "source not owned",
+		source: pubkeys[3],
+		target: pubkeys[1],
+		err:    common.ErrValidatorNotOwned,
+	}, {
+		name:   "source has BLS credentials",
+		source: pubkeys[4],
+		target: pubkeys[1],
+		err:    common.ErrNoExecutionCredentials,
+	}, {
+		name:   "source missing",
+		source: beacon.ValidatorPubkey{0xbb},
+		target: pubkeys[1],
+		err:    common.ErrValidatorNotFound,
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := sp.SubmitConsolidation(ctx, test.source, test.target)
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+	require.Empty(t, *sent)
+}
+
+// Test that consolidations aren't submitted before Electra
+func TestSubmitConsolidation_PreElectra(t *testing.T) {
+	bn, _, sp, sent := newExecutionRequestTestServiceProvider(t)
+	bn.SetWithdrawalCredentials(1, getNodeWithdrawalCredentials(0x02))
+	delete(bn.Spec, "ELECTRA_FORK_VERSION")
+	delete(bn.Spec, "ELECTRA_FORK_EPOCH")
+	bn.ForkVersion = []byte{0x04, 0x00, 0x00, 0x00}
+	pubkeys := getMockValidatorPubkeys(bn)
+
+	_, err := sp.SubmitConsolidation(context.Background(), pubkeys[0], pubkeys[1])
+	require.ErrorIs(t, err, common.ErrElectraNotActive)
+	require.Empty(t, *sent)
+}
@@ -0,0 +1,118 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/nodeset-org/osha/docker"
+	"github.com/stretchr/testify/require"
+)
+
+// A Docker mock that adds latency to container restarts and records how many run at once
+type slowDockerClient struct {
+	*docker.DockerMockManager
+	latency time.Duration
+
+	active    atomic.Int32
+	maxActive atomic.Int32
+}
+
+func (d *slowDockerClient) ContainerRestart(ctx context.Context, containerID string, options container.StopOptions) error {
+	active := d.active.Add(1)
+	defer d.active.Add(-1)
+	for {
+		current := d.maxActive.Load()
+		if active <= current || d.maxActive.CompareAndSwap(current, active) {
+			break
+		}
+	}
+	time.Sleep(d.latency)
+	return d.DockerMockManager.ContainerRestart(ctx, containerID, options)
+}
+
+// Test that mass restarts respect the configured concurrency limit
+func TestRestartContainer_ConcurrencyLimit(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.MaxConcurrentContainerOps.Value = 2
+	mock := &slowDockerClient{
+		DockerMockManager: docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil))),
+		latency:           50 * time.Millisecond,
+	}
+
+	// Make some containers
+	containerCount := 8
+	ids := make([]string, containerCount)
+	now := time.Now().Format(time.RFC3339Nano)
+	for i := range ids {
+		ids[i] = cfg.GetDockerArtifactName(fmt.Sprintf("vc%d", i))
+		err := mock.Mock_AddContainer(dtypes.ContainerJSON{
+			ContainerJSONBase: &dtypes.ContainerJSONBase{
+				Name:    ids[i],
+				Created: now,
+				State: &dtypes.ContainerState{
+					StartedAt:  now,
+					FinishedAt: now,
+				},
+			},
+		})
+		require.NoError(t, err)
+	}
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+
+	// Restart them all at once
+	wg := &sync.WaitGroup{}
+	errs := make([]error, containerCount)
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs[i] = sp.RestartContainer(context.Background(), id)
+		}(i, id)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int32(2), mock.maxActive.Load())
+	t.Logf("Restarted %d containers with at most %d at a time", containerCount, mock.maxActive.Load())
+}
+
+// Test that waiting for a slot can be cancelled
+func TestRunContainerOp_Cancelled(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.MaxConcurrentContainerOps.Value = 1
+	mock := docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+
+	// Hold the only slot
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = sp.RunContainerOp(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	ran := false
+	err := sp.RunContainerOp(ctx, func() error {
+		ran = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, ran)
+}
@@ -0,0 +1,165 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/nodeset-org/osha/docker"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// A Docker mock that serves stats frames fed in by the test, streaming them to every open stats stream of the container
+type statsDockerClient struct {
+	*labeledDockerClient
+	lock    *sync.Mutex
+	latest  map[string]dtypes.StatsJSON
+	streams map[string][]*io.PipeWriter
+}
+
+func (d *statsDockerClient) ContainerStats(ctx context.Context, containerID string, stream bool) (dtypes.ContainerStats, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !stream {
+		frame, exists := d.latest[containerID]
+		if !exists {
+			return dtypes.ContainerStats{}, errors.New("no stats for container " + containerID)
+		}
+		reader, writer := io.Pipe()
+		go func() {
+			_ = json.NewEncoder(writer).Encode(frame)
+			writer.Close()
+		}()
+		return dtypes.ContainerStats{Body: reader, OSType: "linux"}, nil
+	}
+	reader, writer := io.Pipe()
+	d.streams[containerID] = append(d.streams[containerID], writer)
+	return dtypes.ContainerStats{Body: reader, OSType: "linux"}, nil
+}
+
+// Sends a synthetic stats frame to the container's open streams, blocking until each one has read it. Streams that have
+// been closed are dropped.
+func (d *statsDockerClient) FeedStats(containerID string, frame dtypes.StatsJSON) {
+	d.lock.Lock()
+	d.latest[containerID] = frame
+	streams := d.streams[containerID]
+	d.lock.Unlock()
+
+	open := []*io.PipeWriter{}
+	for _, writer := range streams {
+		err := json.NewEncoder(writer).Encode(frame)
+		if err == nil {
+			open = append(open, writer)
+		}
+	}
+	d.lock.Lock()
+	d.streams[containerID] = open
+	d.lock.Unlock()
+}
+
+// Test streaming a container's stats, parsing each synthetic frame, until the stream is cancelled
+func TestStreamContainerStats(t *testing.T) {
+	mock, sp := newStatsTestServiceProvider(t)
+	name := sp.GetConfig().GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	samples, err := sp.StreamContainerStats(ctx, string(config.ContainerID_BeaconNode))
+	require.NoError(t, err)
+	start := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		go mock.FeedStats(name, newTestStatsFrame(name, start.Add(time.Duration(i)*time.Second), uint64(i)))
+		sample := <-samples
+		require.Equal(t, name, sample.Name)
+		require.True(t, start.Add(time.Duration(i)*time.Second).Equal(sample.Time))
+
+		// 2 of the 4 CPUs' worth of time went to the container
+		require.InDelta(t, 200.0, sample.CpuPercent, 0.001)
+
+		// The inactive page cache is left out of the usage
+		require.Equal(t, uint64(3e9), sample.MemoryUsageBytes)
+		require.Equal(t, uint64(12e9), sample.MemoryLimitBytes)
+		require.InDelta(t, 25.0, sample.MemoryPercent, 0.001)
+		require.Equal(t, 1500+i*100, int(sample.NetworkRxBytes))
+		require.Equal(t, 700+i*50, int(sample.NetworkTxBytes))
+	}
+
+	// Cancelling closes the channel
+	cancel()
+	select {
+	case _, open := <-samples:
+		require.False(t, open)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stats channel wasn't closed after cancelling")
+	}
+	t.Log("The stream was closed when it was cancelled")
+}
+
+// Test that the health report includes the latest stats of the running managed containers
+func TestGetHealthReport_ContainerStats(t *testing.T) {
+	mock, sp := newStatsTestServiceProvider(t)
+	project := sp.GetConfig().ProjectName.Value
+	never := time.Time{}.Format(time.RFC3339Nano)
+	stopped := sp.GetConfig().GetDockerArtifactName(string(config.ContainerID_ExecutionClient))
+	mock.addContainer(t, stopped, project, &dtypes.ContainerState{Status: "exited", StartedAt: never, FinishedAt: never})
+	name := sp.GetConfig().GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	mock.FeedStats(name, newTestStatsFrame(name, time.Now(), 0))
+
+	report := sp.GetHealthReport(context.Background())
+	require.Len(t, report.ContainerStats, 1)
+	stats := report.ContainerStats[name]
+	require.Equal(t, uint64(3e9), stats.MemoryUsageBytes)
+	require.InDelta(t, 200.0, stats.CpuPercent, 0.001)
+}
+
+// Creates a service provider with a Docker mock that has a running Beacon Node container
+func newStatsTestServiceProvider(t *testing.T) (*statsDockerClient, *common.ServiceProvider) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	mock := &statsDockerClient{
+		labeledDockerClient: &labeledDockerClient{
+			DockerMockManager: docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil))),
+		},
+		lock:    &sync.Mutex{},
+		latest:  map[string]dtypes.StatsJSON{},
+		streams: map[string][]*io.PipeWriter{},
+	}
+	never := time.Time{}.Format(time.RFC3339Nano)
+	name := cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	mock.addContainer(t, name, cfg.ProjectName.Value, &dtypes.ContainerState{
+		Status:     "running",
+		Running:    true,
+		StartedAt:  time.Now().Format(time.RFC3339Nano),
+		FinishedAt: never,
+	})
+	return mock, newDockerTestServiceProvider(t, cfg, mock)
+}
+
+// Creates a stats frame on a 4 CPU host where the container used 2 CPUs' worth of time since the previous one, and has
+// 4 GB of memory in use of which 1 GB is inactive page cache. The network totals grow with the sequence number.
+func newTestStatsFrame(name string, read time.Time, sequence uint64) dtypes.StatsJSON {
+	var frame dtypes.StatsJSON
+	frame.Name = "/" + name
+	frame.Read = read
+	frame.PreCPUStats.CPUUsage.TotalUsage = 10e9
+	frame.PreCPUStats.SystemUsage = 100e9
+	frame.CPUStats.CPUUsage.TotalUsage = 12e9
+	frame.CPUStats.SystemUsage = 104e9
+	frame.CPUStats.OnlineCPUs = 4
+	frame.MemoryStats.Usage = 4e9
+	frame.MemoryStats.Limit = 12e9
+	frame.MemoryStats.Stats = map[string]uint64{"inactive_file": 1e9}
+	frame.Networks = map[string]dtypes.NetworkStats{
+		"eth0": {RxBytes: 1000 + sequence*100, TxBytes: 500 + sequence*50},
+		"eth1": {RxBytes: 500, TxBytes: 200},
+	}
+	return frame
+}
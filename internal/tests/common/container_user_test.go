@@ -0,0 +1,111 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/api/types/volume"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a configured user is set on the container, and that a new data volume is given to it before the container
+// is created
+func TestCreateContainer_RunAsUser(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ContainerUser.BeaconNode.RunAsUser.Value = "1000"
+	cfg.ContainerUser.BeaconNode.RunAsGroup.Value = "1001"
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+
+	bnName := cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	volumeName := cfg.GetDockerArtifactName(hdconfig.BeaconNodeDataVolume)
+	hostCfg := &container.HostConfig{
+		Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: volumeName, Target: "/ethclient"}},
+	}
+	_, err := sp.CreateContainer(context.Background(), config.ContainerID_BeaconNode, &container.Config{Image: "bn:latest"}, hostCfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, "1000:1001", mock.created[bnName].User)
+	require.Equal(t, []string{"create " + volumeName}, mock.volumeEvents)
+
+	// The owner was set by a container running as root on the same image, which was cleaned up afterwards
+	permissionsName := cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode) + "_permissions")
+	permissionsCfg := mock.created[permissionsName]
+	require.NotNil(t, permissionsCfg)
+	require.Equal(t, "bn:latest", permissionsCfg.Image)
+	require.Equal(t, "0:0", permissionsCfg.User)
+	require.Equal(t, strslice.StrSlice{"chown", "1000:1001", "/data"}, permissionsCfg.Entrypoint)
+	_, err = mock.ContainerInspect(context.Background(), permissionsName)
+	require.Error(t, err)
+
+	// An existing volume is left alone
+	mock.volumeEvents = nil
+	delete(mock.created, permissionsName)
+	require.NoError(t, mock.ContainerRemove(context.Background(), bnName, container.RemoveOptions{}))
+	_, err = sp.CreateContainer(context.Background(), config.ContainerID_BeaconNode, &container.Config{Image: "bn:latest"}, hostCfg, nil)
+	require.NoError(t, err)
+	require.Empty(t, mock.volumeEvents)
+	require.NotContains(t, mock.created, permissionsName)
+}
+
+// Test that a failed ownership change stops the container from being created
+func TestCreateContainer_RunAsUserChownFails(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ContainerUser.ExecutionClient.RunAsUser.Value = "1000"
+	mock := newRecordingDockerClient()
+	mock.exitCodes[cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient)+"_permissions")] = 127
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+
+	hostCfg := &container.HostConfig{
+		Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: cfg.GetDockerArtifactName(hdconfig.ExecutionClientDataVolume), Target: "/ethclient"}},
+	}
+	_, err := sp.CreateContainer(context.Background(), config.ContainerID_ExecutionClient, &container.Config{}, hostCfg, nil)
+	require.ErrorContains(t, err, "code 127")
+	require.NotContains(t, mock.created, cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient)))
+}
+
+// Test that services without a user keep the image's, including when they're recreated after a user was cleared
+func TestCreateContainer_DefaultUser(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ContainerUser.ValidatorClient.RunAsUser.Value = "1000"
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	vcName := cfg.GetDockerArtifactName(string(config.ContainerID_ValidatorClient))
+	mock.addVolume(volume.Volume{Name: cfg.GetDockerArtifactName("vcdata")})
+
+	_, err := sp.CreateContainer(context.Background(), config.ContainerID_MevBoost, &container.Config{User: "nobody"}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "nobody", mock.created[cfg.GetDockerArtifactName(string(config.ContainerID_MevBoost))].User)
+
+	_, err = sp.CreateContainer(context.Background(), config.ContainerID_ValidatorClient, &container.Config{}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "1000", mock.created[vcName].User)
+
+	cfg.ContainerUser.ValidatorClient.RunAsUser.Value = ""
+	require.NoError(t, sp.RecreateContainer(context.Background(), config.ContainerID_ValidatorClient))
+	require.Empty(t, mock.created[vcName].User)
+	require.NotContains(t, mock.created[vcName].Labels, hdconfig.ContainerUserLabel)
+}
+
+// Test that UIDs and GIDs must be non-negative numbers, and that a group needs a user
+func TestContainerUserConfig_Validate(t *testing.T) {
+	cfg := hdconfig.NewContainerUserConfig()
+	require.Empty(t, cfg.Validate())
+
+	cfg.BeaconNode.RunAsUser.Value = "1000"
+	cfg.BeaconNode.RunAsGroup.Value = "0"
+	require.Empty(t, cfg.Validate())
+	require.Equal(t, "1000:0", cfg.GetUser(config.ContainerID_BeaconNode))
+
+	cfg.BeaconNode.RunAsUser.Value = "-1"
+	cfg.ExecutionClient.RunAsGroup.Value = "abc"
+	errs := cfg.Validate()
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0], "[abc]")
+	require.Contains(t, errs[1], "no user")
+	require.Contains(t, errs[2], "[-1]")
+}
@@ -0,0 +1,106 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/log"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// A contract implementing version() and one on the wrong network, with nothing deployed there
+	verifiedContractAddress = ethcommon.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	missingContractAddress  = ethcommon.HexToAddress("0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512")
+)
+
+// Test verifying contracts that are deployed with and without the expected functions
+func TestVerifyContract(t *testing.T) {
+	sp := newContractVerificationTestServiceProvider(t)
+	ctx := context.Background()
+
+	err := sp.VerifyContract(ctx, verifiedContractAddress, [][4]byte{getTestSelector("version()")})
+	require.NoError(t, err)
+
+	err = sp.VerifyContract(ctx, verifiedContractAddress, [][4]byte{getTestSelector("version()"), getTestSelector("owner()")})
+	require.ErrorIs(t, err, common.ErrContractMismatch)
+	t.Logf("Mismatch: %s", err.Error())
+
+	err = sp.VerifyContract(ctx, missingContractAddress, [][4]byte{getTestSelector("version()")})
+	require.ErrorIs(t, err, common.ErrContractMismatch)
+	require.ErrorContains(t, err, "no contract deployed")
+}
+
+// Test that client failures aren't reported as mismatches
+func TestVerifyContract_ClientUnavailable(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "http://127.0.0.1:1")
+	ctx := log.NewDefaultLogger().CreateContextWithLogger(context.Background())
+	err := sp.VerifyContract(ctx, verifiedContractAddress, nil)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, common.ErrContractMismatch))
+}
+
+// Test the preflight check for the contracts registered by modules
+func TestVerifyCriticalContracts(t *testing.T) {
+	sp := newContractVerificationTestServiceProvider(t)
+	ctx := context.Background()
+	require.NoError(t, sp.VerifyCriticalContracts(ctx))
+
+	sp.RegisterCriticalContracts("stakewise", []common.CriticalContract{{
+		Name:              "Vault",
+		Address:           verifiedContractAddress,
+		ExpectedSelectors: [][4]byte{getTestSelector("version()")},
+	}})
+	require.NoError(t, sp.VerifyCriticalContracts(ctx))
+
+	sp.RegisterCriticalContracts("constellation", []common.CriticalContract{{
+		Name:              "Directory",
+		Address:           missingContractAddress,
+		ExpectedSelectors: [][4]byte{getTestSelector("version()")},
+	}})
+	err := sp.VerifyCriticalContracts(ctx)
+	require.ErrorIs(t, err, common.ErrContractMismatch)
+	require.ErrorContains(t, err, "module [constellation] contract [Directory]")
+	require.NotContains(t, err.Error(), "stakewise")
+}
+
+// Creates a service provider with an Execution Client that has a contract implementing version() deployed
+func newContractVerificationTestServiceProvider(t *testing.T) *common.ServiceProvider {
+	ec := newMockExecutionClient(t, 1)
+	ec.Handlers["eth_getCode"] = func(params []json.RawMessage) (any, error) {
+		var address ethcommon.Address
+		require.NoError(t, json.Unmarshal(params[0], &address))
+		if address == verifiedContractAddress {
+			return "0x6080604052", nil
+		}
+		return "0x", nil
+	}
+	ec.Handlers["eth_call"] = func(params []json.RawMessage) (any, error) {
+		var call struct {
+			To    ethcommon.Address `json:"to"`
+			Input hexutil.Bytes     `json:"input"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &call))
+		version := getTestSelector("version()")
+		if call.To == verifiedContractAddress && string(call.Input) == string(version[:]) {
+			return hexutil.Encode(ethcommon.LeftPadBytes([]byte{1}, 32)), nil
+		}
+		return nil, errors.New("execution reverted")
+	}
+	bn := newMockBeaconNode(t)
+	return newTestServiceProvider(t, bn.URL, ec.URL)
+}
+
+// Gets the selector of a function signature
+func getTestSelector(signature string) [4]byte {
+	var selector [4]byte
+	copy(selector[:], crypto.Keccak256([]byte(signature)))
+	return selector
+}
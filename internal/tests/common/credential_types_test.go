@@ -0,0 +1,80 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test tallying the node's validators by withdrawal credential type on Electra
+func TestGetCredentialTypes(t *testing.T) {
+	bn := newCredentialTypesTestBeaconNode(t)
+	bn.ActivateElectra()
+	bn.SetWithdrawalCredentials(0, getMockWithdrawalCredentials(0x00, 0))
+	bn.SetWithdrawalCredentials(3, getMockWithdrawalCredentials(0x02, 3))
+	bn.SetWithdrawalCredentials(4, getMockWithdrawalCredentials(0x02, 4))
+	bn.SetWithdrawalCredentials(5, getMockWithdrawalCredentials(0x02, 5))
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	// Duplicates and validators that aren't on the chain aren't counted
+	pubkeys := getMockValidatorPubkeys(bn)
+	pubkeys = append(pubkeys, pubkeys[3], beacon.ValidatorPubkey{0xbb})
+	counts, err := sp.GetCredentialTypes(context.Background(), pubkeys)
+	require.NoError(t, err)
+	require.Equal(t, map[common.WithdrawalCredentialType]int{
+		common.WithdrawalCredentialType_Bls:         1,
+		common.WithdrawalCredentialType_Execution:   2,
+		common.WithdrawalCredentialType_Compounding: 3,
+	}, counts)
+
+	// Unknown types are counted separately
+	bn.SetWithdrawalCredentials(1, getMockWithdrawalCredentials(0x03, 1))
+	counts, err = sp.GetCredentialTypes(context.Background(), pubkeys[:2])
+	require.NoError(t, err)
+	require.Equal(t, 1, counts[common.WithdrawalCredentialType(0x03)])
+	require.Equal(t, 1, counts[common.WithdrawalCredentialType_Bls])
+	require.Zero(t, counts[common.WithdrawalCredentialType_Execution])
+
+	// No validators still has every type
+	counts, err = sp.GetCredentialTypes(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, counts, 3)
+}
+
+// Test that compounding credentials aren't recognized before Electra
+func TestGetCredentialTypes_PreElectra(t *testing.T) {
+	bn := newCredentialTypesTestBeaconNode(t)
+	bn.SetWithdrawalCredentials(0, getMockWithdrawalCredentials(0x00, 0))
+	sp := newTestServiceProvider(t, bn.URL, "")
+	pubkeys := getMockValidatorPubkeys(bn)
+
+	counts, err := sp.GetCredentialTypes(context.Background(), pubkeys)
+	require.NoError(t, err)
+	require.Equal(t, map[common.WithdrawalCredentialType]int{
+		common.WithdrawalCredentialType_Bls:       1,
+		common.WithdrawalCredentialType_Execution: 5,
+	}, counts)
+
+	bn.SetWithdrawalCredentials(2, getMockWithdrawalCredentials(0x02, 2))
+	_, err = sp.GetCredentialTypes(context.Background(), pubkeys)
+	require.ErrorIs(t, err, common.ErrElectraNotActive)
+}
+
+// Creates a mock Beacon Node with 6 active validators that have execution credentials
+func newCredentialTypesTestBeaconNode(t *testing.T) *mockBeaconNode {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(6, beacon.ValidatorState_ActiveOngoing, 32e9)
+	return bn
+}
+
+// Gets the pubkeys of all of a mock Beacon Node's validators
+func getMockValidatorPubkeys(bn *mockBeaconNode) []beacon.ValidatorPubkey {
+	pubkeys := make([]beacon.ValidatorPubkey, len(bn.Validators))
+	for i, validator := range bn.Validators {
+		pubkeys[i] = beacon.ValidatorPubkey(validator.Validator.Pubkey)
+	}
+	return pubkeys
+}
@@ -0,0 +1,169 @@
+package common_test
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The genesis time written into the test genesis state
+	customGenesisTime int64 = 1700000000
+)
+
+// Test that each client container gets the custom genesis artifacts it needs mounted and referenced, and that they're
+// replaced rather than duplicated when the container is recreated
+func TestCreateContainer_CustomGenesis(t *testing.T) {
+	cfg := newCustomNetworkTestConfig(t)
+	writeCustomGenesisArtifacts(t, cfg, 1337)
+	genesis := cfg.CustomGenesis
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	ctx := context.Background()
+	dataMount := mount.Mount{Type: mount.TypeVolume, Source: cfg.GetDockerArtifactName("data"), Target: "/ethclient"}
+	for _, id := range []config.ContainerID{config.ContainerID_ExecutionClient, config.ContainerID_BeaconNode, config.ContainerID_ValidatorClient, config.ContainerID_MevBoost} {
+		hostCfg := &container.HostConfig{Mounts: []mount.Mount{dataMount}}
+		_, err := sp.CreateContainer(ctx, id, &container.Config{Env: []string{"NETWORK=" + string(hdconfig.Network_LocalTest)}}, hostCfg, nil)
+		require.NoError(t, err)
+		require.Len(t, hostCfg.Mounts, 1, "the caller's host config shouldn't be modified")
+	}
+
+	ecName := cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))
+	require.Equal(t, []mount.Mount{
+		dataMount,
+		{Type: mount.TypeBind, Source: genesis.ExecutionGenesisPath.Value, Target: hdconfig.ExecutionGenesisContainerPath, ReadOnly: true},
+	}, mock.mounts[ecName])
+	require.Contains(t, mock.created[ecName].Env, hdconfig.ExecutionGenesisEnvVar+"="+hdconfig.ExecutionGenesisContainerPath)
+
+	bnName := cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	bnMounts := []mount.Mount{
+		dataMount,
+		{Type: mount.TypeBind, Source: genesis.BeaconConfigPath.Value, Target: hdconfig.BeaconConfigContainerPath, ReadOnly: true},
+		{Type: mount.TypeBind, Source: genesis.BeaconGenesisStatePath.Value, Target: hdconfig.BeaconGenesisStateContainerPath, ReadOnly: true},
+	}
+	require.Equal(t, bnMounts, mock.mounts[bnName])
+	require.ElementsMatch(t, []string{
+		"NETWORK=" + string(hdconfig.Network_LocalTest),
+		hdconfig.BeaconConfigEnvVar + "=" + hdconfig.BeaconConfigContainerPath,
+		hdconfig.BeaconGenesisStateEnvVar + "=" + hdconfig.BeaconGenesisStateContainerPath,
+	}, mock.created[bnName].Env)
+
+	vcName := cfg.GetDockerArtifactName(string(config.ContainerID_ValidatorClient))
+	require.Len(t, mock.mounts[vcName], 2)
+	require.Equal(t, hdconfig.BeaconConfigContainerPath, mock.mounts[vcName][1].Target)
+
+	mevBoostName := cfg.GetDockerArtifactName(string(config.ContainerID_MevBoost))
+	require.Equal(t, []mount.Mount{dataMount}, mock.mounts[mevBoostName])
+	require.Equal(t, []string{"NETWORK=" + string(hdconfig.Network_LocalTest)}, mock.created[mevBoostName].Env)
+
+	// Recreating it keeps a single copy of each artifact
+	err := sp.RecreateContainer(ctx, config.ContainerID_BeaconNode)
+	require.NoError(t, err)
+	require.Equal(t, bnMounts, mock.mounts[bnName])
+	require.Len(t, mock.created[bnName].Env, 3)
+
+	// They're removed once Hyperdrive is on a built-in network
+	cfg.Network.Value = config.Network_Holesky
+	err = sp.RecreateContainer(ctx, config.ContainerID_BeaconNode)
+	require.NoError(t, err)
+	require.Equal(t, []mount.Mount{dataMount}, mock.mounts[bnName])
+	require.Equal(t, []string{"NETWORK=" + string(hdconfig.Network_LocalTest)}, mock.created[bnName].Env)
+}
+
+// Test that the custom genesis artifacts have to exist and parse, and agree on the chain ID, before the config can be
+// saved
+func TestCustomGenesis_Validate(t *testing.T) {
+	cfg := newCustomNetworkTestConfig(t)
+	require.Empty(t, cfg.Validate(), "external clients don't need the artifacts")
+	require.Len(t, cfg.CustomGenesis.Validate(true), 3, "local clients need all of them")
+
+	writeCustomGenesisArtifacts(t, cfg, 1337)
+	require.Empty(t, cfg.Validate())
+
+	// Broken artifacts are rejected
+	require.NoError(t, os.WriteFile(cfg.CustomGenesis.ExecutionGenesisPath.Value, []byte(`{"config":{}}`), 0644))
+	require.NoError(t, os.WriteFile(cfg.CustomGenesis.BeaconConfigPath.Value, []byte("PRESET_BASE: [minimal"), 0644))
+	require.NoError(t, os.WriteFile(cfg.CustomGenesis.BeaconGenesisStatePath.Value, []byte{0x01, 0x02}, 0644))
+	errs := cfg.Validate()
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0], "chain ID")
+	require.Contains(t, errs[1], "error parsing Beacon Chain config")
+	require.Contains(t, errs[2], "too short")
+
+	// So are artifacts from two different networks
+	writeCustomGenesisArtifacts(t, cfg, 1337)
+	require.NoError(t, os.WriteFile(cfg.CustomGenesis.BeaconConfigPath.Value, []byte("SECONDS_PER_SLOT: 6\nDEPOSIT_CHAIN_ID: 7\n"), 0644))
+	errs = cfg.Validate()
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0], "deposit chain ID of 7")
+
+	// Missing ones are rejected, and saving fails without touching the file
+	cfg.CustomGenesis.BeaconGenesisStatePath.Value = filepath.Join(t.TempDir(), "missing.ssz")
+	path := filepath.Join(t.TempDir(), hdconfig.ConfigFilename)
+	_, err := cfg.SaveToFile(path, nil)
+	require.ErrorContains(t, err, "missing.ssz")
+	require.NoFileExists(t, path)
+
+	// On a built-in network they're ignored with a warning
+	cfg.Network.Value = config.Network_Holesky
+	require.Empty(t, cfg.Validate())
+	require.Len(t, cfg.GetWarnings(), 3)
+}
+
+// Test that the genesis time comes from the custom genesis state, without asking the Beacon Node, on a custom network
+func TestGetGenesisTime_CustomGenesis(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	cfg := newCustomNetworkTestConfig(t)
+	cfg.ExternalBeaconClient.HttpUrl.Value = bn.URL
+	writeCustomGenesisArtifacts(t, cfg, 1337)
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	genesisTime, err := sp.GetGenesisTime(context.Background())
+	require.NoError(t, err)
+	require.True(t, time.Unix(customGenesisTime, 0).Equal(genesisTime))
+
+	// Without a genesis state, the Beacon Node's is used
+	cfg.CustomGenesis.BeaconGenesisStatePath.Value = ""
+	genesisTime, err = sp.GetGenesisTime(context.Background())
+	require.NoError(t, err)
+	require.True(t, time.Unix(mockGenesisTime, 0).Equal(genesisTime))
+}
+
+// Creates a test config for a custom network with external clients
+func newCustomNetworkTestConfig(t *testing.T) *hdconfig.HyperdriveConfig {
+	cfg := hdconfig.NewHyperdriveConfigForNetwork(t.TempDir(), hdconfig.Network_LocalTest, config.NewResources(config.Network_Holesky))
+	cfg.Network.Value = hdconfig.Network_LocalTest
+	cfg.ClientMode.Value = config.ClientMode_External
+	cfg.ExternalBeaconClient.HttpUrl.Value = "http://127.0.0.1:1"
+	cfg.ExternalExecutionClient.HttpUrl.Value = "http://127.0.0.1:1"
+	return cfg
+}
+
+// Writes a set of custom genesis artifacts for a network with the provided chain ID, and points the config at them
+func writeCustomGenesisArtifacts(t *testing.T, cfg *hdconfig.HyperdriveConfig, chainID uint64) {
+	dir := t.TempDir()
+	ecGenesis := filepath.Join(dir, "genesis.json")
+	require.NoError(t, os.WriteFile(ecGenesis, []byte(`{"config":{"chainId":`+strconv.FormatUint(chainID, 10)+`},"timestamp":"0x6553f100","alloc":{}}`), 0644))
+	beaconConfig := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(beaconConfig, []byte("PRESET_BASE: mainnet\nSECONDS_PER_SLOT: 12\nDEPOSIT_CHAIN_ID: "+strconv.FormatUint(chainID, 10)+"\n"), 0644))
+
+	// A BeaconState starts with its genesis time, followed by the genesis validators root and the slot
+	state := make([]byte, 8+32+8+16)
+	binary.LittleEndian.PutUint64(state, uint64(customGenesisTime))
+	genesisState := filepath.Join(dir, "genesis.ssz")
+	require.NoError(t, os.WriteFile(genesisState, state, 0644))
+
+	cfg.CustomGenesis.ExecutionGenesisPath.Value = ecGenesis
+	cfg.CustomGenesis.BeaconConfigPath.Value = beaconConfig
+	cfg.CustomGenesis.BeaconGenesisStatePath.Value = genesisState
+}
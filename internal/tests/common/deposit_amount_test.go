@@ -0,0 +1,82 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the deposit amounts for execution and compounding validators against hand-computed vectors
+func TestComputeDepositAmount(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.ActivateElectra()
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	// Deposits to other validators shouldn't count
+	bn.PendingDeposits = append(bn.PendingDeposits, common.BeaconPendingDeposit{Pubkey: make([]byte, beacon.ValidatorPubkeyLength), Amount: 500e9})
+	for _, test := range []struct {
+		name             string
+		credentialType   common.WithdrawalCredentialType
+		effectiveBalance uint64
+		balance          uint64
+		pendingDeposit   uint64
+		desired          uint64
+		expected         uint64
+	}{
+		// 0x01 validators stop at 32 ETH
+		{"execution at max", common.WithdrawalCredentialType_Execution, 32e9, 32e9, 0, 32e9, 0},
+		{"execution capped", common.WithdrawalCredentialType_Execution, 32e9, 32e9, 0, 100e9, 0},
+		{"execution below max", common.WithdrawalCredentialType_Execution, 31e9, 31.1e9, 0, 32e9, 1.15e9 + 1},
+		{"execution far below max", common.WithdrawalCredentialType_Execution, 20e9, 20e9, 0, 32e9, 12e9},
+
+		// 0x02 validators go up to 2048 ETH
+		{"compounding", common.WithdrawalCredentialType_Compounding, 32e9, 32.1e9, 0, 100e9, 67.9e9},
+		{"compounding capped", common.WithdrawalCredentialType_Compounding, 32e9, 32.1e9, 0, 3000e9, 2015.9e9},
+		{"compounding rounded down", common.WithdrawalCredentialType_Compounding, 32e9, 32e9, 0, 40.7e9, 8e9},
+		{"compounding one increment", common.WithdrawalCredentialType_Compounding, 64e9, 64.5e9, 0, 65e9, 0.75e9 + 1},
+		{"compounding with pending deposit", common.WithdrawalCredentialType_Compounding, 32e9, 32.1e9, 10e9, 100e9, 57.9e9},
+		{"compounding covered by pending deposit", common.WithdrawalCredentialType_Compounding, 32e9, 32e9, 100e9, 100e9, 0},
+		{"compounding balance already enough", common.WithdrawalCredentialType_Compounding, 99e9, 100.5e9, 0, 100e9, 0},
+	} {
+		index := len(bn.Validators)
+		bn.AddValidators(1, beacon.ValidatorState_ActiveOngoing, test.effectiveBalance)
+		bn.SetBalance(index, test.balance)
+		bn.SetWithdrawalCredentials(index, getMockWithdrawalCredentials(byte(test.credentialType), index))
+		pubkey := beacon.ValidatorPubkey(bn.Validators[index].Validator.Pubkey)
+		if test.pendingDeposit > 0 {
+			bn.PendingDeposits = append(bn.PendingDeposits, common.BeaconPendingDeposit{Pubkey: pubkey[:], Amount: client.Uinteger(test.pendingDeposit)})
+		}
+
+		amount, err := sp.ComputeDepositAmount(context.Background(), pubkey, test.desired)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.expected, amount, test.name)
+	}
+}
+
+// Test that deposits that can't get the validator to the desired balance are rejected
+func TestComputeDepositAmount_Errors(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(1, beacon.ValidatorState_ActiveOngoing, 40e9)
+	bn.AddValidators(1, beacon.ValidatorState_ExitedUnslashed, 32e9)
+	bn.SetWithdrawalCredentials(0, getMockWithdrawalCredentials(byte(common.WithdrawalCredentialType_Compounding), 0))
+	sp := newTestServiceProvider(t, bn.URL, "")
+	compounding := beacon.ValidatorPubkey(bn.Validators[0].Validator.Pubkey)
+	exited := beacon.ValidatorPubkey(bn.Validators[1].Validator.Pubkey)
+
+	// Compounding credentials don't work until Electra
+	_, err := sp.ComputeDepositAmount(context.Background(), compounding, 64e9)
+	require.ErrorIs(t, err, common.ErrElectraNotActive)
+
+	bn.ActivateElectra()
+	sp = newTestServiceProvider(t, bn.URL, "")
+	_, err = sp.ComputeDepositAmount(context.Background(), compounding, 39.5e9)
+	require.ErrorIs(t, err, common.ErrAboveDesiredBalance)
+	_, err = sp.ComputeDepositAmount(context.Background(), exited, 32e9)
+	require.ErrorIs(t, err, common.ErrValidatorNotActive)
+	_, err = sp.ComputeDepositAmount(context.Background(), beacon.ValidatorPubkey{0x01}, 32e9)
+	require.ErrorIs(t, err, common.ErrValidatorNotFound)
+}
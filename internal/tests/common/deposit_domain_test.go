@@ -0,0 +1,43 @@
+package common_test
+
+import (
+	"testing"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The deposit domain of mainnet, which has a genesis fork version of 0x00000000
+	mainnetDepositDomain string = "0x03000000f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9"
+)
+
+// Test that the mainnet deposit domain matches the known value
+func TestGetDepositDomain_Mainnet(t *testing.T) {
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+	require.Equal(t, config.Network_Mainnet, sp.GetConfig().GetNetworkResources().Network)
+
+	domain, err := sp.GetDepositDomain()
+	require.NoError(t, err)
+	require.Equal(t, mainnetDepositDomain, domain.HexWithPrefix())
+
+	// The cached domain is the same
+	cached, err := sp.GetDepositDomain()
+	require.NoError(t, err)
+	require.Equal(t, domain, cached)
+}
+
+// Test that the domain follows the network's genesis fork version
+func TestGetDepositDomain_Network(t *testing.T) {
+	cfg := hdconfig.NewHyperdriveConfigForNetwork(t.TempDir(), config.Network_Holesky, config.NewResources(config.Network_Holesky))
+	cfg.ClientMode.Value = config.ClientMode_External
+	cfg.ExternalBeaconClient.HttpUrl.Value = "http://127.0.0.1:1"
+	cfg.ExternalExecutionClient.HttpUrl.Value = "http://127.0.0.1:1"
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	domain, err := sp.GetDepositDomain()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x03, 0x00, 0x00, 0x00}, domain[:4])
+	require.NotEqual(t, mainnetDepositDomain, domain.HexWithPrefix())
+}
@@ -0,0 +1,156 @@
+package common_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/prysmaticlabs/prysm/v5/container/trie"
+	prdeposit "github.com/prysmaticlabs/prysm/v5/contracts/deposit"
+	"github.com/rocket-pool/node-manager-core/beacon/ssz_types"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The block the deposit contract was deployed in on mainnet, which is where the deposit log search starts
+	testDepositContractDeploymentBlock uint64 = 11052984
+)
+
+// Test that proofs built from the deposit logs match Prysm's deposit tree and verify against the contract's root
+func TestGetDepositProof(t *testing.T) {
+	depositContract := ethcommon.HexToAddress("0x00000000219ab540356cbb839cbe05303d7705fa")
+	bn := newMockBeaconNode(t)
+	bn.Spec["DEPOSIT_CONTRACT_ADDRESS"] = depositContract.Hex()
+	ec := newMockExecutionClient(t, 1)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	ctx := context.Background()
+
+	// Spread the deposits across several log requests
+	contractAbi, err := abi.JSON(strings.NewReader(prdeposit.DepositContractABI))
+	require.NoError(t, err)
+	depositBlocks := []uint64{5, 6, 6, 12000, 25000}
+	logs := []types.Log{}
+	items := [][]byte{}
+	for i, block := range depositBlocks {
+		depositData := ssz_types.DepositData{
+			PublicKey:             make([]byte, 48),
+			WithdrawalCredentials: make([]byte, 32),
+			Amount:                32e9,
+			Signature:             make([]byte, 96),
+		}
+		depositData.PublicKey[0] = byte(i + 1)
+		depositData.WithdrawalCredentials[0] = 0x01
+		depositData.Signature[95] = byte(i)
+		amount := binary.LittleEndian.AppendUint64(nil, depositData.Amount)
+		index := binary.LittleEndian.AppendUint64(nil, uint64(i))
+		data, err := contractAbi.Events["DepositEvent"].Inputs.Pack(depositData.PublicKey, depositData.WithdrawalCredentials, amount, depositData.Signature, index)
+		require.NoError(t, err)
+		logs = append(logs, types.Log{
+			Address:     depositContract,
+			Topics:      []ethcommon.Hash{crypto.Keccak256Hash([]byte("DepositEvent(bytes,bytes,bytes,bytes,bytes)"))},
+			Data:        data,
+			BlockNumber: testDepositContractDeploymentBlock + block,
+			TxHash:      ethcommon.Hash{byte(i)},
+		})
+		leaf, err := depositData.HashTreeRoot()
+		require.NoError(t, err)
+		items = append(items, leaf[:])
+	}
+	expectedTree, err := trie.GenerateTrieFromItems(items, 32)
+	require.NoError(t, err)
+	depositRoot, err := expectedTree.HashTreeRoot()
+	require.NoError(t, err)
+	serveDepositContract(t, ec, contractAbi, testDepositContractDeploymentBlock+30000, uint64(len(logs)), depositRoot, logs)
+
+	info, err := sp.GetDepositContractInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, depositContract, info.Address)
+	require.Equal(t, uint64(5), info.DepositCount)
+	require.Equal(t, ethcommon.Hash(depositRoot), info.DepositRoot)
+
+	// Check the first, middle, and last deposits
+	for _, index := range []uint64{0, 2, 4} {
+		proof, err := sp.GetDepositProof(ctx, index)
+		require.NoError(t, err)
+		require.Equal(t, ethcommon.BytesToHash(items[index]), proof.Leaf)
+		require.True(t, common.VerifyDepositProof(proof, info.DepositRoot))
+		expectedBranch, err := expectedTree.MerkleProof(int(index))
+		require.NoError(t, err)
+		require.Len(t, proof.Branch, len(expectedBranch))
+		for i := range expectedBranch {
+			require.Equal(t, ethcommon.BytesToHash(expectedBranch[i]), proof.Branch[i], "branch entry %d of deposit %d", i, index)
+		}
+
+		// Proofs don't work for other leaves, roots, or counts
+		wrongLeaf := proof
+		wrongLeaf.Leaf = ethcommon.BytesToHash(items[(index+1)%5])
+		require.False(t, common.VerifyDepositProof(wrongLeaf, info.DepositRoot))
+		require.False(t, common.VerifyDepositProof(proof, ethcommon.Hash{0x01}))
+		wrongCount := proof
+		wrongCount.DepositCount = 6
+		require.False(t, common.VerifyDepositProof(wrongCount, info.DepositRoot))
+	}
+
+	// The search stops at the chunk with the last deposit
+	require.Equal(t, 3*3, ec.GetRequestCount("eth_getLogs"))
+
+	_, err = sp.GetDepositProof(ctx, 5)
+	require.ErrorIs(t, err, common.ErrDepositNotFound)
+
+	// A client that's missing a log can't produce a proof
+	serveDepositContract(t, ec, contractAbi, testDepositContractDeploymentBlock+30000, uint64(len(logs)), depositRoot, append(logs[:2:2], logs[3:]...))
+	_, err = sp.GetDepositProof(ctx, 0)
+	require.ErrorContains(t, err, "expected 2")
+}
+
+// Serves a deposit contract with the provided count, root, and logs from the mock EC, with the provided block as the
+// latest
+func serveDepositContract(t *testing.T, ec *mockExecutionClient, contractAbi abi.ABI, latestBlock uint64, count uint64, root [32]byte, logs []types.Log) {
+	ec.SetResult("eth_blockNumber", hexutil.Uint64(latestBlock))
+	countOutput, err := contractAbi.Methods["get_deposit_count"].Outputs.Pack(binary.LittleEndian.AppendUint64(nil, count))
+	require.NoError(t, err)
+	rootOutput, err := contractAbi.Methods["get_deposit_root"].Outputs.Pack(root)
+	require.NoError(t, err)
+	ec.Handlers["eth_call"] = func(params []json.RawMessage) (any, error) {
+		var call struct {
+			Input hexutil.Bytes `json:"input"`
+			Data  hexutil.Bytes `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &call))
+		input := call.Input
+		if len(input) == 0 {
+			input = call.Data
+		}
+		switch {
+		case len(input) >= 4 && string(input[:4]) == string(contractAbi.Methods["get_deposit_count"].ID):
+			return hexutil.Bytes(countOutput), nil
+		case len(input) >= 4 && string(input[:4]) == string(contractAbi.Methods["get_deposit_root"].ID):
+			return hexutil.Bytes(rootOutput), nil
+		}
+		return nil, fmt.Errorf("unexpected call %x", input)
+	}
+	ec.Handlers["eth_getLogs"] = func(params []json.RawMessage) (any, error) {
+		var filter struct {
+			FromBlock hexutil.Uint64 `json:"fromBlock"`
+			ToBlock   hexutil.Uint64 `json:"toBlock"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &filter))
+		require.LessOrEqual(t, uint64(filter.ToBlock), latestBlock)
+		matches := []types.Log{}
+		for _, log := range logs {
+			if log.BlockNumber >= uint64(filter.FromBlock) && log.BlockNumber <= uint64(filter.ToBlock) {
+				matches = append(matches, log)
+			}
+		}
+		return matches, nil
+	}
+}
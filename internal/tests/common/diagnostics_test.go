@@ -0,0 +1,98 @@
+package common_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/nodeset-org/hyperdrive-daemon/shared"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the diagnostics bundle has everything support needs, and none of the node's secrets
+func TestGenerateDiagnosticsBundle(t *testing.T) {
+	const (
+		walletPassword = "correct-horse-battery-staple"
+		apiToken       = "a1b2c3d4e5f60718293a4b5c6d7e8f90"
+		urlPassword    = "url-credential-secret"
+		queryKey       = "query-api-key-secret"
+		envSecret      = "extra-env-secret-value"
+	)
+	secrets := []string{walletPassword, apiToken, urlPassword, queryKey, envSecret}
+
+	bn := newMockBeaconNode(t)
+	ec := newMockExecutionClient(t, 1)
+	ec.SetResult("web3_clientVersion", "Geth/v1.14.3-stable/linux-amd64/go1.22.2")
+	ec.SetResult("eth_syncing", false)
+	ec.SetResult("admin_nodeInfo", newTestAdminNodeInfo())
+	ecUrl := strings.Replace(ec.URL, "http://", "http://hyperdrive:"+urlPassword+"@", 1)
+	cfg := newTestConfig(t, bn.URL, ecUrl)
+
+	// Put secrets everywhere they could leak from
+	cfg.UserDataPath.Value = t.TempDir()
+	require.NoError(t, os.WriteFile(cfg.GetPasswordFilePath(), []byte(walletPassword+"\n"), 0600))
+	tokenPath := filepath.Join(t.TempDir(), "api-token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(apiToken), 0600))
+	cfg.KeyManager.TokenPath.Value = tokenPath
+	cfg.Fallback.EcHttpUrl.Value = "https://rpc.example.com/v1?apikey=" + queryKey
+	cfg.ExtraEnv.MevBoost["RELAY_AUTH"] = envSecret
+	logContents := "level=INFO msg=\"Connected\" url=" + ecUrl + "\nlevel=DEBUG msg=\"Loaded wallet\" password=" + walletPassword + "\n"
+	require.NoError(t, os.MkdirAll(filepath.Dir(cfg.GetApiLogFilePath()), 0755))
+	require.NoError(t, os.WriteFile(cfg.GetApiLogFilePath(), []byte(logContents), 0644))
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	var buffer bytes.Buffer
+	require.NoError(t, sp.GenerateDiagnosticsBundle(context.Background(), &buffer))
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, file := range reader.File {
+		handle, err := file.Open()
+		require.NoError(t, err)
+		contents, err := io.ReadAll(handle)
+		require.NoError(t, err)
+		handle.Close()
+		files[file.Name] = string(contents)
+	}
+
+	// No secrets anywhere
+	for name, contents := range files {
+		for _, secret := range secrets {
+			require.NotContains(t, contents, secret, "%s leaked a secret", name)
+		}
+	}
+
+	// The manifest covers every other file
+	var manifest common.DiagnosticsManifest
+	require.NoError(t, json.Unmarshal([]byte(files["manifest.json"]), &manifest))
+	require.Equal(t, shared.HyperdriveVersion, manifest.DaemonVersion)
+	require.False(t, manifest.CreatedAt.IsZero())
+	names := []string{}
+	for _, file := range manifest.Files {
+		require.False(t, file.CollectedAt.Before(manifest.CreatedAt))
+		names = append(names, file.Name)
+	}
+	require.Equal(t, []string{"attestation-pool.json", "config.json", "health.json", "logs/api.log", "logs/tasks.log", "metrics.txt", "sync.json", "versions.json"}, names)
+	require.Len(t, files, len(names)+1)
+
+	// Every part could be collected
+	require.Empty(t, manifest.Errors, "unexpected errors: %v", manifest.Errors)
+
+	// The redacted parts are still there so support can tell they were set
+	require.Contains(t, files["config.json"], "RELAY_AUTH")
+	require.Contains(t, files["config.json"], "https://rpc.example.com/v1?apikey=[REDACTED]")
+	require.Contains(t, files["logs/api.log"], "msg=\"Loaded wallet\" password=[REDACTED]")
+	require.Contains(t, files["versions.json"], "Geth/v1.14.3-stable")
+	require.Contains(t, files["versions.json"], bn.Identity.PeerId)
+	require.Contains(t, files["versions.json"], testEnode)
+	require.Contains(t, files["sync.json"], "\"synced\": true")
+	require.Contains(t, files["metrics.txt"], "hyperdrive_goroutines")
+	t.Logf("Bundle is %d bytes with %d files", buffer.Len(), len(files))
+}
@@ -0,0 +1,107 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+// Test draining a validator while the head moves through the wait epochs
+func TestDrainValidator(t *testing.T) {
+	sp, bn, keyManager := newDrainTest(t)
+	pubkey := beacon.ValidatorPubkey{0x01}
+	other := beacon.ValidatorPubkey{0x02}
+	keyManager.History.Data = []common.SlashingProtectionRecord{
+		newTestSlashingProtectionRecord(pubkey, 321, 9, 10),
+		newTestSlashingProtectionRecord(other, 300, 8, 9),
+	}
+
+	recorder := &progressRecorder{t: t}
+	data, err := sp.DrainValidator(context.Background(), pubkey, 2, recorder.report)
+	require.NoError(t, err)
+	require.Equal(t, []progressUpdate{
+		{current: 1, total: 5, message: "Removed " + pubkey.HexWithPrefix() + " from the Validator Client in epoch 10"},
+		{current: 2, total: 5, message: "Waited 1 of 2 epochs"},
+		{current: 3, total: 5, message: "Waited 2 of 2 epochs"},
+		{current: 4, total: 5, message: "Exported the validator's slashing protection data"},
+		{current: 5, total: 5, message: pubkey.HexWithPrefix() + " is safe to import into another Validator Client"},
+	}, recorder.updates)
+	require.GreaterOrEqual(t, bn.HeadSlot, uint64(12*32))
+
+	// Only the drained key is removed and exported
+	require.NotContains(t, keyManager.Keystores, pubkey)
+	require.Contains(t, keyManager.Keystores, other)
+	var interchange common.SlashingProtectionInterchange
+	require.NoError(t, json.Unmarshal(data, &interchange))
+	require.Equal(t, testGenesisValidatorsRoot, interchange.Metadata.GenesisValidatorsRoot)
+	require.Len(t, interchange.Data, 1)
+	require.Equal(t, pubkey, interchange.Data[0].Pubkey)
+	require.Equal(t, client.Uinteger(321), interchange.Data[0].SignedBlocks[0].Slot)
+	require.Equal(t, client.Uinteger(10), interchange.Data[0].SignedAttestations[0].TargetEpoch)
+}
+
+// Test that a validator that signed something after it was removed isn't reported as drained
+func TestDrainValidator_SignedAfterRemoval(t *testing.T) {
+	sp, _, keyManager := newDrainTest(t)
+	pubkey := beacon.ValidatorPubkey{0x01}
+	keyManager.History.Data = []common.SlashingProtectionRecord{
+		newTestSlashingProtectionRecord(pubkey, 321, 10, 11),
+	}
+
+	_, err := sp.DrainValidator(context.Background(), pubkey, 1, nil)
+	require.ErrorIs(t, err, common.ErrValidatorNotDrained)
+	require.ErrorContains(t, err, "epoch 11")
+}
+
+// Test that keys the VC doesn't have can't be drained
+func TestDrainValidator_NotLoaded(t *testing.T) {
+	sp, _, _ := newDrainTest(t)
+	_, err := sp.DrainValidator(context.Background(), beacon.ValidatorPubkey{0xff}, 1, nil)
+	require.ErrorIs(t, err, common.ErrValidatorNotLoaded)
+}
+
+// Test that the wait can be canceled, leaving the key removed
+func TestDrainValidator_Canceled(t *testing.T) {
+	sp, bn, keyManager := newDrainTest(t)
+	bn.HeadSlotStep = 0
+	pubkey := beacon.ValidatorPubkey{0x01}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := sp.DrainValidator(ctx, pubkey, 1, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotContains(t, keyManager.Keystores, pubkey)
+}
+
+// Creates a service provider with a Beacon Node whose head advances an epoch per request and a key manager with two keys loaded
+func newDrainTest(t *testing.T) (*common.ServiceProvider, *mockBeaconNode, *mockKeyManager) {
+	bn := newMockBeaconNode(t)
+	bn.HeadSlotStep = 32
+	keyManager := newMockKeyManager(t, "km-token")
+	keyManager.Keystores[beacon.ValidatorPubkey{0x01}] = "{}"
+	keyManager.Keystores[beacon.ValidatorPubkey{0x02}] = "{}"
+
+	cfg := newTestConfig(t, bn.URL, "")
+	tokenPath := filepath.Join(t.TempDir(), "api-token.txt")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(keyManager.token), 0600))
+	cfg.KeyManager.Url.Value = keyManager.URL
+	cfg.KeyManager.TokenPath.Value = tokenPath
+	return newTestServiceProviderFromConfig(t, cfg), bn, keyManager
+}
+
+// Creates a slashing protection record with a single signed block and attestation
+func newTestSlashingProtectionRecord(pubkey beacon.ValidatorPubkey, slot uint64, sourceEpoch uint64, targetEpoch uint64) common.SlashingProtectionRecord {
+	return common.SlashingProtectionRecord{
+		Pubkey:             pubkey,
+		SignedBlocks:       []common.SignedBlock{{Slot: client.Uinteger(slot)}},
+		SignedAttestations: []common.SignedAttestation{{SourceEpoch: client.Uinteger(sourceEpoch), TargetEpoch: client.Uinteger(targetEpoch)}},
+	}
+}
@@ -0,0 +1,130 @@
+package common_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/nodeset-org/hyperdrive-daemon/tasks"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Registers two modules that both claim the second of the provided validators, and a disabled one that claims the
+// first
+func registerDuplicateValidatorModules(sp *common.ServiceProvider, pubkeys []beacon.ValidatorPubkey) {
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys[0:2],
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "constellation",
+		enabled: true,
+		pubkeys: pubkeys[1:3],
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "disabled",
+		enabled: false,
+		pubkeys: pubkeys[0:1],
+	})
+}
+
+// Test finding a validator key claimed by two modules
+func TestDetectDuplicateValidators(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	pubkeys := getMockValidatorPubkeys(bn)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	duplicates, err := sp.DetectDuplicateValidators(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, duplicates)
+
+	registerDuplicateValidatorModules(sp, pubkeys)
+	duplicates, err = sp.DetectDuplicateValidators(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []common.DuplicateValidator{{
+		Pubkey:  pubkeys[1],
+		Modules: []string{"stakewise", "constellation"},
+	}}, duplicates)
+}
+
+// Test that the Validator Clients aren't started while a key is claimed twice, unless the config allows it
+func TestBootClients_DuplicateValidators(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+	test.bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	pubkeys := getMockValidatorPubkeys(test.bn)
+	registerDuplicateValidatorModules(test.sp, pubkeys)
+
+	err := test.sp.BootClients(context.Background(), []string{test.vcContainer})
+	require.ErrorIs(t, err, common.ErrDuplicateValidators)
+	require.Contains(t, err.Error(), pubkeys[1].HexWithPrefix()+" (stakewise, constellation)")
+	require.Equal(t, []string{test.ecContainer, test.bnContainer}, test.docker.started)
+
+	// It's still checked when the Validator Clients aren't managed by Hyperdrive
+	err = test.sp.BootClients(context.Background(), nil)
+	require.ErrorIs(t, err, common.ErrDuplicateValidators)
+
+	test.sp.GetConfig().AllowDuplicateValidators.Value = true
+	err = test.sp.BootClients(context.Background(), []string{test.vcContainer})
+	require.NoError(t, err)
+	require.Contains(t, test.docker.started, test.vcContainer)
+}
+
+// Test that the daemon doesn't start the Validator Clients while a key is claimed twice
+func TestTaskLoop_DuplicateValidators(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+	test.bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	registerDuplicateValidatorModules(test.sp, getMockValidatorPubkeys(test.bn))
+
+	wg := &sync.WaitGroup{}
+	err := tasks.NewTaskLoop(test.sp, wg).Run()
+	require.NoError(t, err)
+	defer func() {
+		test.sp.CancelContextOnShutdown()
+		wg.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		test.docker.lock.Lock()
+		defer test.docker.lock.Unlock()
+		return len(test.docker.events) >= 4
+	}, 5*time.Second, 50*time.Millisecond)
+	time.Sleep(time.Second)
+	test.docker.lock.Lock()
+	defer test.docker.lock.Unlock()
+	require.Equal(t, []string{test.ecContainer, test.bnContainer}, test.docker.started)
+}
+
+// Test that modules registering after the clients booted still keep the Validator Clients from being started or
+// restarted while a key is claimed twice
+func TestStartContainer_DuplicateValidatorsRegisteredLater(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+	test.bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	ctx := context.Background()
+	err := test.sp.BootClients(ctx, []string{test.vcContainer})
+	require.NoError(t, err)
+	require.Contains(t, test.docker.started, test.vcContainer)
+
+	// The modules show up once the daemon is already running
+	registerDuplicateValidatorModules(test.sp, getMockValidatorPubkeys(test.bn))
+	test.docker.started = nil
+	err = test.sp.RestartContainer(ctx, test.vcContainer)
+	require.ErrorIs(t, err, common.ErrDuplicateValidators)
+	err = test.sp.StartContainer(ctx, test.vcContainer)
+	require.ErrorIs(t, err, common.ErrDuplicateValidators)
+	require.Empty(t, test.docker.started)
+	t.Logf("Validator Client start was refused: %s", err.Error())
+
+	// Other clients aren't affected
+	err = test.sp.RestartContainer(ctx, test.bnContainer)
+	require.NoError(t, err)
+
+	test.sp.GetConfig().AllowDuplicateValidators.Value = true
+	err = test.sp.RestartContainer(ctx, test.vcContainer)
+	require.NoError(t, err)
+	require.Equal(t, []string{test.bnContainer, test.vcContainer}, test.docker.started)
+}
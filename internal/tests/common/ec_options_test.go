@@ -0,0 +1,96 @@
+package common_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the flags for Besu's data storage format, ahead of the user's own flags
+func TestEcFlags_Besu(t *testing.T) {
+	cfg := newLocalEcTestConfig(t, config.ExecutionClient_Besu)
+	flags, err := cfg.GetEcAdditionalFlags()
+	require.NoError(t, err)
+	require.Empty(t, flags)
+
+	cfg.ExecutionClientOptions.Besu.DataStorageFormat.Value = hdconfig.BesuDataStorageFormat_Forest
+	cfg.LocalExecutionClient.Besu.AdditionalFlags.Value = "--rpc-gas-cap=0"
+	flags, err = cfg.GetEcAdditionalFlags()
+	require.NoError(t, err)
+	require.Equal(t, "--data-storage-format=FOREST --rpc-gas-cap=0", flags)
+	require.Empty(t, cfg.GetWarnings())
+}
+
+// Test the flags, tag, and peers for Erigon
+func TestEcFlags_Erigon(t *testing.T) {
+	cfg := newLocalEcTestConfig(t, hdconfig.ExecutionClient_Erigon)
+	for _, option := range cfg.LocalExecutionClient.ExecutionClient.Options {
+		require.NotEqual(t, hdconfig.ExecutionClient_Erigon, option.Value, "Erigon can't be run yet, so it shouldn't be selectable")
+	}
+	flags, err := cfg.GetEcAdditionalFlags()
+	require.NoError(t, err)
+	require.Equal(t, "--maxpeers=50", flags)
+
+	cfg.ExecutionClientOptions.Erigon.PruneMode.Value = hdconfig.ErigonPruneMode_Archive
+	cfg.ExecutionClientOptions.Erigon.MaxPeers.Value = 30
+	cfg.ExecutionClientOptions.Erigon.AdditionalFlags.Value = "--torrent.download.rate=64mb"
+	flags, err = cfg.GetEcAdditionalFlags()
+	require.NoError(t, err)
+	require.Equal(t, "--maxpeers=30 --prune.mode=archive --torrent.download.rate=64mb", flags)
+	maxPeers, err := cfg.GetEcMaxPeers()
+	require.NoError(t, err)
+	require.Equal(t, uint16(30), maxPeers)
+	tag, err := cfg.GetEcContainerTag()
+	require.NoError(t, err)
+	require.Equal(t, cfg.ExecutionClientOptions.Erigon.ContainerTag.Value, tag)
+	require.Contains(t, tag, "erigon")
+	require.Empty(t, cfg.GetWarnings())
+}
+
+// Test that other clients don't get the Besu and Erigon flags, and that saving warns about them instead
+func TestEcFlags_InactiveClient(t *testing.T) {
+	cfg := newLocalEcTestConfig(t, config.ExecutionClient_Geth)
+	cfg.LocalExecutionClient.Geth.AdditionalFlags.Value = "--cache=4096"
+	cfg.ExecutionClientOptions.Besu.DataStorageFormat.Value = hdconfig.BesuDataStorageFormat_Forest
+	cfg.ExecutionClientOptions.Erigon.PruneMode.Value = hdconfig.ErigonPruneMode_Minimal
+	flags, err := cfg.GetEcAdditionalFlags()
+	require.NoError(t, err)
+	require.Equal(t, "--cache=4096", flags)
+
+	path := filepath.Join(t.TempDir(), hdconfig.ConfigFilename)
+	warnings, err := cfg.SaveToFile(path, nil)
+	require.NoError(t, err)
+	require.Len(t, warnings, 2)
+	require.Contains(t, warnings[0], "Besu")
+	require.Contains(t, warnings[1], "Erigon")
+	t.Logf("Save warnings: %v", warnings)
+
+	// Switching to Besu keeps every other setting
+	loaded, err := hdconfig.LoadFromFile(path)
+	require.NoError(t, err)
+	loaded.LocalExecutionClient.ExecutionClient.Value = config.ExecutionClient_Besu
+	require.Equal(t, hdconfig.BesuDataStorageFormat_Forest, loaded.ExecutionClientOptions.Besu.DataStorageFormat.Value)
+	require.Equal(t, hdconfig.ErigonPruneMode_Minimal, loaded.ExecutionClientOptions.Erigon.PruneMode.Value)
+	require.Equal(t, "--cache=4096", loaded.LocalExecutionClient.Geth.AdditionalFlags.Value)
+	flags, err = loaded.GetEcAdditionalFlags()
+	require.NoError(t, err)
+	require.Equal(t, "--data-storage-format=FOREST", flags)
+	warnings = loaded.GetWarnings()
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "Erigon")
+
+	// None of them apply to an external client
+	loaded.ClientMode.Value = config.ClientMode_External
+	require.Len(t, loaded.GetWarnings(), 2)
+}
+
+// Creates a config that runs the provided Execution Client locally
+func newLocalEcTestConfig(t *testing.T, ec config.ExecutionClient) *hdconfig.HyperdriveConfig {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ClientMode.Value = config.ClientMode_Local
+	cfg.LocalExecutionClient.ExecutionClient.Value = ec
+	return cfg
+}
@@ -0,0 +1,123 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+// Test computing attestation effectiveness for validators with good, partial, missing, and no duties
+func TestGetAttestationEffectiveness(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = 16
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, validator := range bn.Validators {
+		pubkeys = append(pubkeys, beacon.ValidatorPubkey(validator.Validator.Pubkey))
+	}
+	unknown := beacon.ValidatorPubkey{0xff}
+	pubkeys = append(pubkeys, unknown)
+
+	// Each validator has one duty in each of epochs 2 and 3
+	bn.Committees[2] = []common.BeaconCommittee{{Index: 0, Slot: 8, Validators: []string{"0", "1", "2"}}}
+	bn.Committees[3] = []common.BeaconCommittee{{Index: 0, Slot: 13, Validators: []string{"2", "1", "0"}}}
+
+	// Validator 0 is always included immediately, validator 1 is included late once and missed once, validator 2 is never included
+	bn.Attestations[9] = []common.BeaconAttestation{newTestAttestation(8, 0, "0x09")}
+	bn.Attestations[10] = []common.BeaconAttestation{newTestAttestation(8, 0, "0x0a")}
+	bn.Attestations[14] = []common.BeaconAttestation{newTestAttestation(13, 0, "0x0c")}
+	bn.Attestations[15] = []common.BeaconAttestation{newTestAttestation(12, 0, "0x0f")}
+
+	sp := newTestServiceProvider(t, bn.URL, "")
+	stats, err := sp.GetAttestationEffectiveness(context.Background(), pubkeys, 2)
+	require.NoError(t, err)
+	require.Len(t, stats, 4)
+
+	good := stats[pubkeys[0]]
+	require.Equal(t, common.EffectivenessStatus_Attesting, good.Status)
+	require.Equal(t, "0", good.Index)
+	require.Equal(t, uint64(2), good.StartEpoch)
+	require.Equal(t, uint64(3), good.EndEpoch)
+	require.Equal(t, uint64(2), good.ExpectedAttestations)
+	require.Equal(t, uint64(2), good.IncludedAttestations)
+	require.Equal(t, 1.0, good.Participation)
+	require.Equal(t, 1.0, good.AverageInclusionDistance)
+
+	partial := stats[pubkeys[1]]
+	require.Equal(t, common.EffectivenessStatus_Attesting, partial.Status)
+	require.Equal(t, uint64(1), partial.IncludedAttestations)
+	require.Equal(t, uint64(1), partial.MissedAttestations)
+	require.Equal(t, 0.5, partial.Participation)
+	require.Equal(t, 2.0, partial.AverageInclusionDistance)
+	require.Equal(t, uint64(2), partial.MinInclusionDistance)
+	require.Equal(t, uint64(2), partial.MaxInclusionDistance)
+
+	missing := stats[pubkeys[2]]
+	require.Equal(t, common.EffectivenessStatus_NoAttestations, missing.Status)
+	require.Equal(t, uint64(2), missing.MissedAttestations)
+	require.Equal(t, 0.0, missing.Participation)
+
+	noDuties := stats[unknown]
+	require.Equal(t, common.EffectivenessStatus_NoDuties, noDuties.Status)
+	require.Equal(t, "", noDuties.Index)
+	require.Equal(t, uint64(0), noDuties.ExpectedAttestations)
+	t.Log("Attestation effectiveness was computed correctly for each validator")
+}
+
+// Test that Electra attestations are matched by their committee bits, and that committees come from each epoch's state
+func TestGetAttestationEffectiveness_Electra(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = 16
+	bn.AttestationsVersion = "electra"
+	bn.AddValidators(5, beacon.ValidatorState_ActiveOngoing, 32e9)
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, validator := range bn.Validators {
+		pubkeys = append(pubkeys, beacon.ValidatorPubkey(validator.Validator.Pubkey))
+	}
+	stateIds := []string{}
+	bn.Handle("/eth/v1/beacon/states/12/committees", func(w http.ResponseWriter, r *http.Request) {
+		stateIds = append(stateIds, "12")
+		writeJson(w, http.StatusOK, map[string]any{"data": bn.Committees[3]})
+	})
+
+	// Two committees in slot 12, plus a third one that no attestation covers
+	bn.Committees[3] = []common.BeaconCommittee{
+		{Index: 0, Slot: 12, Validators: []string{"0", "1"}},
+		{Index: 1, Slot: 12, Validators: []string{"2", "3"}},
+		{Index: 2, Slot: 12, Validators: []string{"4"}},
+	}
+
+	// One aggregate covers committees 0 and 1; validators 1 and 3 attested (bits 1 and 3, plus the length bit at 4)
+	attestation := newTestAttestation(12, 0, "0x1a")
+	attestation.CommitteeBits = []byte{0x03, 0, 0, 0, 0, 0, 0, 0}
+	bn.Attestations[13] = []common.BeaconAttestation{attestation}
+
+	sp := newTestServiceProvider(t, bn.URL, "")
+	stats, err := sp.GetAttestationEffectiveness(context.Background(), pubkeys, 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"12"}, stateIds)
+	require.Equal(t, uint64(0), stats[pubkeys[0]].IncludedAttestations)
+	require.Equal(t, uint64(1), stats[pubkeys[1]].IncludedAttestations)
+	require.Equal(t, uint64(0), stats[pubkeys[2]].IncludedAttestations)
+	require.Equal(t, uint64(1), stats[pubkeys[3]].IncludedAttestations)
+	require.Equal(t, uint64(0), stats[pubkeys[4]].IncludedAttestations)
+	require.Equal(t, uint64(1), stats[pubkeys[4]].MissedAttestations)
+	t.Log("Electra attestations were attributed to the right committee members")
+}
+
+// Creates an attestation for the provided slot and committee
+func newTestAttestation(slot uint64, committeeIndex uint64, aggregationBits string) common.BeaconAttestation {
+	var attestation common.BeaconAttestation
+	attestation.AggregationBits = ethcommon.FromHex(aggregationBits)
+	attestation.Data.Slot = client.Uinteger(slot)
+	attestation.Data.Index = client.Uinteger(committeeIndex)
+	return attestation
+}
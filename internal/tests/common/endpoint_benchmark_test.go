@@ -0,0 +1,112 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Wraps a mock server's handler with a server that delays every request
+func newLatencyServer(t *testing.T, handler http.Handler, latency time.Duration) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Creates a mock EC that answers the benchmark's requests like a full (non-archive) node
+func newBenchmarkExecutionClient(t *testing.T) *mockExecutionClient {
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetResult("eth_blockNumber", "0x3e8")
+	ec.SetResult("eth_getBlockByNumber", map[string]any{"number": "0x3e8"})
+	ec.SetResult("eth_gasPrice", "0x3b9aca00")
+	ec.SetResult("rpc_modules", map[string]string{"eth": "1.0", "net": "1.0", "txpool": "1.0"})
+	ec.Handlers["eth_getBalance"] = func(params []json.RawMessage) (any, error) {
+		var block string
+		_ = json.Unmarshal(params[1], &block)
+		if block != "latest" {
+			return nil, errors.New("missing trie node")
+		}
+		return "0x0", nil
+	}
+	return ec
+}
+
+// Test benchmarking an Execution Client
+func TestBenchmarkEndpoint_ExecutionClient(t *testing.T) {
+	ec := newBenchmarkExecutionClient(t)
+	server := newLatencyServer(t, ec.Config.Handler, 5*time.Millisecond)
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+
+	result, err := sp.BenchmarkEndpoint(context.Background(), server.URL, 20)
+	require.NoError(t, err)
+	require.Equal(t, common.RpcClientType_ExecutionClient, result.ClientType)
+	require.Equal(t, 20, result.Samples)
+	require.Zero(t, result.Errors)
+	require.Equal(t, uint64(20), result.Latency.Count)
+	require.GreaterOrEqual(t, result.Latency.P50, 5*time.Millisecond)
+	require.GreaterOrEqual(t, result.Latency.P99, result.Latency.P50)
+	require.Len(t, result.MethodLatency, 5)
+	require.Equal(t, uint64(4), result.MethodLatency["eth_gasPrice"].Count)
+	require.False(t, result.Capabilities.IsArchive)
+	require.Equal(t, []string{"eth", "net", "txpool"}, result.Capabilities.Namespaces)
+	t.Logf("EC latency: %+v", result.Latency)
+}
+
+// Test that failing requests are counted in the error rate
+func TestBenchmarkEndpoint_Errors(t *testing.T) {
+	ec := newBenchmarkExecutionClient(t)
+	delete(ec.Results, "eth_gasPrice")
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+
+	result, err := sp.BenchmarkEndpoint(context.Background(), ec.URL, 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Errors)
+	require.InDelta(t, 0.2, result.ErrorRate, 1e-9)
+	require.Equal(t, uint64(8), result.Latency.Count)
+}
+
+// Test benchmarking a Beacon Node
+func TestBenchmarkEndpoint_BeaconNode(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	server := newLatencyServer(t, bn.Config.Handler, 2*time.Millisecond)
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+
+	result, err := sp.BenchmarkEndpoint(context.Background(), server.URL, 8)
+	require.NoError(t, err)
+	require.Equal(t, common.RpcClientType_BeaconNode, result.ClientType)
+	require.Equal(t, 8, result.Samples)
+	require.Zero(t, result.Errors)
+	require.Len(t, result.MethodLatency, 4)
+	require.True(t, result.Capabilities.IsArchive)
+	t.Logf("BN latency: %+v", result.Latency)
+}
+
+// Test that a benchmark stops when its context is cancelled, returning the samples taken so far
+func TestBenchmarkEndpoint_Cancelled(t *testing.T) {
+	ec := newBenchmarkExecutionClient(t)
+	server := newLatencyServer(t, ec.Config.Handler, 20*time.Millisecond)
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	result, err := sp.BenchmarkEndpoint(ctx, server.URL, 1000)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Greater(t, result.Samples, 0)
+	require.Less(t, result.Samples, 1000)
+	require.Zero(t, result.Errors)
+	t.Logf("Benchmark stopped after %d samples", result.Samples)
+}
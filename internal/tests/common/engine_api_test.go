@@ -0,0 +1,83 @@
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the Engine API check tells an unreachable endpoint, a rejected secret, and missing methods apart
+func TestVerifyEngineApiReady(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte{0x11}, 32)
+	secretPath := filepath.Join(t.TempDir(), "jwtsecret")
+	require.NoError(t, os.WriteFile(secretPath, []byte("0x"+hex.EncodeToString(secret)+"\n"), 0600))
+
+	engine := newMockExecutionClient(t, 1)
+	engine.JwtSecret = secret
+	engine.SetResult("engine_exchangeCapabilities", []string{"engine_newPayloadV3", "engine_forkchoiceUpdatedV3", "engine_getPayloadV3"})
+	cfg := newTestConfig(t, "http://127.0.0.1:1", engine.URL)
+	cfg.ClientMode.Value = config.ClientMode_External
+	cfg.EngineApiUrl.Value = engine.URL
+	cfg.EngineJwtSecretPath.Value = secretPath
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	require.NoError(t, sp.VerifyEngineApiReady(ctx))
+
+	// The client only knows an older fork's methods
+	engine.SetResult("engine_exchangeCapabilities", []string{"engine_newPayloadV2", "engine_forkchoiceUpdatedV2", "engine_getPayloadV2"})
+	err := sp.VerifyEngineApiReady(ctx)
+	require.ErrorIs(t, err, common.ErrEngineApiUnsupported)
+	require.ErrorContains(t, err, "engine_newPayloadV3")
+
+	// The client doesn't know engine_exchangeCapabilities at all
+	delete(engine.Results, "engine_exchangeCapabilities")
+	require.ErrorIs(t, sp.VerifyEngineApiReady(ctx), common.ErrEngineApiUnsupported)
+
+	// The client was set up with a different secret
+	engine.JwtSecret = bytes.Repeat([]byte{0x22}, 32)
+	require.ErrorIs(t, sp.VerifyEngineApiReady(ctx), common.ErrEngineApiAuthFailed)
+
+	// Nothing is listening
+	engine.Close()
+	require.ErrorIs(t, sp.VerifyEngineApiReady(ctx), common.ErrEngineApiUnreachable)
+
+	// An external client can't be checked without knowing where its Engine API is
+	cfg.EngineApiUrl.Value = ""
+	require.ErrorIs(t, sp.VerifyEngineApiReady(ctx), common.ErrEngineApiNotConfigured)
+}
+
+// Test that startup doesn't start the Beacon Node when the Execution Client rejects the Engine API secret
+func TestBootClients_EngineApiAuthFailed(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+	test.engine.JwtSecret = bytes.Repeat([]byte{0x22}, 32)
+
+	err := test.sp.BootClients(context.Background(), []string{test.vcContainer})
+	require.ErrorIs(t, err, common.ErrEngineApiAuthFailed)
+	require.Equal(t, []string{test.ecContainer}, test.docker.started)
+	t.Logf("Startup failed with: %s", err.Error())
+}
+
+// Test that startup reports an Engine API that never comes up as a client that isn't ready, keeping the underlying error
+func TestBootClients_EngineApiUnreachable(t *testing.T) {
+	test := newBootTest(t, 1*time.Second)
+	test.docker.onStart[test.ecContainer] = func() {
+		test.ec.SetUnavailable(false)
+	}
+
+	err := test.sp.BootClients(context.Background(), []string{test.vcContainer})
+	require.ErrorIs(t, err, common.ErrEngineApiUnreachable)
+	var bootErr *common.ClientBootError
+	require.ErrorAs(t, err, &bootErr)
+	require.Equal(t, "Execution Client", bootErr.Client)
+	require.True(t, bootErr.NotReady)
+	require.Equal(t, []string{test.ecContainer}, test.docker.started)
+	t.Logf("Startup failed with: %s", err.Error())
+}
@@ -0,0 +1,117 @@
+package common_test
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that rotating the secret restarts the EC and BN together and waits for both to come back
+func TestRotateEngineJwt(t *testing.T) {
+	test := newBootTest(t, 5*time.Second)
+	test.sp.GetConfig().EnableEngineJwtRotation.Value = true
+
+	err := test.sp.RotateEngineJwt(context.Background())
+	require.NoError(t, err)
+
+	// Both restarts should have happened before either client came back
+	require.Len(t, test.docker.events, 4)
+	require.ElementsMatch(t, []string{"start " + test.ecContainer, "start " + test.bnContainer}, test.docker.events[:2])
+	require.ElementsMatch(t, []string{"ready " + test.ecContainer, "ready " + test.bnContainer}, test.docker.events[2:])
+	t.Logf("Restart events: %v", test.docker.events)
+
+	// Check the secret was written to the file the containers mount, keeping its permissions
+	path := filepath.Join(test.secretsDir, hdconfig.EngineJwtFilename)
+	first, err := os.ReadFile(path)
+	require.NoError(t, err)
+	secret, err := hex.DecodeString(string(first))
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// Rotating again should replace it
+	err = test.sp.RotateEngineJwt(context.Background())
+	require.NoError(t, err)
+	second, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+	t.Log("Secret was replaced on the second rotation")
+}
+
+// Test that rotation fails if a client doesn't come back after the restart
+func TestRotateEngineJwt_ClientDoesntReconnect(t *testing.T) {
+	test := newBootTest(t, 1*time.Second)
+	test.sp.GetConfig().EnableEngineJwtRotation.Value = true
+	delete(test.docker.onStart, test.bnContainer)
+
+	err := test.sp.RotateEngineJwt(context.Background())
+	require.ErrorContains(t, err, "Beacon Node")
+}
+
+// Test that rotation fails if the Beacon Node can't authenticate to the Execution Client afterwards
+func TestRotateEngineJwt_EngineOffline(t *testing.T) {
+	test := newBootTest(t, 1*time.Second)
+	test.sp.GetConfig().EnableEngineJwtRotation.Value = true
+	test.bn.SetElOffline(true)
+
+	err := test.sp.RotateEngineJwt(context.Background())
+	require.ErrorContains(t, err, "Execution Client as offline")
+	t.Logf("Rotation failed with: %s", err.Error())
+}
+
+// Test that rotation refuses to run if the clients don't share a secret, or can't read it
+func TestRotateEngineJwt_SecretNotShared(t *testing.T) {
+	test := newBootTest(t, 1*time.Second)
+	test.sp.GetConfig().EnableEngineJwtRotation.Value = true
+	otherDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, hdconfig.EngineJwtFilename), []byte("00"), 0600))
+	require.NoError(t, test.docker.ContainerRemove(context.Background(), test.bnContainer, container.RemoveOptions{}))
+	addJwtTestContainer(t, test, test.bnContainer, otherDir, "")
+
+	err := test.sp.RotateEngineJwt(context.Background())
+	require.ErrorContains(t, err, "mounted into both")
+	require.Empty(t, test.docker.events)
+
+	// A BN running as a user that can't read the secret
+	require.NoError(t, test.docker.ContainerRemove(context.Background(), test.bnContainer, container.RemoveOptions{}))
+	addJwtTestContainer(t, test, test.bnContainer, test.secretsDir, "65533:65533")
+	err = test.sp.RotateEngineJwt(context.Background())
+	require.ErrorContains(t, err, "can't read")
+	require.Empty(t, test.docker.events)
+}
+
+// Test that rotation respects the config setting
+func TestRotateEngineJwt_Disabled(t *testing.T) {
+	test := newBootTest(t, 1*time.Second)
+	err := test.sp.RotateEngineJwt(context.Background())
+	require.ErrorIs(t, err, common.ErrEngineJwtRotationDisabled)
+	require.Empty(t, test.docker.events)
+}
+
+// Adds a stopped container that mounts the provided secrets directory and runs as the provided user
+func addJwtTestContainer(t *testing.T, test *bootTest, name string, secretsDir string, user string) {
+	then := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+	err := test.docker.Mock_AddContainer(dtypes.ContainerJSON{
+		ContainerJSONBase: &dtypes.ContainerJSONBase{
+			Name:  name,
+			State: &dtypes.ContainerState{StartedAt: then, FinishedAt: then},
+		},
+		Mounts: []dtypes.MountPoint{
+			{Type: mount.TypeBind, Source: secretsDir, Destination: "/secrets"},
+		},
+		Config: &container.Config{User: user},
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,261 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+const testAggregate3ValueAbi string = `[{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"value","type":"uint256"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3Value","outputs":[],"stateMutability":"payable","type":"function"}]`
+
+var (
+	claimContract      = ethcommon.HexToAddress("0x00000000000000000000000000000000000c1a10")
+	stakeContract      = ethcommon.HexToAddress("0x00000000000000000000000000000000000c1a11")
+	distributeContract = ethcommon.HexToAddress("0x00000000000000000000000000000000000c1a12")
+)
+
+type testMulticallCall struct {
+	Target       ethcommon.Address
+	AllowFailure bool
+	Value        *big.Int
+	CallData     []byte
+}
+
+// Creates a pending action with a transaction from the test node to the provided contract
+func newTxPendingAction(id string, to ethcommon.Address, value int64, dependsOn ...string) common.PendingAction {
+	action := newMockPendingAction(id, id, ethcommon.Hash{})
+	action.From = testNodeAddress
+	action.DependsOn = dependsOn
+	action.Transaction = types.NewTx(&types.DynamicFeeTx{
+		To:    &to,
+		Value: big.NewInt(value),
+		Data:  []byte(id),
+	})
+	return action
+}
+
+// Creates a service provider with a mock Execution Client that has receipts for the transactions it receives. Gas
+// estimation reverts for calls to revertingTarget, and transactions to failingTarget revert once they're included.
+func newExecuteActionsTestServiceProvider(t *testing.T, multicallDeployed bool, revertingTarget ethcommon.Address, failingTarget ethcommon.Address) (*mockExecutionClient, *common.ServiceProvider, *[]*types.Transaction) {
+	_, ec, sp, sent := newExecutionRequestTestServiceProvider(t)
+	ec.Handlers["eth_getCode"] = func(params []json.RawMessage) (any, error) {
+		var address ethcommon.Address
+		require.NoError(t, json.Unmarshal(params[0], &address))
+		if multicallDeployed && address == common.Multicall3Address {
+			return "0x6080604052", nil
+		}
+		return "0x", nil
+	}
+	ec.Handlers["eth_estimateGas"] = func(params []json.RawMessage) (any, error) {
+		var call struct {
+			To ethcommon.Address `json:"to"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &call))
+		if call.To == revertingTarget {
+			return nil, &mockRpcError{Code: 3, Message: "execution reverted: not ready", Data: hexutil.Encode(newRevertReasonData(t, "not ready"))}
+		}
+		return hexutil.Uint64(60000), nil
+	}
+	ec.Handlers["eth_getTransactionReceipt"] = func(params []json.RawMessage) (any, error) {
+		var hash ethcommon.Hash
+		require.NoError(t, json.Unmarshal(params[0], &hash))
+		receipt := &types.Receipt{
+			Type:        types.DynamicFeeTxType,
+			Status:      types.ReceiptStatusSuccessful,
+			TxHash:      hash,
+			GasUsed:     50000,
+			BlockNumber: big.NewInt(1001),
+			Logs:        []*types.Log{},
+		}
+		for _, tx := range *sent {
+			if tx.Hash() == hash && *tx.To() == failingTarget {
+				receipt.Status = types.ReceiptStatusFailed
+			}
+		}
+		return receipt, nil
+	}
+	return ec, sp, sent
+}
+
+// Test executing actions one at a time, where one would revert, one reverts once it's included, and the actions that
+// depend on them are skipped
+func TestExecuteActions_Sequential(t *testing.T) {
+	_, sp, sent := newExecuteActionsTestServiceProvider(t, true, stakeContract, distributeContract)
+	exitHash := ethcommon.HexToHash("0xee")
+	actions := []common.PendingAction{
+		newTxPendingAction("claim", claimContract, 0),
+		newTxPendingAction("stake", stakeContract, 0),
+		newTxPendingAction("deposit", claimContract, 0, "stake"),
+		newMockPendingAction("exit", "Exit validator 5", exitHash),
+		newTxPendingAction("distribute", distributeContract, 2, "claim"),
+		newTxPendingAction("restake", claimContract, 0, "distribute"),
+	}
+
+	results, err := sp.ExecuteActions(context.Background(), actions, common.ExecuteOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, len(actions))
+	require.Len(t, *sent, 2)
+	require.Equal(t, uint64(7), (*sent)[0].Nonce())
+	require.Equal(t, claimContract, *(*sent)[0].To())
+	require.Equal(t, uint64(8), (*sent)[1].Nonce())
+	require.Equal(t, distributeContract, *(*sent)[1].To())
+	require.Equal(t, big.NewInt(2), (*sent)[1].Value())
+
+	require.True(t, results[0].Success)
+	require.Equal(t, (*sent)[0].Hash(), results[0].TxHash)
+	require.Equal(t, (*sent)[0].Hash(), results[0].Receipt.TxHash)
+	require.False(t, results[1].Success)
+	require.Contains(t, results[1].Error, common.ErrTransactionWouldRevert.Error())
+	require.Equal(t, ethcommon.Hash{}, results[1].TxHash)
+	require.False(t, results[2].Success)
+	require.Contains(t, results[2].Error, "[stake]")
+	require.True(t, results[3].Success)
+	require.Equal(t, exitHash, results[3].TxHash)
+	require.False(t, results[4].Success)
+	require.Equal(t, types.ReceiptStatusFailed, results[4].Receipt.Status)
+	require.Equal(t, "the transaction reverted", results[4].Error)
+	require.Contains(t, results[5].Error, "[distribute]")
+	for _, result := range results {
+		require.False(t, result.Bundled)
+	}
+}
+
+// Test bundling the actions that support it into one multicall, with the rest sent after it
+func TestExecuteActions_Bundled(t *testing.T) {
+	_, sp, sent := newExecuteActionsTestServiceProvider(t, true, ethcommon.Address{}, ethcommon.Address{})
+	claim := newTxPendingAction("claim", claimContract, 0)
+	claim.Bundleable = true
+	distribute := newTxPendingAction("distribute", distributeContract, 3)
+	distribute.Bundleable = true
+	actions := []common.PendingAction{
+		claim,
+		newTxPendingAction("stake", stakeContract, 0, "claim"),
+		distribute,
+	}
+
+	results, err := sp.ExecuteActions(context.Background(), actions, common.ExecuteOptions{Bundle: true})
+	require.NoError(t, err)
+	require.Len(t, *sent, 2)
+	bundle := (*sent)[0]
+	require.Equal(t, common.Multicall3Address, *bundle.To())
+	require.Equal(t, uint64(7), bundle.Nonce())
+	require.Equal(t, big.NewInt(3), bundle.Value())
+	require.Equal(t, stakeContract, *(*sent)[1].To())
+	require.Equal(t, uint64(8), (*sent)[1].Nonce())
+
+	multicallAbi, err := abi.JSON(strings.NewReader(testAggregate3ValueAbi))
+	require.NoError(t, err)
+	method := multicallAbi.Methods["aggregate3Value"]
+	require.Equal(t, method.ID, bundle.Data()[:4])
+	unpacked, err := method.Inputs.Unpack(bundle.Data()[4:])
+	require.NoError(t, err)
+	calls := *abi.ConvertType(unpacked[0], new([]testMulticallCall)).(*[]testMulticallCall)
+	require.Len(t, calls, 2)
+	for i, action := range []common.PendingAction{claim, distribute} {
+		require.Equal(t, *action.Transaction.To(), calls[i].Target)
+		require.False(t, calls[i].AllowFailure)
+		require.Zero(t, action.Transaction.Value().Cmp(calls[i].Value))
+		require.Equal(t, action.Transaction.Data(), calls[i].CallData)
+	}
+
+	for _, i := range []int{0, 2} {
+		require.True(t, results[i].Bundled)
+		require.True(t, results[i].Success)
+		require.Equal(t, bundle.Hash(), results[i].TxHash)
+	}
+	require.False(t, results[1].Bundled)
+	require.True(t, results[1].Success)
+	require.Equal(t, (*sent)[1].Hash(), results[1].TxHash)
+}
+
+// Test that bundling falls back to sending the actions individually when Multicall3 isn't deployed, and that invalid
+// batches are rejected
+func TestExecuteActions_BundleFallback(t *testing.T) {
+	_, sp, sent := newExecuteActionsTestServiceProvider(t, false, ethcommon.Address{}, ethcommon.Address{})
+	claim := newTxPendingAction("claim", claimContract, 0)
+	claim.Bundleable = true
+	distribute := newTxPendingAction("distribute", distributeContract, 0)
+	distribute.Bundleable = true
+
+	results, err := sp.ExecuteActions(context.Background(), []common.PendingAction{claim, distribute}, common.ExecuteOptions{Bundle: true})
+	require.NoError(t, err)
+	require.Len(t, *sent, 2)
+	require.Equal(t, uint64(7), (*sent)[0].Nonce())
+	require.Equal(t, uint64(8), (*sent)[1].Nonce())
+	for _, result := range results {
+		require.False(t, result.Bundled)
+		require.True(t, result.Success)
+	}
+
+	_, err = sp.ExecuteActions(context.Background(), []common.PendingAction{claim, claim}, common.ExecuteOptions{})
+	require.ErrorContains(t, err, "more than once")
+	_, err = sp.ExecuteActions(context.Background(), []common.PendingAction{newTxPendingAction("stake", stakeContract, 0, "claim"), claim}, common.ExecuteOptions{})
+	require.ErrorContains(t, err, "comes after it")
+	require.Len(t, *sent, 2)
+}
+
+// Test that actions whose transactions were replaced or dropped fail instead of being waited on forever, and that
+// nothing is executed when the node wallet isn't ready
+func TestExecuteActions_Dropped(t *testing.T) {
+	ec, sp, sent := newExecuteActionsTestServiceProvider(t, false, ethcommon.Address{}, ethcommon.Address{})
+	droppedHash := ethcommon.HexToHash("0xdd")
+	getReceipt := ec.Handlers["eth_getTransactionReceipt"]
+	ec.Handlers["eth_getTransactionReceipt"] = func(params []json.RawMessage) (any, error) {
+		var hash ethcommon.Hash
+		require.NoError(t, json.Unmarshal(params[0], &hash))
+		if hash == droppedHash || hash == (*sent)[0].Hash() {
+			return nil, nil
+		}
+		return getReceipt(params)
+	}
+	ec.Handlers["eth_getTransactionByHash"] = func(params []json.RawMessage) (any, error) {
+		var hash ethcommon.Hash
+		require.NoError(t, json.Unmarshal(params[0], &hash))
+		for _, tx := range *sent {
+			if tx.Hash() == hash {
+				return tx, nil
+			}
+		}
+		return nil, nil
+	}
+
+	// The first transaction's nonce has been used by something else
+	ec.Handlers["eth_getTransactionCount"] = func(params []json.RawMessage) (any, error) {
+		var block string
+		require.NoError(t, json.Unmarshal(params[1], &block))
+		if block == "pending" {
+			return hexutil.Uint64(7), nil
+		}
+		return hexutil.Uint64(8), nil
+	}
+
+	actions := []common.PendingAction{
+		newTxPendingAction("claim", claimContract, 0),
+		newMockPendingAction("exit", "Exit validator 5", droppedHash),
+		newTxPendingAction("distribute", distributeContract, 0),
+	}
+	results, err := sp.ExecuteActions(context.Background(), actions, common.ExecuteOptions{})
+	require.NoError(t, err)
+	require.Len(t, *sent, 2)
+	require.False(t, results[0].Success)
+	require.Contains(t, results[0].Error, "replaced")
+	require.Nil(t, results[0].Receipt)
+	require.False(t, results[1].Success)
+	require.Contains(t, results[1].Error, "dropped")
+	require.True(t, results[2].Success)
+
+	// A read-only node can't execute anything
+	require.NoError(t, sp.GetWallet().MasqueradeAsAddress(ethcommon.HexToAddress("0x1234")))
+	_, err = sp.ExecuteActions(context.Background(), actions, common.ExecuteOptions{})
+	require.ErrorContains(t, err, "read-only")
+	require.Len(t, *sent, 2)
+}
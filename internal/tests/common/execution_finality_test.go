@@ -0,0 +1,75 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the EC's block pointers and comparing its finalized block with the BN's
+func TestGetExecutionBlockPointers(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.SetFinalizedEpoch(8)
+	ec := newMockExecutionClient(t, 1)
+	numbers := map[string]uint64{"latest": 330, "safe": 288, "finalized": 256}
+	setMockBlockTags(t, ec, numbers)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+
+	pointers, err := sp.GetExecutionBlockPointers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, common.BlockPointer{Number: 330, Hash: getMockBlockHash(330)}, pointers.Latest)
+	require.Equal(t, common.BlockPointer{Number: 288, Hash: getMockBlockHash(288)}, pointers.Safe)
+	require.Equal(t, common.BlockPointer{Number: 256, Hash: getMockBlockHash(256)}, pointers.Finalized)
+	require.Equal(t, uint64(74), pointers.GetBlocksSinceFinality())
+	require.False(t, pointers.PossibleFinalityIssue)
+
+	// The BN finalized the first slot of epoch 8, which matches
+	crossCheck, err := sp.CrossCheckFinality(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, pointers.Finalized, crossCheck.BeaconFinalized)
+	require.True(t, crossCheck.Consistent)
+
+	// Now let the EC fall behind on finality
+	numbers["latest"] = 256 + common.ExecutionFinalityGapThreshold + 1
+	numbers["safe"] = 250
+	numbers["finalized"] = 224
+	pointers, err = sp.GetExecutionBlockPointers(context.Background())
+	require.NoError(t, err)
+	require.True(t, pointers.PossibleFinalityIssue)
+	crossCheck, err = sp.CrossCheckFinality(context.Background())
+	require.NoError(t, err)
+	require.False(t, crossCheck.Consistent)
+	require.Equal(t, uint64(256), crossCheck.BeaconFinalized.Number)
+	require.Equal(t, uint64(224), crossCheck.Execution.Finalized.Number)
+}
+
+// Test that an EC without a finalized block (e.g. one that's still syncing) is reported as an error
+func TestGetExecutionBlockPointers_NoFinalizedBlock(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	ec := newMockExecutionClient(t, 1)
+	setMockBlockTags(t, ec, map[string]uint64{"latest": 100})
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+
+	_, err := sp.GetExecutionBlockPointers(context.Background())
+	require.ErrorContains(t, err, "finalized")
+}
+
+// Serves the blocks for the provided tags from eth_getBlockByNumber, returning null for tags that aren't in the map
+func setMockBlockTags(t *testing.T, ec *mockExecutionClient, numbers map[string]uint64) {
+	ec.Handlers["eth_getBlockByNumber"] = func(params []json.RawMessage) (any, error) {
+		var tag string
+		require.NoError(t, json.Unmarshal(params[0], &tag))
+		number, exists := numbers[tag]
+		if !exists {
+			return nil, nil
+		}
+		return map[string]any{
+			"number": hexutil.Uint64(number),
+			"hash":   getMockBlockHash(number),
+		}, nil
+	}
+}
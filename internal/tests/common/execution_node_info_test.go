@@ -0,0 +1,87 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The enode reported by the stubbed admin_nodeInfo
+	testEnode string = "enode://6f8a80d14311c39f35f516fa664deaaaa13e85b2f7493f37f6144d86991ec012937307647bd3b9a82abe2974e1407241d54947bbb39763a4cac9f77166ad92a0@203.0.113.7:30303?discport=30304"
+)
+
+// Test getting the enode and the rest of the Execution Client's P2P identity
+func TestGetExecutionNodeInfo(t *testing.T) {
+	ec := newMockExecutionClient(t, 1)
+	ec.SetResult("admin_nodeInfo", newTestAdminNodeInfo())
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+	info, err := sp.GetExecutionNodeInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, common.ExecutionNodeInfo{
+		Enode:         testEnode,
+		Enr:           "enr:-Jq4QPKUw1kTA4dN",
+		ID:            "ae10cd5af4f9347f8e4b7a4c6316dab1d8e1007d8bfed9ea99e07e0f8a3b4c57",
+		ClientVersion: "Geth/v1.14.3-stable/linux-amd64/go1.22.2",
+		IP:            "203.0.113.7",
+		ListenAddr:    "[::]:30303",
+		DiscoveryPort: 30304,
+		ListenerPort:  30303,
+		Protocols:     []string{"eth", "snap"},
+	}, info)
+}
+
+// Test that clients without the admin namespace are reported as such
+func TestGetExecutionNodeInfo_AdminDisabled(t *testing.T) {
+	for _, rpcErr := range []*mockRpcError{
+		{Code: -32601, Message: "the method admin_nodeInfo does not exist/is not available"},
+		{Code: -32604, Message: "Method not enabled"},
+	} {
+		t.Run(rpcErr.Message, func(t *testing.T) {
+			ec := newMockExecutionClient(t, 1)
+			ec.Handlers["admin_nodeInfo"] = func(params []json.RawMessage) (any, error) {
+				return nil, rpcErr
+			}
+			sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+			_, err := sp.GetExecutionNodeInfo(context.Background())
+			require.ErrorIs(t, err, common.ErrAdminNamespaceDisabled)
+		})
+	}
+
+	// Other errors are passed through
+	ec := newMockExecutionClient(t, 1)
+	ec.Handlers["admin_nodeInfo"] = func(params []json.RawMessage) (any, error) {
+		return nil, &mockRpcError{Code: -32000, Message: "p2p server not running"}
+	}
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+	_, err := sp.GetExecutionNodeInfo(context.Background())
+	require.NotErrorIs(t, err, common.ErrAdminNamespaceDisabled)
+	require.ErrorContains(t, err, "p2p server not running")
+}
+
+// Creates an admin_nodeInfo response like Geth's
+func newTestAdminNodeInfo() map[string]any {
+	return map[string]any{
+		"id":    "ae10cd5af4f9347f8e4b7a4c6316dab1d8e1007d8bfed9ea99e07e0f8a3b4c57",
+		"name":  "Geth/v1.14.3-stable/linux-amd64/go1.22.2",
+		"enode": testEnode,
+		"enr":   "enr:-Jq4QPKUw1kTA4dN",
+		"ip":    "203.0.113.7",
+		"ports": map[string]any{
+			"discovery": 30304,
+			"listener":  30303,
+		},
+		"listenAddr": "[::]:30303",
+		"protocols": map[string]any{
+			"eth": map[string]any{
+				"network": 1,
+				"genesis": "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3",
+			},
+			"snap": map[string]any{},
+		},
+	}
+}
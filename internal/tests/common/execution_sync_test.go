@@ -0,0 +1,93 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test the sync progress of a client that's downloading blocks, including the rate and ETA across calls
+func TestGetExecutionSyncProgress(t *testing.T) {
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetResult("eth_syncing", map[string]string{
+		"startingBlock": "0x0",
+		"currentBlock":  "0x3e8",  // 1000
+		"highestBlock":  "0x2710", // 10000
+	})
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+	ctx := context.Background()
+
+	// The first call doesn't have a rate yet
+	progress, err := sp.GetExecutionSyncProgress(ctx)
+	require.NoError(t, err)
+	require.False(t, progress.Synced)
+	require.Equal(t, uint64(1000), progress.CurrentBlock)
+	require.Equal(t, uint64(10000), progress.HighestBlock)
+	require.InDelta(t, 10, progress.Percent, 0.001)
+	require.Zero(t, progress.BlocksPerSecond)
+	require.False(t, progress.HasEta)
+	require.False(t, progress.Healing)
+
+	// The second one measures the progress since the first
+	time.Sleep(200 * time.Millisecond)
+	ec.SetResult("eth_syncing", map[string]string{
+		"startingBlock": "0x0",
+		"currentBlock":  "0x1388", // 5000
+		"highestBlock":  "0x2710",
+	})
+	progress, err = sp.GetExecutionSyncProgress(ctx)
+	require.NoError(t, err)
+	require.InDelta(t, 50, progress.Percent, 0.001)
+	require.Positive(t, progress.BlocksPerSecond)
+	require.Less(t, progress.BlocksPerSecond, 4000/0.2)
+	require.True(t, progress.HasEta)
+	expectedEta := time.Duration(5000 / progress.BlocksPerSecond * float64(time.Second))
+	require.InDelta(t, float64(expectedEta), float64(progress.Eta), float64(time.Millisecond))
+	t.Logf("Syncing at %.0f blocks per second with %s left", progress.BlocksPerSecond, progress.Eta)
+}
+
+// Test the healing phase of snap sync, where the blocks are done but the state isn't
+func TestGetExecutionSyncProgress_Healing(t *testing.T) {
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetResult("eth_syncing", map[string]string{
+		"startingBlock":       "0x0",
+		"currentBlock":        "0x2710",
+		"highestBlock":        "0x2710",
+		"healedTrienodes":     "0x4d2", // 1234
+		"healedBytecodes":     "0x10",
+		"healingTrienodes":    "0x200", // 512
+		"healingBytecode":     "0x0",
+		"syncedAccounts":      "0x100",
+		"syncedAccountBytes":  "0x1000",
+		"syncedStorage":       "0x100",
+		"syncedStorageBytes":  "0x1000",
+		"syncedBytecodes":     "0x10",
+		"syncedBytecodeBytes": "0x100",
+	})
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+	progress, err := sp.GetExecutionSyncProgress(context.Background())
+	require.NoError(t, err)
+	require.False(t, progress.Synced)
+	require.True(t, progress.Healing)
+	require.Equal(t, uint64(1234), progress.HealedTrienodes)
+	require.Equal(t, uint64(16), progress.HealedBytecodes)
+	require.Equal(t, uint64(512), progress.HealingTrienodes)
+	require.Zero(t, progress.HealingBytecode)
+	require.InDelta(t, 100, progress.Percent, 0.001)
+	require.False(t, progress.HasEta)
+}
+
+// Test that a synced client reports it instead of failing
+func TestGetExecutionSyncProgress_Synced(t *testing.T) {
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetResult("eth_syncing", false)
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+	progress, err := sp.GetExecutionSyncProgress(context.Background())
+	require.NoError(t, err)
+	require.True(t, progress.Synced)
+	require.Zero(t, progress.HighestBlock)
+}
@@ -0,0 +1,271 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/osha/docker"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// A Docker mock that records the config, restart policy, and mounts of each container it's asked to create, and every volume
+// it's asked to remove or create. Waiting on a container returns its exit code from exitCodes, or 0.
+type recordingDockerClient struct {
+	*docker.DockerMockManager
+	created         map[string]*container.Config
+	restartPolicies map[string]container.RestartPolicy
+	mounts          map[string][]mount.Mount
+	volumes         map[string]volume.Volume
+	volumeEvents    []string
+	exitCodes       map[string]int64
+}
+
+func (d *recordingDockerClient) ContainerCreate(ctx context.Context, cfg *container.Config, hostCfg *container.HostConfig, networkCfg *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.CreateResponse, error) {
+	d.created[containerName] = cfg
+	if hostCfg != nil {
+		d.restartPolicies[containerName] = hostCfg.RestartPolicy
+		d.mounts[containerName] = hostCfg.Mounts
+	}
+	neverRun := time.Time{}.Format(time.RFC3339Nano)
+	err := d.Mock_AddContainer(types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         containerName,
+			Name:       containerName,
+			State:      &types.ContainerState{Status: "created", StartedAt: neverRun, FinishedAt: neverRun},
+			HostConfig: hostCfg,
+		},
+		Config:          cfg,
+		NetworkSettings: &types.NetworkSettings{},
+	})
+	if err != nil {
+		return container.CreateResponse{}, err
+	}
+	return container.CreateResponse{ID: containerName}, nil
+}
+
+func (d *recordingDockerClient) ContainerRename(ctx context.Context, containerID string, newContainerName string) error {
+	info, err := d.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	if _, err := d.ContainerInspect(ctx, newContainerName); err == nil {
+		return fmt.Errorf("Conflict. The container name %s is already in use", newContainerName)
+	}
+	err = d.ContainerRemove(ctx, containerID, container.RemoveOptions{})
+	if err != nil {
+		return err
+	}
+	info.ID = newContainerName
+	info.Name = newContainerName
+	d.created[newContainerName] = d.created[containerID]
+	delete(d.created, containerID)
+	return d.Mock_AddContainer(info)
+}
+
+func (d *recordingDockerClient) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	vol, exists := d.volumes[volumeID]
+	if !exists {
+		return volume.Volume{}, errdefs.NotFound(fmt.Errorf("No such volume: %s", volumeID))
+	}
+	return vol, nil
+}
+
+func (d *recordingDockerClient) ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
+	statusCh := make(chan container.WaitResponse, 1)
+	errCh := make(chan error, 1)
+	statusCh <- container.WaitResponse{StatusCode: d.exitCodes[containerID]}
+	return statusCh, errCh
+}
+
+func (d *recordingDockerClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	d.volumeEvents = append(d.volumeEvents, "remove "+volumeID)
+	if _, exists := d.volumes[volumeID]; !exists {
+		return fmt.Errorf("No such volume: %s", volumeID)
+	}
+	delete(d.volumes, volumeID)
+	return nil
+}
+
+func (d *recordingDockerClient) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	d.volumeEvents = append(d.volumeEvents, "create "+options.Name)
+	if _, exists := d.volumes[options.Name]; exists {
+		return volume.Volume{}, fmt.Errorf("volume %s already exists", options.Name)
+	}
+	vol := volume.Volume{
+		Name:    options.Name,
+		Driver:  options.Driver,
+		Options: options.DriverOpts,
+		Labels:  options.Labels,
+	}
+	d.volumes[options.Name] = vol
+	return vol, nil
+}
+
+// Adds a volume without recording it as an event
+func (d *recordingDockerClient) addVolume(vol volume.Volume) {
+	d.volumes[vol.Name] = vol
+}
+
+// Creates a recording Docker mock
+func newRecordingDockerClient() *recordingDockerClient {
+	return &recordingDockerClient{
+		DockerMockManager: docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil))),
+		created:           map[string]*container.Config{},
+		restartPolicies:   map[string]container.RestartPolicy{},
+		mounts:            map[string][]mount.Mount{},
+		volumes:           map[string]volume.Volume{},
+		exitCodes:         map[string]int64{},
+	}
+}
+
+// Test that extra environment variables are merged underneath the ones Hyperdrive manages
+func TestCreateContainer_ExtraEnv(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExtraEnv.BeaconNode = map[string]string{
+		"JAVA_OPTS": "-Xmx8g",
+		"FOO":       "a=b;c",
+		"NETWORK":   "override",
+	}
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+
+	managed := []string{"NETWORK=holesky", "BN_PORT=5052"}
+	_, err := sp.CreateContainer(context.Background(), config.ContainerID_BeaconNode, &container.Config{Env: managed}, nil, nil)
+	require.NoError(t, err)
+
+	created := mock.created[cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))]
+	require.NotNil(t, created)
+	require.Equal(t, []string{
+		"FOO=a=b;c",
+		"JAVA_OPTS=-Xmx8g",
+		"NETWORK=holesky",
+		"BN_PORT=5052",
+	}, created.Env)
+	require.Equal(t, "FOO,JAVA_OPTS", created.Labels[hdconfig.ExtraEnvLabel])
+	require.Equal(t, []string{"NETWORK=holesky", "BN_PORT=5052"}, managed)
+
+	// Containers without extra variables get the managed ones untouched
+	_, err = sp.CreateContainer(context.Background(), config.ContainerID_ExecutionClient, &container.Config{Env: managed}, nil, nil)
+	require.NoError(t, err)
+	created = mock.created[cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))]
+	require.Equal(t, managed, created.Env)
+	require.NotContains(t, created.Labels, hdconfig.ExtraEnvLabel)
+}
+
+// Test that recreating a container swaps its old extra variables for the current ones
+func TestRecreateContainer_ExtraEnv(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExtraEnv.BeaconNode = map[string]string{"JAVA_OPTS": "-Xmx8g", "OLD": "1"}
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	ctx := context.Background()
+	name := cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+
+	_, err := sp.CreateContainer(ctx, config.ContainerID_BeaconNode, &container.Config{Env: []string{"NETWORK=holesky"}}, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, sp.StartContainer(ctx, name))
+
+	// Change the settings and recreate it
+	cfg.ExtraEnv.BeaconNode = map[string]string{"JAVA_OPTS": "-Xmx16g"}
+	err = sp.RecreateContainer(ctx, config.ContainerID_BeaconNode)
+	require.NoError(t, err)
+	require.Equal(t, []string{"JAVA_OPTS=-Xmx16g", "NETWORK=holesky"}, mock.created[name].Env)
+	require.Equal(t, "JAVA_OPTS", mock.created[name].Labels[hdconfig.ExtraEnvLabel])
+
+	info, err := mock.ContainerInspect(ctx, name)
+	require.NoError(t, err)
+	require.True(t, info.State.Running)
+}
+
+// Test that the old container is put back if its replacement can't be created
+func TestRecreateContainer_RestoresOnCreateFailure(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	ctx := context.Background()
+	name := cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))
+
+	_, err := sp.CreateContainer(ctx, config.ContainerID_ExecutionClient, &container.Config{Env: []string{"NETWORK=holesky"}}, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, sp.StartContainer(ctx, name))
+
+	// Invalid extra variables make the new container fail to be created
+	cfg.ExtraEnv.ExecutionClient = map[string]string{"1BAD": "value"}
+	recreateErr := sp.RecreateContainer(ctx, config.ContainerID_ExecutionClient)
+	require.ErrorContains(t, recreateErr, "1BAD")
+
+	info, err := mock.ContainerInspect(ctx, name)
+	require.NoError(t, err)
+	require.True(t, info.State.Running)
+	require.Equal(t, []string{"NETWORK=holesky"}, info.Config.Env)
+	require.Len(t, mock.created, 1)
+	t.Logf("The old container was restored after: %s", recreateErr.Error())
+}
+
+// Test that malformed extra environment variables are rejected at save time
+func TestExtraEnv_Validate(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	require.Empty(t, cfg.Validate())
+
+	cfg.ExtraEnv.ExecutionClient = map[string]string{"1BAD": "value"}
+	cfg.ExtraEnv.BeaconNode = map[string]string{"GOOD": "1", "BAD-NAME": "2"}
+	cfg.ExtraEnv.MevBoost = map[string]string{"": "value"}
+	errs := cfg.Validate()
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0], "1BAD")
+	require.Contains(t, errs[1], "BAD-NAME")
+	require.Contains(t, errs[2], "[]")
+
+	// Saving the config fails without touching the file
+	path := filepath.Join(t.TempDir(), hdconfig.ConfigFilename)
+	_, err := cfg.SaveToFile(path, nil)
+	require.ErrorContains(t, err, "1BAD")
+	require.NoFileExists(t, path)
+
+	// Creating a container with invalid variables fails instead of silently dropping them
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	_, err = sp.CreateContainer(context.Background(), config.ContainerID_ExecutionClient, &container.Config{}, nil, nil)
+	require.Error(t, err)
+	require.Empty(t, mock.created)
+}
+
+// Test that extra environment variables are saved as a map per service and loaded back
+func TestExtraEnv_SaveAndLoad(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExtraEnv.BeaconNode = map[string]string{"JAVA_OPTS": "-Xmx8g -XX:+UseG1GC", "SEMI": "a;b"}
+	cfg.ExtraEnv.MevBoost = map[string]string{"RELAY_TIMEOUT": "5"}
+
+	path := filepath.Join(t.TempDir(), hdconfig.ConfigFilename)
+	warnings, err := cfg.SaveToFile(path, nil)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "JAVA_OPTS: -Xmx8g -XX:+UseG1GC")
+
+	loaded, err := hdconfig.LoadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, cfg.ExtraEnv.BeaconNode, loaded.ExtraEnv.BeaconNode)
+	require.Equal(t, cfg.ExtraEnv.MevBoost, loaded.ExtraEnv.MevBoost)
+	require.Empty(t, loaded.ExtraEnv.ExecutionClient)
+
+	// Clones get their own copies of the maps
+	clone := loaded.Clone()
+	clone.ExtraEnv.BeaconNode["JAVA_OPTS"] = "changed"
+	require.Equal(t, "-Xmx8g -XX:+UseG1GC", loaded.ExtraEnv.BeaconNode["JAVA_OPTS"])
+}
@@ -0,0 +1,127 @@
+package common_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that extra mounts are added after the managed ones and swapped out when the container is recreated
+func TestCreateContainer_ExtraMounts(t *testing.T) {
+	genesisDir := t.TempDir()
+	setupDir := t.TempDir()
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExtraMounts.BeaconNode = []hdconfig.MountSpec{
+		{HostPath: genesisDir, ContainerPath: "/genesis", ReadOnly: true},
+	}
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	ctx := context.Background()
+	name := cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	dataMount := mount.Mount{Type: mount.TypeVolume, Source: cfg.GetDockerArtifactName("bndata"), Target: "/ethclient"}
+	hostCfg := &container.HostConfig{Mounts: []mount.Mount{dataMount}}
+
+	_, err := sp.CreateContainer(ctx, config.ContainerID_BeaconNode, &container.Config{}, hostCfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, []mount.Mount{
+		dataMount,
+		{Type: mount.TypeBind, Source: genesisDir, Target: "/genesis", ReadOnly: true},
+	}, mock.mounts[name])
+	require.Equal(t, `["/genesis"]`, mock.created[name].Labels[hdconfig.ExtraMountsLabel])
+	require.Len(t, hostCfg.Mounts, 1, "the caller's host config shouldn't be modified")
+
+	// Change the mounts and recreate it
+	cfg.ExtraMounts.BeaconNode = []hdconfig.MountSpec{
+		{HostPath: setupDir, ContainerPath: "/trusted-setup"},
+	}
+	err = sp.RecreateContainer(ctx, config.ContainerID_BeaconNode)
+	require.NoError(t, err)
+	require.Equal(t, []mount.Mount{
+		dataMount,
+		{Type: mount.TypeBind, Source: setupDir, Target: "/trusted-setup"},
+	}, mock.mounts[name])
+
+	// Removing them should leave only the managed mounts
+	cfg.ExtraMounts.BeaconNode = []hdconfig.MountSpec{}
+	err = sp.RecreateContainer(ctx, config.ContainerID_BeaconNode)
+	require.NoError(t, err)
+	require.Equal(t, []mount.Mount{dataMount}, mock.mounts[name])
+	require.NotContains(t, mock.created[name].Labels, hdconfig.ExtraMountsLabel)
+}
+
+// Test that extra mounts can't shadow the container's managed mounts
+func TestCreateContainer_ExtraMountOverlap(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExtraMounts.ExecutionClient = []hdconfig.MountSpec{
+		{HostPath: t.TempDir(), ContainerPath: "/ethclient/genesis"},
+	}
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	hostCfg := &container.HostConfig{Binds: []string{"/srv/ec:/ethclient:rw"}}
+
+	_, err := sp.CreateContainer(context.Background(), config.ContainerID_ExecutionClient, &container.Config{}, hostCfg, nil)
+	require.ErrorContains(t, err, "/ethclient")
+	require.Empty(t, mock.created)
+}
+
+// Test that unusable extra mounts are rejected at save time
+func TestExtraMounts_Validate(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	require.NoError(t, os.MkdirAll(cfg.UserDataPath.Value, 0700))
+	hostDir := t.TempDir()
+	cfg.ExtraMounts.ExecutionClient = []hdconfig.MountSpec{
+		{HostPath: "relative/path", ContainerPath: "/a"},
+	}
+	cfg.ExtraMounts.BeaconNode = []hdconfig.MountSpec{
+		{HostPath: filepath.Join(hostDir, "missing"), ContainerPath: "/b"},
+	}
+	cfg.ExtraMounts.ValidatorClient = []hdconfig.MountSpec{
+		{HostPath: cfg.UserDataPath.Value, ContainerPath: "/c"},
+	}
+	cfg.ExtraMounts.MevBoost = []hdconfig.MountSpec{
+		{HostPath: hostDir, ContainerPath: "/d"},
+		{HostPath: hostDir, ContainerPath: "/d/inner"},
+	}
+	errs := cfg.Validate()
+	require.Len(t, errs, 4)
+	require.Contains(t, errs[0], "must be absolute")
+	require.Contains(t, errs[1], "can't be accessed")
+	require.Contains(t, errs[2], "managed path")
+	require.Contains(t, errs[3], "overlaps with extra mount 1")
+
+	// Saving the config fails without touching the file
+	path := filepath.Join(t.TempDir(), hdconfig.ConfigFilename)
+	_, err := cfg.SaveToFile(path, nil)
+	require.Error(t, err)
+	require.NoFileExists(t, path)
+}
+
+// Test that extra mounts are saved as a list per service and loaded back
+func TestExtraMounts_SaveAndLoad(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	hostDir := t.TempDir()
+	cfg.ExtraMounts.BeaconNode = []hdconfig.MountSpec{
+		{HostPath: hostDir, ContainerPath: "/genesis", ReadOnly: true},
+		{HostPath: hostDir, ContainerPath: "/other"},
+	}
+
+	path := filepath.Join(t.TempDir(), hdconfig.ConfigFilename)
+	_, err := cfg.SaveToFile(path, nil)
+	require.NoError(t, err)
+	loaded, err := hdconfig.LoadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, cfg.ExtraMounts.BeaconNode, loaded.ExtraMounts.BeaconNode)
+	require.Empty(t, loaded.ExtraMounts.ExecutionClient)
+
+	// Clones get their own copies of the lists
+	clone := loaded.Clone()
+	clone.ExtraMounts.BeaconNode[0].ReadOnly = false
+	require.True(t, loaded.ExtraMounts.BeaconNode[0].ReadOnly)
+}
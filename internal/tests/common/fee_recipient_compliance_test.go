@@ -0,0 +1,132 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// A smoothing pool address the fee recipient rules can require
+	testSmoothingPool = ethcommon.HexToAddress("0x15d34AAf54267DB7D7c367839AAf71A00a2C6A65")
+)
+
+// A fake fee recipient rule that expects a fixed fee recipient for each validator
+type mockFeeRecipientRule struct {
+	name     string
+	enabled  bool
+	expected map[beacon.ValidatorPubkey]ethcommon.Address
+}
+
+func (r *mockFeeRecipientRule) GetModuleName() string {
+	return r.name
+}
+
+func (r *mockFeeRecipientRule) IsEnabled() bool {
+	return r.enabled
+}
+
+func (r *mockFeeRecipientRule) GetExpectedFeeRecipients(ctx context.Context) (map[beacon.ValidatorPubkey]ethcommon.Address, error) {
+	return r.expected, nil
+}
+
+// Test checking a mix of compliant and non-compliant validators across more keys than fit in one batch, where one
+// validator isn't loaded in the VC and a disabled module is ignored
+func TestVerifyFeeRecipientCompliance(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	pubkeys := addFeeRecipientTestKeys(keyManager, 40)
+	smoothingPool := map[beacon.ValidatorPubkey]ethcommon.Address{}
+	for i, pubkey := range pubkeys[:35] {
+		smoothingPool[pubkey] = testSmoothingPool
+		keyManager.FeeRecipients[pubkey] = testSmoothingPool
+		if i%10 == 3 {
+			keyManager.FeeRecipients[pubkey] = testFeeRecipient
+		}
+	}
+	missing := beacon.ValidatorPubkey{0xff}
+	smoothingPool[missing] = testSmoothingPool
+	solo := map[beacon.ValidatorPubkey]ethcommon.Address{}
+	for _, pubkey := range pubkeys[35:] {
+		solo[pubkey] = testFeeRecipient
+		keyManager.FeeRecipients[pubkey] = testFeeRecipient
+	}
+	sp.RegisterFeeRecipientRule(&mockFeeRecipientRule{name: "rocketpool", enabled: true, expected: smoothingPool})
+	sp.RegisterFeeRecipientRule(&mockFeeRecipientRule{name: "solo", enabled: true, expected: solo})
+	sp.RegisterFeeRecipientRule(&mockFeeRecipientRule{
+		name:     "legacy",
+		enabled:  false,
+		expected: map[beacon.ValidatorPubkey]ethcommon.Address{pubkeys[0]: testFeeRecipient},
+	})
+
+	report, err := sp.VerifyFeeRecipientCompliance(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.Validators, 41)
+	require.Equal(t, 36, report.CompliantCount)
+	require.Equal(t, 4, report.NonCompliantCount)
+	require.Equal(t, 1, report.ErrorCount)
+	for i, check := range report.Validators {
+		if i > 0 && report.Validators[i-1].Module == check.Module {
+			require.Less(t, report.Validators[i-1].Pubkey.Hex(), check.Pubkey.Hex())
+		}
+		switch {
+		case check.Pubkey == missing:
+			require.Contains(t, check.Error, "404")
+		case check.Module == "rocketpool":
+			require.Equal(t, testSmoothingPool, check.Expected)
+			require.Equal(t, keyManager.FeeRecipients[check.Pubkey], check.Configured)
+			require.Equal(t, check.Configured == testSmoothingPool, check.Compliant)
+		default:
+			require.Equal(t, "solo", check.Module)
+			require.True(t, check.Compliant)
+		}
+	}
+	t.Logf("%d of %d validators were non-compliant", report.NonCompliantCount, len(report.Validators))
+}
+
+// Test that a violation is only alerted on once while it lasts, and again if it comes back after being fixed
+func TestCheckFeeRecipientCompliance_Alerts(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	sink := &mockAlertSink{}
+	sp.GetAlertManager().AddSink(sink, common.GetAlertSeveritiesFrom(hdconfig.AlertSeverity_Info)...)
+	pubkeys := addFeeRecipientTestKeys(keyManager, 3)
+	expected := map[beacon.ValidatorPubkey]ethcommon.Address{}
+	for _, pubkey := range pubkeys {
+		expected[pubkey] = testSmoothingPool
+		keyManager.FeeRecipients[pubkey] = testSmoothingPool
+	}
+	keyManager.FeeRecipients[pubkeys[1]] = testFeeRecipient
+	sp.RegisterFeeRecipientRule(&mockFeeRecipientRule{name: "rocketpool", enabled: true, expected: expected})
+
+	report, err := sp.CheckFeeRecipientCompliance(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, report.NonCompliantCount)
+	require.Len(t, sink.alerts, 1)
+	require.Equal(t, hdconfig.AlertSeverity_Critical, sink.alerts[0].Severity)
+	require.Contains(t, sink.alerts[0].Message, pubkeys[1].HexWithPrefix())
+	require.Contains(t, sink.alerts[0].Message, testFeeRecipient.Hex())
+
+	// The same violation isn't alerted on again
+	_, err = sp.CheckFeeRecipientCompliance(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sink.alerts, 1)
+
+	// Once it's fixed and broken again, it is
+	keyManager.FeeRecipients[pubkeys[1]] = testSmoothingPool
+	report, err = sp.CheckFeeRecipientCompliance(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, report.NonCompliantCount)
+	require.Len(t, sink.alerts, 1)
+	keyManager.FeeRecipients[pubkeys[1]] = testFeeRecipient
+	keyManager.FeeRecipients[pubkeys[2]] = testFeeRecipient
+	_, err = sp.CheckFeeRecipientCompliance(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sink.alerts, 2)
+	require.Equal(t, "2 validators have the wrong fee recipient", sink.alerts[1].Title)
+}
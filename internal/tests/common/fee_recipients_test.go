@@ -0,0 +1,99 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// The fee recipient used by the tests
+	testFeeRecipient = ethcommon.HexToAddress("0x90F79bf6EB2c4f870365E785982E1f101E93b906")
+)
+
+// Test updating the fee recipients of more keys than fit in one batch, where some updates fail or don't stick
+func TestSetFeeRecipients(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	pubkeys := addFeeRecipientTestKeys(keyManager, 40)
+	ignored := pubkeys[7]
+	keyManager.IgnoredFeeRecipients[ignored] = true
+
+	// One of the keys isn't loaded into the VC
+	unknown := beacon.ValidatorPubkey{0xff}
+	recipients := map[beacon.ValidatorPubkey]ethcommon.Address{
+		unknown: testFeeRecipient,
+	}
+	for _, pubkey := range pubkeys {
+		recipients[pubkey] = testFeeRecipient
+	}
+
+	result, err := sp.SetFeeRecipients(context.Background(), recipients, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Updates, len(recipients))
+	require.Equal(t, len(pubkeys)-1, result.SucceededCount)
+	require.Equal(t, 2, result.FailedCount)
+	for i, update := range result.Updates {
+		if i > 0 {
+			require.Less(t, result.Updates[i-1].Pubkey.Hex(), update.Pubkey.Hex())
+		}
+		switch update.Pubkey {
+		case ignored:
+			require.False(t, update.Success)
+			require.Contains(t, update.Error, "instead")
+		case unknown:
+			require.False(t, update.Success)
+			require.Contains(t, update.Error, "404")
+		default:
+			require.True(t, update.Success, update.Error)
+			require.Equal(t, testFeeRecipient, keyManager.FeeRecipients[update.Pubkey])
+		}
+	}
+}
+
+// Test that an invalid address stops every update from being sent
+func TestSetFeeRecipients_ZeroAddress(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	pubkeys := addFeeRecipientTestKeys(keyManager, 3)
+
+	_, err := sp.SetFeeRecipients(context.Background(), map[beacon.ValidatorPubkey]ethcommon.Address{
+		pubkeys[0]: testFeeRecipient,
+		pubkeys[1]: {},
+		pubkeys[2]: testFeeRecipient,
+	}, nil)
+	require.ErrorIs(t, err, common.ErrInvalidFeeRecipient)
+	require.Empty(t, keyManager.FeeRecipients)
+}
+
+// Test that fee recipients have to be checksummed
+func TestParseFeeRecipient(t *testing.T) {
+	address, err := common.ParseFeeRecipient(testFeeRecipient.Hex())
+	require.NoError(t, err)
+	require.Equal(t, testFeeRecipient, address)
+
+	for _, invalid := range []string{
+		"0x90f79bf6eb2c4f870365e785982e1f101e93b906",
+		"0x90F79bf6EB2c4f870365E785982E1f101E93b907",
+		"0x90F79bf6EB2c4f870365E785982E1f101E93b9",
+		"90F79bf6EB2c4f870365E785982E1f101E93b906",
+	} {
+		_, err = common.ParseFeeRecipient(invalid)
+		require.ErrorIs(t, err, common.ErrInvalidFeeRecipient, invalid)
+	}
+}
+
+// Loads fake keys into the mock key manager
+func addFeeRecipientTestKeys(keyManager *mockKeyManager, count int) []beacon.ValidatorPubkey {
+	pubkeys := []beacon.ValidatorPubkey{}
+	for i := 0; i < count; i++ {
+		pubkey := beacon.ValidatorPubkey{byte(i + 1)}
+		keyManager.Keystores[pubkey] = "{}"
+		pubkeys = append(pubkeys, pubkey)
+	}
+	return pubkeys
+}
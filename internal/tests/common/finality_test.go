@@ -0,0 +1,56 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test reading the finality checkpoints and detecting stalled finality against the head epoch
+func TestGetFinalityCheckpoints(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.SetFinalizedEpoch(8)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	ctx := context.Background()
+
+	// The mock's head slot is 320, so the head is in epoch 10 and finality is a healthy 2 epochs behind
+	info, err := sp.GetFinalityCheckpoints(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), info.HeadEpoch)
+	require.Equal(t, uint64(8), info.Finalized.Epoch)
+	require.Equal(t, uint64(9), info.CurrentJustified.Epoch)
+	require.Equal(t, uint64(8), info.PreviousJustified.Epoch)
+	require.Equal(t, byte(0xcc), info.Finalized.Root[0])
+	require.Equal(t, byte(8), info.Finalized.Root[31])
+	require.Equal(t, uint64(2), info.GetEpochsSinceFinality())
+	require.False(t, info.IsStalled())
+	t.Logf("Finalized epoch %d, justified epoch %d, head epoch %d", info.Finalized.Epoch, info.CurrentJustified.Epoch, info.HeadEpoch)
+
+	// Right at the threshold is still fine
+	bn.SetFinalizedEpoch(10 - common.FinalityStallThreshold)
+	info, err = sp.GetFinalityCheckpoints(ctx)
+	require.NoError(t, err)
+	require.False(t, info.IsStalled())
+
+	// Past it, finality has stalled
+	bn.SetFinalizedEpoch(10 - common.FinalityStallThreshold - 1)
+	info, err = sp.GetFinalityCheckpoints(ctx)
+	require.NoError(t, err)
+	require.True(t, info.IsStalled())
+	t.Logf("Finality was reported as stalled %d epochs behind the head", info.GetEpochsSinceFinality())
+}
+
+// Test that a missing head state is reported instead of returning empty checkpoints
+func TestGetFinalityCheckpoints_Missing(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Handle("/eth/v1/beacon/states/head/finality_checkpoints", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusNotFound, map[string]any{"code": 404, "message": "state not found"})
+	})
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	_, err := sp.GetFinalityCheckpoints(context.Background())
+	require.ErrorContains(t, err, "finality checkpoints")
+}
@@ -0,0 +1,114 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the gas price history from eth_feeHistory
+func TestGetGasPriceHistory(t *testing.T) {
+	ec := newMockExecutionClient(t, 1)
+	ec.Handlers["eth_feeHistory"] = func(params []json.RawMessage) (any, error) {
+		require.JSONEq(t, `"0x3"`, string(params[0]))
+		require.JSONEq(t, `"latest"`, string(params[1]))
+		require.JSONEq(t, `[10, 50, 90]`, string(params[2]))
+		return map[string]any{
+			"oldestBlock":   "0x64",
+			"baseFeePerGas": []string{"0x3b9aca00", "0x77359400", "0xb2d05e00", "0xee6b2800"},
+			"gasUsedRatio":  []float64{0.5, 1, 0.25},
+			"reward":        [][]string{{"0x1", "0x2", "0x3"}, {"0x4", "0x5", "0x6"}, {"0x0", "0x0", "0x0"}},
+		}, nil
+	}
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+	history, err := sp.GetGasPriceHistory(context.Background(), 3)
+	require.NoError(t, err)
+	require.True(t, history.Eip1559)
+	require.Equal(t, []float64{10, 50, 90}, history.RewardPercentiles)
+	require.Len(t, history.Blocks, 3)
+	require.Equal(t, uint64(100), history.Blocks[0].Number)
+	require.Equal(t, uint64(102), history.Blocks[2].Number)
+	require.Equal(t, big.NewInt(2e9), history.Blocks[1].BaseFee)
+	require.Equal(t, 0.25, history.Blocks[2].GasUsedRatio)
+	require.Equal(t, []*big.Int{big.NewInt(4), big.NewInt(5), big.NewInt(6)}, history.Blocks[1].PriorityFees)
+	require.Nil(t, history.Blocks[0].GasPrice)
+	require.Equal(t, big.NewInt(4e9), history.NextBaseFee)
+
+	// The next block's base fee isn't part of the average
+	require.Equal(t, big.NewInt(2e9), history.AverageBaseFee)
+	require.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}, history.AveragePriorityFees)
+	require.Equal(t, big.NewInt(2e9+2), history.AverageGasPrice)
+
+	// It can be charted straight from JSON
+	bytes, err := json.Marshal(history)
+	require.NoError(t, err)
+	var decoded common.GasHistory
+	require.NoError(t, json.Unmarshal(bytes, &decoded))
+	roundTrip, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	require.JSONEq(t, string(bytes), string(roundTrip))
+
+	// Out of range block counts are rejected
+	_, err = sp.GetGasPriceHistory(context.Background(), 0)
+	require.Error(t, err)
+	_, err = sp.GetGasPriceHistory(context.Background(), common.MaxGasHistoryBlocks+1)
+	require.Error(t, err)
+}
+
+// Test that networks without EIP-1559 fall back to eth_gasPrice
+func TestGetGasPriceHistory_Legacy(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		feeHistory func(params []json.RawMessage) (any, error)
+	}{{
+		name: "unsupported",
+		feeHistory: func(params []json.RawMessage) (any, error) {
+			return nil, &mockRpcError{Code: -32601, Message: "the method eth_feeHistory does not exist/is not available"}
+		},
+	}, {
+		name: "no base fees",
+		feeHistory: func(params []json.RawMessage) (any, error) {
+			return map[string]any{
+				"oldestBlock":   "0x64",
+				"baseFeePerGas": []string{"0x0", "0x0", "0x0"},
+				"gasUsedRatio":  []float64{0.5, 0.5},
+			}, nil
+		},
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			ec := newMockExecutionClient(t, 1)
+			ec.Handlers["eth_feeHistory"] = test.feeHistory
+			ec.SetResult("eth_blockNumber", hexutil.Uint64(101))
+			ec.SetResult("eth_gasPrice", (*hexutil.Big)(big.NewInt(25e9)))
+			sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+
+			history, err := sp.GetGasPriceHistory(context.Background(), 2)
+			require.NoError(t, err)
+			require.False(t, history.Eip1559)
+			require.Len(t, history.Blocks, 1)
+			require.Equal(t, uint64(101), history.Blocks[0].Number)
+			require.Equal(t, big.NewInt(25e9), history.Blocks[0].GasPrice)
+			require.Nil(t, history.Blocks[0].BaseFee)
+			require.Nil(t, history.AverageBaseFee)
+			require.Equal(t, big.NewInt(25e9), history.AverageGasPrice)
+			_, err = json.Marshal(history)
+			require.NoError(t, err)
+		})
+	}
+
+	// Other errors aren't treated as a legacy network
+	ec := newMockExecutionClient(t, 1)
+	ec.Handlers["eth_feeHistory"] = func(params []json.RawMessage) (any, error) {
+		return nil, &mockRpcError{Code: -32000, Message: "request beyond head block"}
+	}
+	ec.SetResult("eth_gasPrice", (*hexutil.Big)(big.NewInt(25e9)))
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+	_, err := sp.GetGasPriceHistory(context.Background(), 2)
+	require.ErrorContains(t, err, "beyond head block")
+}
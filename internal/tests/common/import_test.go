@@ -0,0 +1,120 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+// Test importing a directory with new, duplicate, and invalid keystores into the VC
+func TestImportKeystores(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+
+	// Make the directory
+	dir := t.TempDir()
+	first := writeTestKeystore(t, dir, "a-first.json", keystorePassword, nil)
+	second := writeTestKeystore(t, dir, "b-second.json", keystorePassword, nil)
+	existing := writeTestKeystore(t, dir, "c-existing.json", keystorePassword, nil)
+	wrongPassword := writeTestKeystore(t, dir, "d-wrong-password.json", "another-password", nil)
+	corrupt := filepath.Join(dir, "e-corrupt.json")
+	err := os.WriteFile(corrupt, []byte("{{{"), 0600)
+	require.NoError(t, err)
+	firstPubkey := readTestKeystorePubkey(t, first)
+	slashingProtection := fmt.Sprintf(`{
+		"metadata": {"interchange_format_version": "5", "genesis_validators_root": "%s"},
+		"data": [{
+			"pubkey": "%s",
+			"signed_blocks": [{"slot": "81952"}],
+			"signed_attestations": [{"source_epoch": "2290", "target_epoch": "3007"}]
+		}]
+	}`, testGenesisValidatorsRoot, firstPubkey.HexWithPrefix())
+	err = os.WriteFile(filepath.Join(dir, hdconfig.SlashingProtectionFilename), []byte(slashingProtection), 0600)
+	require.NoError(t, err)
+
+	// Preload one of the keys into the VC
+	existingPubkey := readTestKeystorePubkey(t, existing)
+	keyManager.Keystores[existingPubkey] = "{}"
+
+	result, err := sp.ImportKeystores(context.Background(), dir, keystorePassword, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Keystores, 5)
+	require.Equal(t, 2, result.ImportedCount)
+	require.Equal(t, 1, result.SkippedCount)
+	require.Equal(t, 2, result.FailedCount)
+	require.True(t, result.ImportedSlashingProtection)
+
+	require.Equal(t, first, result.Keystores[0].Path)
+	require.Equal(t, common.KeystoreImportStatus_Imported, result.Keystores[0].Status)
+	require.Equal(t, second, result.Keystores[1].Path)
+	require.Equal(t, common.KeystoreImportStatus_Imported, result.Keystores[1].Status)
+	require.Equal(t, common.KeystoreImportStatus_Skipped, result.Keystores[2].Status)
+	require.Equal(t, existingPubkey, result.Keystores[2].Pubkey)
+	require.Equal(t, wrongPassword, result.Keystores[3].Path)
+	require.Equal(t, common.KeystoreImportStatus_Failed, result.Keystores[3].Status)
+	require.Equal(t, common.KeystoreFailureReason_WrongPassword, result.Keystores[3].FailureReason)
+	require.Equal(t, corrupt, result.Keystores[4].Path)
+	require.Equal(t, common.KeystoreFailureReason_CorruptJson, result.Keystores[4].FailureReason)
+
+	// Make sure the VC got the right data
+	require.Len(t, keyManager.Keystores, 3)
+	require.Contains(t, keyManager.Keystores, firstPubkey)
+	require.Contains(t, keyManager.Keystores, readTestKeystorePubkey(t, second))
+	var sentProtection common.SlashingProtectionInterchange
+	err = json.Unmarshal([]byte(keyManager.SlashingProtection), &sentProtection)
+	require.NoError(t, err)
+	require.Equal(t, testGenesisValidatorsRoot, sentProtection.Metadata.GenesisValidatorsRoot)
+	require.Len(t, sentProtection.Data, 1)
+	require.Equal(t, firstPubkey, sentProtection.Data[0].Pubkey)
+	require.Equal(t, client.Uinteger(81952), sentProtection.Data[0].SignedBlocks[0].Slot)
+	t.Log("Imported the valid keystores and skipped the rest")
+
+	// Importing again should skip everything that was imported
+	result, err = sp.ImportKeystores(context.Background(), dir, keystorePassword, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ImportedCount)
+	require.Equal(t, 3, result.SkippedCount)
+	require.Equal(t, 2, result.FailedCount)
+}
+
+// Test that importing fails cleanly when the key manager isn't configured
+func TestImportKeystores_NotConfigured(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	dir := t.TempDir()
+	writeTestKeystore(t, dir, "keystore.json", keystorePassword, nil)
+
+	_, err := sp.ImportKeystores(context.Background(), dir, keystorePassword, nil)
+	require.ErrorIs(t, err, common.ErrKeyManagerNotConfigured)
+}
+
+// Creates a service provider that uses the provided mock key manager
+func newKeyManagerTestServiceProvider(t *testing.T, keyManager *mockKeyManager) *common.ServiceProvider {
+	bn := newMockBeaconNode(t)
+	cfg := newTestConfig(t, bn.URL, "")
+	tokenPath := filepath.Join(t.TempDir(), "api-token.txt")
+	err := os.WriteFile(tokenPath, []byte(keyManager.token+"\n"), 0600)
+	require.NoError(t, err)
+	cfg.KeyManager.Url.Value = keyManager.URL
+	cfg.KeyManager.TokenPath.Value = tokenPath
+	return newTestServiceProviderFromConfig(t, cfg)
+}
+
+// Reads the pubkey from a keystore file
+func readTestKeystorePubkey(t *testing.T, path string) beacon.ValidatorPubkey {
+	bytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var keystore beacon.ValidatorKeystore
+	err = json.Unmarshal(bytes, &keystore)
+	require.NoError(t, err)
+	return keystore.Pubkey
+}
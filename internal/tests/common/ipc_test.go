@@ -0,0 +1,111 @@
+package common_test
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// A bare-bones stand-in for an Execution Client's eth namespace
+type ipcStubService struct {
+	chainId     *big.Int
+	blockNumber uint64
+}
+
+func (s *ipcStubService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.chainId)
+}
+
+func (s *ipcStubService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.blockNumber)
+}
+
+// Test connecting to the EC over IPC while the BN still uses HTTP
+func TestExecutionClientIpc(t *testing.T) {
+	socketPath := startIpcStub(t, &ipcStubService{
+		chainId:     big.NewInt(17000),
+		blockNumber: 1234,
+	})
+
+	bn := newMockBeaconNode(t)
+	cfg := newTestConfig(t, bn.URL, socketPath)
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	ctx := context.Background()
+	chainId, err := sp.GetEthClient().ChainID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(17000), chainId.Int64())
+	blockNumber, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1234), blockNumber)
+	t.Logf("Connected to the EC over IPC at %s", socketPath)
+}
+
+// Test that the IPC path is checked when the service provider starts
+func TestExecutionClientIpc_InvalidSocket(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	dir := t.TempDir()
+
+	// Missing socket
+	cfg := newTestConfig(t, bn.URL, "")
+	cfg.ExternalExecutionClient.HttpUrl.Value = filepath.Join(dir, "missing.ipc")
+	_, err := common.NewServiceProviderFromConfig(cfg)
+	require.ErrorContains(t, err, "does not exist")
+
+	// Regular file instead of a socket
+	filePath := filepath.Join(dir, "file.ipc")
+	err = os.WriteFile(filePath, []byte{}, 0600)
+	require.NoError(t, err)
+	cfg.ExternalExecutionClient.HttpUrl.Value = filePath
+	_, err = common.NewServiceProviderFromConfig(cfg)
+	require.ErrorContains(t, err, "is not a socket")
+	t.Log("Invalid IPC paths were rejected")
+}
+
+// Test detecting IPC paths in the EC endpoints and rejecting relative ones
+func TestExecutionClientIpc_Validation(t *testing.T) {
+	require.True(t, hdconfig.IsIpcEndpoint("/var/run/geth.ipc"))
+	require.True(t, hdconfig.IsIpcEndpoint("./geth.ipc"))
+	require.False(t, hdconfig.IsIpcEndpoint("http://localhost:8545"))
+	require.False(t, hdconfig.IsIpcEndpoint("ws://localhost:8546"))
+	require.False(t, hdconfig.IsIpcEndpoint(""))
+
+	cfg := newTestConfig(t, "http://localhost:5052", "/var/run/geth.ipc")
+	require.Empty(t, cfg.Validate())
+	cfg.ExternalExecutionClient.HttpUrl.Value = "./geth.ipc"
+	require.Len(t, cfg.Validate(), 1)
+
+	// The local EC is always reached over HTTP, so the unused external URL isn't checked
+	cfg.ClientMode.Value = config.ClientMode_Local
+	require.Empty(t, cfg.Validate())
+	t.Log("IPC endpoints were validated")
+}
+
+// Starts an RPC server for the stub service on a Unix socket, returning the socket's path
+func startIpcStub(t *testing.T, service *ipcStubService) string {
+	server := rpc.NewServer()
+	err := server.RegisterName("eth", service)
+	require.NoError(t, err)
+
+	socketPath := filepath.Join(t.TempDir(), "ec.ipc")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	go func() {
+		_ = server.ServeListener(listener)
+	}()
+	t.Cleanup(func() {
+		server.Stop()
+		listener.Close()
+	})
+	return socketPath
+}
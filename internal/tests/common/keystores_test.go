@@ -0,0 +1,139 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	"github.com/stretchr/testify/require"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+const (
+	keystorePassword string = "test-password-1234"
+)
+
+// Test verifying a directory with good, corrupted, wrong-password, and unsupported keystores
+func TestVerifyKeystores(t *testing.T) {
+	dir := t.TempDir()
+	good := writeTestKeystore(t, dir, "a-good.json", keystorePassword, nil)
+	wrongPassword := writeTestKeystore(t, dir, "b-wrong-password.json", "some-other-password", nil)
+	unsupportedKdf := writeTestKeystore(t, dir, "c-unsupported-kdf.json", keystorePassword, func(ks *beacon.ValidatorKeystore) {
+		ks.Crypto["kdf"].(map[string]any)["function"] = "argon2"
+	})
+	corrupt := filepath.Join(dir, "d-corrupt.json")
+	err := os.WriteFile(corrupt, []byte(`{"crypto": {"kdf": `), 0600)
+	require.NoError(t, err)
+
+	// Files that aren't keystores should be ignored
+	err = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a keystore"), 0600)
+	require.NoError(t, err)
+
+	result, err := common.VerifyKeystoresInDir(context.Background(), dir, keystorePassword)
+	require.NoError(t, err)
+	require.Len(t, result.Keystores, 4)
+	require.Equal(t, 1, result.SuccessCount)
+	require.Equal(t, 3, result.FailureCount)
+
+	require.Equal(t, good, result.Keystores[0].Path)
+	require.True(t, result.Keystores[0].Success)
+	require.Equal(t, common.KeystoreFailureReason_None, result.Keystores[0].FailureReason)
+
+	require.Equal(t, wrongPassword, result.Keystores[1].Path)
+	require.False(t, result.Keystores[1].Success)
+	require.Equal(t, common.KeystoreFailureReason_WrongPassword, result.Keystores[1].FailureReason)
+
+	require.Equal(t, unsupportedKdf, result.Keystores[2].Path)
+	require.Equal(t, common.KeystoreFailureReason_UnsupportedKdf, result.Keystores[2].FailureReason)
+
+	require.Equal(t, corrupt, result.Keystores[3].Path)
+	require.Equal(t, common.KeystoreFailureReason_CorruptJson, result.Keystores[3].FailureReason)
+	require.NotEmpty(t, result.Keystores[3].Error)
+	t.Log("Keystore verification reported the correct result for each keystore")
+}
+
+// Test verifying a keystore directory that doesn't exist yet
+func TestVerifyKeystores_NoDirectory(t *testing.T) {
+	result, err := common.VerifyKeystoresInDir(context.Background(), filepath.Join(t.TempDir(), "missing"), keystorePassword)
+	require.NoError(t, err)
+	require.Empty(t, result.Keystores)
+}
+
+// Test validating single keystore files, with a distinct error for each kind of failure
+func TestValidateKeystoreFile(t *testing.T) {
+	marshal := func(ks beacon.ValidatorKeystore) []byte {
+		bytes, err := json.Marshal(ks)
+		require.NoError(t, err)
+		return bytes
+	}
+	good := createTestKeystore(t, keystorePassword)
+	pubkey, err := common.ValidateKeystoreFile(marshal(good), keystorePassword)
+	require.NoError(t, err)
+	require.Equal(t, good.Pubkey, pubkey)
+
+	unsupportedKdf := createTestKeystore(t, keystorePassword)
+	unsupportedKdf.Crypto["kdf"].(map[string]any)["function"] = "argon2"
+	wrongVersion := createTestKeystore(t, keystorePassword)
+	wrongVersion.Version = 3
+	mismatched := createTestKeystore(t, keystorePassword)
+	mismatched.Pubkey = good.Pubkey
+
+	tests := []struct {
+		name     string
+		data     []byte
+		password string
+		err      error
+	}{
+		{name: "malformed JSON", data: []byte(`{"crypto": {"kdf": `), password: keystorePassword, err: common.ErrKeystoreMalformed},
+		{name: "wrong version", data: marshal(wrongVersion), password: keystorePassword, err: common.ErrKeystoreMalformed},
+		{name: "unsupported KDF", data: marshal(unsupportedKdf), password: keystorePassword, err: common.ErrKeystoreUnsupportedKdf},
+		{name: "wrong password", data: marshal(good), password: "some-other-password", err: common.ErrKeystoreWrongPassword},
+		{name: "pubkey mismatch", data: marshal(mismatched), password: keystorePassword, err: common.ErrKeystorePubkeyMismatch},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := common.ValidateKeystoreFile(test.data, test.password)
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+// Creates a new random validator key and writes its keystore to disk, optionally modifying it first
+func writeTestKeystore(t *testing.T, dir string, name string, password string, modify func(*beacon.ValidatorKeystore)) string {
+	ks := createTestKeystore(t, password)
+	if modify != nil {
+		modify(&ks)
+	}
+	bytes, err := json.Marshal(ks)
+	require.NoError(t, err)
+	path := filepath.Join(dir, name)
+	err = os.WriteFile(path, bytes, 0600)
+	require.NoError(t, err)
+	return path
+}
+
+// Creates a keystore for a new random validator key
+func createTestKeystore(t *testing.T, password string) beacon.ValidatorKeystore {
+	err := validator.InitializeBls()
+	require.NoError(t, err)
+	key, err := eth2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+
+	encryptor := eth2ks.New(eth2ks.WithCipher("pbkdf2"))
+	crypto, err := encryptor.Encrypt(key.Marshal(), password)
+	require.NoError(t, err)
+	return beacon.ValidatorKeystore{
+		Crypto:  crypto,
+		Version: encryptor.Version(),
+		UUID:    uuid.New(),
+		Path:    "m/12381/3600/0/0/0",
+		Pubkey:  beacon.ValidatorPubkey(key.PublicKey().Marshal()),
+	}
+}
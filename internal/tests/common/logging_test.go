@@ -0,0 +1,138 @@
+package common_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rocket-pool/node-manager-core/log"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the daemon's log files are rotated once they pass the configured size, without losing or tearing any lines
+// written concurrently
+func TestLogger_Rotation(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.Logging.MaxSize.Value = 1
+	cfg.Logging.MaxBackups.Value = 0
+	cfg.Logging.Compress.Value = false
+	logDir := t.TempDir()
+	logger, err := log.NewLogger(filepath.Join(logDir, "api.log"), cfg.GetLoggerOptions())
+	require.NoError(t, err)
+
+	// Write about 3 MB from several goroutines
+	const writers = 8
+	const linesPerWriter = 2000
+	padding := strings.Repeat("x", 150)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for j := 0; j < linesPerWriter; j++ {
+				logger.Info("rotation test", "writer", writer, "line", j, "padding", padding)
+			}
+		}(i)
+	}
+	wg.Wait()
+	logger.Close()
+
+	// Every line should be intact in exactly one file, and none of the files should be over the limit
+	entries, err := os.ReadDir(logDir)
+	require.NoError(t, err)
+	require.Greater(t, len(entries), 2, "the log should have been rotated at least twice")
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		require.NoError(t, err)
+		require.LessOrEqual(t, info.Size(), int64(1024*1024), "%s is over the max size", entry.Name())
+
+		file, err := os.Open(filepath.Join(logDir, entry.Name()))
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.Contains(line, "rotation test") {
+				continue
+			}
+			require.True(t, strings.HasSuffix(line, "padding="+padding), "torn line in %s: %s", entry.Name(), line)
+			seen[line[strings.Index(line, "writer="):]] = true
+		}
+		require.NoError(t, scanner.Err())
+		file.Close()
+	}
+	require.Len(t, seen, writers*linesPerWriter)
+	for i := 0; i < writers; i++ {
+		require.True(t, seen[fmt.Sprintf("writer=%d line=%d padding=%s", i, linesPerWriter-1, padding)])
+	}
+}
+
+// Test that old archives are cleaned up to the configured count
+func TestLogger_RotationLimits(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.Logging.MaxSize.Value = 1
+	cfg.Logging.MaxBackups.Value = 1
+	cfg.Logging.Compress.Value = false
+	logDir := t.TempDir()
+	logger, err := log.NewLogger(filepath.Join(logDir, "tasks.log"), cfg.GetLoggerOptions())
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		logger.Info("before rotation", "round", i)
+		require.NoError(t, logger.Rotate())
+	}
+	logger.Close()
+
+	// Cleanup runs in the background after each rotation
+	require.Eventually(t, func() bool {
+		entries, err := os.ReadDir(logDir)
+		return err == nil && len(entries) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// Test that archives older than the configured age are cleaned up, even when the backup count allows keeping them
+func TestLogger_RotationMaxAge(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.Logging.MaxAge.Value = 1
+	cfg.Logging.MaxBackups.Value = 0
+	cfg.Logging.Compress.Value = false
+	cfg.Logging.LocalTime.Value = false
+	logDir := t.TempDir()
+
+	// Archives are dated by the timestamp in their name
+	oldArchive := filepath.Join(logDir, "tasks-"+time.Now().UTC().AddDate(0, 0, -2).Format("2006-01-02T15-04-05.000")+".log")
+	require.NoError(t, os.WriteFile(oldArchive, []byte("old\n"), 0644))
+	logger, err := log.NewLogger(filepath.Join(logDir, "tasks.log"), cfg.GetLoggerOptions())
+	require.NoError(t, err)
+	logger.Info("before rotation")
+	require.NoError(t, logger.Rotate())
+	logger.Close()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(oldArchive)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	entries, err := os.ReadDir(logDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "the current log and the new archive should be kept")
+}
+
+// Test that the rotation settings are ignored when logging to stdout only, since there's no file to rotate
+func TestLogger_StdoutIgnoresRotation(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.Logging.MaxSize.Value = 1
+	cfg.Logging.MaxAge.Value = 1
+	cfg.Logging.MaxBackups.Value = 1
+	cfg.Logging.Compress.Value = true
+	require.Equal(t, 1, cfg.GetLoggerOptions().MaxSize)
+
+	terminalLogger := log.NewDefaultLogger()
+	terminalLogger.Info("stdout only")
+	require.NoError(t, terminalLogger.Rotate())
+	require.Empty(t, terminalLogger.GetFilePath())
+	terminalLogger.Close()
+}
@@ -0,0 +1,130 @@
+package common_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/osha/docker"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// A Docker mock whose container list honors label filters, which the osha mock ignores
+type labeledDockerClient struct {
+	*docker.DockerMockManager
+}
+
+func (d *labeledDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]dtypes.Container, error) {
+	containers, err := d.DockerMockManager.ContainerList(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return filterContainersByLabel(containers, options), nil
+}
+
+// Gets the listed containers that match the label filters in the list options
+func filterContainersByLabel(containers []dtypes.Container, options container.ListOptions) []dtypes.Container {
+	filtered := []dtypes.Container{}
+	for _, listed := range containers {
+		matches := true
+		for _, label := range options.Filters.Get("label") {
+			key, value, hasValue := strings.Cut(label, "=")
+			actual, exists := listed.Labels[key]
+			if !exists || (hasValue && actual != value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, listed)
+		}
+	}
+	return filtered
+}
+
+// Adds a container with the provided instance label to the mock
+func (d *labeledDockerClient) addContainer(t *testing.T, name string, project string, state *dtypes.ContainerState) {
+	labels := map[string]string{}
+	if project != "" {
+		labels[hdconfig.InstanceLabel] = project
+	}
+	var size int64
+	err := d.Mock_AddContainer(dtypes.ContainerJSON{
+		ContainerJSONBase: &dtypes.ContainerJSONBase{
+			ID:         name,
+			Name:       name,
+			Created:    time.Now().Format(time.RFC3339),
+			State:      state,
+			HostConfig: &container.HostConfig{},
+			SizeRw:     &size,
+			SizeRootFs: &size,
+		},
+		Config:          &container.Config{Image: "image/" + name, Labels: labels},
+		NetworkSettings: &dtypes.NetworkSettings{},
+	})
+	require.NoError(t, err)
+}
+
+// Test that only the containers labeled with this instance's project are listed, with their status
+func TestListManagedContainers(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ProjectName.Value = "hd-a"
+	mock := &labeledDockerClient{
+		DockerMockManager: docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil))),
+	}
+	startedAt := time.Now().Add(-time.Hour)
+	never := time.Time{}.Format(time.RFC3339Nano)
+	mock.addContainer(t, "hd-a_vc", "hd-a", &dtypes.ContainerState{
+		Status:     "running",
+		Running:    true,
+		StartedAt:  startedAt.Format(time.RFC3339Nano),
+		FinishedAt: never,
+		Health:     &dtypes.Health{Status: "healthy"},
+	})
+	mock.addContainer(t, "hd-a_eth1", "hd-a", &dtypes.ContainerState{
+		Status:     "exited",
+		ExitCode:   1,
+		StartedAt:  startedAt.Format(time.RFC3339Nano),
+		FinishedAt: time.Now().Format(time.RFC3339Nano),
+	})
+	mock.addContainer(t, "hd-b_vc", "hd-b", &dtypes.ContainerState{Status: "running", Running: true, StartedAt: never, FinishedAt: never})
+	mock.addContainer(t, "unrelated", "", &dtypes.ContainerState{Status: "running", Running: true, StartedAt: never, FinishedAt: never})
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+
+	statuses, err := sp.ListManagedContainers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+
+	require.Equal(t, "hd-a_eth1", statuses[0].Name)
+	require.Equal(t, "image/hd-a_eth1", statuses[0].Image)
+	require.Equal(t, "exited", statuses[0].State)
+	require.Empty(t, statuses[0].Health)
+	require.Zero(t, statuses[0].Uptime)
+
+	require.Equal(t, "hd-a_vc", statuses[1].Name)
+	require.Equal(t, "running", statuses[1].State)
+	require.Equal(t, "healthy", statuses[1].Health)
+	require.GreaterOrEqual(t, statuses[1].Uptime, time.Hour)
+	require.Less(t, statuses[1].Uptime, time.Hour+time.Minute)
+}
+
+// Test that created containers are labeled with the instance's project
+func TestCreateContainer_InstanceLabel(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ProjectName.Value = "hd-a"
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+
+	_, err := sp.CreateContainer(context.Background(), config.ContainerID_BeaconNode, &container.Config{}, nil, nil)
+	require.NoError(t, err)
+	created := mock.created[cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))]
+	require.Equal(t, "hd-a", created.Labels[hdconfig.InstanceLabel])
+	require.Equal(t, "hyperdrive.instance=hd-a", cfg.ContainerInstanceLabel())
+}
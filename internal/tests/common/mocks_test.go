@@ -0,0 +1,1493 @@
+package common_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/rocket-pool/node-manager-core/utils"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The genesis validators root used by the mock Beacon Node (Holesky's)
+	testGenesisValidatorsRoot string = "0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"
+)
+
+// A fake Beacon Node that serves deterministic chain data for the Beacon API routes used by the daemon
+type mockBeaconNode struct {
+	*httptest.Server
+	t testing.TB
+
+	// The chain spec
+	Spec map[string]any
+
+	// The version of the fork active on the head state
+	ForkVersion []byte
+
+	// The chain's genesis validators root
+	GenesisValidatorsRoot []byte
+
+	// The slot of the head block
+	HeadSlot uint64
+
+	// If set, the head slot follows the wall clock from the genesis time instead of staying at HeadSlot, with the node's
+	// clock shifted by ClockOffset
+	FollowClock bool
+	ClockOffset time.Duration
+
+	// The number of slots the head advances by after each head block header request, so tests can move through epochs
+	// without waiting for them
+	HeadSlotStep uint64
+
+	// The finalized epoch on the head state; the current justified epoch is the one after it, and the previous justified epoch is the same
+	FinalizedEpoch uint64
+
+	// The validators on the head state
+	Validators []client.Validator
+
+	// The attestation committees for each epoch
+	Committees map[uint64][]common.BeaconCommittee
+
+	// The number of seats in each sync committee; they're filled from the active validators in a rotation that moves on
+	// by this many validators each period
+	SyncCommitteeSize int
+
+	// The deposits in the head state's pending deposit queue (Electra onwards)
+	PendingDeposits []common.BeaconPendingDeposit
+
+	// The attestations included in each block, keyed by slot; slots without an entry are treated as missed
+	Attestations map[uint64][]common.BeaconAttestation
+
+	// The fork name reported with block attestations
+	AttestationsVersion string
+
+	// The attestations in the node's pool waiting to be aggregated or included; they're served filtered by slot and
+	// committee index
+	AttestationPool []common.BeaconAttestation
+
+	// The blob sidecars attached to each block, keyed by slot; slots without an entry are treated as missed
+	BlobSidecars map[uint64][]common.BlobSidecar
+
+	// The withdrawals in each block's execution payload, keyed by slot; slots without an entry are treated as missed
+	Withdrawals map[uint64][]common.BeaconWithdrawal
+
+	// The fee recipients in each block's execution payload, keyed by slot; slots with an entry here or in Withdrawals have
+	// a block
+	FeeRecipients map[uint64]ethcommon.Address
+
+	// The validator balances on historical states, keyed by slot and then validator index; states without an entry have
+	// the head balances
+	BalanceHistory map[uint64]map[string]uint64
+
+	// The earliest slot the node still has the state for; earlier states have been pruned
+	EarliestStateSlot uint64
+
+	// Slots whose blocks fail to load with a server error
+	FailingSlots map[uint64]bool
+
+	// True if the node is refusing requests, as if it were still starting up
+	Unavailable bool
+
+	// True if the node can't reach its Execution Client over the Engine API
+	ElOffline bool
+
+	// How many slots behind the head the node reports it is; it's syncing while this is above 0
+	SyncDistance uint64
+
+	// True if the node reports its head as optimistic, since its Execution Client hasn't verified it yet
+	IsOptimistic bool
+
+	// Where the node's stored history starts, served on Lighthouse's database route when the version string is
+	// Lighthouse's; nil if the node has its whole history since genesis
+	LighthouseAnchor *common.LighthouseAnchorInfo
+
+	// The number of epochs past the head's that proposer duties are served for
+	ProposerLookahead uint64
+
+	// The node's network identity
+	Identity common.BeaconNodeIdentity
+
+	// The node's version string
+	Version string
+
+	// The number of requests made to each path
+	Requests map[string]int
+
+	// Handlers for additional routes, keyed by path
+	routes map[string]http.HandlerFunc
+	lock   *sync.Mutex
+
+	// The open event streams, the number that have been opened so far, and whether new ones are refused because the
+	// node is shutting down
+	eventStreams     map[*mockEventStream]bool
+	EventConnections int
+	eventsClosed     bool
+}
+
+// An event stream opened on the mock Beacon Node
+type mockEventStream struct {
+	topics []string
+	events chan string
+	drop   chan struct{}
+}
+
+// Creates a new mock Beacon Node with a mainnet-like spec on the Deneb fork
+func newMockBeaconNode(t testing.TB) *mockBeaconNode {
+	m := &mockBeaconNode{
+		t: t,
+		Spec: map[string]any{
+			"CONFIG_NAME":                          "mainnet",
+			"PRESET_BASE":                          "mainnet",
+			"SLOTS_PER_EPOCH":                      "32",
+			"SECONDS_PER_SLOT":                     "12",
+			"EPOCHS_PER_SYNC_COMMITTEE_PERIOD":     "256",
+			"MIN_GENESIS_TIME":                     "1606824000",
+			"MAX_EFFECTIVE_BALANCE":                "32000000000",
+			"EFFECTIVE_BALANCE_INCREMENT":          "1000000000",
+			"MIN_PER_EPOCH_CHURN_LIMIT":            "4",
+			"CHURN_LIMIT_QUOTIENT":                 "65536",
+			"MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT": "8",
+			"SHARD_COMMITTEE_PERIOD":               "256",
+			"MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP": "16384",
+			"MIN_VALIDATOR_WITHDRAWABILITY_DELAY":  "256",
+			"GENESIS_FORK_VERSION":                 "0x00000000",
+			"ALTAIR_FORK_VERSION":                  "0x01000000",
+			"ALTAIR_FORK_EPOCH":                    "1",
+			"BELLATRIX_FORK_VERSION":               "0x02000000",
+			"BELLATRIX_FORK_EPOCH":                 "2",
+			"CAPELLA_FORK_VERSION":                 "0x03000000",
+			"CAPELLA_FORK_EPOCH":                   "3",
+			"DENEB_FORK_VERSION":                   "0x04000000",
+			"DENEB_FORK_EPOCH":                     "4",
+			"DEPOSIT_CHAIN_ID":                     "1",
+			"DEPOSIT_CONTRACT_ADDRESS":             "0x00000000219ab540356cbb839cbe05303d7705fa",
+			"TARGET_AGGREGATORS_PER_COMMITTEE":     "16",
+			"DOMAIN_BEACON_PROPOSER":               "0x00000000",
+		},
+		ForkVersion:           []byte{0x04, 0x00, 0x00, 0x00},
+		GenesisValidatorsRoot: ethcommon.FromHex(testGenesisValidatorsRoot),
+		HeadSlot:              320,
+		FinalizedEpoch:        8,
+		Validators:            []client.Validator{},
+		Committees:            map[uint64][]common.BeaconCommittee{},
+		SyncCommitteeSize:     4,
+		PendingDeposits:       []common.BeaconPendingDeposit{},
+		Attestations:          map[uint64][]common.BeaconAttestation{},
+		AttestationsVersion:   "deneb",
+		AttestationPool:       []common.BeaconAttestation{},
+		BlobSidecars:          map[uint64][]common.BlobSidecar{},
+		Withdrawals:           map[uint64][]common.BeaconWithdrawal{},
+		FeeRecipients:         map[uint64]ethcommon.Address{},
+		BalanceHistory:        map[uint64]map[string]uint64{},
+		FailingSlots:          map[uint64]bool{},
+		ProposerLookahead:     1,
+		Version:               "Lighthouse/v4.5.0-441fc16/x86_64-linux",
+		Requests:              map[string]int{},
+		routes:                map[string]http.HandlerFunc{},
+		lock:                  &sync.Mutex{},
+		eventStreams:          map[*mockEventStream]bool{},
+		Identity: common.BeaconNodeIdentity{
+			PeerId:             "16Uiu2HAmWyx1HRvbQHPndcRbCHDSzFZSwmjqNbxrPvSguPzu5T7t",
+			Enr:                "enr:-Iq4QMockBeaconNodeRecord",
+			P2pAddresses:       []string{"/ip4/127.0.0.1/tcp/9000"},
+			DiscoveryAddresses: []string{"/ip4/127.0.0.1/udp/9000"},
+		},
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveHttp))
+	t.Cleanup(func() {
+		// Event streams stay open until they're dropped, which would hold up the server's shutdown
+		m.lock.Lock()
+		m.eventsClosed = true
+		m.lock.Unlock()
+		m.DropEventStreams()
+		m.Close()
+	})
+	return m
+}
+
+// Registers a handler for an additional route
+func (m *mockBeaconNode) Handle(path string, handler http.HandlerFunc) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.routes[path] = handler
+}
+
+// Sends a server-sent event to every open event stream subscribed to its topic
+func (m *mockBeaconNode) EmitEvent(topic string, data any) {
+	bytes, err := json.Marshal(data)
+	require.NoError(m.t, err)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for stream := range m.eventStreams {
+		if slices.Contains(stream.topics, topic) {
+			stream.events <- fmt.Sprintf("event: %s\ndata: %s\n\n", topic, bytes)
+		}
+	}
+}
+
+// Closes every open event stream, as if the connections dropped
+func (m *mockBeaconNode) DropEventStreams() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for stream := range m.eventStreams {
+		close(stream.drop)
+		delete(m.eventStreams, stream)
+	}
+}
+
+// Waits for the provided number of event streams to have been opened in total, and for one to be open
+func (m *mockBeaconNode) WaitForEventConnections(count int) {
+	require.Eventually(m.t, func() bool {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		return m.EventConnections >= count && len(m.eventStreams) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// Serves an event stream until the client disconnects or the stream is dropped
+func (m *mockBeaconNode) serveEvents(w http.ResponseWriter, r *http.Request) {
+	m.lock.Lock()
+	if m.Unavailable || m.eventsClosed {
+		m.lock.Unlock()
+		writeJson(w, http.StatusServiceUnavailable, map[string]any{"code": 503, "message": "node is starting"})
+		return
+	}
+	topics := strings.Split(r.URL.Query().Get("topics"), ",")
+	for _, topic := range topics {
+		if !slices.Contains([]string{"head", "block", "finalized_checkpoint", "chain_reorg"}, topic) {
+			m.lock.Unlock()
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid topic: " + topic})
+			return
+		}
+	}
+	stream := &mockEventStream{
+		topics: topics,
+		events: make(chan string, 64),
+		drop:   make(chan struct{}),
+	}
+	m.eventStreams[stream] = true
+	m.EventConnections++
+	m.lock.Unlock()
+	defer func() {
+		m.lock.Lock()
+		delete(m.eventStreams, stream)
+		m.lock.Unlock()
+	}()
+
+	// Start with a keepalive comment, like real nodes send between events
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(": keepalive\n\n"))
+	flusher := w.(http.Flusher)
+	flusher.Flush()
+	for {
+		select {
+		case event := <-stream.events:
+			_, _ = w.Write([]byte(event))
+			flusher.Flush()
+		case <-stream.drop:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Adds a number of validators with the provided status and effective balance (in gwei)
+func (m *mockBeaconNode) AddValidators(count int, status beacon.ValidatorState, effectiveBalance uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i := 0; i < count; i++ {
+		var validator client.Validator
+		index := len(m.Validators)
+		validator.Index = fmt.Sprint(index)
+		validator.Status = string(status)
+		validator.Balance = client.Uinteger(effectiveBalance)
+		validator.Validator.EffectiveBalance = client.Uinteger(effectiveBalance)
+		pubkey := make([]byte, beacon.ValidatorPubkeyLength)
+		pubkey[0] = 0xaa
+		pubkey[1] = byte(index >> 8)
+		pubkey[2] = byte(index)
+		validator.Validator.Pubkey = pubkey
+		validator.Validator.WithdrawalCredentials = getMockWithdrawalCredentials(0x01, index)
+		validator.Validator.ExitEpoch = client.Uinteger(common.FarFutureEpoch)
+		validator.Validator.WithdrawableEpoch = client.Uinteger(common.FarFutureEpoch)
+		m.Validators = append(m.Validators, validator)
+	}
+}
+
+// Adds a number of validators to the activation queue with the provided eligibility epoch; they haven't been assigned an
+// activation epoch yet
+func (m *mockBeaconNode) AddQueuedValidators(count int, eligibilityEpoch uint64) {
+	start := len(m.Validators)
+	m.AddValidators(count, beacon.ValidatorState_PendingQueued, 32e9)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i := start; i < len(m.Validators); i++ {
+		m.Validators[i].Validator.ActivationEligibilityEpoch = client.Uinteger(eligibilityEpoch)
+		m.Validators[i].Validator.ActivationEpoch = client.Uinteger(common.FarFutureEpoch)
+	}
+}
+
+// Assigns the activation epoch of the queued validator with the provided index, as if it had left the activation queue
+func (m *mockBeaconNode) ScheduleActivation(index int, activationEpoch uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Validators[index].Validator.ActivationEpoch = client.Uinteger(activationEpoch)
+}
+
+// Sets the withdrawal credentials of the validator with the provided index
+func (m *mockBeaconNode) SetWithdrawalCredentials(index int, credentials []byte) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Validators[index].Validator.WithdrawalCredentials = credentials
+}
+
+// Sets the effective balance (in gwei) of the validator with the provided index, e.g. to model a compounding validator
+func (m *mockBeaconNode) SetEffectiveBalance(index int, effectiveBalance uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Validators[index].Validator.EffectiveBalance = client.Uinteger(effectiveBalance)
+}
+
+// Sets the exit and withdrawable epochs of the validator with the provided index, as if it had started exiting
+func (m *mockBeaconNode) SetExitEpochs(index int, exitEpoch uint64, withdrawableEpoch uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Validators[index].Validator.ExitEpoch = client.Uinteger(exitEpoch)
+	m.Validators[index].Validator.WithdrawableEpoch = client.Uinteger(withdrawableEpoch)
+}
+
+// Sets the balance (in gwei) of the validator with the provided index, leaving its effective balance as it is
+func (m *mockBeaconNode) SetBalance(index int, balance uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Validators[index].Balance = client.Uinteger(balance)
+}
+
+// Adds a number of validators with states picked from a seeded random source, so the mix is the same on every run.
+// Returns the number of validators added in each state.
+func (m *mockBeaconNode) AddSeededValidators(seed int64, count int) map[beacon.ValidatorState]int {
+	states := []beacon.ValidatorState{
+		beacon.ValidatorState_PendingInitialized,
+		beacon.ValidatorState_PendingQueued,
+		beacon.ValidatorState_ActiveOngoing,
+		beacon.ValidatorState_ActiveExiting,
+		beacon.ValidatorState_ActiveSlashed,
+		beacon.ValidatorState_ExitedUnslashed,
+		beacon.ValidatorState_ExitedSlashed,
+		beacon.ValidatorState_WithdrawalPossible,
+		beacon.ValidatorState_WithdrawalDone,
+	}
+	random := rand.New(rand.NewSource(seed))
+	added := map[beacon.ValidatorState]int{}
+	for i := 0; i < count; i++ {
+		state := states[random.Intn(len(states))]
+		balance := uint64(32e9)
+		if state == beacon.ValidatorState_WithdrawalDone {
+			balance = 0
+		}
+		m.AddValidators(1, state, balance)
+		added[state]++
+	}
+	return added
+}
+
+// Schedules Electra at epoch 5, after Deneb and before the default head, and makes it the head state's fork
+func (m *mockBeaconNode) ActivateElectra() {
+	m.Spec["ELECTRA_FORK_VERSION"] = "0x05000000"
+	m.Spec["ELECTRA_FORK_EPOCH"] = "5"
+	m.ForkVersion = []byte{0x05, 0x00, 0x00, 0x00}
+}
+
+// Assigns every active validator to a committee during the provided epoch, one committee per slot
+func (m *mockBeaconNode) AssignCommittees(epoch uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	slotsPerEpoch := m.getSlotsPerEpoch()
+	committees := make([]common.BeaconCommittee, slotsPerEpoch)
+	for i := range committees {
+		committees[i] = common.BeaconCommittee{
+			Slot:       client.Uinteger(epoch*slotsPerEpoch + uint64(i)),
+			Validators: []string{},
+		}
+	}
+	assigned := 0
+	for _, validator := range m.Validators {
+		if !strings.HasPrefix(validator.Status, "active") {
+			continue
+		}
+		committee := &committees[assigned%len(committees)]
+		committee.Validators = append(committee.Validators, validator.Index)
+		assigned++
+	}
+	m.Committees[epoch] = committees
+}
+
+// Adds a block in the provided slot that withdraws 0.01 ETH from each of the provided validators, to an address derived from the validator index
+func (m *mockBeaconNode) AddWithdrawals(slot uint64, validatorIndices ...uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	withdrawals := m.Withdrawals[slot]
+	if withdrawals == nil {
+		withdrawals = []common.BeaconWithdrawal{}
+	}
+	nextIndex := uint64(0)
+	for _, blockWithdrawals := range m.Withdrawals {
+		nextIndex += uint64(len(blockWithdrawals))
+	}
+	for _, validatorIndex := range validatorIndices {
+		address := make([]byte, 20)
+		address[0] = 0xdd
+		address[19] = byte(validatorIndex)
+		withdrawals = append(withdrawals, common.BeaconWithdrawal{
+			Index:          client.Uinteger(nextIndex),
+			ValidatorIndex: client.Uinteger(validatorIndex),
+			Address:        address,
+			Amount:         client.Uinteger(1e7),
+		})
+		nextIndex++
+	}
+	m.Withdrawals[slot] = withdrawals
+}
+
+// Sets the balance of every validator on the states at the provided slots, from a function of the validator's index and
+// the slot
+func (m *mockBeaconNode) SetBalanceHistory(slots []uint64, getBalance func(index int, slot uint64) uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, slot := range slots {
+		balances := make(map[string]uint64, len(m.Validators))
+		for i, validator := range m.Validators {
+			balances[validator.Index] = getBalance(i, slot)
+		}
+		m.BalanceHistory[slot] = balances
+	}
+}
+
+// Gets the number of requests made to a path
+func (m *mockBeaconNode) GetRequestCount(path string) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.Requests[path]
+}
+
+// Sets whether the node is refusing requests
+func (m *mockBeaconNode) SetUnavailable(unavailable bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Unavailable = unavailable
+}
+
+// Sets whether the node reports its Execution Client as offline
+func (m *mockBeaconNode) SetElOffline(offline bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.ElOffline = offline
+}
+
+// Sets how many slots behind the head the node reports it is, so tests can hold it in place to look stuck
+func (m *mockBeaconNode) SetSyncDistance(distance uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.SyncDistance = distance
+}
+
+// Puts the node partway through a checkpoint sync from the provided slot, with its head optimistic and the blocks back
+// to the provided oldest slot backfilled. Historical states haven't been rebuilt yet.
+func (m *mockBeaconNode) SetBackfilling(anchorSlot uint64, oldestBlockSlot uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.IsOptimistic = true
+	m.LighthouseAnchor = &common.LighthouseAnchorInfo{
+		AnchorSlot:      client.Uinteger(anchorSlot),
+		OldestBlockSlot: client.Uinteger(oldestBlockSlot),
+		StateUpperLimit: client.Uinteger(anchorSlot),
+		StateLowerLimit: 0,
+	}
+}
+
+// Sets the finalized epoch of the head state
+func (m *mockBeaconNode) SetFinalizedEpoch(epoch uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.FinalizedEpoch = epoch
+}
+
+// Seeds a block in the provided slot with a number of deterministic blob sidecars
+func (m *mockBeaconNode) AddBlobSidecars(slot uint64, count int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	sidecars := make([]common.BlobSidecar, count)
+	for i := range sidecars {
+		commitment := make([]byte, 48)
+		proof := make([]byte, 48)
+		for j := range commitment {
+			commitment[j] = byte(slot) + byte(i)
+			proof[j] = ^commitment[j]
+		}
+		sidecars[i] = common.BlobSidecar{
+			Index:         client.Uinteger(i),
+			KzgCommitment: commitment,
+			KzgProof:      proof,
+		}
+	}
+	m.BlobSidecars[slot] = sidecars
+}
+
+func (m *mockBeaconNode) serveHttp(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/eth/v1/events" {
+		m.serveEvents(w, r)
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	path := r.URL.Path
+	m.Requests[path]++
+	if m.FollowClock {
+		m.HeadSlot = uint64(time.Now().Add(m.ClockOffset).Sub(time.Unix(mockGenesisTime, 0)) / (12 * time.Second))
+	}
+	if m.Unavailable {
+		writeJson(w, http.StatusServiceUnavailable, map[string]any{"code": 503, "message": "node is starting"})
+		return
+	}
+
+	if handler, exists := m.routes[path]; exists {
+		handler(w, r)
+		return
+	}
+
+	switch {
+	case path == "/eth/v1/node/syncing":
+		writeJson(w, http.StatusOK, map[string]any{
+			"data": common.BeaconSyncStatus{
+				HeadSlot:     client.Uinteger(m.HeadSlot),
+				SyncDistance: client.Uinteger(m.SyncDistance),
+				IsSyncing:    m.SyncDistance > 0,
+				IsOptimistic: m.IsOptimistic,
+				ElOffline:    m.ElOffline,
+			},
+		})
+
+	case path == "/lighthouse/database/info" && strings.HasPrefix(m.Version, "Lighthouse/"):
+		writeJson(w, http.StatusOK, map[string]any{"schema_version": 22, "anchor": m.LighthouseAnchor})
+
+	case path == "/eth/v1/node/identity":
+		writeJson(w, http.StatusOK, map[string]any{"data": m.Identity})
+
+	case path == "/eth/v1/node/version":
+		writeJson(w, http.StatusOK, map[string]any{"data": map[string]string{"version": m.Version}})
+
+	case path == "/eth/v1/config/spec":
+		writeJson(w, http.StatusOK, map[string]any{"data": m.Spec})
+
+	case path == "/eth/v1/beacon/genesis":
+		var response client.GenesisResponse
+		response.Data.GenesisTime = client.Uinteger(mockGenesisTime)
+		response.Data.GenesisForkVersion = []byte{0x01, 0x01, 0x70, 0x00}
+		response.Data.GenesisValidatorsRoot = m.GenesisValidatorsRoot
+		writeJson(w, http.StatusOK, response)
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/states/") && strings.HasSuffix(path, "/fork"):
+		writeJson(w, http.StatusOK, map[string]any{
+			"data": map[string]string{
+				"previous_version": utils.EncodeHexWithPrefix(m.ForkVersion),
+				"current_version":  utils.EncodeHexWithPrefix(m.ForkVersion),
+				"epoch":            "0",
+			},
+		})
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/headers/"):
+		var response client.BeaconBlockHeaderResponse
+		response.Data.Canonical = true
+		response.Data.Header.Message.Slot = client.Uinteger(m.HeadSlot)
+		writeJson(w, http.StatusOK, response)
+		if path == "/eth/v1/beacon/headers/head" {
+			m.HeadSlot += m.HeadSlotStep
+		}
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/states/") && strings.HasSuffix(path, "/finality_checkpoints"):
+		writeJson(w, http.StatusOK, map[string]any{
+			"data": common.BeaconFinalityCheckpoints{
+				PreviousJustified: getMockCheckpoint(m.FinalizedEpoch),
+				CurrentJustified:  getMockCheckpoint(m.FinalizedEpoch + 1),
+				Finalized:         getMockCheckpoint(m.FinalizedEpoch),
+			},
+		})
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/states/") && strings.HasSuffix(path, "/validators"):
+		writeJson(w, http.StatusOK, client.ValidatorsResponse{
+			Data: filterValidators(m.Validators, r.URL.Query().Get("status"), r.URL.Query().Get("id")),
+		})
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/states/") && strings.HasSuffix(path, "/validator_balances"):
+		stateId := strings.Split(path, "/")[5]
+		history := map[string]uint64(nil)
+		if slot, err := strconv.ParseUint(stateId, 10, 64); err == nil {
+			if slot < m.EarliestStateSlot {
+				writeJson(w, http.StatusNotFound, map[string]any{"code": 404, "message": "state not found"})
+				return
+			}
+			history = m.BalanceHistory[slot]
+		}
+		balances := []map[string]string{}
+		for _, validator := range filterValidators(m.Validators, "", r.URL.Query().Get("id")) {
+			balance := uint64(validator.Balance)
+			if historical, exists := history[validator.Index]; exists {
+				balance = historical
+			}
+			balances = append(balances, map[string]string{"index": validator.Index, "balance": strconv.FormatUint(balance, 10)})
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": balances})
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/states/") && strings.HasSuffix(path, "/committees"):
+		epoch, err := strconv.ParseUint(r.URL.Query().Get("epoch"), 10, 64)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid epoch"})
+			return
+		}
+		committees, exists := m.Committees[epoch]
+		if !exists {
+			committees = []common.BeaconCommittee{}
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": committees})
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/states/") && strings.HasSuffix(path, "/sync_committees"):
+		epoch, err := strconv.ParseUint(r.URL.Query().Get("epoch"), 10, 64)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid epoch"})
+			return
+		}
+		altairEpoch, _ := strconv.ParseUint(m.Spec["ALTAIR_FORK_EPOCH"].(string), 10, 64)
+		headPeriod := m.HeadSlot / m.getSlotsPerEpoch() / m.getEpochsPerSyncCommitteePeriod()
+		period := epoch / m.getEpochsPerSyncCommitteePeriod()
+		if epoch < altairEpoch || period < headPeriod || period > headPeriod+1 {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "sync committee is not available for the requested epoch"})
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": map[string]any{"validators": m.getSyncCommittee(period)}})
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/states/") && strings.HasSuffix(path, "/pending_deposits"):
+		writeJson(w, http.StatusOK, map[string]any{"data": m.PendingDeposits})
+
+	case path == "/eth/v1/beacon/pool/attestations":
+		query := r.URL.Query()
+		attestations := []common.BeaconAttestation{}
+		for _, attestation := range m.AttestationPool {
+			if query.Has("slot") && strconv.FormatUint(uint64(attestation.Data.Slot), 10) != query.Get("slot") {
+				continue
+			}
+			if query.Has("committee_index") && strconv.FormatUint(uint64(attestation.Data.Index), 10) != query.Get("committee_index") {
+				continue
+			}
+			attestations = append(attestations, attestation)
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": attestations})
+
+	case strings.HasPrefix(path, "/eth/v2/beacon/blocks/") && strings.HasSuffix(path, "/attestations"):
+		slot, err := strconv.ParseUint(strings.Split(path, "/")[5], 10, 64)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid block ID"})
+			return
+		}
+		attestations, exists := m.Attestations[slot]
+		if !exists {
+			writeJson(w, http.StatusNotFound, map[string]any{"code": 404, "message": "block not found"})
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]any{"version": m.AttestationsVersion, "data": attestations})
+
+	case strings.HasPrefix(path, "/eth/v2/beacon/blocks/"):
+		// The finalized block is the first slot of the finalized epoch, which always has a block
+		blockId := strings.Split(path, "/")[5]
+		isFinalized := blockId == "finalized"
+		if isFinalized {
+			blockId = strconv.FormatUint(m.FinalizedEpoch*m.getSlotsPerEpoch(), 10)
+		}
+		slot, err := strconv.ParseUint(blockId, 10, 64)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid block ID"})
+			return
+		}
+		if m.FailingSlots[slot] {
+			writeJson(w, http.StatusInternalServerError, map[string]any{"code": 500, "message": "internal error"})
+			return
+		}
+		withdrawals, hasWithdrawals := m.Withdrawals[slot]
+		feeRecipient, hasFeeRecipient := m.FeeRecipients[slot]
+		if !hasWithdrawals && !hasFeeRecipient && !isFinalized {
+			writeJson(w, http.StatusNotFound, map[string]any{"code": 404, "message": "block not found"})
+			return
+		}
+		if withdrawals == nil {
+			withdrawals = []common.BeaconWithdrawal{}
+		}
+		writeJson(w, http.StatusOK, map[string]any{
+			"version": "deneb",
+			"data": map[string]any{
+				"message": map[string]any{
+					"slot": strconv.FormatUint(slot, 10),
+					"body": map[string]any{
+						"execution_payload": map[string]any{
+							"block_number":  strconv.FormatUint(slot, 10),
+							"block_hash":    getMockBlockHash(slot).Hex(),
+							"fee_recipient": feeRecipient.Hex(),
+							"withdrawals":   withdrawals,
+						},
+					},
+				},
+			},
+		})
+
+	case strings.HasPrefix(path, "/eth/v1/validator/duties/attester/") && r.Method == http.MethodPost:
+		epoch, err := strconv.ParseUint(strings.TrimPrefix(path, "/eth/v1/validator/duties/attester/"), 10, 64)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid epoch"})
+			return
+		}
+		var indices []string
+		err = json.NewDecoder(r.Body).Decode(&indices)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid request"})
+			return
+		}
+		requested := map[string]bool{}
+		for _, index := range indices {
+			requested[index] = true
+		}
+		duties := []common.BeaconAttesterDuty{}
+		for _, validator := range m.getActiveValidators() {
+			if !requested[validator.Index] {
+				continue
+			}
+			index, _ := strconv.ParseUint(validator.Index, 10, 64)
+			duties = append(duties, common.BeaconAttesterDuty{
+				Pubkey:         validator.Validator.Pubkey,
+				ValidatorIndex: validator.Index,
+				Slot:           client.Uinteger(getMockAttesterSlot(epoch, index, m.getSlotsPerEpoch())),
+			})
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": duties})
+
+	case strings.HasPrefix(path, "/eth/v1/validator/duties/proposer/"):
+		epoch, err := strconv.ParseUint(strings.TrimPrefix(path, "/eth/v1/validator/duties/proposer/"), 10, 64)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid epoch"})
+			return
+		}
+		slotsPerEpoch := m.getSlotsPerEpoch()
+		if epoch > m.HeadSlot/slotsPerEpoch+m.ProposerLookahead {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "epoch is too far in the future"})
+			return
+		}
+		active := m.getActiveValidators()
+		duties := []common.BeaconProposerDuty{}
+		for slot := epoch * slotsPerEpoch; slot < (epoch+1)*slotsPerEpoch && len(active) > 0; slot++ {
+			proposer := active[getMockProposerPosition(slot, len(active))]
+			duties = append(duties, common.BeaconProposerDuty{
+				Pubkey:         proposer.Validator.Pubkey,
+				ValidatorIndex: proposer.Index,
+				Slot:           client.Uinteger(slot),
+			})
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": duties})
+
+	case strings.HasPrefix(path, "/eth/v1/beacon/blob_sidecars/"):
+		slot, err := strconv.ParseUint(strings.Split(path, "/")[5], 10, 64)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid block ID"})
+			return
+		}
+		sidecars, exists := m.BlobSidecars[slot]
+		if !exists {
+			writeJson(w, http.StatusNotFound, map[string]any{"code": 404, "message": "block not found"})
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": sidecars})
+
+	default:
+		writeJson(w, http.StatusNotFound, map[string]any{"code": 404, "message": "not found"})
+	}
+}
+
+// Creates withdrawal credentials with the provided prefix, pointing to an address derived from the validator's index
+func getMockWithdrawalCredentials(prefix byte, index int) []byte {
+	credentials := make([]byte, 32)
+	credentials[0] = prefix
+	credentials[12] = 0xee
+	credentials[30] = byte(index >> 8)
+	credentials[31] = byte(index)
+	return credentials
+}
+
+// Gets the validators that are active on the head state
+func (m *mockBeaconNode) getActiveValidators() []client.Validator {
+	active := []client.Validator{}
+	for _, validator := range m.Validators {
+		if strings.HasPrefix(validator.Status, "active") {
+			active = append(active, validator)
+		}
+	}
+	return active
+}
+
+// Gets the indices of the validators in the sync committee for a period. Seats are filled from the active validators in
+// order, starting SyncCommitteeSize validators further along for each period and wrapping around, so small validator sets
+// fill the committee with repeats like a real one would.
+func (m *mockBeaconNode) getSyncCommittee(period uint64) []string {
+	active := m.getActiveValidators()
+	committee := make([]string, 0, m.SyncCommitteeSize)
+	if len(active) == 0 {
+		return committee
+	}
+	for seat := 0; seat < m.SyncCommitteeSize; seat++ {
+		position := (period*uint64(m.SyncCommitteeSize) + uint64(seat)) % uint64(len(active))
+		committee = append(committee, active[position].Index)
+	}
+	return committee
+}
+
+// Gets the number of epochs in each sync committee period in the mock spec
+func (m *mockBeaconNode) getEpochsPerSyncCommitteePeriod() uint64 {
+	epochs, err := strconv.ParseUint(m.Spec["EPOCHS_PER_SYNC_COMMITTEE_PERIOD"].(string), 10, 64)
+	if err != nil {
+		m.t.Fatalf("invalid EPOCHS_PER_SYNC_COMMITTEE_PERIOD in mock spec: %v", err)
+	}
+	return epochs
+}
+
+// Gets the number of slots per epoch in the mock spec
+func (m *mockBeaconNode) getSlotsPerEpoch() uint64 {
+	slotsPerEpoch, err := strconv.ParseUint(m.Spec["SLOTS_PER_EPOCH"].(string), 10, 64)
+	if err != nil {
+		m.t.Fatalf("invalid SLOTS_PER_EPOCH in mock spec: %v", err)
+	}
+	return slotsPerEpoch
+}
+
+// Gets the slot an active validator attests in during an epoch on the mock Beacon Node
+func getMockAttesterSlot(epoch uint64, index uint64, slotsPerEpoch uint64) uint64 {
+	return epoch*slotsPerEpoch + (index*7+epoch)%slotsPerEpoch
+}
+
+// Gets the position (among the active validators) of the validator that proposes a slot on the mock Beacon Node
+func getMockProposerPosition(slot uint64, activeCount int) int {
+	return int(slot % uint64(activeCount))
+}
+
+// Creates a deterministic Execution layer block hash for the provided block number
+func getMockBlockHash(number uint64) ethcommon.Hash {
+	return ethcommon.HexToHash(fmt.Sprintf("0xb10c%060x", number))
+}
+
+// Creates a checkpoint with a deterministic root for the provided epoch
+func getMockCheckpoint(epoch uint64) common.BeaconCheckpoint {
+	root := make([]byte, 32)
+	root[0] = 0xcc
+	root[31] = byte(epoch)
+	return common.BeaconCheckpoint{
+		Epoch: client.Uinteger(epoch),
+		Root:  root,
+	}
+}
+
+// Filters validators by the status and ID query parameters
+func filterValidators(validators []client.Validator, statusParam string, idParam string) []client.Validator {
+	statuses := map[string]bool{}
+	for _, status := range strings.Split(statusParam, ",") {
+		if status != "" {
+			statuses[status] = true
+		}
+	}
+	ids := map[string]bool{}
+	for _, id := range strings.Split(idParam, ",") {
+		if id != "" {
+			ids[strings.ToLower(id)] = true
+		}
+	}
+
+	filtered := []client.Validator{}
+	for _, validator := range validators {
+		if len(statuses) > 0 {
+			generalStatus := strings.Split(validator.Status, "_")[0]
+			if !statuses[validator.Status] && !statuses[generalStatus] {
+				continue
+			}
+		}
+		if len(ids) > 0 && !ids[validator.Index] && !ids[utils.EncodeHexWithPrefix(validator.Validator.Pubkey)] {
+			continue
+		}
+		filtered = append(filtered, validator)
+	}
+	return filtered
+}
+
+// A fake Validator Client key manager API that keeps its keystores in memory
+type mockKeyManager struct {
+	*httptest.Server
+	t     *testing.T
+	token string
+
+	// The keystores loaded into the VC, keyed by pubkey
+	Keystores map[beacon.ValidatorPubkey]string
+
+	// The remote keys the VC signs for, keyed by pubkey with the URL of their signer. They're also listed as read-only
+	// keystores, as Lighthouse does.
+	RemoteKeys map[beacon.ValidatorPubkey]string
+
+	// The slashing protection data provided with the last import
+	SlashingProtection string
+
+	// The VC's slashing protection history, which imports are merged into
+	History common.SlashingProtectionInterchange
+
+	// The fee recipient of each loaded key that has one set
+	FeeRecipients map[beacon.ValidatorPubkey]ethcommon.Address
+
+	// Keys whose fee recipient updates are accepted but not applied, like a VC that ignores them
+	IgnoredFeeRecipients map[beacon.ValidatorPubkey]bool
+
+	// Called after keystores are deleted, if set
+	OnDelete func()
+
+	lock *sync.Mutex
+}
+
+// Creates a new mock key manager that requires the provided auth token
+func newMockKeyManager(t *testing.T, token string) *mockKeyManager {
+	m := &mockKeyManager{
+		t:                    t,
+		token:                token,
+		Keystores:            map[beacon.ValidatorPubkey]string{},
+		RemoteKeys:           map[beacon.ValidatorPubkey]string{},
+		FeeRecipients:        map[beacon.ValidatorPubkey]ethcommon.Address{},
+		IgnoredFeeRecipients: map[beacon.ValidatorPubkey]bool{},
+		History: common.SlashingProtectionInterchange{
+			Metadata: common.SlashingProtectionMetadata{
+				InterchangeFormatVersion: common.SlashingProtectionInterchangeVersion,
+				GenesisValidatorsRoot:    testGenesisValidatorsRoot,
+			},
+			Data: []common.SlashingProtectionRecord{},
+		},
+		lock: &sync.Mutex{},
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveHttp))
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockKeyManager) serveHttp(w http.ResponseWriter, r *http.Request) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+m.token {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"message": "invalid token"})
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/eth/v1/keystores" && r.Method == http.MethodGet:
+		keystores := []common.KeyManagerKeystore{}
+		for pubkey := range m.Keystores {
+			keystores = append(keystores, common.KeyManagerKeystore{Pubkey: pubkey})
+		}
+		for pubkey := range m.RemoteKeys {
+			keystores = append(keystores, common.KeyManagerKeystore{Pubkey: pubkey, ReadOnly: true})
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": keystores})
+
+	case r.URL.Path == "/eth/v1/remotekeys" && r.Method == http.MethodGet:
+		remoteKeys := []common.KeyManagerRemoteKey{}
+		for pubkey, url := range m.RemoteKeys {
+			remoteKeys = append(remoteKeys, common.KeyManagerRemoteKey{Pubkey: pubkey, Url: url})
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": remoteKeys})
+
+	case r.URL.Path == "/eth/v1/remotekeys" && r.Method == http.MethodPost:
+		var request struct {
+			RemoteKeys []struct {
+				Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+				Url    string                 `json:"url"`
+			} `json:"remote_keys"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]string{"message": "invalid request"})
+			return
+		}
+		statuses := []common.KeyManagerStatus{}
+		for _, remoteKey := range request.RemoteKeys {
+			_, isRemote := m.RemoteKeys[remoteKey.Pubkey]
+			_, isLocal := m.Keystores[remoteKey.Pubkey]
+			switch {
+			case isRemote || isLocal:
+				statuses = append(statuses, common.KeyManagerStatus{Status: "duplicate"})
+			case remoteKey.Url == "":
+				statuses = append(statuses, common.KeyManagerStatus{Status: "error", Message: "missing url"})
+			default:
+				m.RemoteKeys[remoteKey.Pubkey] = remoteKey.Url
+				statuses = append(statuses, common.KeyManagerStatus{Status: "imported"})
+			}
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": statuses})
+
+	case r.URL.Path == "/eth/v1/remotekeys" && r.Method == http.MethodDelete:
+		var request struct {
+			Pubkeys []beacon.ValidatorPubkey `json:"pubkeys"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]string{"message": "invalid request"})
+			return
+		}
+		statuses := []common.KeyManagerStatus{}
+		for _, pubkey := range request.Pubkeys {
+			if _, exists := m.RemoteKeys[pubkey]; !exists {
+				statuses = append(statuses, common.KeyManagerStatus{Status: "not_found"})
+				continue
+			}
+			delete(m.RemoteKeys, pubkey)
+			statuses = append(statuses, common.KeyManagerStatus{Status: "deleted"})
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": statuses})
+
+	case r.URL.Path == "/eth/v1/keystores" && r.Method == http.MethodPost:
+		var request struct {
+			Keystores          []string `json:"keystores"`
+			Passwords          []string `json:"passwords"`
+			SlashingProtection string   `json:"slashing_protection"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil || len(request.Keystores) != len(request.Passwords) {
+			writeJson(w, http.StatusBadRequest, map[string]string{"message": "invalid request"})
+			return
+		}
+		m.SlashingProtection = request.SlashingProtection
+		if request.SlashingProtection != "" {
+			var incoming common.SlashingProtectionInterchange
+			err = json.Unmarshal([]byte(request.SlashingProtection), &incoming)
+			if err != nil || !strings.EqualFold(incoming.Metadata.GenesisValidatorsRoot, testGenesisValidatorsRoot) {
+				writeJson(w, http.StatusBadRequest, map[string]string{"message": "invalid slashing protection data"})
+				return
+			}
+			m.History = common.MergeSlashingProtection(m.History, incoming)
+		}
+		statuses := []common.KeyManagerStatus{}
+		for _, keystoreString := range request.Keystores {
+			var keystore beacon.ValidatorKeystore
+			err := json.Unmarshal([]byte(keystoreString), &keystore)
+			if err != nil {
+				statuses = append(statuses, common.KeyManagerStatus{Status: "error", Message: err.Error()})
+				continue
+			}
+			if _, exists := m.Keystores[keystore.Pubkey]; exists {
+				statuses = append(statuses, common.KeyManagerStatus{Status: "duplicate"})
+				continue
+			}
+			m.Keystores[keystore.Pubkey] = keystoreString
+			statuses = append(statuses, common.KeyManagerStatus{Status: "imported"})
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": statuses})
+
+	case r.URL.Path == "/eth/v1/keystores" && r.Method == http.MethodDelete:
+		var request struct {
+			Pubkeys []beacon.ValidatorPubkey `json:"pubkeys"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]string{"message": "invalid request"})
+			return
+		}
+		released := common.SlashingProtectionInterchange{
+			Metadata: m.History.Metadata,
+			Data:     []common.SlashingProtectionRecord{},
+		}
+		statuses := []common.KeyManagerStatus{}
+		for _, pubkey := range request.Pubkeys {
+			var record *common.SlashingProtectionRecord
+			for i := range m.History.Data {
+				if m.History.Data[i].Pubkey == pubkey {
+					record = &m.History.Data[i]
+				}
+			}
+			if record != nil {
+				released.Data = append(released.Data, *record)
+			}
+			_, loaded := m.Keystores[pubkey]
+			switch {
+			case loaded:
+				delete(m.Keystores, pubkey)
+				statuses = append(statuses, common.KeyManagerStatus{Status: "deleted"})
+			case record != nil:
+				statuses = append(statuses, common.KeyManagerStatus{Status: "not_active"})
+			default:
+				statuses = append(statuses, common.KeyManagerStatus{Status: "not_found"})
+			}
+		}
+		releasedBytes, err := json.Marshal(released)
+		require.NoError(m.t, err)
+		if m.OnDelete != nil {
+			m.OnDelete()
+		}
+		writeJson(w, http.StatusOK, map[string]any{"data": statuses, "slashing_protection": string(releasedBytes)})
+
+	case strings.HasPrefix(r.URL.Path, "/eth/v1/validator/") && strings.HasSuffix(r.URL.Path, "/feerecipient"):
+		pubkey, err := beacon.HexToValidatorPubkey(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/eth/v1/validator/"), "/feerecipient"))
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]string{"message": "invalid pubkey"})
+			return
+		}
+		if _, exists := m.Keystores[pubkey]; !exists {
+			writeJson(w, http.StatusNotFound, map[string]string{"message": "validator not found"})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJson(w, http.StatusOK, map[string]any{"data": map[string]any{"pubkey": pubkey.HexWithPrefix(), "ethaddress": m.FeeRecipients[pubkey]}})
+		case http.MethodPost:
+			var request struct {
+				EthAddress ethcommon.Address `json:"ethaddress"`
+			}
+			err := json.NewDecoder(r.Body).Decode(&request)
+			if err != nil {
+				writeJson(w, http.StatusBadRequest, map[string]string{"message": "invalid request"})
+				return
+			}
+			if !m.IgnoredFeeRecipients[pubkey] {
+				m.FeeRecipients[pubkey] = request.EthAddress
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			writeJson(w, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+		}
+
+	default:
+		writeJson(w, http.StatusNotFound, map[string]string{"message": "not found"})
+	}
+}
+
+// A fake Execution Client that answers basic JSON-RPC requests
+type mockExecutionClient struct {
+	*httptest.Server
+	t *testing.T
+
+	// The chain ID reported by the client
+	ChainID uint64
+
+	// Canned results for other JSON-RPC methods, keyed by method name; methods without an entry aren't supported
+	Results map[string]any
+
+	// Handlers for JSON-RPC methods whose results depend on their parameters; these take precedence over Results.
+	// A returned error is sent back as a JSON-RPC error.
+	Handlers map[string]func(params []json.RawMessage) (any, error)
+
+	// True if the client is refusing requests, as if it were still starting up
+	Unavailable bool
+
+	// If set, requests have to be authenticated with a JWT signed by this secret, as on the Engine API
+	JwtSecret []byte
+
+	// The number of requests made for each JSON-RPC method
+	Requests map[string]int
+
+	lock *sync.Mutex
+}
+
+// A JSON-RPC error with a specific code and data, for handlers that need more than the default error
+type mockRpcError struct {
+	Code    int
+	Message string
+	Data    any
+}
+
+func (e *mockRpcError) Error() string {
+	return e.Message
+}
+
+// Creates a new mock Execution Client for the provided chain
+func newMockExecutionClient(t *testing.T, chainID uint64) *mockExecutionClient {
+	m := &mockExecutionClient{
+		t:        t,
+		ChainID:  chainID,
+		Results:  map[string]any{},
+		Handlers: map[string]func(params []json.RawMessage) (any, error){},
+		Requests: map[string]int{},
+		lock:     &sync.Mutex{},
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveHttp))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// Sets the result the client returns for a JSON-RPC method
+func (m *mockExecutionClient) SetResult(method string, result any) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Results[method] = result
+}
+
+// Sets the client's reported sync progress, so tests can hold it in place to look stuck
+func (m *mockExecutionClient) SetSyncDistance(currentBlock uint64, highestBlock uint64) {
+	m.SetResult("eth_syncing", map[string]string{
+		"startingBlock": "0x0",
+		"currentBlock":  fmt.Sprintf("0x%x", currentBlock),
+		"highestBlock":  fmt.Sprintf("0x%x", highestBlock),
+	})
+}
+
+// Checks that an Authorization header has an HS256 JWT signed by the secret and issued within the last minute
+func checkMockJwt(header string, secret []byte) bool {
+	parts := strings.Split(strings.TrimPrefix(header, "Bearer "), ".")
+	if len(parts) != 3 {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return false
+	}
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		IssuedAt int64 `json:"iat"`
+	}
+	err = json.Unmarshal(claimsBytes, &claims)
+	return err == nil && time.Since(time.Unix(claims.IssuedAt, 0)).Abs() < time.Minute
+}
+
+// Gets the number of requests made for a JSON-RPC method
+func (m *mockExecutionClient) GetRequestCount(method string) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.Requests[method]
+}
+
+// Sets whether the client is refusing requests
+func (m *mockExecutionClient) SetUnavailable(unavailable bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Unavailable = unavailable
+}
+
+func (m *mockExecutionClient) serveHttp(w http.ResponseWriter, r *http.Request) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.Unavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if m.JwtSecret != nil && !checkMockJwt(r.Header.Get("Authorization"), m.JwtSecret) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]any{"jsonrpc": "2.0", "error": map[string]any{"code": -32700, "message": "parse error"}})
+		return
+	}
+	m.Requests[request.Method]++
+
+	response := map[string]any{
+		"jsonrpc": "2.0",
+		"id":      request.ID,
+	}
+	handler, hasHandler := m.Handlers[request.Method]
+	result, exists := m.Results[request.Method]
+	switch {
+	case hasHandler:
+		result, err := handler(request.Params)
+		var rpcErr *mockRpcError
+		switch {
+		case errors.As(err, &rpcErr):
+			response["error"] = map[string]any{"code": rpcErr.Code, "message": rpcErr.Message, "data": rpcErr.Data}
+		case err != nil:
+			response["error"] = map[string]any{"code": -32000, "message": err.Error()}
+		default:
+			response["result"] = result
+		}
+	case exists:
+		response["result"] = result
+	case request.Method == "eth_chainId":
+		response["result"] = fmt.Sprintf("0x%x", m.ChainID)
+	default:
+		response["error"] = map[string]any{"code": -32601, "message": fmt.Sprintf("the method %s does not exist/is not available", request.Method)}
+	}
+	writeJson(w, http.StatusOK, response)
+}
+
+// A mock SMTP server that records the emails it receives. It's plaintext only, so clients only send passwords to it
+// because it's on localhost.
+type mockSmtpServer struct {
+	t        testing.TB
+	listener net.Listener
+
+	// The host and port the server listens on
+	Host string
+	Port uint16
+
+	// The login the server requires; if the username is blank, the server doesn't offer logging in
+	Username string
+	Password string
+
+	// The emails the server has received
+	Messages []mockSmtpMessage
+
+	lock *sync.Mutex
+}
+
+// An email received by the mock SMTP server
+type mockSmtpMessage struct {
+	// The envelope's sender and recipients
+	From string
+	To   []string
+
+	// The message's headers and body
+	Data string
+}
+
+// Creates a new mock SMTP server on localhost that requires the provided login if the username isn't blank
+func newMockSmtpServer(t testing.TB, username string, password string) *mockSmtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().(*net.TCPAddr)
+	m := &mockSmtpServer{
+		t:        t,
+		listener: listener,
+		Host:     address.IP.String(),
+		Port:     uint16(address.Port),
+		Username: username,
+		Password: password,
+		Messages: []mockSmtpMessage{},
+		lock:     &sync.Mutex{},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	t.Cleanup(m.Close)
+	return m
+}
+
+// Stops the server from accepting connections
+func (m *mockSmtpServer) Close() {
+	m.listener.Close()
+}
+
+// Gets the emails the server has received
+func (m *mockSmtpServer) GetMessages() []mockSmtpMessage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return slices.Clone(m.Messages)
+}
+
+// Handles one client's SMTP session
+func (m *mockSmtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...any) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 mock ESMTP ready") {
+		return
+	}
+	authenticated := m.Username == ""
+	message := mockSmtpMessage{}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			if m.Username != "" {
+				reply("250-mock")
+				reply("250-AUTH PLAIN")
+			}
+			reply("250 mock")
+		case "AUTH":
+			mechanism, encoded, _ := strings.Cut(argument, " ")
+			credentials, err := base64.StdEncoding.DecodeString(encoded)
+			if strings.ToUpper(mechanism) != "PLAIN" || err != nil {
+				reply("504 5.5.4 unrecognized authentication type")
+				continue
+			}
+			if string(credentials) != "\x00"+m.Username+"\x00"+m.Password {
+				reply("535 5.7.8 authentication credentials invalid")
+				continue
+			}
+			authenticated = true
+			reply("235 2.7.0 accepted")
+		case "MAIL":
+			if !authenticated {
+				reply("530 5.7.0 authentication required")
+				continue
+			}
+			message = mockSmtpMessage{From: getSmtpPath(argument)}
+			reply("250 2.1.0 ok")
+		case "RCPT":
+			message.To = append(message.To, getSmtpPath(argument))
+			reply("250 2.1.5 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message.Data = string(data)
+			m.lock.Lock()
+			m.Messages = append(m.Messages, message)
+			m.lock.Unlock()
+			reply("250 2.0.0 queued")
+		case "RSET", "NOOP":
+			reply("250 2.0.0 ok")
+		case "QUIT":
+			reply("221 2.0.0 bye")
+			return
+		default:
+			reply("502 5.5.2 command not recognized")
+		}
+	}
+}
+
+// Gets the address between the angle brackets of a MAIL FROM or RCPT TO argument
+func getSmtpPath(argument string) string {
+	_, path, _ := strings.Cut(argument, "<")
+	path, _, _ = strings.Cut(path, ">")
+	return path
+}
@@ -0,0 +1,95 @@
+package common_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// The vault address a mock module resolves by default
+	defaultVaultAddress ethcommon.Address = ethcommon.HexToAddress("0x000000000000000000000000000000000000a001")
+
+	// The vault's address after a mock protocol upgrade
+	upgradedVaultAddress ethcommon.Address = ethcommon.HexToAddress("0x000000000000000000000000000000000000a002")
+)
+
+// A module that looks its vault contract up from the service provider whenever it needs it
+type mockResourceModule struct {
+	sp *common.ServiceProvider
+}
+
+func (m *mockResourceModule) getVaultAddress(t *testing.T) ethcommon.Address {
+	address, exists := m.sp.GetModuleResource("stakewise", "vault")
+	require.True(t, exists)
+	return address
+}
+
+// Test that module code picks up an overridden resource address, and that overrides only survive a restart if saved
+func TestOverrideModuleResource(t *testing.T) {
+	ec := newModuleResourceExecutionClient(t)
+	cfg := newTestConfig(t, "http://127.0.0.1:1", ec.URL)
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	sp.RegisterModuleResources("stakewise", map[string]ethcommon.Address{"vault": defaultVaultAddress})
+	module := &mockResourceModule{sp: sp}
+	require.Equal(t, defaultVaultAddress, module.getVaultAddress(t))
+
+	err := sp.OverrideModuleResource("stakewise", "vault", upgradedVaultAddress)
+	require.NoError(t, err)
+	require.Equal(t, upgradedVaultAddress, module.getVaultAddress(t))
+
+	// Without saving, a restart goes back to the default
+	restarted := newTestServiceProviderFromConfig(t, cfg)
+	restarted.RegisterModuleResources("stakewise", map[string]ethcommon.Address{"vault": defaultVaultAddress})
+	require.Equal(t, defaultVaultAddress, (&mockResourceModule{sp: restarted}).getVaultAddress(t))
+
+	// Once saved, it's applied after a restart
+	require.NoError(t, sp.SaveModuleResourceOverrides())
+	data, err := os.ReadFile(filepath.Join(cfg.GetUserDirectory(), hdconfig.ModuleResourceOverridesFilename))
+	require.NoError(t, err)
+	var saved map[string]map[string]ethcommon.Address
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.Equal(t, upgradedVaultAddress, saved["stakewise"]["vault"])
+	restarted = newTestServiceProviderFromConfig(t, cfg)
+	restarted.RegisterModuleResources("stakewise", map[string]ethcommon.Address{"vault": defaultVaultAddress})
+	require.Equal(t, upgradedVaultAddress, (&mockResourceModule{sp: restarted}).getVaultAddress(t))
+}
+
+// Test that overrides are refused for addresses without code and for resources the module didn't register
+func TestOverrideModuleResource_Rejected(t *testing.T) {
+	ec := newModuleResourceExecutionClient(t)
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+	sp.RegisterModuleResources("stakewise", map[string]ethcommon.Address{"vault": defaultVaultAddress})
+
+	err := sp.OverrideModuleResource("stakewise", "vault", ethcommon.HexToAddress("0x000000000000000000000000000000000000dead"))
+	require.ErrorIs(t, err, common.ErrContractMismatch)
+	err = sp.OverrideModuleResource("stakewise", "registry", upgradedVaultAddress)
+	require.ErrorIs(t, err, common.ErrUnknownModuleResource)
+	err = sp.OverrideModuleResource("constellation", "vault", upgradedVaultAddress)
+	require.ErrorIs(t, err, common.ErrUnknownModuleResource)
+
+	address, exists := sp.GetModuleResource("stakewise", "vault")
+	require.True(t, exists)
+	require.Equal(t, defaultVaultAddress, address)
+	require.Empty(t, sp.GetModuleResourceOverrides())
+}
+
+// Creates an Execution Client where only the upgraded vault has code deployed
+func newModuleResourceExecutionClient(t *testing.T) *mockExecutionClient {
+	ec := newMockExecutionClient(t, 1)
+	ec.Handlers["eth_getCode"] = func(params []json.RawMessage) (any, error) {
+		var address ethcommon.Address
+		require.NoError(t, json.Unmarshal(params[0], &address))
+		if address == upgradedVaultAddress {
+			return "0x6080604052", nil
+		}
+		return "0x", nil
+	}
+	return ec
+}
@@ -0,0 +1,64 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the validators of a single module, where the module owns every other validator of a seeded set along
+// with one that hasn't been deposited yet
+func TestGetValidatorsByModule(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddSeededValidators(196, 1200)
+	pubkeys := getMockValidatorPubkeys(bn)
+	owned := []beacon.ValidatorPubkey{}
+	for i := 0; i < len(pubkeys); i += 2 {
+		owned = append(owned, pubkeys[i])
+	}
+	undeposited := beacon.ValidatorPubkey{0xee}
+	owned = append(owned, undeposited)
+
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: owned,
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "constellation",
+		enabled: true,
+		pubkeys: pubkeys[1:2],
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "legacy",
+		enabled: false,
+		pubkeys: pubkeys,
+	})
+
+	validators, err := sp.GetValidatorsByModule(context.Background(), "stakewise")
+	require.NoError(t, err)
+	require.Len(t, validators, len(owned))
+	for i, info := range validators[:len(validators)-1] {
+		expected := bn.Validators[i*2]
+		require.Equal(t, owned[i], info.Pubkey)
+		require.True(t, info.OnBeaconChain)
+		require.Equal(t, expected.Index, info.Index)
+		require.Equal(t, beacon.ValidatorState(expected.Status), info.State)
+		require.NotEmpty(t, info.Status)
+		require.Equal(t, uint64(expected.Balance), info.Balance)
+		require.Equal(t, uint64(expected.Validator.EffectiveBalance), info.EffectiveBalance)
+	}
+	require.Equal(t, common.ValidatorInfo{Pubkey: undeposited}, validators[len(validators)-1])
+
+	// The 601 pubkeys are looked up in batches of 600
+	require.Equal(t, 2, bn.Requests["/eth/v1/beacon/states/head/validators"])
+
+	for _, module := range []string{"legacy", "unknown"} {
+		_, err = sp.GetValidatorsByModule(context.Background(), module)
+		require.ErrorIs(t, err, common.ErrModuleNotEnabled)
+	}
+}
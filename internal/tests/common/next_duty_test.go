@@ -0,0 +1,93 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The genesis time reported by the mock Beacon Node
+	mockGenesisTime int64 = 1695902400
+)
+
+// Test finding the next duty as the head moves through the current and next epoch
+func TestGetNextDuty(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(64, beacon.ValidatorState_ActiveOngoing, 32e9)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	pubkey := beacon.ValidatorPubkey(bn.Validators[5].Validator.Pubkey)
+	ctx := context.Background()
+
+	// Validator 5 proposes slot 325 and attests in slot 333 of epoch 10, then attests in slot 366 of epoch 11
+	require.Equal(t, uint64(333), getMockAttesterSlot(10, 5, 32))
+	require.Equal(t, uint64(366), getMockAttesterSlot(11, 5, 32))
+	tests := []struct {
+		headSlot uint64
+		dutyType common.DutyType
+		slot     uint64
+	}{
+		{headSlot: 320, dutyType: common.DutyType_Proposal, slot: 325},
+		{headSlot: 325, dutyType: common.DutyType_Proposal, slot: 325},
+		{headSlot: 326, dutyType: common.DutyType_Attestation, slot: 333},
+		{headSlot: 334, dutyType: common.DutyType_Attestation, slot: 366},
+	}
+	for _, test := range tests {
+		bn.HeadSlot = test.headSlot
+		duty, err := sp.GetNextDuty(ctx, pubkey)
+		require.NoError(t, err)
+		require.True(t, duty.HasDuty)
+		require.Equal(t, test.dutyType, duty.Type, "head slot %d", test.headSlot)
+		require.Equal(t, test.slot, duty.Slot, "head slot %d", test.headSlot)
+		require.Equal(t, time.Unix(mockGenesisTime+int64(test.slot)*12, 0), duty.Time)
+		require.Equal(t, test.headSlot, duty.HeadSlot)
+		require.Equal(t, uint64(383), duty.LookaheadEndSlot)
+	}
+}
+
+// Test that the next epoch's proposals are found when the Beacon Node knows them, and skipped when it doesn't
+func TestGetNextDuty_ProposerLookahead(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(40, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.HeadSlot = 350
+	sp := newTestServiceProvider(t, bn.URL, "")
+	pubkey := beacon.ValidatorPubkey(bn.Validators[0].Validator.Pubkey)
+	ctx := context.Background()
+
+	// Validator 0's duties in epoch 10 have passed; in epoch 11 it proposes slot 360 and attests in slot 363
+	require.Equal(t, uint64(330), getMockAttesterSlot(10, 0, 32))
+	require.Equal(t, uint64(363), getMockAttesterSlot(11, 0, 32))
+	require.Equal(t, 0, getMockProposerPosition(360, 40))
+	duty, err := sp.GetNextDuty(ctx, pubkey)
+	require.NoError(t, err)
+	require.Equal(t, common.DutyType_Proposal, duty.Type)
+	require.Equal(t, uint64(360), duty.Slot)
+
+	bn.ProposerLookahead = 0
+	duty, err = sp.GetNextDuty(ctx, pubkey)
+	require.NoError(t, err)
+	require.Equal(t, common.DutyType_Attestation, duty.Type)
+	require.Equal(t, uint64(363), duty.Slot)
+}
+
+// Test that a validator without duties is reported as having none
+func TestGetNextDuty_NoDuty(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(32, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.AddValidators(1, beacon.ValidatorState_PendingQueued, 32e9)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	duty, err := sp.GetNextDuty(context.Background(), beacon.ValidatorPubkey(bn.Validators[32].Validator.Pubkey))
+	require.NoError(t, err)
+	require.False(t, duty.HasDuty)
+	require.Equal(t, uint64(320), duty.HeadSlot)
+	require.Equal(t, uint64(383), duty.LookaheadEndSlot)
+	require.True(t, duty.Time.IsZero())
+
+	_, err = sp.GetNextDuty(context.Background(), beacon.ValidatorPubkey{0x01})
+	require.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,239 @@
+package common_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the NodeSet client logs in, caches its token, and refreshes it once it expires
+func TestNodeSetClient_TokenRefresh(t *testing.T) {
+	signer := newTestSigner(t)
+	server := newMockNodeSetServer(t, signer.address, 200*time.Millisecond)
+	defer server.Close()
+	client := common.NewNodeSetClient(server.URL, signer, time.Second)
+	ctx := context.Background()
+
+	// The first request should log in
+	var response map[string]string
+	err := client.Get(ctx, "data", &response)
+	require.NoError(t, err)
+	require.Equal(t, "ok", response["status"])
+	require.Equal(t, 1, server.getLoginCount())
+	t.Log("First request logged in and succeeded")
+
+	// The second request should reuse the cached token
+	err = client.Get(ctx, "data", &response)
+	require.NoError(t, err)
+	require.Equal(t, 1, server.getLoginCount())
+	t.Log("Second request reused the cached token")
+
+	// Once the token has expired, the client should log in again and retry the request
+	time.Sleep(300 * time.Millisecond)
+	err = client.Post(ctx, "data", map[string]string{"key": "value"}, &response)
+	require.NoError(t, err)
+	require.Equal(t, "ok", response["status"])
+	require.Equal(t, 2, server.getLoginCount())
+	require.Equal(t, 4, server.getDataCount())
+	t.Log("Expired token was refreshed and the request was retried")
+}
+
+// Test that NodeSet failures are surfaced as typed errors
+func TestNodeSetClient_TypedErrors(t *testing.T) {
+	signer := newTestSigner(t)
+	server := newMockNodeSetServer(t, signer.address, time.Minute)
+	defer server.Close()
+	client := common.NewNodeSetClient(server.URL, signer, time.Second)
+	ctx := context.Background()
+
+	err := client.Get(ctx, "rate-limited", nil)
+	require.ErrorIs(t, err, common.ErrNodeSetRateLimited)
+	var requestErr *common.NodeSetRequestError
+	require.True(t, errors.As(err, &requestErr))
+	require.Equal(t, http.StatusTooManyRequests, requestErr.StatusCode)
+	require.Equal(t, "slow down", requestErr.Message)
+
+	err = client.Get(ctx, "broken", nil)
+	require.ErrorIs(t, err, common.ErrNodeSetServerFailure)
+
+	// Other client errors shouldn't be reported as server failures
+	err = client.Get(ctx, "invalid", nil)
+	require.ErrorIs(t, err, common.ErrNodeSetBadRequest)
+	require.NotErrorIs(t, err, common.ErrNodeSetServerFailure)
+	require.True(t, errors.As(err, &requestErr))
+	require.Equal(t, http.StatusBadRequest, requestErr.StatusCode)
+	require.Equal(t, "bad body", requestErr.Message)
+
+	// A request that's always rejected should only be retried once, for both 401 and 403
+	err = client.Get(ctx, "rejected", nil)
+	require.ErrorIs(t, err, common.ErrNodeSetUnauthorized)
+	require.Equal(t, 2, server.getLoginCount())
+	err = client.Get(ctx, "forbidden", nil)
+	require.ErrorIs(t, err, common.ErrNodeSetUnauthorized)
+	require.True(t, errors.As(err, &requestErr))
+	require.Equal(t, http.StatusForbidden, requestErr.StatusCode)
+	require.Equal(t, 3, server.getLoginCount())
+	t.Log("Received the correct typed errors")
+}
+
+// Test that a wrong signature fails the login with an auth error
+func TestNodeSetClient_BadSignature(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+	server := newMockNodeSetServer(t, other.address, time.Minute)
+	defer server.Close()
+	client := common.NewNodeSetClient(server.URL, signer, time.Second)
+
+	err := client.Get(context.Background(), "data", nil)
+	require.ErrorIs(t, err, common.ErrNodeSetUnauthorized)
+	t.Log("Login with the wrong key was rejected")
+}
+
+// ===============
+// === Helpers ===
+// ===============
+
+// Signs messages with a random key
+type testSigner struct {
+	key     *ecdsa.PrivateKey
+	address ethcommon.Address
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return &testSigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+func (s *testSigner) GetAddress() (ethcommon.Address, bool) {
+	return s.address, true
+}
+
+func (s *testSigner) SignMessage(message []byte) ([]byte, error) {
+	signature, err := crypto.Sign(accounts.TextHash(message), s.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// A fake NodeSet server that issues tokens with a short lifespan
+type mockNodeSetServer struct {
+	*httptest.Server
+	t          *testing.T
+	address    ethcommon.Address
+	lifespan   time.Duration
+	tokens     map[string]time.Time
+	nonce      int
+	loginCount int
+	dataCount  int
+	lock       *sync.Mutex
+}
+
+func newMockNodeSetServer(t *testing.T, address ethcommon.Address, lifespan time.Duration) *mockNodeSetServer {
+	server := &mockNodeSetServer{
+		t:        t,
+		address:  address,
+		lifespan: lifespan,
+		tokens:   map[string]time.Time{},
+		lock:     &sync.Mutex{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nonce", server.handleNonce)
+	mux.HandleFunc("/login", server.handleLogin)
+	mux.HandleFunc("/data", server.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, map[string]string{"status": "ok"})
+	}))
+	mux.HandleFunc("/rate-limited", server.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusTooManyRequests, map[string]string{"error": "slow down"})
+	}))
+	mux.HandleFunc("/broken", server.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "oops"})
+	}))
+	mux.HandleFunc("/invalid", server.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "bad body"})
+	}))
+	mux.HandleFunc("/rejected", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "never allowed"})
+	})
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusForbidden, map[string]string{"error": "no access"})
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func (s *mockNodeSetServer) handleNonce(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nonce++
+	writeJson(w, http.StatusOK, map[string]string{"nonce": fmt.Sprint(s.nonce)})
+}
+
+func (s *mockNodeSetServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var request map[string]string
+	err := json.NewDecoder(r.Body).Decode(&request)
+	require.NoError(s.t, err)
+
+	// Verify the signature
+	message := fmt.Sprintf(`{"nonce":"%s","address":"%s"}`, request["nonce"], request["address"])
+	signature := ethcommon.FromHex(request["signature"])
+	signature[crypto.RecoveryIDOffset] -= 27
+	pubkey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), signature)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != s.address {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.loginCount++
+	token := fmt.Sprintf("token-%d", s.loginCount)
+	s.tokens[token] = time.Now().Add(s.lifespan)
+	writeJson(w, http.StatusOK, map[string]string{"token": token})
+}
+
+func (s *mockNodeSetServer) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		s.dataCount++
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		expiration, exists := s.tokens[token]
+		s.lock.Unlock()
+		if !exists || time.Now().After(expiration) {
+			writeJson(w, http.StatusUnauthorized, map[string]string{"error": "token expired"})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *mockNodeSetServer) getLoginCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.loginCount
+}
+
+func (s *mockNodeSetServer) getDataCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dataCount
+}
@@ -0,0 +1,74 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that an operation interrupted between steps is resumed by a new service provider without redoing the steps that
+// already finished
+func TestResumeOperations(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	ctx := context.Background()
+	steps := []common.Step{
+		{Handler: "record", Data: json.RawMessage(`"first"`)},
+		{Handler: "record", Data: json.RawMessage(`"second"`)},
+		{Handler: "record", Data: json.RawMessage(`"third"`)},
+	}
+
+	// Stop the first run after its first step, as if the daemon went down in the middle of the second
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	ran := []string{}
+	sp.RegisterStepHandler("record", func(ctx context.Context, data json.RawMessage) error {
+		var name string
+		require.NoError(t, json.Unmarshal(data, &name))
+		if name == "second" {
+			return errors.New("daemon stopped")
+		}
+		ran = append(ran, name)
+		return nil
+	})
+	_, err := sp.StartResumableOperation("../escape", steps)
+	require.Error(t, err)
+	_, err = sp.StartResumableOperation("other", []common.Step{{Handler: "missing"}})
+	require.ErrorIs(t, err, common.ErrUnknownStepHandler)
+
+	op, err := sp.StartResumableOperation("exit-validators", steps)
+	require.NoError(t, err)
+	require.ErrorContains(t, op.Run(ctx), "daemon stopped")
+	require.Equal(t, []string{"first"}, ran)
+	_, err = sp.StartResumableOperation("exit-validators", steps)
+	require.ErrorIs(t, err, common.ErrOperationExists)
+
+	// The restarted daemon picks up at the second step
+	restarted := newTestServiceProviderFromConfig(t, cfg)
+	ran = []string{}
+	restarted.RegisterStepHandler("record", func(ctx context.Context, data json.RawMessage) error {
+		var name string
+		require.NoError(t, json.Unmarshal(data, &name))
+		ran = append(ran, name)
+		return nil
+	})
+	resumed, err := restarted.ResumeOperations(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []common.ResumedOperation{
+		{ID: "exit-validators", ResumedFromStep: 1, CompletedSteps: 3, TotalSteps: 3, Completed: true},
+	}, resumed)
+	require.Equal(t, []string{"second", "third"}, ran)
+
+	// Finished operations are cleaned up, so there's nothing to resume again
+	entries, err := os.ReadDir(filepath.Join(cfg.GetUserDirectory(), hdconfig.OperationJournalDir))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	resumed, err = restarted.ResumeOperations(ctx)
+	require.NoError(t, err)
+	require.Empty(t, resumed)
+}
@@ -0,0 +1,170 @@
+package common_test
+
+import (
+	"os"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The standard Hardhat test mnemonic; its first account is 0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266
+	testMnemonic        string = "test test test test test test test test test test test junk"
+	testWalletPassword  string = "Password123"
+	ownershipProofNonce string = "ZB3qQ0G5lkX-6zj6Rer8RQ"
+)
+
+var (
+	testNodeAddress ethcommon.Address = ethcommon.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+
+	// Test vector for the nonce and address above on chain 1. NodeSet doesn't publish vectors for its verifier, so this
+	// is the test wallet's signature over the EIP-712 digest computed by hand in TestOwnershipProofVector_Eip712Digest.
+	ownershipProofVectorSignature string = "0xe38de8fa06ff305ca42f6acac22a74bfef85f0e9ff2c2cc3e1a20e0b7871a7f53f28767dbe645baa057ce9b9b244eb458be3e06ee080029db4238a901b9930231c"
+)
+
+// Test generating an ownership proof and matching it against the test vector
+func TestGenerateOwnershipProof(t *testing.T) {
+	sp := newOwnershipTestServiceProvider(t)
+
+	proof, err := sp.GenerateOwnershipProof(ownershipProofNonce)
+	require.NoError(t, err)
+	require.Equal(t, testNodeAddress, proof.Address)
+	require.Equal(t, ownershipProofNonce, proof.Nonce)
+	require.Equal(t, uint64(1), proof.ChainID)
+	require.Equal(t, ownershipProofVectorSignature, proof.Signature.String())
+
+	err = sp.VerifyOwnershipProof(proof, testNodeAddress)
+	require.NoError(t, err)
+}
+
+// Test that tampered or mismatched proofs are rejected
+func TestVerifyOwnershipProof_Invalid(t *testing.T) {
+	sp := newOwnershipTestServiceProvider(t)
+	signature, err := hexutil.Decode(ownershipProofVectorSignature)
+	require.NoError(t, err)
+	proof := common.OwnershipProof{
+		Address:   testNodeAddress,
+		Nonce:     ownershipProofNonce,
+		ChainID:   1,
+		Signature: signature,
+	}
+	require.NoError(t, sp.VerifyOwnershipProof(proof, testNodeAddress))
+
+	// Wrong expected address
+	other := ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	require.Error(t, sp.VerifyOwnershipProof(proof, other))
+
+	// A different nonce
+	tampered := proof
+	tampered.Nonce = "different"
+	require.Error(t, sp.VerifyOwnershipProof(tampered, testNodeAddress))
+
+	// A different chain
+	tampered = proof
+	tampered.ChainID = 17000
+	require.ErrorContains(t, sp.VerifyOwnershipProof(tampered, testNodeAddress), "chain")
+
+	// A truncated signature
+	tampered = proof
+	tampered.Signature = signature[:64]
+	require.ErrorContains(t, sp.VerifyOwnershipProof(tampered, testNodeAddress), "length")
+}
+
+// Creates a service provider with the test wallet loaded
+func newOwnershipTestServiceProvider(t *testing.T) *common.ServiceProvider {
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+	err := os.MkdirAll(sp.GetConfig().UserDataPath.Value, 0700)
+	require.NoError(t, err)
+	err = sp.GetWallet().Recover(wallet.DefaultNodeKeyPath, 0, testMnemonic, testWalletPassword, false, false)
+	require.NoError(t, err)
+	return sp
+}
+
+// Test the typed data signer against the "Ether Mail" example from the EIP-712 specification
+func TestSignTypedData_Eip712Vector(t *testing.T) {
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": []apitypes.Type{
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Person": []apitypes.Type{
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+			"Mail": []apitypes.Type{
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainId:           math.NewHexOrDecimal256(1),
+			VerifyingContract: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+		},
+		Message: apitypes.TypedDataMessage{
+			"from": map[string]interface{}{
+				"name":   "Cow",
+				"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+			},
+			"to": map[string]interface{}{
+				"name":   "Bob",
+				"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+			},
+			"contents": "Hello, Bob!",
+		},
+	}
+
+	// The signer in the example uses keccak256("cow") as its private key
+	keyBytes := crypto.Keccak256([]byte("cow"))
+	signature, err := common.SignTypedData(typedData, keyBytes)
+	require.NoError(t, err)
+	require.Equal(t, "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c", hexutil.Encode(signature))
+	require.Equal(t, make([]byte, len(keyBytes)), keyBytes, "private key bytes should be zeroed after signing")
+
+	signer, err := common.RecoverTypedDataSigner(typedData, signature)
+	require.NoError(t, err)
+	require.Equal(t, ethcommon.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"), signer)
+}
+
+// Test that the ownership proof test vector matches an EIP-712 digest built by hand from the specification, so the vector
+// doesn't only come from the code under test. This pins the domain and message layout NodeSet's verifier rebuilds:
+// EIP712Domain(string name,string version,uint256 chainId) with "NodeSet" and "1", and NodeOwnership(string nonce,address address).
+func TestOwnershipProofVector_Eip712Digest(t *testing.T) {
+	domainSeparator := crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId)")),
+		crypto.Keccak256([]byte("NodeSet")),
+		crypto.Keccak256([]byte("1")),
+		ethcommon.LeftPadBytes([]byte{1}, 32),
+	)
+	structHash := crypto.Keccak256(
+		crypto.Keccak256([]byte("NodeOwnership(string nonce,address address)")),
+		crypto.Keccak256([]byte(ownershipProofNonce)),
+		ethcommon.LeftPadBytes(testNodeAddress.Bytes(), 32),
+	)
+	digest := crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash)
+
+	// The test mnemonic's first key is Hardhat's first default account key
+	key, err := crypto.HexToECDSA("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	require.NoError(t, err)
+	signature, err := crypto.Sign(digest, key)
+	require.NoError(t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+	require.Equal(t, ownershipProofVectorSignature, hexutil.Encode(signature))
+
+	require.Equal(t, "NodeSet", common.OwnershipProofDomainName)
+	require.Equal(t, "1", common.OwnershipProofDomainVersion)
+	require.Equal(t, "NodeOwnership", common.OwnershipProofPrimaryType)
+}
@@ -0,0 +1,124 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+type mockActionProvider struct {
+	name    string
+	enabled bool
+	actions []common.PendingAction
+	err     error
+}
+
+func (p *mockActionProvider) GetModuleName() string {
+	return p.name
+}
+
+func (p *mockActionProvider) IsEnabled() bool {
+	return p.enabled
+}
+
+func (p *mockActionProvider) GetPendingActions(ctx context.Context) ([]common.PendingAction, error) {
+	return p.actions, p.err
+}
+
+// Creates a pending action that returns the provided hash when it's executed
+func newMockPendingAction(id string, description string, hash ethcommon.Hash) common.PendingAction {
+	return common.PendingAction{
+		ID:           id,
+		Description:  description,
+		EstimatedGas: 100000,
+		Execute: func(ctx context.Context) (ethcommon.Hash, error) {
+			return hash, nil
+		},
+	}
+}
+
+// Test merging the pending actions of two modules that share one, where a third module fails
+func TestGetPendingActions(t *testing.T) {
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+	claimHash := ethcommon.HexToHash("0x01")
+	stakeHash := ethcommon.HexToHash("0x02")
+	sp.RegisterActionProvider(&mockActionProvider{
+		name:    "stakewise",
+		enabled: true,
+		actions: []common.PendingAction{
+			newMockPendingAction("claim-rewards", "Claim 0.5 ETH of rewards", claimHash),
+		},
+	})
+	sp.RegisterActionProvider(&mockActionProvider{
+		name:    "constellation",
+		enabled: true,
+		actions: []common.PendingAction{
+			newMockPendingAction("claim-rewards", "Claim 0.5 ETH of rewards", claimHash),
+			newMockPendingAction("stake-minipool", "Stake minipool 0x1234", stakeHash),
+		},
+	})
+	sp.RegisterActionProvider(&mockActionProvider{
+		name:    "broken",
+		enabled: true,
+		err:     errors.New("contract call reverted"),
+	})
+	sp.RegisterActionProvider(&mockActionProvider{
+		name: "disabled",
+		actions: []common.PendingAction{
+			newMockPendingAction("disabled-action", "Never listed", ethcommon.Hash{}),
+		},
+	})
+
+	ctx := context.Background()
+	actions, err := sp.GetPendingActions(ctx)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	require.Equal(t, "claim-rewards", actions[0].ID)
+	require.Equal(t, "stakewise", actions[0].Module)
+	require.Equal(t, "stake-minipool", actions[1].ID)
+	require.Equal(t, "constellation", actions[1].Module)
+	require.Equal(t, uint64(100000), actions[1].EstimatedGas)
+	hash, err := actions[1].Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, stakeHash, hash)
+
+	result, err := sp.GetPendingActionsResult(ctx)
+	require.NoError(t, err)
+	require.Len(t, result.Actions, 2)
+	require.Equal(t, map[string]string{"broken": "contract call reverted"}, result.FailedModules)
+}
+
+// Test that a module reporting actions that can't be resolved is treated as failing
+func TestGetPendingActions_Invalid(t *testing.T) {
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+	sp.RegisterActionProvider(&mockActionProvider{
+		name:    "stakewise",
+		enabled: true,
+		actions: []common.PendingAction{
+			newMockPendingAction("claim-rewards", "Claim rewards", ethcommon.Hash{}),
+			{ID: "stake", Description: "Stake"},
+		},
+	})
+	sp.RegisterActionProvider(&mockActionProvider{
+		name:    "constellation",
+		enabled: true,
+		actions: []common.PendingAction{
+			{Description: "Missing ID", Execute: newMockPendingAction("", "", ethcommon.Hash{}).Execute},
+		},
+	})
+
+	result, err := sp.GetPendingActionsResult(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, result.Actions)
+	require.Contains(t, result.FailedModules["stakewise"], "can't be executed")
+	require.Contains(t, result.FailedModules["constellation"], "doesn't have an ID")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sp.GetPendingActions(cancelled)
+	require.ErrorIs(t, err, context.Canceled)
+}
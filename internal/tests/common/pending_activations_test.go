@@ -0,0 +1,122 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the activation estimates on Deneb, where the queue drains at 8 validators per epoch. The node owns a scheduled
+// validator, one at the front of the queue, one further back, and one that isn't eligible yet.
+func TestGetPendingActivations_Deneb(t *testing.T) {
+	bn := newPendingActivationsBeaconNode(t)
+	bn.AddQueuedValidators(40, 5)                                       // 200-239
+	bn.AddValidators(1, beacon.ValidatorState_PendingInitialized, 32e9) // 240
+	bn.ScheduleActivation(239, 13)
+	pubkeys := getMockValidatorPubkeys(bn)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: []beacon.ValidatorPubkey{pubkeys[240], pubkeys[230], pubkeys[0], pubkeys[200], pubkeys[239]},
+	})
+
+	activations, err := sp.GetPendingActivations(context.Background())
+	require.NoError(t, err)
+	require.Len(t, activations, 4)
+
+	// The scheduled validator is at the front with its assigned epoch
+	require.Equal(t, "239", activations[0].Index)
+	require.Equal(t, uint64(1), activations[0].Position)
+	require.True(t, activations[0].Scheduled)
+	require.Equal(t, uint64(13), activations[0].EstimatedEpoch)
+
+	// The front of the queue leaves it this epoch and is activated 5 epochs later, regardless of the queue's length
+	require.Equal(t, "200", activations[1].Index)
+	require.Equal(t, uint64(2), activations[1].Position)
+	require.False(t, activations[1].Scheduled)
+	require.Equal(t, uint64(15), activations[1].EstimatedEpoch)
+
+	// 31st in line waits for 3 more epochs of churn
+	require.Equal(t, "230", activations[2].Index)
+	require.Equal(t, uint64(32), activations[2].Position)
+	require.Equal(t, uint64(18), activations[2].EstimatedEpoch)
+
+	// The validator that isn't eligible yet joins the back of the queue
+	require.Equal(t, "240", activations[3].Index)
+	require.Equal(t, uint64(41), activations[3].Position)
+	require.Equal(t, uint64(19), activations[3].EstimatedEpoch)
+
+	for _, activation := range activations {
+		expected := time.Unix(mockGenesisTime, 0).Add(time.Duration(activation.EstimatedEpoch*32*12) * time.Second)
+		require.True(t, expected.Equal(activation.EstimatedTime))
+	}
+	t.Logf("Estimated %d activations, the last at epoch %d", len(activations), activations[3].EstimatedEpoch)
+}
+
+// Test that a validator whose deposit is still pending on Electra is estimated from the deposit balance ahead of it
+func TestGetPendingActivations_ElectraDeposit(t *testing.T) {
+	bn := newPendingActivationsBeaconNode(t)
+	bn.ActivateElectra()
+	bn.AddQueuedValidators(2, 9) // 200-201
+	for i := 0; i < 10; i++ {
+		other := beacon.ValidatorPubkey{0xbb, byte(i)}
+		bn.PendingDeposits = append(bn.PendingDeposits, common.BeaconPendingDeposit{Pubkey: other[:], Amount: 32e9})
+	}
+	deposited := beacon.ValidatorPubkey{0xee}
+	bn.PendingDeposits = append(bn.PendingDeposits, common.BeaconPendingDeposit{Pubkey: deposited[:], Amount: 32e9})
+	bn.PendingDeposits = append(bn.PendingDeposits, common.BeaconPendingDeposit{Pubkey: deposited[:], Amount: 1e9})
+	pubkeys := getMockValidatorPubkeys(bn)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: []beacon.ValidatorPubkey{deposited, pubkeys[201]},
+	})
+
+	activations, err := sp.GetPendingActivations(context.Background())
+	require.NoError(t, err)
+	require.Len(t, activations, 2)
+
+	// Eligible validators aren't rate limited on Electra, so this one only waits for its eligibility to finalize
+	require.Equal(t, "201", activations[0].Index)
+	require.Equal(t, uint64(2), activations[0].Position)
+	require.Equal(t, uint64(16), activations[0].EstimatedEpoch)
+
+	// 352 ETH of deposits at 256 ETH per epoch are processed next epoch, then it becomes eligible and finalizes
+	require.Equal(t, deposited, activations[1].Pubkey)
+	require.Empty(t, activations[1].Index)
+	require.Equal(t, uint64(13), activations[1].Position)
+	require.Equal(t, uint64(20), activations[1].EstimatedEpoch)
+}
+
+// Test that a node without pending validators doesn't have any activations
+func TestGetPendingActivations_NonePending(t *testing.T) {
+	bn := newPendingActivationsBeaconNode(t)
+	bn.AddQueuedValidators(10, 5)
+	pubkeys := getMockValidatorPubkeys(bn)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys[:5],
+	})
+
+	activations, err := sp.GetPendingActivations(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, activations)
+}
+
+// Creates a mock Beacon Node at epoch 10 with 200 active validators and a small churn quotient, so the activation churn
+// is held at the Deneb cap of 8
+func newPendingActivationsBeaconNode(t *testing.T) *mockBeaconNode {
+	bn := newMockBeaconNode(t)
+	bn.Spec["CHURN_LIMIT_QUOTIENT"] = "16"
+	bn.AddValidators(200, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.AssignCommittees(10)
+	return bn
+}
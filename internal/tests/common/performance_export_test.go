@@ -0,0 +1,84 @@
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// A writer that counts how many times it's written to
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+// Test that the exported CSV parses back into a row per module validator per epoch with the expected values
+func TestExportPerformanceCSV(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = 16
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.SetBalanceHistory([]uint64{4, 8, 12}, getMockHistoricalBalance)
+	pubkeys := getMockValidatorPubkeys(bn)
+
+	// Validator 2 isn't the node's, but it shares the committees
+	bn.Committees[1] = []common.BeaconCommittee{{Index: 0, Slot: 5, Validators: []string{"0", "1", "2"}}}
+	bn.Committees[2] = []common.BeaconCommittee{{Index: 0, Slot: 9, Validators: []string{"1", "0", "2"}}}
+	bn.Committees[3] = []common.BeaconCommittee{{Index: 0, Slot: 14, Validators: []string{"0", "2"}}}
+	bn.Attestations[6] = []common.BeaconAttestation{newTestAttestation(5, 0, "0x0b")}
+	bn.Attestations[11] = []common.BeaconAttestation{newTestAttestation(9, 0, "0x09")}
+	bn.Attestations[15] = []common.BeaconAttestation{newTestAttestation(14, 0, "0x05")}
+	bn.AddWithdrawals(7, 0)
+	bn.AddWithdrawals(10, 0, 1, 2)
+
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys[:2],
+	})
+
+	output := &countingWriter{}
+	err := sp.ExportPerformanceCSV(context.Background(), output, 1, 3)
+	require.NoError(t, err)
+	records, err := csv.NewReader(bytes.NewReader(output.Bytes())).ReadAll()
+	require.NoError(t, err)
+
+	// The header is part of the format, so it's checked against a literal
+	require.Equal(t, []string{
+		"epoch", "validator_index", "pubkey", "balance_gwei", "attestation_duties", "attestations_included",
+		"inclusion_distance", "participation", "withdrawals", "withdrawn_gwei",
+	}, records[0])
+	balance := func(index int, slot uint64) string {
+		return fmt.Sprint(getMockHistoricalBalance(index, slot))
+	}
+	pk0 := pubkeys[0].HexWithPrefix()
+	pk1 := pubkeys[1].HexWithPrefix()
+	require.Equal(t, [][]string{
+		{"1", "0", pk0, balance(0, 4), "1", "1", "1", "1", "1", "10000000"},
+		{"1", "1", pk1, balance(1, 4), "1", "1", "1", "1", "0", "0"},
+		{"2", "0", pk0, balance(0, 8), "1", "0", "", "0", "1", "10000000"},
+		{"2", "1", pk1, balance(1, 8), "1", "1", "2", "1", "1", "10000000"},
+		{"3", "0", pk0, balance(0, 12), "1", "1", "1", "1", "0", "0"},
+		{"3", "1", pk1, balance(1, 12), "0", "0", "", "", "0", "0"},
+	}, records[1:])
+
+	// The header and each epoch are flushed separately rather than all at the end
+	require.Equal(t, 4, output.writes)
+	t.Logf("Exported %d rows", len(records)-1)
+
+	// The last epoch has to be complete
+	err = sp.ExportPerformanceCSV(context.Background(), &bytes.Buffer{}, 1, 4)
+	require.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/osha/docker"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that an occupied port is reported as a conflict with its owner, while ports the service's own container has
+// published aren't
+func TestCheckPortBindings(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ClientMode.Value = config.ClientMode_Local
+	cfg.LocalBeaconClient.BeaconNode.Value = config.BeaconNode_Teku
+	cfg.MevBoost.Enable.Value = false
+	cfg.Metrics.EnableMetrics.Value = false
+
+	// Occupy the EC's P2P port
+	ecPort := getFreeTestPort(t)
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", ecPort))
+	require.NoError(t, err)
+	defer listener.Close()
+	cfg.LocalExecutionClient.P2pPort.Value = ecPort
+
+	// Have the BN's running container publish its P2P port, which is also held here as Docker's proxy would
+	bnPort := getFreeTestPort(t)
+	bnListener, err := net.Listen("tcp", fmt.Sprintf(":%d", bnPort))
+	require.NoError(t, err)
+	defer bnListener.Close()
+	cfg.LocalBeaconClient.P2pPort.Value = bnPort
+	cfg.LocalBeaconClient.HttpPort.Value = getFreeTestPort(t)
+	cfg.LocalBeaconClient.OpenHttpPort.Value = config.RpcPortMode_OpenLocalhost
+
+	mock := &labeledDockerClient{
+		DockerMockManager: docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil))),
+	}
+	var size int64
+	bnBinding := []nat.PortBinding{{HostPort: fmt.Sprint(bnPort)}}
+	err = mock.Mock_AddContainer(dtypes.ContainerJSON{
+		ContainerJSONBase: &dtypes.ContainerJSONBase{
+			ID:      "bn",
+			Name:    cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode)),
+			Created: time.Now().Format(time.RFC3339),
+			State: &dtypes.ContainerState{
+				Status:     "running",
+				Running:    true,
+				StartedAt:  time.Now().Format(time.RFC3339Nano),
+				FinishedAt: time.Time{}.Format(time.RFC3339Nano),
+			},
+			HostConfig: &container.HostConfig{},
+			SizeRw:     &size,
+			SizeRootFs: &size,
+		},
+		Config: &container.Config{Labels: map[string]string{hdconfig.InstanceLabel: cfg.ProjectName.Value}},
+		NetworkSettings: &dtypes.NetworkSettings{
+			NetworkSettingsBase: dtypes.NetworkSettingsBase{
+				Ports: nat.PortMap{
+					nat.Port(fmt.Sprintf("%d/tcp", bnPort)): bnBinding,
+					nat.Port(fmt.Sprintf("%d/udp", bnPort)): bnBinding,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	sp := newDockerTestServiceProviderWithUrls(t, cfg, mock, "http://127.0.0.1:1", "http://127.0.0.1:1")
+
+	checks, err := sp.CheckPortBindings(context.Background())
+	require.NoError(t, err)
+	require.Len(t, checks, 5)
+	byKey := map[string]common.PortCheck{}
+	for _, check := range checks {
+		byKey[fmt.Sprintf("%s %s %s", check.Service, check.Name, check.Protocol)] = check
+	}
+
+	// The occupied TCP port is a conflict, but UDP on the same port is free
+	ecTcp := byKey["ec P2P tcp"]
+	require.True(t, ecTcp.Conflict)
+	require.False(t, ecTcp.Published)
+	require.Empty(t, ecTcp.Error)
+	_, err = os.Stat("/proc/net/tcp")
+	if err == nil {
+		require.Equal(t, os.Getpid(), ecTcp.OwnerPid)
+		require.NotEmpty(t, ecTcp.OwnerProcess)
+		t.Logf("Port %d is held by %s (%d)", ecPort, ecTcp.OwnerProcess, ecTcp.OwnerPid)
+	} else {
+		require.Zero(t, ecTcp.OwnerPid)
+	}
+	require.False(t, byKey["ec P2P udp"].Conflict)
+
+	// The BN's ports are published by its own container, so they're fine even though they're held
+	for _, protocol := range []string{"tcp", "udp"} {
+		bnCheck := byKey["bn P2P "+protocol]
+		require.True(t, bnCheck.Published)
+		require.False(t, bnCheck.Conflict)
+	}
+	bnHttp := byKey["bn HTTP API tcp"]
+	require.Equal(t, "127.0.0.1", bnHttp.HostIp)
+	require.False(t, bnHttp.Conflict)
+}
+
+// Gets a port that's free for both TCP and UDP. The Docker mock can only parse host ports up to 32767, so this doesn't
+// use an ephemeral port.
+func getFreeTestPort(t *testing.T) uint16 {
+	for port := 20000 + os.Getpid()%5000; port < 32768; port++ {
+		address := fmt.Sprintf(":%d", port)
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			continue
+		}
+		conn, err := net.ListenPacket("udp", address)
+		listener.Close()
+		if err != nil {
+			continue
+		}
+		conn.Close()
+		return uint16(port)
+	}
+	t.Fatal("couldn't find a free port")
+	return 0
+}
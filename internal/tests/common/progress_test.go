@@ -0,0 +1,79 @@
+package common_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// A progress callback invocation
+type progressUpdate struct {
+	current int
+	total   int
+	message string
+}
+
+// Records progress callbacks, failing the test if any of them overlap
+type progressRecorder struct {
+	t       *testing.T
+	active  atomic.Bool
+	updates []progressUpdate
+}
+
+func (r *progressRecorder) report(current int, total int, message string) {
+	require.True(r.t, r.active.CompareAndSwap(false, true), "progress callbacks overlapped")
+	defer r.active.Store(false)
+	r.updates = append(r.updates, progressUpdate{current: current, total: total, message: message})
+}
+
+// Test that importing keystores reports each checked keystore and then the import
+func TestImportKeystores_Progress(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	dir := t.TempDir()
+	writeTestKeystore(t, dir, "a-first.json", keystorePassword, nil)
+	writeTestKeystore(t, dir, "b-second.json", keystorePassword, nil)
+	writeTestKeystore(t, dir, "c-wrong-password.json", "another-password", nil)
+
+	recorder := &progressRecorder{t: t}
+	_, err := sp.ImportKeystores(context.Background(), dir, keystorePassword, recorder.report)
+	require.NoError(t, err)
+	require.Equal(t, []progressUpdate{
+		{current: 1, total: 4, message: "Checked a-first.json"},
+		{current: 2, total: 4, message: "Checked b-second.json"},
+		{current: 3, total: 4, message: "Checked c-wrong-password.json"},
+		{current: 4, total: 4, message: "Imported 2 keystores into the Validator Client"},
+	}, recorder.updates)
+}
+
+// Test that setting fee recipients across several batches reports every update in order
+func TestSetFeeRecipients_Progress(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	pubkeys := addFeeRecipientTestKeys(keyManager, 70)
+	recipients := map[beacon.ValidatorPubkey]ethcommon.Address{
+		{0xff}: testFeeRecipient,
+	}
+	for _, pubkey := range pubkeys {
+		recipients[pubkey] = testFeeRecipient
+	}
+
+	recorder := &progressRecorder{t: t}
+	result, err := sp.SetFeeRecipients(context.Background(), recipients, recorder.report)
+	require.NoError(t, err)
+	require.Len(t, recorder.updates, len(recipients))
+	failed := 0
+	for i, update := range recorder.updates {
+		require.Equal(t, i+1, update.current)
+		require.Equal(t, len(recipients), update.total)
+		if update.message == "Failed to set the fee recipient for "+(beacon.ValidatorPubkey{0xff}).HexWithPrefix() {
+			failed++
+		}
+	}
+	require.Equal(t, 1, failed)
+	require.Equal(t, 1, result.FailedCount)
+}
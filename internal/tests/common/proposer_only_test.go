@@ -0,0 +1,74 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the proposer-only flag for each Validator Client, and the warnings for setups it won't work in
+func TestGetVcProposerOnlyFlags(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Lighthouse
+	require.Empty(t, cfg.GetVcProposerOnlyFlags())
+	cfg.KeyManager.ProposerOnly.Value = true
+
+	for _, test := range []struct {
+		client config.BeaconNode
+		flags  []string
+	}{
+		{client: config.BeaconNode_Lighthouse, flags: []string{"--disable-attesting"}},
+		{client: config.BeaconNode_Lodestar, flags: []string{}},
+		{client: config.BeaconNode_Nimbus, flags: []string{}},
+		{client: config.BeaconNode_Prysm, flags: []string{}},
+		{client: config.BeaconNode_Teku, flags: []string{}},
+	} {
+		cfg.ExternalBeaconClient.BeaconNode.Value = test.client
+		require.Equal(t, test.flags, cfg.GetVcProposerOnlyFlags(), test.client)
+	}
+
+	// Without an attestation VC, the validators would stop attesting entirely
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Lighthouse
+	warnings := cfg.GetWarnings()
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "attestation Validator Client")
+	cfg.KeyManager.AttestationVcUrl.Value = "http://192.168.1.20:5062"
+	require.Empty(t, cfg.GetWarnings())
+}
+
+// Test turning proposer-only mode on and off, which marks the VC for a restart
+func TestSetProposerOnlyMode(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Lighthouse
+	cfg.KeyManager.AttestationVcUrl.Value = "http://192.168.1.20:5062"
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	require.False(t, sp.GetProposerOnlyMode())
+
+	require.NoError(t, sp.SetProposerOnlyMode(false))
+	require.Empty(t, sp.GetContainersPendingRestart())
+	require.NoError(t, sp.SetProposerOnlyMode(true))
+	require.True(t, sp.GetProposerOnlyMode())
+	require.Equal(t, []config.ContainerID{config.ContainerID_ValidatorClient}, sp.GetContainersPendingRestart())
+	require.Equal(t, []string{"--disable-attesting"}, cfg.GetVcProposerOnlyFlags())
+}
+
+// Test that Validator Clients without the mode reject it, but can still have it turned off
+func TestSetProposerOnlyMode_Unsupported(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Teku
+	sp := newTestServiceProviderFromConfig(t, cfg)
+
+	err := sp.SetProposerOnlyMode(true)
+	require.ErrorIs(t, err, common.ErrUnsupportedByClient)
+	require.False(t, sp.GetProposerOnlyMode())
+	require.Empty(t, sp.GetContainersPendingRestart())
+	require.NoError(t, sp.SetProposerOnlyMode(false))
+
+	// Editing the config directly still gets caught
+	cfg.KeyManager.ProposerOnly.Value = true
+	warnings := cfg.GetWarnings()
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "teku")
+}
@@ -0,0 +1,329 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/prysmaticlabs/prysm/v5/consensus-types/primitives"
+	ethpb "github.com/prysmaticlabs/prysm/v5/proto/prysm/v1alpha1"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// A stub of Prysm's gRPC API, serving a fixed chain and recording the calls made to it
+type stubPrysmGrpc struct {
+	URL string
+
+	headEpoch  uint64
+	validators []*ethpb.Validators_ValidatorContainer
+	exits      []*ethpb.SignedVoluntaryExit
+	calls      []string
+	lock       *sync.Mutex
+}
+
+type stubPrysmNodeServer struct {
+	ethpb.UnimplementedNodeServer
+	stub *stubPrysmGrpc
+}
+
+type stubPrysmBeaconChainServer struct {
+	ethpb.UnimplementedBeaconChainServer
+	stub *stubPrysmGrpc
+}
+
+type stubPrysmValidatorServer struct {
+	ethpb.UnimplementedBeaconNodeValidatorServer
+	stub *stubPrysmGrpc
+}
+
+// Starts a stub Prysm gRPC server with an active, a pending, and an exited validator at epoch 10
+func newStubPrysmGrpc(t *testing.T) *stubPrysmGrpc {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	newValidator := func(index uint64, activationEligibility uint64, activation uint64, exit uint64, withdrawable uint64) *ethpb.Validators_ValidatorContainer {
+		pubkey := make([]byte, beacon.ValidatorPubkeyLength)
+		pubkey[0] = 0xbb
+		pubkey[1] = byte(index)
+		return &ethpb.Validators_ValidatorContainer{
+			Index: primitives.ValidatorIndex(index),
+			Validator: &ethpb.Validator{
+				PublicKey:                  pubkey,
+				WithdrawalCredentials:      make([]byte, 32),
+				EffectiveBalance:           32e9,
+				ActivationEligibilityEpoch: primitives.Epoch(activationEligibility),
+				ActivationEpoch:            primitives.Epoch(activation),
+				ExitEpoch:                  primitives.Epoch(exit),
+				WithdrawableEpoch:          primitives.Epoch(withdrawable),
+			},
+		}
+	}
+	stub := &stubPrysmGrpc{
+		URL:       listener.Addr().String(),
+		headEpoch: 10,
+		validators: []*ethpb.Validators_ValidatorContainer{
+			newValidator(0, 0, 0, common.FarFutureEpoch, common.FarFutureEpoch),
+			newValidator(1, 5, common.FarFutureEpoch, common.FarFutureEpoch, common.FarFutureEpoch),
+			newValidator(2, 0, 0, 5, 300),
+		},
+		lock: &sync.Mutex{},
+	}
+
+	server := grpc.NewServer()
+	ethpb.RegisterNodeServer(server, &stubPrysmNodeServer{stub: stub})
+	ethpb.RegisterBeaconChainServer(server, &stubPrysmBeaconChainServer{stub: stub})
+	ethpb.RegisterBeaconNodeValidatorServer(server, &stubPrysmValidatorServer{stub: stub})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return stub
+}
+
+// Records a call to the stub
+func (s *stubPrysmGrpc) record(method string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = append(s.calls, method)
+}
+
+// Gets the calls made to the stub so far
+func (s *stubPrysmGrpc) getCalls() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.calls...)
+}
+
+// Gets the voluntary exits submitted to the stub so far
+func (s *stubPrysmGrpc) getExits() []*ethpb.SignedVoluntaryExit {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*ethpb.SignedVoluntaryExit{}, s.exits...)
+}
+
+func (s *stubPrysmNodeServer) GetSyncStatus(ctx context.Context, _ *emptypb.Empty) (*ethpb.SyncStatus, error) {
+	s.stub.record("GetSyncStatus")
+	return &ethpb.SyncStatus{Syncing: false}, nil
+}
+
+func (s *stubPrysmNodeServer) GetGenesis(ctx context.Context, _ *emptypb.Empty) (*ethpb.Genesis, error) {
+	s.stub.record("GetGenesis")
+	return &ethpb.Genesis{
+		GenesisTime:           timestamppb.New(timestamppb.Now().AsTime().Add(-1000 * 12e9)),
+		GenesisValidatorsRoot: make([]byte, 32),
+	}, nil
+}
+
+func (s *stubPrysmBeaconChainServer) GetChainHead(ctx context.Context, _ *emptypb.Empty) (*ethpb.ChainHead, error) {
+	s.stub.record("GetChainHead")
+	return &ethpb.ChainHead{
+		HeadSlot:               primitives.Slot(s.stub.headEpoch * 32),
+		HeadEpoch:              primitives.Epoch(s.stub.headEpoch),
+		FinalizedEpoch:         primitives.Epoch(s.stub.headEpoch - 2),
+		JustifiedEpoch:         primitives.Epoch(s.stub.headEpoch - 1),
+		PreviousJustifiedEpoch: primitives.Epoch(s.stub.headEpoch - 2),
+	}, nil
+}
+
+func (s *stubPrysmBeaconChainServer) GetBeaconConfig(ctx context.Context, _ *emptypb.Empty) (*ethpb.BeaconConfig, error) {
+	s.stub.record("GetBeaconConfig")
+	return &ethpb.BeaconConfig{
+		Config: map[string]string{
+			"SecondsPerSlot":               "12",
+			"SlotsPerEpoch":                "32",
+			"EpochsPerSyncCommitteePeriod": "256",
+			"GenesisForkVersion":           "[1 0 0 0]",
+			"CapellaForkVersion":           "[4 0 0 0]",
+			"DepositChainID":               "17000",
+			"DepositContractAddress":       "0x4242424242424242424242424242424242424242",
+		},
+	}, nil
+}
+
+func (s *stubPrysmBeaconChainServer) ListValidators(ctx context.Context, request *ethpb.ListValidatorsRequest) (*ethpb.Validators, error) {
+	s.stub.record("ListValidators")
+	response := &ethpb.Validators{
+		Epoch: primitives.Epoch(s.stub.headEpoch),
+	}
+	for _, container := range s.stub.validators {
+		for _, pubkey := range request.PublicKeys {
+			if string(pubkey) == string(container.Validator.PublicKey) {
+				response.ValidatorList = append(response.ValidatorList, container)
+			}
+		}
+		for _, index := range request.Indices {
+			if index == container.Index {
+				response.ValidatorList = append(response.ValidatorList, container)
+			}
+		}
+	}
+	return response, nil
+}
+
+func (s *stubPrysmBeaconChainServer) ListValidatorBalances(ctx context.Context, request *ethpb.ListValidatorBalancesRequest) (*ethpb.ValidatorBalances, error) {
+	s.stub.record("ListValidatorBalances")
+	response := &ethpb.ValidatorBalances{
+		Epoch: primitives.Epoch(s.stub.headEpoch),
+	}
+	for _, index := range request.Indices {
+		response.Balances = append(response.Balances, &ethpb.ValidatorBalances_Balance{
+			Index:   index,
+			Balance: 32e9 + uint64(index),
+		})
+	}
+	return response, nil
+}
+
+func (s *stubPrysmValidatorServer) ProposeExit(ctx context.Context, exit *ethpb.SignedVoluntaryExit) (*ethpb.ProposeExitResponse, error) {
+	s.stub.record("ProposeExit")
+	s.stub.lock.Lock()
+	defer s.stub.lock.Unlock()
+	s.stub.exits = append(s.stub.exits, exit)
+	return &ethpb.ProposeExitResponse{}, nil
+}
+
+// Creates a config for an external Prysm node that uses the REST API at the provided URL and the gRPC API at the provided target
+func newPrysmTestConfig(t *testing.T, mode hdconfig.PrysmApiMode, httpUrl string, grpcUrl string) *hdconfig.HyperdriveConfig {
+	cfg := newTestConfig(t, httpUrl, "")
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Prysm
+	cfg.ExternalBeaconClient.PrysmRpcUrl.Value = grpcUrl
+	cfg.PrysmApiMode.Value = mode
+	return cfg
+}
+
+// Test that REST mode keeps using the Beacon REST API even when Prysm's gRPC API is available
+func TestPrysmApiMode_Rest(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	stub := newStubPrysmGrpc(t)
+	cfg := newPrysmTestConfig(t, hdconfig.PrysmApiMode_Rest, bn.URL, stub.URL)
+	require.Empty(t, cfg.Validate())
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	syncStatus, err := sp.GetBeaconClient().GetSyncStatus(ctx)
+	require.NoError(t, err)
+	require.False(t, syncStatus.Syncing)
+	require.Empty(t, stub.getCalls())
+	t.Log("Sync status was read from the REST API without touching gRPC")
+}
+
+// Test that gRPC mode translates the beacon client's calls into Prysm's gRPC API
+func TestPrysmApiMode_Grpc(t *testing.T) {
+	stub := newStubPrysmGrpc(t)
+	cfg := newPrysmTestConfig(t, hdconfig.PrysmApiMode_Grpc, "http://127.0.0.1:1", stub.URL)
+	require.Empty(t, cfg.Validate())
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	bc := sp.GetBeaconClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Sync status
+	syncStatus, err := bc.GetSyncStatus(ctx)
+	require.NoError(t, err)
+	require.False(t, syncStatus.Syncing)
+	require.Contains(t, stub.getCalls(), "GetSyncStatus")
+	t.Log("Sync status was read over gRPC")
+
+	// Chain config, which needs the spec and the genesis info
+	eth2Config, err := bc.GetEth2Config(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), eth2Config.SecondsPerSlot)
+	require.Equal(t, uint64(32), eth2Config.SlotsPerEpoch)
+	require.Equal(t, []byte{1, 0, 0, 0}, eth2Config.GenesisForkVersion)
+	depositContract, err := bc.GetEth2DepositContract(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(17000), depositContract.ChainID)
+	t.Log("Chain config was translated from Prysm's beacon config")
+
+	// Validator statuses are derived from the validator fields the same way the REST API does it. This uses the provider
+	// directly since the standard client's batched status lookup can't make progress on a single-core machine.
+	provider, err := common.NewPrysmGrpcProvider(stub.URL, time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = provider.Close()
+	})
+	validators, err := provider.Beacon_Validators(ctx, "head", []string{"0", "1", "2", "99"})
+	require.NoError(t, err)
+	require.Len(t, validators.Data, 3)
+	expectedStates := []beacon.ValidatorState{
+		beacon.ValidatorState_ActiveOngoing,
+		beacon.ValidatorState_PendingQueued,
+		beacon.ValidatorState_ExitedUnslashed,
+	}
+	for i, expectedState := range expectedStates {
+		validator := validators.Data[i]
+		require.Equal(t, fmt.Sprint(i), validator.Index)
+		require.Equal(t, stub.validators[i].Validator.PublicKey, []byte(validator.Validator.Pubkey))
+		require.Equal(t, string(expectedState), validator.Status)
+		require.Equal(t, uint64(32e9+i), uint64(validator.Balance))
+	}
+	t.Log("Validator statuses were read over gRPC")
+
+	// Exits are submitted through the validator service
+	err = bc.ExitValidator(ctx, "0", 12, beacon.ValidatorSignature{0x01})
+	require.NoError(t, err)
+	exits := stub.getExits()
+	require.Len(t, exits, 1)
+	require.Equal(t, primitives.ValidatorIndex(0), exits[0].Exit.ValidatorIndex)
+	require.Equal(t, primitives.Epoch(12), exits[0].Exit.Epoch)
+	t.Log("Voluntary exit was submitted over gRPC")
+
+	// Blocks don't have a gRPC equivalent
+	_, _, err = bc.GetBeaconBlock(ctx, "head")
+	require.ErrorIs(t, err, common.ErrPrysmGrpcUnsupported)
+	t.Log("Unsupported operations fail with a clear error")
+}
+
+// Test that gRPC mode is rejected for other clients and for URLs that point at Prysm's HTTP port
+func TestPrysmApiMode_Validation(t *testing.T) {
+	// Not Prysm
+	cfg := newPrysmTestConfig(t, hdconfig.PrysmApiMode_Grpc, "http://192.168.1.10:5052", "192.168.1.10:5053")
+	cfg.ExternalBeaconClient.BeaconNode.Value = config.BeaconNode_Lighthouse
+	require.Len(t, cfg.Validate(), 1)
+	require.False(t, cfg.UsePrysmGrpc())
+	t.Logf("gRPC mode with Lighthouse was rejected: %s", cfg.Validate()[0])
+
+	// gRPC URL on the HTTP port
+	cfg = newPrysmTestConfig(t, hdconfig.PrysmApiMode_Grpc, "http://192.168.1.10:5052", "http://192.168.1.10:5052")
+	require.Len(t, cfg.Validate(), 1)
+	t.Logf("gRPC URL on the HTTP port was rejected: %s", cfg.Validate()[0])
+
+	// gRPC URL without a port
+	cfg = newPrysmTestConfig(t, hdconfig.PrysmApiMode_Grpc, "http://192.168.1.10:5052", "192.168.1.10")
+	require.Len(t, cfg.Validate(), 1)
+	t.Logf("gRPC URL without a port was rejected: %s", cfg.Validate()[0])
+
+	// Local Prysm with the RPC port on top of the HTTP port
+	cfg = newPrysmTestConfig(t, hdconfig.PrysmApiMode_Grpc, "", "")
+	cfg.ClientMode.Value = config.ClientMode_Local
+	cfg.LocalBeaconClient.BeaconNode.Value = config.BeaconNode_Prysm
+	require.Empty(t, cfg.Validate())
+	cfg.LocalBeaconClient.Prysm.RpcPort.Value = cfg.LocalBeaconClient.HttpPort.Value
+	require.Len(t, cfg.Validate(), 1)
+	t.Logf("Local Prysm with overlapping ports was rejected: %s", cfg.Validate()[0])
+
+	// The local container's RPC port is the one that gets dialed
+	cfg.LocalBeaconClient.Prysm.RpcPort.Value = 6000
+	primary, fallback, err := cfg.GetPrysmGrpcTargets()
+	require.NoError(t, err)
+	require.Equal(t, "bn:6000", primary)
+	require.Empty(t, fallback)
+
+	// Valid external config
+	cfg = newPrysmTestConfig(t, hdconfig.PrysmApiMode_Grpc, "http://192.168.1.10:5052", "http://192.168.1.10:5053")
+	require.Empty(t, cfg.Validate())
+	primary, _, err = cfg.GetPrysmGrpcTargets()
+	require.NoError(t, err)
+	require.Equal(t, "192.168.1.10:5053", primary)
+}
@@ -0,0 +1,87 @@
+package common_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+// Test reconciling a keystore directory that's out of sync with the VC in every way
+func TestReconcileKeystores(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	cfg := sp.GetConfig()
+	err := os.MkdirAll(cfg.UserDataPath.Value, 0700)
+	require.NoError(t, err)
+	err = sp.GetWallet().Recover(wallet.DefaultNodeKeyPath, 0, testMnemonic, keystorePassword, true, false)
+	require.NoError(t, err)
+
+	// Make a directory with a loaded key, a missing key, and a key that can't be decrypted, and load a key with no keystore
+	dir := cfg.GetKeystoreDirectory()
+	err = os.MkdirAll(dir, 0700)
+	require.NoError(t, err)
+	loaded := writeTestKeystore(t, dir, "a-loaded.json", keystorePassword, nil)
+	missing := writeTestKeystore(t, dir, "b-missing.json", keystorePassword, nil)
+	wrongPassword := writeTestKeystore(t, dir, "c-wrong-password.json", "another-password", nil)
+	loadedPubkey := readTestKeystorePubkey(t, loaded)
+	missingPubkey := readTestKeystorePubkey(t, missing)
+	extraneousPubkey := readTestKeystorePubkey(t, writeTestKeystore(t, t.TempDir(), "extraneous.json", keystorePassword, nil))
+	keyManager.Keystores[loadedPubkey] = "{}"
+	keyManager.Keystores[extraneousPubkey] = "{}"
+
+	checkDiscrepancies := func(result common.ReconcileKeystoreResult, missingAction common.KeystoreReconcileAction) {
+		require.Equal(t, 1, result.ConsistentCount)
+		require.Len(t, result.Discrepancies, 3)
+		require.Equal(t, missing, result.Discrepancies[0].Path)
+		require.Equal(t, missingPubkey, result.Discrepancies[0].Pubkey)
+		require.Equal(t, common.KeystoreDiscrepancyType_NotLoaded, result.Discrepancies[0].Type)
+		require.Equal(t, missingAction, result.Discrepancies[0].Action)
+		require.Equal(t, wrongPassword, result.Discrepancies[1].Path)
+		require.Equal(t, common.KeystoreDiscrepancyType_Invalid, result.Discrepancies[1].Type)
+		require.Equal(t, common.KeystoreReconcileAction_None, result.Discrepancies[1].Action)
+		require.NotEmpty(t, result.Discrepancies[1].Error)
+		require.Equal(t, extraneousPubkey, result.Discrepancies[2].Pubkey)
+		require.Empty(t, result.Discrepancies[2].Path)
+		require.Equal(t, common.KeystoreDiscrepancyType_NotOnDisk, result.Discrepancies[2].Type)
+		require.Equal(t, common.KeystoreReconcileAction_None, result.Discrepancies[2].Action)
+	}
+
+	// A dry run shouldn't change anything
+	result, err := sp.ReconcileKeystores(context.Background(), true)
+	require.NoError(t, err)
+	require.True(t, result.DryRun)
+	checkDiscrepancies(result, common.KeystoreReconcileAction_WouldImport)
+	require.Len(t, keyManager.Keystores, 2)
+	require.NotContains(t, keyManager.Keystores, missingPubkey)
+	t.Log("Dry run reported every discrepancy without changing the VC")
+
+	// A real run should import the missing key and leave the extraneous one loaded
+	result, err = sp.ReconcileKeystores(context.Background(), false)
+	require.NoError(t, err)
+	require.False(t, result.DryRun)
+	checkDiscrepancies(result, common.KeystoreReconcileAction_Imported)
+	require.Len(t, keyManager.Keystores, 3)
+	require.Contains(t, keyManager.Keystores, missingPubkey)
+	require.Contains(t, keyManager.Keystores, extraneousPubkey)
+	t.Log("Imported the missing key and kept the extraneous one")
+
+	// Now only the keys that can't be fixed automatically should be left
+	result, err = sp.ReconcileKeystores(context.Background(), false)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.ConsistentCount)
+	require.Len(t, result.Discrepancies, 2)
+	require.Equal(t, common.KeystoreDiscrepancyType_Invalid, result.Discrepancies[0].Type)
+	require.Equal(t, common.KeystoreDiscrepancyType_NotOnDisk, result.Discrepancies[1].Type)
+}
+
+// Test that reconciling fails without a node password
+func TestReconcileKeystores_NoPassword(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	_, err := sp.ReconcileKeystores(context.Background(), true)
+	require.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test adding, listing, and removing remote keys alongside the keystores loaded into the VC
+func TestRemoteKeys(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	local := beacon.ValidatorPubkey{0x01}
+	keyManager.Keystores[local] = "{}"
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	tokenPath := filepath.Join(t.TempDir(), "api-token.txt")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(keyManager.token), 0600))
+	cfg.KeyManager.Url.Value = keyManager.URL
+	cfg.KeyManager.TokenPath.Value = tokenPath
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	ctx := context.Background()
+	remote1 := beacon.ValidatorPubkey{0x02}
+	remote2 := beacon.ValidatorPubkey{0x03}
+
+	// Remote keys need a signer
+	_, err := sp.AddRemoteKeys(ctx, []beacon.ValidatorPubkey{remote1})
+	require.ErrorIs(t, err, common.ErrRemoteSignerNotConfigured)
+
+	cfg.KeyManager.RemoteSignerUrl.Value = "http://web3signer:9000/"
+	statuses, err := sp.AddRemoteKeys(ctx, []beacon.ValidatorPubkey{remote1, remote2, local})
+	require.NoError(t, err)
+	require.Equal(t, []string{"imported", "imported", "duplicate"}, []string{statuses[0].Status, statuses[1].Status, statuses[2].Status})
+	require.Equal(t, "http://web3signer:9000", keyManager.RemoteKeys[remote1])
+
+	pubkeys, err := sp.ListRemoteKeys(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []beacon.ValidatorPubkey{remote1, remote2}, pubkeys)
+
+	// The remote keys are also listed as read-only keystores, but they should only show up once
+	keys, err := sp.ListValidatorKeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []common.ValidatorKey{
+		{Pubkey: local, Source: common.ValidatorKeySource_Local},
+		{Pubkey: remote1, Source: common.ValidatorKeySource_Remote, SignerUrl: "http://web3signer:9000"},
+		{Pubkey: remote2, Source: common.ValidatorKeySource_Remote, SignerUrl: "http://web3signer:9000"},
+	}, keys)
+
+	statuses, err = sp.DeleteRemoteKeys(ctx, []beacon.ValidatorPubkey{remote1, local})
+	require.NoError(t, err)
+	require.Equal(t, "deleted", statuses[0].Status)
+	require.Equal(t, "not_found", statuses[1].Status)
+	require.Contains(t, keyManager.Keystores, local)
+	pubkeys, err = sp.ListRemoteKeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []beacon.ValidatorPubkey{remote2}, pubkeys)
+}
+
+// Test that the remote signer URL has to be reachable for the config to be saved
+func TestRemoteSignerUrl_Validation(t *testing.T) {
+	signer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer signer.Close()
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	path := filepath.Join(t.TempDir(), "user-settings.yml")
+
+	cfg.KeyManager.RemoteSignerUrl.Value = signer.URL
+	_, err := cfg.SaveToFile(path, nil)
+	require.NoError(t, err)
+
+	cfg.KeyManager.RemoteSignerUrl.Value = "web3signer:9000"
+	require.Len(t, cfg.Validate(), 1)
+
+	signer.Close()
+	cfg.KeyManager.RemoteSignerUrl.Value = signer.URL
+	_, err = cfg.SaveToFile(path, nil)
+	require.ErrorContains(t, err, "couldn't be reached")
+}
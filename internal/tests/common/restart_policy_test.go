@@ -0,0 +1,73 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that each service's restart policy is set on its container when it's created
+func TestCreateContainer_RestartPolicy(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.RestartPolicy.BeaconNode.Policy.Value = hdconfig.RestartPolicy_OnFailure
+	cfg.RestartPolicy.BeaconNode.MaxRetries.Value = 5
+	cfg.RestartPolicy.ValidatorClient.Policy.Value = hdconfig.RestartPolicy_UnlessStopped
+	mock := newRecordingDockerClient()
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	ctx := context.Background()
+
+	// The configured policy replaces the provided one
+	bnName := cfg.GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+	hostCfg := &container.HostConfig{
+		NetworkMode:   "host",
+		RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyAlways},
+	}
+	_, err := sp.CreateContainer(ctx, config.ContainerID_BeaconNode, &container.Config{}, hostCfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 5}, mock.restartPolicies[bnName])
+	require.Equal(t, container.RestartPolicyAlways, hostCfg.RestartPolicy.Name)
+
+	// Services without a host config get one
+	vcName := cfg.GetDockerArtifactName(string(config.ContainerID_ValidatorClient))
+	_, err = sp.CreateContainer(ctx, config.ContainerID_ValidatorClient, &container.Config{}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, container.RestartPolicy{Name: container.RestartPolicyUnlessStopped}, mock.restartPolicies[vcName])
+
+	// The default leaves restarts to Hyperdrive
+	ecName := cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))
+	_, err = sp.CreateContainer(ctx, config.ContainerID_ExecutionClient, &container.Config{}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, container.RestartPolicy{Name: container.RestartPolicyDisabled}, mock.restartPolicies[ecName])
+
+	// Recreating a container picks up policy changes
+	cfg.RestartPolicy.BeaconNode.Policy.Value = hdconfig.RestartPolicy_Always
+	cfg.RestartPolicy.BeaconNode.MaxRetries.Value = 0
+	require.NoError(t, sp.RecreateContainer(ctx, config.ContainerID_BeaconNode))
+	require.Equal(t, container.RestartPolicy{Name: container.RestartPolicyAlways}, mock.restartPolicies[bnName])
+}
+
+// Test that a max retry count is only allowed with the OnFailure policy
+func TestRestartPolicy_Validate(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.RestartPolicy.ExecutionClient.Policy.Value = hdconfig.RestartPolicy_OnFailure
+	cfg.RestartPolicy.ExecutionClient.MaxRetries.Value = 3
+	require.Empty(t, cfg.Validate())
+
+	cfg.RestartPolicy.MevBoost.Policy.Value = hdconfig.RestartPolicy_Always
+	cfg.RestartPolicy.MevBoost.MaxRetries.Value = 3
+	errs := cfg.Validate()
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0], "MEV-Boost")
+	require.Contains(t, errs[0], string(hdconfig.RestartPolicy_OnFailure))
+
+	// The policies are copied with the rest of the config
+	cfg.RestartPolicy.MevBoost.MaxRetries.Value = 0
+	clone := cfg.Clone()
+	require.Equal(t, hdconfig.RestartPolicy_OnFailure, clone.RestartPolicy.ExecutionClient.Policy.Value)
+	require.Equal(t, uint64(3), clone.RestartPolicy.ExecutionClient.MaxRetries.Value)
+	require.Equal(t, hdconfig.RestartPolicy_Always, clone.RestartPolicy.MevBoost.Policy.Value)
+}
@@ -0,0 +1,66 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test splitting the balance changes of validators between epochs 2 and 5 of a chain with 4-slot epochs. Validator 0
+// earned 0.02 ETH and had 0.01 ETH withdrawn, validator 1 lost 0.01 ETH, and validator 2 earned exactly what was
+// withdrawn from it. The withdrawals in the start slot and after the end slot are already out of the start balance or
+// not yet out of the end balance, so they're ignored.
+func TestGetRewardPeriodDelta(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = 24
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	startBalances := []uint64{32.05e9, 32e9, 32.01e9}
+	endBalances := []uint64{32.06e9, 31.99e9, 32.01e9}
+	bn.SetBalanceHistory([]uint64{8, 20}, func(index int, slot uint64) uint64 {
+		if slot == 8 {
+			return startBalances[index]
+		}
+		return endBalances[index]
+	})
+	bn.AddWithdrawals(8, 1)
+	bn.AddWithdrawals(14, 0, 2)
+	bn.AddWithdrawals(21, 0)
+	pubkeys := getMockValidatorPubkeys(bn)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys,
+	})
+
+	delta, err := sp.GetRewardPeriodDelta(context.Background(), 2, 5)
+	require.NoError(t, err)
+	require.Equal(t, common.RewardDelta{
+		StartEpoch: 2,
+		EndEpoch:   5,
+		Validators: []common.ValidatorRewardDelta{
+			{Pubkey: pubkeys[0], Index: "0", StartBalance: 32.05e9, EndBalance: 32.06e9, Delta: 1e7, Withdrawals: 1e7, Rewards: 2e7},
+			{Pubkey: pubkeys[1], Index: "1", StartBalance: 32e9, EndBalance: 31.99e9, Delta: -1e7, Penalties: 1e7},
+			{Pubkey: pubkeys[2], Index: "2", StartBalance: 32.01e9, EndBalance: 32.01e9, Withdrawals: 1e7, Rewards: 1e7},
+		},
+		TotalDelta:       0,
+		TotalWithdrawals: 2e7,
+		TotalRewards:     3e7,
+		TotalPenalties:   1e7,
+	}, delta)
+
+	// States the Beacon Node has pruned are reported as missing history
+	bn.EarliestStateSlot = 10
+	_, err = sp.GetRewardPeriodDelta(context.Background(), 2, 5)
+	var historyErr *common.InsufficientHistoryError
+	require.True(t, errors.As(err, &historyErr))
+	require.Equal(t, uint64(2), historyErr.StartEpoch)
+
+	_, err = sp.GetRewardPeriodDelta(context.Background(), 5, 7)
+	require.ErrorContains(t, err, "after the head epoch")
+}
@@ -0,0 +1,83 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the percentiles reported for a known set of latencies
+func TestRpcLatencyTracker_Percentiles(t *testing.T) {
+	tracker := common.NewRpcLatencyTracker()
+
+	// 90 fast calls, 9 medium calls, and 1 slow call
+	for i := 0; i < 90; i++ {
+		tracker.Record(common.RpcClientType_ExecutionClient, "eth_call", 2*time.Millisecond)
+	}
+	for i := 0; i < 9; i++ {
+		tracker.Record(common.RpcClientType_ExecutionClient, "eth_call", 40*time.Millisecond)
+	}
+	tracker.Record(common.RpcClientType_ExecutionClient, "eth_call", 900*time.Millisecond)
+	tracker.Record(common.RpcClientType_BeaconNode, "GetSyncStatus", 5*time.Millisecond)
+
+	stats := tracker.GetStats()
+	require.Equal(t, common.RpcLatencyWindow, stats.Window)
+	call := stats.ExecutionClient["eth_call"]
+	require.Equal(t, uint64(100), call.Count)
+	requireLatencyNear(t, 2*time.Millisecond, call.P50)
+	requireLatencyNear(t, 40*time.Millisecond, call.P95)
+	requireLatencyNear(t, 40*time.Millisecond, call.P99)
+	require.Len(t, stats.BeaconNode, 1)
+	requireLatencyNear(t, 5*time.Millisecond, stats.BeaconNode["GetSyncStatus"].P50)
+	t.Logf("eth_call: p50 = %s, p95 = %s, p99 = %s", call.P50, call.P95, call.P99)
+}
+
+// Test that calls made through the service provider's clients are tracked and exported as metrics
+func TestGetRpcLatencyStats(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	// The mock doesn't serve the sync status, but the call should still be timed
+	_, _ = sp.GetBeaconClient().GetSyncStatus(context.Background())
+	_, _ = sp.GetEthClient().GetPrimaryClient().BlockNumber(context.Background())
+	_, err := sp.GetBeaconApiClient().GetSpec(context.Background())
+	require.NoError(t, err)
+	stats := sp.GetRpcLatencyStats()
+	require.Equal(t, uint64(1), stats.BeaconNode["GetSyncStatus"].Count)
+	require.Equal(t, uint64(1), stats.BeaconNode["GetSpec"].Count)
+	require.Equal(t, uint64(1), stats.ExecutionClient["eth_blockNumber"].Count)
+
+	families, err := sp.GetMetricsRegistry().Gather()
+	require.NoError(t, err)
+	found := false
+	for _, family := range families {
+		if family.GetName() == "hyperdrive_rpc_latency_seconds" {
+			found = true
+			require.Len(t, family.GetMetric(), 3)
+		}
+	}
+	require.True(t, found)
+}
+
+// Measures the overhead of recording a call
+func BenchmarkRpcLatencyTracker_Record(b *testing.B) {
+	tracker := common.NewRpcLatencyTracker()
+	methods := []string{"eth_call", "eth_getLogs", "eth_blockNumber", "eth_getBalance"}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			tracker.Record(common.RpcClientType_ExecutionClient, methods[i%len(methods)], time.Duration(i%5000)*time.Microsecond)
+			i++
+		}
+	})
+}
+
+// Checks that a reported latency is within the resolution of the histogram (25%) of the expected value
+func requireLatencyNear(t *testing.T, expected time.Duration, actual time.Duration) {
+	require.GreaterOrEqual(t, actual, expected)
+	require.LessOrEqual(t, actual, expected*5/4)
+}
@@ -0,0 +1,131 @@
+package common_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a task that takes longer than its interval never overlaps with itself, and that the missed runs are dropped
+func TestScheduler_SlowTaskDoesNotPileUp(t *testing.T) {
+	scheduler := common.NewScheduler(context.Background())
+	defer scheduler.Stop()
+
+	running := atomic.Int32{}
+	maxRunning := atomic.Int32{}
+	runs := atomic.Int32{}
+	err := scheduler.Schedule("slow", 10*time.Millisecond, 0, func(ctx context.Context) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			highest := maxRunning.Load()
+			if current <= highest || maxRunning.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+		runs.Add(1)
+		time.Sleep(45 * time.Millisecond)
+	})
+	require.NoError(t, err)
+
+	time.Sleep(300 * time.Millisecond)
+	scheduler.Stop()
+	require.Equal(t, int32(1), maxRunning.Load())
+
+	// Each run takes about 5 intervals, so there's only room for about 6 of them
+	require.GreaterOrEqual(t, runs.Load(), int32(3))
+	require.LessOrEqual(t, runs.Load(), int32(7))
+	statuses := scheduler.GetTaskStatuses()
+	require.Len(t, statuses, 1)
+	require.Equal(t, uint64(runs.Load()), statuses[0].RunCount)
+	require.GreaterOrEqual(t, statuses[0].SkippedRuns, uint64(3*runs.Load()))
+	require.GreaterOrEqual(t, statuses[0].LastDuration, 45*time.Millisecond)
+	t.Logf("%d runs, %d skipped", statuses[0].RunCount, statuses[0].SkippedRuns)
+}
+
+// Test that the run times are reported, jitter delays the runs, and cancelling the context stops every task
+func TestScheduler_StatusAndCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := common.NewScheduler(ctx)
+	defer scheduler.Stop()
+
+	fastRuns := atomic.Int32{}
+	jitteredRuns := atomic.Int32{}
+	scheduled := time.Now()
+	require.NoError(t, scheduler.Schedule("fast", 20*time.Millisecond, 0, func(ctx context.Context) {
+		fastRuns.Add(1)
+	}))
+	require.NoError(t, scheduler.Schedule("jittered", time.Hour, 50*time.Millisecond, func(ctx context.Context) {
+		jitteredRuns.Add(1)
+	}))
+	err := scheduler.Schedule("fast", time.Second, 0, func(ctx context.Context) {})
+	require.ErrorIs(t, err, common.ErrTaskAlreadyScheduled)
+
+	time.Sleep(100 * time.Millisecond)
+	statuses := scheduler.GetTaskStatuses()
+	require.Len(t, statuses, 2)
+	fast := statuses[0]
+	require.Equal(t, "fast", fast.Name)
+	require.False(t, fast.LastRun.Before(scheduled))
+	require.True(t, fast.NextRun.After(fast.LastRun))
+	require.LessOrEqual(t, fast.NextRun.Sub(fast.LastRun), 20*time.Millisecond)
+
+	// The jittered task runs once within its jitter and then waits out its interval
+	jittered := statuses[1]
+	require.Equal(t, "jittered", jittered.Name)
+	require.Equal(t, int32(1), jitteredRuns.Load())
+	require.Less(t, jittered.LastRun.Sub(scheduled), 50*time.Millisecond+10*time.Millisecond)
+	require.GreaterOrEqual(t, jittered.NextRun.Sub(jittered.LastRun), time.Hour)
+
+	// Cancelling should stop everything
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	stoppedAt := fastRuns.Load()
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, stoppedAt, fastRuns.Load())
+	err = scheduler.Schedule("late", time.Second, 0, func(ctx context.Context) {})
+	require.ErrorIs(t, err, common.ErrSchedulerStopped)
+}
+
+// Test that closing the service provider stops its scheduled tasks and cancels the running one
+func TestServiceProvider_SchedulerStopsOnClose(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	cancelled := make(chan struct{})
+	err := sp.GetScheduler().Schedule("blocking", time.Hour, 0, func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	sp.Close()
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("the running task wasn't cancelled when the provider was closed")
+	}
+}
+
+// Test that shortening a waiting task's interval brings its next run forward
+func TestScheduler_SetInterval(t *testing.T) {
+	scheduler := common.NewScheduler(context.Background())
+	defer scheduler.Stop()
+
+	runs := atomic.Int32{}
+	require.NoError(t, scheduler.Schedule("hourly", time.Hour, 0, func(ctx context.Context) {
+		runs.Add(1)
+	}))
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, scheduler.SetInterval("hourly", 20*time.Millisecond))
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, 20*time.Millisecond, scheduler.GetTaskStatuses()[0].Interval)
+
+	require.ErrorIs(t, scheduler.SetInterval("missing", time.Second), common.ErrTaskNotFound)
+	require.Error(t, scheduler.SetInterval("hourly", 0))
+}
@@ -0,0 +1,48 @@
+package common_test
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the daemon's resource usage is populated on the current platform and exported as metrics
+func TestGetSelfResourceUsage(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	time.Sleep(10 * time.Millisecond)
+
+	usage := sp.GetSelfResourceUsage()
+	require.Positive(t, usage.Goroutines)
+	require.Positive(t, usage.HeapAllocBytes)
+	require.GreaterOrEqual(t, usage.Uptime, 10*time.Millisecond)
+	_, err := os.Stat("/proc/self/fd")
+	hasProc := err == nil
+	require.Equal(t, hasProc, usage.HasOpenFileDescriptors)
+	if hasProc {
+		// At least stdin, stdout, and stderr
+		require.GreaterOrEqual(t, usage.OpenFileDescriptors, 3)
+	} else {
+		require.Zero(t, usage.OpenFileDescriptors)
+	}
+	t.Logf("Usage on %s: %+v", runtime.GOOS, usage)
+
+	families, err := sp.GetMetricsRegistry().Gather()
+	require.NoError(t, err)
+	names := map[string]bool{}
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	require.True(t, names["hyperdrive_goroutines"])
+	require.True(t, names["hyperdrive_heap_alloc_bytes"])
+	require.True(t, names["hyperdrive_uptime_seconds"])
+	require.Equal(t, hasProc, names["hyperdrive_open_fds"])
+
+	report := sp.GetHealthReport(context.Background())
+	require.Positive(t, report.SelfUsage.Goroutines)
+	require.GreaterOrEqual(t, report.SelfUsage.Uptime, usage.Uptime)
+}
@@ -0,0 +1,121 @@
+package common_test
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/ssz_types"
+	"github.com/rocket-pool/node-manager-core/wallet"
+	"github.com/stretchr/testify/require"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+var (
+	// The mainnet genesis validators root
+	mainnetGenesisValidatorsRoot []byte = ethcommon.FromHex("0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95")
+)
+
+// Test signing an exit offline and verifying it against the validator's pubkey and the exit domain
+func TestGenerateSignedExit(t *testing.T) {
+	sp, pubkey := newSignedExitTestServiceProvider(t)
+
+	// Mainnet is past Deneb, so the domain should use the Capella fork version
+	epoch := uint64(300000)
+	exit, err := sp.GenerateSignedExit(pubkey, epoch, 1234)
+	require.NoError(t, err)
+	require.Equal(t, epoch, uint64(exit.Message.Epoch))
+	require.Equal(t, "1234", exit.Message.ValidatorIndex)
+	require.Len(t, exit.Signature, beacon.ValidatorSignatureLength)
+
+	capellaDomain, err := eth2types.ComputeDomain(eth2types.DomainVoluntaryExit, ethcommon.FromHex("0x03000000"), mainnetGenesisValidatorsRoot)
+	require.NoError(t, err)
+	verifyTestExitSignature(t, pubkey, exit, capellaDomain)
+	t.Log("The exit signature is valid for the Capella domain")
+
+	// It shouldn't verify against the Deneb domain
+	denebDomain, err := eth2types.ComputeDomain(eth2types.DomainVoluntaryExit, ethcommon.FromHex("0x04000000"), mainnetGenesisValidatorsRoot)
+	require.NoError(t, err)
+	require.False(t, isValidTestExitSignature(t, pubkey, exit, denebDomain))
+}
+
+// Test that the exit domain follows the fork schedule until Deneb, and stays on Capella afterwards
+func TestGetVoluntaryExitDomain(t *testing.T) {
+	sp, _ := newSignedExitTestServiceProvider(t)
+	for _, test := range []struct {
+		epoch       uint64
+		forkVersion string
+	}{
+		{0, "0x00000000"},
+		{74240, "0x01000000"},
+		{150000, "0x02000000"},
+		{194048, "0x03000000"},
+		{269568, "0x03000000"},
+		{400000, "0x03000000"},
+	} {
+		expected, err := eth2types.ComputeDomain(eth2types.DomainVoluntaryExit, ethcommon.FromHex(test.forkVersion), mainnetGenesisValidatorsRoot)
+		require.NoError(t, err)
+		domain, err := sp.GetVoluntaryExitDomain(test.epoch)
+		require.NoError(t, err)
+		require.Equal(t, expected, domain[:], "wrong domain for epoch %d", test.epoch)
+	}
+
+	// Networks without a known schedule can't be signed for offline
+	sp.GetConfig().Network.Value = hdconfig.Network_LocalTest
+	_, err := sp.GetVoluntaryExitDomain(0)
+	require.ErrorIs(t, err, common.ErrNoOfflineForkSchedule)
+}
+
+// Test that exits for validators without a keystore on disk are rejected
+func TestGenerateSignedExit_KeyNotFound(t *testing.T) {
+	sp, _ := newSignedExitTestServiceProvider(t)
+	otherPubkey := readTestKeystorePubkey(t, writeTestKeystore(t, t.TempDir(), "other.json", keystorePassword, nil))
+	_, err := sp.GenerateSignedExit(otherPubkey, 300000, 1)
+	require.ErrorIs(t, err, common.ErrValidatorKeyNotFound)
+}
+
+// Creates a mainnet service provider with the node password set and a validator keystore on disk, without any clients
+func newSignedExitTestServiceProvider(t *testing.T) (*common.ServiceProvider, beacon.ValidatorPubkey) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	err := os.MkdirAll(cfg.UserDataPath.Value, 0700)
+	require.NoError(t, err)
+	err = sp.GetWallet().Recover(wallet.DefaultNodeKeyPath, 0, testMnemonic, keystorePassword, true, false)
+	require.NoError(t, err)
+
+	dir := cfg.GetKeystoreDirectory()
+	err = os.MkdirAll(dir, 0700)
+	require.NoError(t, err)
+	writeTestKeystore(t, dir, "a-other.json", keystorePassword, nil)
+	pubkey := readTestKeystorePubkey(t, writeTestKeystore(t, dir, "b-exiting.json", keystorePassword, nil))
+	return sp, pubkey
+}
+
+// Requires the exit's signature to be valid for the pubkey and domain
+func verifyTestExitSignature(t *testing.T, pubkey beacon.ValidatorPubkey, exit common.SignedVoluntaryExit, domain []byte) {
+	require.True(t, isValidTestExitSignature(t, pubkey, exit, domain))
+}
+
+// Checks the exit's signature against the pubkey and domain
+func isValidTestExitSignature(t *testing.T, pubkey beacon.ValidatorPubkey, exit common.SignedVoluntaryExit, domain []byte) bool {
+	index, err := strconv.ParseUint(exit.Message.ValidatorIndex, 10, 64)
+	require.NoError(t, err)
+	message := ssz_types.VoluntaryExit{
+		Epoch:          uint64(exit.Message.Epoch),
+		ValidatorIndex: index,
+	}
+	objectRoot, err := message.HashTreeRoot()
+	require.NoError(t, err)
+	signingRoot, err := (&ssz_types.SigningRoot{ObjectRoot: objectRoot[:], Domain: domain}).HashTreeRoot()
+	require.NoError(t, err)
+
+	blsPubkey, err := eth2types.BLSPublicKeyFromBytes(pubkey[:])
+	require.NoError(t, err)
+	signature, err := eth2types.BLSSignatureFromBytes(exit.Signature)
+	require.NoError(t, err)
+	return signature.Verify(signingRoot[:], blsPubkey)
+}
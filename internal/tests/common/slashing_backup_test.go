@@ -0,0 +1,252 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/rocket-pool/node-manager-core/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The name of the VC's data volume in tests, minus the project prefix
+	testVcDataVolume string = "vcdata"
+)
+
+// A Docker mock for recreating the VC, which wipes the mock key manager whenever the container is created and can be
+// told to fail starts
+type vcDockerClient struct {
+	*recordingDockerClient
+	keyManager *mockKeyManager
+	failStart  bool
+}
+
+func (d *vcDockerClient) ContainerCreate(ctx context.Context, cfg *container.Config, hostCfg *container.HostConfig, networkCfg *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.CreateResponse, error) {
+	d.keyManager.lock.Lock()
+	d.keyManager.Keystores = map[beacon.ValidatorPubkey]string{}
+	d.keyManager.History.Data = []common.SlashingProtectionRecord{}
+	d.keyManager.lock.Unlock()
+	return d.recordingDockerClient.ContainerCreate(ctx, cfg, hostCfg, networkCfg, platform, containerName)
+}
+
+func (d *vcDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	if d.failStart {
+		return errors.New("simulated start failure")
+	}
+	return d.recordingDockerClient.ContainerStart(ctx, containerID, options)
+}
+
+// Test that recreating the VC backs up its slashing protection, restores it into the new container, and prunes old backups
+func TestRecreateContainer_SlashingProtectionBackup(t *testing.T) {
+	sp, keyManager, _, pubkeys := newSlashingBackupTest(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		err := sp.RecreateContainer(ctx, config.ContainerID_ValidatorClient)
+		require.NoError(t, err)
+	}
+	requireSlashingProtectionRestored(t, keyManager, pubkeys)
+
+	// Only the configured number of backups are kept
+	backups, err := sp.GetSlashingProtectionBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Less(t, backups[0], backups[1])
+	require.Equal(t, sp.GetConfig().GetSlashingProtectionBackupDirectory(), filepath.Dir(backups[1]))
+	t.Logf("Kept backups %v", backups)
+}
+
+// Test that the backup is restored when the new VC container fails to start
+func TestRecreateContainer_RestoresBackupOnFailure(t *testing.T) {
+	sp, keyManager, docker, pubkeys := newSlashingBackupTest(t)
+	docker.failStart = true
+
+	err := sp.RecreateContainer(context.Background(), config.ContainerID_ValidatorClient)
+	require.ErrorContains(t, err, "simulated start failure")
+	requireSlashingProtectionRestored(t, keyManager, pubkeys)
+
+	backups, err := sp.GetSlashingProtectionBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	data, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), pubkeys[0].Hex())
+}
+
+// Test that disabling backups leaves the VC's keys alone
+func TestRecreateContainer_SlashingProtectionBackupDisabled(t *testing.T) {
+	sp, _, _, _ := newSlashingBackupTest(t)
+	sp.GetConfig().KeyManager.SlashingProtectionBackups.Value = 0
+
+	err := sp.RecreateContainer(context.Background(), config.ContainerID_ValidatorClient)
+	require.NoError(t, err)
+	backups, err := sp.GetSlashingProtectionBackups()
+	require.NoError(t, err)
+	require.Empty(t, backups)
+}
+
+// Test that a backup isn't attempted when the backup directory can't be written to, so the keys stay loaded
+func TestBackupSlashingProtection_DirectoryNotWritable(t *testing.T) {
+	sp, keyManager, _, pubkeys := newSlashingBackupTest(t)
+	dir := sp.GetConfig().GetSlashingProtectionBackupDirectory()
+	require.NoError(t, os.MkdirAll(filepath.Dir(dir), 0700))
+	require.NoError(t, os.WriteFile(dir, []byte{}, 0600))
+
+	err := sp.RecreateContainer(context.Background(), config.ContainerID_ValidatorClient)
+	require.ErrorContains(t, err, "can't write to the slashing protection backup directory")
+	requireSlashingProtectionRestored(t, keyManager, pubkeys)
+	t.Logf("Backup was refused: %s", err.Error())
+}
+
+// Test that the keys and their slashing protection are loaded back into the VC if writing the backup fails
+func TestBackupSlashingProtection_WriteFailure(t *testing.T) {
+	sp, keyManager, _, pubkeys := newSlashingBackupTest(t)
+	dir := sp.GetConfig().GetSlashingProtectionBackupDirectory()
+	keyManager.OnDelete = func() {
+		// Drop the VC's history too, so it has to come back from the export
+		keyManager.History.Data = []common.SlashingProtectionRecord{}
+		require.NoError(t, os.RemoveAll(dir))
+		require.NoError(t, os.WriteFile(dir, []byte{}, 0600))
+	}
+
+	_, err := sp.BackupSlashingProtection(context.Background())
+	require.ErrorContains(t, err, "error writing slashing protection backup")
+	requireSlashingProtectionRestored(t, keyManager, pubkeys)
+	t.Logf("Keys were loaded again after: %s", err.Error())
+}
+
+// Test that keys without a keystore in the node's keystore directory aren't removed for a backup
+func TestBackupSlashingProtection_MissingKeystore(t *testing.T) {
+	sp, keyManager, _, pubkeys := newSlashingBackupTest(t)
+	moduleKey := beacon.ValidatorPubkey{0xd4}
+	keyManager.Keystores[moduleKey] = "{}"
+
+	err := sp.RecreateContainer(context.Background(), config.ContainerID_ValidatorClient)
+	require.ErrorContains(t, err, "1 keys loaded into the Validator Client don't have a keystore")
+	require.Contains(t, keyManager.Keystores, moduleKey)
+	for _, pubkey := range pubkeys {
+		require.Contains(t, keyManager.Keystores, pubkey)
+	}
+	backups, err := sp.GetSlashingProtectionBackups()
+	require.NoError(t, err)
+	require.Empty(t, backups)
+}
+
+// Test that restoring a backup only loads the keys that were removed for it, not every keystore on disk
+func TestRecreateContainer_RestoresOnlyRemovedKeys(t *testing.T) {
+	sp, keyManager, _, pubkeys := newSlashingBackupTest(t)
+	writeTestKeystore(t, sp.GetConfig().GetKeystoreDirectory(), "unloaded.json", keystorePassword, nil)
+
+	err := sp.RecreateContainer(context.Background(), config.ContainerID_ValidatorClient)
+	require.NoError(t, err)
+	requireSlashingProtectionRestored(t, keyManager, pubkeys)
+}
+
+// Test that a backup can be restored from its file alone, like after the daemon restarts, including keys without any
+// signing history
+func TestRestoreSlashingProtectionBackup_FromFile(t *testing.T) {
+	sp, keyManager, _, pubkeys := newSlashingBackupTest(t)
+	path := writeTestKeystore(t, sp.GetConfig().GetKeystoreDirectory(), "new.json", keystorePassword, nil)
+	bytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+	newKey := readTestKeystorePubkey(t, path)
+	keyManager.Keystores[newKey] = string(bytes)
+
+	ctx := context.Background()
+	_, err = sp.BackupSlashingProtection(ctx)
+	require.NoError(t, err)
+	require.Empty(t, keyManager.Keystores)
+
+	// Restore it with a fresh VC, using only what's on disk
+	keyManager.History.Data = []common.SlashingProtectionRecord{}
+	backups, err := sp.GetSlashingProtectionBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	err = sp.RestoreSlashingProtectionBackup(ctx, backups[0])
+	require.NoError(t, err)
+	require.Contains(t, keyManager.Keystores, newKey)
+	delete(keyManager.Keystores, newKey)
+	requireSlashingProtectionRestored(t, keyManager, pubkeys)
+	t.Log("Restored the keys and slashing protection from the backup file")
+}
+
+// Creates a service provider with a running VC that has two keys from the node's keystore directory and some signing history
+func newSlashingBackupTest(t *testing.T) (*common.ServiceProvider, *mockKeyManager, *vcDockerClient, []beacon.ValidatorPubkey) {
+	keyManager := newMockKeyManager(t, "km-token")
+	bn := newMockBeaconNode(t)
+	cfg := newTestConfig(t, bn.URL, "")
+	tokenPath := filepath.Join(t.TempDir(), "api-token.txt")
+	err := os.WriteFile(tokenPath, []byte(keyManager.token), 0600)
+	require.NoError(t, err)
+	cfg.KeyManager.Url.Value = keyManager.URL
+	cfg.KeyManager.TokenPath.Value = tokenPath
+	cfg.KeyManager.SlashingProtectionBackups.Value = 2
+	docker := &vcDockerClient{
+		recordingDockerClient: newRecordingDockerClient(),
+		keyManager:            keyManager,
+	}
+	sp := newDockerTestServiceProviderWithUrls(t, cfg, docker, "http://127.0.0.1:1", bn.URL)
+
+	// Save the node password
+	err = os.MkdirAll(cfg.UserDataPath.Value, 0700)
+	require.NoError(t, err)
+	err = sp.GetWallet().Recover(wallet.DefaultNodeKeyPath, 0, testMnemonic, keystorePassword, true, false)
+	require.NoError(t, err)
+
+	// Start the VC with its data volume
+	ctx := context.Background()
+	name := cfg.GetDockerArtifactName(string(config.ContainerID_ValidatorClient))
+	docker.addVolume(volume.Volume{Name: cfg.GetDockerArtifactName(testVcDataVolume), Driver: "local"})
+	hostCfg := &container.HostConfig{
+		Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: cfg.GetDockerArtifactName(testVcDataVolume), Target: "/validators"}},
+	}
+	_, err = sp.CreateContainer(ctx, config.ContainerID_ValidatorClient, &container.Config{}, hostCfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, sp.StartContainer(ctx, name))
+
+	// Load the keys into it
+	keystoreDir := cfg.GetKeystoreDirectory()
+	require.NoError(t, os.MkdirAll(keystoreDir, 0700))
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, filename := range []string{"a.json", "b.json"} {
+		path := writeTestKeystore(t, keystoreDir, filename, keystorePassword, nil)
+		bytes, err := os.ReadFile(path)
+		require.NoError(t, err)
+		pubkey := readTestKeystorePubkey(t, path)
+		keyManager.Keystores[pubkey] = string(bytes)
+		keyManager.History.Data = append(keyManager.History.Data, common.SlashingProtectionRecord{
+			Pubkey:             pubkey,
+			SignedBlocks:       []common.SignedBlock{{Slot: 81952}},
+			SignedAttestations: []common.SignedAttestation{{SourceEpoch: 2290, TargetEpoch: 3007}},
+		})
+		pubkeys = append(pubkeys, pubkey)
+	}
+	return sp, keyManager, docker, pubkeys
+}
+
+// Makes sure the VC has the test keys loaded again, along with their slashing protection
+func requireSlashingProtectionRestored(t *testing.T, keyManager *mockKeyManager, pubkeys []beacon.ValidatorPubkey) {
+	keyManager.lock.Lock()
+	defer keyManager.lock.Unlock()
+	require.Len(t, keyManager.Keystores, len(pubkeys))
+	require.Len(t, keyManager.History.Data, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		require.Contains(t, keyManager.Keystores, pubkey)
+	}
+	for _, record := range keyManager.History.Data {
+		require.Equal(t, client.Uinteger(81952), record.SignedBlocks[0].Slot)
+		require.Equal(t, client.Uinteger(3007), record.SignedAttestations[0].TargetEpoch)
+	}
+}
@@ -0,0 +1,210 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	slashingTestPubkeyA beacon.ValidatorPubkey = beacon.ValidatorPubkey{0xa1}
+	slashingTestPubkeyB beacon.ValidatorPubkey = beacon.ValidatorPubkey{0xb2}
+	slashingTestPubkeyC beacon.ValidatorPubkey = beacon.ValidatorPubkey{0xc3}
+)
+
+// Test importing slashing protection data into the VC and exporting it again through the key manager API
+func TestSlashingProtection_RoundTrip(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	ctx := context.Background()
+
+	// Nothing has been imported yet
+	exported := exportTestSlashingProtection(t, sp)
+	require.Equal(t, common.SlashingProtectionInterchangeVersion, exported.Metadata.InterchangeFormatVersion)
+	require.Equal(t, testGenesisValidatorsRoot, exported.Metadata.GenesisValidatorsRoot)
+	require.Empty(t, exported.Data)
+
+	// Import a complete history like a VC would produce
+	data := fmt.Sprintf(`{
+		"metadata": {
+			"interchange_format_version": "5",
+			"genesis_validators_root": "%s"
+		},
+		"data": [
+			{
+				"pubkey": "%s",
+				"signed_blocks": [
+					{"slot": "81952", "signing_root": "0x4ff6f743a43f3b4f95350831aeaf0a122a1a392922c45d804280284a69eb850b"},
+					{"slot": "81951"}
+				],
+				"signed_attestations": [
+					{"source_epoch": "2290", "target_epoch": "3007", "signing_root": "0x587d6a4f59a58fe24f406e0502413e77fe1babddee641fda30034ed37ecc884d"},
+					{"source_epoch": "2290", "target_epoch": "3008"}
+				]
+			},
+			{
+				"pubkey": "%s",
+				"signed_blocks": [],
+				"signed_attestations": [{"source_epoch": "10", "target_epoch": "11"}]
+			}
+		]
+	}`, testGenesisValidatorsRoot, slashingTestPubkeyA.HexWithPrefix(), slashingTestPubkeyB.HexWithPrefix())
+	err := sp.ImportSlashingProtection(ctx, []byte(data))
+	require.NoError(t, err)
+	require.Len(t, keyManager.History.Data, 2)
+	t.Log("The VC received the history")
+
+	// The keys aren't loaded, so the VC releases their history for the keystores on disk without removing anything
+	keystoreDir := sp.GetConfig().GetKeystoreDirectory()
+	writeTestKeystorePubkey(t, keystoreDir, "a.json", slashingTestPubkeyA)
+	writeTestKeystorePubkey(t, keystoreDir, "b.json", slashingTestPubkeyB)
+	exported = exportTestSlashingProtection(t, sp)
+	require.Len(t, exported.Data, 2)
+	requireSlashingRecord(t, exported, slashingTestPubkeyA, 81952, 2290, 3008)
+	requireSlashingRecord(t, exported, slashingTestPubkeyB, 0, 10, 11)
+	require.Empty(t, findSlashingRecord(exported, slashingTestPubkeyB).SignedBlocks)
+	require.Len(t, keyManager.History.Data, 2)
+	t.Log("Exported the minimal version of the imported history")
+
+	// Loaded keys release the same history when they're removed
+	keyManager.Keystores[slashingTestPubkeyA] = "{}"
+	keyManager.Keystores[slashingTestPubkeyB] = "{}"
+	exported = removeKeysAndExportTestSlashingProtection(t, sp)
+	require.Empty(t, keyManager.Keystores)
+	require.Len(t, exported.Data, 2)
+	requireSlashingRecord(t, exported, slashingTestPubkeyA, 81952, 2290, 3008)
+	requireSlashingRecord(t, exported, slashingTestPubkeyB, 0, 10, 11)
+
+	// Import an older history for A, a newer one for B, and a new validator C; nothing should regress
+	data = fmt.Sprintf(`{
+		"metadata": {"interchange_format_version": "5", "genesis_validators_root": "%s"},
+		"data": [
+			{"pubkey": "%s", "signed_blocks": [{"slot": "100"}], "signed_attestations": [{"source_epoch": "5", "target_epoch": "6"}]},
+			{"pubkey": "%s", "signed_blocks": [{"slot": "500"}], "signed_attestations": [{"source_epoch": "20", "target_epoch": "21"}]},
+			{"pubkey": "%s", "signed_blocks": [{"slot": "7"}], "signed_attestations": []}
+		]
+	}`, testGenesisValidatorsRoot, slashingTestPubkeyA.HexWithPrefix(), slashingTestPubkeyB.HexWithPrefix(), slashingTestPubkeyC.HexWithPrefix())
+	err = sp.ImportSlashingProtection(ctx, []byte(data))
+	require.NoError(t, err)
+
+	keyManager.Keystores[slashingTestPubkeyA] = "{}"
+	keyManager.Keystores[slashingTestPubkeyB] = "{}"
+	keyManager.Keystores[slashingTestPubkeyC] = "{}"
+	exported = removeKeysAndExportTestSlashingProtection(t, sp)
+	require.Len(t, exported.Data, 3)
+	requireSlashingRecord(t, exported, slashingTestPubkeyA, 81952, 2290, 3008)
+	requireSlashingRecord(t, exported, slashingTestPubkeyB, 500, 20, 21)
+	require.Equal(t, client.Uinteger(7), findSlashingRecord(exported, slashingTestPubkeyC).SignedBlocks[0].Slot)
+	require.Empty(t, findSlashingRecord(exported, slashingTestPubkeyC).SignedAttestations)
+	t.Log("Merging kept the highest slots and epochs for each validator")
+}
+
+// Test that slashing protection data for another network or format is rejected before it reaches the VC
+func TestSlashingProtection_InvalidMetadata(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	ctx := context.Background()
+
+	wrongVersion := fmt.Sprintf(`{"metadata": {"interchange_format_version": "4", "genesis_validators_root": "%s"}, "data": []}`, testGenesisValidatorsRoot)
+	err := sp.ImportSlashingProtection(ctx, []byte(wrongVersion))
+	require.ErrorContains(t, err, "unsupported slashing protection interchange version")
+
+	mainnetRoot := "0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"
+	wrongNetwork := fmt.Sprintf(`{"metadata": {"interchange_format_version": "5", "genesis_validators_root": "%s"}, "data": []}`, mainnetRoot)
+	err = sp.ImportSlashingProtection(ctx, []byte(wrongNetwork))
+	require.ErrorContains(t, err, "genesis validators root")
+
+	err = sp.ImportSlashingProtection(ctx, []byte("not json"))
+	require.Error(t, err)
+	require.Empty(t, keyManager.SlashingProtection)
+	t.Log("Invalid slashing protection data was rejected")
+}
+
+// Test that the read-only export refuses to run while keys are loaded and leaves them in the VC, then exports the history
+// of the keys on disk once they aren't loaded
+func TestSlashingProtection_ExportWithLoadedKeys(t *testing.T) {
+	keyManager := newMockKeyManager(t, "km-token")
+	sp := newKeyManagerTestServiceProvider(t, keyManager)
+	keyManager.Keystores[slashingTestPubkeyA] = "{}"
+	keyManager.RemoteKeys[slashingTestPubkeyB] = "http://web3signer:9000"
+
+	_, err := sp.ExportSlashingProtection(context.Background())
+	require.ErrorIs(t, err, common.ErrSlashingProtectionKeysLoaded)
+	require.Contains(t, keyManager.Keystores, slashingTestPubkeyA)
+	require.Contains(t, keyManager.RemoteKeys, slashingTestPubkeyB)
+	t.Logf("Export was refused: %s", err.Error())
+
+	// Read-only remote keys don't block it
+	delete(keyManager.Keystores, slashingTestPubkeyA)
+	keyManager.History.Data = append(keyManager.History.Data, common.SlashingProtectionRecord{
+		Pubkey:             slashingTestPubkeyA,
+		SignedBlocks:       []common.SignedBlock{{Slot: 12}},
+		SignedAttestations: []common.SignedAttestation{{SourceEpoch: 1, TargetEpoch: 2}},
+	})
+	writeTestKeystorePubkey(t, sp.GetConfig().GetKeystoreDirectory(), "a.json", slashingTestPubkeyA)
+	exported := exportTestSlashingProtection(t, sp)
+	require.Len(t, exported.Data, 1)
+	requireSlashingRecord(t, exported, slashingTestPubkeyA, 12, 1, 2)
+	require.Contains(t, keyManager.RemoteKeys, slashingTestPubkeyB)
+}
+
+// Writes a keystore file that only declares its pubkey, which is all the read-only export needs
+func writeTestKeystorePubkey(t *testing.T, dir string, filename string, pubkey beacon.ValidatorPubkey) {
+	err := os.MkdirAll(dir, 0700)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, filename), []byte(fmt.Sprintf(`{"pubkey": "%s", "version": 4}`, pubkey.Hex())), 0600)
+	require.NoError(t, err)
+}
+
+// Exports and deserializes the stored slashing protection data without changing the VC
+func exportTestSlashingProtection(t *testing.T, sp *common.ServiceProvider) common.SlashingProtectionInterchange {
+	bytes, err := sp.ExportSlashingProtection(context.Background())
+	require.NoError(t, err)
+	return unmarshalTestSlashingProtection(t, bytes)
+}
+
+// Removes the VC's keys, then exports and deserializes their slashing protection data
+func removeKeysAndExportTestSlashingProtection(t *testing.T, sp *common.ServiceProvider) common.SlashingProtectionInterchange {
+	bytes, err := sp.RemoveKeysAndExportSlashingProtection(context.Background())
+	require.NoError(t, err)
+	return unmarshalTestSlashingProtection(t, bytes)
+}
+
+// Deserializes exported slashing protection data
+func unmarshalTestSlashingProtection(t *testing.T, bytes []byte) common.SlashingProtectionInterchange {
+	var interchange common.SlashingProtectionInterchange
+	err := json.Unmarshal(bytes, &interchange)
+	require.NoError(t, err)
+	return interchange
+}
+
+// Finds the record for a validator in an interchange
+func findSlashingRecord(interchange common.SlashingProtectionInterchange, pubkey beacon.ValidatorPubkey) common.SlashingProtectionRecord {
+	for _, record := range interchange.Data {
+		if record.Pubkey == pubkey {
+			return record
+		}
+	}
+	return common.SlashingProtectionRecord{}
+}
+
+// Checks the watermarks of a validator's record
+func requireSlashingRecord(t *testing.T, interchange common.SlashingProtectionInterchange, pubkey beacon.ValidatorPubkey, slot uint64, source uint64, target uint64) {
+	record := findSlashingRecord(interchange, pubkey)
+	require.Equal(t, pubkey, record.Pubkey)
+	if slot > 0 {
+		require.Len(t, record.SignedBlocks, 1)
+		require.Equal(t, client.Uinteger(slot), record.SignedBlocks[0].Slot)
+	}
+	require.Len(t, record.SignedAttestations, 1)
+	require.Equal(t, client.Uinteger(source), record.SignedAttestations[0].SourceEpoch)
+	require.Equal(t, client.Uinteger(target), record.SignedAttestations[0].TargetEpoch)
+}
@@ -0,0 +1,100 @@
+package common_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/eth"
+	"github.com/stretchr/testify/require"
+)
+
+// A fake module that contributes a fixed set of validators and collateral
+type mockStakeContributor struct {
+	name       string
+	enabled    bool
+	pubkeys    []beacon.ValidatorPubkey
+	collateral *big.Int
+}
+
+func (c *mockStakeContributor) GetModuleName() string {
+	return c.name
+}
+
+func (c *mockStakeContributor) IsEnabled() bool {
+	return c.enabled
+}
+
+func (c *mockStakeContributor) GetValidatorPubkeys(ctx context.Context) ([]beacon.ValidatorPubkey, error) {
+	return c.pubkeys, nil
+}
+
+func (c *mockStakeContributor) GetCollateral(ctx context.Context) (*big.Int, error) {
+	return c.collateral, nil
+}
+
+// Test aggregating the stake of two modules, ignoring a disabled one
+func TestGetTotalStake(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)      // 0-2
+	bn.AddValidators(1, beacon.ValidatorState_PendingQueued, 32e9)      // 3
+	bn.AddValidators(1, beacon.ValidatorState_ActiveExiting, 31e9)      // 4
+	bn.AddValidators(1, beacon.ValidatorState_WithdrawalPossible, 32e9) // 5
+	bn.AddValidators(1, beacon.ValidatorState_WithdrawalDone, 0)        // 6
+	bn.AddValidators(1, beacon.ValidatorState_ActiveOngoing, 32e9)      // 7
+	pubkey := func(index int) beacon.ValidatorPubkey {
+		return beacon.ValidatorPubkey(bn.Validators[index].Validator.Pubkey)
+	}
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:       "stakewise",
+		enabled:    true,
+		pubkeys:    []beacon.ValidatorPubkey{pubkey(0), pubkey(1), pubkey(3), pubkey(6)},
+		collateral: nil,
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:       "constellation",
+		enabled:    true,
+		pubkeys:    []beacon.ValidatorPubkey{pubkey(2), pubkey(4), pubkey(5)},
+		collateral: eth.EthToWei(2.4),
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:       "disabled",
+		enabled:    false,
+		pubkeys:    []beacon.ValidatorPubkey{pubkey(7)},
+		collateral: eth.EthToWei(100),
+	})
+
+	summary, err := sp.GetTotalStake(context.Background())
+	require.NoError(t, err)
+	require.Len(t, summary.Modules, 2)
+
+	// The first module has 2 active and 1 pending validator; the withdrawn one doesn't count
+	first := summary.Modules["stakewise"]
+	require.Equal(t, 3, first.ValidatorCount)
+	require.Equal(t, uint64(64e9), first.Balances[common.StakeStatus_Active])
+	require.Equal(t, uint64(32e9), first.Balances[common.StakeStatus_Pending])
+	require.Equal(t, uint64(96e9), first.TotalBalance)
+	require.Equal(t, 0, first.Collateral.Sign())
+
+	// The second module has one validator in each of active, exiting, and withdrawable
+	second := summary.Modules["constellation"]
+	require.Equal(t, 3, second.ValidatorCount)
+	require.Equal(t, uint64(32e9), second.Balances[common.StakeStatus_Active])
+	require.Equal(t, uint64(31e9), second.Balances[common.StakeStatus_Exiting])
+	require.Equal(t, uint64(32e9), second.Balances[common.StakeStatus_Withdrawable])
+	require.Equal(t, uint64(95e9), second.TotalBalance)
+	require.Equal(t, eth.EthToWei(2.4), second.Collateral)
+
+	// Totals
+	require.Equal(t, uint64(96e9), summary.Balances[common.StakeStatus_Active])
+	require.Equal(t, uint64(32e9), summary.Balances[common.StakeStatus_Pending])
+	require.Equal(t, uint64(31e9), summary.Balances[common.StakeStatus_Exiting])
+	require.Equal(t, uint64(32e9), summary.Balances[common.StakeStatus_Withdrawable])
+	require.Equal(t, uint64(191e9), summary.TotalBalance)
+	require.Equal(t, eth.EthToWei(2.4), summary.TotalCollateral)
+	t.Logf("Total stake is %d gwei plus %s wei of collateral", summary.TotalBalance, summary.TotalCollateral.String())
+}
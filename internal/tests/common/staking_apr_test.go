@@ -0,0 +1,166 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// The fee recipient of the node's validators
+	aprFeeRecipient ethcommon.Address = ethcommon.HexToAddress("0x00000000000000000000000000000000000fee01")
+
+	// The fee recipient of the block builder that built one of the proposals
+	aprBuilder ethcommon.Address = ethcommon.HexToAddress("0x00000000000000000000000000000000000b0b01")
+
+	// Another account that sends transactions in the mock blocks
+	aprSender ethcommon.Address = ethcommon.HexToAddress("0x0000000000000000000000000000000000005e01")
+)
+
+// Test computing the APR from a window with balance growth, a withdrawal, a locally built block, and a builder's block
+func TestComputeAPR(t *testing.T) {
+	bn, ec := newAprTestClients(t)
+	sp := newAprTestServiceProvider(t, bn, ec)
+
+	breakdown, err := sp.ComputeAPR(context.Background(), 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), breakdown.StartEpoch)
+	require.Equal(t, uint64(10), breakdown.EndEpoch)
+
+	// Validator 2 joined partway through the window, so only 0 and 1 count
+	require.Equal(t, 2, breakdown.ValidatorCount)
+	require.Equal(t, uint64(64e9), breakdown.Principal)
+
+	// The balances grew by 5 mETH, and validator 0 withdrew another 10 mETH
+	require.Equal(t, int64(15e6), breakdown.ConsensusRewards)
+
+	// Slot 24 was built locally and slot 25 by a builder; the blocks in slots 22 and 30 belong to validator 2
+	require.Equal(t, 2, breakdown.ProposalCount)
+	require.Equal(t, big.NewInt(21000*2e9+50000*1e9), breakdown.PriorityFees)
+	require.Equal(t, big.NewInt(5e16), breakdown.MevRewards)
+
+	// The window is 5 epochs of 4 12-second slots
+	annualization := 365.25 * 24 * 60 * 60 / 240
+	require.InDelta(t, 15e6/64e9*annualization, breakdown.ConsensusAPR, 1e-9)
+	require.InDelta(t, (21000*2e9+50000*1e9+5e16)/64e18*annualization, breakdown.ExecutionAPR, 1e-9)
+	require.InDelta(t, breakdown.ConsensusAPR+breakdown.ExecutionAPR, breakdown.TotalAPR, 1e-12)
+	t.Logf("CL APR: %.4f, EL APR: %.4f", breakdown.ConsensusAPR, breakdown.ExecutionAPR)
+}
+
+// Test that lookbacks beyond the clients' history fail with an InsufficientHistoryError
+func TestComputeAPR_InsufficientHistory(t *testing.T) {
+	t.Run("before genesis", func(t *testing.T) {
+		bn, ec := newAprTestClients(t)
+		sp := newAprTestServiceProvider(t, bn, ec)
+		_, err := sp.ComputeAPR(context.Background(), 11)
+		var historyErr *common.InsufficientHistoryError
+		require.ErrorAs(t, err, &historyErr)
+	})
+
+	t.Run("pruned state", func(t *testing.T) {
+		bn, ec := newAprTestClients(t)
+		bn.EarliestStateSlot = 24
+		sp := newAprTestServiceProvider(t, bn, ec)
+		_, err := sp.ComputeAPR(context.Background(), 5)
+		var historyErr *common.InsufficientHistoryError
+		require.ErrorAs(t, err, &historyErr)
+		require.Equal(t, uint64(5), historyErr.StartEpoch)
+
+		// A shorter window is still available
+		_, err = sp.ComputeAPR(context.Background(), 4)
+		require.NoError(t, err)
+	})
+
+	t.Run("missing block", func(t *testing.T) {
+		bn, ec := newAprTestClients(t)
+		getBlock := ec.Handlers["eth_getBlockByNumber"]
+		ec.Handlers["eth_getBlockByNumber"] = func(params []json.RawMessage) (any, error) {
+			var number string
+			require.NoError(t, json.Unmarshal(params[0], &number))
+			if number == "0x18" {
+				return nil, nil
+			}
+			return getBlock(params)
+		}
+		sp := newAprTestServiceProvider(t, bn, ec)
+		_, err := sp.ComputeAPR(context.Background(), 5)
+		var historyErr *common.InsufficientHistoryError
+		require.ErrorAs(t, err, &historyErr)
+		t.Logf("History error: %s", historyErr.Error())
+	})
+}
+
+// Creates a Beacon Node with 4-slot epochs at the start of epoch 10 and an Execution Client with the blocks it proposed.
+// Validators 0-3 propose in rotation; slot 24 (validator 0) was built locally, slot 25 (validator 1) by a builder, and
+// validator 0 has a withdrawal in slot 22.
+func newAprTestClients(t *testing.T) (*mockBeaconNode, *mockExecutionClient) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = 40
+	bn.AddValidators(4, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.Validators[2].Validator.ActivationEpoch = 7
+	bn.Validators[0].Balance = 32e9 + 2e6
+	bn.Validators[1].Balance = 32e9 + 3e6
+	bn.BalanceHistory[20] = map[string]uint64{"0": 32e9, "1": 32e9, "2": 32e9, "3": 32e9}
+	bn.AddWithdrawals(22, 0)
+	bn.AddWithdrawals(30, 2)
+	bn.FeeRecipients[24] = aprFeeRecipient
+	bn.FeeRecipients[25] = aprBuilder
+
+	gwei := func(amount int64) *hexutil.Big {
+		return (*hexutil.Big)(new(big.Int).Mul(big.NewInt(amount), big.NewInt(1e9)))
+	}
+	transaction := func(from ethcommon.Address, to ethcommon.Address, value *big.Int) map[string]any {
+		return map[string]any{"from": from, "to": to, "value": (*hexutil.Big)(value)}
+	}
+	ec := newMockExecutionClient(t, 1)
+	ec.Handlers["eth_getBlockByNumber"] = func(params []json.RawMessage) (any, error) {
+		var number string
+		require.NoError(t, json.Unmarshal(params[0], &number))
+		block := map[string]any{
+			"baseFeePerGas": gwei(10),
+			"transactions":  []any{},
+		}
+		switch number {
+		case "0x18":
+			block["transactions"] = []any{
+				transaction(aprSender, aprBuilder, big.NewInt(0)),
+				transaction(aprSender, aprFeeRecipient, big.NewInt(1e18)),
+			}
+		case "0x19":
+			block["transactions"] = []any{
+				transaction(aprSender, aprSender, big.NewInt(0)),
+				transaction(aprBuilder, aprFeeRecipient, big.NewInt(5e16)),
+			}
+		}
+		return block, nil
+	}
+	ec.Handlers["eth_getBlockReceipts"] = func(params []json.RawMessage) (any, error) {
+		var number string
+		require.NoError(t, json.Unmarshal(params[0], &number))
+		require.Equal(t, "0x18", number, "only the locally built block's receipts are needed")
+		return []any{
+			map[string]any{"gasUsed": hexutil.Uint64(21000), "effectiveGasPrice": gwei(12)},
+			map[string]any{"gasUsed": hexutil.Uint64(50000), "effectiveGasPrice": gwei(11)},
+		}, nil
+	}
+	return bn, ec
+}
+
+// Creates a service provider whose module runs validators 0-2 of the APR test clients
+func newAprTestServiceProvider(t *testing.T, bn *mockBeaconNode, ec *mockExecutionClient) *common.ServiceProvider {
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: getMockValidatorPubkeys(bn)[:3],
+	})
+	return sp
+}
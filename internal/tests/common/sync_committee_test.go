@@ -0,0 +1,78 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test finding the node's validators in the current and next sync committees
+func TestGetCurrentSyncCommitteeMembership(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(10, beacon.ValidatorState_ActiveOngoing, 32e9)
+	pubkey := func(index int) beacon.ValidatorPubkey {
+		return beacon.ValidatorPubkey(bn.Validators[index].Validator.Pubkey)
+	}
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: []beacon.ValidatorPubkey{pubkey(1), pubkey(5), pubkey(9)},
+	})
+	ctx := context.Background()
+
+	// The head is in period 0, which seats validators 0-3; period 1 seats 4-7
+	memberships, err := sp.GetCurrentSyncCommitteeMembership(ctx)
+	require.NoError(t, err)
+	periodSlots := uint64(256 * 32)
+	require.Equal(t, []common.SyncCommitteeMembership{
+		{
+			Period:     0,
+			IsCurrent:  true,
+			StartSlot:  0,
+			EndSlot:    periodSlots - 1,
+			StartTime:  time.Unix(mockGenesisTime, 0),
+			EndTime:    time.Unix(mockGenesisTime+int64(periodSlots)*12, 0),
+			Validators: []beacon.ValidatorPubkey{pubkey(1)},
+		},
+		{
+			Period:     1,
+			IsCurrent:  false,
+			StartSlot:  periodSlots,
+			EndSlot:    2*periodSlots - 1,
+			StartTime:  time.Unix(mockGenesisTime+int64(periodSlots)*12, 0),
+			EndTime:    time.Unix(mockGenesisTime+2*int64(periodSlots)*12, 0),
+			Validators: []beacon.ValidatorPubkey{pubkey(5)},
+		},
+	}, memberships)
+
+	// Validators that hold more than one seat are only listed once
+	bn.SyncCommitteeSize = 12
+	memberships, err = sp.GetCurrentSyncCommitteeMembership(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []beacon.ValidatorPubkey{pubkey(1), pubkey(5), pubkey(9)}, memberships[0].Validators)
+	require.Equal(t, []beacon.ValidatorPubkey{pubkey(5), pubkey(9), pubkey(1)}, memberships[1].Validators)
+
+	// The health report includes the memberships
+	report := sp.GetHealthReport(ctx)
+	require.Equal(t, memberships, report.SyncCommittees)
+}
+
+// Test that sync committees aren't looked up before Altair
+func TestGetCurrentSyncCommitteeMembership_PreAltair(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["ALTAIR_FORK_EPOCH"] = "100"
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	_, err := sp.GetCurrentSyncCommitteeMembership(context.Background())
+	require.ErrorIs(t, err, common.ErrSyncCommitteeNotActive)
+	report := sp.GetHealthReport(context.Background())
+	require.Empty(t, report.SyncCommittees)
+	for _, warning := range report.Warnings {
+		require.NotContains(t, warning, "sync committee")
+	}
+}
@@ -0,0 +1,81 @@
+package common_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that clients whose distance stays the same for a whole window are flagged as stuck, while one that's catching up
+// slowly isn't
+func TestGetSyncHealth(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.SetSyncDistance(500)
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetSyncDistance(1000, 5000)
+	cfg := newTestConfig(t, bn.URL, ec.URL)
+	cfg.StuckSyncWindow.Value = 1
+	sp := newTestServiceProviderFromConfig(t, cfg)
+	ctx := context.Background()
+
+	// Nothing can be said about the first sample
+	health, err := sp.GetSyncHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, health.Window)
+	require.Equal(t, common.SyncState_Measuring, health.ExecutionClient.State)
+	require.Equal(t, uint64(4000), health.ExecutionClient.Distance)
+	require.Equal(t, common.SyncState_Measuring, health.BeaconNode.State)
+	require.Equal(t, uint64(500), health.BeaconNode.Distance)
+
+	// The EC gets a little closer while the BN doesn't move
+	time.Sleep(500 * time.Millisecond)
+	ec.SetSyncDistance(1001, 5000)
+	health, err = sp.GetSyncHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, common.SyncState_Progressing, health.ExecutionClient.State)
+	require.Equal(t, uint64(4000), health.ExecutionClient.WindowStartDistance)
+	require.Positive(t, health.ExecutionClient.ProgressPerMinute)
+	require.True(t, health.ExecutionClient.HasEta)
+	require.Equal(t, common.SyncState_Measuring, health.BeaconNode.State)
+
+	// Once a whole window has passed, the BN is stuck but the EC is still progressing since its last sample
+	time.Sleep(700 * time.Millisecond)
+	health, err = sp.GetSyncHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, common.SyncState_Stuck, health.BeaconNode.State)
+	require.Equal(t, uint64(500), health.BeaconNode.WindowStartDistance)
+	require.False(t, health.BeaconNode.HasEta)
+	require.Equal(t, common.SyncState_Progressing, health.ExecutionClient.State)
+
+	report := sp.GetHealthReport(ctx)
+	found := false
+	for _, warning := range report.Warnings {
+		if strings.Contains(warning, "Beacon Node's sync looks stuck") {
+			found = true
+		}
+		require.NotContains(t, warning, "Execution Client's sync")
+	}
+	require.True(t, found, "missing stuck sync warning in %v", report.Warnings)
+
+	// Moving forward again clears the stuck state
+	bn.SetSyncDistance(400)
+	health, err = sp.GetSyncHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, common.SyncState_Progressing, health.BeaconNode.State)
+
+	// Synced clients start over
+	bn.SetSyncDistance(0)
+	ec.SetResult("eth_syncing", false)
+	health, err = sp.GetSyncHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, common.SyncState_Synced, health.BeaconNode.State)
+	require.Equal(t, common.SyncState_Synced, health.ExecutionClient.State)
+	bn.SetSyncDistance(400)
+	health, err = sp.GetSyncHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, common.SyncState_Measuring, health.BeaconNode.State)
+}
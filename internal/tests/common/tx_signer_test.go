@@ -0,0 +1,264 @@
+package common_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	walletapi "github.com/nodeset-org/hyperdrive-daemon/server/api/wallet"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/types/api"
+	apitypes "github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/log"
+	"github.com/rocket-pool/node-manager-core/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The key of Hardhat's second default account, 0x70997970C51812dc3A010C7d01b50e0d17dc79C8
+	remoteSignerKey string = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+)
+
+// A signer that stands in for a hardware wallet or remote signer, with its own key
+type mockRemoteSigner struct {
+	t       *testing.T
+	address ethcommon.Address
+
+	// The chain IDs of the transactions it was asked to sign
+	ChainIDs []*big.Int
+
+	lock *sync.Mutex
+}
+
+func newMockRemoteSigner(t *testing.T) *mockRemoteSigner {
+	key, err := crypto.HexToECDSA(remoteSignerKey)
+	require.NoError(t, err)
+	return &mockRemoteSigner{
+		t:        t,
+		address:  crypto.PubkeyToAddress(key.PublicKey),
+		ChainIDs: []*big.Int{},
+		lock:     &sync.Mutex{},
+	}
+}
+
+func (s *mockRemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ChainIDs = append(s.ChainIDs, chainID)
+	key, err := crypto.HexToECDSA(remoteSignerKey)
+	require.NoError(s.t, err)
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+}
+
+func (s *mockRemoteSigner) Address() ethcommon.Address {
+	return s.address
+}
+
+// Test that transactions are signed with the node wallet's keystore by default
+func TestTxSigner_Keystore(t *testing.T) {
+	sp := newOwnershipTestServiceProvider(t)
+	signer := sp.GetTxSigner()
+	require.Equal(t, testNodeAddress, signer.Address())
+
+	to := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		To:        &to,
+		Value:     big.NewInt(1),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(2e9),
+		GasTipCap: big.NewInt(1e9),
+	})
+	opts := sp.GetSignerTransactor(context.Background())
+	require.Equal(t, testNodeAddress, opts.From)
+	signed, err := opts.Signer(opts.From, tx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1), signed.ChainId())
+	sender, err := types.Sender(types.LatestSignerForChainID(signed.ChainId()), signed)
+	require.NoError(t, err)
+	require.Equal(t, testNodeAddress, sender)
+
+	// Other senders aren't signed for
+	_, err = opts.Signer(ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"), tx)
+	require.Error(t, err)
+}
+
+// Test that the keystore signer keeps signing for the wallet's own address while the node is masquerading as another one,
+// so the transaction options' sender matches the key that signs
+func TestTxSigner_KeystoreMasquerading(t *testing.T) {
+	sp := newOwnershipTestServiceProvider(t)
+	masquerade := ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	require.NoError(t, sp.GetWallet().MasqueradeAsAddress(masquerade))
+	require.Equal(t, testNodeAddress, sp.GetTxSigner().Address())
+
+	to := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		To:        &to,
+		Gas:       21000,
+		GasFeeCap: big.NewInt(2e9),
+		GasTipCap: big.NewInt(1e9),
+	})
+	opts := sp.GetSignerTransactor(context.Background())
+	require.Equal(t, testNodeAddress, opts.From)
+	signed, err := opts.Signer(opts.From, tx)
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(signed.ChainId()), signed)
+	require.NoError(t, err)
+	require.Equal(t, opts.From, sender)
+	_, err = opts.Signer(masquerade, tx)
+	require.Error(t, err)
+}
+
+// Test that a custom signer selected in the config is used to submit transactions
+func TestTxSigner_Remote(t *testing.T) {
+	signer := newMockRemoteSigner(t)
+	bn := newMockBeaconNode(t)
+	bn.ActivateElectra()
+	bn.AddValidators(1, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.SetWithdrawalCredentials(0, getAddressWithdrawalCredentials(0x01, signer.Address()))
+	ec, sent := newTxTestExecutionClient(t)
+
+	cfg := newTestConfig(t, bn.URL, ec.URL)
+	cfg.TransactionSigner.Value = "remote"
+	sp, err := common.NewServiceProviderFromConfigWithSigners(cfg, map[string]common.Signer{"remote": signer})
+	require.NoError(t, err)
+	t.Cleanup(sp.Close)
+	require.NoError(t, os.MkdirAll(sp.GetConfig().UserDataPath.Value, 0700))
+	require.NoError(t, sp.GetWallet().Recover(wallet.DefaultNodeKeyPath, 0, testMnemonic, testWalletPassword, false, false))
+	require.Same(t, signer, sp.GetTxSigner())
+
+	hash, err := sp.SubmitWithdrawalRequest(context.Background(), getMockValidatorPubkeys(bn)[0], 0)
+	require.NoError(t, err)
+	require.Len(t, *sent, 1)
+	tx := (*sent)[0]
+	require.Equal(t, tx.Hash(), hash)
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), sender)
+	require.Equal(t, []*big.Int{big.NewInt(1)}, signer.ChainIDs)
+
+	// Validators that withdraw to the node wallet instead of the signer can't be managed with it
+	bn.SetWithdrawalCredentials(0, getNodeWithdrawalCredentials(0x01))
+	_, err = sp.SubmitWithdrawalRequest(context.Background(), getMockValidatorPubkeys(bn)[0], 0)
+	require.ErrorIs(t, err, common.ErrValidatorNotOwned)
+}
+
+// Test that selecting a signer that wasn't provided is an error
+func TestTxSigner_Unknown(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.TransactionSigner.Value = "ledger"
+	_, err := common.NewServiceProviderFromConfigWithSigners(cfg, map[string]common.Signer{"remote": newMockRemoteSigner(t)})
+	require.ErrorIs(t, err, common.ErrUnknownTxSigner)
+	require.ErrorContains(t, err, "local, remote")
+}
+
+// Test that the wallet's sign-tx route signs with the selected signer instead of the node wallet's keystore
+func TestTxSigner_RemoteWalletSignTx(t *testing.T) {
+	signer := newMockRemoteSigner(t)
+	ec, _ := newTxTestExecutionClient(t)
+	sp := newRemoteSignerServiceProvider(t, signer, ec)
+
+	to := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	unsignedTx, err := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		To:        &to,
+		Value:     big.NewInt(1),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(2e9),
+		GasTipCap: big.NewInt(1e9),
+	}).MarshalBinary()
+	require.NoError(t, err)
+	data := callWalletRoute[api.WalletSignTxData](t, sp, "sign-tx", url.Values{"tx": {hex.EncodeToString(unsignedTx)}})
+
+	var tx types.Transaction
+	require.NoError(t, tx.UnmarshalBinary(data.SignedTx))
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx)
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), sender)
+	require.Equal(t, []*big.Int{big.NewInt(1)}, signer.ChainIDs)
+}
+
+// Test that the wallet's send route checks the selected signer's balance and builds the transfer from its address
+func TestTxSigner_RemoteWalletSend(t *testing.T) {
+	signer := newMockRemoteSigner(t)
+	ec, _ := newTxTestExecutionClient(t)
+	ec.SetResult("eth_syncing", false)
+	ec.SetResult("eth_getBlockByNumber", &types.Header{
+		Number:     big.NewInt(1000),
+		Difficulty: big.NewInt(0),
+		BaseFee:    big.NewInt(3e9),
+		Time:       uint64(time.Now().Unix()),
+	})
+	ec.Handlers["eth_getBalance"] = func(params []json.RawMessage) (any, error) {
+		var address ethcommon.Address
+		require.NoError(t, json.Unmarshal(params[0], &address))
+		if address != signer.Address() {
+			return (*hexutil.Big)(big.NewInt(0)), nil
+		}
+		return (*hexutil.Big)(big.NewInt(1e18)), nil
+	}
+	senders := []ethcommon.Address{}
+	ec.Handlers["eth_estimateGas"] = func(params []json.RawMessage) (any, error) {
+		var call struct {
+			From ethcommon.Address `json:"from"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &call))
+		senders = append(senders, call.From)
+		return hexutil.Uint64(21000), nil
+	}
+	sp := newRemoteSignerServiceProvider(t, signer, ec)
+
+	args := url.Values{
+		"amount":    {"500000000000000000"},
+		"token":     {"eth"},
+		"recipient": {"0x00000000000000000000000000000000000c0de1"},
+	}
+	data := callWalletRoute[api.WalletSendData](t, sp, "send", args)
+	require.Equal(t, big.NewInt(1e18), data.Balance)
+	require.True(t, data.CanSend)
+	require.NotNil(t, data.TxInfo)
+	require.Empty(t, data.TxInfo.SimulationResult.SimulationError)
+	require.NotEmpty(t, senders)
+	for _, sender := range senders {
+		require.Equal(t, signer.Address(), sender)
+	}
+}
+
+// Creates a service provider that signs with the provided signer, with the node wallet loaded
+func newRemoteSignerServiceProvider(t *testing.T, signer *mockRemoteSigner, ec *mockExecutionClient) *common.ServiceProvider {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", ec.URL)
+	cfg.TransactionSigner.Value = "remote"
+	sp, err := common.NewServiceProviderFromConfigWithSigners(cfg, map[string]common.Signer{"remote": signer})
+	require.NoError(t, err)
+	t.Cleanup(sp.Close)
+	require.NoError(t, os.MkdirAll(sp.GetConfig().UserDataPath.Value, 0700))
+	require.NoError(t, sp.GetWallet().Recover(wallet.DefaultNodeKeyPath, 0, testMnemonic, testWalletPassword, false, false))
+	return sp
+}
+
+// Calls one of the daemon's wallet routes and returns the data of its successful response
+func callWalletRoute[DataType any](t *testing.T, sp *common.ServiceProvider, path string, args url.Values) *DataType {
+	logger := log.NewDefaultLogger()
+	router := mux.NewRouter()
+	walletapi.NewWalletHandler(logger, logger.CreateContextWithLogger(context.Background()), sp).RegisterRoutes(router)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/wallet/"+path+"?"+args.Encode(), nil))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var response apitypes.ApiResponse[DataType]
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response.Data
+}
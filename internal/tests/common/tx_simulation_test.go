@@ -0,0 +1,118 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// A contract ABI with a custom error
+	simulationTestAbi string = `[{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`
+)
+
+// Test simulating transactions that succeed, revert in each of the supported ways, or can't be run
+func TestSimulateTransaction(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(simulationTestAbi))
+	require.NoError(t, err)
+	customError := contractAbi.Errors["InsufficientBalance"]
+	customArgs, err := customError.Inputs.Pack(big.NewInt(5), big.NewInt(10))
+	require.NoError(t, err)
+	customData := append(customError.ID.Bytes()[:4], customArgs...)
+
+	ec := newMockExecutionClient(t, 1)
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", ec.URL)
+	from := ethcommon.HexToAddress("0x90F79bf6EB2c4f870365E785982E1f101E93b906")
+	to := ethcommon.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		To:        &to,
+		Data:      []byte{0x12, 0x34, 0x56, 0x78},
+		Value:     big.NewInt(0),
+		Gas:       100000,
+		GasFeeCap: big.NewInt(2e9),
+		GasTipCap: big.NewInt(1e9),
+	})
+
+	for _, test := range []struct {
+		name     string
+		response func() (any, error)
+		result   common.SimResult
+		isError  bool
+	}{{
+		name:     "success",
+		response: func() (any, error) { return "0x01", nil },
+		result:   common.SimResult{ReturnData: []byte{0x01}},
+	}, {
+		name: "reason string",
+		response: func() (any, error) {
+			return nil, &mockRpcError{Code: 3, Message: "execution reverted: not allowed", Data: hexutil.Encode(newRevertReasonData(t, "not allowed"))}
+		},
+		result: common.SimResult{Reverted: true, RevertData: newRevertReasonData(t, "not allowed"), RevertReason: "not allowed"},
+	}, {
+		name: "Hardhat custom error",
+		response: func() (any, error) {
+			return nil, &mockRpcError{Code: -32603, Message: "Error: VM Exception while processing transaction: reverted with a custom error", Data: map[string]any{"message": "reverted", "data": hexutil.Encode(customData)}}
+		},
+		result: common.SimResult{Reverted: true, RevertData: customData, RevertReason: "InsufficientBalance(5, 10)"},
+	}, {
+		name:     "no reason",
+		response: func() (any, error) { return nil, errors.New("execution reverted") },
+		result:   common.SimResult{Reverted: true},
+	}, {
+		name:     "not a revert",
+		response: func() (any, error) { return nil, errors.New("insufficient funds for gas * price + value") },
+		isError:  true,
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			ec.Handlers["eth_call"] = func(params []json.RawMessage) (any, error) {
+				var call struct {
+					From  ethcommon.Address `json:"from"`
+					To    ethcommon.Address `json:"to"`
+					Input hexutil.Bytes     `json:"input"`
+				}
+				require.NoError(t, json.Unmarshal(params[0], &call))
+				require.Equal(t, from, call.From)
+				require.Equal(t, to, call.To)
+				require.Equal(t, tx.Data(), []byte(call.Input))
+				require.JSONEq(t, `"pending"`, string(params[1]))
+				return test.response()
+			}
+			result, err := sp.SimulateTransaction(context.Background(), tx, from, &contractAbi)
+			if test.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.result, result)
+		})
+	}
+
+	// Without the ABI, custom errors are left undecoded
+	ec.Handlers["eth_call"] = func(params []json.RawMessage) (any, error) {
+		return nil, &mockRpcError{Code: 3, Message: "execution reverted", Data: hexutil.Encode(customData)}
+	}
+	result, err := sp.SimulateTransaction(context.Background(), tx, from)
+	require.NoError(t, err)
+	require.True(t, result.Reverted)
+	require.Equal(t, hexutil.Bytes(customData), result.RevertData)
+	require.Empty(t, result.RevertReason)
+}
+
+// Encodes an Error(string) revert
+func newRevertReasonData(t *testing.T, reason string) []byte {
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err)
+	packed, err := abi.Arguments{{Type: stringType}}.Pack(reason)
+	require.NoError(t, err)
+	return append(ethcommon.FromHex("0x08c379a0"), packed...)
+}
@@ -0,0 +1,159 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	txPoolNodeAddress  = ethcommon.HexToAddress("0x18d3f8a8b2b2e8c6ec79ebdf3fdbd4dbd5c0b8c9")
+	txPoolOtherAddress = ethcommon.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+// Test getting the txpool counts and the node's own transactions
+func TestGetTxPoolStatus(t *testing.T) {
+	ec := newMockTxPoolExecutionClient(t)
+	sp := newTxPoolTestServiceProvider(t, ec)
+
+	status, err := sp.GetTxPoolStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x21), status.Pending)
+	require.Equal(t, uint64(0x3), status.Queued)
+	require.Len(t, status.OwnPending, 2)
+	require.Equal(t, uint64(4), status.OwnPending[0].Nonce)
+	require.Equal(t, uint64(5), status.OwnPending[1].Nonce)
+	require.Equal(t, big.NewInt(2e9), status.OwnPending[1].GetFeeCap())
+	require.Len(t, status.OwnQueued, 1)
+	require.Equal(t, uint64(9), status.OwnQueued[0].Nonce)
+	t.Logf("Found %d pending and %d queued transactions from the node", len(status.OwnPending), len(status.OwnQueued))
+}
+
+// Test that clients with txpool_contentFrom are only asked for the node's transactions
+func TestGetTxPoolStatus_ContentFrom(t *testing.T) {
+	ec := newMockTxPoolExecutionClient(t)
+	delete(ec.Results, "txpool_content")
+	requested := []ethcommon.Address{}
+	ec.Handlers["txpool_contentFrom"] = func(params []json.RawMessage) (any, error) {
+		var address ethcommon.Address
+		err := json.Unmarshal(params[0], &address)
+		if err != nil {
+			return nil, err
+		}
+		requested = append(requested, address)
+		return map[string]any{
+			"pending": map[string]any{
+				"7": newMockPooledTransaction(address, 7, 5e9),
+			},
+			"queued": map[string]any{},
+		}, nil
+	}
+	sp := newTxPoolTestServiceProvider(t, ec)
+
+	status, err := sp.GetTxPoolStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []ethcommon.Address{txPoolNodeAddress}, requested)
+	require.Len(t, status.OwnPending, 1)
+	require.Equal(t, uint64(7), status.OwnPending[0].Nonce)
+	require.Empty(t, status.OwnQueued)
+}
+
+// Test that the stuck transactions show up in the health report
+func TestGetTxPoolStatus_StuckTransactions(t *testing.T) {
+	ec := newMockTxPoolExecutionClient(t)
+	sp := newTxPoolTestServiceProvider(t, ec)
+
+	stuck, err := sp.GetStuckTransactions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, stuck, 2)
+	require.Equal(t, uint64(5), stuck[0].Nonce) // Fee cap below the base fee
+	require.Equal(t, uint64(9), stuck[1].Nonce) // Waiting on nonces 6-8
+
+	report := sp.GetHealthReport(context.Background())
+	stuckWarnings := 0
+	for _, warning := range report.Warnings {
+		if strings.Contains(warning, stuck[0].Hash.Hex()) || strings.Contains(warning, stuck[1].Hash.Hex()) {
+			stuckWarnings++
+		}
+	}
+	require.Equal(t, 2, stuckWarnings)
+	t.Logf("Health report warnings: %v", report.Warnings)
+}
+
+// Test clients that don't have the txpool API
+func TestGetTxPoolStatus_Unsupported(t *testing.T) {
+	ec := newMockExecutionClient(t, 17000)
+	sp := newTxPoolTestServiceProvider(t, ec)
+
+	_, err := sp.GetTxPoolStatus(context.Background())
+	require.ErrorIs(t, err, common.ErrTxPoolUnsupported)
+
+	report := sp.GetHealthReport(context.Background())
+	for _, warning := range report.Warnings {
+		require.NotContains(t, warning, "transaction")
+	}
+}
+
+// Creates a mock EC with a mempool containing transactions from the node and another address, and a base fee of 3 gwei
+func newMockTxPoolExecutionClient(t *testing.T) *mockExecutionClient {
+	ec := newMockExecutionClient(t, 17000)
+	ec.SetResult("txpool_status", map[string]string{
+		"pending": "0x21",
+		"queued":  "0x3",
+	})
+	ec.SetResult("txpool_content", map[string]any{
+		"pending": map[string]any{
+			txPoolNodeAddress.Hex(): map[string]any{
+				"4": newMockPooledTransaction(txPoolNodeAddress, 4, 5e9),
+				"5": newMockPooledTransaction(txPoolNodeAddress, 5, 2e9),
+			},
+			txPoolOtherAddress.Hex(): map[string]any{
+				"0": newMockPooledTransaction(txPoolOtherAddress, 0, 5e9),
+			},
+		},
+		"queued": map[string]any{
+			txPoolNodeAddress.Hex(): map[string]any{
+				"9": newMockPooledTransaction(txPoolNodeAddress, 9, 5e9),
+			},
+		},
+	})
+	ec.SetResult("eth_getBlockByNumber", &types.Header{
+		Number:     big.NewInt(1000),
+		Difficulty: big.NewInt(0),
+		BaseFee:    big.NewInt(3e9),
+	})
+	return ec
+}
+
+// Creates a txpool transaction with a deterministic hash
+func newMockPooledTransaction(from ethcommon.Address, nonce uint64, maxFeePerGas uint64) map[string]any {
+	hash := ethcommon.BigToHash(new(big.Int).SetUint64(nonce + 1))
+	hash[0] = from[0]
+	return map[string]any{
+		"hash":                 hash.Hex(),
+		"from":                 from.Hex(),
+		"to":                   txPoolOtherAddress.Hex(),
+		"nonce":                "0x" + new(big.Int).SetUint64(nonce).Text(16),
+		"maxFeePerGas":         "0x" + new(big.Int).SetUint64(maxFeePerGas).Text(16),
+		"maxPriorityFeePerGas": "0x3b9aca00",
+	}
+}
+
+// Creates a service provider that uses the provided EC, with the node wallet set to the node address
+func newTxPoolTestServiceProvider(t *testing.T, ec *mockExecutionClient) *common.ServiceProvider {
+	bn := newMockBeaconNode(t)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	err := os.MkdirAll(sp.GetConfig().UserDataPath.Value, 0700)
+	require.NoError(t, err)
+	err = sp.GetWallet().MasqueradeAsAddress(txPoolNodeAddress)
+	require.NoError(t, err)
+	return sp
+}
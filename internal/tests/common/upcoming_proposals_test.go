@@ -0,0 +1,111 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the proposals of the node's validators over the current epoch and beyond
+func TestGetUpcomingProposals(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(40, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.HeadSlot = 322
+	pubkeys := getMockValidatorPubkeys(bn)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: []beacon.ValidatorPubkey{pubkeys[0], pubkeys[5]},
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "constellation",
+		enabled: true,
+		pubkeys: []beacon.ValidatorPubkey{pubkeys[5], pubkeys[1]},
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "disabled",
+		enabled: false,
+		pubkeys: []beacon.ValidatorPubkey{pubkeys[2]},
+	})
+	ctx := context.Background()
+
+	// Validators 0, 1, and 5 propose slots 320, 321, and 325 in epoch 10, then slots 360, 361, and 365 in epoch 11
+	require.Equal(t, 5, getMockProposerPosition(325, 40))
+	require.Equal(t, 0, getMockProposerPosition(360, 40))
+	proposals, err := sp.GetUpcomingProposals(ctx, 4)
+	require.NoError(t, err)
+	type expectedProposal struct {
+		epoch uint64
+		slot  uint64
+		index int
+	}
+	expected := []expectedProposal{
+		{epoch: 10, slot: 325, index: 5},
+		{epoch: 11, slot: 360, index: 0},
+		{epoch: 11, slot: 361, index: 1},
+		{epoch: 11, slot: 365, index: 5},
+	}
+	require.Len(t, proposals, len(expected)+2)
+	for i, proposal := range expected {
+		require.Equal(t, common.UpcomingProposal{
+			Epoch:          proposal.epoch,
+			Determinable:   true,
+			Slot:           proposal.slot,
+			ValidatorIndex: bn.Validators[proposal.index].Index,
+			Pubkey:         pubkeys[proposal.index],
+			Time:           time.Unix(mockGenesisTime+int64(proposal.slot)*12, 0),
+		}, proposals[i])
+	}
+
+	// Epochs past the lookahead are marked instead of guessed
+	for i, epoch := range []uint64{12, 13} {
+		require.Equal(t, common.UpcomingProposal{
+			Epoch: epoch,
+			Time:  time.Unix(mockGenesisTime+int64(epoch*32)*12, 0),
+		}, proposals[len(expected)+i])
+	}
+
+	// A single epoch only covers the current one
+	proposals, err = sp.GetUpcomingProposals(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, proposals, 1)
+	require.Equal(t, uint64(325), proposals[0].Slot)
+
+	_, err = sp.GetUpcomingProposals(ctx, 0)
+	require.Error(t, err)
+}
+
+// Test that the next epoch is marked when the Beacon Node doesn't know its proposers yet
+func TestGetUpcomingProposals_ProposerLookahead(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(40, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.HeadSlot = 330
+	bn.ProposerLookahead = 0
+	pubkeys := getMockValidatorPubkeys(bn)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys[:1],
+	})
+
+	// Validator 0's proposal in epoch 10 has passed, and epoch 11 isn't known yet
+	proposals, err := sp.GetUpcomingProposals(context.Background(), 3)
+	require.NoError(t, err)
+	require.Len(t, proposals, 2)
+	for i, proposal := range proposals {
+		require.False(t, proposal.Determinable)
+		require.Equal(t, uint64(11+i), proposal.Epoch)
+		require.Zero(t, proposal.Slot)
+	}
+
+	// The current epoch's proposers are required
+	bn.SetUnavailable(true)
+	_, err = sp.GetUpcomingProposals(context.Background(), 3)
+	require.Error(t, err)
+}
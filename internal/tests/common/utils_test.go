@@ -0,0 +1,73 @@
+package common_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	dclient "github.com/docker/docker/client"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	bclient "github.com/rocket-pool/node-manager-core/beacon/client"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/rocket-pool/node-manager-core/node/services"
+	"github.com/stretchr/testify/require"
+)
+
+// Creates a service provider in a temporary directory that uses the provided Beacon Node.
+// The Execution Client is left unreachable unless the provided URL is set.
+func newTestServiceProvider(t testing.TB, bnUrl string, ecUrl string) *common.ServiceProvider {
+	return newTestServiceProviderFromConfig(t, newTestConfig(t, bnUrl, ecUrl))
+}
+
+// Creates a Hyperdrive config in a temporary directory that uses the provided external clients
+func newTestConfig(t testing.TB, bnUrl string, ecUrl string) *hdconfig.HyperdriveConfig {
+	if ecUrl == "" {
+		ecUrl = "http://127.0.0.1:1"
+	}
+	cfg := hdconfig.NewHyperdriveConfig(t.TempDir())
+	cfg.ClientMode.Value = config.ClientMode_External
+	cfg.ExternalBeaconClient.HttpUrl.Value = bnUrl
+	cfg.ExternalExecutionClient.HttpUrl.Value = ecUrl
+	return cfg
+}
+
+// Creates a service provider from a test config, closing it when the test is done
+func newTestServiceProviderFromConfig(t testing.TB, cfg *hdconfig.HyperdriveConfig) *common.ServiceProvider {
+	sp, err := common.NewServiceProviderFromConfig(cfg)
+	require.NoError(t, err)
+	t.Cleanup(sp.Close)
+	return sp
+}
+
+// Creates a service provider from a test config that uses the provided Docker client
+func newDockerTestServiceProvider(t *testing.T, cfg *hdconfig.HyperdriveConfig, docker dclient.APIClient) *common.ServiceProvider {
+	primaryEcUrl, _ := cfg.GetExecutionClientUrls()
+	primaryBnUrl, _ := cfg.GetBeaconNodeUrls()
+	return newDockerTestServiceProviderWithUrls(t, cfg, docker, primaryEcUrl, primaryBnUrl)
+}
+
+// Creates a service provider that uses the provided Docker client and client URLs, regardless of the config's client mode
+func newDockerTestServiceProviderWithUrls(t *testing.T, cfg *hdconfig.HyperdriveConfig, docker dclient.APIClient, primaryEcUrl string, primaryBnUrl string) *common.ServiceProvider {
+	resources := cfg.GetNetworkResources()
+	ec, err := ethclient.Dial(primaryEcUrl)
+	require.NoError(t, err)
+	bn := bclient.NewStandardHttpClient(primaryBnUrl, hdconfig.ClientTimeout)
+	ecManager := services.NewExecutionClientManager(ec, resources.ChainID, hdconfig.ClientTimeout)
+	bnManager := services.NewBeaconClientManager(bn, resources.ChainID, hdconfig.ClientTimeout)
+
+	bnApi := common.NewBeaconApiClient(primaryBnUrl, "", hdconfig.ClientTimeout)
+
+	sp, err := common.NewServiceProviderFromCustomServicesWithBeaconApi(cfg, resources, ecManager, bnManager, bnApi, docker)
+	require.NoError(t, err)
+	t.Cleanup(sp.Close)
+	return sp
+}
+
+// Writes a JSON response for a mock server
+func writeJson(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
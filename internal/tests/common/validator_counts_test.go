@@ -0,0 +1,75 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test counting a large, mixed set of validators across modules, including one shared by two modules and a disabled module
+func TestCountValidatorsByStatus(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	added := bn.AddSeededValidators(125, 1300)
+	bn.AddValidators(1, beacon.ValidatorState_ActiveOngoing, 32e9) // Belongs to the disabled module
+	pubkeys := make([]beacon.ValidatorPubkey, len(bn.Validators))
+	for i, validator := range bn.Validators {
+		pubkeys[i] = beacon.ValidatorPubkey(validator.Validator.Pubkey)
+	}
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	// Split the seeded set between two modules, with the boundary validator in both
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys[:701],
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "constellation",
+		enabled: true,
+		pubkeys: pubkeys[700:1300],
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "disabled",
+		enabled: false,
+		pubkeys: pubkeys[1300:],
+	})
+
+	counts, err := sp.CountValidatorsByStatus(context.Background())
+	require.NoError(t, err)
+	expected := map[common.ValidatorStatus]int{
+		common.ValidatorStatus_Pending:      added[beacon.ValidatorState_PendingInitialized] + added[beacon.ValidatorState_PendingQueued],
+		common.ValidatorStatus_Active:       added[beacon.ValidatorState_ActiveOngoing],
+		common.ValidatorStatus_Exiting:      added[beacon.ValidatorState_ActiveExiting] + added[beacon.ValidatorState_ExitedUnslashed],
+		common.ValidatorStatus_Slashed:      added[beacon.ValidatorState_ActiveSlashed] + added[beacon.ValidatorState_ExitedSlashed],
+		common.ValidatorStatus_Withdrawable: added[beacon.ValidatorState_WithdrawalPossible],
+		common.ValidatorStatus_Withdrawn:    added[beacon.ValidatorState_WithdrawalDone],
+	}
+	require.Equal(t, expected, counts)
+	for status, count := range expected {
+		require.NotZero(t, count, "seeded set has no %s validators", status)
+	}
+	t.Logf("Validator counts: %v", counts)
+
+	// The health report should include them and warn about the slashed ones
+	report := sp.GetHealthReport(context.Background())
+	require.Equal(t, expected, report.ValidatorCounts)
+	require.Contains(t, report.Warnings, fmt.Sprintf("%d of the node's validators have been slashed.", expected[common.ValidatorStatus_Slashed]))
+}
+
+// Test that a node without any modules has zero of everything
+func TestCountValidatorsByStatus_NoModules(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(2, beacon.ValidatorState_ActiveOngoing, 32e9)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	counts, err := sp.CountValidatorsByStatus(context.Background())
+	require.NoError(t, err)
+	require.Len(t, counts, 6)
+	for status, count := range counts {
+		require.Zero(t, count, "unexpected %s validators", status)
+	}
+}
@@ -0,0 +1,76 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The path the mock Beacon Node serves validators on for the head state
+	headValidatorsPath string = "/eth/v1/beacon/states/head/validators"
+)
+
+// Test looking up validator indices, with the second lookup coming from the cache
+func TestGetValidatorIndex(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddSeededValidators(7, 20)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	pubkeys := getMockValidatorPubkeys(bn)
+	for i, pubkey := range pubkeys {
+		index, found, err := sp.GetValidatorIndex(context.Background(), pubkey)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, uint64(i), index)
+	}
+	require.Equal(t, len(pubkeys), bn.GetRequestCount(headValidatorsPath))
+
+	for i, pubkey := range pubkeys {
+		index, found, err := sp.GetValidatorIndex(context.Background(), pubkey)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, uint64(i), index)
+	}
+	require.Equal(t, len(pubkeys), bn.GetRequestCount(headValidatorsPath))
+}
+
+// Test that a validator whose deposit hasn't been processed isn't found, and is looked up again once it is
+func TestGetValidatorIndex_NotInState(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	pubkey := beacon.ValidatorPubkey{0xaa, 0x00, 0x03}
+
+	_, found, err := sp.GetValidatorIndex(context.Background(), pubkey)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	bn.AddValidators(1, beacon.ValidatorState_PendingInitialized, 32e9)
+	index, found, err := sp.GetValidatorIndex(context.Background(), pubkey)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, uint64(3), index)
+	require.Equal(t, 2, bn.GetRequestCount(headValidatorsPath))
+}
+
+// Measures repeated index lookups; with the cache, the Beacon Node is only asked once per validator no matter how many
+// lookups there are
+func BenchmarkGetValidatorIndex(b *testing.B) {
+	bn := newMockBeaconNode(b)
+	bn.AddSeededValidators(7, 100)
+	sp := newTestServiceProvider(b, bn.URL, "")
+	pubkeys := getMockValidatorPubkeys(bn)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := sp.GetValidatorIndex(context.Background(), pubkeys[i%len(pubkeys)])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(bn.GetRequestCount(headValidatorsPath))/float64(b.N), "requests/op")
+}
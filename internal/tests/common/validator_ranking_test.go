@@ -0,0 +1,117 @@
+package common_test
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// The attestation record of a mock validator over a ranking window
+type mockAttestationRecord struct {
+	expected      uint64
+	missed        uint64
+	totalDistance uint64
+}
+
+// Test ranking a seeded set of validators with varied attestation performance
+func TestRankValidatorPerformance(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.Spec["SLOTS_PER_EPOCH"] = "4"
+	bn.HeadSlot = 24
+	bn.AddValidators(13, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.Validators[11].Validator.ActivationEpoch = 3
+	pubkeys := getMockValidatorPubkeys(bn)
+	records := addSeededAttestations(bn, 67, 2, 5)
+
+	sp := newTestServiceProvider(t, bn.URL, "")
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "stakewise",
+		enabled: true,
+		pubkeys: pubkeys[:12],
+	})
+	sp.RegisterStakeContributor(&mockStakeContributor{
+		name:    "disabled",
+		enabled: false,
+		pubkeys: pubkeys[12:],
+	})
+
+	for _, weights := range []common.PerformanceWeights{
+		common.DefaultPerformanceWeights,
+		{InclusionDistance: 1},
+	} {
+		ranks, err := sp.RankValidatorPerformance(context.Background(), 4, weights)
+		require.NoError(t, err)
+		require.Len(t, ranks, 12)
+
+		// Validator 11 joined partway through the window, so it isn't ranked
+		unranked := ranks[11]
+		require.Equal(t, pubkeys[11], unranked.Pubkey)
+		require.True(t, unranked.RecentlyActivated)
+		require.Zero(t, unranked.Rank)
+
+		for i, rank := range ranks[:11] {
+			require.False(t, rank.RecentlyActivated)
+			require.Equal(t, i+1, rank.Rank)
+			if i > 0 {
+				require.LessOrEqual(t, rank.Score, ranks[i-1].Score)
+			}
+			index, err := strconv.Atoi(rank.Index)
+			require.NoError(t, err)
+			require.Equal(t, pubkeys[index], rank.Pubkey)
+			record := records[rank.Index]
+			require.Equal(t, record.expected, rank.Stats.ExpectedAttestations)
+			require.Equal(t, record.missed, rank.Stats.MissedAttestations)
+
+			expectedScore := weights.MissedAttestations * float64(record.missed) / float64(record.expected)
+			if included := record.expected - record.missed; included > 0 {
+				expectedScore += weights.InclusionDistance * (float64(record.totalDistance)/float64(included) - 1)
+			}
+			require.InDelta(t, expectedScore, rank.Score, 1e-9, "validator %s", rank.Index)
+		}
+		require.Greater(t, ranks[0].Score, ranks[10].Score)
+	}
+
+	_, err := sp.RankValidatorPerformance(context.Background(), 4, common.PerformanceWeights{MissedAttestations: -1})
+	require.Error(t, err)
+}
+
+// Assigns committees for the provided epochs and includes each duty's attestation after a seeded random delay, or not at
+// all. Returns the resulting record of each validator, keyed by index.
+func addSeededAttestations(bn *mockBeaconNode, seed int64, startEpoch uint64, endEpoch uint64) map[string]*mockAttestationRecord {
+	random := rand.New(rand.NewSource(seed))
+	records := map[string]*mockAttestationRecord{}
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		bn.AssignCommittees(epoch)
+		for _, committee := range bn.Committees[epoch] {
+			// Bits for each inclusion slot, with each member at its committee position and the length bit after them
+			slot := uint64(committee.Slot)
+			bits := map[uint64]byte{}
+			for position, index := range committee.Validators {
+				record, exists := records[index]
+				if !exists {
+					record = &mockAttestationRecord{}
+					records[index] = record
+				}
+				record.expected++
+				distance := uint64(random.Intn(4))
+				if distance == 0 || slot+distance > bn.HeadSlot {
+					record.missed++
+					continue
+				}
+				record.totalDistance += distance
+				bits[slot+distance] |= 1 << position
+			}
+			for inclusionSlot, aggregationBits := range bits {
+				aggregationBits |= 1 << len(committee.Validators)
+				attestation := newTestAttestation(slot, 0, "0x"+strconv.FormatUint(uint64(aggregationBits), 16))
+				bn.Attestations[inclusionSlot] = append(bn.Attestations[inclusionSlot], attestation)
+			}
+		}
+	}
+	return records
+}
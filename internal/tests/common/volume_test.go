@@ -0,0 +1,85 @@
+package common_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types/volume"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/nodeset-org/osha/docker"
+	"github.com/rocket-pool/node-manager-core/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the usage of a Docker-managed EC volume and a bind-mounted BN volume
+func TestGetVolumeUsage(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	cfg.ClientMode.Value = config.ClientMode_Local
+	bindDir := t.TempDir()
+
+	// Create the volumes
+	mock := docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	ecVolumeName := cfg.GetDockerArtifactName(hdconfig.ExecutionClientDataVolume)
+	bnVolumeName := cfg.GetDockerArtifactName(hdconfig.BeaconNodeDataVolume)
+	err := mock.Mock_AddVolume(volume.Volume{
+		Name:       ecVolumeName,
+		Driver:     "local",
+		Mountpoint: "/var/lib/docker/volumes/" + ecVolumeName + "/_data",
+		UsageData:  &volume.UsageData{},
+	})
+	require.NoError(t, err)
+	err = mock.Mock_SetVolumeDiskUsage(ecVolumeName, 1_250_000_000_000)
+	require.NoError(t, err)
+	err = mock.Mock_AddVolume(volume.Volume{
+		Name:       bnVolumeName,
+		Driver:     "local",
+		Mountpoint: "/var/lib/docker/volumes/" + bnVolumeName + "/_data",
+		Options: map[string]string{
+			"type":   "none",
+			"o":      "bind",
+			"device": bindDir,
+		},
+		UsageData: &volume.UsageData{Size: -1},
+	})
+	require.NoError(t, err)
+
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	usages, err := sp.GetVolumeUsage(context.Background())
+	require.NoError(t, err)
+	require.Len(t, usages, 2)
+
+	// The managed volume's size comes from Docker; its mountpoint isn't visible here
+	ecUsage := usages[hdconfig.ExecutionClientDataVolume]
+	require.Equal(t, ecVolumeName, ecUsage.Name)
+	require.False(t, ecUsage.IsBindMount)
+	require.Equal(t, uint64(1_250_000_000_000), ecUsage.UsedBytes)
+	require.False(t, ecUsage.HasFilesystemInfo)
+	require.False(t, ecUsage.NearlyFull)
+	t.Logf("EC volume uses %d bytes", ecUsage.UsedBytes)
+
+	// The bind mount is read from the host filesystem
+	bnUsage := usages[hdconfig.BeaconNodeDataVolume]
+	require.True(t, bnUsage.IsBindMount)
+	require.Equal(t, bindDir, bnUsage.Path)
+	require.True(t, bnUsage.HasFilesystemInfo)
+	require.NotZero(t, bnUsage.TotalBytes)
+	require.LessOrEqual(t, bnUsage.AvailableBytes, bnUsage.TotalBytes)
+	require.Equal(t, float64(bnUsage.AvailableBytes) < float64(bnUsage.TotalBytes)*0.1, bnUsage.NearlyFull)
+	t.Logf("BN volume filesystem has %d of %d bytes available", bnUsage.AvailableBytes, bnUsage.TotalBytes)
+
+	// The health report should include both
+	report := sp.GetHealthReport(context.Background())
+	require.Len(t, report.Volumes, 2)
+}
+
+// Test that external client mode doesn't report any volumes
+func TestGetVolumeUsage_ExternalMode(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	mock := docker.NewDockerMockManager(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	sp := newDockerTestServiceProvider(t, cfg, mock)
+	usages, err := sp.GetVolumeUsage(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, usages)
+}
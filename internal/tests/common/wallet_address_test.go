@@ -0,0 +1,51 @@
+package common_test
+
+import (
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the recovered wallet matches its own address
+func TestVerifyWalletAddress_Match(t *testing.T) {
+	sp := newOwnershipTestServiceProvider(t)
+	require.NoError(t, sp.VerifyWalletAddress(testNodeAddress))
+}
+
+// Test that a different expected address is reported with both addresses
+func TestVerifyWalletAddress_Mismatch(t *testing.T) {
+	sp := newOwnershipTestServiceProvider(t)
+	expected := ethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+
+	err := sp.VerifyWalletAddress(expected)
+	require.ErrorIs(t, err, common.ErrWalletAddressMismatch)
+	require.ErrorContains(t, err, testNodeAddress.Hex())
+	require.ErrorContains(t, err, expected.Hex())
+}
+
+// Test that the check fails without a wallet rather than passing
+func TestVerifyWalletAddress_NoWallet(t *testing.T) {
+	sp := newTestServiceProvider(t, "http://127.0.0.1:1", "")
+	err := sp.VerifyWalletAddress(testNodeAddress)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, common.ErrWalletAddressMismatch)
+}
+
+// Test that the expected node address setting is optional but must be valid when set
+func TestExpectedNodeAddress_Config(t *testing.T) {
+	cfg := newTestConfig(t, "http://127.0.0.1:1", "")
+	_, isSet := cfg.GetExpectedNodeAddress()
+	require.False(t, isSet)
+	require.Empty(t, cfg.Validate())
+
+	cfg.ExpectedNodeAddress.Value = testNodeAddress.Hex()
+	address, isSet := cfg.GetExpectedNodeAddress()
+	require.True(t, isSet)
+	require.Equal(t, testNodeAddress, address)
+	require.Empty(t, cfg.Validate())
+
+	cfg.ExpectedNodeAddress.Value = "0x1234"
+	require.Len(t, cfg.Validate(), 1)
+}
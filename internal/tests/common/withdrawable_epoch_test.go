@@ -0,0 +1,52 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test getting the withdrawable epoch of exiting validators, and that validators that aren't exiting are rejected
+func TestGetWithdrawableEpoch(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(1, beacon.ValidatorState_ActiveExiting, 32e9)
+	bn.AddValidators(1, beacon.ValidatorState_ActiveSlashed, 32e9)
+	bn.AddValidators(1, beacon.ValidatorState_ActiveExiting, 32e9)
+	bn.AddValidators(1, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.SetExitEpochs(0, 20, 276)
+	bn.SetExitEpochs(1, 20, 8212)
+	bn.SetExitEpochs(2, 20, common.FarFutureEpoch)
+	sp := newTestServiceProvider(t, bn.URL, "")
+	ctx := context.Background()
+	epochTime := func(epoch uint64) time.Time {
+		return time.Unix(mockGenesisTime+int64(epoch)*32*12, 0)
+	}
+
+	tests := []struct {
+		name  string
+		index int
+		epoch uint64
+	}{
+		{name: "exiting", index: 0, epoch: 276},
+		{name: "slashed", index: 1, epoch: 8212},
+		{name: "estimated from the exit epoch", index: 2, epoch: 20 + 256},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pubkey := beacon.ValidatorPubkey(bn.Validators[test.index].Validator.Pubkey)
+			epoch, withdrawableTime, err := sp.GetWithdrawableEpoch(ctx, pubkey)
+			require.NoError(t, err)
+			require.Equal(t, test.epoch, epoch)
+			require.Equal(t, epochTime(test.epoch), withdrawableTime)
+		})
+	}
+
+	_, _, err := sp.GetWithdrawableEpoch(ctx, beacon.ValidatorPubkey(bn.Validators[3].Validator.Pubkey))
+	require.ErrorIs(t, err, common.ErrValidatorNotExiting)
+	_, _, err = sp.GetWithdrawableEpoch(ctx, beacon.ValidatorPubkey{0x01})
+	require.ErrorIs(t, err, common.ErrValidatorNotFound)
+}
@@ -0,0 +1,186 @@
+package common_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The fee the mock request contracts charge, in wei
+	testExecutionRequestFee int64 = 3
+)
+
+// Test submitting full exits and partial withdrawals for the node's validators
+func TestSubmitWithdrawalRequest(t *testing.T) {
+	bn, _, sp, sent := newExecutionRequestTestServiceProvider(t)
+	bn.SetWithdrawalCredentials(1, getNodeWithdrawalCredentials(0x02))
+	pubkeys := getMockValidatorPubkeys(bn)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name   string
+		pubkey beacon.ValidatorPubkey
+		amount uint64
+	}{{
+		name:   "full exit",
+		pubkey: pubkeys[0],
+		amount: 0,
+	}, {
+		name:   "partial withdrawal",
+		pubkey: pubkeys[1],
+		amount: 1e9,
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			hash, err := sp.SubmitWithdrawalRequest(ctx, test.pubkey, test.amount)
+			require.NoError(t, err)
+			tx := (*sent)[len(*sent)-1]
+			require.Equal(t, tx.Hash(), hash)
+			require.Equal(t, common.WithdrawalRequestPredeployAddress, *tx.To())
+			require.Equal(t, big.NewInt(testExecutionRequestFee), tx.Value())
+			require.Len(t, tx.Data(), 56)
+			require.Equal(t, test.pubkey[:], tx.Data()[:48])
+			require.Equal(t, test.amount, binary.BigEndian.Uint64(tx.Data()[48:]))
+			sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+			require.NoError(t, err)
+			require.Equal(t, testNodeAddress, sender)
+		})
+	}
+}
+
+// Test that requests for validators the node can't withdraw from are rejected before anything is submitted
+func TestSubmitWithdrawalRequest_Invalid(t *testing.T) {
+	bn, _, sp, sent := newExecutionRequestTestServiceProvider(t)
+	bn.AddValidators(1, beacon.ValidatorState_PendingQueued, 32e9)
+	bn.SetWithdrawalCredentials(1, getMockWithdrawalCredentials(0x00, 1))
+	bn.SetWithdrawalCredentials(2, getMockWithdrawalCredentials(0x01, 2))
+	bn.SetWithdrawalCredentials(3, getNodeWithdrawalCredentials(0x01))
+	pubkeys := getMockValidatorPubkeys(bn)
+	ctx := context.Background()
+
+	_, err := sp.SubmitWithdrawalRequest(ctx, pubkeys[0], 1e9)
+	require.ErrorIs(t, err, common.ErrPartialWithdrawalNotCompounding)
+	_, err = sp.SubmitWithdrawalRequest(ctx, pubkeys[1], 0)
+	require.ErrorIs(t, err, common.ErrNoExecutionCredentials)
+	_, err = sp.SubmitWithdrawalRequest(ctx, pubkeys[2], 0)
+	require.ErrorIs(t, err, common.ErrValidatorNotOwned)
+	_, err = sp.SubmitWithdrawalRequest(ctx, pubkeys[3], 0)
+	require.ErrorIs(t, err, common.ErrValidatorNotActive)
+	_, err = sp.SubmitWithdrawalRequest(ctx, beacon.ValidatorPubkey{0xbb}, 0)
+	require.ErrorIs(t, err, common.ErrValidatorNotFound)
+	require.Empty(t, *sent)
+}
+
+// Test that withdrawal requests aren't submitted before Electra
+func TestSubmitWithdrawalRequest_PreElectra(t *testing.T) {
+	bn, _, sp, sent := newExecutionRequestTestServiceProvider(t)
+	delete(bn.Spec, "ELECTRA_FORK_VERSION")
+	delete(bn.Spec, "ELECTRA_FORK_EPOCH")
+	bn.ForkVersion = []byte{0x04, 0x00, 0x00, 0x00}
+
+	_, err := sp.SubmitWithdrawalRequest(context.Background(), getMockValidatorPubkeys(bn)[0], 0)
+	require.ErrorIs(t, err, common.ErrElectraNotActive)
+	require.Empty(t, *sent)
+}
+
+// Test that withdrawal requests are still submitted once the chain has moved past Electra
+func TestSubmitWithdrawalRequest_PostElectra(t *testing.T) {
+	bn, _, sp, sent := newExecutionRequestTestServiceProvider(t)
+	bn.Spec["FULU_FORK_VERSION"] = "0x06000000"
+	bn.Spec["FULU_FORK_EPOCH"] = "8"
+	bn.ForkVersion = []byte{0x06, 0x00, 0x00, 0x00}
+
+	_, err := sp.SubmitWithdrawalRequest(context.Background(), getMockValidatorPubkeys(bn)[0], 0)
+	require.NoError(t, err)
+	require.Len(t, *sent, 1)
+}
+
+// Test that requests the contract would reject aren't submitted
+func TestSubmitWithdrawalRequest_Reverted(t *testing.T) {
+	bn, ec, sp, sent := newExecutionRequestTestServiceProvider(t)
+	ec.Handlers["eth_estimateGas"] = func(params []json.RawMessage) (any, error) {
+		return nil, &mockRpcError{Code: 3, Message: "execution reverted"}
+	}
+
+	_, err := sp.SubmitWithdrawalRequest(context.Background(), getMockValidatorPubkeys(bn)[0], 0)
+	require.ErrorIs(t, err, common.ErrTransactionWouldRevert)
+	require.Empty(t, *sent)
+}
+
+// Creates a service provider with the test wallet loaded, a mock Beacon Node on Electra with 3 active validators that
+// withdraw to the node's address, and a mock Execution Client that accepts transactions to the request contracts.
+// Returns the transactions the Execution Client received.
+func newExecutionRequestTestServiceProvider(t *testing.T) (*mockBeaconNode, *mockExecutionClient, *common.ServiceProvider, *[]*types.Transaction) {
+	bn := newMockBeaconNode(t)
+	bn.ActivateElectra()
+	bn.AddValidators(3, beacon.ValidatorState_ActiveOngoing, 32e9)
+	for i := range bn.Validators {
+		bn.SetWithdrawalCredentials(i, getNodeWithdrawalCredentials(0x01))
+	}
+
+	ec, sent := newTxTestExecutionClient(t)
+	sp := newTestServiceProvider(t, bn.URL, ec.URL)
+	require.NoError(t, os.MkdirAll(sp.GetConfig().UserDataPath.Value, 0700))
+	require.NoError(t, sp.GetWallet().Recover(wallet.DefaultNodeKeyPath, 0, testMnemonic, testWalletPassword, false, false))
+	return bn, ec, sp, sent
+}
+
+// Creates a mock Execution Client on chain 1 that accepts transactions to the request contracts, charging them
+// testExecutionRequestFee. Returns the transactions it received.
+func newTxTestExecutionClient(t *testing.T) (*mockExecutionClient, *[]*types.Transaction) {
+	sent := []*types.Transaction{}
+	ec := newMockExecutionClient(t, 1)
+	ec.SetResult("eth_estimateGas", hexutil.Uint64(60000))
+	ec.SetResult("eth_maxPriorityFeePerGas", (*hexutil.Big)(big.NewInt(1e9)))
+	ec.SetResult("eth_getTransactionCount", hexutil.Uint64(7))
+	ec.SetResult("eth_getBlockByNumber", &types.Header{
+		Number:     big.NewInt(1000),
+		Difficulty: big.NewInt(0),
+		BaseFee:    big.NewInt(3e9),
+	})
+	ec.Handlers["eth_call"] = func(params []json.RawMessage) (any, error) {
+		var call struct {
+			To    ethcommon.Address `json:"to"`
+			Input hexutil.Bytes     `json:"input"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &call))
+		if len(call.Input) > 0 {
+			return nil, errors.New("unexpected call data")
+		}
+		return hexutil.Encode(ethcommon.LeftPadBytes(big.NewInt(testExecutionRequestFee).Bytes(), 32)), nil
+	}
+	ec.Handlers["eth_sendRawTransaction"] = func(params []json.RawMessage) (any, error) {
+		var raw hexutil.Bytes
+		require.NoError(t, json.Unmarshal(params[0], &raw))
+		var tx types.Transaction
+		require.NoError(t, tx.UnmarshalBinary(raw))
+		sent = append(sent, &tx)
+		return tx.Hash(), nil
+	}
+	return ec, &sent
+}
+
+// Creates withdrawal credentials with the provided prefix that point to the test node's address
+func getNodeWithdrawalCredentials(prefix byte) []byte {
+	return getAddressWithdrawalCredentials(prefix, testNodeAddress)
+}
+
+// Creates withdrawal credentials with the provided prefix that point to an address
+func getAddressWithdrawalCredentials(prefix byte, address ethcommon.Address) []byte {
+	credentials := make([]byte, 32)
+	credentials[0] = prefix
+	copy(credentials[12:], address.Bytes())
+	return credentials
+}
@@ -0,0 +1,117 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+// Test finding withdrawals for a set of validators across several chunks of blocks
+func TestGetWithdrawals(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	for slot := uint64(100); slot <= 200; slot++ {
+		bn.AddWithdrawals(slot, slot%16, slot%16+16)
+	}
+	delete(bn.Withdrawals, 150) // A missed slot
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	withdrawals, err := sp.GetWithdrawals(context.Background(), 100, 200, []uint64{3, 20})
+	require.NoError(t, err)
+
+	// Validator 3 is withdrawn in every slot where slot % 16 == 3, and validator 20 where slot % 16 == 4
+	expectedSlots := []uint64{}
+	for slot := uint64(100); slot <= 200; slot++ {
+		if slot%16 == 3 || slot%16 == 4 {
+			expectedSlots = append(expectedSlots, slot)
+		}
+	}
+	require.Len(t, withdrawals, len(expectedSlots))
+	for i, withdrawal := range withdrawals {
+		require.Equal(t, expectedSlots[i], withdrawal.Slot)
+		require.Contains(t, []uint64{3, 20}, withdrawal.ValidatorIndex)
+		require.Equal(t, byte(withdrawal.ValidatorIndex), withdrawal.Address[19])
+		require.Equal(t, uint64(1e7), withdrawal.Amount)
+	}
+	t.Logf("Found %d withdrawals in slot order", len(withdrawals))
+}
+
+// Test that failed blocks are reported alongside the withdrawals from the rest of the range
+func TestGetWithdrawals_PartialFailure(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	for slot := uint64(10); slot <= 20; slot++ {
+		bn.AddWithdrawals(slot, 1)
+	}
+	bn.FailingSlots[12] = true
+	bn.FailingSlots[18] = true
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	withdrawals, err := sp.GetWithdrawals(context.Background(), 10, 20, []uint64{1})
+	require.Len(t, withdrawals, 9)
+	var scanErr *common.WithdrawalScanError
+	require.True(t, errors.As(err, &scanErr))
+	require.Equal(t, []uint64{12, 18}, scanErr.FailedSlots)
+	t.Logf("Scan returned %d withdrawals with error: %s", len(withdrawals), err.Error())
+}
+
+// Test that cancelling a scan returns the context error
+func TestGetWithdrawals_Cancelled(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddWithdrawals(10, 1)
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	withdrawals, err := sp.GetWithdrawals(ctx, 0, 1000, []uint64{1})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, withdrawals)
+}
+
+// Test classifying withdrawals on either side of the 32 ETH threshold
+func TestClassifyWithdrawal(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   uint64
+		balance  uint64
+		expected common.WithdrawalType
+	}{
+		{"excess balance sweep", 0.05e9, 32.05e9, common.WithdrawalType_Partial},
+		{"sweep leaving exactly 32 ETH", 1, 32e9 + 1, common.WithdrawalType_Partial},
+		{"would leave just under 32 ETH", 2, 32e9 + 1, common.WithdrawalType_Unknown},
+		{"compounding sweep", 1.5e9, 2049.5e9, common.WithdrawalType_Partial},
+		{"requested partial withdrawal", 100e9, 500e9, common.WithdrawalType_Partial},
+		{"exit above 32 ETH", 32.05e9, 32.05e9, common.WithdrawalType_FullExit},
+		{"exit below 32 ETH", 31.9e9, 31.9e9, common.WithdrawalType_FullExit},
+		{"exit of a compounding validator", 2048e9, 2048e9, common.WithdrawalType_FullExit},
+		{"more than the balance", 33e9, 32e9, common.WithdrawalType_Unknown},
+		{"no amount", 0, 32e9, common.WithdrawalType_Unknown},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withdrawal := common.Withdrawal{Amount: test.amount}
+			require.Equal(t, test.expected, common.ClassifyWithdrawal(withdrawal, test.balance))
+		})
+	}
+}
+
+// Test that scanned withdrawals are classified from the validators' balances after their blocks
+func TestGetWithdrawals_Classified(t *testing.T) {
+	bn := newMockBeaconNode(t)
+	bn.AddValidators(1, beacon.ValidatorState_ActiveOngoing, 32e9)
+	bn.AddValidators(1, beacon.ValidatorState_WithdrawalDone, 0)
+	bn.Validators[1].Balance = 0
+	bn.AddWithdrawals(10, 0, 1)
+	bn.Withdrawals[10][1].Amount = 31.9e9 // The exited validator's whole balance
+	bn.AddWithdrawals(11, 2)              // A validator the Beacon Node doesn't know about
+	sp := newTestServiceProvider(t, bn.URL, "")
+
+	withdrawals, err := sp.GetWithdrawals(context.Background(), 10, 11, []uint64{0, 1, 2})
+	require.NoError(t, err)
+	require.Len(t, withdrawals, 3)
+	require.Equal(t, common.WithdrawalType_Partial, withdrawals[0].Type)
+	require.Equal(t, common.WithdrawalType_FullExit, withdrawals[1].Type)
+	require.Equal(t, common.WithdrawalType_Unknown, withdrawals[2].Type)
+}
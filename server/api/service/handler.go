@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/nodeset-org/hyperdrive-daemon/common"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rocket-pool/node-manager-core/api/server"
 	"github.com/rocket-pool/node-manager-core/log"
 )
@@ -25,6 +26,7 @@ func NewServiceHandler(logger *log.Logger, ctx context.Context, serviceProvider
 	h.factories = []server.IContextFactory{
 		&serviceClientStatusContextFactory{h},
 		&serviceGetConfigContextFactory{h},
+		&serviceRecreateContainerContextFactory{h},
 		&serviceRestartContainerContextFactory{h},
 		&serviceRotateLogsContextFactory{h},
 		&serviceVersionContextFactory{h},
@@ -37,4 +39,7 @@ func (h *ServiceHandler) RegisterRoutes(router *mux.Router) {
 	for _, factory := range h.factories {
 		factory.RegisterRoute(subrouter)
 	}
+
+	// Serve the daemon's own metrics for Prometheus to scrape
+	subrouter.Handle("/metrics", promhttp.HandlerFor(h.serviceProvider.GetMetricsRegistry(), promhttp.HandlerOpts{}))
 }
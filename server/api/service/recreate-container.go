@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type serviceRecreateContainerContextFactory struct {
+	handler *ServiceHandler
+}
+
+func (f *serviceRecreateContainerContextFactory) Create(args url.Values) (*serviceRecreateContainerContext, error) {
+	c := &serviceRecreateContainerContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{
+		server.GetStringFromVars("container", args, &c.container),
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *serviceRecreateContainerContextFactory) RegisterRoute(router *mux.Router) {
+	server.RegisterQuerylessGet[*serviceRecreateContainerContext, types.SuccessData](
+		router, "recreate-container", f, f.handler.logger.Logger, f.handler.serviceProvider.ServiceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type serviceRecreateContainerContext struct {
+	handler   *ServiceHandler
+	container string
+}
+
+func (c *serviceRecreateContainerContext) PrepareData(data *types.SuccessData, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	err := c.handler.serviceProvider.RecreateContainer(c.handler.ctx, config.ContainerID(c.container))
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+	return types.ResponseStatus_Success, nil
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"net/url"
 
-	"github.com/docker/docker/api/types/container"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/gorilla/mux"
 	"github.com/rocket-pool/node-manager-core/api/server"
@@ -47,11 +46,10 @@ type serviceRestartContainerContext struct {
 func (c *serviceRestartContainerContext) PrepareData(data *types.SuccessData, opts *bind.TransactOpts) (types.ResponseStatus, error) {
 	sp := c.handler.serviceProvider
 	cfg := sp.GetConfig()
-	d := sp.GetDocker()
 	ctx := c.handler.ctx
 
 	id := cfg.GetDockerArtifactName(c.container)
-	err := d.ContainerRestart(ctx, id, container.StopOptions{})
+	err := sp.RestartContainer(ctx, id)
 	if err != nil {
 		return types.ResponseStatus_Error, err
 	}
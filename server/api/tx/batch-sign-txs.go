@@ -65,7 +65,6 @@ func (c *txBatchSignTxsContext) PrepareData(data *api.TxBatchSignTxData, opts *b
 	ec := sp.GetEthClient()
 	txMgr := sp.GetTransactionManager()
 	ctx := c.handler.ctx
-	nodeAddress, _ := sp.GetWallet().GetAddress()
 
 	// Requirements
 	err := sp.RequireWalletReady()
@@ -73,12 +72,15 @@ func (c *txBatchSignTxsContext) PrepareData(data *api.TxBatchSignTxData, opts *b
 		return types.ResponseStatus_WalletNotReady, err
 	}
 
+	// The signer's address is the sender, so the nonces come from it
+	opts = sp.GetSignerTransactor(ctx)
+
 	// Get the first nonce
 	var currentNonce *big.Int
 	if c.body.FirstNonce != nil {
 		currentNonce = c.body.FirstNonce
 	} else {
-		nonce, err := ec.NonceAt(ctx, nodeAddress, nil)
+		nonce, err := ec.NonceAt(ctx, opts.From, nil)
 		if err != nil {
 			return types.ResponseStatus_Error, fmt.Errorf("error getting latest nonce for node: %w", err)
 		}
@@ -64,7 +64,6 @@ func (c *txBatchSubmitTxsContext) PrepareData(data *api.BatchTxData, opts *bind.
 	txMgr := sp.GetTransactionManager()
 	ec := sp.GetEthClient()
 	ctx := c.handler.ctx
-	nodeAddress, _ := sp.GetWallet().GetAddress()
 
 	// Requirements
 	err := sp.RequireWalletReady()
@@ -72,12 +71,15 @@ func (c *txBatchSubmitTxsContext) PrepareData(data *api.BatchTxData, opts *bind.
 		return types.ResponseStatus_WalletNotReady, err
 	}
 
+	// The signer's address is the sender, so the nonces come from it
+	opts = sp.GetSignerTransactor(ctx)
+
 	// Get the first nonce
 	var currentNonce *big.Int
 	if c.body.FirstNonce != nil {
 		currentNonce = c.body.FirstNonce
 	} else {
-		nonce, err := ec.NonceAt(ctx, nodeAddress, nil)
+		nonce, err := ec.NonceAt(ctx, opts.From, nil)
 		if err != nil {
 			return types.ResponseStatus_Error, fmt.Errorf("error getting latest nonce for node: %w", err)
 		}
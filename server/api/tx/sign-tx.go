@@ -66,6 +66,8 @@ func (c *txSignTxContext) PrepareData(data *api.TxSignTxData, opts *bind.Transac
 		return types.ResponseStatus_WalletNotReady, err
 	}
 
+	opts = sp.GetSignerTransactor(c.handler.ctx)
+
 	if c.body.Nonce != nil {
 		opts.Nonce = c.body.Nonce
 	}
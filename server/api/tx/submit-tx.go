@@ -5,7 +5,9 @@ import (
 	_ "time/tzdata"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/gorilla/mux"
+	"github.com/nodeset-org/hyperdrive-daemon/common"
 	"github.com/nodeset-org/hyperdrive-daemon/shared/types/api"
 	"github.com/rocket-pool/node-manager-core/api/server"
 	"github.com/rocket-pool/node-manager-core/api/types"
@@ -65,6 +67,9 @@ func (c *txSubmitTxContext) PrepareData(data *api.TxData, opts *bind.TransactOpt
 		return types.ResponseStatus_WalletNotReady, err
 	}
 
+	// Sign with the configured transaction signer
+	opts = sp.GetSignerTransactor(c.handler.ctx)
+
 	if c.body.Nonce != nil {
 		opts.Nonce = c.body.Nonce
 	}
@@ -72,6 +77,26 @@ func (c *txSubmitTxContext) PrepareData(data *api.TxData, opts *bind.TransactOpt
 	opts.GasFeeCap = c.body.MaxFee
 	opts.GasTipCap = c.body.MaxPriorityFee
 
+	// Catch reverts before paying for them
+	if c.body.Simulate {
+		txInfo := c.body.Submission.TxInfo
+		candidate := ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+			To:        &txInfo.To,
+			Data:      txInfo.Data,
+			Value:     txInfo.Value,
+			Gas:       opts.GasLimit,
+			GasFeeCap: opts.GasFeeCap,
+			GasTipCap: opts.GasTipCap,
+		})
+		result, err := sp.SimulateTransaction(c.handler.ctx, candidate, opts.From)
+		if err != nil {
+			return types.ResponseStatus_Error, err
+		}
+		if result.Reverted {
+			return types.ResponseStatus_InvalidChainState, fmt.Errorf("%w: %s", common.ErrTransactionWouldRevert, getRevertDescription(result))
+		}
+	}
+
 	tx, err := txMgr.ExecuteTransaction(c.body.Submission.TxInfo, opts)
 	if err != nil {
 		return types.ResponseStatus_Error, fmt.Errorf("error submitting transaction: %w", err)
@@ -79,3 +104,14 @@ func (c *txSubmitTxContext) PrepareData(data *api.TxData, opts *bind.TransactOpt
 	data.TxHash = tx.Hash()
 	return types.ResponseStatus_Success, nil
 }
+
+// Describes why a simulated transaction reverted
+func getRevertDescription(result common.SimResult) string {
+	if result.RevertReason != "" {
+		return result.RevertReason
+	}
+	if len(result.RevertData) > 0 {
+		return fmt.Sprintf("reverted with data %s", result.RevertData.String())
+	}
+	return "reverted without a reason"
+}
@@ -42,6 +42,7 @@ func NewWalletHandler(logger *log.Logger, ctx context.Context, serviceProvider *
 		&walletStatusContextFactory{h},
 		&walletTestRecoverContextFactory{h},
 		&walletTestSearchAndRecoverContextFactory{h},
+		&walletVerifyKeystoresContextFactory{h},
 	}
 	return h
 }
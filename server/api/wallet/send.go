@@ -1,7 +1,6 @@
 package wallet
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"math/big"
@@ -65,7 +64,6 @@ func (c *walletSendContext) PrepareData(data *api.WalletSendData, opts *bind.Tra
 	qMgr := sp.GetQueryManager()
 	txMgr := sp.GetTransactionManager()
 	ctx := c.handler.ctx
-	nodeAddress, _ := sp.GetWallet().GetAddress()
 
 	// Requirements
 	err := sp.RequireNodeAddress()
@@ -77,6 +75,9 @@ func (c *walletSendContext) PrepareData(data *api.WalletSendData, opts *bind.Tra
 		return types.ResponseStatus_ClientsNotSynced, err
 	}
 
+	// Send from the configured transaction signer, so the balance checked is the one that pays
+	opts = sp.GetSignerTransactor(ctx)
+
 	// Get the contract (nil in the case of ETH)
 	var tokenContract contracts.IErc20Token
 	if c.token == "eth" {
@@ -100,7 +101,7 @@ func (c *walletSendContext) PrepareData(data *api.WalletSendData, opts *bind.Tra
 	// Get the balance
 	if tokenContract != nil {
 		err := qMgr.Query(func(mc *batch.MultiCaller) error {
-			tokenContract.BalanceOf(mc, &data.Balance, nodeAddress)
+			tokenContract.BalanceOf(mc, &data.Balance, opts.From)
 			return nil
 		}, nil)
 		if err != nil {
@@ -109,7 +110,7 @@ func (c *walletSendContext) PrepareData(data *api.WalletSendData, opts *bind.Tra
 	} else {
 		// ETH balance
 		var err error
-		data.Balance, err = ec.BalanceAt(context.Background(), nodeAddress, nil)
+		data.Balance, err = ec.BalanceAt(ctx, opts.From, nil)
 		if err != nil {
 			return types.ResponseStatus_Error, fmt.Errorf("error getting ETH balance: %w", err)
 		}
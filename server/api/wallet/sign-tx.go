@@ -2,7 +2,6 @@ package wallet
 
 import (
 	"errors"
-	"fmt"
 	"net/url"
 	_ "time/tzdata"
 
@@ -49,7 +48,6 @@ type walletSignTxContext struct {
 
 func (c *walletSignTxContext) PrepareData(data *api.WalletSignTxData, opts *bind.TransactOpts) (types.ResponseStatus, error) {
 	sp := c.handler.serviceProvider
-	w := sp.GetWallet()
 
 	// Requirements
 	err := sp.RequireWalletReady()
@@ -57,9 +55,9 @@ func (c *walletSignTxContext) PrepareData(data *api.WalletSignTxData, opts *bind
 		return types.ResponseStatus_WalletNotReady, err
 	}
 
-	signedBytes, err := w.SignTransaction(c.tx)
+	signedBytes, err := sp.SignSerializedTransaction(c.handler.ctx, c.tx)
 	if err != nil {
-		return types.ResponseStatus_Error, fmt.Errorf("error signing transaction: %w", err)
+		return types.ResponseStatus_Error, err
 	}
 	data.SignedTx = signedBytes
 	return types.ResponseStatus_Success, nil
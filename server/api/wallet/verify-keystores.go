@@ -0,0 +1,62 @@
+package wallet
+
+import (
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/types/api"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletVerifyKeystoresContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletVerifyKeystoresContextFactory) Create(args url.Values) (*walletVerifyKeystoresContext, error) {
+	c := &walletVerifyKeystoresContext{
+		handler: f.handler,
+	}
+	return c, nil
+}
+
+func (f *walletVerifyKeystoresContextFactory) RegisterRoute(router *mux.Router) {
+	server.RegisterQuerylessGet[*walletVerifyKeystoresContext, api.WalletVerifyKeystoresData](
+		router, "verify-keystores", f, f.handler.logger.Logger, f.handler.serviceProvider.ServiceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletVerifyKeystoresContext struct {
+	handler *WalletHandler
+}
+
+func (c *walletVerifyKeystoresContext) PrepareData(data *api.WalletVerifyKeystoresData, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+
+	result, err := sp.VerifyKeystores(c.handler.ctx)
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+	data.Keystores = make([]api.WalletKeystoreVerification, len(result.Keystores))
+	for i, keystore := range result.Keystores {
+		data.Keystores[i] = api.WalletKeystoreVerification{
+			Path:          keystore.Path,
+			Pubkey:        keystore.Pubkey,
+			Success:       keystore.Success,
+			FailureReason: string(keystore.FailureReason),
+			Error:         keystore.Error,
+		}
+	}
+	data.SuccessCount = result.SuccessCount
+	data.FailureCount = result.FailureCount
+	return types.ResponseStatus_Success, nil
+}
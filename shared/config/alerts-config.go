@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// Configuration for delivering the daemon's alerts by email
+type AlertsConfig struct {
+	// The hostname of the SMTP server to send alert emails through
+	SmtpHost config.Parameter[string]
+
+	// The port of the SMTP server
+	SmtpPort config.Parameter[uint16]
+
+	// The address alert emails are sent from
+	SmtpFrom config.Parameter[string]
+
+	// The comma-separated addresses alert emails are sent to
+	SmtpTo config.Parameter[string]
+
+	// The username to log into the SMTP server with
+	SmtpUsername config.Parameter[string]
+
+	// The path of the file containing the password to log into the SMTP server with
+	SmtpPasswordPath config.Parameter[string]
+
+	// The least severe alerts that are sent by email
+	EmailSeverity config.Parameter[AlertSeverity]
+}
+
+// Generates a new alerts configuration
+func NewAlertsConfig() *AlertsConfig {
+	return &AlertsConfig{
+		SmtpHost: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertsSmtpHostID,
+				Name:               "SMTP Host",
+				Description:        "The hostname of the SMTP server Hyperdrive sends alert emails through (e.g. `smtp.gmail.com`).\n\nLeave this blank if you don't want alerts sent by email.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		SmtpPort: config.Parameter[uint16]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertsSmtpPortID,
+				Name:               "SMTP Port",
+				Description:        "The port of the SMTP server. Hyperdrive upgrades the connection with STARTTLS when the server offers it; port 587 is the usual one for that.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint16{
+				config.Network_All: 587,
+			},
+		},
+
+		SmtpFrom: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertsSmtpFromID,
+				Name:               "Sender Address",
+				Description:        "The address alert emails are sent from. Your SMTP server may require this to match the account you log in with.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		SmtpTo: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertsSmtpToID,
+				Name:               "Recipient Addresses",
+				Description:        "The addresses alert emails are sent to, separated by commas.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		SmtpUsername: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertsSmtpUsernameID,
+				Name:               "SMTP Username",
+				Description:        "The username to log into the SMTP server with.\n\nLeave this blank if your server doesn't require logging in.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		SmtpPasswordPath: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertsSmtpPasswordPathID,
+				Name:               "SMTP Password Path",
+				Description:        "The path of the file containing the password to log into the SMTP server with. The password is only sent over an encrypted connection, unless the server is on this machine.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		EmailSeverity: config.Parameter[AlertSeverity]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertsEmailSeverityID,
+				Name:               "Email Severity",
+				Description:        "The least severe alerts that are sent by email. Alerts below this are only logged.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Options: []*config.ParameterOption[AlertSeverity]{{
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "Info",
+					Description: "Send every alert by email, including informational ones.",
+				},
+				Value: AlertSeverity_Info,
+			}, {
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "Warning",
+					Description: "Send warnings and critical alerts by email.",
+				},
+				Value: AlertSeverity_Warning,
+			}, {
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "Critical",
+					Description: "Only send critical alerts by email.",
+				},
+				Value: AlertSeverity_Critical,
+			}},
+			Default: map[config.Network]AlertSeverity{
+				config.Network_All: AlertSeverity_Warning,
+			},
+		},
+	}
+}
+
+// The title for the config
+func (cfg *AlertsConfig) GetTitle() string {
+	return "Alerts"
+}
+
+// Get the Parameters for this config
+func (cfg *AlertsConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{
+		&cfg.SmtpHost,
+		&cfg.SmtpPort,
+		&cfg.SmtpFrom,
+		&cfg.SmtpTo,
+		&cfg.SmtpUsername,
+		&cfg.SmtpPasswordPath,
+		&cfg.EmailSeverity,
+	}
+}
+
+// Get the sections underneath this one
+func (cfg *AlertsConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{}
+}
+
+// True if an SMTP server is set, so alerts are sent by email
+func (cfg *AlertsConfig) IsEmailEnabled() bool {
+	return cfg.SmtpHost.Value != ""
+}
+
+// Gets the addresses alert emails are sent to, without any blank entries
+func (cfg *AlertsConfig) GetEmailRecipients() []string {
+	recipients := []string{}
+	for _, recipient := range strings.Split(cfg.SmtpTo.Value, ",") {
+		recipient = strings.TrimSpace(recipient)
+		if recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+// Checks that the sender and recipients are valid addresses and that there's at least one recipient, if email alerts
+// are enabled
+func (cfg *AlertsConfig) Validate() []string {
+	if !cfg.IsEmailEnabled() {
+		return nil
+	}
+	errors := []string{}
+	if cfg.SmtpPort.Value == 0 {
+		errors = append(errors, "The SMTP port for email alerts can't be 0.")
+	}
+	if _, err := mail.ParseAddress(cfg.SmtpFrom.Value); err != nil {
+		errors = append(errors, fmt.Sprintf("The sender address for email alerts [%s] isn't a valid email address.", cfg.SmtpFrom.Value))
+	}
+	recipients := cfg.GetEmailRecipients()
+	if len(recipients) == 0 {
+		errors = append(errors, "Email alerts need at least one recipient address.")
+	}
+	for _, recipient := range recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			errors = append(errors, fmt.Sprintf("The recipient address for email alerts [%s] isn't a valid email address.", recipient))
+		}
+	}
+	return errors
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The builder boost factor that compares builder blocks to local ones as-is
+	BuilderBoostFactor_Default uint64 = 100
+
+	// The largest builder boost factor that's treated as a percentage; anything higher is almost certainly a typo
+	BuilderBoostFactor_MaxPercentage uint64 = 10000
+
+	// The builder boost factor that always uses the builder's block, as defined by the Beacon API
+	BuilderBoostFactor_AlwaysBuilder uint64 = math.MaxUint64
+)
+
+// Checks if a builder boost factor is a percentage up to BuilderBoostFactor_MaxPercentage, or BuilderBoostFactor_AlwaysBuilder
+func IsValidBuilderBoostFactor(factor uint64) bool {
+	return factor <= BuilderBoostFactor_MaxPercentage || factor == BuilderBoostFactor_AlwaysBuilder
+}
+
+// Gets the Validator Client flag that sets the builder boost factor, or false if the client doesn't have one
+func GetBuilderBoostFactorFlag(client config.BeaconNode, factor uint64) (string, bool) {
+	switch client {
+	case config.BeaconNode_Lighthouse:
+		return fmt.Sprintf("--builder-boost-factor=%d", factor), true
+	case config.BeaconNode_Lodestar:
+		return fmt.Sprintf("--builder.boostFactor=%d", factor), true
+	}
+	return "", false
+}
+
+// Gets the flags for the Validator Client's builder boost factor. The VC always matches the Beacon Node, so clients that
+// don't support it and the default factor have no flags.
+// Used by text/template to format vc.yml
+func (cfg *HyperdriveConfig) GetVcBuilderBoostFlags() []string {
+	factor := cfg.MevBoost.BuilderBoostFactor.Value
+	if factor == BuilderBoostFactor_Default {
+		return []string{}
+	}
+	flag, supported := GetBuilderBoostFactorFlag(cfg.GetSelectedBeaconNode(), factor)
+	if !supported {
+		return []string{}
+	}
+	return []string{flag}
+}
+
+// Checks that the builder boost factor is in range
+func (cfg *HyperdriveConfig) validateBuilderBoostFactor() []string {
+	factor := cfg.MevBoost.BuilderBoostFactor.Value
+	if !IsValidBuilderBoostFactor(factor) {
+		return []string{fmt.Sprintf("The builder boost factor is %d, but it must be between 0 and %d, or %d to always use the builder's block.", factor, BuilderBoostFactor_MaxPercentage, BuilderBoostFactor_AlwaysBuilder)}
+	}
+	return nil
+}
+
+// Gets a warning if the builder boost factor has been changed for a Validator Client that doesn't support it
+func (cfg *HyperdriveConfig) getBuilderBoostFactorWarnings() []string {
+	if cfg.MevBoost.BuilderBoostFactor.Value == BuilderBoostFactor_Default {
+		return nil
+	}
+	bn := cfg.GetSelectedBeaconNode()
+	if _, supported := GetBuilderBoostFactorFlag(bn, 0); supported {
+		return nil
+	}
+	return []string{fmt.Sprintf("The builder boost factor is set, but your Validator Client is %s, which doesn't support it, so it won't be applied.", bn)}
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The label on containers that run as a configured user, so it's only cleared from their settings when they're
+	// recreated if Hyperdrive set it
+	ContainerUserLabel string = "hyperdrive.user"
+)
+
+// The users the client containers run as, for hosts that need their data owned by a specific UID and GID. Services
+// without a user run as whatever their image specifies.
+type ContainerUserConfig struct {
+	ExecutionClient *ServiceContainerUserConfig
+	BeaconNode      *ServiceContainerUserConfig
+	ValidatorClient *ServiceContainerUserConfig
+	MevBoost        *ServiceContainerUserConfig
+}
+
+// The user a single service's container runs as
+type ServiceContainerUserConfig struct {
+	// The numeric UID to run as
+	RunAsUser config.Parameter[string]
+
+	// The numeric GID to run as
+	RunAsGroup config.Parameter[string]
+
+	// The service's name, for the title and errors
+	serviceName string
+}
+
+// Generates a new container user configuration
+func NewContainerUserConfig() *ContainerUserConfig {
+	return &ContainerUserConfig{
+		ExecutionClient: newServiceContainerUserConfig(config.ContainerID_ExecutionClient, "Execution Client"),
+		BeaconNode:      newServiceContainerUserConfig(config.ContainerID_BeaconNode, "Beacon Node"),
+		ValidatorClient: newServiceContainerUserConfig(config.ContainerID_ValidatorClient, "Validator Client"),
+		MevBoost:        newServiceContainerUserConfig(config.ContainerID_MevBoost, "MEV-Boost"),
+	}
+}
+
+// Generates a new container user configuration for one service
+func newServiceContainerUserConfig(container config.ContainerID, serviceName string) *ServiceContainerUserConfig {
+	return &ServiceContainerUserConfig{
+		RunAsUser: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.ContainerUserRunAsUserID,
+				Name:               "Run as User",
+				Description:        fmt.Sprintf("The numeric UID the %s container runs as. Its data volume is given to this user when it's first created.\n\nLeave this blank to use the image's default user.\n\n[orange]NOTE: The container has to be recreated for changes to take effect.", serviceName),
+				AffectsContainers:  []config.ContainerID{container},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		RunAsGroup: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.ContainerUserRunAsGroupID,
+				Name:               "Run as Group",
+				Description:        fmt.Sprintf("The numeric GID the %s container runs as. This can only be set along with the user.\n\nLeave this blank to use the user's primary group.", serviceName),
+				AffectsContainers:  []config.ContainerID{container},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		serviceName: serviceName,
+	}
+}
+
+// The title for the config
+func (cfg *ContainerUserConfig) GetTitle() string {
+	return "Container Users"
+}
+
+// Get the Parameters for this config
+func (cfg *ContainerUserConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{}
+}
+
+// Get the sections underneath this one
+func (cfg *ContainerUserConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{
+		ids.ContainerUserExecutionClientID: cfg.ExecutionClient,
+		ids.ContainerUserBeaconNodeID:      cfg.BeaconNode,
+		ids.ContainerUserValidatorClientID: cfg.ValidatorClient,
+		ids.ContainerUserMevBoostID:        cfg.MevBoost,
+	}
+}
+
+// Get the IDs of the subconfigs in display order
+func (cfg *ContainerUserConfig) GetSubconfigOrder() []string {
+	return []string{
+		ids.ContainerUserExecutionClientID,
+		ids.ContainerUserBeaconNodeID,
+		ids.ContainerUserValidatorClientID,
+		ids.ContainerUserMevBoostID,
+	}
+}
+
+// Gets the user spec for a container's User setting, as "uid" or "uid:gid". Returns an empty string if the container
+// doesn't have a user configured.
+func (cfg *ContainerUserConfig) GetUser(container config.ContainerID) string {
+	var service *ServiceContainerUserConfig
+	switch container {
+	case config.ContainerID_ExecutionClient:
+		service = cfg.ExecutionClient
+	case config.ContainerID_BeaconNode:
+		service = cfg.BeaconNode
+	case config.ContainerID_ValidatorClient:
+		service = cfg.ValidatorClient
+	case config.ContainerID_MevBoost:
+		service = cfg.MevBoost
+	default:
+		return ""
+	}
+	if service.RunAsUser.Value == "" {
+		return ""
+	}
+	if service.RunAsGroup.Value == "" {
+		return service.RunAsUser.Value
+	}
+	return service.RunAsUser.Value + ":" + service.RunAsGroup.Value
+}
+
+// Checks that each UID and GID is a non-negative number, and that groups are only set along with users
+func (cfg *ContainerUserConfig) Validate() []string {
+	errors := []string{}
+	for _, service := range []*ServiceContainerUserConfig{cfg.ExecutionClient, cfg.BeaconNode, cfg.ValidatorClient, cfg.MevBoost} {
+		for _, param := range []*config.Parameter[string]{&service.RunAsUser, &service.RunAsGroup} {
+			if param.Value == "" {
+				continue
+			}
+			_, err := strconv.ParseUint(param.Value, 10, 32)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("the %s's %s is [%s], but it must be a non-negative number", service.serviceName, param.Name, param.Value))
+			}
+		}
+		if service.RunAsUser.Value == "" && service.RunAsGroup.Value != "" {
+			errors = append(errors, fmt.Sprintf("the %s has a group to run as but no user; the group can only be set along with the user", service.serviceName))
+		}
+	}
+	return errors
+}
+
+// The title for the config
+func (cfg *ServiceContainerUserConfig) GetTitle() string {
+	return cfg.serviceName
+}
+
+// Get the Parameters for this config
+func (cfg *ServiceContainerUserConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{
+		&cfg.RunAsUser,
+		&cfg.RunAsGroup,
+	}
+}
+
+// Get the sections underneath this one
+func (cfg *ServiceContainerUserConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{}
+}
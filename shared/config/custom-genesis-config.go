@@ -0,0 +1,273 @@
+package config
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// The directory the custom genesis artifacts are mounted into inside the client containers
+	CustomGenesisContainerDir string = "/genesis"
+
+	// The path of the Execution Client's genesis file inside its container
+	ExecutionGenesisContainerPath string = CustomGenesisContainerDir + "/genesis.json"
+
+	// The path of the Beacon Chain config inside the Beacon Node and Validator Client containers
+	BeaconConfigContainerPath string = CustomGenesisContainerDir + "/config.yaml"
+
+	// The path of the Beacon Chain genesis state inside the Beacon Node container
+	BeaconGenesisStateContainerPath string = CustomGenesisContainerDir + "/genesis.ssz"
+
+	// The environment variable the Execution Client's start script reads the genesis file path from
+	ExecutionGenesisEnvVar string = "EC_GENESIS_FILE"
+
+	// The environment variable the Beacon Node's and Validator Client's start scripts read the Beacon Chain config path
+	// from
+	BeaconConfigEnvVar string = "BN_NETWORK_CONFIG_FILE"
+
+	// The environment variable the Beacon Node's start script reads the genesis state path from
+	BeaconGenesisStateEnvVar string = "BN_GENESIS_STATE_FILE"
+
+	// The length of a BeaconState's leading genesis_time, genesis_validators_root, and slot fields
+	beaconStateHeaderSize int = 8 + 32 + 8
+)
+
+// The genesis artifacts for a private network that the clients don't know about. The Execution Client needs the
+// network's genesis file, and the Beacon Node needs its Beacon Chain config and genesis state. They're only used when
+// Hyperdrive is set to a custom network.
+type CustomGenesisConfig struct {
+	// The path of the Execution Client's genesis.json on the host
+	ExecutionGenesisPath config.Parameter[string]
+
+	// The path of the Beacon Chain's config.yaml on the host
+	BeaconConfigPath config.Parameter[string]
+
+	// The path of the Beacon Chain's genesis.ssz on the host
+	BeaconGenesisStatePath config.Parameter[string]
+}
+
+// The parts of an Execution Client genesis file that Hyperdrive checks
+type executionGenesis struct {
+	Config *struct {
+		ChainID *big.Int `json:"chainId"`
+	} `json:"config"`
+	Timestamp *hexutil.Uint64 `json:"timestamp"`
+}
+
+// Generates a new custom genesis configuration
+func NewCustomGenesisConfig() *CustomGenesisConfig {
+	return &CustomGenesisConfig{
+		ExecutionGenesisPath: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.CustomGenesisExecutionGenesisID,
+				Name:               "Execution Genesis Path",
+				Description:        "The path of the `genesis.json` file the Execution Client should start a custom network from. Only used when Hyperdrive is set to a custom network.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_ExecutionClient},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		BeaconConfigPath: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.CustomGenesisBeaconConfigID,
+				Name:               "Beacon Chain Config Path",
+				Description:        "The path of the `config.yaml` file with the custom network's Beacon Chain settings, like its fork schedule and deposit contract. Only used when Hyperdrive is set to a custom network.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_BeaconNode, config.ContainerID_ValidatorClient},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		BeaconGenesisStatePath: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.CustomGenesisBeaconStateID,
+				Name:               "Beacon Genesis State Path",
+				Description:        "The path of the `genesis.ssz` file with the custom network's Beacon Chain genesis state. Hyperdrive also reads the network's genesis time from it. Only used when Hyperdrive is set to a custom network.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_BeaconNode, config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+	}
+}
+
+// The title for the config
+func (cfg *CustomGenesisConfig) GetTitle() string {
+	return "Custom Genesis"
+}
+
+// Get the Parameters for this config
+func (cfg *CustomGenesisConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{
+		&cfg.ExecutionGenesisPath,
+		&cfg.BeaconConfigPath,
+		&cfg.BeaconGenesisStatePath,
+	}
+}
+
+// Get the sections underneath this one
+func (cfg *CustomGenesisConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{}
+}
+
+// Checks that each of the artifacts that's set exists and parses, returning a list of errors that must be fixed before
+// saving. If required is set, all three artifacts have to be provided since the local clients can't start without them.
+// The Execution Client's chain ID has to match the Beacon Chain config's deposit chain ID, if it has one.
+func (cfg *CustomGenesisConfig) Validate(required bool) []string {
+	errors := []string{}
+	for _, param := range []*config.Parameter[string]{&cfg.ExecutionGenesisPath, &cfg.BeaconConfigPath, &cfg.BeaconGenesisStatePath} {
+		if param.Value == "" {
+			if required {
+				errors = append(errors, fmt.Sprintf("The %s is required to run local clients on a custom network.", param.Name))
+			}
+		} else if !filepath.IsAbs(param.Value) {
+			errors = append(errors, fmt.Sprintf("The %s [%s] must be an absolute path.", param.Name, param.Value))
+		}
+	}
+	if len(errors) > 0 {
+		return errors
+	}
+
+	var chainID *big.Int
+	if cfg.ExecutionGenesisPath.Value != "" {
+		genesis, err := cfg.readExecutionGenesis()
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("The %s [%s] is invalid: %s", cfg.ExecutionGenesisPath.Name, cfg.ExecutionGenesisPath.Value, err.Error()))
+		} else {
+			chainID = genesis.Config.ChainID
+		}
+	}
+	if cfg.BeaconConfigPath.Value != "" {
+		depositChainID, err := cfg.readBeaconConfig()
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("The %s [%s] is invalid: %s", cfg.BeaconConfigPath.Name, cfg.BeaconConfigPath.Value, err.Error()))
+		} else if chainID != nil && depositChainID != nil && chainID.Cmp(depositChainID) != 0 {
+			errors = append(errors, fmt.Sprintf("The Execution Client's genesis has a chain ID of %s, but the Beacon Chain config has a deposit chain ID of %s.", chainID, depositChainID))
+		}
+	}
+	if cfg.BeaconGenesisStatePath.Value != "" {
+		_, err := cfg.GetGenesisTime()
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("The %s [%s] is invalid: %s", cfg.BeaconGenesisStatePath.Name, cfg.BeaconGenesisStatePath.Value, err.Error()))
+		}
+	}
+	return errors
+}
+
+// Reads the network's genesis time from the start of the Beacon Chain genesis state
+func (cfg *CustomGenesisConfig) GetGenesisTime() (time.Time, error) {
+	file, err := os.Open(cfg.BeaconGenesisStatePath.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error opening genesis state: %w", err)
+	}
+	defer file.Close()
+
+	// genesis_time is the first field of a BeaconState, so it doesn't need to be decoded fully
+	header := make([]byte, beaconStateHeaderSize)
+	_, err = io.ReadFull(file, header)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("genesis state is too short to be an SSZ-encoded BeaconState")
+	}
+	genesisTime := binary.LittleEndian.Uint64(header[:8])
+	if genesisTime == 0 {
+		return time.Time{}, fmt.Errorf("genesis state doesn't have a genesis time")
+	}
+	return time.Unix(int64(genesisTime), 0), nil
+}
+
+// Parses the Execution Client's genesis file, making sure it has the chain ID and timestamp every client needs
+func (cfg *CustomGenesisConfig) readExecutionGenesis() (executionGenesis, error) {
+	bytes, err := os.ReadFile(cfg.ExecutionGenesisPath.Value)
+	if err != nil {
+		return executionGenesis{}, fmt.Errorf("error reading genesis file: %w", err)
+	}
+	var genesis executionGenesis
+	err = json.Unmarshal(bytes, &genesis)
+	if err != nil {
+		return executionGenesis{}, fmt.Errorf("error parsing genesis file: %w", err)
+	}
+	if genesis.Config == nil || genesis.Config.ChainID == nil {
+		return executionGenesis{}, fmt.Errorf("genesis file doesn't have a chain ID")
+	}
+	if genesis.Timestamp == nil {
+		return executionGenesis{}, fmt.Errorf("genesis file doesn't have a timestamp")
+	}
+	return genesis, nil
+}
+
+// Parses the Beacon Chain config, making sure it has a slot time, and gets its deposit chain ID if it has one
+func (cfg *CustomGenesisConfig) readBeaconConfig() (*big.Int, error) {
+	bytes, err := os.ReadFile(cfg.BeaconConfigPath.Value)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Beacon Chain config: %w", err)
+	}
+	var settings map[string]any
+	err = yaml.Unmarshal(bytes, &settings)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Beacon Chain config: %w", err)
+	}
+	secondsPerSlot, err := strconv.ParseUint(fmt.Sprint(settings["SECONDS_PER_SLOT"]), 10, 64)
+	if err != nil || secondsPerSlot == 0 {
+		return nil, fmt.Errorf("Beacon Chain config doesn't have a valid SECONDS_PER_SLOT")
+	}
+	depositChainID, exists := settings["DEPOSIT_CHAIN_ID"]
+	if !exists {
+		return nil, nil
+	}
+	chainID, isValid := new(big.Int).SetString(fmt.Sprint(depositChainID), 10)
+	if !isValid {
+		return nil, fmt.Errorf("Beacon Chain config has a DEPOSIT_CHAIN_ID of [%v], which isn't a number", depositChainID)
+	}
+	return chainID, nil
+}
+
+// Checks if Hyperdrive is set to a custom network, rather than one the clients have built in
+func (cfg *HyperdriveConfig) IsCustomNetwork() bool {
+	_, isBuiltIn := GetForkSchedule(cfg.Network.Value)
+	return !isBuiltIn
+}
+
+// Validates the custom genesis artifacts on a custom network. They're required when Hyperdrive runs the clients locally;
+// external clients already have their own, but the ones that are set still have to be valid.
+func (cfg *HyperdriveConfig) validateCustomGenesis() []string {
+	if !cfg.IsCustomNetwork() {
+		return nil
+	}
+	return cfg.CustomGenesis.Validate(cfg.IsLocalMode())
+}
+
+// Warns about custom genesis artifacts that are set on a built-in network, where they're ignored
+func (cfg *HyperdriveConfig) getCustomGenesisWarnings() []string {
+	if cfg.IsCustomNetwork() {
+		return nil
+	}
+	warnings := []string{}
+	for _, param := range cfg.CustomGenesis.GetParameters() {
+		if param.GetValueAsAny() != "" {
+			warnings = append(warnings, fmt.Sprintf("The %s is set, but it's only used on custom networks so the %s genesis will be used instead.", param.GetCommon().Name, cfg.Network.Value))
+		}
+	}
+	return warnings
+}
@@ -12,6 +12,39 @@ const (
 	Network_LocalTest config.Network = "hd-local-test"
 )
 
+// Erigon, which isn't one of the Execution Clients built into node-manager-core. Its client-specific settings are
+// supported, but it isn't a selectable client since Hyperdrive's compose templates can't run it yet.
+const ExecutionClient_Erigon config.ExecutionClient = "erigon"
+
+type BesuDataStorageFormat string
+
+// Enum to describe how Besu stores its world state
+const (
+	BesuDataStorageFormat_Default BesuDataStorageFormat = ""
+	BesuDataStorageFormat_Bonsai  BesuDataStorageFormat = "bonsai"
+	BesuDataStorageFormat_Forest  BesuDataStorageFormat = "forest"
+)
+
+type ErigonPruneMode string
+
+// Enum to describe how much history Erigon keeps
+const (
+	ErigonPruneMode_Default ErigonPruneMode = ""
+	ErigonPruneMode_Full    ErigonPruneMode = "full"
+	ErigonPruneMode_Archive ErigonPruneMode = "archive"
+	ErigonPruneMode_Minimal ErigonPruneMode = "minimal"
+)
+
+type RestartPolicy string
+
+// Enum to describe when Docker restarts a service container; the values match Docker's restart policy names
+const (
+	RestartPolicy_No            RestartPolicy = "no"
+	RestartPolicy_OnFailure     RestartPolicy = "on-failure"
+	RestartPolicy_Always        RestartPolicy = "always"
+	RestartPolicy_UnlessStopped RestartPolicy = "unless-stopped"
+)
+
 type MevRelayID string
 
 // Enum to identify MEV-boost relays
@@ -31,3 +64,20 @@ const (
 	MevSelectionMode_All    MevSelectionMode = "all"
 	MevSelectionMode_Manual MevSelectionMode = "manual"
 )
+
+type PrysmApiMode string
+
+// Enum to describe which API the daemon uses to talk to a Prysm Beacon Node
+const (
+	PrysmApiMode_Rest PrysmApiMode = "rest"
+	PrysmApiMode_Grpc PrysmApiMode = "grpc"
+)
+
+type AlertSeverity string
+
+// Enum to describe how urgent an alert is, from least to most
+const (
+	AlertSeverity_Info     AlertSeverity = "info"
+	AlertSeverity_Warning  AlertSeverity = "warning"
+	AlertSeverity_Critical AlertSeverity = "critical"
+)
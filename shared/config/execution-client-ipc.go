@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// True if the provided Execution Client endpoint is the path of an IPC socket rather than a URL
+func IsIpcEndpoint(endpoint string) bool {
+	return endpoint != "" && !strings.Contains(endpoint, "://") && (strings.HasPrefix(endpoint, "/") || strings.HasPrefix(endpoint, "."))
+}
+
+// Checks the Execution Client endpoints that point at IPC sockets.
+// Only externally managed clients can be reached over IPC; the local EC is always accessed over HTTP, since its
+// socket lives in the EC's data volume and is never mounted into the daemon container. An external socket has to be
+// reachable at the same absolute path from wherever the daemon runs.
+func (cfg *HyperdriveConfig) validateExecutionClientIpc() []string {
+	errors := []string{}
+	endpoints := map[string]string{}
+	if !cfg.IsLocalMode() {
+		endpoints["external Execution Client"] = cfg.ExternalExecutionClient.HttpUrl.Value
+	}
+	if cfg.Fallback.UseFallbackClients.Value {
+		endpoints["fallback Execution Client"] = cfg.Fallback.EcHttpUrl.Value
+	}
+	for _, name := range []string{"external Execution Client", "fallback Execution Client"} {
+		endpoint, exists := endpoints[name]
+		if !exists || !IsIpcEndpoint(endpoint) {
+			continue
+		}
+		if !filepath.IsAbs(endpoint) {
+			errors = append(errors, fmt.Sprintf("The %s is set to the IPC path [%s], which is relative. IPC paths must be absolute.", name, endpoint))
+		}
+	}
+	return errors
+}
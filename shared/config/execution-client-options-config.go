@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The default Erigon container
+	erigonTag string = "erigontech/erigon:v3.0.0"
+)
+
+// Settings that only apply to one kind of local Execution Client, on top of the ones in node-manager-core.
+// Each client's settings are kept when a different client is selected, but are only turned into flags for the selected one.
+type ExecutionClientOptionsConfig struct {
+	Besu   *BesuOptionsConfig
+	Erigon *ErigonOptionsConfig
+}
+
+// Besu-specific settings
+type BesuOptionsConfig struct {
+	// How Besu stores its world state
+	DataStorageFormat config.Parameter[BesuDataStorageFormat]
+}
+
+// Erigon-specific settings
+type ErigonOptionsConfig struct {
+	// How much history Erigon keeps
+	PruneMode config.Parameter[ErigonPruneMode]
+
+	// Max number of P2P peers to connect to
+	MaxPeers config.Parameter[uint16]
+
+	// The Docker Hub tag for Erigon
+	ContainerTag config.Parameter[string]
+
+	// Custom command line flags
+	AdditionalFlags config.Parameter[string]
+}
+
+// Generates a new client-specific Execution Client configuration
+func NewExecutionClientOptionsConfig() *ExecutionClientOptionsConfig {
+	return &ExecutionClientOptionsConfig{
+		Besu: &BesuOptionsConfig{
+			DataStorageFormat: config.Parameter[BesuDataStorageFormat]{
+				ParameterCommon: &config.ParameterCommon{
+					ID:                 ids.BesuDataStorageFormatID,
+					Name:               "Data Storage Format",
+					Description:        "How Besu stores its world state. Bonsai keeps only recent state and is much smaller; Forest keeps the full trie, which some archive workloads need.\n\n[orange]NOTE: Changing this on an existing database requires a resync.",
+					AffectsContainers:  []config.ContainerID{config.ContainerID_ExecutionClient},
+					CanBeBlank:         true,
+					OverwriteOnUpgrade: false,
+				},
+				Options: []*config.ParameterOption[BesuDataStorageFormat]{{
+					ParameterOptionCommon: &config.ParameterOptionCommon{
+						Name:        "Client Default",
+						Description: "Don't set the format, so Besu uses its default (Bonsai).",
+					},
+					Value: BesuDataStorageFormat_Default,
+				}, {
+					ParameterOptionCommon: &config.ParameterOptionCommon{
+						Name:        "Bonsai",
+						Description: "Store only the recent world state.",
+					},
+					Value: BesuDataStorageFormat_Bonsai,
+				}, {
+					ParameterOptionCommon: &config.ParameterOptionCommon{
+						Name:        "Forest",
+						Description: "Store the full world state trie.",
+					},
+					Value: BesuDataStorageFormat_Forest,
+				}},
+				Default: map[config.Network]BesuDataStorageFormat{
+					config.Network_All: BesuDataStorageFormat_Default,
+				},
+			},
+		},
+
+		Erigon: &ErigonOptionsConfig{
+			PruneMode: config.Parameter[ErigonPruneMode]{
+				ParameterCommon: &config.ParameterCommon{
+					ID:                 ids.ErigonPruneModeID,
+					Name:               "Prune Mode",
+					Description:        "How much history Erigon keeps. Full keeps the state and blocks a normal node needs; Archive keeps everything; Minimal keeps as little as possible and can't serve old blocks.\n\n[orange]NOTE: Changing this on an existing database requires a resync.",
+					AffectsContainers:  []config.ContainerID{config.ContainerID_ExecutionClient},
+					CanBeBlank:         true,
+					OverwriteOnUpgrade: false,
+				},
+				Options: []*config.ParameterOption[ErigonPruneMode]{{
+					ParameterOptionCommon: &config.ParameterOptionCommon{
+						Name:        "Client Default",
+						Description: "Don't set the prune mode, so Erigon uses its default (full).",
+					},
+					Value: ErigonPruneMode_Default,
+				}, {
+					ParameterOptionCommon: &config.ParameterOptionCommon{
+						Name:        "Full",
+						Description: "Keep the recent state and all blocks.",
+					},
+					Value: ErigonPruneMode_Full,
+				}, {
+					ParameterOptionCommon: &config.ParameterOptionCommon{
+						Name:        "Archive",
+						Description: "Keep every historical state.",
+					},
+					Value: ErigonPruneMode_Archive,
+				}, {
+					ParameterOptionCommon: &config.ParameterOptionCommon{
+						Name:        "Minimal",
+						Description: "Keep only what's needed to follow the chain.",
+					},
+					Value: ErigonPruneMode_Minimal,
+				}},
+				Default: map[config.Network]ErigonPruneMode{
+					config.Network_All: ErigonPruneMode_Default,
+				},
+			},
+
+			MaxPeers: config.Parameter[uint16]{
+				ParameterCommon: &config.ParameterCommon{
+					ID:                 ids.ErigonMaxPeersID,
+					Name:               "Max Peers",
+					Description:        "The maximum number of peers Erigon should connect to. This can be lowered to improve performance on low-power systems or constrained networks. We recommend keeping it at 12 or higher.",
+					AffectsContainers:  []config.ContainerID{config.ContainerID_ExecutionClient},
+					CanBeBlank:         false,
+					OverwriteOnUpgrade: false,
+				},
+				Default: map[config.Network]uint16{
+					config.Network_All: 50,
+				},
+			},
+
+			ContainerTag: config.Parameter[string]{
+				ParameterCommon: &config.ParameterCommon{
+					ID:                 ids.ErigonContainerTagID,
+					Name:               "Container Tag",
+					Description:        "The tag name of the Erigon container you want to use on Docker Hub.",
+					AffectsContainers:  []config.ContainerID{config.ContainerID_ExecutionClient},
+					CanBeBlank:         false,
+					OverwriteOnUpgrade: true,
+				},
+				Default: map[config.Network]string{
+					config.Network_All: erigonTag,
+				},
+			},
+
+			AdditionalFlags: config.Parameter[string]{
+				ParameterCommon: &config.ParameterCommon{
+					ID:                 ids.ErigonAdditionalFlagsID,
+					Name:               "Additional Flags",
+					Description:        "Additional custom command line flags you want to pass to Erigon, to take advantage of other settings that Hyperdrive's configuration doesn't cover.",
+					AffectsContainers:  []config.ContainerID{config.ContainerID_ExecutionClient},
+					CanBeBlank:         true,
+					OverwriteOnUpgrade: false,
+				},
+				Default: map[config.Network]string{
+					config.Network_All: "",
+				},
+			},
+		},
+	}
+}
+
+// The title for the config
+func (cfg *BesuOptionsConfig) GetTitle() string {
+	return "Besu"
+}
+
+// Get the Parameters for this config
+func (cfg *BesuOptionsConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{
+		&cfg.DataStorageFormat,
+	}
+}
+
+// Get the sections underneath this one
+func (cfg *BesuOptionsConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{}
+}
+
+// The title for the config
+func (cfg *ErigonOptionsConfig) GetTitle() string {
+	return "Erigon"
+}
+
+// Get the Parameters for this config
+func (cfg *ErigonOptionsConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{
+		&cfg.PruneMode,
+		&cfg.MaxPeers,
+		&cfg.ContainerTag,
+		&cfg.AdditionalFlags,
+	}
+}
+
+// Get the sections underneath this one
+func (cfg *ErigonOptionsConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{}
+}
+
+// The title for the config
+func (cfg *ExecutionClientOptionsConfig) GetTitle() string {
+	return "Execution Client Options"
+}
+
+// Get the Parameters for this config
+func (cfg *ExecutionClientOptionsConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{}
+}
+
+// Get the sections underneath this one
+func (cfg *ExecutionClientOptionsConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{
+		ids.EcOptionsBesuID:   cfg.Besu,
+		ids.EcOptionsErigonID: cfg.Erigon,
+	}
+}
+
+// Get the IDs of the subconfigs in display order
+func (cfg *ExecutionClientOptionsConfig) GetSubconfigOrder() []string {
+	return []string{
+		ids.EcOptionsBesuID,
+		ids.EcOptionsErigonID,
+	}
+}
+
+// Gets the command line flags for the provided client's settings. Clients without any specific settings have no flags.
+func (cfg *ExecutionClientOptionsConfig) GetFlags(client config.ExecutionClient) []string {
+	flags := []string{}
+	switch client {
+	case config.ExecutionClient_Besu:
+		if cfg.Besu.DataStorageFormat.Value != BesuDataStorageFormat_Default {
+			flags = append(flags, "--data-storage-format="+strings.ToUpper(string(cfg.Besu.DataStorageFormat.Value)))
+		}
+	case ExecutionClient_Erigon:
+		flags = append(flags, fmt.Sprintf("--maxpeers=%d", cfg.Erigon.MaxPeers.Value))
+		if cfg.Erigon.PruneMode.Value != ErigonPruneMode_Default {
+			flags = append(flags, "--prune.mode="+string(cfg.Erigon.PruneMode.Value))
+		}
+	}
+	return flags
+}
+
+// Gets a warning for each client-specific setting that has been changed for a client that isn't the selected local one,
+// since those settings won't be applied. The selected client is blank if Hyperdrive isn't running one locally.
+func (cfg *ExecutionClientOptionsConfig) getInactiveClientWarnings(selected config.ExecutionClient) []string {
+	warnings := []string{}
+	if selected != config.ExecutionClient_Besu && cfg.Besu.DataStorageFormat.Value != BesuDataStorageFormat_Default {
+		warnings = append(warnings, getInactiveClientWarning("Besu", cfg.Besu.DataStorageFormat.Name, selected))
+	}
+	if selected != ExecutionClient_Erigon {
+		if cfg.Erigon.PruneMode.Value != ErigonPruneMode_Default {
+			warnings = append(warnings, getInactiveClientWarning("Erigon", cfg.Erigon.PruneMode.Name, selected))
+		}
+		if cfg.Erigon.AdditionalFlags.Value != "" {
+			warnings = append(warnings, getInactiveClientWarning("Erigon", cfg.Erigon.AdditionalFlags.Name, selected))
+		}
+	}
+	return warnings
+}
+
+// Describes a client-specific setting that won't be applied
+func getInactiveClientWarning(client string, setting string, selected config.ExecutionClient) string {
+	if selected == config.ExecutionClient_Unknown {
+		return fmt.Sprintf("%s's %s setting is set, but Hyperdrive isn't running a local Execution Client, so it won't be applied.", client, setting)
+	}
+	return fmt.Sprintf("%s's %s setting is set, but your local Execution Client is %s, so it won't be applied.", client, setting, selected)
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Gets the expected node wallet address, if one is set
+func (cfg *HyperdriveConfig) GetExpectedNodeAddress() (common.Address, bool) {
+	if cfg.ExpectedNodeAddress.Value == "" {
+		return common.Address{}, false
+	}
+	return common.HexToAddress(cfg.ExpectedNodeAddress.Value), true
+}
+
+// Checks that the expected node address is a valid address if it's set
+func (cfg *HyperdriveConfig) validateExpectedNodeAddress() []string {
+	address := cfg.ExpectedNodeAddress.Value
+	if address != "" && !common.IsHexAddress(address) {
+		return []string{fmt.Sprintf("The expected node address [%s] isn't a valid address.", address)}
+	}
+	return nil
+}
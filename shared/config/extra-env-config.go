@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The label on a Hyperdrive service container listing the extra environment variables it was created with, separated by commas
+	ExtraEnvLabel string = "hyperdrive.extraEnv"
+)
+
+var (
+	// Valid environment variable names
+	envVarNameRegex *regexp.Regexp = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+)
+
+// Extra environment variables to pass to the client containers, keyed by variable name.
+// The names and values are free-form, so they're stored as maps in the settings file instead of as parameters:
+//
+//	extraEnv:
+//	  beaconNode:
+//	    JAVA_OPTS: -Xmx8g
+type ExtraEnvConfig struct {
+	// Extra variables for the Execution Client
+	ExecutionClient map[string]string
+
+	// Extra variables for the Beacon Node
+	BeaconNode map[string]string
+
+	// Extra variables for MEV-Boost
+	MevBoost map[string]string
+}
+
+// Generates a new extra environment variable configuration
+func NewExtraEnvConfig() *ExtraEnvConfig {
+	return &ExtraEnvConfig{
+		ExecutionClient: map[string]string{},
+		BeaconNode:      map[string]string{},
+		MevBoost:        map[string]string{},
+	}
+}
+
+// Gets the extra environment variables for a container. Containers without any configured variables return an empty map.
+func (cfg *ExtraEnvConfig) GetEnv(container config.ContainerID) (map[string]string, error) {
+	id, env := cfg.getServiceEnv(container)
+	if env == nil {
+		return map[string]string{}, nil
+	}
+	err := validateExtraEnv(env)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extra environment variables for [%s]: %w", id, err)
+	}
+	return maps.Clone(env), nil
+}
+
+// Verify the environment variable names are valid, returning a list of errors that must be fixed before saving
+func (cfg *ExtraEnvConfig) Validate() []string {
+	errors := []string{}
+	for _, container := range []config.ContainerID{config.ContainerID_ExecutionClient, config.ContainerID_BeaconNode, config.ContainerID_MevBoost} {
+		_, err := cfg.GetEnv(container)
+		if err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+	return errors
+}
+
+// Serializes the variables into a map of each service's variables, compatible with a settings file
+func (cfg *ExtraEnvConfig) Serialize() map[string]any {
+	return map[string]any{
+		ids.ExtraEnvExecutionClientID: maps.Clone(cfg.ExecutionClient),
+		ids.ExtraEnvBeaconNodeID:      maps.Clone(cfg.BeaconNode),
+		ids.ExtraEnvMevBoostID:        maps.Clone(cfg.MevBoost),
+	}
+}
+
+// Deserializes the variables from a settings file. Services that are missing from the map are left without extra variables.
+func (cfg *ExtraEnvConfig) Deserialize(serialized map[string]any) error {
+	for _, entry := range []struct {
+		id  string
+		env *map[string]string
+	}{
+		{ids.ExtraEnvExecutionClientID, &cfg.ExecutionClient},
+		{ids.ExtraEnvBeaconNodeID, &cfg.BeaconNode},
+		{ids.ExtraEnvMevBoostID, &cfg.MevBoost},
+	} {
+		env := map[string]string{}
+		value, exists := serialized[entry.id]
+		if exists && value != nil {
+			envMap, isMap := value.(map[string]any)
+			if !isMap {
+				return fmt.Errorf("expected [%s - %s] to be a map but it's a %s", ids.ExtraEnvID, entry.id, reflect.TypeOf(value))
+			}
+			for name, envValue := range envMap {
+				env[name] = fmt.Sprint(envValue)
+			}
+		}
+		*entry.env = env
+	}
+	return nil
+}
+
+// Creates a copy of the configuration
+func (cfg *ExtraEnvConfig) Clone() *ExtraEnvConfig {
+	return &ExtraEnvConfig{
+		ExecutionClient: maps.Clone(cfg.ExecutionClient),
+		BeaconNode:      maps.Clone(cfg.BeaconNode),
+		MevBoost:        maps.Clone(cfg.MevBoost),
+	}
+}
+
+// Gets the ID and variables of the service a container runs, or nil variables if it doesn't support extra ones
+func (cfg *ExtraEnvConfig) getServiceEnv(container config.ContainerID) (string, map[string]string) {
+	switch container {
+	case config.ContainerID_ExecutionClient:
+		return ids.ExtraEnvExecutionClientID, cfg.ExecutionClient
+	case config.ContainerID_BeaconNode:
+		return ids.ExtraEnvBeaconNodeID, cfg.BeaconNode
+	case config.ContainerID_MevBoost:
+		return ids.ExtraEnvMevBoostID, cfg.MevBoost
+	}
+	return "", nil
+}
+
+// Checks that each variable has a valid name, reporting them in sorted order so the errors are stable
+func validateExtraEnv(env map[string]string) error {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !envVarNameRegex.MatchString(name) {
+			return fmt.Errorf("[%s] is not a valid environment variable name", name)
+		}
+	}
+	return nil
+}
+
+// Merges extra environment variables into the variables Hyperdrive manages for a container, in Docker's `NAME=value` format.
+// Managed variables take precedence; extra variables with the same name are dropped. Extra variables come first, sorted by name.
+// Also returns the names of the extra variables that were added.
+func MergeContainerEnv(managed []string, extra map[string]string) ([]string, []string) {
+	managedNames := map[string]bool{}
+	for _, entry := range managed {
+		name, _, _ := strings.Cut(entry, "=")
+		managedNames[name] = true
+	}
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if !managedNames[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	merged := make([]string, 0, len(names)+len(managed))
+	for _, name := range names {
+		merged = append(merged, name+"="+extra[name])
+	}
+	return append(merged, managed...), names
+}
+
+// Removes the extra environment variables listed in a container's ExtraEnvLabel, leaving the ones Hyperdrive manages
+func RemoveExtraEnv(env []string, label string) []string {
+	extraNames := map[string]bool{}
+	for _, name := range strings.Split(label, ",") {
+		if name != "" {
+			extraNames[name] = true
+		}
+	}
+	managed := make([]string, 0, len(env))
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		if !extraNames[name] {
+			managed = append(managed, entry)
+		}
+	}
+	return managed
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The label on a Hyperdrive service container listing the container paths of the extra mounts it was created with,
+	// as a JSON array
+	ExtraMountsLabel string = "hyperdrive.extraMounts"
+)
+
+// A host path to bind mount into a client container
+type MountSpec struct {
+	// The absolute path on the host
+	HostPath string
+
+	// The absolute path to mount it at inside the container
+	ContainerPath string
+
+	// True to mount it read-only
+	ReadOnly bool
+}
+
+// Extra host paths to mount into the client containers, for things like custom genesis or trusted setup files.
+// Each service has a list of mounts, so they're stored as lists in the settings file instead of as parameters:
+//
+//	extraMounts:
+//	  beaconNode:
+//	    - hostPath: /srv/genesis
+//	      containerPath: /genesis
+//	      readOnly: true
+type ExtraMountsConfig struct {
+	// Extra mounts for the Execution Client
+	ExecutionClient []MountSpec
+
+	// Extra mounts for the Beacon Node
+	BeaconNode []MountSpec
+
+	// Extra mounts for the Validator Client
+	ValidatorClient []MountSpec
+
+	// Extra mounts for MEV-Boost
+	MevBoost []MountSpec
+}
+
+// Generates a new extra mount configuration
+func NewExtraMountsConfig() *ExtraMountsConfig {
+	return &ExtraMountsConfig{
+		ExecutionClient: []MountSpec{},
+		BeaconNode:      []MountSpec{},
+		ValidatorClient: []MountSpec{},
+		MevBoost:        []MountSpec{},
+	}
+}
+
+// Gets the extra mounts for a container. Containers without any configured mounts return an empty list.
+func (cfg *ExtraMountsConfig) GetMounts(container config.ContainerID) []MountSpec {
+	_, mounts := cfg.getServiceMounts(container)
+	return slices.Clone(mounts)
+}
+
+// Verify the mounts are usable, returning a list of errors that must be fixed before saving.
+// Both paths have to be absolute, the host path has to exist, and a service can't mount two things at the same
+// container path. Host paths can't overlap with any of the provided managed paths, so Hyperdrive's own data can't be
+// exposed to a client by mistake.
+func (cfg *ExtraMountsConfig) Validate(managedPaths []string) []string {
+	errors := []string{}
+	for _, container := range []config.ContainerID{config.ContainerID_ExecutionClient, config.ContainerID_BeaconNode, config.ContainerID_ValidatorClient, config.ContainerID_MevBoost} {
+		id, mounts := cfg.getServiceMounts(container)
+		for i, mount := range mounts {
+			prefix := fmt.Sprintf("extra mount %d for [%s]", i+1, id)
+			if !filepath.IsAbs(mount.HostPath) {
+				errors = append(errors, fmt.Sprintf("%s has a host path of [%s], but it must be absolute", prefix, mount.HostPath))
+			} else if _, err := os.Stat(mount.HostPath); err != nil {
+				errors = append(errors, fmt.Sprintf("%s has a host path of [%s], but it can't be accessed: %s", prefix, mount.HostPath, err.Error()))
+			} else {
+				for _, managed := range managedPaths {
+					if managed != "" && PathsOverlap(mount.HostPath, managed) {
+						errors = append(errors, fmt.Sprintf("%s has a host path of [%s], which overlaps with Hyperdrive's managed path [%s]", prefix, mount.HostPath, managed))
+					}
+				}
+			}
+			if !filepath.IsAbs(mount.ContainerPath) {
+				errors = append(errors, fmt.Sprintf("%s has a container path of [%s], but it must be absolute", prefix, mount.ContainerPath))
+				continue
+			}
+			for j := 0; j < i; j++ {
+				if PathsOverlap(mount.ContainerPath, mounts[j].ContainerPath) {
+					errors = append(errors, fmt.Sprintf("%s has a container path of [%s], which overlaps with extra mount %d", prefix, mount.ContainerPath, j+1))
+				}
+			}
+		}
+	}
+	return errors
+}
+
+// Serializes the mounts into a map of each service's mounts, compatible with a settings file
+func (cfg *ExtraMountsConfig) Serialize() map[string]any {
+	serialized := map[string]any{}
+	for _, container := range []config.ContainerID{config.ContainerID_ExecutionClient, config.ContainerID_BeaconNode, config.ContainerID_ValidatorClient, config.ContainerID_MevBoost} {
+		id, mounts := cfg.getServiceMounts(container)
+		list := make([]any, len(mounts))
+		for i, mount := range mounts {
+			list[i] = map[string]any{
+				ids.ExtraMountHostPathID:      mount.HostPath,
+				ids.ExtraMountContainerPathID: mount.ContainerPath,
+				ids.ExtraMountReadOnlyID:      mount.ReadOnly,
+			}
+		}
+		serialized[id] = list
+	}
+	return serialized
+}
+
+// Deserializes the mounts from a settings file. Services that are missing from the map are left without extra mounts.
+func (cfg *ExtraMountsConfig) Deserialize(serialized map[string]any) error {
+	for _, entry := range []struct {
+		id     string
+		mounts *[]MountSpec
+	}{
+		{ids.ExtraMountsExecutionClientID, &cfg.ExecutionClient},
+		{ids.ExtraMountsBeaconNodeID, &cfg.BeaconNode},
+		{ids.ExtraMountsValidatorClientID, &cfg.ValidatorClient},
+		{ids.ExtraMountsMevBoostID, &cfg.MevBoost},
+	} {
+		mounts := []MountSpec{}
+		value, exists := serialized[entry.id]
+		if exists && value != nil {
+			list, isList := value.([]any)
+			if !isList {
+				return fmt.Errorf("expected [%s - %s] to be a list but it's a %s", ids.ExtraMountsID, entry.id, reflect.TypeOf(value))
+			}
+			for i, item := range list {
+				mount, err := deserializeMountSpec(item)
+				if err != nil {
+					return fmt.Errorf("error deserializing [%s - %s] entry %d: %w", ids.ExtraMountsID, entry.id, i+1, err)
+				}
+				mounts = append(mounts, mount)
+			}
+		}
+		*entry.mounts = mounts
+	}
+	return nil
+}
+
+// Creates a copy of the configuration
+func (cfg *ExtraMountsConfig) Clone() *ExtraMountsConfig {
+	return &ExtraMountsConfig{
+		ExecutionClient: slices.Clone(cfg.ExecutionClient),
+		BeaconNode:      slices.Clone(cfg.BeaconNode),
+		ValidatorClient: slices.Clone(cfg.ValidatorClient),
+		MevBoost:        slices.Clone(cfg.MevBoost),
+	}
+}
+
+// Gets the ID and mounts of the service a container runs, or nil mounts if it doesn't support extra ones
+func (cfg *ExtraMountsConfig) getServiceMounts(container config.ContainerID) (string, []MountSpec) {
+	switch container {
+	case config.ContainerID_ExecutionClient:
+		return ids.ExtraMountsExecutionClientID, cfg.ExecutionClient
+	case config.ContainerID_BeaconNode:
+		return ids.ExtraMountsBeaconNodeID, cfg.BeaconNode
+	case config.ContainerID_ValidatorClient:
+		return ids.ExtraMountsValidatorClientID, cfg.ValidatorClient
+	case config.ContainerID_MevBoost:
+		return ids.ExtraMountsMevBoostID, cfg.MevBoost
+	}
+	return "", nil
+}
+
+// Parses a single mount from its settings file map
+func deserializeMountSpec(item any) (MountSpec, error) {
+	mountMap, isMap := item.(map[string]any)
+	if !isMap {
+		return MountSpec{}, fmt.Errorf("expected a map but it's a %s", reflect.TypeOf(item))
+	}
+	mount := MountSpec{}
+	if hostPath, exists := mountMap[ids.ExtraMountHostPathID]; exists && hostPath != nil {
+		mount.HostPath = fmt.Sprint(hostPath)
+	}
+	if containerPath, exists := mountMap[ids.ExtraMountContainerPathID]; exists && containerPath != nil {
+		mount.ContainerPath = fmt.Sprint(containerPath)
+	}
+	switch readOnly := mountMap[ids.ExtraMountReadOnlyID].(type) {
+	case nil:
+	case bool:
+		mount.ReadOnly = readOnly
+	case string:
+		parsed, err := strconv.ParseBool(readOnly)
+		if err != nil {
+			return MountSpec{}, fmt.Errorf("[%s] is [%s], but it must be true or false", ids.ExtraMountReadOnlyID, readOnly)
+		}
+		mount.ReadOnly = parsed
+	default:
+		return MountSpec{}, fmt.Errorf("expected [%s] to be a bool but it's a %s", ids.ExtraMountReadOnlyID, reflect.TypeOf(readOnly))
+	}
+	return mount, nil
+}
+
+// Checks if two paths are the same or one is inside the other
+func PathsOverlap(first string, second string) bool {
+	first = filepath.Clean(first)
+	second = filepath.Clean(second)
+	if first == second {
+		return true
+	}
+	isInside := func(path string, dir string) bool {
+		return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+	}
+	return isInside(first, second) || isInside(second, first)
+}
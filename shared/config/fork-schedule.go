@@ -0,0 +1,87 @@
+package config
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// Beacon Chain fork names, as used in the Beacon API
+	ForkName_Phase0    string = "phase0"
+	ForkName_Altair    string = "altair"
+	ForkName_Bellatrix string = "bellatrix"
+	ForkName_Capella   string = "capella"
+	ForkName_Deneb     string = "deneb"
+	ForkName_Electra   string = "electra"
+)
+
+// A Beacon Chain fork
+type Fork struct {
+	Name    string
+	Version []byte
+	Epoch   uint64
+}
+
+// The identity and fork history of a Beacon Chain, for computing signing domains without a Beacon Node
+type ForkSchedule struct {
+	GenesisValidatorsRoot []byte
+
+	// The forks in activation order, starting with the genesis fork
+	Forks []Fork
+}
+
+// Gets the fork that's active at the provided epoch
+func (s ForkSchedule) GetForkAt(epoch uint64) Fork {
+	active := s.Forks[0]
+	for _, fork := range s.Forks[1:] {
+		if fork.Epoch > epoch {
+			break
+		}
+		active = fork
+	}
+	return active
+}
+
+// Gets the fork with the provided name. Returns false if the chain doesn't have it scheduled.
+func (s ForkSchedule) GetFork(name string) (Fork, bool) {
+	for _, fork := range s.Forks {
+		if fork.Name == name {
+			return fork, true
+		}
+	}
+	return Fork{}, false
+}
+
+// Gets the fork schedule of a public network. Returns false for networks whose schedule isn't known ahead of time, such
+// as local test networks.
+func GetForkSchedule(network config.Network) (ForkSchedule, bool) {
+	switch network {
+	case config.Network_Mainnet:
+		// https://github.com/ethereum/consensus-specs/blob/dev/configs/mainnet.yaml
+		return ForkSchedule{
+			GenesisValidatorsRoot: common.FromHex("0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+			Forks: []Fork{
+				{Name: ForkName_Phase0, Version: common.FromHex("0x00000000"), Epoch: 0},
+				{Name: ForkName_Altair, Version: common.FromHex("0x01000000"), Epoch: 74240},
+				{Name: ForkName_Bellatrix, Version: common.FromHex("0x02000000"), Epoch: 144896},
+				{Name: ForkName_Capella, Version: common.FromHex("0x03000000"), Epoch: 194048},
+				{Name: ForkName_Deneb, Version: common.FromHex("0x04000000"), Epoch: 269568},
+				{Name: ForkName_Electra, Version: common.FromHex("0x05000000"), Epoch: 364032},
+			},
+		}, true
+	case config.Network_Holesky, Network_HoleskyDev:
+		// https://github.com/eth-clients/holesky/blob/main/metadata/config.yaml
+		return ForkSchedule{
+			GenesisValidatorsRoot: common.FromHex("0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"),
+			Forks: []Fork{
+				{Name: ForkName_Phase0, Version: common.FromHex("0x01017000"), Epoch: 0},
+				{Name: ForkName_Altair, Version: common.FromHex("0x02017000"), Epoch: 0},
+				{Name: ForkName_Bellatrix, Version: common.FromHex("0x03017000"), Epoch: 0},
+				{Name: ForkName_Capella, Version: common.FromHex("0x04017000"), Epoch: 256},
+				{Name: ForkName_Deneb, Version: common.FromHex("0x05017000"), Epoch: 29696},
+				{Name: ForkName_Electra, Version: common.FromHex("0x06017000"), Epoch: 115968},
+			},
+		}, true
+	}
+	return ForkSchedule{}, false
+}
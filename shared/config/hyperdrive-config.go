@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/alessio/shellescape"
 	"github.com/nodeset-org/hyperdrive-daemon/shared"
@@ -23,20 +25,39 @@ import (
 const (
 	// Tags
 	hyperdriveTag string = "nodeset/hyperdrive:v" + shared.HyperdriveVersion
+
+	// The label on every container Hyperdrive manages, set to the project name so instances sharing a Docker host can
+	// find their own containers
+	InstanceLabel string = "hyperdrive.instance"
+
+	// The label on a module's containers, set to the module's name so Hyperdrive can tell which module they belong to
+	ModuleLabel string = "hyperdrive.module"
 )
 
 // The master configuration struct
 type HyperdriveConfig struct {
 	// General settings
-	Network                  config.Parameter[config.Network]
-	ClientMode               config.Parameter[config.ClientMode]
-	ProjectName              config.Parameter[string]
-	ApiPort                  config.Parameter[uint16]
-	UserDataPath             config.Parameter[string]
-	AutoTxMaxFee             config.Parameter[float64]
-	MaxPriorityFee           config.Parameter[float64]
-	AutoTxGasThreshold       config.Parameter[float64]
-	AdditionalDockerNetworks config.Parameter[string]
+	Network                   config.Parameter[config.Network]
+	ClientMode                config.Parameter[config.ClientMode]
+	ProjectName               config.Parameter[string]
+	ApiPort                   config.Parameter[uint16]
+	UserDataPath              config.Parameter[string]
+	AutoTxMaxFee              config.Parameter[float64]
+	MaxPriorityFee            config.Parameter[float64]
+	AutoTxGasThreshold        config.Parameter[float64]
+	AdditionalDockerNetworks  config.Parameter[string]
+	NodeSetApiUrl             config.Parameter[string]
+	MaxConcurrentContainerOps config.Parameter[uint64]
+	StartupTimeout            config.Parameter[uint64]
+	EnableEngineJwtRotation   config.Parameter[bool]
+	PrysmApiMode              config.Parameter[PrysmApiMode]
+	TransactionSigner         config.Parameter[string]
+	ExpectedNodeAddress       config.Parameter[string]
+	ClockSkewThreshold        config.Parameter[uint64]
+	StuckSyncWindow           config.Parameter[uint64]
+	EngineApiUrl              config.Parameter[string]
+	EngineJwtSecretPath       config.Parameter[string]
+	AllowDuplicateValidators  config.Parameter[bool]
 
 	// The Docker Hub tag for the daemon container
 	ContainerTag config.Parameter[string]
@@ -61,6 +82,30 @@ type HyperdriveConfig struct {
 	// MEV-Boost
 	MevBoost *MevBoostConfig
 
+	// Validator Client key manager
+	KeyManager *KeyManagerConfig
+
+	// Extra environment variables for the client containers
+	ExtraEnv *ExtraEnvConfig
+
+	// Extra host paths to mount into the client containers
+	ExtraMounts *ExtraMountsConfig
+
+	// Settings for specific local Execution Clients
+	ExecutionClientOptions *ExecutionClientOptionsConfig
+
+	// Docker restart policies for the client containers
+	RestartPolicy *RestartPolicyConfig
+
+	// The users the client containers run as
+	ContainerUser *ContainerUserConfig
+
+	// Alert delivery
+	Alerts *AlertsConfig
+
+	// Genesis artifacts for custom networks
+	CustomGenesis *CustomGenesisConfig
+
 	// Modules
 	Modules map[string]any
 
@@ -100,6 +145,25 @@ func LoadFromFile(path string) (*HyperdriveConfig, error) {
 	return cfg, nil
 }
 
+// Saves the configuration and the provided module configs to a settings file.
+// The settings are validated first; if any of them are invalid, the file is left alone and the errors are returned.
+// Settings that are valid but won't have any effect are saved, and returned as warnings for the user.
+func (cfg *HyperdriveConfig) SaveToFile(path string, modules []IModuleConfig) ([]string, error) {
+	errs := cfg.Validate()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("config has invalid settings:\n%s", strings.Join(errs, "\n"))
+	}
+	configBytes, err := yaml.Marshal(cfg.Serialize(modules, false))
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize settings: %w", err)
+	}
+	err = os.WriteFile(path, configBytes, 0664)
+	if err != nil {
+		return nil, fmt.Errorf("could not write Hyperdrive settings file to %s: %w", shellescape.Quote(path), err)
+	}
+	return cfg.GetWarnings(), nil
+}
+
 // Creates a new Hyperdrive configuration instance
 func NewHyperdriveConfig(hdDir string) *HyperdriveConfig {
 	cfg := newHyperdriveConfigImpl(hdDir, config.Network_Mainnet) // Default to mainnet
@@ -124,7 +188,7 @@ func newHyperdriveConfigImpl(hdDir string, network config.Network) *HyperdriveCo
 			ParameterCommon: &config.ParameterCommon{
 				ID:                 ids.ProjectNameID,
 				Name:               "Project Name",
-				Description:        "This is the prefix that will be attached to all of the Docker containers managed by Hyperdrive.",
+				Description:        "This is the prefix that will be attached to all of the Docker containers managed by Hyperdrive. It's also used to label them, so multiple Hyperdrive instances on the same machine can tell their containers apart.",
 				AffectsContainers:  []config.ContainerID{config.ContainerID_BeaconNode, config.ContainerID_Daemon, config.ContainerID_ExecutionClient, config.ContainerID_Exporter, config.ContainerID_Grafana, config.ContainerID_Prometheus, config.ContainerID_ValidatorClient},
 				CanBeBlank:         false,
 				OverwriteOnUpgrade: false,
@@ -261,6 +325,190 @@ func newHyperdriveConfigImpl(hdDir string, network config.Network) *HyperdriveCo
 			},
 		},
 
+		NodeSetApiUrl: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.NodeSetApiUrlID,
+				Name:               "NodeSet API URL",
+				Description:        "The URL of the NodeSet web API that Hyperdrive and its modules use to communicate with NodeSet's backend.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: true,
+			},
+			Default: map[config.Network]string{
+				config.Network_Mainnet: "https://nodeset.io/api",
+				config.Network_Holesky: "https://staging.nodeset.io/api",
+				Network_HoleskyDev:     "https://staging.nodeset.io/api",
+				config.Network_All:     "https://nodeset.io/api",
+			},
+		},
+
+		MaxConcurrentContainerOps: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.MaxConcurrentContainerOpsID,
+				Name:               "Max Concurrent Container Operations",
+				Description:        "The maximum number of Docker containers the daemon will create, start, or stop at the same time. Lowering this can keep small machines responsive when many containers are restarted at once.\n\nUse 0 to limit it to the number of CPU cores on your machine.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 0,
+			},
+		},
+
+		StartupTimeout: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.StartupTimeoutID,
+				Name:               "Client Startup Timeout",
+				Description:        "The number of seconds to wait for each client to come up when starting the node. Clients are started in order (Execution Client, then Beacon Node, then Validator Clients), and startup stops if one doesn't respond in time.\n\nYou may need to raise this on slow machines, where clients can take a while to open their databases.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 300,
+			},
+		},
+
+		EnableEngineJwtRotation: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.EnableEngineJwtRotationID,
+				Name:               "Enable Engine API Secret Rotation",
+				Description:        "Allow the daemon to replace the secret your Execution Client and Beacon Node use to authenticate with each other. When the secret is rotated, both clients are restarted together so they pick up the new one at the same time.\n\nOnly applies when Hyperdrive manages your clients.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: false,
+			},
+		},
+
+		PrysmApiMode: config.Parameter[PrysmApiMode]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.PrysmApiModeID,
+				Name:               "Prysm API Mode",
+				Description:        "Select which of Prysm's APIs the daemon uses for its Beacon Node client, which handles things like sync status, validator statuses, duties, and exits. This only applies if your Beacon Node is Prysm.\n\nFeatures that rely on the Beacon REST API specifically will still use Prysm's HTTP port.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Options: []*config.ParameterOption[PrysmApiMode]{{
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "REST",
+					Description: "Use the standard Beacon REST API on Prysm's HTTP port. This is supported by every Beacon Node and is the recommended option.",
+				},
+				Value: PrysmApiMode_Rest,
+			}, {
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "gRPC",
+					Description: "Use Prysm's gRPC API on its RPC port. Some operations, such as fetching blocks and submitting BLS-to-execution changes, aren't available over gRPC and will fail in this mode.",
+				},
+				Value: PrysmApiMode_Grpc,
+			}},
+			Default: map[config.Network]PrysmApiMode{
+				config.Network_All: PrysmApiMode_Rest,
+			},
+		},
+
+		TransactionSigner: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.TransactionSignerID,
+				Name:               "Transaction Signer",
+				Description:        "The name of the signer the daemon uses to sign the node's transactions. Use `" + LocalTransactionSignerName + "` to sign with the node wallet's keystore.\n\nOther signers, such as hardware wallets or remote signers, are only available if the daemon was built with them.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: LocalTransactionSignerName,
+			},
+		},
+
+		ExpectedNodeAddress: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.ExpectedNodeAddressID,
+				Name:               "Expected Node Address",
+				Description:        "The address you expect the node wallet to have. If set, the daemon checks the loaded wallet against it on startup and logs an error if they don't match, which catches restoring the wrong mnemonic or derivation path.\n\nLeave this blank to skip the check.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		ClockSkewThreshold: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.ClockSkewThresholdID,
+				Name:               "Clock Skew Threshold",
+				Description:        "The number of milliseconds the system clock can be off from the Beacon Chain's before the health report flags it. Validators need an accurate clock to perform their duties on time, so make sure NTP is running if this is flagged.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 2000,
+			},
+		},
+
+		StuckSyncWindow: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.StuckSyncWindowID,
+				Name:               "Stuck Sync Window",
+				Description:        "The number of seconds a syncing client's distance from the head can go without shrinking before it's considered stuck. Clients that are slow but still catching up aren't flagged, so raise this if a heavily loaded machine keeps reporting its clients as stuck.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 1800,
+			},
+		},
+
+		EngineApiUrl: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.EngineApiUrlID,
+				Name:               "Engine API URL",
+				Description:        "The URL of the Execution Client's Engine API, which the daemon checks on startup to make sure the client is ready for the Beacon Node. Leave this blank to use the Engine API port of the Execution Client Hyperdrive manages.\n\nIf you use an external Execution Client, set this and the Engine API Secret Path to have the daemon check it too.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		EngineJwtSecretPath: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.EngineJwtSecretPathID,
+				Name:               "Engine API Secret Path",
+				Description:        "The path of the secret the Execution Client uses to authenticate Engine API requests. Leave this blank to use the secret shared by the Execution Client and Beacon Node containers Hyperdrive manages.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		AllowDuplicateValidators: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AllowDuplicateValidatorsID,
+				Name:               "Allow Duplicate Validators",
+				Description:        "Start the Validator Clients even if more than one module claims the same validator key. A key loaded by two modules is run twice, which gets it slashed, so only enable this if you're sure the modules won't both run it.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: false,
+			},
+		},
+
 		ContainerTag: config.Parameter[string]{
 			ParameterCommon: &config.ParameterCommon{
 				ID:                 ids.ContainerTagID,
@@ -285,6 +533,14 @@ func newHyperdriveConfigImpl(hdDir string, network config.Network) *HyperdriveCo
 	cfg.Fallback = config.NewFallbackConfig()
 	cfg.Metrics = NewMetricsConfig()
 	cfg.MevBoost = NewMevBoostConfig(cfg)
+	cfg.KeyManager = NewKeyManagerConfig()
+	cfg.ExtraEnv = NewExtraEnvConfig()
+	cfg.ExtraMounts = NewExtraMountsConfig()
+	cfg.ExecutionClientOptions = NewExecutionClientOptionsConfig()
+	cfg.RestartPolicy = NewRestartPolicyConfig()
+	cfg.ContainerUser = NewContainerUserConfig()
+	cfg.Alerts = NewAlertsConfig()
+	cfg.CustomGenesis = NewCustomGenesisConfig()
 
 	// Apply the default values for the network
 	cfg.Network.Value = network
@@ -310,6 +566,18 @@ func (cfg *HyperdriveConfig) GetParameters() []config.IParameter {
 		&cfg.AutoTxGasThreshold,
 		&cfg.UserDataPath,
 		&cfg.AdditionalDockerNetworks,
+		&cfg.NodeSetApiUrl,
+		&cfg.MaxConcurrentContainerOps,
+		&cfg.StartupTimeout,
+		&cfg.EnableEngineJwtRotation,
+		&cfg.PrysmApiMode,
+		&cfg.TransactionSigner,
+		&cfg.ExpectedNodeAddress,
+		&cfg.ClockSkewThreshold,
+		&cfg.StuckSyncWindow,
+		&cfg.EngineApiUrl,
+		&cfg.EngineJwtSecretPath,
+		&cfg.AllowDuplicateValidators,
 		&cfg.ContainerTag,
 	}
 }
@@ -320,19 +588,76 @@ func (cfg *HyperdriveConfig) GetSubconfigs() map[string]config.IConfigSection {
 		ids.LoggingID:           cfg.Logging,
 		ids.FallbackID:          cfg.Fallback,
 		ids.LocalExecutionID:    cfg.LocalExecutionClient,
+		ids.EcOptionsID:         cfg.ExecutionClientOptions,
 		ids.ExternalExecutionID: cfg.ExternalExecutionClient,
 		ids.LocalBeaconID:       cfg.LocalBeaconClient,
 		ids.ExternalBeaconID:    cfg.ExternalBeaconClient,
 		ids.MetricsID:           cfg.Metrics,
 		ids.MevBoostID:          cfg.MevBoost,
+		ids.KeyManagerID:        cfg.KeyManager,
+		ids.RestartPolicyID:     cfg.RestartPolicy,
+		ids.ContainerUserID:     cfg.ContainerUser,
+		ids.AlertsID:            cfg.Alerts,
+		ids.CustomGenesisID:     cfg.CustomGenesis,
 	}
 }
 
+// Get the IDs of the subconfigs in display order
+func (cfg *HyperdriveConfig) GetSubconfigOrder() []string {
+	return []string{
+		ids.LoggingID,
+		ids.LocalExecutionID,
+		ids.EcOptionsID,
+		ids.ExternalExecutionID,
+		ids.LocalBeaconID,
+		ids.ExternalBeaconID,
+		ids.FallbackID,
+		ids.MetricsID,
+		ids.MevBoostID,
+		ids.KeyManagerID,
+		ids.RestartPolicyID,
+		ids.ContainerUserID,
+		ids.AlertsID,
+		ids.CustomGenesisID,
+	}
+}
+
+// Verify the current settings and publish a list of errors that must be resolved before saving
+func (cfg *HyperdriveConfig) Validate() []string {
+	errors := []string{}
+	errors = append(errors, cfg.ExtraEnv.Validate()...)
+	errors = append(errors, cfg.ExtraMounts.Validate([]string{cfg.UserDataPath.Value})...)
+	errors = append(errors, cfg.RestartPolicy.Validate()...)
+	errors = append(errors, cfg.KeyManager.Validate()...)
+	errors = append(errors, cfg.ContainerUser.Validate()...)
+	errors = append(errors, cfg.Alerts.Validate()...)
+	errors = append(errors, cfg.validateCustomGenesis()...)
+	errors = append(errors, cfg.validatePrysmApiMode()...)
+	errors = append(errors, cfg.validateExecutionClientIpc()...)
+	errors = append(errors, cfg.validateBuilderBoostFactor()...)
+	errors = append(errors, cfg.validateExpectedNodeAddress()...)
+	return errors
+}
+
+// Checks the configuration for settings that are valid but won't have any effect, returning a warning for each one
+func (cfg *HyperdriveConfig) GetWarnings() []string {
+	selected := config.ExecutionClient_Unknown
+	if cfg.IsLocalMode() {
+		selected = cfg.LocalExecutionClient.ExecutionClient.Value
+	}
+	warnings := cfg.ExecutionClientOptions.getInactiveClientWarnings(selected)
+	warnings = append(warnings, cfg.getBuilderBoostFactorWarnings()...)
+	warnings = append(warnings, cfg.getCustomGenesisWarnings()...)
+	return append(warnings, cfg.getProposerOnlyWarnings()...)
+}
+
 // Serializes the configuration into a map of maps, compatible with a settings file
 func (cfg *HyperdriveConfig) Serialize(modules []IModuleConfig, includeUserDir bool) map[string]any {
 	masterMap := map[string]any{}
 
 	hdMap := config.Serialize(cfg)
+	hdMap[ids.ExtraEnvID] = cfg.ExtraEnv.Serialize()
+	hdMap[ids.ExtraMountsID] = cfg.ExtraMounts.Serialize()
 	masterMap[ids.VersionID] = fmt.Sprintf("v%s", shared.HyperdriveVersion)
 	masterMap[ids.RootConfigID] = hdMap
 
@@ -387,6 +712,28 @@ func (cfg *HyperdriveConfig) Deserialize(masterMap map[string]any) error {
 	if err != nil {
 		return fmt.Errorf("error deserializing [%s]: %w", ids.RootConfigID, err)
 	}
+	extraEnv, exists := hdMap[ids.ExtraEnvID]
+	if exists {
+		extraEnvMap, isMap := extraEnv.(map[string]any)
+		if !isMap {
+			return fmt.Errorf("config has an entry named [%s - %s] but it is not a map, it's a %s", ids.RootConfigID, ids.ExtraEnvID, reflect.TypeOf(extraEnv))
+		}
+		err = cfg.ExtraEnv.Deserialize(extraEnvMap)
+		if err != nil {
+			return fmt.Errorf("error deserializing [%s - %s]: %w", ids.RootConfigID, ids.ExtraEnvID, err)
+		}
+	}
+	extraMounts, exists := hdMap[ids.ExtraMountsID]
+	if exists {
+		extraMountsMap, isMap := extraMounts.(map[string]any)
+		if !isMap {
+			return fmt.Errorf("config has an entry named [%s - %s] but it is not a map, it's a %s", ids.RootConfigID, ids.ExtraMountsID, reflect.TypeOf(extraMounts))
+		}
+		err = cfg.ExtraMounts.Deserialize(extraMountsMap)
+		if err != nil {
+			return fmt.Errorf("error deserializing [%s - %s]: %w", ids.RootConfigID, ids.ExtraMountsID, err)
+		}
+	}
 
 	// Get the special fields
 	version, exists := masterMap[ids.VersionID]
@@ -433,6 +780,8 @@ func (cfg *HyperdriveConfig) ChangeNetwork(newNetwork config.Network) {
 func (cfg *HyperdriveConfig) Clone() *HyperdriveConfig {
 	clone := NewHyperdriveConfig(cfg.hyperdriveUserDirectory)
 	config.Clone(cfg, clone, cfg.Network.Value)
+	clone.ExtraEnv = cfg.ExtraEnv.Clone()
+	clone.ExtraMounts = cfg.ExtraMounts.Clone()
 	clone.updateResources()
 	clone.Version = cfg.Version
 	return clone
@@ -517,6 +866,37 @@ func (cfg *HyperdriveConfig) GetPasswordFilePath() string {
 	return filepath.Join(cfg.UserDataPath.Value, UserPasswordFilename)
 }
 
+func (cfg *HyperdriveConfig) GetKeystoreDirectory() string {
+	return filepath.Join(cfg.UserDataPath.Value, KeystoreDir)
+}
+
+func (cfg *HyperdriveConfig) GetSlashingProtectionBackupDirectory() string {
+	return filepath.Join(cfg.hyperdriveUserDirectory, SlashingProtectionBackupDir)
+}
+
+// Gets the maximum number of container operations that can run at once, defaulting to the number of CPU cores
+func (cfg *HyperdriveConfig) GetMaxConcurrentContainerOps() int {
+	if cfg.MaxConcurrentContainerOps.Value == 0 {
+		return runtime.NumCPU()
+	}
+	return int(cfg.MaxConcurrentContainerOps.Value)
+}
+
+// Gets how far the system clock can be off before the health report flags it
+func (cfg *HyperdriveConfig) GetClockSkewThreshold() time.Duration {
+	return time.Duration(cfg.ClockSkewThreshold.Value) * time.Millisecond
+}
+
+// Gets how long a syncing client can go without getting closer to the head before it's considered stuck
+func (cfg *HyperdriveConfig) GetStuckSyncWindow() time.Duration {
+	return time.Duration(cfg.StuckSyncWindow.Value) * time.Second
+}
+
+// Gets how long to wait for each client to come up during startup
+func (cfg *HyperdriveConfig) GetStartupTimeout() time.Duration {
+	return time.Duration(cfg.StartupTimeout.Value) * time.Second
+}
+
 func (cfg *HyperdriveConfig) GetNetworkResources() *config.NetworkResources {
 	return cfg.resources
 }
@@ -2,19 +2,31 @@ package ids
 
 const (
 	// Hyperdrive parameter IDs
-	RootConfigID               string = "hyperdrive"
-	VersionID                  string = "version"
-	UserDirID                  string = "hdUserDir"
-	ApiPortID                  string = "apiPort"
-	NetworkID                  string = "network"
-	ClientModeID               string = "clientMode"
-	UserDataPathID             string = "hdUserDataDir"
-	ProjectNameID              string = "projectName"
-	AutoTxMaxFeeID             string = "autoTxMaxFee"
-	MaxPriorityFeeID           string = "maxPriorityFee"
-	AutoTxGasThresholdID       string = "autoTxGasThreshold"
-	AdditionalDockerNetworksID string = "additionalDockerNetworks"
-	ContainerTagID             string = "containerTag"
+	RootConfigID                string = "hyperdrive"
+	VersionID                   string = "version"
+	UserDirID                   string = "hdUserDir"
+	ApiPortID                   string = "apiPort"
+	NetworkID                   string = "network"
+	ClientModeID                string = "clientMode"
+	UserDataPathID              string = "hdUserDataDir"
+	ProjectNameID               string = "projectName"
+	AutoTxMaxFeeID              string = "autoTxMaxFee"
+	MaxPriorityFeeID            string = "maxPriorityFee"
+	AutoTxGasThresholdID        string = "autoTxGasThreshold"
+	AdditionalDockerNetworksID  string = "additionalDockerNetworks"
+	ContainerTagID              string = "containerTag"
+	NodeSetApiUrlID             string = "nodesetApiUrl"
+	MaxConcurrentContainerOpsID string = "maxConcurrentContainerOps"
+	StartupTimeoutID            string = "startupTimeout"
+	EnableEngineJwtRotationID   string = "enableEngineJwtRotation"
+	PrysmApiModeID              string = "prysmApiMode"
+	TransactionSignerID         string = "transactionSigner"
+	ExpectedNodeAddressID       string = "expectedNodeAddress"
+	ClockSkewThresholdID        string = "clockSkewThreshold"
+	StuckSyncWindowID           string = "stuckSyncWindow"
+	EngineApiUrlID              string = "engineApiUrl"
+	EngineJwtSecretPathID       string = "engineJwtSecretPath"
+	AllowDuplicateValidatorsID  string = "allowDuplicateValidators"
 
 	// Subconfig IDs
 	LoggingID           string = "logging"
@@ -25,6 +37,14 @@ const (
 	ExternalBeaconID    string = "externalBeacon"
 	MetricsID           string = "metrics"
 	MevBoostID          string = "mevBoost"
+	KeyManagerID        string = "keyManager"
+	ExtraEnvID          string = "extraEnv"
+	EcOptionsID         string = "executionClientOptions"
+	RestartPolicyID     string = "restartPolicy"
+	ContainerUserID     string = "containerUser"
+	ExtraMountsID       string = "extraMounts"
+	AlertsID            string = "alerts"
+	CustomGenesisID     string = "customGenesis"
 
 	// MEV-Boost
 	MevBoostEnableID             string = "enableMevBoost"
@@ -38,4 +58,66 @@ const (
 	MevBoostEdenID               string = "edenEnabled"
 	MevBoostTitanRegionalID      string = "titanRegionaEnabled"
 	MevBoostCustomRelaysID       string = "customRelays"
+	MevBoostBuilderBoostFactorID string = "builderBoostFactor"
+
+	// Key Manager
+	KeyManagerUrlID             string = "url"
+	KeyManagerTokenPathID       string = "tokenPath"
+	KeyManagerBackupsID         string = "slashingProtectionBackups"
+	KeyManagerRemoteSignerUrlID string = "remoteSignerUrl"
+	KeyManagerProposerOnlyID    string = "proposerOnly"
+	KeyManagerAttestationVcID   string = "attestationVcUrl"
+
+	// Alerts
+	AlertsSmtpHostID         string = "smtpHost"
+	AlertsSmtpPortID         string = "smtpPort"
+	AlertsSmtpFromID         string = "smtpFrom"
+	AlertsSmtpToID           string = "smtpTo"
+	AlertsSmtpUsernameID     string = "smtpUsername"
+	AlertsSmtpPasswordPathID string = "smtpPasswordPath"
+	AlertsEmailSeverityID    string = "emailSeverity"
+
+	// Custom genesis
+	CustomGenesisExecutionGenesisID string = "executionGenesisPath"
+	CustomGenesisBeaconConfigID     string = "beaconConfigPath"
+	CustomGenesisBeaconStateID      string = "beaconGenesisStatePath"
+
+	// Client-specific Execution Client settings
+	EcOptionsBesuID         string = "besu"
+	EcOptionsErigonID       string = "erigon"
+	BesuDataStorageFormatID string = "dataStorageFormat"
+	ErigonPruneModeID       string = "pruneMode"
+	ErigonMaxPeersID        string = "maxPeers"
+	ErigonContainerTagID    string = "containerTag"
+	ErigonAdditionalFlagsID string = "additionalFlags"
+
+	// Container restart policies
+	RestartPolicyExecutionClientID string = "executionClient"
+	RestartPolicyBeaconNodeID      string = "beaconNode"
+	RestartPolicyValidatorClientID string = "validatorClient"
+	RestartPolicyMevBoostID        string = "mevBoost"
+	RestartPolicyModeID            string = "policy"
+	RestartPolicyMaxRetriesID      string = "maxRetries"
+
+	// Container users
+	ContainerUserExecutionClientID string = "executionClient"
+	ContainerUserBeaconNodeID      string = "beaconNode"
+	ContainerUserValidatorClientID string = "validatorClient"
+	ContainerUserMevBoostID        string = "mevBoost"
+	ContainerUserRunAsUserID       string = "runAsUser"
+	ContainerUserRunAsGroupID      string = "runAsGroup"
+
+	// Extra environment variable parameter IDs
+	ExtraEnvExecutionClientID string = "executionClient"
+	ExtraEnvBeaconNodeID      string = "beaconNode"
+	ExtraEnvMevBoostID        string = "mevBoost"
+
+	// Extra mount IDs
+	ExtraMountsExecutionClientID string = "executionClient"
+	ExtraMountsBeaconNodeID      string = "beaconNode"
+	ExtraMountsValidatorClientID string = "validatorClient"
+	ExtraMountsMevBoostID        string = "mevBoost"
+	ExtraMountHostPathID         string = "hostPath"
+	ExtraMountContainerPathID    string = "containerPath"
+	ExtraMountReadOnlyID         string = "readOnly"
 )
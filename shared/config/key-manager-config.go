@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// Configuration for the Validator Client's key manager API
+type KeyManagerConfig struct {
+	// The URL of the key manager API
+	Url config.Parameter[string]
+
+	// The path of the file containing the key manager API's auth token
+	TokenPath config.Parameter[string]
+
+	// The number of slashing protection backups to keep
+	SlashingProtectionBackups config.Parameter[uint64]
+
+	// The URL of the remote signer the Validator Client uses for remote keys
+	RemoteSignerUrl config.Parameter[string]
+
+	// True if the Validator Client only proposes blocks, leaving attestations to another one
+	ProposerOnly config.Parameter[bool]
+
+	// The URL of the separate Validator Client that attests for the validators when this one only proposes
+	AttestationVcUrl config.Parameter[string]
+}
+
+const (
+	// How long to wait for the remote signer when checking that it can be reached
+	remoteSignerCheckTimeout time.Duration = 5 * time.Second
+
+	// The remote signer route used to check that it's up
+	remoteSignerUpcheckPath string = "/upcheck"
+)
+
+// Generates a new key manager configuration
+func NewKeyManagerConfig() *KeyManagerConfig {
+	return &KeyManagerConfig{
+		Url: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyManagerUrlID,
+				Name:               "Key Manager API URL",
+				Description:        "The URL of your Validator Client's key manager API (e.g. `http://127.0.0.1:5062`). Hyperdrive uses this to manage the keys loaded into the Validator Client.\n\nLeave this blank if you don't want Hyperdrive to manage your Validator Client's keys.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		TokenPath: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyManagerTokenPathID,
+				Name:               "Key Manager API Token Path",
+				Description:        "The path of the file containing the auth token for your Validator Client's key manager API.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		SlashingProtectionBackups: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyManagerBackupsID,
+				Name:               "Slashing Protection Backups",
+				Description:        "The number of slashing protection backups to keep. Hyperdrive backs up your Validator Client's slashing protection data before recreating its container and restores it afterwards, including when the new container fails to start. Older backups are deleted.\n\nUse 0 to disable the backups.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 5,
+			},
+		},
+
+		RemoteSignerUrl: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyManagerRemoteSignerUrlID,
+				Name:               "Remote Signer URL",
+				Description:        "The URL of the remote signer (such as Web3Signer) that holds your remote keys (e.g. `http://127.0.0.1:9000`). Keys added as remote keys are signed for by this signer instead of by keystores loaded into the Validator Client, so it must be reachable from the Validator Client's container.\n\nLeave this blank if you don't use a remote signer.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon, config.ContainerID_ValidatorClient},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		ProposerOnly: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyManagerProposerOnlyID,
+				Name:               "Proposer-Only Mode",
+				Description:        "Run the Validator Client so it only proposes blocks, without attesting or taking part in sync committees. This is for advanced setups that split duties across two Validator Clients; the other one has to handle attestations for the same validators, or they'll miss them.\n\nOnly Lighthouse supports this setting.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_ValidatorClient},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: false,
+			},
+		},
+
+		AttestationVcUrl: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyManagerAttestationVcID,
+				Name:               "Attestation Validator Client URL",
+				Description:        "The URL of the separate Validator Client that attests for your validators while this one is in proposer-only mode (e.g. its key manager API at `http://192.168.1.20:5062`). Hyperdrive only uses this to know that attestations are covered.\n\nLeave this blank if you don't split duties across Validator Clients.",
+				AffectsContainers:  []config.ContainerID{config.ContainerID_Daemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+	}
+}
+
+// The title for the config
+func (cfg *KeyManagerConfig) GetTitle() string {
+	return "Key Manager"
+}
+
+// Get the Parameters for this config
+func (cfg *KeyManagerConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{
+		&cfg.Url,
+		&cfg.TokenPath,
+		&cfg.SlashingProtectionBackups,
+		&cfg.RemoteSignerUrl,
+		&cfg.ProposerOnly,
+		&cfg.AttestationVcUrl,
+	}
+}
+
+// Get the sections underneath this one
+func (cfg *KeyManagerConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{}
+}
+
+// Checks that the remote signer URL is a valid HTTP URL and that the signer can be reached, if it's set. Any response
+// from the signer counts as reachable, since this is only meant to catch typos and signers that aren't running.
+func (cfg *KeyManagerConfig) Validate() []string {
+	signerUrl := strings.TrimSuffix(cfg.RemoteSignerUrl.Value, "/")
+	if signerUrl == "" {
+		return nil
+	}
+	parsedUrl, err := url.Parse(signerUrl)
+	if err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		return []string{fmt.Sprintf("The remote signer URL [%s] isn't a valid HTTP or HTTPS URL.", cfg.RemoteSignerUrl.Value)}
+	}
+	client := http.Client{
+		Timeout: remoteSignerCheckTimeout,
+	}
+	response, err := client.Get(signerUrl + remoteSignerUpcheckPath)
+	if err != nil {
+		return []string{fmt.Sprintf("The remote signer at [%s] couldn't be reached: %s", cfg.RemoteSignerUrl.Value, err.Error())}
+	}
+	response.Body.Close()
+	return nil
+}
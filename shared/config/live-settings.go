@@ -0,0 +1,16 @@
+package config
+
+import (
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// Gets the settings the daemon applies as soon as the config is reloaded, without restarting any containers. Every
+// other setting only takes effect once the containers it affects are restarted.
+func (cfg *HyperdriveConfig) GetLiveParameters() []config.IParameter {
+	params := []config.IParameter{
+		&cfg.Logging.Level,
+		&cfg.ClockSkewThreshold,
+		&cfg.StuckSyncWindow,
+	}
+	return append(params, cfg.Alerts.GetParameters()...)
+}
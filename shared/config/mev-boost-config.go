@@ -66,6 +66,9 @@ type MevBoostConfig struct {
 	// The URL of an external MEV-Boost client
 	ExternalUrl config.Parameter[string]
 
+	// How strongly the Validator Client prefers builder blocks over local ones, as a percentage of the builder block's value
+	BuilderBoostFactor config.Parameter[uint64]
+
 	///////////////////////////
 	// Non-editable settings //
 	///////////////////////////
@@ -246,6 +249,20 @@ func NewMevBoostConfig(parent *HyperdriveConfig) *MevBoostConfig {
 			},
 		},
 
+		BuilderBoostFactor: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.MevBoostBuilderBoostFactorID,
+				Name:               "Builder Boost Factor",
+				Description:        fmt.Sprintf("The percentage the Validator Client multiplies a builder block's value by before comparing it to a locally built block. 100 compares them as-is, 0 always uses the local block, and %d always uses the builder block.\n\nOnly Lighthouse and Lodestar support this setting.", BuilderBoostFactor_AlwaysBuilder),
+				AffectsContainers:  []config.ContainerID{config.ContainerID_ValidatorClient},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: BuilderBoostFactor_Default,
+			},
+		},
+
 		relays:   relays,
 		relayMap: relayMap,
 	}
@@ -273,6 +290,7 @@ func (cfg *MevBoostConfig) GetParameters() []config.IParameter {
 		&cfg.ContainerTag,
 		&cfg.AdditionalFlags,
 		&cfg.ExternalUrl,
+		&cfg.BuilderBoostFactor,
 	}
 }
 
@@ -7,6 +7,9 @@ import (
 const (
 	ModulesName         string = "modules"
 	ValidatorsDirectory string = "validators"
+
+	// The ID of the parameter in each module's config section that enables the module
+	ModuleEnableID string = "enable"
 )
 
 type IModuleConfig interface {
@@ -63,3 +66,13 @@ type IModuleConfig interface {
 	// Get the version of the module config
 	GetVersion() string
 }
+
+// True if the module with the provided name has a config section and is enabled in it
+func (c *HyperdriveConfig) IsModuleEnabled(moduleName string) bool {
+	modCfg, ok := c.Modules[moduleName].(map[string]any)
+	if !ok {
+		return false
+	}
+	enabled, _ := modCfg[ModuleEnableID].(string)
+	return enabled == "true"
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+const (
+	// The prefix for environment variables exported from the config
+	ExportEnvPrefix string = "HD"
+)
+
+// A config section that declares the order of its subconfigs, since GetSubconfigs returns them as a map
+type IOrderedConfigSection interface {
+	config.IConfigSection
+
+	// Get the IDs of the section's subconfigs, in display order
+	GetSubconfigOrder() []string
+}
+
+// A subconfig along with its ID in the parent section
+type NamedConfigSection struct {
+	ID      string
+	Section config.IConfigSection
+}
+
+// A parameter along with its full key, which is the IDs of the sections it's in and its own ID separated by dots
+type ParameterEntry struct {
+	Key       string
+	Parameter config.IParameter
+}
+
+// A description of a single parameter, for rendering the config outside of the daemon
+type ParameterSchema struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Default     any      `json:"default"`
+	Value       any      `json:"value"`
+	Options     []string `json:"options,omitempty"`
+	CanBeBlank  bool     `json:"canBeBlank"`
+}
+
+// Gets a section's subconfigs in a stable order. Sections that implement IOrderedConfigSection use their declared order;
+// any subconfigs missing from it (and all subconfigs of sections that don't declare one) follow, sorted by ID.
+func GetOrderedSubconfigs(section config.IConfigSection) []NamedConfigSection {
+	subconfigs := section.GetSubconfigs()
+	ordered := make([]NamedConfigSection, 0, len(subconfigs))
+	added := map[string]bool{}
+	if orderedSection, isOrdered := section.(IOrderedConfigSection); isOrdered {
+		for _, id := range orderedSection.GetSubconfigOrder() {
+			subconfig, exists := subconfigs[id]
+			if !exists || added[id] {
+				continue
+			}
+			ordered = append(ordered, NamedConfigSection{ID: id, Section: subconfig})
+			added[id] = true
+		}
+	}
+
+	remaining := []string{}
+	for id := range subconfigs {
+		if !added[id] {
+			remaining = append(remaining, id)
+		}
+	}
+	sort.Strings(remaining)
+	for _, id := range remaining {
+		ordered = append(ordered, NamedConfigSection{ID: id, Section: subconfigs[id]})
+	}
+	return ordered
+}
+
+// Gets every parameter in a section and its subconfigs, in declaration order: a section's own parameters come first,
+// followed by each subconfig's parameters in the order given by GetOrderedSubconfigs.
+func GetOrderedParameters(section config.IConfigSection) []ParameterEntry {
+	return appendOrderedParameters([]ParameterEntry{}, "", section)
+}
+
+// Adds the parameters of a section to the list, prefixing their keys with the section's key
+func appendOrderedParameters(entries []ParameterEntry, prefix string, section config.IConfigSection) []ParameterEntry {
+	for _, param := range section.GetParameters() {
+		entries = append(entries, ParameterEntry{
+			Key:       prefix + param.GetCommon().ID,
+			Parameter: param,
+		})
+	}
+	for _, subconfig := range GetOrderedSubconfigs(section) {
+		entries = appendOrderedParameters(entries, prefix+subconfig.ID+".", subconfig.Section)
+	}
+	return entries
+}
+
+// Describes every Hyperdrive parameter, in declaration order
+func (cfg *HyperdriveConfig) Schema() []ParameterSchema {
+	network := cfg.Network.Value
+	entries := GetOrderedParameters(cfg)
+	schema := make([]ParameterSchema, len(entries))
+	for i, entry := range entries {
+		common := entry.Parameter.GetCommon()
+		paramSchema := ParameterSchema{
+			Key:         entry.Key,
+			Name:        common.Name,
+			Description: common.Description,
+			Default:     entry.Parameter.GetDefaultAsAny(network),
+			Value:       entry.Parameter.GetValueAsAny(),
+			CanBeBlank:  common.CanBeBlank,
+		}
+		for _, option := range entry.Parameter.GetOptions() {
+			paramSchema.Options = append(paramSchema.Options, option.String())
+		}
+		schema[i] = paramSchema
+	}
+	return schema
+}
+
+// Exports every Hyperdrive parameter as an environment variable in `NAME=value` format, in declaration order.
+// Names are the parameter's key in upper snake case with the HD prefix, so `mevBoost.mode` becomes `HD_MEV_BOOST_MODE`.
+func (cfg *HyperdriveConfig) ExportEnv() []string {
+	entries := GetOrderedParameters(cfg)
+	env := make([]string, len(entries))
+	for i, entry := range entries {
+		env[i] = fmt.Sprintf("%s=%s", GetExportEnvName(entry.Key), entry.Parameter.String())
+	}
+	return env
+}
+
+// Gets the environment variable name for a parameter key
+func GetExportEnvName(key string) string {
+	builder := strings.Builder{}
+	builder.WriteString(ExportEnvPrefix)
+	for _, part := range strings.Split(key, ".") {
+		builder.WriteRune('_')
+		for i, r := range part {
+			if i > 0 && unicode.IsUpper(r) {
+				builder.WriteRune('_')
+			}
+			builder.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return builder.String()
+}
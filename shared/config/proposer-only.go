@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// Gets the Validator Client flag that stops it from attesting so it only proposes blocks, or false if the client
+// doesn't have one
+func GetProposerOnlyFlag(client config.BeaconNode) (string, bool) {
+	switch client {
+	case config.BeaconNode_Lighthouse:
+		// This also turns off sync committee duties
+		return "--disable-attesting", true
+	}
+	return "", false
+}
+
+// Gets the flags for the Validator Client's proposer-only mode. The VC always matches the Beacon Node, so clients that
+// don't support it have no flags.
+// Used by text/template to format vc.yml
+func (cfg *HyperdriveConfig) GetVcProposerOnlyFlags() []string {
+	if !cfg.KeyManager.ProposerOnly.Value {
+		return []string{}
+	}
+	flag, supported := GetProposerOnlyFlag(cfg.GetSelectedBeaconNode())
+	if !supported {
+		return []string{}
+	}
+	return []string{flag}
+}
+
+// Gets warnings for a proposer-only Validator Client that doesn't support the mode, or that doesn't have another
+// Validator Client attesting for it
+func (cfg *HyperdriveConfig) getProposerOnlyWarnings() []string {
+	if !cfg.KeyManager.ProposerOnly.Value {
+		return nil
+	}
+	warnings := []string{}
+	bn := cfg.GetSelectedBeaconNode()
+	if _, supported := GetProposerOnlyFlag(bn); !supported {
+		warnings = append(warnings, fmt.Sprintf("Proposer-only mode is enabled, but your Validator Client is %s, which doesn't support it, so it will keep attesting.", bn))
+	} else if cfg.KeyManager.AttestationVcUrl.Value == "" {
+		warnings = append(warnings, "Proposer-only mode is enabled, but there isn't an attestation Validator Client configured. Unless another Validator Client is attesting for your validators, they'll miss every attestation.")
+	}
+	return warnings
+}
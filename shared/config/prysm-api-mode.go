@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// True if the daemon should talk to the Beacon Node over Prysm's gRPC API instead of the REST API
+func (cfg *HyperdriveConfig) UsePrysmGrpc() bool {
+	return cfg.PrysmApiMode.Value == PrysmApiMode_Grpc && cfg.GetSelectedBeaconNode() == config.BeaconNode_Prysm
+}
+
+// Gets the gRPC targets (host:port) of the primary and fallback Prysm Beacon Nodes.
+// The fallback is blank if fallback clients are disabled.
+func (cfg *HyperdriveConfig) GetPrysmGrpcTargets() (string, string, error) {
+	primaryUrl, err := cfg.BnRpcUrl()
+	if err != nil {
+		return "", "", err
+	}
+	primary, err := parseGrpcTarget(primaryUrl)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Prysm RPC URL [%s]: %w", primaryUrl, err)
+	}
+
+	fallbackUrl := cfg.FallbackBnRpcUrl()
+	if fallbackUrl == "" {
+		return primary, "", nil
+	}
+	fallback, err := parseGrpcTarget(fallbackUrl)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid fallback Prysm RPC URL [%s]: %w", fallbackUrl, err)
+	}
+	return primary, fallback, nil
+}
+
+// Checks that gRPC mode is only used with Prysm, and that each gRPC URL points at Prysm's RPC port rather than its HTTP port
+func (cfg *HyperdriveConfig) validatePrysmApiMode() []string {
+	if cfg.PrysmApiMode.Value != PrysmApiMode_Grpc {
+		return nil
+	}
+	bn := cfg.GetSelectedBeaconNode()
+	if bn != config.BeaconNode_Prysm {
+		return []string{fmt.Sprintf("The Prysm API mode is set to gRPC, but your Beacon Node is %s. gRPC mode can only be used with Prysm.", bn)}
+	}
+
+	errors := []string{}
+	if cfg.IsLocalMode() {
+		// The container serves gRPC on its RPC port, so that's what the daemon will dial
+		rpcPort := cfg.LocalBeaconClient.Prysm.RpcPort.Value
+		httpPort := cfg.LocalBeaconClient.HttpPort.Value
+		if rpcPort == httpPort {
+			errors = append(errors, fmt.Sprintf("Prysm's RPC port and HTTP port are both set to %d. gRPC mode needs Prysm's RPC port to be separate from its HTTP port.", rpcPort))
+		}
+	} else {
+		errors = append(errors, validatePrysmGrpcUrl("external Beacon Node", cfg.ExternalBeaconClient.PrysmRpcUrl.Value, cfg.ExternalBeaconClient.HttpUrl.Value)...)
+	}
+	if cfg.Fallback.UseFallbackClients.Value {
+		errors = append(errors, validatePrysmGrpcUrl("fallback Beacon Node", cfg.Fallback.PrysmRpcUrl.Value, cfg.Fallback.BnHttpUrl.Value)...)
+	}
+	return errors
+}
+
+// Checks that a Prysm gRPC URL has a port, and that it isn't the port of the same node's HTTP API
+func validatePrysmGrpcUrl(name string, rpcUrl string, httpUrl string) []string {
+	if rpcUrl == "" {
+		return []string{fmt.Sprintf("The Prysm API mode is set to gRPC, but the %s doesn't have a Prysm RPC URL.", name)}
+	}
+	target, err := parseGrpcTarget(rpcUrl)
+	if err != nil {
+		return []string{fmt.Sprintf("The Prysm RPC URL of the %s [%s] is invalid: %s", name, rpcUrl, err.Error())}
+	}
+
+	// Compare against the HTTP API's port, using the scheme's default if it isn't explicit
+	parsedHttpUrl, err := url.Parse(httpUrl)
+	if err != nil || parsedHttpUrl.Host == "" {
+		return nil
+	}
+	httpPort := parsedHttpUrl.Port()
+	if httpPort == "" {
+		switch parsedHttpUrl.Scheme {
+		case "http":
+			httpPort = "80"
+		case "https":
+			httpPort = "443"
+		}
+	}
+	rpcHost, rpcPort, _ := net.SplitHostPort(target)
+	if strings.EqualFold(rpcHost, parsedHttpUrl.Hostname()) && rpcPort == httpPort {
+		return []string{fmt.Sprintf("The Prysm RPC URL of the %s [%s] uses the same port as its HTTP URL [%s]. Prysm serves gRPC on its RPC port, so use that port instead.", name, rpcUrl, httpUrl)}
+	}
+	return nil
+}
+
+// Converts a URL (with or without a scheme) into a gRPC dial target of the form host:port
+func parseGrpcTarget(rawUrl string) (string, error) {
+	target := rawUrl
+	if strings.Contains(rawUrl, "://") {
+		parsedUrl, err := url.Parse(rawUrl)
+		if err != nil {
+			return "", err
+		}
+		target = parsedUrl.Host
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return "", errors.New("it must include a host and port")
+	}
+	if host == "" {
+		return "", errors.New("it is missing a host")
+	}
+	_, err = strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", fmt.Errorf("port [%s] is not a valid port number", port)
+	}
+	return target, nil
+}
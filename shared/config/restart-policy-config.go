@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config/ids"
+	"github.com/rocket-pool/node-manager-core/config"
+)
+
+// How Docker restarts each of the client containers when they stop. Services left on No are only restarted by
+// Hyperdrive's own reconciler, so operators can pick whichever one they'd rather rely on.
+type RestartPolicyConfig struct {
+	ExecutionClient *ServiceRestartPolicyConfig
+	BeaconNode      *ServiceRestartPolicyConfig
+	ValidatorClient *ServiceRestartPolicyConfig
+	MevBoost        *ServiceRestartPolicyConfig
+}
+
+// The restart policy for a single service's container
+type ServiceRestartPolicyConfig struct {
+	// When Docker restarts the container
+	Policy config.Parameter[RestartPolicy]
+
+	// The number of times Docker restarts the container before giving up, when the policy is OnFailure
+	MaxRetries config.Parameter[uint64]
+
+	// The service's name, for the title and errors
+	serviceName string
+}
+
+// Generates a new restart policy configuration
+func NewRestartPolicyConfig() *RestartPolicyConfig {
+	return &RestartPolicyConfig{
+		ExecutionClient: newServiceRestartPolicyConfig(config.ContainerID_ExecutionClient, "Execution Client"),
+		BeaconNode:      newServiceRestartPolicyConfig(config.ContainerID_BeaconNode, "Beacon Node"),
+		ValidatorClient: newServiceRestartPolicyConfig(config.ContainerID_ValidatorClient, "Validator Client"),
+		MevBoost:        newServiceRestartPolicyConfig(config.ContainerID_MevBoost, "MEV-Boost"),
+	}
+}
+
+// Generates a new restart policy configuration for one service
+func newServiceRestartPolicyConfig(container config.ContainerID, serviceName string) *ServiceRestartPolicyConfig {
+	return &ServiceRestartPolicyConfig{
+		Policy: config.Parameter[RestartPolicy]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.RestartPolicyModeID,
+				Name:               "Restart Policy",
+				Description:        fmt.Sprintf("When Docker should restart the %s container after it stops. Leave this on No to let Hyperdrive restart it instead.\n\n[orange]NOTE: The container has to be recreated for changes to take effect.", serviceName),
+				AffectsContainers:  []config.ContainerID{container},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Options: []*config.ParameterOption[RestartPolicy]{{
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "No",
+					Description: "Docker never restarts the container.",
+				},
+				Value: RestartPolicy_No,
+			}, {
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "On Failure",
+					Description: "Docker restarts the container if it exits with an error, up to the max retry count.",
+				},
+				Value: RestartPolicy_OnFailure,
+			}, {
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "Always",
+					Description: "Docker always restarts the container, including when the Docker daemon starts after it was stopped manually.",
+				},
+				Value: RestartPolicy_Always,
+			}, {
+				ParameterOptionCommon: &config.ParameterOptionCommon{
+					Name:        "Unless Stopped",
+					Description: "Docker always restarts the container unless it was stopped manually.",
+				},
+				Value: RestartPolicy_UnlessStopped,
+			}},
+			Default: map[config.Network]RestartPolicy{
+				config.Network_All: RestartPolicy_No,
+			},
+		},
+
+		MaxRetries: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.RestartPolicyMaxRetriesID,
+				Name:               "Max Retries",
+				Description:        fmt.Sprintf("The number of times Docker restarts the %s container before giving up. This can only be set when the restart policy is On Failure.\n\nUse 0 for no limit.", serviceName),
+				AffectsContainers:  []config.ContainerID{container},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 0,
+			},
+		},
+
+		serviceName: serviceName,
+	}
+}
+
+// The title for the config
+func (cfg *RestartPolicyConfig) GetTitle() string {
+	return "Restart Policies"
+}
+
+// Get the Parameters for this config
+func (cfg *RestartPolicyConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{}
+}
+
+// Get the sections underneath this one
+func (cfg *RestartPolicyConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{
+		ids.RestartPolicyExecutionClientID: cfg.ExecutionClient,
+		ids.RestartPolicyBeaconNodeID:      cfg.BeaconNode,
+		ids.RestartPolicyValidatorClientID: cfg.ValidatorClient,
+		ids.RestartPolicyMevBoostID:        cfg.MevBoost,
+	}
+}
+
+// Get the IDs of the subconfigs in display order
+func (cfg *RestartPolicyConfig) GetSubconfigOrder() []string {
+	return []string{
+		ids.RestartPolicyExecutionClientID,
+		ids.RestartPolicyBeaconNodeID,
+		ids.RestartPolicyValidatorClientID,
+		ids.RestartPolicyMevBoostID,
+	}
+}
+
+// Gets the restart policy for a container. Returns nil for containers that don't have one configured.
+func (cfg *RestartPolicyConfig) GetServicePolicy(container config.ContainerID) *ServiceRestartPolicyConfig {
+	switch container {
+	case config.ContainerID_ExecutionClient:
+		return cfg.ExecutionClient
+	case config.ContainerID_BeaconNode:
+		return cfg.BeaconNode
+	case config.ContainerID_ValidatorClient:
+		return cfg.ValidatorClient
+	case config.ContainerID_MevBoost:
+		return cfg.MevBoost
+	}
+	return nil
+}
+
+// Checks that a max retry count is only set for services that restart on failure
+func (cfg *RestartPolicyConfig) Validate() []string {
+	errors := []string{}
+	for _, service := range []*ServiceRestartPolicyConfig{cfg.ExecutionClient, cfg.BeaconNode, cfg.ValidatorClient, cfg.MevBoost} {
+		if service.MaxRetries.Value > 0 && service.Policy.Value != RestartPolicy_OnFailure {
+			errors = append(errors, fmt.Sprintf("the %s's restart policy is [%s], but a max retry count can only be set with [%s]", service.serviceName, service.Policy.Value, RestartPolicy_OnFailure))
+		}
+	}
+	return errors
+}
+
+// The title for the config
+func (cfg *ServiceRestartPolicyConfig) GetTitle() string {
+	return cfg.serviceName
+}
+
+// Get the Parameters for this config
+func (cfg *ServiceRestartPolicyConfig) GetParameters() []config.IParameter {
+	return []config.IParameter{
+		&cfg.Policy,
+		&cfg.MaxRetries,
+	}
+}
+
+// Get the sections underneath this one
+func (cfg *ServiceRestartPolicyConfig) GetSubconfigs() map[string]config.IConfigSection {
+	return map[string]config.IConfigSection{}
+}
@@ -7,6 +7,7 @@ const (
 	HyperdriveDaemonRoute    string = "hyperdrive"
 	HyperdriveApiVersion     string = "1"
 	HyperdriveApiClientRoute string = HyperdriveDaemonRoute + "/api/v" + HyperdriveApiVersion
+	HyperdriveMetricsPath    string = "/" + HyperdriveApiClientRoute + "/service/metrics"
 	ConfigFilename           string = "user-settings.yml"
 	DefaultApiPort           uint16 = 8080
 
@@ -15,6 +16,28 @@ const (
 	UserWalletDataFilename string = "wallet"
 	UserPasswordFilename   string = "password"
 
+	// The name of the transaction signer that uses the node wallet's keystore
+	LocalTransactionSignerName string = "local"
+
+	// Validator keys
+	KeystoreDir                string = "keystores"
+	SlashingProtectionFilename string = "slashing_protection.json"
+
+	// The directory in the user directory where slashing protection is backed up before the Validator Client is recreated
+	SlashingProtectionBackupDir string = "slashing-protection-backups"
+
+	// The file in the user directory that module resource overrides are saved to so they survive restarts
+	ModuleResourceOverridesFilename string = "module-resource-overrides.json"
+
+	// The directory in the user directory where the progress of resumable operations is journaled
+	OperationJournalDir string = "operations"
+
+	// The append-only journal in the user directory that config snapshots are recorded to for auditing
+	ConfigHistoryFilename string = "config-history.jsonl"
+
+	// The name of the Engine API secret file the Execution Client and Beacon Node share
+	EngineJwtFilename string = "jwtsecret"
+
 	// Scripts
 	EcStartScript       string = "start-ec.sh"
 	BnStartScript       string = "start-bn.sh"
@@ -41,6 +41,16 @@ func (c *HyperdriveConfig) MevBoostContainerName() string {
 	return string(config.ContainerID_MevBoost)
 }
 
+// The instance label for the service containers in compose templates, in key=value form
+func (c *HyperdriveConfig) ContainerInstanceLabel() string {
+	return InstanceLabel + "=" + c.ProjectName.Value
+}
+
+// The module label for a module's service containers in compose templates, in key=value form
+func (c *HyperdriveConfig) ContainerModuleLabel(moduleName string) string {
+	return ModuleLabel + "=" + moduleName
+}
+
 func (c *HyperdriveConfig) ExecutionClientDataVolume() string {
 	return ExecutionClientDataVolume
 }
@@ -185,6 +195,9 @@ func (cfg *HyperdriveConfig) GetEcMaxPeers() (uint16, error) {
 	if !cfg.IsLocalMode() {
 		return 0, fmt.Errorf("Execution client is external, there is no max peers")
 	}
+	if cfg.LocalExecutionClient.ExecutionClient.Value == ExecutionClient_Erigon {
+		return cfg.ExecutionClientOptions.Erigon.MaxPeers.Value, nil
+	}
 	return cfg.LocalExecutionClient.GetMaxPeers(), nil
 }
 
@@ -194,15 +207,31 @@ func (cfg *HyperdriveConfig) GetEcContainerTag() (string, error) {
 	if !cfg.IsLocalMode() {
 		return "", fmt.Errorf("Execution client is external, there is no container tag")
 	}
+	if cfg.LocalExecutionClient.ExecutionClient.Value == ExecutionClient_Erigon {
+		return cfg.ExecutionClientOptions.Erigon.ContainerTag.Value, nil
+	}
 	return cfg.LocalExecutionClient.GetContainerTag(), nil
 }
 
+// Gets the flags for the selected client's client-specific settings, followed by the user's additional flags so those
+// can override them.
 // Used by text/template to format ec.yml
 func (cfg *HyperdriveConfig) GetEcAdditionalFlags() (string, error) {
 	if !cfg.IsLocalMode() {
 		return "", fmt.Errorf("Execution client is external, there are no additional flags")
 	}
-	return cfg.LocalExecutionClient.GetAdditionalFlags(), nil
+	client := cfg.LocalExecutionClient.ExecutionClient.Value
+	flags := cfg.ExecutionClientOptions.GetFlags(client)
+	var additionalFlags string
+	if client == ExecutionClient_Erigon {
+		additionalFlags = cfg.ExecutionClientOptions.Erigon.AdditionalFlags.Value
+	} else {
+		additionalFlags = cfg.LocalExecutionClient.GetAdditionalFlags()
+	}
+	if additionalFlags != "" {
+		flags = append(flags, additionalFlags)
+	}
+	return strings.Join(flags, " "), nil
 }
 
 // Used by text/template to format bn.yml
@@ -414,3 +443,13 @@ func (cfg *HyperdriveConfig) GetMevBoostOpenPorts() string {
 	port := cfg.MevBoost.Port.Value
 	return fmt.Sprintf("\"%s\"", portMode.DockerPortMapping(port))
 }
+
+// Gets the extra environment variables for a container, applied underneath the variables Hyperdrive sets itself
+func (cfg *HyperdriveConfig) GetExtraEnv(container string) map[string]string {
+	env, err := cfg.ExtraEnv.GetEnv(config.ContainerID(container))
+	if err != nil {
+		// Invalid settings are caught by Validate before saving, so this only happens on hand-edited configs
+		return map[string]string{}
+	}
+	return env
+}
@@ -28,6 +28,9 @@ type SubmitTxBody struct {
 	Nonce          *big.Int                   `json:"nonce,omitempty"`
 	MaxFee         *big.Int                   `json:"maxFee"`
 	MaxPriorityFee *big.Int                   `json:"maxPriorityFee"`
+
+	// True to simulate the transaction against the pending block first, and refuse to submit it if it would revert
+	Simulate bool `json:"simulate,omitempty"`
 }
 
 type BatchSubmitTxsBody struct {
@@ -81,3 +81,17 @@ type WalletSendData struct {
 	InsufficientBalance bool                 `json:"insufficientBalance"`
 	TxInfo              *eth.TransactionInfo `json:"txInfo"`
 }
+
+type WalletKeystoreVerification struct {
+	Path          string                 `json:"path"`
+	Pubkey        beacon.ValidatorPubkey `json:"pubkey"`
+	Success       bool                   `json:"success"`
+	FailureReason string                 `json:"failureReason,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+type WalletVerifyKeystoresData struct {
+	Keystores    []WalletKeystoreVerification `json:"keystores"`
+	SuccessCount int                          `json:"successCount"`
+	FailureCount int                          `json:"failureCount"`
+}
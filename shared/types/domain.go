@@ -0,0 +1,13 @@
+package types
+
+import (
+	"encoding/hex"
+)
+
+// A Beacon Chain signature domain, as returned by compute_domain
+type Domain [32]byte
+
+// Gets the domain as a hex string with a 0x prefix
+func (d Domain) HexWithPrefix() string {
+	return "0x" + hex.EncodeToString(d[:])
+}
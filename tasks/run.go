@@ -2,6 +2,8 @@ package tasks
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
@@ -41,6 +43,8 @@ type TaskLoop struct {
 	// Internal
 	wasExecutionClientSynced bool
 	wasBeaconClientSynced    bool
+	ranContractPreflight     bool
+	ranWalletPreflight       bool
 }
 
 func NewTaskLoop(sp *common.ServiceProvider, wg *sync.WaitGroup) *TaskLoop {
@@ -65,6 +69,14 @@ func (t *TaskLoop) Run() error {
 	go func() {
 		defer t.wg.Done()
 
+		// Catch port conflicts before they stop the clients from starting
+		t.runPortPreflight()
+
+		// Start the clients in dependency order; nothing else runs until they're all up
+		if !t.bootClients() {
+			return
+		}
+
 		for {
 			// Make sure all of the resources are ready for task processing
 			readyResult := t.waitUntilReady()
@@ -75,6 +87,14 @@ func (t *TaskLoop) Run() error {
 				continue
 			}
 
+			// Check the modules' contracts once the clients can answer for them
+			if !t.ranContractPreflight {
+				t.runContractPreflight()
+			}
+			if !t.ranWalletPreflight {
+				t.runWalletPreflight()
+			}
+
 			// === Task execution ===
 			if t.runTasks() {
 				return
@@ -95,6 +115,97 @@ func (t *TaskLoop) Run() error {
 	return nil
 }
 
+// Starts the clients in dependency order, including this instance's Validator Clients, and opens their connections.
+// Startup is retried on every cooldown until it succeeds, so the tasks don't run against clients that didn't come up
+// or with validator keys claimed by more than one module. Returns false if the loop needs to exit.
+func (t *TaskLoop) bootClients() bool {
+	for {
+		vcContainers, err := t.sp.GetValidatorClientContainers(t.ctx)
+		if err == nil {
+			err = t.sp.BootClients(t.ctx, vcContainers)
+		}
+		if err == nil {
+			break
+		}
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		var bootErr *common.ClientBootError
+		switch {
+		case errors.Is(err, common.ErrEngineApiUnreachable):
+			t.logger.Error("The Execution Client's Engine API didn't come up, will try again. Check that its Engine API port is enabled.", slog.String(log.ErrorKey, err.Error()))
+		case errors.As(err, &bootErr) && bootErr.NotReady:
+			t.logger.Error(fmt.Sprintf("The %s didn't come up, will try again. Check its logs for problems, or raise the startup timeout if it's just slow to start.", bootErr.Client), slog.String("client", bootErr.Client), slog.String(log.ErrorKey, err.Error()))
+		case bootErr != nil:
+			t.logger.Error("Error starting client, will try again", slog.String("client", bootErr.Client), slog.String(log.ErrorKey, err.Error()))
+		default:
+			t.logger.Error("Error starting clients, will try again", slog.String(log.ErrorKey, err.Error()))
+		}
+		if utils.SleepWithCancel(t.ctx, taskCooldown) {
+			return false
+		}
+	}
+
+	// Open the client connections now instead of on the first task
+	err := t.sp.WarmUpClients(t.ctx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		t.logger.Warn("Error warming up client connections", slog.String(log.ErrorKey, err.Error()))
+	}
+	return true
+}
+
+// Verifies the modules' critical contracts. Mismatches are logged as they're found; if the check couldn't be completed
+// for another reason, it's tried again on the next loop.
+func (t *TaskLoop) runContractPreflight() {
+	err := t.sp.VerifyCriticalContracts(t.ctx)
+	if err == nil {
+		t.ranContractPreflight = true
+		return
+	}
+	if errors.Is(err, common.ErrContractMismatch) {
+		t.logger.Error("Some module contracts don't match their expected ABI; check that the modules are configured for the right network")
+		t.ranContractPreflight = true
+		return
+	}
+	t.logger.Warn("Couldn't verify module contracts, will try again later", slog.String(log.ErrorKey, err.Error()))
+}
+
+// Checks the host ports the managed containers publish, logging each one that's held by something else
+func (t *TaskLoop) runPortPreflight() {
+	checks, err := t.sp.CheckPortBindings(t.ctx)
+	if err != nil {
+		t.logger.Warn("Couldn't check the client port bindings", slog.String(log.ErrorKey, err.Error()))
+		return
+	}
+	for _, check := range checks {
+		if !check.Conflict {
+			continue
+		}
+		attrs := []any{
+			slog.String("service", string(check.Service)),
+			slog.String("port", fmt.Sprintf("%d/%s", check.Port, check.Protocol)),
+		}
+		if check.OwnerPid != 0 {
+			attrs = append(attrs, slog.Int("pid", check.OwnerPid), slog.String("process", check.OwnerProcess))
+		}
+		t.logger.Error(fmt.Sprintf("The %s port is already in use, so the container won't be able to start", check.Name), attrs...)
+	}
+}
+
+// Checks the loaded wallet against the expected node address, if one is configured. The wallet is ready by now, so
+// this only needs to run once.
+func (t *TaskLoop) runWalletPreflight() {
+	t.ranWalletPreflight = true
+	expected, isSet := t.sp.GetConfig().GetExpectedNodeAddress()
+	if !isSet {
+		return
+	}
+	err := t.sp.VerifyWalletAddress(expected)
+	if err != nil {
+		t.logger.Error("The node wallet doesn't match the expected node address; check the mnemonic and derivation path it was recovered with", slog.String(log.ErrorKey, err.Error()))
+	}
+}
+
 // Wait until the chains and other resources are ready to be queried
 // Returns true if the owning loop needs to exit, false if it can continue
 func (t *TaskLoop) waitUntilReady() waitUntilReadyResult {
@@ -107,6 +218,7 @@ func (t *TaskLoop) waitUntilReady() waitUntilReadyResult {
 		}
 		t.wasExecutionClientSynced = false
 		t.logger.Error("Execution Client not synced. Waiting for sync...", slog.String(log.ErrorKey, errMsg))
+		t.checkSyncHealth()
 		return t.sleepAndReturnReadyResult()
 	}
 
@@ -125,6 +237,7 @@ func (t *TaskLoop) waitUntilReady() waitUntilReadyResult {
 		// NOTE: if not synced, it returns an error - so there isn't necessarily an underlying issue
 		t.wasBeaconClientSynced = false
 		t.logger.Error("Beacon Node not synced. Waiting for sync...", slog.String(log.ErrorKey, errMsg))
+		t.checkSyncHealth()
 		return t.sleepAndReturnReadyResult()
 	}
 
@@ -147,6 +260,22 @@ func (t *TaskLoop) waitUntilReady() waitUntilReadyResult {
 	return waitUntilReadySuccess
 }
 
+// Checks whether the clients that are syncing have stopped getting closer to the head, logging any that have.
+// This runs on every cooldown while waiting for sync, which also keeps the sync health samples spread across the window.
+func (t *TaskLoop) checkSyncHealth() {
+	health, err := t.sp.GetSyncHealth(t.ctx)
+	if err != nil {
+		t.logger.Debug("Couldn't check the clients' sync progress", slog.String(log.ErrorKey, err.Error()))
+		return
+	}
+	if health.ExecutionClient.State == common.SyncState_Stuck {
+		t.logger.Error("Execution Client sync looks stuck", slog.Uint64("distance", health.ExecutionClient.Distance), slog.Duration("window", health.Window))
+	}
+	if health.BeaconNode.State == common.SyncState_Stuck {
+		t.logger.Error("Beacon Node sync looks stuck", slog.Uint64("distance", health.BeaconNode.Distance), slog.Duration("window", health.Window))
+	}
+}
+
 // Sleep on the context for the task cooldown time, and return either exit or continue
 // based on whether the context was cancelled.
 func (t *TaskLoop) sleepAndReturnReadyResult() waitUntilReadyResult {
@@ -160,5 +289,32 @@ func (t *TaskLoop) sleepAndReturnReadyResult() waitUntilReadyResult {
 // Runs an iteration of the node tasks.
 // Returns true if the task loop should exit, false if it should continue.
 func (t *TaskLoop) runTasks() bool {
+	t.checkFeeRecipientCompliance()
 	return utils.SleepWithCancel(t.ctx, tasksInterval)
 }
+
+// Checks that the validators use the fee recipients their modules require, logging each one that doesn't. New
+// violations are also sent as alerts.
+func (t *TaskLoop) checkFeeRecipientCompliance() {
+	report, err := t.sp.CheckFeeRecipientCompliance(t.ctx)
+	if err != nil {
+		if errors.Is(err, common.ErrKeyManagerNotConfigured) {
+			return
+		}
+		t.logger.Warn("Couldn't check the validators' fee recipients", slog.String(log.ErrorKey, err.Error()))
+		if len(report.Validators) == 0 {
+			return
+		}
+	}
+	for _, check := range report.Validators {
+		if check.Error != "" || check.Compliant {
+			continue
+		}
+		t.logger.Error("Validator has the wrong fee recipient",
+			slog.String("pubkey", check.Pubkey.HexWithPrefix()),
+			slog.String("module", check.Module),
+			slog.String("feeRecipient", check.Configured.Hex()),
+			slog.String("expected", check.Expected.Hex()),
+		)
+	}
+}
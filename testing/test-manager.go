@@ -1,6 +1,7 @@
 package testing
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os"
@@ -18,6 +19,30 @@ import (
 	"github.com/rocket-pool/node-manager-core/node/services"
 )
 
+// The ID of a snapshot taken by the test manager
+type SnapshotID string
+
+// The label ListAllSnapshots() uses for the baseline snapshot the test manager is reset to when it closes
+const BaselineSnapshotLabel string = "baseline"
+
+// Info about a snapshot taken by the test manager
+type SnapshotInfo struct {
+	// The snapshot's ID, which is blank for the baseline
+	ID SnapshotID
+
+	// The label the snapshot was created with, which is blank for snapshots taken by WithAutoSnapshot()
+	Label string
+
+	// When the snapshot was taken
+	CreatedAt time.Time
+
+	// The Execution Client's latest block when the snapshot was taken
+	Block uint64
+
+	// The Beacon Node's current slot when the snapshot was taken
+	Slot uint64
+}
+
 // HyperdriveTestManager provides bootstrapping and a test service provider, useful for testing
 type HyperdriveTestManager struct {
 	*osha.TestManager
@@ -33,6 +58,12 @@ type HyperdriveTestManager struct {
 
 	// Wait group for graceful shutdown
 	wg *sync.WaitGroup
+
+	// The state of the chain when the baseline snapshot was taken
+	baseline SnapshotInfo
+
+	// The snapshots taken by the test manager that can still be reverted to, oldest first
+	snapshots []SnapshotInfo
 }
 
 // Creates a new HyperdriveTestManager instance.
@@ -117,6 +148,12 @@ func newHyperdriveTestManagerImpl(address string, tm *osha.TestManager, cfg *hdc
 		serverMgr:       serverMgr,
 		apiClient:       apiClient,
 		wg:              wg,
+		snapshots:       []SnapshotInfo{},
+	}
+	m.baseline, err = m.getSnapshotInfo("", BaselineSnapshotLabel)
+	if err != nil {
+		closeTestManager(tm)
+		return nil, err
 	}
 	return m, nil
 }
@@ -136,6 +173,128 @@ func (m *HyperdriveTestManager) GetApiClient() *client.ApiClient {
 	return m.apiClient
 }
 
+// Takes a snapshot of the Ethereum clients and Docker, then runs the provided function. This is meant to wrap destructive
+// operations such as resyncing or swapping a client so their effects can be undone with a single call to RevertToSnapshot().
+// The snapshot ID is returned even if the function fails, so the caller can roll back whatever it managed to do.
+// Note that this only covers chain and container state; changes to the config or data directories are not captured.
+func (m *HyperdriveTestManager) WithAutoSnapshot(fn func() error) (SnapshotID, error) {
+	snapshotName, err := m.TestManager.CreateCustomSnapshot(osha.Service_EthClients | osha.Service_Docker)
+	if err != nil {
+		return "", fmt.Errorf("error creating snapshot: %w", err)
+	}
+	snapshotID := SnapshotID(snapshotName)
+	err = m.recordSnapshot(snapshotID, "")
+	if err != nil {
+		return snapshotID, err
+	}
+
+	err = fn()
+	return snapshotID, err
+}
+
+// Takes a snapshot of every service with a label describing it, which shows up in ListSnapshots(). Labels must be unique
+// among the snapshots that can still be reverted to.
+func (m *HyperdriveTestManager) CreateNamedSnapshot(label string) (SnapshotID, error) {
+	if label == "" {
+		return "", fmt.Errorf("snapshot label cannot be blank")
+	}
+	if label == BaselineSnapshotLabel {
+		return "", fmt.Errorf("snapshot label [%s] is reserved for the baseline", label)
+	}
+	for _, snapshot := range m.snapshots {
+		if snapshot.Label == label {
+			return "", fmt.Errorf("a snapshot with label [%s] already exists", label)
+		}
+	}
+
+	snapshotName, err := m.TestManager.CreateCustomSnapshot(osha.Service_All)
+	if err != nil {
+		return "", fmt.Errorf("error creating snapshot [%s]: %w", label, err)
+	}
+	snapshotID := SnapshotID(snapshotName)
+	err = m.recordSnapshot(snapshotID, label)
+	if err != nil {
+		return "", err
+	}
+	return snapshotID, nil
+}
+
+// Lists the snapshots taken by the test manager that can still be reverted to, oldest first. The baseline isn't included;
+// use ListAllSnapshots() for that.
+func (m *HyperdriveTestManager) ListSnapshots() []SnapshotInfo {
+	snapshots := make([]SnapshotInfo, len(m.snapshots))
+	copy(snapshots, m.snapshots)
+	return snapshots
+}
+
+// Lists the baseline snapshot followed by the ones from ListSnapshots()
+func (m *HyperdriveTestManager) ListAllSnapshots() []SnapshotInfo {
+	return append([]SnapshotInfo{m.baseline}, m.ListSnapshots()...)
+}
+
+// Resyncs the Beacon Node by restarting its container, taking a snapshot first so the resync can be rolled back with
+// RevertToSnapshot()
+func (m *HyperdriveTestManager) ResyncBeaconClient(ctx context.Context) (SnapshotID, error) {
+	return m.WithAutoSnapshot(func() error {
+		sp := m.serviceProvider
+		bnContainer := sp.GetConfig().GetDockerArtifactName(string(config.ContainerID_BeaconNode))
+		err := sp.StopContainer(ctx, bnContainer)
+		if err != nil {
+			return fmt.Errorf("error stopping Beacon Node: %w", err)
+		}
+		err = sp.StartContainer(ctx, bnContainer)
+		if err != nil {
+			return fmt.Errorf("error starting Beacon Node: %w", err)
+		}
+		return nil
+	})
+}
+
+// Switches the local Execution Client to the provided client and restarts its container, taking a snapshot first so the
+// containers can be rolled back with RevertToSnapshot(). The snapshot doesn't cover the config, so the previous client
+// is returned for the caller to restore.
+func (m *HyperdriveTestManager) SwapExecutionClient(ctx context.Context, ec config.ExecutionClient) (SnapshotID, config.ExecutionClient, error) {
+	cfg := m.serviceProvider.GetConfig()
+	previous := cfg.LocalExecutionClient.ExecutionClient.Value
+	snapshotID, err := m.WithAutoSnapshot(func() error {
+		cfg.LocalExecutionClient.ExecutionClient.Value = ec
+		ecContainer := cfg.GetDockerArtifactName(string(config.ContainerID_ExecutionClient))
+		err := m.serviceProvider.RestartContainer(ctx, ecContainer)
+		if err != nil {
+			return fmt.Errorf("error restarting Execution Client: %w", err)
+		}
+		return nil
+	})
+	return snapshotID, previous, err
+}
+
+// Reverts to a snapshot taken by WithAutoSnapshot() or CreateNamedSnapshot().
+// Hardhat discards a snapshot and every one after it when it's reverted to, so they're removed from ListSnapshots().
+func (m *HyperdriveTestManager) RevertToSnapshot(snapshotID SnapshotID) error {
+	err := m.TestManager.RevertToCustomSnapshot(string(snapshotID))
+	if err != nil {
+		return err
+	}
+	for i, snapshot := range m.snapshots {
+		if snapshot.ID == snapshotID {
+			m.snapshots = m.snapshots[:i]
+			break
+		}
+	}
+	return nil
+}
+
+// Reverts every service to the baseline snapshot, discarding the snapshots taken since
+func (m *HyperdriveTestManager) RevertToBaseline() error {
+	err := m.TestManager.RevertToBaseline()
+	if err != nil {
+		return err
+	}
+	m.snapshots = []SnapshotInfo{}
+	m.baseline, err = m.getSnapshotInfo("", BaselineSnapshotLabel)
+	return err
+}
+
 // Closes the Hyperdrive test manager, shutting down the daemon
 func (m *HyperdriveTestManager) Close() error {
 	if m.serverMgr != nil {
@@ -156,6 +315,31 @@ func (m *HyperdriveTestManager) Close() error {
 // === Internal Functions ===
 // ==========================
 
+// Records a snapshot the test manager just took
+func (m *HyperdriveTestManager) recordSnapshot(snapshotID SnapshotID, label string) error {
+	info, err := m.getSnapshotInfo(snapshotID, label)
+	if err != nil {
+		return err
+	}
+	m.snapshots = append(m.snapshots, info)
+	return nil
+}
+
+// Gets the info for a snapshot taken at the current state of the chain
+func (m *HyperdriveTestManager) getSnapshotInfo(snapshotID SnapshotID, label string) (SnapshotInfo, error) {
+	block, err := m.TestManager.GetExecutionClient().BlockNumber(context.Background())
+	if err != nil {
+		return SnapshotInfo{}, fmt.Errorf("error getting latest block for snapshot: %w", err)
+	}
+	return SnapshotInfo{
+		ID:        snapshotID,
+		Label:     label,
+		CreatedAt: time.Now(),
+		Block:     block,
+		Slot:      m.TestManager.GetBeaconMockManager().GetCurrentSlot(),
+	}, nil
+}
+
 // Closes the OSHA test manager, logging any errors
 func closeTestManager(tm *osha.TestManager) {
 	err := tm.Close()